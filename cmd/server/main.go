@@ -2,14 +2,23 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
 
 	"github.com/gbfs-validator-go/pkg/env"
 	"github.com/gbfs-validator-go/pkg/api"
+	"github.com/gbfs-validator-go/pkg/catalog"
+	"github.com/gbfs-validator-go/pkg/fetcher"
+	"github.com/gbfs-validator-go/pkg/schema"
+	"github.com/gbfs-validator-go/pkg/signing"
 )
 
 // main configures and runs the HTTP server.
@@ -20,6 +29,17 @@ func main() {
 
 	port := flag.Int("port", 8080, "Server port")
 	staticDir := flag.String("static", "", "Directory containing static files for viewer (optional)")
+	schemaPath := flag.String("schemas", "", "Local schema directory or tarball overriding the embedded set (optional)")
+	auditLog := flag.String("audit-log", "", "Audit log file for validation requests, \"stdout\", or a sqlite:path or postgres://user@host/db database (optional)")
+	catalogPath := flag.String("catalog", "", "systems.csv feed catalog, as a file or URL, to check system_id against; \"mobilitydata\" fetches the Mobility Database catalog (optional)")
+	proxies := flag.String("trusted-proxies", "", "Comma-separated proxy IPs or CIDR ranges whose X-Forwarded-For is trusted (optional)")
+	userAgent := flag.String("user-agent", "", "User-Agent sent with every feed request instead of GBFS-Validator-Go/1.0 (optional)")
+	contact := flag.String("contact", "", "How feed providers can reach the operator, e.g. an email address, sent with every feed request (optional)")
+	contactHeader := flag.String("contact-header", "From", "Header that carries -contact, e.g. From or X-Contact")
+	robots := flag.Bool("robots", false, "Skip feed URLs that a host's robots.txt disallows (optional)")
+	validationTimeout := flag.Duration("validation-timeout", 50*time.Second, "Stop each validation after this long and return its incomplete result (0 means no limit); requests may set a shorter timeout")
+	fileTimeout := flag.Duration("file-timeout", 0, "Fail each feed file request after this long, e.g. 10s (0 uses 30s); requests may set a shorter fileTimeout")
+	registry := flag.String("host-intervals", "", "File of \"host duration\" lines setting the minimum time between requests to each host; * sets other hosts (optional)")
 	flag.Parse()
 
 	var server *api.Server
@@ -35,6 +55,87 @@ func main() {
 		server = api.NewServer()
 	}
 
+	if *schemaPath != "" {
+		bundle, err := schema.Load(*schemaPath)
+		if err != nil {
+			log.Fatalf("Failed to load schemas from %s: %v", *schemaPath, err)
+		}
+		server.SetSchemas(bundle)
+		log.Printf("Validating against schemas from: %s", *schemaPath)
+	}
+
+	if *catalogPath != "" {
+		location := *catalogPath
+		if location == "mobilitydata" {
+			location = catalog.DefaultURL
+		}
+		c, err := catalog.Load(context.Background(), location)
+		if err != nil {
+			log.Fatalf("Failed to load catalog %s: %v", location, err)
+		}
+		server.SetCatalog(c)
+		log.Printf("Checking system_id against the catalog at: %s", location)
+	}
+
+	var fetcherOpts []fetcher.Option
+	if *userAgent != "" {
+		fetcherOpts = append(fetcherOpts, fetcher.WithUserAgent(*userAgent))
+	}
+	if *contact != "" {
+		fetcherOpts = append(fetcherOpts, fetcher.WithContact(*contactHeader, *contact))
+		log.Printf("Identifying feed requests with %s: %s", *contactHeader, *contact)
+	}
+	if *robots || *registry != "" {
+		c := &fetcher.Courtesy{Robots: *robots}
+		if *registry != "" {
+			intervals, err := fetcher.LoadIntervals(*registry)
+			if err != nil {
+				log.Fatalf("Failed to load host intervals from %s: %v", *registry, err)
+			}
+			c.Intervals = intervals
+		}
+		fetcherOpts = append(fetcherOpts, fetcher.WithCourtesy(c))
+	}
+	server.SetFetcherOptions(fetcherOpts...)
+	server.SetValidationTimeouts(*validationTimeout, *fileTimeout)
+
+	signer, err := signing.FromEnv()
+	if err != nil {
+		log.Fatalf("Failed to load signing key: %v", err)
+	}
+	if signer != nil {
+		server.SetSigner(signer)
+		log.Printf("Signing results with key %s", signer.Public().KeyID)
+	}
+
+	if *proxies != "" {
+		if err := server.SetTrustedProxies(strings.Split(*proxies, ",")); err != nil {
+			log.Fatalf("Invalid -trusted-proxies: %v", err)
+		}
+	}
+
+	if *auditLog == "stdout" {
+		server.SetAuditSink(api.NewJSONAuditSink(os.Stdout))
+	} else if api.IsAuditDB(*auditLog) {
+		sink, err := api.OpenAuditDB(*auditLog)
+		if err != nil {
+			log.Fatalf("Failed to open audit log %s: %v", fetcher.RedactURL(*auditLog), err)
+		}
+		server.SetAuditSink(sink)
+		log.Printf("Writing audit log to: %s", fetcher.RedactURL(*auditLog))
+	} else if *auditLog != "" {
+		sink, err := api.OpenAuditFile(*auditLog)
+		if err != nil {
+			log.Fatalf("Failed to open audit log %s: %v", *auditLog, err)
+		}
+		server.SetAuditSink(sink)
+		log.Printf("Writing audit log to: %s", *auditLog)
+	}
+
+	if err := server.WarmSchemas(); err != nil {
+		log.Printf("Failed to compile schemas: %v", err)
+	}
+
 	addr := fmt.Sprintf(":%d", *port)
 	
 	fmt.Println("┌─────────────────────────────────────────────┐")
@@ -44,10 +145,15 @@ func main() {
 	fmt.Println("│                                             │")
 	fmt.Println("│  API Endpoints:                             │")
 	fmt.Println("│    POST /api/validator                      │")
+	fmt.Println("│    POST /api/validate-file                  │")
+	fmt.Println("│    POST /api/validate-upload                │")
 	fmt.Println("│    POST /api/validator-summary              │")
 	fmt.Println("│    POST /api/feed                           │")
+	fmt.Println("│    POST /api/jobs                           │")
+	fmt.Println("│    GET  /api/jobs/{id}                      │")
 	fmt.Println("│    POST /api/gbfs                           │")
 	fmt.Println("│    GET  /api/proxy?url=...                  │")
+	fmt.Println("│    GET  /api/rules                          │")
 	fmt.Println("│    GET  /health                             │")
 	if *staticDir != "" {
 		fmt.Println("│                                             │")
@@ -55,5 +161,26 @@ func main() {
 	}
 	fmt.Println("└─────────────────────────────────────────────┘")
 
-	log.Fatal(http.ListenAndServe(addr, server))
+	httpServer := server.HTTPServer(addr)
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		<-ctx.Done()
+		log.Println("Server is shutting down...")
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		if err := httpServer.Shutdown(shutdownCtx); err != nil {
+			log.Printf("Could not gracefully shut down the server: %v", err)
+		}
+	}()
+
+	if err := httpServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		log.Fatalf("Could not listen on %s: %v", addr, err)
+	}
+	<-done
+	if err := server.CloseAudit(); err != nil {
+		log.Printf("Failed to close audit log: %v", err)
+	}
 }
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/gbfs-validator-go/pkg/compare"
+	"github.com/gbfs-validator-go/pkg/coverage"
+	"github.com/gbfs-validator-go/pkg/fetcher"
+	"github.com/gbfs-validator-go/pkg/gbfsclient"
+	"github.com/gbfs-validator-go/pkg/mds"
+	"github.com/gbfs-validator-go/pkg/validator"
+)
+
+// loadClient fetches every file of the feed at url.
+func loadClient(url string) *gbfsclient.Client {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+	c := gbfsclient.New(url)
+	if err := c.Load(ctx); err != nil && c.Autodiscovery() == nil {
+		log.Fatalf("Failed to load feed: %v", err)
+	}
+	return c
+}
+
+// writeJSON prints v as indented JSON.
+func writeJSON(v interface{}) {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// setupCoverage registers flags for the coverage command.
+func setupCoverage(fs *flag.FlagSet) func() {
+	url := fs.String("url", "", "GBFS feed URL to analyze")
+	format := fs.String("format", "text", "Output format (text, json)")
+	return func() {
+		if *url == "" {
+			log.Fatal("coverage: -url is required")
+		}
+		c := loadClient(*url)
+		report := coverage.Analyze(c.GeofencingZones(), c.StationInformation(), c.VehicleStatus())
+		if *format == "json" {
+			writeJSON(report)
+			return
+		}
+
+		fmt.Printf("Zones: %d covering %.2f km²\n", report.Zones, report.ZoneAreaKm2)
+		fmt.Printf("Service area (hull of stations and vehicles): %.2f km², %.2f%% inside a zone\n", report.HullAreaKm2, report.HullCoveredPercent)
+		fmt.Printf("Stations: %d, %.2f%% inside a zone\n", report.Stations, report.StationsCoveredPercent)
+		fmt.Printf("Vehicles: %d, %.2f%% inside a zone\n", report.Vehicles, report.FleetCoveredPercent)
+		for _, p := range report.Outside {
+			fmt.Printf("  outside: %s %s at %.6f,%.6f\n", p.Kind, p.ID, p.Lat, p.Lon)
+		}
+	}
+}
+
+// setupCompare registers flags for the compare command.
+func setupCompare(fs *flag.FlagSet) func() {
+	var urls stringsFlag
+	fs.Var(&urls, "url", "GBFS feed URL to compare (repeatable, at least two)")
+	format := fs.String("format", "text", "Output format (text, json, csv)")
+	ver := fs.String("version", "", "Force specific GBFS version")
+	lenient := fs.Bool("lenient", false, "Enable lenient mode (coerce 0/1 to bool, string to number, etc.)")
+	profile := fs.String("profile", "default", "Severity profile ("+strings.Join(validator.ProfileNames(), ", ")+")")
+	fetchOptions := fetchFlags(fs)
+	return func() {
+		if len(urls) < 2 {
+			log.Fatal("compare: at least two -url flags are required")
+		}
+		if err := validator.CheckProfile(*profile); err != nil {
+			log.Fatalf("-profile: %v", err)
+		}
+		opts := validator.Options{Version: *ver, LenientMode: *lenient, Profile: *profile}
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+		defer cancel()
+		fetchOpts := fetchOptions()
+		report := compare.Feeds(ctx, urls, func(ctx context.Context, url string) (*validator.ValidationResult, error) {
+			return validator.New(fetcher.New(fetchOpts...), opts).Validate(ctx, url)
+		})
+
+		switch *format {
+		case "json":
+			writeJSON(report)
+		case "csv":
+			if err := report.WriteCSV(os.Stdout); err != nil {
+				log.Fatalf("Failed to write CSV: %v", err)
+			}
+		default:
+			tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(tw, strings.Join(report.Header(), "\t"))
+			for _, row := range report.Table() {
+				fmt.Fprintln(tw, strings.Join(row, "\t"))
+			}
+			tw.Flush()
+		}
+	}
+}
+
+// setupMDSCheck registers flags for the mds-check command.
+func setupMDSCheck(fs *flag.FlagSet) func() {
+	url := fs.String("url", "", "GBFS feed URL to check")
+	mdsURL := fs.String("mds-url", "", "MDS provider /vehicles URL; a bearer token is read from MDS_TOKEN")
+	mdsVersion := fs.String("mds-version", "2.0", "MDS version to request")
+	tolerance := fs.Float64("tolerance", mds.DefaultTolerancePercent, "Fleet size difference in percent tolerated before reporting a discrepancy")
+	format := fs.String("format", "text", "Output format (text, json)")
+	return func() {
+		if *url == "" || *mdsURL == "" {
+			log.Fatal("mds-check: -url and -mds-url are required")
+		}
+		c := loadClient(*url)
+
+		opts := []fetcher.Option{fetcher.WithHeaders(map[string]string{"Accept": mds.AcceptHeader(*mdsVersion)})}
+		if token := os.Getenv("MDS_TOKEN"); token != "" {
+			opts = append(opts, fetcher.WithAuth(&fetcher.AuthConfig{
+				Type:        fetcher.AuthBearerToken,
+				BearerToken: &fetcher.BearerTokenConfig{Token: token},
+			}))
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+		defer cancel()
+		vehicles, err := mds.Fetch(ctx, fetcher.New(opts...), *mdsURL)
+		if err != nil {
+			log.Fatalf("Failed to fetch MDS vehicles: %v", err)
+		}
+		report := mds.CrossCheck(c.VehicleStatus(), c.StationStatus(), c.VehicleTypes(), vehicles, *tolerance)
+
+		if *format == "json" {
+			writeJSON(report)
+		} else {
+			fmt.Printf("GBFS vehicles: %d\n", report.GBFSVehicles)
+			fmt.Printf("MDS vehicles: %d in the right of way, %d in total\n", report.MDSVehicles, report.MDSTotal)
+			fmt.Printf("Difference: %.1f%% (tolerance %.1f%%)\n", report.DifferencePercent, report.TolerancePercent)
+			for _, t := range report.Types {
+				fmt.Printf("  %-16s GBFS %d, MDS %d\n", t.Type, t.GBFS, t.MDS)
+			}
+			for _, d := range report.Discrepancies {
+				fmt.Printf("  discrepancy: %s\n", d)
+			}
+		}
+		if len(report.Discrepancies) > 0 {
+			os.Exit(1)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"log"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/gbfs-validator-go/pkg/anonymize"
+)
+
+// setupAnonymize registers flags for the anonymize command.
+func setupAnonymize(fs *flag.FlagSet) func() {
+	defaults := anonymize.DefaultConfig()
+	in := fs.String("in", "", "Directory holding a snapshot of the feed's .json files")
+	out := fs.String("out", "", "Directory to write the anonymized files to")
+	seed := fs.Int64("seed", 0, "Seed keying the rewrite; 0 picks a random one. Keep it secret: it lets guesses of original values be tested")
+	jitter := fs.Float64("jitter", defaults.JitterMeters, "Maximum distance in meters a coordinate moves")
+	force := fs.Bool("force", false, "Overwrite existing files")
+	return func() {
+		if *in == "" || *out == "" {
+			log.Fatal("anonymize: -in and -out are required")
+		}
+		if filepath.Clean(*in) == filepath.Clean(*out) {
+			log.Fatal("anonymize: -out must differ from -in")
+		}
+
+		paths, err := filepath.Glob(filepath.Join(*in, "*.json"))
+		if err != nil || len(paths) == 0 {
+			log.Fatalf("anonymize: no .json files in %s", *in)
+		}
+		files := make(map[string][]byte, len(paths))
+		for _, path := range paths {
+			data, err := os.ReadFile(path)
+			if err != nil {
+				log.Fatalf("anonymize: %v", err)
+			}
+			files[filepath.Base(path)] = data
+		}
+
+		cfg := anonymize.Config{Seed: *seed, JitterMeters: *jitter}
+		if cfg.Seed == 0 {
+			cfg.Seed = rand.Int64()
+		}
+		rewritten := anonymize.Anonymize(files, cfg)
+
+		if err := os.MkdirAll(*out, 0o755); err != nil {
+			log.Fatalf("anonymize: %v", err)
+		}
+		names := make([]string, 0, len(rewritten))
+		for name := range rewritten {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		if !*force {
+			for _, name := range names {
+				path := filepath.Join(*out, name)
+				if _, err := os.Stat(path); err == nil {
+					log.Fatalf("anonymize: %s already exists (use -force to overwrite)", path)
+				}
+			}
+		}
+		for _, name := range names {
+			path := filepath.Join(*out, name)
+			data := rewritten[name]
+			if !bytes.HasSuffix(data, []byte("\n")) {
+				data = append(data, '\n')
+			}
+			if err := os.WriteFile(path, data, 0o644); err != nil {
+				log.Fatalf("anonymize: %v", err)
+			}
+			fmt.Fprintf(os.Stderr, "wrote %s\n", path)
+		}
+		fmt.Fprintln(os.Stderr, "Review the files before sharing them: names, addresses, and other free text are not rewritten.")
+	}
+}
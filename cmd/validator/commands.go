@@ -0,0 +1,860 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/gbfs-validator-go/pkg/api"
+	"github.com/gbfs-validator-go/pkg/archive"
+	"github.com/gbfs-validator-go/pkg/catalog"
+	"github.com/gbfs-validator-go/pkg/fetcher"
+	"github.com/gbfs-validator-go/pkg/monitor"
+	"github.com/gbfs-validator-go/pkg/notify"
+	"github.com/gbfs-validator-go/pkg/report"
+	"github.com/gbfs-validator-go/pkg/schema"
+	"github.com/gbfs-validator-go/pkg/secret"
+	"github.com/gbfs-validator-go/pkg/signing"
+	"github.com/gbfs-validator-go/pkg/validator"
+)
+
+// command describes a validator subcommand.
+type command struct {
+	Name    string
+	Summary string
+	// Setup registers flags on fs and returns the function that runs the
+	// command once fs has been parsed.
+	Setup func(fs *flag.FlagSet) func()
+}
+
+// commands lists subcommands in help order.
+func commands() []command {
+	return []command{
+		{Name: "validate", Summary: "Validate a GBFS feed and print a report", Setup: setupValidate},
+		{Name: "serve", Summary: "Run the HTTP API server", Setup: setupServe},
+		{Name: "tui", Summary: "Validate a feed in an interactive terminal UI", Setup: setupTUI},
+		{Name: "rules", Summary: "List the built-in checks with their severities, versions, and files", Setup: setupRules},
+		{Name: "scaffold-gbfs", Summary: "Probe a base URL and print a gbfs.json listing the files found", Setup: setupScaffoldGBFS},
+		{Name: "scaffold-feed", Summary: "Write minimal valid example files for a GBFS version", Setup: setupScaffoldFeed},
+		{Name: "mock-server", Summary: "Serve a mock feed with injected faults and latency for integration tests", Setup: setupMockServer},
+		{Name: "anonymize", Summary: "Rewrite a feed snapshot with jittered coordinates, random IDs, and no contact details for sharing", Setup: setupAnonymize},
+		{Name: "genfeed", Summary: "Generate a synthetic feed of configurable size and serve it for load testing", Setup: setupGenfeed},
+		{Name: "coverage", Summary: "Compare geofencing zone coverage with station and vehicle positions", Setup: setupCoverage},
+		{Name: "compare", Summary: "Validate several operators' feeds and print a comparison table", Setup: setupCompare},
+		{Name: "mds-check", Summary: "Cross-check fleet size and vehicle types with an MDS /vehicles endpoint", Setup: setupMDSCheck},
+		{Name: "monitor", Summary: "Validate feeds on a schedule and open incidents when they degrade", Setup: setupMonitor},
+		{Name: "digest", Summary: "Email digests of archived results to recipient groups", Setup: setupDigest},
+		{Name: "prune", Summary: "Delete archived runs outside a retention policy", Setup: setupPrune},
+		{Name: "seal", Summary: "Encrypt a credential from stdin for a monitor configuration file", Setup: setupSeal},
+		{Name: "verify", Summary: "Check that a report was signed by a validator server and not altered", Setup: setupVerify},
+		{Name: "completion", Summary: "Print a shell completion script (bash, zsh, fish)", Setup: setupCompletion},
+		{Name: "man", Summary: "Print a man page in roff format", Setup: setupMan},
+	}
+}
+
+// findCommand returns the named subcommand.
+func findCommand(name string) (command, bool) {
+	for _, c := range commands() {
+		if c.Name == name {
+			return c, true
+		}
+	}
+	return command{}, false
+}
+
+// runCommand parses args for c and runs it.
+func runCommand(c command, args []string) {
+	fs := flag.NewFlagSet(c.Name, flag.ExitOnError)
+	run := c.Setup(fs)
+	fs.Parse(args)
+	run()
+}
+
+// runLegacy keeps the pre-subcommand interface: -url or -feed validates,
+// otherwise serve.
+func runLegacy() {
+	port := flag.Int("port", 8080, "Port to listen on")
+	url, options := feedFlags(flag.CommandLine)
+	fetchOptions := fetchFlags(flag.CommandLine)
+	printer := outputFlags(flag.CommandLine)
+	flag.Usage = usage
+	flag.Parse()
+
+	opts := options()
+	if *url != "" || len(opts.FeedURLs) > 0 {
+		format, out := printer()
+		runCLI(*url, opts, fetchOptions(), format, out, "", "")
+		return
+	}
+
+	runServer(*port, opts.Schemas, nil)
+}
+
+// usage prints top-level help including subcommands.
+func usage() {
+	out := flag.CommandLine.Output()
+	fmt.Fprintf(out, "Usage: %s <command> [flags]\n\nCommands:\n", os.Args[0])
+	for _, c := range commands() {
+		fmt.Fprintf(out, "  %-12s %s\n", c.Name, c.Summary)
+	}
+	fmt.Fprintf(out, "\nWithout a command, -url validates a feed and otherwise the server starts.\n\nFlags:\n")
+	flag.PrintDefaults()
+}
+
+// feedFlags registers flags that configure a validation run.
+func feedFlags(fs *flag.FlagSet) (*string, func() validator.Options) {
+	url := fs.String("url", "", "GBFS feed URL to validate")
+	ver := fs.String("version", "", "Force specific GBFS version")
+	docked := fs.Bool("docked", false, "Require station-based (docked) files")
+	freefloating := fs.Bool("freefloating", false, "Require free-floating vehicle files")
+	lenient := fs.Bool("lenient", false, "Enable lenient mode (coerce 0/1 to bool, string to number, etc.)")
+	profile := fs.String("profile", "default", "Severity profile ("+strings.Join(validator.ProfileNames(), ", ")+")")
+	warningsAsErrors := fs.Bool("warnings-as-errors", false, "Fail the feed, and exit 1, on warnings as well as errors")
+	overrides := keyValueFlag{}
+	fs.Var(overrides, "override", "Override a feed URL as name=url (repeatable)")
+	referenced := keyValueFlag{}
+	fs.Var(referenced, "referenced-file-severity", "Set the severity of an optional file referenced by ID but absent, as check=error|warning|info, e.g. conditionalRegions=info (repeatable; see the rules command)")
+	budgets := keyValueFlag{}
+	fs.Var(budgets, "size-budget", "Warn when a file is larger than a budget, as file=size with an optional KiB or MiB suffix, e.g. station_status=2MiB; * sets other files and a negative size disables (repeatable)")
+	feeds := keyValueFlag{}
+	fs.Var(feeds, "feed", "Validate a feed URL as name=url without gbfs.json (repeatable; replaces -url)")
+	languages := fs.String("languages", "", "Comma-separated v1/v2 language blocks to validate, or \"all\" to validate and compare every block")
+	schemas := fs.String("schemas", "", "Validate against a local schema directory or tarball instead of the embedded set")
+	schemaRef := fs.String("schema-ref", "", "Download schemas from this git ref of the schema repository (e.g. a release candidate branch)")
+	schemaRepo := fs.String("schema-repo", schema.DefaultRepo, "GitHub owner/name of the schema repository used with -schema-ref")
+	var extensions stringsFlag
+	fs.Var(&extensions, "extension", "Collect fields with this prefix as a proprietary extension, as prefix[=schemas.json] where the file maps field names to JSON Schemas (repeatable)")
+	depotThreshold := fs.Float64("depot-threshold", 0, "Warn when this share of vehicles reports one exact position, e.g. 0.2 (0 uses the default, negative disables)")
+	currencies := fs.String("currencies", "", "Comma-separated currencies pricing plans may mix, e.g. EUR,CHF")
+	checkImages := fs.Bool("check-images", false, "Request image URLs and warn about broken, non-image, or oversized images")
+	imageMaxBytes := fs.Int64("image-max-bytes", 0, "Largest acceptable image size in bytes with -check-images (0 uses 2 MiB)")
+	checkAlertURLs := fs.Bool("check-alert-urls", false, "Request alert URLs and warn about pages that are unreachable")
+	compareVersions := fs.Bool("compare-versions", false, "Fetch the other versions listed in gbfs_versions.json and warn when their system_id, stations, or fleet size diverge")
+	sameOrigin := fs.Bool("same-origin", false, "Warn when gbfs.json lists feeds on another origin")
+	dataQuality := fs.Bool("data-quality", false, "Warn about valid but hard to use data, such as empty, duplicated, or code-like station names")
+	minDecimals := fs.Int("min-coordinate-decimals", 0, "Warn about coordinates with fewer decimal places (0 uses 4, negative disables)")
+	maxDecimals := fs.Int("max-coordinate-decimals", 0, "Warn about coordinates with more decimal places (0 uses 8, negative disables)")
+	sample := fs.Int("sample", 0, "Check only this many entries of each large array, for a quick smoke check of a huge feed (0 checks all)")
+	sampleRandom := fs.Bool("sample-random", false, "With -sample, check a random choice of entries instead of the first ones")
+	catalogPath := fs.String("catalog", "", "Warn when system_id differs from the one a systems.csv feed catalog registers for the URL, as a file or URL (\"mobilitydata\" fetches the Mobility Database catalog)")
+	var rulePacks stringsFlag
+	fs.Var(&rulePacks, "rule-pack", "Evaluate a regulatory rule pack JSON file and report it in its own section (repeatable)")
+
+	return url, func() validator.Options {
+		if err := validator.CheckProfile(*profile); err != nil {
+			log.Fatalf("-profile: %v", err)
+		}
+		opts := validator.Options{
+			Version:      *ver,
+			Docked:       *docked,
+			Freefloating: *freefloating,
+			LenientMode:  *lenient,
+			Profile:      *profile,
+		}
+		opts.DepotThreshold = *depotThreshold
+		opts.CheckImages, opts.ImageMaxBytes = *checkImages, *imageMaxBytes
+		opts.CheckAlertURLs = *checkAlertURLs
+		opts.CompareVersions = *compareVersions
+		opts.SameOrigin = *sameOrigin
+		opts.DataQuality = *dataQuality
+		opts.TreatWarningsAsErrors = *warningsAsErrors
+		opts.MinCoordinateDecimals, opts.MaxCoordinateDecimals = *minDecimals, *maxDecimals
+		opts.SampleSize, opts.SampleRandom = *sample, *sampleRandom
+		for name, u := range overrides {
+			if err := validator.CheckFeedURLOverride(name, u); err != nil {
+				log.Fatalf("-override: %v", err)
+			}
+		}
+		if len(overrides) > 0 {
+			opts.FeedURLOverrides = overrides
+		}
+		if len(feeds) > 0 {
+			opts.FeedURLs = feeds
+		}
+		for file, size := range budgets {
+			opts.SizeBudgets = setSizeBudget(opts.SizeBudgets, file, size)
+		}
+		for check, severity := range referenced {
+			opts.ReferencedFileSeverity = setReferencedFileSeverity(opts.ReferencedFileSeverity, check, severity)
+		}
+		if *currencies != "" {
+			opts.Currencies = strings.Split(*currencies, ",")
+		}
+		if *languages != "" {
+			opts.Languages = strings.Split(*languages, ",")
+		}
+		if *schemas != "" && *schemaRef != "" {
+			log.Fatal("-schemas and -schema-ref are mutually exclusive")
+		}
+		if *schemas != "" {
+			opts.Schemas = loadSchemas(*schemas)
+		}
+		if *schemaRef != "" {
+			opts.Schemas = fetchSchemas(*schemaRepo, *schemaRef)
+		}
+		for _, spec := range extensions {
+			opts.Extensions = append(opts.Extensions, loadExtension(spec))
+		}
+		for _, path := range rulePacks {
+			pack, err := validator.LoadRulePack(path)
+			if err != nil {
+				log.Fatalf("Failed to load rule pack: %v", err)
+			}
+			opts.RulePacks = append(opts.RulePacks, pack)
+		}
+		if *catalogPath != "" {
+			opts.Catalog = loadCatalog(*catalogPath)
+		}
+		return opts
+	}
+}
+
+// fetchFlags registers flags that configure HTTP requests.
+func fetchFlags(fs *flag.FlagSet) func() []fetcher.Option {
+	http1 := fs.Bool("http1", false, "Disable HTTP/2, for servers with broken HTTP/2 support")
+	resolver := fs.String("resolver", "", "Resolve host names through this DNS server (host or host:port) instead of the system resolver")
+	ipVersion := fs.String("ip", "", "Connect over IPv4 (4) or IPv6 (6) only")
+	dialTimeout := fs.Duration("dial-timeout", 0, "Connection timeout per host, e.g. 5s (0 uses 10s)")
+	userAgent := fs.String("user-agent", "", "Send this User-Agent with every request instead of GBFS-Validator-Go/1.0")
+	contact := fs.String("contact", "", "Tell feed providers how to reach you, e.g. an email address, in a header sent with every request")
+	contactHeader := fs.String("contact-header", "From", "Header that carries -contact, e.g. From or X-Contact")
+	robots := fs.Bool("robots", false, "Skip URLs that a host's robots.txt disallows, for bulk runs over feeds you do not operate")
+	intervals := keyValueFlag{}
+	fs.Var(intervals, "host-interval", "Wait at least this long between requests to a host and its subdomains, as host=duration, e.g. gbfs.example.com=5s; * sets other hosts (repeatable)")
+	registry := fs.String("host-intervals", "", "Read per-host minimum request intervals from a file of \"host duration\" lines; -host-interval entries take precedence")
+
+	return func() []fetcher.Option {
+		var opts []fetcher.Option
+		if *http1 {
+			opts = append(opts, fetcher.WithHTTP1())
+		}
+		if *resolver != "" {
+			opts = append(opts, fetcher.WithResolver(*resolver))
+		}
+		version, err := fetcher.ParseIPVersion(*ipVersion)
+		if err != nil {
+			log.Fatalf("Invalid -ip: %v", err)
+		}
+		if version != 0 {
+			opts = append(opts, fetcher.WithIPVersion(version))
+		}
+		if *dialTimeout > 0 {
+			opts = append(opts, fetcher.WithDialTimeout(*dialTimeout))
+		}
+		if *userAgent != "" {
+			opts = append(opts, fetcher.WithUserAgent(*userAgent))
+		}
+		if *contact != "" {
+			opts = append(opts, fetcher.WithContact(*contactHeader, *contact))
+		}
+		if c := courtesy(*robots, *registry, intervals); c != nil {
+			opts = append(opts, fetcher.WithCourtesy(c))
+		}
+		return opts
+	}
+}
+
+// outputFlags registers flags that control report rendering.
+func outputFlags(fs *flag.FlagSet) func() (string, *textPrinter) {
+	format := fs.String("format", "text", "Output format ("+strings.Join(report.Names(), ", ")+")")
+	verbose := fs.Bool("v", false, "Print every unique issue per file")
+	veryVerbose := fs.Bool("vv", false, "Print every issue with its instance path")
+	quiet := fs.Bool("quiet", false, "Print only the summary line")
+	noColor := fs.Bool("no-color", false, "Disable colored output")
+
+	return func() (string, *textPrinter) {
+		verbosity := 0
+		if *verbose {
+			verbosity = 1
+		}
+		if *veryVerbose {
+			verbosity = 2
+		}
+		color := !*noColor && os.Getenv("NO_COLOR") == "" && isTerminal(os.Stdout)
+		return *format, newTextPrinter(os.Stdout, verbosity, *quiet, color)
+	}
+}
+
+// setupValidate registers flags for the validate command.
+func setupValidate(fs *flag.FlagSet) func() {
+	url, options := feedFlags(fs)
+	fetchOptions := fetchFlags(fs)
+	printer := outputFlags(fs)
+	archiveURI := fs.String("archive", "", "Store the result and fetched files in s3://bucket/prefix, gs://bucket/prefix, or a directory")
+	harPath := fs.String("har", "", "Record every request and response of the run in this HAR file, credentials redacted")
+	dryRun := fs.Bool("dry-run", false, "List the files and checks the run would involve, with severities, without fetching anything")
+	return func() {
+		opts := options()
+		format, out := printer()
+		if *dryRun {
+			runDryRun(*url, opts, format, out)
+			return
+		}
+		if *url == "" && len(opts.FeedURLs) == 0 {
+			log.Fatal("validate: -url or -feed is required")
+		}
+		runCLI(*url, opts, fetchOptions(), format, out, *archiveURI, *harPath)
+	}
+}
+
+// setupServe registers flags for the serve command.
+func setupServe(fs *flag.FlagSet) func() {
+	port := fs.Int("port", 8080, "Port to listen on")
+	schemas := fs.String("schemas", "", "Validate against a local schema directory or tarball instead of the embedded set")
+	auditLog := fs.String("audit-log", "", "Write an audit log of validation requests to a file, \"stdout\", or a sqlite:path or postgres://user@host/db database")
+	proxies := fs.String("trusted-proxies", "", "Comma-separated proxy IPs or CIDR ranges whose X-Forwarded-For is trusted")
+	archiveURI := fs.String("archive", "", "Store async job results and fetched files in s3://bucket/prefix, gs://bucket/prefix, or a directory")
+	storeURI := fs.String("store", "", "Also keep run results for history and trends in memory:, sqlite:path, or postgres://user@host/db (pool_max_conns and related query parameters size the connection pool)")
+	retention := retentionFlags(fs)
+	monitorConfig := fs.String("monitor", "", "Also monitor the feeds in this configuration file and serve their status")
+	workQueue := fs.String("work-queue", "", "Share monitor checks between all replicas through a postgres://user@host/db queue, which also holds incident state and feeds changed through the API on any replica")
+	queueWorkers := fs.Int("queue-workers", 4, "Monitor checks from -work-queue each replica runs at once")
+	election := fs.String("leader-election", "", "Elect one replica through a postgres://user@host/db lease to schedule all monitor checks and send alerts; feeds changed through the API on any replica are kept in the same database")
+	monitorState := fs.String("monitor-state", "", "Persist feeds added, changed, or paused through the monitor API in this file (not with -work-queue or -leader-election, which keep them in their database)")
+	cacheTTL := fs.Duration("cache-ttl", 0, "Serve repeated validations of the same URL and options from a cache for this long, e.g. 5m")
+	maxConcurrent := fs.Int("max-concurrent", 0, "Run at most this many validations at once (0 means no limit)")
+	queue := fs.Int("queue", 16, "With -max-concurrent, let this many more validations wait before answering 503")
+	validationTimeout := fs.Duration("validation-timeout", 50*time.Second, "Stop each validation after this long and return its incomplete result (0 means no limit); requests may set a shorter timeout")
+	fileTimeout := fs.Duration("file-timeout", 0, "Fail each feed file request after this long, e.g. 10s (0 uses 30s); requests may set a shorter fileTimeout")
+	fetchOptions := fetchFlags(fs)
+	return func() {
+		var bundle *schema.Bundle
+		if *schemas != "" {
+			bundle = loadSchemas(*schemas)
+		}
+		runServer(*port, bundle, func(server *api.Server) func(context.Context) error {
+			configureAudit(server, *auditLog, *proxies)
+			server.SetFetcherOptions(fetchOptions()...)
+			server.SetResultCache(*cacheTTL)
+			server.SetConcurrencyLimit(*maxConcurrent, *queue)
+			server.SetValidationTimeouts(*validationTimeout, *fileTimeout)
+			server.SetAdminToken(os.Getenv("GBFS_ADMIN_TOKEN"))
+			signer, err := signing.FromEnv()
+			if err != nil {
+				log.Fatalf("Failed to load signing key: %v", err)
+			}
+			if signer != nil {
+				server.SetSigner(signer)
+				log.Printf("Signing results with key %s", signer.Public().KeyID)
+			}
+			var a *archive.Archive
+			if *archiveURI != "" {
+				var err error
+				a, err = archive.OpenURI(*archiveURI)
+				if err != nil {
+					log.Fatalf("Failed to open archive: %v", err)
+				}
+				a.SetRetention(retention())
+				server.SetArchive(a)
+			}
+			var store archive.Store
+			if *storeURI != "" {
+				store = openStore(*storeURI)
+				server.SetStore(store)
+			}
+			if *monitorConfig != "" {
+				if *monitorState != "" && (*workQueue != "" || *election != "") {
+					log.Fatal("-monitor-state is per replica; -work-queue and -leader-election keep feed changes in their database")
+				}
+				m := loadMonitor(*monitorConfig, a, store, fetchOptions(), replicaOptions(*workQueue, *election, *queueWorkers)...)
+				if *monitorState != "" {
+					if err := m.OpenState(*monitorState); err != nil {
+						log.Fatalf("Failed to load monitor state: %v", err)
+					}
+				}
+				server.SetMonitor(m)
+				return m.Run
+			}
+			return nil
+		})
+	}
+}
+
+// retentionFlags registers flags for an archive retention policy.
+func retentionFlags(fs *flag.FlagSet) func() archive.Retention {
+	keep := fs.Int("retain-runs", 0, "Keep only this many most recent runs per feed in the archive (0 keeps all)")
+	age := fs.String("retain-age", "", "Delete archived runs older than this, e.g. 30d or 12h")
+	return func() archive.Retention {
+		r := archive.Retention{KeepRuns: *keep}
+		if *age != "" {
+			d, err := archive.ParseAge(*age)
+			if err != nil {
+				log.Fatalf("Invalid -retain-age: %v", err)
+			}
+			r.MaxAge = d
+		}
+		return r
+	}
+}
+
+// setupPrune registers flags for the prune command.
+func setupPrune(fs *flag.FlagSet) func() {
+	archiveURI := fs.String("archive", "", "Archive to prune: s3://bucket/prefix, gs://bucket/prefix, or a directory")
+	retention := retentionFlags(fs)
+	return func() {
+		if *archiveURI == "" {
+			log.Fatal("prune: -archive is required")
+		}
+		policy := retention()
+		if policy.IsZero() {
+			log.Fatal("prune: -retain-runs or -retain-age is required")
+		}
+		a, err := archive.OpenURI(*archiveURI)
+		if err != nil {
+			log.Fatalf("Failed to open archive: %v", err)
+		}
+		report, err := a.Prune(context.Background(), policy, time.Now())
+		if err != nil {
+			log.Fatalf("Prune failed: %v", err)
+		}
+		fmt.Printf("Pruned %d runs (%d objects) across %d feeds; %d runs kept\n",
+			report.RunsDeleted, report.ObjectsDeleted, report.Feeds, report.RunsKept)
+	}
+}
+
+// setupMonitor registers flags for the monitor command.
+func setupMonitor(fs *flag.FlagSet) func() {
+	configPath := fs.String("config", "", "Monitor configuration file (feeds, tenants, and alert integrations)")
+	archiveURI := fs.String("archive", "", "Store each run in s3://bucket/prefix, gs://bucket/prefix, or a directory")
+	storeURI := fs.String("store", "", "Also keep run results for history and trends in memory:, sqlite:path, or postgres://user@host/db (pool_max_conns and related query parameters size the connection pool)")
+	workQueue := fs.String("work-queue", "", "Share monitor checks between all replicas through a postgres://user@host/db queue, which also holds incident state and feeds changed through the API on any replica")
+	queueWorkers := fs.Int("queue-workers", 4, "Monitor checks from -work-queue each replica runs at once")
+	election := fs.String("leader-election", "", "Elect one replica through a postgres://user@host/db lease to schedule all monitor checks and send alerts; feeds changed through the API on any replica are kept in the same database")
+	retention := retentionFlags(fs)
+	fetchOptions := fetchFlags(fs)
+	return func() {
+		if *configPath == "" {
+			log.Fatal("monitor: -config is required")
+		}
+		var a *archive.Archive
+		if *archiveURI != "" {
+			var err error
+			a, err = archive.OpenURI(*archiveURI)
+			if err != nil {
+				log.Fatalf("Failed to open archive: %v", err)
+			}
+			a.SetRetention(retention())
+		}
+		var store archive.Store
+		if *storeURI != "" {
+			store = openStore(*storeURI)
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		loadMonitor(*configPath, a, store, fetchOptions(), replicaOptions(*workQueue, *election, *queueWorkers)...).Run(ctx)
+	}
+}
+
+// loadMonitor builds a monitor from a configuration file, archiving to a
+// and storing results in store when they are set, fetching with
+// fetchOpts, and applying any extra options.
+func loadMonitor(path string, a *archive.Archive, store archive.Store, fetchOpts []fetcher.Option, extra ...monitor.Option) *monitor.Monitor {
+	cfg, err := monitor.LoadConfig(path)
+	if err != nil {
+		log.Fatalf("Failed to load monitor config: %v", err)
+	}
+	box, err := secret.FromEnv()
+	if err != nil {
+		log.Fatalf("Failed to load secret key: %v", err)
+	}
+	if err := cfg.OpenSecrets(box); err != nil {
+		log.Fatalf("Failed to decrypt monitor config: %v", err)
+	}
+
+	opts := []monitor.Option{
+		monitor.WithHysteresis(cfg.Alerts.TriggerAfter, cfg.Alerts.ResolveAfter),
+		monitor.WithTenants(cfg.Tenants),
+		monitor.WithSecrets(box),
+		monitor.WithFetcherOptions(fetchOpts...),
+	}
+	if alerters := cfg.Alerts.Alerters(); len(alerters) > 0 {
+		opts = append(opts, monitor.WithAlerter(alerters))
+	}
+	if a != nil {
+		opts = append(opts, monitor.WithArchive(a))
+	}
+	if store != nil {
+		opts = append(opts, monitor.WithStore(store))
+	}
+	opts = append(opts, extra...)
+	log.Printf("Monitoring %d feeds for %d tenants", len(cfg.AllFeeds()), len(cfg.Tenants))
+	m := monitor.New(cfg.Feeds, opts...)
+	if err := m.SyncFeeds(context.Background()); err != nil {
+		log.Fatalf("Failed to load monitor feeds: %v", err)
+	}
+	return m
+}
+
+// setupSeal registers flags for the seal command.
+func setupSeal(fs *flag.FlagSet) func() {
+	generate := fs.Bool("generate-key", false, "Print a new random key for "+secret.KeyEnv+" instead")
+	return func() {
+		if *generate {
+			key, err := secret.GenerateKey()
+			if err != nil {
+				log.Fatalf("seal: %v", err)
+			}
+			fmt.Println(key)
+			return
+		}
+		box, err := secret.FromEnv()
+		if err != nil {
+			log.Fatalf("Failed to load secret key: %v", err)
+		}
+		if box == nil {
+			log.Fatalf("seal: %v", secret.ErrNoKey)
+		}
+		value, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			log.Fatalf("seal: %v", err)
+		}
+		sealed, err := box.Seal(strings.TrimRight(string(value), "\r\n"))
+		if err != nil {
+			log.Fatalf("seal: %v", err)
+		}
+		fmt.Println(sealed)
+	}
+}
+
+// setupVerify registers flags for the verify command, which checks the
+// detached signature a server returns with a report in X-GBFS-Signature.
+func setupVerify(fs *flag.FlagSet) func() {
+	reportPath := fs.String("report", "", "Report file, exactly as the server returned it")
+	signature := fs.String("signature", "", "The X-GBFS-Signature header returned with the report")
+	publicKey := fs.String("public-key", "", "The server's base64 public key, as served at /api/signing-key")
+	serverURL := fs.String("server", "", "Fetch the public key from this validator server instead of -public-key")
+	generate := fs.Bool("generate-key", false, "Print a new random key for "+signing.KeyEnv+" and its public key instead")
+	feedURL := fs.String("feed-url", "", "Fail unless the report is for this gbfs.json URL")
+	maxAge := fs.Duration("max-age", 0, "Fail if the feed was validated longer ago than this (0 skips the check)")
+	return func() {
+		if *generate {
+			seed, err := signing.GenerateKey()
+			if err != nil {
+				log.Fatalf("verify: %v", err)
+			}
+			signer, err := signing.ParseKey(seed)
+			if err != nil {
+				log.Fatalf("verify: %v", err)
+			}
+			fmt.Printf("%s=%s\npublic key: %s (key %s)\n", signing.KeyEnv, seed, signer.Public().Key, signer.Public().KeyID)
+			return
+		}
+		if *reportPath == "" || *signature == "" || (*publicKey == "") == (*serverURL == "") {
+			log.Fatal("verify: -report, -signature, and one of -public-key or -server are required")
+		}
+		key := *publicKey
+		if *serverURL != "" {
+			var published signing.PublicKey
+			result := fetcher.New().FetchJSON(context.Background(), strings.TrimSuffix(*serverURL, "/")+"/api/signing-key", &published)
+			if result.Error != nil {
+				log.Fatalf("Failed to fetch the public key: %v", result.Error)
+			}
+			key = published.Key
+		}
+		pub, err := signing.ParsePublicKey(key)
+		if err != nil {
+			log.Fatalf("verify: %v", err)
+		}
+		data, err := os.ReadFile(*reportPath)
+		if err != nil {
+			log.Fatalf("verify: %v", err)
+		}
+		if err := signing.Verify(pub, data, *signature); err != nil {
+			fmt.Printf("%s: %v\n", *reportPath, err)
+			os.Exit(1)
+		}
+		fmt.Printf("%s: signature valid (key %s)\n", *reportPath, signing.KeyID(pub))
+		if err := checkSignedResult(data, *feedURL, *maxAge, time.Now()); err != nil {
+			fmt.Printf("%s: %v\n", *reportPath, err)
+			os.Exit(1)
+		}
+	}
+}
+
+// checkSignedResult prints what a signed JSON result says it validated
+// and when, and checks both against -feed-url and -max-age. Reports in
+// other formats carry neither, so they only pass when no check is asked
+// for.
+func checkSignedResult(data []byte, feedURL string, maxAge time.Duration, now time.Time) error {
+	var result validator.ValidationResult
+	if err := json.Unmarshal(data, &result); err != nil || result.ValidatedAt == nil {
+		if feedURL != "" || maxAge > 0 {
+			return errors.New("report has no feed URL or validation time; request it with format=json")
+		}
+		return nil
+	}
+	if result.FeedURL != "" {
+		fmt.Printf("feed: %s\n", result.FeedURL)
+	}
+	fmt.Printf("validated at: %s\n", result.ValidatedAt.Format(time.RFC3339))
+	if feedURL != "" && result.FeedURL != fetcher.RedactURL(feedURL) {
+		return fmt.Errorf("report is for %q, not %q", result.FeedURL, fetcher.RedactURL(feedURL))
+	}
+	if age := now.Sub(*result.ValidatedAt); maxAge > 0 && age > maxAge {
+		return fmt.Errorf("report is %s old, more than -max-age %s", age.Round(time.Second), maxAge)
+	}
+	return nil
+}
+
+// setupDigest registers flags for the digest command, meant to run from
+// cron once per schedule period.
+func setupDigest(fs *flag.FlagSet) func() {
+	configPath := fs.String("config", "", "Digest configuration file (SMTP server and recipient groups)")
+	archiveURI := fs.String("archive", "", "Archive holding validation runs: s3://bucket/prefix, gs://bucket/prefix, or a directory")
+	schedule := fs.String("schedule", "daily", "Send to groups on this schedule (daily, weekly)")
+	dryRun := fs.Bool("dry-run", false, "Print digests instead of sending them")
+	return func() {
+		if *configPath == "" || *archiveURI == "" {
+			log.Fatal("digest: -config and -archive are required")
+		}
+		cfg, err := notify.LoadDigestConfig(*configPath)
+		if err != nil {
+			log.Fatalf("Failed to load digest config: %v", err)
+		}
+		a, err := archive.OpenURI(*archiveURI)
+		if err != nil {
+			log.Fatalf("Failed to open archive: %v", err)
+		}
+
+		ctx := context.Background()
+		mailer := notify.NewMailer(cfg.SMTP)
+		to := time.Now()
+		for _, group := range cfg.Groups {
+			if string(group.Schedule) != *schedule {
+				continue
+			}
+			digest, err := notify.BuildDigest(ctx, a, group.Feeds, to.Add(-group.Schedule.Period()), to)
+			if err != nil {
+				log.Fatalf("Failed to build digest for %s: %v", group.Name, err)
+			}
+			if *dryRun {
+				fmt.Println(notify.RenderDigest(group.Name, digest))
+				continue
+			}
+			sent, err := mailer.SendDigest(group, digest)
+			if err != nil {
+				log.Fatal(err)
+			}
+			if sent {
+				log.Printf("Sent %s digest to %d recipients", group.Name, len(group.Recipients))
+			}
+		}
+	}
+}
+
+// configureAudit enables the audit log and trusted proxies, or exits.
+func configureAudit(server *api.Server, auditLog, proxies string) {
+	if proxies != "" {
+		if err := server.SetTrustedProxies(strings.Split(proxies, ",")); err != nil {
+			log.Fatalf("Invalid -trusted-proxies: %v", err)
+		}
+	}
+	switch auditLog {
+	case "":
+	case "stdout":
+		server.SetAuditSink(api.NewJSONAuditSink(os.Stdout))
+	default:
+		var sink api.AuditSink
+		var err error
+		if api.IsAuditDB(auditLog) {
+			sink, err = api.OpenAuditDB(auditLog)
+		} else {
+			sink, err = api.OpenAuditFile(auditLog)
+		}
+		if err != nil {
+			log.Fatalf("Failed to open audit log: %v", err)
+		}
+		server.SetAuditSink(sink)
+		log.Printf("Writing audit log to %s", fetcher.RedactURL(auditLog))
+	}
+}
+
+// loadSchemas loads a schema bundle or exits.
+func loadSchemas(path string) *schema.Bundle {
+	bundle, err := schema.Load(path)
+	if err != nil {
+		log.Fatalf("Failed to load schemas from %s: %v", path, err)
+	}
+	return bundle
+}
+
+// loadExtension parses an -extension flag value, reading its field schemas
+// if a file is given, or exits.
+func loadExtension(spec string) validator.Extension {
+	prefix, path, _ := strings.Cut(spec, "=")
+	if prefix == "" {
+		log.Fatalf("-extension %q: prefix is required", spec)
+	}
+	ext := validator.Extension{Prefix: prefix}
+	if path == "" {
+		return ext
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		log.Fatalf("Failed to read extension schemas: %v", err)
+	}
+	if err := json.Unmarshal(data, &ext.Schemas); err != nil {
+		log.Fatalf("Extension schemas in %s must map field names to schemas: %v", path, err)
+	}
+	return ext
+}
+
+// fetchSchemas downloads a schema bundle from a git ref or exits.
+func fetchSchemas(repo, ref string) *schema.Bundle {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	bundle, err := schema.FetchRef(ctx, schema.RemoteOptions{Repo: repo, Ref: ref})
+	if err != nil {
+		log.Fatalf("Failed to fetch schemas for %s@%s: %v", repo, ref, err)
+	}
+	log.Printf("Loaded schemas for versions %v from %s@%s", bundle.Versions(), repo, ref)
+	return bundle
+}
+
+// keyValueFlag collects repeated name=value flags.
+type keyValueFlag map[string]string
+
+// String formats the collected pairs.
+func (f keyValueFlag) String() string {
+	pairs := make([]string, 0, len(f))
+	for k, v := range f {
+		pairs = append(pairs, k+"="+v)
+	}
+	return strings.Join(pairs, ",")
+}
+
+// Set parses a single name=value pair.
+func (f keyValueFlag) Set(value string) error {
+	name, val, ok := strings.Cut(value, "=")
+	if !ok || name == "" {
+		return fmt.Errorf("expected name=value, got %q", value)
+	}
+	f[name] = val
+	return nil
+}
+
+// setReferencedFileSeverity adds a -referenced-file-severity entry to m,
+// exiting on an unknown check or severity.
+func setReferencedFileSeverity(m map[string]validator.ValidationSeverity, check, severity string) map[string]validator.ValidationSeverity {
+	if err := validator.CheckReferencedFileSeverity(check, severity); err != nil {
+		log.Fatalf("-referenced-file-severity: %v", err)
+	}
+	if m == nil {
+		m = make(map[string]validator.ValidationSeverity)
+	}
+	m[check] = validator.ValidationSeverity(severity)
+	return m
+}
+
+// setSizeBudget adds a -size-budget entry to m, exiting on a malformed size.
+func setSizeBudget(m map[string]int64, file, size string) map[string]int64 {
+	unit := int64(1)
+	number := strings.TrimSpace(size)
+	for suffix, u := range map[string]int64{"KiB": 1 << 10, "MiB": 1 << 20} {
+		if trimmed, ok := strings.CutSuffix(number, suffix); ok {
+			number, unit = strings.TrimSpace(trimmed), u
+		}
+	}
+	n, err := strconv.ParseFloat(number, 64)
+	if err != nil {
+		log.Fatalf("-size-budget: invalid size %q for %s", size, file)
+	}
+	if m == nil {
+		m = make(map[string]int64)
+	}
+	m[strings.TrimSuffix(file, ".json")] = int64(n * float64(unit))
+	return m
+}
+
+// courtesy builds the robots.txt and per-host interval settings shared by
+// every fetch of a run, or returns nil when none are set.
+func courtesy(robots bool, registry string, intervals keyValueFlag) *fetcher.Courtesy {
+	if !robots && registry == "" && len(intervals) == 0 {
+		return nil
+	}
+	c := &fetcher.Courtesy{Robots: robots, Intervals: make(map[string]time.Duration)}
+	if registry != "" {
+		loaded, err := fetcher.LoadIntervals(registry)
+		if err != nil {
+			log.Fatalf("Failed to load -host-intervals %s: %v", registry, err)
+		}
+		c.Intervals = loaded
+	}
+	for host, value := range intervals {
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			log.Fatalf("-host-interval: invalid duration %q for %s", value, host)
+		}
+		c.Intervals[strings.ToLower(host)] = d
+	}
+	return c
+}
+
+// openStore opens a -store results store, exiting when it cannot.
+func openStore(uri string) archive.Store {
+	store, err := archive.OpenStore(uri)
+	if err != nil {
+		log.Fatalf("Failed to open store %s: %v", fetcher.RedactURL(uri), err)
+	}
+	return store
+}
+
+// replicaOptions opens a -work-queue, worked by up to workers checks at
+// once, or a -leader-election, and the feed store in the same database,
+// exiting when it cannot.
+func replicaOptions(queueURI, electionURI string, workers int) []monitor.Option {
+	if queueURI != "" && electionURI != "" {
+		// Every replica works the queue; see monitor.WithQueue.
+		log.Fatal("-work-queue shares checks between all replicas; drop -leader-election")
+	}
+	var opts []monitor.Option
+	feedsURI := queueURI
+	if queueURI != "" {
+		q, err := monitor.OpenQueue(queueURI)
+		if err != nil {
+			log.Fatalf("Failed to open work queue %s: %v", fetcher.RedactURL(queueURI), err)
+		}
+		opts = append(opts, monitor.WithQueue(q), monitor.WithQueueWorkers(workers))
+	}
+	if electionURI != "" {
+		e, err := monitor.OpenElection(electionURI)
+		if err != nil {
+			log.Fatalf("Failed to open leader election %s: %v", fetcher.RedactURL(electionURI), err)
+		}
+		opts = append(opts, monitor.WithElection(e))
+		feedsURI = electionURI
+	}
+	if feedsURI != "" {
+		feeds, err := monitor.OpenFeedStore(feedsURI)
+		if err != nil {
+			log.Fatalf("Failed to open monitor feeds %s: %v", fetcher.RedactURL(feedsURI), err)
+		}
+		opts = append(opts, monitor.WithFeedStore(feeds))
+	}
+	return opts
+}
+
+// loadCatalog loads a -catalog feed catalog, exiting when it cannot.
+func loadCatalog(location string) *catalog.Catalog {
+	if location == "mobilitydata" {
+		location = catalog.DefaultURL
+	}
+	c, err := catalog.Load(context.Background(), location)
+	if err != nil {
+		log.Fatalf("Failed to load catalog %s: %v", location, err)
+	}
+	return c
+}
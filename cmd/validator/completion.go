@@ -0,0 +1,206 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"time"
+)
+
+// commandFlags returns the flags a command registers, in definition order.
+func commandFlags(c command) []*flag.Flag {
+	fs := flag.NewFlagSet(c.Name, flag.ContinueOnError)
+	c.Setup(fs)
+
+	var flags []*flag.Flag
+	fs.VisitAll(func(f *flag.Flag) {
+		flags = append(flags, f)
+	})
+	return flags
+}
+
+// commandNames lists subcommand names.
+func commandNames() []string {
+	var names []string
+	for _, c := range commands() {
+		names = append(names, c.Name)
+	}
+	return names
+}
+
+// setupCompletion registers flags for the completion command.
+func setupCompletion(fs *flag.FlagSet) func() {
+	return func() {
+		shell := fs.Arg(0)
+		var err error
+		switch shell {
+		case "bash":
+			err = writeBashCompletion(os.Stdout)
+		case "zsh":
+			err = writeZshCompletion(os.Stdout)
+		case "fish":
+			err = writeFishCompletion(os.Stdout)
+		default:
+			log.Fatalf("completion: unsupported shell %q (want bash, zsh, or fish)", shell)
+		}
+		if err != nil {
+			log.Fatalf("completion: %v", err)
+		}
+	}
+}
+
+// setupMan registers flags for the man command.
+func setupMan(fs *flag.FlagSet) func() {
+	return func() {
+		if err := writeManPage(os.Stdout, time.Now()); err != nil {
+			log.Fatalf("man: %v", err)
+		}
+	}
+}
+
+// writeBashCompletion writes a bash completion script.
+func writeBashCompletion(w io.Writer) error {
+	var b strings.Builder
+	b.WriteString("# bash completion for validator\n")
+	b.WriteString("_validator() {\n")
+	b.WriteString("    local cur=\"${COMP_WORDS[COMP_CWORD]}\"\n")
+	b.WriteString("    if [ \"$COMP_CWORD\" -eq 1 ]; then\n")
+	fmt.Fprintf(&b, "        COMPREPLY=($(compgen -W \"%s\" -- \"$cur\"))\n", strings.Join(commandNames(), " "))
+	b.WriteString("        return\n")
+	b.WriteString("    fi\n")
+	b.WriteString("    case \"${COMP_WORDS[1]}\" in\n")
+	for _, c := range commands() {
+		words := flagWords(commandFlags(c))
+		if c.Name == "completion" {
+			words = []string{"bash", "zsh", "fish"}
+		}
+		fmt.Fprintf(&b, "        %s) COMPREPLY=($(compgen -W \"%s\" -- \"$cur\")) ;;\n", c.Name, strings.Join(words, " "))
+	}
+	b.WriteString("    esac\n")
+	b.WriteString("}\n")
+	b.WriteString("complete -F _validator validator\n")
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// writeZshCompletion writes a zsh completion script.
+func writeZshCompletion(w io.Writer) error {
+	var b strings.Builder
+	b.WriteString("#compdef validator\n\n")
+	b.WriteString("_validator() {\n")
+	b.WriteString("    local -a commands\n")
+	b.WriteString("    commands=(\n")
+	for _, c := range commands() {
+		fmt.Fprintf(&b, "        '%s:%s'\n", c.Name, zshEscape(c.Summary))
+	}
+	b.WriteString("    )\n\n")
+	b.WriteString("    if (( CURRENT == 2 )); then\n")
+	b.WriteString("        _describe 'command' commands\n")
+	b.WriteString("        return\n")
+	b.WriteString("    fi\n\n")
+	b.WriteString("    case $words[2] in\n")
+	for _, c := range commands() {
+		fmt.Fprintf(&b, "        %s)\n", c.Name)
+		if c.Name == "completion" {
+			b.WriteString("            _values 'shell' bash zsh fish\n")
+			b.WriteString("            ;;\n")
+			continue
+		}
+		b.WriteString("            _arguments")
+		for _, f := range commandFlags(c) {
+			fmt.Fprintf(&b, " \\\n                '-%s[%s]'", f.Name, zshEscape(f.Usage))
+		}
+		b.WriteString("\n            ;;\n")
+	}
+	b.WriteString("    esac\n")
+	b.WriteString("}\n\n")
+	b.WriteString("_validator \"$@\"\n")
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// writeFishCompletion writes a fish completion script.
+func writeFishCompletion(w io.Writer) error {
+	var b strings.Builder
+	b.WriteString("# fish completion for validator\n")
+	for _, c := range commands() {
+		fmt.Fprintf(&b, "complete -c validator -n '__fish_use_subcommand' -a %s -d '%s'\n", c.Name, fishEscape(c.Summary))
+	}
+	for _, c := range commands() {
+		if c.Name == "completion" {
+			fmt.Fprintf(&b, "complete -c validator -n '__fish_seen_subcommand_from completion' -a 'bash zsh fish'\n")
+			continue
+		}
+		for _, f := range commandFlags(c) {
+			fmt.Fprintf(&b, "complete -c validator -n '__fish_seen_subcommand_from %s' -o %s -d '%s'\n", c.Name, f.Name, fishEscape(f.Usage))
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// writeManPage writes a roff man page describing all commands and flags.
+func writeManPage(w io.Writer, date time.Time) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, ".TH VALIDATOR 1 \"%s\" \"gbfs-validator-go\" \"User Commands\"\n", date.Format("2006-01-02"))
+	b.WriteString(".SH NAME\n")
+	b.WriteString("validator \\- validate GBFS feeds from the command line or over HTTP\n")
+	b.WriteString(".SH SYNOPSIS\n")
+	b.WriteString(".B validator\n")
+	b.WriteString("\\fIcommand\\fR [\\fIflags\\fR]\n")
+	b.WriteString(".SH DESCRIPTION\n")
+	b.WriteString("Without a command, \\fB\\-url\\fR validates a feed and otherwise the API server starts.\n")
+	b.WriteString(".SH COMMANDS\n")
+	for _, c := range commands() {
+		fmt.Fprintf(&b, ".SS %s\n%s\n", c.Name, roffEscape(c.Summary))
+		for _, f := range commandFlags(c) {
+			fmt.Fprintf(&b, ".TP\n.B \\-%s\n%s", f.Name, roffEscape(f.Usage))
+			if f.DefValue != "" && f.DefValue != "false" {
+				fmt.Fprintf(&b, " (default: %s)", roffEscape(f.DefValue))
+			}
+			b.WriteString("\n")
+		}
+	}
+	b.WriteString(".SH ENVIRONMENT\n")
+	b.WriteString(".TP\n.B NO_COLOR\nDisable colored output when set.\n")
+	b.WriteString(".TP\n.B GOOGLE_MAPS_API_KEY\nKey returned to the viewer by /api/config.\n")
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// flagWords returns flag names prefixed with a dash.
+func flagWords(flags []*flag.Flag) []string {
+	var words []string
+	for _, f := range flags {
+		words = append(words, "-"+f.Name)
+	}
+	return words
+}
+
+// zshEscape escapes text for zsh _arguments specs.
+func zshEscape(s string) string {
+	r := strings.NewReplacer("'", "'\\''", "[", "\\[", "]", "\\]", ":", "\\:")
+	return r.Replace(s)
+}
+
+// fishEscape escapes text for single-quoted fish strings.
+func fishEscape(s string) string {
+	return strings.ReplaceAll(s, "'", "\\'")
+}
+
+// roffEscape escapes text for roff output.
+func roffEscape(s string) string {
+	s = strings.ReplaceAll(s, "\\", "\\\\")
+	s = strings.ReplaceAll(s, "-", "\\-")
+	if strings.HasPrefix(s, ".") || strings.HasPrefix(s, "'") {
+		s = "\\&" + s
+	}
+	return s
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/gbfs-validator-go/pkg/genfeed"
+)
+
+// setupGenfeed registers flags for the genfeed command.
+func setupGenfeed(fs *flag.FlagSet) func() {
+	defaults := genfeed.DefaultConfig()
+	ver := fs.String("version", defaults.Version, "GBFS version to generate (2.1 or later)")
+	stations := fs.Int("stations", defaults.Stations, "Number of stations")
+	vehicles := fs.Int("vehicles", defaults.Vehicles, "Number of free-floating vehicles")
+	geofences := fs.Int("geofences", defaults.Geofences, "Number of geofencing zones")
+	bbox := fs.String("bbox", fmt.Sprintf("%g,%g,%g,%g", defaults.BBox.MinLat, defaults.BBox.MinLon, defaults.BBox.MaxLat, defaults.BBox.MaxLon), "Bounding box as minLat,minLon,maxLat,maxLon")
+	seed := fs.Int64("seed", defaults.Seed, "Random seed; equal seeds generate equal feeds")
+	port := fs.Int("port", 8081, "Port to serve the feed on")
+	dir := fs.String("dir", "", "Write files to this directory instead of serving them")
+	baseURL := fs.String("base-url", "http://localhost:8081/", "Base URL used in gbfs.json when writing with -dir")
+	return func() {
+		box, err := genfeed.ParseBBox(*bbox)
+		if err != nil {
+			log.Fatalf("genfeed: %v", err)
+		}
+
+		start := time.Now()
+		feed, err := genfeed.Generate(genfeed.Config{
+			Version:   *ver,
+			Stations:  *stations,
+			Vehicles:  *vehicles,
+			Geofences: *geofences,
+			BBox:      box,
+			Seed:      *seed,
+		})
+		if err != nil {
+			log.Fatalf("genfeed: %v", err)
+		}
+		log.Printf("Generated GBFS %s feed with %d stations, %d vehicles, %d geofences in %s",
+			*ver, *stations, *vehicles, *geofences, time.Since(start).Round(time.Millisecond))
+
+		if *dir != "" {
+			writeGeneratedFeed(feed, *dir, *baseURL)
+			return
+		}
+
+		log.Printf("Serving feed at http://localhost:%d/gbfs.json", *port)
+		if err := http.ListenAndServe(fmt.Sprintf(":%d", *port), feed.Handler()); err != nil {
+			log.Fatalf("genfeed: %v", err)
+		}
+	}
+}
+
+// writeGeneratedFeed writes every file of feed, plus gbfs.json, to dir.
+func writeGeneratedFeed(feed *genfeed.Feed, dir, baseURL string) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		log.Fatalf("genfeed: %v", err)
+	}
+
+	gbfsData, err := feed.GBFS(baseURL)
+	if err != nil {
+		log.Fatalf("genfeed: %v", err)
+	}
+
+	files := map[string][]byte{"gbfs": gbfsData}
+	for name, data := range feed.Files {
+		files[name] = data
+	}
+	for name, data := range files {
+		if err := os.WriteFile(filepath.Join(dir, name+".json"), data, 0o644); err != nil {
+			log.Fatalf("genfeed: %v", err)
+		}
+	}
+	log.Printf("Wrote %d files to %s", len(files), dir)
+}
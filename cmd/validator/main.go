@@ -3,7 +3,7 @@ package main
 
 import (
 	"context"
-	"flag"
+	"encoding/json"
 	"fmt"
 	"log"
 	"net/http"
@@ -13,107 +13,86 @@ import (
 	"time"
 
 	"github.com/gbfs-validator-go/pkg/api"
+	"github.com/gbfs-validator-go/pkg/archive"
 	"github.com/gbfs-validator-go/pkg/env"
 	"github.com/gbfs-validator-go/pkg/fetcher"
+	"github.com/gbfs-validator-go/pkg/report"
+	"github.com/gbfs-validator-go/pkg/schema"
 	"github.com/gbfs-validator-go/pkg/validator"
 )
 
-// main parses flags and chooses CLI or server mode.
+// main dispatches to a subcommand or the legacy flag interface.
 func main() {
 	if err := env.LoadFile(".env"); err != nil {
 		log.Printf("Failed to load .env: %v", err)
 	}
 
-	var (
-		port         = flag.Int("port", 8080, "Port to listen on")
-		url          = flag.String("url", "", "GBFS feed URL to validate (CLI mode)")
-		version      = flag.String("version", "", "Force specific GBFS version")
-		docked       = flag.Bool("docked", false, "Require station-based (docked) files")
-		freefloating = flag.Bool("freefloating", false, "Require free-floating vehicle files")
-		lenient      = flag.Bool("lenient", false, "Enable lenient mode (coerce 0/1 to bool, string to number, etc.)")
-	)
-	flag.Parse()
-
-	if *url != "" {
-		runCLI(*url, *version, *docked, *freefloating, *lenient)
-		return
+	if len(os.Args) > 1 {
+		if c, ok := findCommand(os.Args[1]); ok {
+			runCommand(c, os.Args[2:])
+			return
+		}
 	}
 
-	runServer(*port)
+	runLegacy()
 }
 
-// runCLI validates a feed URL and prints results to stdout.
-func runCLI(feedURL, ver string, docked, freefloating, lenient bool) {
-	fmt.Printf("Validating GBFS feed: %s\n", feedURL)
-	if lenient {
-		fmt.Println("Mode: LENIENT (data coercion enabled)")
+// runCLI validates a feed URL and prints results to stdout. If archiveURI is
+// set, the result and fetched files are also stored there; if harPath is
+// set, the run's HTTP traffic is written there as a HAR file.
+func runCLI(feedURL string, opts validator.Options, fetchOpts []fetcher.Option, format string, out *textPrinter, archiveURI, harPath string) {
+	if _, ok := report.Lookup(format); !ok {
+		log.Fatalf("Unknown output format: %s", format)
+	}
+
+	if format == "text" {
+		out.header(feedURL, opts)
+	}
+
+	showProgress := format == "text" && !out.quiet && isTerminal(os.Stderr)
+	if showProgress {
+		opts.OnProgress = progressBar(os.Stderr)
 	}
-	fmt.Println("================================")
 
-	f := fetcher.New()
-	v := validator.New(f, validator.Options{
-		Version:      ver,
-		Docked:       docked,
-		Freefloating: freefloating,
-		LenientMode:  lenient,
-	})
+	var recorder *fetcher.Recorder
+	if harPath != "" {
+		recorder = fetcher.NewRecorder()
+		fetchOpts = append(fetchOpts, fetcher.WithRecorder(recorder))
+	}
+	f := fetcher.New(fetchOpts...)
+	v := validator.New(f, opts)
 
 	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
 	defer cancel()
 
 	result, err := v.Validate(ctx, feedURL)
-	if err != nil {
-		log.Fatalf("Validation failed: %v", err)
+	if showProgress {
+		clearProgress(os.Stderr)
 	}
-
-	fmt.Printf("\nVersion: detected=%s, validated=%s\n",
-		result.Summary.Version.Detected,
-		result.Summary.Version.Validated)
-
-	if result.Summary.HasErrors {
-		fmt.Printf("Status: INVALID (%d errors)\n", result.Summary.ErrorsCount)
-	} else {
-		fmt.Println("Status: VALID")
+	if recorder != nil {
+		writeHAR(recorder, harPath)
 	}
-
-	if lenient && result.Summary.CoercionSummary != nil && result.Summary.CoercionSummary.TotalCoercions > 0 {
-		fmt.Printf("\nCoercions applied: %d\n", result.Summary.CoercionSummary.TotalCoercions)
+	if err != nil {
+		log.Fatalf("Validation failed: %v", err)
 	}
 
-	fmt.Println("\nFiles:")
-	for _, file := range result.Files {
-		status := "✓"
-		if file.HasErrors {
-			status = "✗"
-		} else if !file.Exists {
-			if file.Required {
-				status = "✗ MISSING (required)"
-			} else {
-				status = "- (optional, not present)"
-			}
+	if archiveURI != "" {
+		a, err := archive.OpenURI(archiveURI)
+		if err == nil {
+			err = a.Save(ctx, archive.Key(feedURL, opts.FeedURLs), time.Now(), result)
 		}
-
-		coercionInfo := ""
-		if file.CoercionCount > 0 {
-			coercionInfo = fmt.Sprintf(" [%d coercions]", file.CoercionCount)
+		if err != nil {
+			log.Fatalf("Failed to archive result: %v", err)
 		}
+	}
 
-		fmt.Printf("  %s %s%s\n", status, file.File, coercionInfo)
-		
-		if file.HasErrors {
-			// Limit error output to first 5 unique error types
-			seen := make(map[string]int)
-			for _, err := range file.Errors {
-				key := err.Message
-				seen[key]++
-				if seen[key] == 1 && len(seen) <= 5 {
-					fmt.Printf("      %s: %s\n", err.Severity, err.Message)
-				}
-			}
-			if len(seen) > 5 {
-				fmt.Printf("      ... and %d more unique error types\n", len(seen)-5)
-			}
-		}
+	// The text format is printed with the terminal printer, which adds
+	// color and honors verbosity; other formats come from pkg/report.
+	if format == "text" {
+		out.report(result, opts)
+		out.connections(f.Stats())
+	} else if err := report.Render(format, result, os.Stdout); err != nil {
+		log.Fatalf("Failed to write %s: %v", format, err)
 	}
 
 	if result.Summary.HasErrors {
@@ -121,24 +100,59 @@ func runCLI(feedURL, ver string, docked, freefloating, lenient bool) {
 	}
 }
 
-// runServer starts the HTTP API server with graceful shutdown.
-func runServer(port int) {
-	server := api.NewServer()
+// runDryRun prints the validation plan for feedURL and opts.
+func runDryRun(feedURL string, opts validator.Options, format string, out *textPrinter) {
+	plan := validator.Plan(feedURL, opts)
+	switch format {
+	case "text":
+		out.plan(plan)
+	case "json":
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(plan); err != nil {
+			log.Fatalf("Failed to write JSON: %v", err)
+		}
+	default:
+		log.Fatalf("-dry-run supports the text and json formats, not %s", format)
+	}
+}
 
-	httpServer := &http.Server{
-		Addr:         fmt.Sprintf(":%d", port),
-		Handler:      server,
-		ReadTimeout:  15 * time.Second,
-		WriteTimeout: 60 * time.Second,
-		IdleTimeout:  60 * time.Second,
+// runServer starts the HTTP API server with graceful shutdown. configure,
+// if set, applies further settings before the server starts and may
+// return a background task, such as a monitor, that runs until the server
+// receives SIGINT or SIGTERM; runServer waits for it before returning.
+func runServer(port int, schemas *schema.Bundle, configure func(*api.Server) func(context.Context) error) {
+	server := api.NewServer()
+	if schemas != nil {
+		server.SetSchemas(schemas)
+		log.Printf("Validating against schemas from %s", schemas.Source)
+	}
+	var background func(context.Context) error
+	if configure != nil {
+		background = configure(server)
+	}
+	warmStart := time.Now()
+	if err := server.WarmSchemas(); err != nil {
+		log.Printf("Failed to compile schemas: %v", err)
+	} else {
+		log.Printf("Compiled schemas in %v", time.Since(warmStart).Round(time.Millisecond))
 	}
 
-	done := make(chan bool)
-	quit := make(chan os.Signal, 1)
-	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	httpServer := server.HTTPServer(fmt.Sprintf(":%d", port))
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	backgroundDone := make(chan struct{})
+	go func() {
+		defer close(backgroundDone)
+		if background != nil {
+			background(ctx)
+		}
+	}()
 
+	done := make(chan bool)
 	go func() {
-		<-quit
+		<-ctx.Done()
 		log.Println("Server is shutting down...")
 
 		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
@@ -152,9 +166,29 @@ func runServer(port int) {
 
 	log.Printf("GBFS Validator API server starting on port %d", port)
 	log.Printf("API endpoints:")
-	log.Printf("  POST /api/validator        - Validate a GBFS feed")
+	log.Printf("  POST /api/validator        - Validate a GBFS feed (?format= text, markdown, html, csv, junit, sarif; ?dryRun=true lists the checks)")
+	log.Printf("  POST /api/validate-file    - Validate one feed document (?fileType=, ?version=)")
+	log.Printf("  POST /api/validate-upload  - Validate uploaded feed files or a zip (multipart/form-data)")
 	log.Printf("  POST /api/feed             - Get feed data for visualization")
 	log.Printf("  POST /api/validator-summary - Get grouped validation summary")
+	log.Printf("  POST /api/jobs             - Validate asynchronously with an optional callback")
+	log.Printf("  GET  /api/jobs/{id}        - Get an asynchronous validation")
+	log.Printf("  POST /api/coverage         - Compare geofencing zone coverage with the fleet")
+	log.Printf("  POST /api/compare          - Validate several feeds and compare them side by side")
+	log.Printf("  POST /api/mds-check        - Cross-check fleet size and vehicle types with an MDS /vehicles endpoint")
+	log.Printf("  POST /api/admin/prune      - Apply the archive retention policy (admin)")
+	log.Printf("  GET  /api/feeds/{id}/trends - Get archived error, score, and latency trends")
+	log.Printf("  GET  /api/monitor/feeds    - Get monitored feed status for a tenant API key or the admin")
+	log.Printf("  POST /api/monitor/feeds    - Add a monitored feed (editor or admin)")
+	log.Printf("  GET|PUT|DELETE /api/monitor/feeds/{id} - Get a feed's last result, change or remove it")
+	log.Printf("  POST /api/monitor/feeds/{id}/pause|resume - Pause or resume checks of a feed")
+	log.Printf("  GET  /api/monitor/availability - Get 24h/7d/30d availability of monitored feeds and files")
+	log.Printf("  GET  /api/monitor/feeds/{id}/availability - Get availability of one monitored feed")
+	log.Printf("  GET  /api/monitor/feeds/{id}/churn - Get station availability churn and frozen stations")
+	log.Printf("  GET  /api/monitor/feeds/{id}/rotation - Get vehicle IDs that persist across trips")
+	log.Printf("  GET  /api/monitor/feeds/{id}/unchanged - Get files whose content stays the same despite their ttl")
+	log.Printf("  GET  /metrics              - Prometheus availability metrics of monitored feeds")
+	log.Printf("  GET  /api/rules            - List the built-in checks (?version= filters)")
 	log.Printf("  GET  /health               - Health check")
 
 	if err := httpServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
@@ -162,5 +196,24 @@ func runServer(port int) {
 	}
 
 	<-done
+	<-backgroundDone
+	if err := server.CloseAudit(); err != nil {
+		log.Printf("Failed to close audit log: %v", err)
+	}
 	log.Println("Server stopped")
 }
+
+// writeHAR saves recorded traffic to path.
+func writeHAR(recorder *fetcher.Recorder, path string) {
+	file, err := os.Create(path)
+	if err == nil {
+		err = recorder.WriteHAR(file)
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	if err != nil {
+		log.Fatalf("Failed to write HAR file: %v", err)
+	}
+	log.Printf("Recorded %d requests in %s", recorder.Len(), path)
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gbfs-validator-go/pkg/mockserver"
+)
+
+// setupMockServer registers flags for the mock-server command.
+func setupMockServer(fs *flag.FlagSet) func() {
+	port := fs.Int("port", 8082, "Port to listen on")
+	ver := fs.String("version", "3.0", "GBFS version to serve")
+	docked := fs.Bool("docked", false, "Serve station-based (docked) files")
+	freefloating := fs.Bool("freefloating", false, "Serve free-floating vehicle files")
+	omit := fs.String("omit", "", "Comma-separated files to leave out of gbfs.json and serve as 404")
+	drop := fs.String("drop", "", "Comma-separated files to list in gbfs.json but serve as 404")
+	corrupt := fs.String("corrupt", "", "Comma-separated files to serve as truncated JSON")
+	wrongTypes := fs.String("wrong-types", "", "Comma-separated files whose booleans and numbers are served as strings")
+	latency := fs.Duration("latency", 0, "Delay added to every response")
+	jitter := fs.Duration("jitter", 0, "Random extra delay of up to this much per response")
+	var faultSpecs stringsFlag
+	fs.Var(&faultSpecs, "fault", "Inject a fault as kind[:file[:script-or-rate]], kind one of error, truncate, slow, expired; e.g. error:station_status:..X (repeatable)")
+	faultDelay := fs.Duration("fault-delay", 10*time.Second, "How long slow faults hold a response")
+	return func() {
+		var faults []mockserver.Fault
+		for _, spec := range faultSpecs {
+			f, err := mockserver.ParseFault(spec)
+			if err != nil {
+				log.Fatalf("mock-server: %v", err)
+			}
+			f.Delay = *faultDelay
+			faults = append(faults, f)
+		}
+
+		srv, err := mockserver.New(mockserver.Config{
+			Version:      *ver,
+			Docked:       *docked,
+			Freefloating: *freefloating,
+			Omit:         splitList(*omit),
+			Drop:         splitList(*drop),
+			Corrupt:      splitList(*corrupt),
+			WrongTypes:   splitList(*wrongTypes),
+			Latency:      *latency,
+			Jitter:       *jitter,
+			Faults:       faults,
+		})
+		if err != nil {
+			log.Fatalf("mock-server: %v", err)
+		}
+
+		log.Printf("Serving mock GBFS %s feed at http://localhost:%d/gbfs.json", *ver, *port)
+		if err := http.ListenAndServe(fmt.Sprintf(":%d", *port), srv); err != nil {
+			log.Fatalf("mock-server: %v", err)
+		}
+	}
+}
+
+// splitList splits a comma-separated flag value, dropping empty entries.
+func splitList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// stringsFlag collects repeated string flags.
+type stringsFlag []string
+
+// String joins the collected values.
+func (f *stringsFlag) String() string {
+	return strings.Join(*f, ",")
+}
+
+// Set appends a value.
+func (f *stringsFlag) Set(value string) error {
+	*f = append(*f, value)
+	return nil
+}
@@ -0,0 +1,351 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/gbfs-validator-go/pkg/fetcher"
+	"github.com/gbfs-validator-go/pkg/validator"
+)
+
+// ANSI color codes used for terminal output.
+const (
+	colorReset  = "\033[0m"
+	colorRed    = "\033[31m"
+	colorGreen  = "\033[32m"
+	colorYellow = "\033[33m"
+	colorCyan   = "\033[36m"
+	colorGray   = "\033[90m"
+)
+
+// defaultUniqueIssues caps unique messages per file at the default verbosity.
+const defaultUniqueIssues = 10
+
+// textPrinter renders validation results for humans.
+type textPrinter struct {
+	w         io.Writer
+	verbosity int
+	quiet     bool
+	color     bool
+}
+
+// newTextPrinter constructs a textPrinter.
+func newTextPrinter(w io.Writer, verbosity int, quiet, color bool) *textPrinter {
+	return &textPrinter{
+		w:         w,
+		verbosity: verbosity,
+		quiet:     quiet,
+		color:     color,
+	}
+}
+
+// isTerminal reports whether f is attached to a terminal.
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+// paint wraps text in a color code when color output is enabled.
+func (p *textPrinter) paint(color, text string) string {
+	if !p.color {
+		return text
+	}
+	return color + text + colorReset
+}
+
+// severityColor returns the color for a severity.
+func severityColor(severity validator.ValidationSeverity) string {
+	switch severity {
+	case validator.SeverityError:
+		return colorRed
+	case validator.SeverityWarning:
+		return colorYellow
+	default:
+		return colorCyan
+	}
+}
+
+// header prints the banner shown before validation starts.
+func (p *textPrinter) header(feedURL string, opts validator.Options) {
+	if p.quiet {
+		return
+	}
+	if len(opts.FeedURLs) > 0 {
+		fmt.Fprintf(p.w, "Validating %d feed URLs without gbfs.json\n", len(opts.FeedURLs))
+	} else {
+		fmt.Fprintf(p.w, "Validating GBFS feed: %s\n", feedURL)
+	}
+	if opts.LenientMode {
+		fmt.Fprintln(p.w, "Mode: LENIENT (data coercion enabled)")
+	}
+	fmt.Fprintln(p.w, "================================")
+}
+
+// report prints the summary and, unless quiet, per-file details.
+func (p *textPrinter) report(result *validator.ValidationResult, opts validator.Options) {
+	if p.quiet {
+		p.summaryLine(result)
+		return
+	}
+
+	fmt.Fprintf(p.w, "\nVersion: detected=%s, validated=%s\n",
+		result.Summary.Version.Detected,
+		result.Summary.Version.Validated)
+
+	if result.Summary.HasErrors {
+		fmt.Fprintf(p.w, "Status: %s (%d errors, %d warnings)\n", p.paint(colorRed, "INVALID"), result.Summary.ErrorsCount, result.Summary.WarningsCount)
+	} else {
+		fmt.Fprintf(p.w, "Status: %s\n", p.paint(colorGreen, "VALID"))
+	}
+	if result.Summary.Incomplete {
+		fmt.Fprintf(p.w, "%s: the deadline passed before every file was checked\n", p.paint(colorYellow, "INCOMPLETE"))
+	}
+	if result.Summary.Sampled {
+		fmt.Fprintf(p.w, "%s: only some entries of large arrays were checked\n", p.paint(colorYellow, "SAMPLED"))
+	}
+
+	if len(result.Summary.Categories) > 0 {
+		fmt.Fprintln(p.w, "\nBy category:")
+		for _, category := range validator.ErrorCategories() {
+			c, ok := result.Summary.Categories[category]
+			if !ok {
+				continue
+			}
+			fmt.Fprintf(p.w, "  %-16s %d errors, %d warnings, %d info\n", category, c.Errors, c.Warnings, c.Infos)
+		}
+	}
+
+	if opts.LenientMode && result.Summary.CoercionSummary != nil && result.Summary.CoercionSummary.TotalCoercions > 0 {
+		fmt.Fprintf(p.w, "\nCoercions applied: %d\n", result.Summary.CoercionSummary.TotalCoercions)
+	}
+
+	fmt.Fprintln(p.w, "\nFiles:")
+	for _, file := range result.Files {
+		p.file(file)
+	}
+
+	if len(result.RulePacks) > 0 {
+		fmt.Fprintln(p.w, "\nRule packs:")
+		for _, pack := range result.RulePacks {
+			p.rulePack(pack)
+		}
+	}
+
+	if len(result.SuggestedGBFS) > 0 {
+		fmt.Fprintf(p.w, "\nSuggested gbfs.json:\n%s\n", result.SuggestedGBFS)
+	}
+}
+
+// plan prints the files and checks a run would involve.
+func (p *textPrinter) plan(plan validator.ValidationPlan) {
+	ver := plan.Version
+	if plan.VersionAssumed {
+		ver += " (assumed; the run validates the version gbfs.json declares)"
+	}
+	fmt.Fprintf(p.w, "Version: %s\nProfile: %s\n", ver, plan.Profile)
+	if plan.TreatWarningsAsErrors {
+		fmt.Fprintln(p.w, "Warnings fail the feed")
+	}
+	if plan.SampleSize > 0 {
+		which := "first"
+		if plan.SampleRandom {
+			which = "random"
+		}
+		fmt.Fprintf(p.w, "Sampling: the %s %d entries of each large array\n", which, plan.SampleSize)
+	}
+
+	fmt.Fprintln(p.w, "\nFiles:")
+	for _, f := range plan.Files {
+		requirement := p.paint(colorGray, "optional")
+		switch {
+		case f.Required:
+			requirement = p.paint(colorRed, "required")
+		case f.Recommended:
+			requirement = p.paint(colorYellow, "recommended")
+		}
+		fmt.Fprintf(p.w, "  %-24s %s\n", f.File, requirement)
+	}
+
+	fmt.Fprintln(p.w, "\nChecks:")
+	for _, c := range plan.Checks {
+		if c.Skipped != "" {
+			if p.verbosity >= 1 {
+				fmt.Fprintf(p.w, "  %s %-24s %s\n", p.paint(colorGray, "-"), c.ID, p.paint(colorGray, c.Skipped))
+			}
+			continue
+		}
+		fmt.Fprintf(p.w, "  %s %-24s %-8s %s\n", p.paint(colorGreen, "✓"), c.ID, p.paint(severityColor(c.Severity), string(c.Severity)), c.Description)
+	}
+
+	if len(plan.RulePacks) > 0 {
+		fmt.Fprintln(p.w, "\nRule packs:")
+		for _, pack := range plan.RulePacks {
+			fmt.Fprintf(p.w, "  %s\n", pack.Name)
+			for _, rule := range pack.Rules {
+				severity := rule.Severity
+				if severity == "" {
+					severity = validator.SeverityError
+				}
+				fmt.Fprintf(p.w, "      %-20s %-8s %s\n", rule.ID, p.paint(severityColor(severity), string(severity)), rule.Description)
+			}
+		}
+	}
+}
+
+// connections prints connection reuse in verbose mode.
+func (p *textPrinter) connections(stats fetcher.ConnStats) {
+	if p.quiet || p.verbosity < 1 || stats.Requests == 0 {
+		return
+	}
+	fmt.Fprintf(p.w, "\nConnections: %d requests, %d on reused connections, %d over HTTP/2\n",
+		stats.Requests, stats.Reused, stats.HTTP2)
+}
+
+// rulePack prints a rule pack's outcome and its failing rules.
+func (p *textPrinter) rulePack(pack validator.RulePackResult) {
+	status := p.paint(colorGreen, "✓ PASS")
+	if !pack.Passed {
+		status = p.paint(colorRed, "✗ FAIL")
+	}
+	fmt.Fprintf(p.w, "  %s %s\n", status, pack.Name)
+	for _, rule := range pack.Rules {
+		if rule.Passed {
+			if p.verbosity >= 1 {
+				fmt.Fprintf(p.w, "      %s %s\n", p.paint(colorGreen, "✓"), rule.ID)
+			}
+			continue
+		}
+		path := ""
+		if p.verbosity >= 1 {
+			path = strings.Join(rule.Examples, ", ")
+		}
+		p.issue(rule.Severity, rule.ID+": "+rule.Message, path, 1)
+	}
+}
+
+// summaryLine prints a single line describing the overall outcome.
+func (p *textPrinter) summaryLine(result *validator.ValidationResult) {
+	status := p.paint(colorGreen, "VALID")
+	if result.Summary.HasErrors {
+		status = p.paint(colorRed, "INVALID")
+	}
+	fmt.Fprintf(p.w, "%s: %d errors, %d warnings (version %s)\n", status, result.Summary.ErrorsCount, result.Summary.WarningsCount, result.Summary.Version.Validated)
+}
+
+// file prints one file's status line and its issues.
+func (p *textPrinter) file(file validator.FileValidationResult) {
+	status := p.paint(colorGreen, "✓")
+	if file.HasErrors {
+		status = p.paint(colorRed, "✗")
+	} else if file.Status == validator.FileStatusNotChecked {
+		status = p.paint(colorYellow, "? NOT CHECKED (timeout)")
+	} else if !file.Exists {
+		if file.Required {
+			status = p.paint(colorRed, "✗ MISSING (required)")
+		} else if file.Status == validator.FileStatusRecommendedMissing {
+			status = p.paint(colorYellow, "! MISSING (recommended)")
+		} else {
+			status = p.paint(colorGray, "- (optional, not present)")
+		}
+	}
+
+	coercionInfo := ""
+	if file.CoercionCount > 0 {
+		coercionInfo = fmt.Sprintf(" [%d coercions]", file.CoercionCount)
+	}
+
+	extensionInfo := ""
+	if len(file.Extensions) > 0 {
+		extensionInfo = fmt.Sprintf(" [%d extension fields]", len(file.Extensions))
+	}
+
+	failureInfo := ""
+	if file.FailureKind != "" && file.FailureKind != fetcher.FailureNotFound && file.Status != validator.FileStatusNotChecked {
+		failureInfo = p.paint(colorYellow, fmt.Sprintf(" [%s failure]", file.FailureKind))
+	}
+
+	timingInfo := ""
+	if p.verbosity >= 1 && file.Timing != nil {
+		timingInfo = p.paint(colorGray, fmt.Sprintf(" (fetch %dms, validate %dms)", file.Timing.FetchMs, file.Timing.ValidateMs))
+	}
+
+	fmt.Fprintf(p.w, "  %s %s%s%s%s%s\n", status, file.File, coercionInfo, extensionInfo, failureInfo, timingInfo)
+
+	switch {
+	case p.verbosity >= 2:
+		for _, e := range file.Errors {
+			p.issue(e.Severity, e.Message, e.InstancePath, 1)
+		}
+	case p.verbosity == 1:
+		p.uniqueIssues(file.Errors, false, 0)
+	case file.HasErrors:
+		p.uniqueIssues(file.Errors, true, defaultUniqueIssues)
+	}
+}
+
+// uniqueIssues prints issues grouped by message, optionally errors only and capped.
+func (p *textPrinter) uniqueIssues(issues []validator.ValidationError, errorsOnly bool, limit int) {
+	var order []validator.ValidationError
+	counts := make(map[string]int)
+	hidden := 0
+	for _, e := range issues {
+		if errorsOnly && e.Severity != validator.SeverityError {
+			hidden++
+			continue
+		}
+		key := string(e.Severity) + "|" + e.Message
+		if counts[key] == 0 {
+			order = append(order, e)
+		}
+		counts[key]++
+	}
+
+	for i, e := range order {
+		if limit > 0 && i >= limit {
+			fmt.Fprintf(p.w, "      ... and %d more unique issues (use -v to show all)\n", len(order)-limit)
+			break
+		}
+		p.issue(e.Severity, e.Message, "", counts[string(e.Severity)+"|"+e.Message])
+	}
+
+	if hidden > 0 {
+		fmt.Fprintf(p.w, "      %s\n", p.paint(colorGray, fmt.Sprintf("(%d warnings/info hidden, use -v to show)", hidden)))
+	}
+}
+
+// issue prints a single issue line.
+func (p *textPrinter) issue(severity validator.ValidationSeverity, message, path string, count int) {
+	line := fmt.Sprintf("      %s: %s", p.paint(severityColor(severity), string(severity)), message)
+	if path != "" {
+		line += p.paint(colorGray, " at "+path)
+	}
+	if count > 1 {
+		line += fmt.Sprintf(" (x%d)", count)
+	}
+	fmt.Fprintln(p.w, line)
+}
+
+// progressWidth is the number of cells in the progress bar.
+const progressWidth = 30
+
+// progressBar returns a progress callback that redraws a bar on w.
+func progressBar(w io.Writer) func(validator.ProgressEvent) {
+	return func(e validator.ProgressEvent) {
+		filled := 0
+		if e.Total > 0 {
+			filled = e.Done * progressWidth / e.Total
+		}
+		bar := strings.Repeat("=", filled) + strings.Repeat(" ", progressWidth-filled)
+		fmt.Fprintf(w, "\r\033[K[%s] %d/%d %s", bar, e.Done, e.Total, e.File)
+	}
+}
+
+// clearProgress erases the progress bar line.
+func clearProgress(w io.Writer) {
+	fmt.Fprint(w, "\r\033[K")
+}
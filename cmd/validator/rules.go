@@ -0,0 +1,60 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/gbfs-validator-go/pkg/validator"
+	"github.com/gbfs-validator-go/pkg/version"
+)
+
+// setupRules registers flags for the rules command.
+func setupRules(fs *flag.FlagSet) func() {
+	ver := fs.String("version", "", "List only the checks that apply to this GBFS version")
+	format := fs.String("format", "text", "Output format (text, json)")
+	return func() {
+		if *ver != "" {
+			if _, ok := version.GetConfig(*ver); !ok {
+				log.Fatalf("rules: unknown version %s", *ver)
+			}
+		}
+		var rules []validator.Check
+		for _, c := range validator.Checks() {
+			if *ver == "" || c.AppliesTo(*ver) {
+				rules = append(rules, c)
+			}
+		}
+		if *format == "json" {
+			writeJSON(rules)
+			return
+		}
+
+		tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "ID\tSEVERITY\tVERSIONS\tFILES\tDESCRIPTION")
+		for _, c := range rules {
+			files := strings.Join(c.Files, ",")
+			if files == "" {
+				files = "all"
+			}
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", c.ID, c.Severity, checkVersions(c), files, c.Description)
+		}
+		tw.Flush()
+	}
+}
+
+// checkVersions describes the versions a check applies to.
+func checkVersions(c validator.Check) string {
+	switch {
+	case c.MinVersion != "" && c.BeforeVersion != "":
+		return c.MinVersion + " to before " + c.BeforeVersion
+	case c.MinVersion != "":
+		return c.MinVersion + "+"
+	case c.BeforeVersion != "":
+		return "before " + c.BeforeVersion
+	}
+	return "all"
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/gbfs-validator-go/pkg/fetcher"
+	"github.com/gbfs-validator-go/pkg/scaffold"
+	"github.com/gbfs-validator-go/pkg/version"
+)
+
+// setupScaffoldGBFS registers flags for the scaffold-gbfs command.
+func setupScaffoldGBFS(fs *flag.FlagSet) func() {
+	url := fs.String("url", "", "Base URL to probe for feed files")
+	ver := fs.String("version", "", "GBFS version to emit (default: from system_information, else 3.0)")
+	lang := fs.String("language", "", "Language block for v1/v2 output (default: from system_information, else en)")
+	output := fs.String("o", "", "Write gbfs.json to this file instead of stdout")
+	return func() {
+		if *url == "" {
+			log.Fatal("scaffold-gbfs: -url is required")
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+		defer cancel()
+
+		d := scaffold.Probe(ctx, fetcher.New(), *url, *ver)
+		if len(d.Feeds) == 0 {
+			log.Fatalf("scaffold-gbfs: no feed files found under %s", *url)
+		}
+		if d.Version == "" {
+			d.Version = "3.0"
+		}
+		if *lang != "" {
+			d.Language = *lang
+		}
+
+		names := make([]string, 0, len(d.Feeds))
+		for name := range d.Feeds {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		fmt.Fprintf(os.Stderr, "Discovered %d files for GBFS %s: %v\n", len(names), d.Version, names)
+
+		data, err := scaffold.GBFS(d.Feeds, d.Version, d.Language, fetcher.BuildFeedURL(*url, "gbfs"), time.Now())
+		if err != nil {
+			log.Fatalf("scaffold-gbfs: %v", err)
+		}
+		data = append(data, '\n')
+
+		if *output == "" {
+			os.Stdout.Write(data)
+			return
+		}
+		if err := os.WriteFile(*output, data, 0o644); err != nil {
+			log.Fatalf("scaffold-gbfs: %v", err)
+		}
+	}
+}
+
+// setupScaffoldFeed registers flags for the scaffold-feed command.
+func setupScaffoldFeed(fs *flag.FlagSet) func() {
+	ver := fs.String("version", "3.0", "GBFS version to generate")
+	baseURL := fs.String("base-url", "https://example.com/gbfs", "Base URL the files will be published under, used in gbfs.json")
+	docked := fs.Bool("docked", false, "Generate station-based (docked) files")
+	freefloating := fs.Bool("freefloating", false, "Generate free-floating vehicle files")
+	dir := fs.String("dir", "gbfs-skeleton", "Directory to write files to")
+	force := fs.Bool("force", false, "Overwrite existing files")
+	return func() {
+		files, err := scaffold.Skeleton(*ver, *baseURL, version.Options{Docked: *docked, Freefloating: *freefloating}, time.Now())
+		if err != nil {
+			log.Fatalf("scaffold-feed: %v", err)
+		}
+
+		if err := os.MkdirAll(*dir, 0o755); err != nil {
+			log.Fatalf("scaffold-feed: %v", err)
+		}
+
+		names := make([]string, 0, len(files))
+		for name := range files {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		if !*force {
+			for _, name := range names {
+				path := filepath.Join(*dir, name)
+				if _, err := os.Stat(path); err == nil {
+					log.Fatalf("scaffold-feed: %s already exists (use -force to overwrite)", path)
+				}
+			}
+		}
+
+		for _, name := range names {
+			path := filepath.Join(*dir, name)
+			if err := os.WriteFile(path, append(files[name], '\n'), 0o644); err != nil {
+				log.Fatalf("scaffold-feed: %v", err)
+			}
+			fmt.Fprintf(os.Stderr, "wrote %s\n", path)
+		}
+	}
+}
@@ -0,0 +1,199 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gbfs-validator-go/pkg/fetcher"
+	"github.com/gbfs-validator-go/pkg/validator"
+)
+
+// tui holds interactive session state.
+type tui struct {
+	feedURL  string
+	opts     validator.Options
+	out      *textPrinter
+	result   *validator.ValidationResult
+	selected int
+	severity validator.ValidationSeverity
+	elapsed  time.Duration
+}
+
+// setupTUI registers flags for the tui command.
+func setupTUI(fs *flag.FlagSet) func() {
+	url, options := feedFlags(fs)
+	return func() {
+		if *url == "" {
+			log.Fatal("tui: -url is required")
+		}
+
+		t := &tui{
+			feedURL: *url,
+			opts:    options(),
+			out:     newTextPrinter(os.Stdout, 2, false, os.Getenv("NO_COLOR") == "" && isTerminal(os.Stdout)),
+		}
+
+		t.rerun()
+		t.loop(os.Stdin)
+	}
+}
+
+// rerun validates the feed again and resets the selection if needed.
+func (t *tui) rerun() {
+	fmt.Fprintf(t.out.w, "Validating %s ...\n", t.feedURL)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+
+	start := time.Now()
+	result, err := validator.New(fetcher.New(), t.opts).Validate(ctx, t.feedURL)
+	if err != nil {
+		fmt.Fprintf(t.out.w, "Validation failed: %v\n", err)
+		return
+	}
+	t.elapsed = time.Since(start)
+	t.result = result
+	if t.selected >= len(result.Files) {
+		t.selected = 0
+	}
+}
+
+// loop reads commands until quit or EOF.
+func (t *tui) loop(in io.Reader) {
+	scanner := bufio.NewScanner(in)
+	t.render()
+	for {
+		fmt.Fprint(t.out.w, "\n> ")
+		if !scanner.Scan() {
+			return
+		}
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			t.render()
+			continue
+		}
+
+		switch fields[0] {
+		case "q", "quit", "exit":
+			return
+		case "r", "rerun":
+			t.rerun()
+		case "n", "next":
+			t.move(1)
+		case "p", "prev":
+			t.move(-1)
+		case "f", "filter":
+			t.filter(fields[1:])
+		case "h", "help", "?":
+			t.help()
+			continue
+		default:
+			if n, err := strconv.Atoi(fields[0]); err == nil {
+				t.selectFile(n)
+			} else {
+				fmt.Fprintf(t.out.w, "unknown command %q (type help)\n", fields[0])
+				continue
+			}
+		}
+		t.render()
+	}
+}
+
+// move shifts the selected file by delta, wrapping around.
+func (t *tui) move(delta int) {
+	if t.result == nil || len(t.result.Files) == 0 {
+		return
+	}
+	n := len(t.result.Files)
+	t.selected = ((t.selected+delta)%n + n) % n
+}
+
+// selectFile selects a file by its 1-based index in the file pane.
+func (t *tui) selectFile(n int) {
+	if t.result == nil || n < 1 || n > len(t.result.Files) {
+		return
+	}
+	t.selected = n - 1
+}
+
+// filter sets the severity shown in the error pane.
+func (t *tui) filter(args []string) {
+	if len(args) == 0 || args[0] == "all" {
+		t.severity = ""
+		return
+	}
+	t.severity = validator.ValidationSeverity(args[0])
+}
+
+// help prints the command reference.
+func (t *tui) help() {
+	fmt.Fprintln(t.out.w, "Commands:")
+	fmt.Fprintln(t.out.w, "  <n>                  select file n")
+	fmt.Fprintln(t.out.w, "  n, next / p, prev    move selection")
+	fmt.Fprintln(t.out.w, "  f, filter <severity> show only error, warning, info, or all")
+	fmt.Fprintln(t.out.w, "  r, rerun             validate the feed again")
+	fmt.Fprintln(t.out.w, "  q, quit              exit")
+}
+
+// render redraws the file, error, and coercion panes.
+func (t *tui) render() {
+	if t.out.color {
+		fmt.Fprint(t.out.w, "\033[H\033[2J")
+	}
+	if t.result == nil {
+		fmt.Fprintln(t.out.w, "No results yet. Type rerun to try again.")
+		return
+	}
+
+	p := t.out
+	fmt.Fprintf(p.w, "%s  version %s  (%s)\n", t.feedURL, t.result.Summary.Version.Validated, t.elapsed.Round(time.Millisecond))
+	p.summaryLine(t.result)
+
+	pane(p.w, "Files")
+	for i, file := range t.result.Files {
+		marker := " "
+		if i == t.selected {
+			marker = ">"
+		}
+		fmt.Fprintf(p.w, "%s %2d  %-20s %-28s %d issues\n", marker, i+1, file.Status, file.File, len(file.Errors))
+	}
+
+	file := t.result.Files[t.selected]
+	filterName := "all"
+	if t.severity != "" {
+		filterName = string(t.severity)
+	}
+	pane(p.w, fmt.Sprintf("Errors: %s [%s]", file.File, filterName))
+	shown := 0
+	for _, e := range file.Errors {
+		if t.severity != "" && e.Severity != t.severity {
+			continue
+		}
+		p.issue(e.Severity, e.Message, e.InstancePath, 1)
+		shown++
+	}
+	if shown == 0 {
+		fmt.Fprintln(p.w, "      (none)")
+	}
+
+	pane(p.w, fmt.Sprintf("Coercions: %s", file.File))
+	if len(file.Coercions) == 0 {
+		fmt.Fprintln(p.w, "      (none)")
+	}
+	for _, c := range file.Coercions {
+		fmt.Fprintf(p.w, "      %s/%s: %v (%s) -> %v (%s)\n", c.Path, c.Field, c.From, c.FromType, c.To, c.ToType)
+	}
+}
+
+// pane prints a pane heading.
+func pane(w io.Writer, title string) {
+	fmt.Fprintf(w, "\n── %s %s\n", title, strings.Repeat("─", max(0, 60-len(title))))
+}
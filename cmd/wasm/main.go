@@ -0,0 +1,64 @@
+//go:build js && wasm
+
+// Command wasm exposes the validation core to JavaScript, so the viewer can
+// validate feed files without them leaving the browser. Build it with
+//
+//	GOOS=js GOARCH=wasm go build -o web/validator.wasm ./cmd/wasm
+//	cp "$(go env GOROOT)/lib/wasm/wasm_exec.js" web/js/
+//
+// (wasm_exec.js is in misc/wasm before Go 1.24.)
+//
+// It registers gbfsValidate(files, options): files maps file names to their
+// JSON text, options is validator.Options as JSON, and the result is a
+// ValidationResult as JSON, or {"error": ...}.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"syscall/js"
+
+	"github.com/gbfs-validator-go/pkg/validator"
+)
+
+// main registers gbfsValidate and keeps the module alive for later calls.
+func main() {
+	js.Global().Set("gbfsValidate", js.FuncOf(validate))
+	select {}
+}
+
+// validate is the JavaScript entry point.
+func validate(this js.Value, args []js.Value) interface{} {
+	if len(args) == 0 || args[0].Type() != js.TypeObject {
+		return errorJSON("gbfsValidate expects an object of file names to JSON text")
+	}
+	docs := make(map[string][]byte)
+	keys := js.Global().Get("Object").Call("keys", args[0])
+	for i := 0; i < keys.Length(); i++ {
+		name := keys.Index(i).String()
+		docs[name] = []byte(args[0].Get(name).String())
+	}
+
+	var opts validator.Options
+	if len(args) > 1 && args[1].Type() == js.TypeString {
+		if err := json.Unmarshal([]byte(args[1].String()), &opts); err != nil {
+			return errorJSON("invalid options: " + err.Error())
+		}
+	}
+
+	result, err := validator.NewOffline(opts).ValidateDocuments(context.Background(), docs)
+	if err != nil {
+		return errorJSON(err.Error())
+	}
+	data, err := json.Marshal(result)
+	if err != nil {
+		return errorJSON(err.Error())
+	}
+	return string(data)
+}
+
+// errorJSON formats an error the way the API reports one.
+func errorJSON(message string) string {
+	data, _ := json.Marshal(map[string]string{"error": message})
+	return string(data)
+}
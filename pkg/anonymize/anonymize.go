@@ -0,0 +1,205 @@
+// Package anonymize rewrites a snapshot of a GBFS feed so it can be shared
+// publicly: coordinates are jittered, IDs randomized, and contact details
+// replaced, while the structure and the validation findings of the feed are
+// preserved.
+package anonymize
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/gbfs-validator-go/pkg/fetcher"
+	"github.com/gbfs-validator-go/pkg/scaffold"
+)
+
+// metersPerDegree is the length of a degree of latitude.
+const metersPerDegree = 111320
+
+// Config selects how a feed is anonymized.
+type Config struct {
+	// Seed keys the rewriting; equal seeds rewrite a feed equally. Anyone
+	// who knows the seed can test guesses of the original values, so keep
+	// it secret or random.
+	Seed int64
+	// JitterMeters bounds how far a coordinate moves.
+	JitterMeters float64
+}
+
+// DefaultConfig returns a config that moves coordinates up to 150 meters.
+func DefaultConfig() Config {
+	return Config{JitterMeters: 150}
+}
+
+// contactFields are fields holding contact details, with their
+// replacements.
+var contactFields = map[string]string{
+	"email":              "contact@example.com",
+	"feed_contact_email": scaffold.PlaceholderEmail,
+	"phone_number":       "+15555550100",
+}
+
+// Anonymize rewrites every file of a feed, keyed by file name. An ID is
+// rewritten to the same value in every file, so references between files
+// resolve, or fail to, as before. Values keep their JSON type, so a number
+// sent as a string stays a string, and files that do not parse are
+// returned unchanged.
+func Anonymize(files map[string][]byte, cfg Config) map[string][]byte {
+	a := &anonymizer{cfg: cfg}
+	binary.BigEndian.PutUint64(a.seed[:], uint64(cfg.Seed))
+	out := make(map[string][]byte, len(files))
+	for name, data := range files {
+		out[name] = a.file(data)
+	}
+	return out
+}
+
+// anonymizer holds the state shared by the files of one feed.
+type anonymizer struct {
+	cfg  Config
+	seed [8]byte
+}
+
+// file rewrites one document.
+func (a *anonymizer) file(data []byte) []byte {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var doc interface{}
+	if dec.Decode(&doc) != nil || dec.More() {
+		return data
+	}
+	doc = a.value("", doc)
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if enc.Encode(doc) != nil {
+		return data
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n"))
+}
+
+// value rewrites v, found under the object key key.
+func (a *anonymizer) value(key string, v interface{}) interface{} {
+	switch {
+	case strings.HasSuffix(key, "_id"):
+		return a.id(v)
+	case strings.HasSuffix(key, "_ids"):
+		if list, ok := v.([]interface{}); ok {
+			for i := range list {
+				list[i] = a.id(list[i])
+			}
+			return list
+		}
+		return a.id(v)
+	case key == "lat" || key == "lon":
+		return a.coordinate(key, v)
+	case key == "coordinates":
+		return a.coordinates(v)
+	case key == "url" || strings.HasSuffix(key, "_url"):
+		if s, ok := v.(string); ok {
+			return fetcher.RedactURL(s)
+		}
+	}
+	if placeholder, ok := contactFields[key]; ok {
+		if s, ok := v.(string); ok && s != "" {
+			return placeholder
+		}
+		return v
+	}
+
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, child := range v {
+			v[k] = a.value(k, child)
+		}
+	case []interface{}:
+		for i, child := range v {
+			v[i] = a.value(key, child)
+		}
+	}
+	return v
+}
+
+// id rewrites an ID, keeping its JSON type. Empty strings stay empty so
+// that findings about them remain.
+func (a *anonymizer) id(v interface{}) interface{} {
+	switch v := v.(type) {
+	case string:
+		if v == "" {
+			return v
+		}
+		return strconv.FormatUint(a.hash("id", v)>>16, 36)
+	case json.Number:
+		return json.Number(strconv.FormatUint(a.hash("id", v.String())%1e9, 10))
+	}
+	return v
+}
+
+// coordinates rewrites the positions of a GeoJSON geometry, which are
+// [lon, lat] arrays at some depth.
+func (a *anonymizer) coordinates(v interface{}) interface{} {
+	list, ok := v.([]interface{})
+	if !ok {
+		return v
+	}
+	if len(list) >= 2 {
+		if _, ok := list[0].(json.Number); ok {
+			list[0] = a.coordinate("lon", list[0])
+			list[1] = a.coordinate("lat", list[1])
+			return list
+		}
+	}
+	for i := range list {
+		list[i] = a.coordinates(list[i])
+	}
+	return list
+}
+
+// coordinate moves a latitude or longitude by up to JitterMeters. Equal
+// values move equally, so vehicles parked at one spot stay together and
+// polygons stay closed. A coordinate sent as a string stays a string, the
+// number of decimals is kept, and zero, a common placeholder, is left
+// alone.
+func (a *anonymizer) coordinate(axis string, v interface{}) interface{} {
+	var text string
+	switch v := v.(type) {
+	case json.Number:
+		text = v.String()
+	case string:
+		text = strings.TrimSpace(v)
+	default:
+		return v
+	}
+	f, err := strconv.ParseFloat(text, 64)
+	if err != nil || f == 0 {
+		return v
+	}
+	decimals := 0
+	if strings.ContainsAny(text, "eE") {
+		decimals = -1
+	} else if i := strings.IndexByte(text, '.'); i >= 0 {
+		decimals = len(text) - i - 1
+	}
+	unit := float64(a.hash(axis, text)) / math.MaxUint64
+	f += (2*unit - 1) * a.cfg.JitterMeters / metersPerDegree
+	moved := strconv.FormatFloat(f, 'f', decimals, 64)
+	if _, ok := v.(string); ok {
+		return moved
+	}
+	return json.Number(moved)
+}
+
+// hash keys a value of one kind with the seed.
+func (a *anonymizer) hash(kind, value string) uint64 {
+	h := sha256.New()
+	h.Write(a.seed[:])
+	h.Write([]byte(kind))
+	h.Write([]byte{0})
+	h.Write([]byte(value))
+	return binary.BigEndian.Uint64(h.Sum(nil))
+}
@@ -0,0 +1,76 @@
+package anonymize
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gbfs-validator-go/pkg/scaffold"
+	"github.com/gbfs-validator-go/pkg/validator"
+	"github.com/gbfs-validator-go/pkg/version"
+)
+
+func TestAnonymize(t *testing.T) {
+	files, err := scaffold.Skeleton("2.3", "https://example.com/?token=s3cret", version.Options{}, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	files["station_status.json"] = []byte(strings.Replace(string(files["station_status.json"]), `"is_renting":true`, `"is_renting":"1"`, 1))
+	files["free_bike_status.json"] = []byte(`{"last_updated":1700000000,"ttl":0,"version":"2.3","data":{"bikes":[` +
+		`{"bike_id":"b1","lat":48.85661,"lon":2.35222,"is_reserved":false,"is_disabled":false,"vehicle_type_id":"missing"},` +
+		`{"bike_id":"b2","lat":48.85661,"lon":"2.35222","is_reserved":false,"is_disabled":false}]}}`)
+	files["broken.json"] = []byte(`{"data":`)
+
+	out := Anonymize(files, Config{Seed: 7, JitterMeters: 100})
+	if string(out["broken.json"]) != `{"data":` {
+		t.Errorf("unparseable file changed: %s", out["broken.json"])
+	}
+	for _, name := range []string{"gbfs.json", "system_information.json"} {
+		if text := string(out[name]); strings.Contains(text, "s3cret") || strings.Contains(text, "example_system") {
+			t.Errorf("%s leaks: %s", name, text)
+		}
+	}
+
+	var bikes struct {
+		Data struct {
+			Bikes []map[string]interface{} `json:"bikes"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(out["free_bike_status.json"], &bikes); err != nil {
+		t.Fatal(err)
+	}
+	b1, b2 := bikes.Data.Bikes[0], bikes.Data.Bikes[1]
+	if b1["bike_id"] == "b1" || b1["vehicle_type_id"] == "missing" {
+		t.Errorf("IDs kept: %v", b1)
+	}
+	lat := b1["lat"].(float64)
+	if lat == 48.85661 || math.Abs(lat-48.85661) > 100.0/metersPerDegree || lat != b2["lat"] {
+		t.Errorf("lat %v, %v", lat, b2["lat"])
+	}
+	if _, ok := b2["lon"].(string); !ok {
+		t.Errorf("string lon became %T", b2["lon"])
+	}
+
+	if again := Anonymize(files, Config{Seed: 7, JitterMeters: 100}); string(again["station_status.json"]) != string(out["station_status.json"]) {
+		t.Error("equal seeds rewrote differently")
+	}
+
+	delete(files, "broken.json")
+	delete(out, "broken.json")
+	v := validator.NewOffline(validator.Options{})
+	before, err := v.ValidateDocuments(context.Background(), files)
+	if err != nil {
+		t.Fatal(err)
+	}
+	after, err := v.ValidateDocuments(context.Background(), out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if before.Summary.ErrorsCount == 0 || before.Summary.ErrorsCount != after.Summary.ErrorsCount ||
+		before.Summary.WarningsCount != after.Summary.WarningsCount {
+		t.Errorf("findings changed: %+v, then %+v", before.Summary, after.Summary)
+	}
+}
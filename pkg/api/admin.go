@@ -0,0 +1,92 @@
+package api
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gbfs-validator-go/pkg/archive"
+)
+
+// SetAdminToken enables the admin endpoints for requests carrying
+// "Authorization: Bearer <token>". They are disabled while it is empty.
+func (s *Server) SetAdminToken(token string) {
+	s.adminToken = token
+}
+
+// bearerToken returns the request's bearer token, if any.
+func bearerToken(r *http.Request) string {
+	token, _ := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return token
+}
+
+// isAdmin reports whether the request carries the admin token.
+func (s *Server) isAdmin(r *http.Request) bool {
+	token := bearerToken(r)
+	return s.adminToken != "" && token != "" && subtle.ConstantTimeCompare([]byte(token), []byte(s.adminToken)) == 1
+}
+
+// authorizeAdmin checks the admin token, writing an error response if it
+// does not match.
+func (s *Server) authorizeAdmin(w http.ResponseWriter, r *http.Request) bool {
+	if s.adminToken == "" {
+		respondError(w, http.StatusForbidden, "admin API is disabled")
+		return false
+	}
+	if !s.isAdmin(r) {
+		respondError(w, http.StatusUnauthorized, "invalid admin token")
+		return false
+	}
+	return true
+}
+
+// PruneRequest overrides the archive's retention policy for one prune.
+type PruneRequest struct {
+	KeepRuns int `json:"keepRuns,omitempty"`
+	// MaxAge is a duration such as "30d" or "12h".
+	MaxAge string `json:"maxAge,omitempty"`
+}
+
+// handlePrune applies a retention policy to the archive.
+func (s *Server) handlePrune(w http.ResponseWriter, r *http.Request) {
+	if !s.authorizeAdmin(w, r) {
+		return
+	}
+	if s.archive == nil {
+		respondError(w, http.StatusNotFound, "no archive configured")
+		return
+	}
+
+	var req PruneRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			respondError(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+	}
+	policy := s.archive.Retention()
+	if req.KeepRuns > 0 {
+		policy.KeepRuns = req.KeepRuns
+	}
+	if req.MaxAge != "" {
+		age, err := archive.ParseAge(req.MaxAge)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		policy.MaxAge = age
+	}
+	if policy.IsZero() {
+		respondError(w, http.StatusBadRequest, "no retention policy configured or given")
+		return
+	}
+
+	report, err := s.archive.Prune(r.Context(), policy, time.Now())
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	respondJSON(w, http.StatusOK, report)
+}
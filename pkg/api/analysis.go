@@ -0,0 +1,155 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/gbfs-validator-go/pkg/compare"
+	"github.com/gbfs-validator-go/pkg/coverage"
+	"github.com/gbfs-validator-go/pkg/fetcher"
+	"github.com/gbfs-validator-go/pkg/gbfsclient"
+	"github.com/gbfs-validator-go/pkg/mds"
+	"github.com/gbfs-validator-go/pkg/validator"
+)
+
+// maxCompareFeeds caps how many feeds one comparison validates.
+const maxCompareFeeds = 20
+
+// CompareRequest lists the feeds to compare; Options apply to all of them.
+type CompareRequest struct {
+	URLs    []string         `json:"urls"`
+	Options *ValidateOptions `json:"options,omitempty"`
+}
+
+// loadFeed decodes a ValidateRequest and loads every file of its feed,
+// writing an error response on failure.
+func (s *Server) loadFeed(w http.ResponseWriter, r *http.Request) (*gbfsclient.Client, bool) {
+	var req ValidateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return nil, false
+	}
+	recordRequest(r, req)
+	return s.openFeed(w, r, req)
+}
+
+// openFeed loads every file of the requested feed, writing an error
+// response on failure.
+func (s *Server) openFeed(w http.ResponseWriter, r *http.Request, req ValidateRequest) (*gbfsclient.Client, bool) {
+	if req.URL == "" {
+		respondError(w, http.StatusBadRequest, "URL is required")
+		return nil, false
+	}
+
+	c := gbfsclient.New(req.URL, gbfsclient.WithFetcher(s.newFetcher(req.Options)))
+	if err := c.Load(r.Context()); err != nil && c.Autodiscovery() == nil {
+		respondError(w, http.StatusBadGateway, err.Error())
+		return nil, false
+	}
+	return c, true
+}
+
+// MDSCheckRequest names a GBFS feed and the operator's MDS /vehicles
+// endpoint to cross-check it against.
+type MDSCheckRequest struct {
+	ValidateRequest
+	MDS MDSSource `json:"mds"`
+}
+
+// MDSSource locates an MDS provider /vehicles endpoint.
+type MDSSource struct {
+	URL string `json:"url"`
+	// Version selects the Accept header; it defaults to 2.0.
+	Version          string              `json:"version,omitempty"`
+	Auth             *fetcher.AuthConfig `json:"auth,omitempty"`
+	TolerancePercent float64             `json:"tolerancePercent,omitempty"`
+}
+
+// handleMDSCheck compares a feed's fleet with an MDS /vehicles snapshot.
+func (s *Server) handleMDSCheck(w http.ResponseWriter, r *http.Request) {
+	var req MDSCheckRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	recordRequest(r, req.ValidateRequest)
+	if req.MDS.URL == "" {
+		respondError(w, http.StatusBadRequest, "mds.url is required")
+		return
+	}
+	c, ok := s.openFeed(w, r, req.ValidateRequest)
+	if !ok {
+		return
+	}
+
+	version := req.MDS.Version
+	if version == "" {
+		version = "2.0"
+	}
+	f := s.newFetcher(&ValidateOptions{
+		Auth:    req.MDS.Auth,
+		Headers: map[string]string{"Accept": mds.AcceptHeader(version)},
+	})
+	vehicles, err := mds.Fetch(r.Context(), f, req.MDS.URL)
+	if err != nil {
+		respondError(w, http.StatusBadGateway, err.Error())
+		return
+	}
+	respondJSON(w, http.StatusOK, mds.CrossCheck(c.VehicleStatus(), c.StationStatus(), c.VehicleTypes(), vehicles, req.MDS.TolerancePercent))
+}
+
+// handleCoverage compares a feed's geofencing zones with the positions of
+// its stations and vehicles.
+func (s *Server) handleCoverage(w http.ResponseWriter, r *http.Request) {
+	c, ok := s.loadFeed(w, r)
+	if !ok {
+		return
+	}
+	respondJSON(w, http.StatusOK, coverage.Analyze(c.GeofencingZones(), c.StationInformation(), c.VehicleStatus()))
+}
+
+// handleCompare validates several feeds and returns a comparison table.
+func (s *Server) handleCompare(w http.ResponseWriter, r *http.Request) {
+	var req CompareRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if len(req.URLs) < 2 || len(req.URLs) > maxCompareFeeds {
+		respondError(w, http.StatusBadRequest, fmt.Sprintf("urls must list between 2 and %d feeds", maxCompareFeeds))
+		return
+	}
+	if err := checkRulePacks(req.Options); err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := checkTimeouts(req.Options); err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := checkSeverities(req.Options); err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := checkFeedURLOverrides(req.Options); err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	sl, ok := s.acquireSlot(r.Context(), w)
+	if !ok {
+		return
+	}
+	defer sl.release()
+
+	opts := s.validatorOptions(req.Options)
+	opts.FeedURLs = nil
+	report := compare.Feeds(r.Context(), req.URLs, func(ctx context.Context, url string) (*validator.ValidationResult, error) {
+		ctx, cancel := s.validationContext(ctx, req.Options)
+		defer cancel()
+		return validator.New(s.newFetcher(req.Options), opts).Validate(ctx, url)
+	})
+	respondJSON(w, http.StatusOK, report)
+}
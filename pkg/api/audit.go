@@ -0,0 +1,411 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gbfs-validator-go/pkg/archive"
+	"github.com/gbfs-validator-go/pkg/fetcher"
+	"github.com/gbfs-validator-go/pkg/validator"
+)
+
+// auditQueue is how many audit entries may wait for the sink; entries
+// beyond it are dropped and logged rather than holding up responses.
+const auditQueue = 1024
+
+// AuditEntry records one validation request.
+type AuditEntry struct {
+	Time       time.Time        `json:"time"`
+	RemoteIP   string           `json:"remoteIp"`
+	Method     string           `json:"method"`
+	Path       string           `json:"path"`
+	URL        string           `json:"url,omitempty"`
+	Options    *ValidateOptions `json:"options,omitempty"`
+	DurationMs int64            `json:"durationMs"`
+	Status     int              `json:"status"`
+	// Outcome is "valid" or "invalid" for validations, "ok" for other
+	// successful requests, and "error" otherwise.
+	Outcome string `json:"outcome"`
+}
+
+// AuditSink stores audit entries. Implementations must be safe for
+// concurrent use.
+type AuditSink interface {
+	WriteAudit(entry AuditEntry) error
+}
+
+// JSONAuditSink writes audit entries as JSON lines.
+type JSONAuditSink struct {
+	mu sync.Mutex
+	w  io.Writer
+	c  io.Closer
+}
+
+// NewJSONAuditSink writes audit entries to w, e.g. os.Stdout.
+func NewJSONAuditSink(w io.Writer) *JSONAuditSink {
+	return &JSONAuditSink{w: w}
+}
+
+// OpenAuditFile appends audit entries to the file at path.
+func OpenAuditFile(path string) (*JSONAuditSink, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	return &JSONAuditSink{w: f, c: f}, nil
+}
+
+// WriteAudit writes one entry as a line of JSON.
+func (s *JSONAuditSink) WriteAudit(entry AuditEntry) error {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, err = s.w.Write(append(line, '\n'))
+	return err
+}
+
+// Close closes the underlying file, if any.
+func (s *JSONAuditSink) Close() error {
+	if s.c == nil {
+		return nil
+	}
+	return s.c.Close()
+}
+
+// auditWriteTimeout bounds the database write of one audit entry.
+const auditWriteTimeout = 5 * time.Second
+
+// SQLAuditSink writes audit entries to the gbfs_audit table of a SQLite or
+// Postgres database, which may be the one the results store uses.
+type SQLAuditSink struct {
+	db      *sql.DB
+	dialect archive.Dialect
+}
+
+// IsAuditDB reports whether the audit log uri names a database rather
+// than a file.
+func IsAuditDB(uri string) bool {
+	for _, prefix := range []string{"sqlite:", "postgres://", "postgresql://"} {
+		if strings.HasPrefix(uri, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// OpenAuditDB writes audit entries to the sqlite: or postgres:// database
+// of uri, as accepted by archive.OpenStore, applying the schema migrations
+// it needs.
+func OpenAuditDB(uri string) (*SQLAuditSink, error) {
+	db, d, err := archive.OpenDB(uri)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	if err := archive.Migrate(ctx, db, d); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("migrating %s database: %w", d.Name, err)
+	}
+	return &SQLAuditSink{db: db, dialect: d}, nil
+}
+
+// WriteAudit inserts one entry.
+func (s *SQLAuditSink) WriteAudit(entry AuditEntry) error {
+	var options sql.NullString
+	if entry.Options != nil {
+		data, err := json.Marshal(entry.Options)
+		if err != nil {
+			return err
+		}
+		options = sql.NullString{String: string(data), Valid: true}
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), auditWriteTimeout)
+	defer cancel()
+	_, err := s.db.ExecContext(ctx, s.dialect.Rebind(`INSERT INTO gbfs_audit
+		(at, remote_ip, method, path, url, options, duration_ms, status, outcome)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`),
+		entry.Time.UnixNano(), entry.RemoteIP, entry.Method, entry.Path, entry.URL, options,
+		entry.DurationMs, entry.Status, entry.Outcome)
+	return err
+}
+
+// Close closes the database.
+func (s *SQLAuditSink) Close() error {
+	return s.db.Close()
+}
+
+// SetAuditSink enables audit logging of validation requests. Entries are
+// written to sink in the background; see CloseAudit.
+func (s *Server) SetAuditSink(sink AuditSink) {
+	if sink == nil {
+		s.audit = nil
+		return
+	}
+	s.audit = newAuditWriter(sink)
+}
+
+// CloseAudit writes the audit entries still queued and closes the audit
+// sink, if it is an io.Closer. Call it once the HTTP server has shut down;
+// requests audited afterwards are not logged.
+func (s *Server) CloseAudit() error {
+	if s.audit == nil {
+		return nil
+	}
+	return s.audit.close()
+}
+
+// auditWriter hands audit entries to a sink from one background
+// goroutine, so that a slow file or database does not delay responses.
+type auditWriter struct {
+	sink    AuditSink
+	entries chan AuditEntry
+	done    chan struct{}
+
+	mu     sync.Mutex
+	closed bool
+}
+
+func newAuditWriter(sink AuditSink) *auditWriter {
+	w := &auditWriter{sink: sink, entries: make(chan AuditEntry, auditQueue), done: make(chan struct{})}
+	go w.run()
+	return w
+}
+
+func (w *auditWriter) run() {
+	defer close(w.done)
+	for entry := range w.entries {
+		if err := w.sink.WriteAudit(entry); err != nil {
+			log.Printf("Failed to write audit log: %v", err)
+		}
+	}
+}
+
+// write queues entry without waiting, dropping it when the queue is full.
+func (w *auditWriter) write(entry AuditEntry) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return
+	}
+	select {
+	case w.entries <- entry:
+	default:
+		log.Printf("Audit log queue is full; dropped the entry for %s %s at %s", entry.Method, entry.Path, entry.Time.Format(time.RFC3339))
+	}
+}
+
+// close writes the queued entries and closes the sink.
+func (w *auditWriter) close() error {
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return nil
+	}
+	w.closed = true
+	close(w.entries)
+	w.mu.Unlock()
+	<-w.done
+	if c, ok := w.sink.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+// SetTrustedProxies lists the proxies, as IPs or CIDR ranges, whose
+// X-Forwarded-For header is believed when determining the requester IP.
+func (s *Server) SetTrustedProxies(proxies []string) error {
+	nets := make([]*net.IPNet, 0, len(proxies))
+	for _, p := range proxies {
+		p = strings.TrimSpace(p)
+		if p == "" {
+			continue
+		}
+		if !strings.Contains(p, "/") {
+			ip := net.ParseIP(p)
+			if ip == nil {
+				return fmt.Errorf("invalid proxy address %q", p)
+			}
+			bits := 8 * len(ip.To16())
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 32
+			}
+			nets = append(nets, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, n, err := net.ParseCIDR(p)
+		if err != nil {
+			return fmt.Errorf("invalid proxy range %q: %w", p, err)
+		}
+		nets = append(nets, n)
+	}
+	s.trustedProxies = nets
+	return nil
+}
+
+// trusted reports whether ip belongs to a trusted proxy.
+func (s *Server) trusted(ip net.IP) bool {
+	for _, n := range s.trustedProxies {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// clientIP returns the requester IP. X-Forwarded-For is only consulted when
+// the connection comes from a trusted proxy, and is read right to left
+// until an address that is not a trusted proxy is found.
+func (s *Server) clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || !s.trusted(ip) {
+		return host
+	}
+
+	hops := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop := net.ParseIP(strings.TrimSpace(hops[i]))
+		if hop == nil {
+			break
+		}
+		host = hop.String()
+		if !s.trusted(hop) {
+			break
+		}
+	}
+	return host
+}
+
+// auditRecordKey carries the request's *auditRecord through its context.
+type auditRecordKey struct{}
+
+// auditRecord is what a handler notes about its request for the audit log.
+type auditRecord struct {
+	outcome string
+	req     *ValidateRequest
+}
+
+// auditRecordOf returns the request's audit record, or nil when the
+// request is not audited.
+func auditRecordOf(r *http.Request) *auditRecord {
+	rec, _ := r.Context().Value(auditRecordKey{}).(*auditRecord)
+	return rec
+}
+
+// recordOutcome notes whether the validated feed had errors, for the audit
+// log.
+func recordOutcome(r *http.Request, result *validator.ValidationResult) {
+	rec := auditRecordOf(r)
+	if rec == nil || result == nil {
+		return
+	}
+	if result.Summary.HasErrors {
+		rec.outcome = "invalid"
+	} else {
+		rec.outcome = "valid"
+	}
+}
+
+// recordRequest notes the feed and options a handler decoded from its
+// request, for the audit log, which redacts them.
+func recordRequest(r *http.Request, req ValidateRequest) {
+	if rec := auditRecordOf(r); rec != nil {
+		rec.req = &req
+	}
+}
+
+// statusRecorder captures the response status code.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (w *statusRecorder) WriteHeader(status int) {
+	w.status = status
+	w.ResponseWriter.WriteHeader(status)
+}
+
+// audited wraps a validation handler so that each request is written to the
+// audit sink, when one is set. The handler notes the feed and options it
+// decoded with recordRequest; the body is not read here, so a handler
+// reads it only once it holds a validation slot.
+func (s *Server) audited(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if s.audit == nil {
+			h(w, r)
+			return
+		}
+
+		start := time.Now()
+		audit := &auditRecord{}
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		h(rec, r.WithContext(context.WithValue(r.Context(), auditRecordKey{}, audit)))
+
+		outcome := audit.outcome
+		if outcome == "" {
+			outcome = "ok"
+			if rec.status >= http.StatusBadRequest {
+				outcome = "error"
+			}
+		}
+		entry := AuditEntry{
+			Time:       start.UTC(),
+			RemoteIP:   s.clientIP(r),
+			Method:     r.Method,
+			Path:       r.URL.Path,
+			DurationMs: time.Since(start).Milliseconds(),
+			Status:     rec.status,
+			Outcome:    outcome,
+		}
+		if audit.req != nil {
+			entry.URL = fetcher.RedactURL(audit.req.URL)
+			entry.Options = redactOptions(audit.req.Options)
+		}
+		s.audit.write(entry)
+	}
+}
+
+// redactOptions returns a copy of opts safe to log.
+func redactOptions(opts *ValidateOptions) *ValidateOptions {
+	if opts == nil {
+		return nil
+	}
+	out := *opts
+	out.Auth = opts.Auth.Redacted()
+	if len(opts.Headers) > 0 {
+		out.Headers = make(map[string]string, len(opts.Headers))
+		for k := range opts.Headers {
+			out.Headers[k] = fetcher.Redacted
+		}
+	}
+	if len(opts.FeedURLs) > 0 {
+		out.FeedURLs = make(map[string]string, len(opts.FeedURLs))
+		for k, u := range opts.FeedURLs {
+			out.FeedURLs[k] = fetcher.RedactURL(u)
+		}
+	}
+	if len(opts.FeedURLOverrides) > 0 {
+		out.FeedURLOverrides = make(map[string]string, len(opts.FeedURLOverrides))
+		for k, u := range opts.FeedURLOverrides {
+			out.FeedURLOverrides[k] = fetcher.RedactURL(u)
+		}
+	}
+	return &out
+}
@@ -0,0 +1,147 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestClientIP(t *testing.T) {
+	s := NewServer()
+	if err := s.SetTrustedProxies([]string{"10.0.0.0/8", "192.0.2.1"}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		remote, xff, want string
+	}{
+		{"203.0.113.5:1234", "198.51.100.9", "203.0.113.5"},
+		{"192.0.2.1:1234", "198.51.100.9", "198.51.100.9"},
+		{"192.0.2.1:1234", "198.51.100.9, 10.1.2.3", "198.51.100.9"},
+		{"192.0.2.1:1234", "6.6.6.6, 198.51.100.9", "198.51.100.9"},
+		{"10.0.0.2:1234", "", "10.0.0.2"},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest(http.MethodPost, "/api/validator", nil)
+		r.RemoteAddr = tt.remote
+		if tt.xff != "" {
+			r.Header.Set("X-Forwarded-For", tt.xff)
+		}
+		if got := s.clientIP(r); got != tt.want {
+			t.Errorf("clientIP(%s, %q) = %s, want %s", tt.remote, tt.xff, got, tt.want)
+		}
+	}
+}
+
+func TestAuditLogRedactsSecrets(t *testing.T) {
+	var buf bytes.Buffer
+	s := NewServer()
+	s.SetAuditSink(NewJSONAuditSink(&buf))
+
+	body := `{"url":"","options":{"auth":{"type":"bearer_token","bearerToken":{"token":"s3cr3t"}},"headers":{"X-Key":"s3cr3t"}}}`
+	r := httptest.NewRequest(http.MethodPost, "/api/validator", strings.NewReader(body))
+	s.ServeHTTP(httptest.NewRecorder(), r)
+	if err := s.CloseAudit(); err != nil {
+		t.Fatal(err)
+	}
+
+	var entry AuditEntry
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("audit line: %v (%q)", err, buf.String())
+	}
+	if entry.Status != http.StatusBadRequest || entry.Outcome != "error" {
+		t.Errorf("status %d outcome %q", entry.Status, entry.Outcome)
+	}
+	if entry.Options == nil || len(entry.Options.Headers) != 1 {
+		t.Errorf("options not recorded: %+v", entry.Options)
+	}
+	if strings.Contains(buf.String(), "s3cr3t") {
+		t.Errorf("audit log leaks secret: %s", buf.String())
+	}
+}
+
+// blockingSink holds every write until released.
+type blockingSink struct {
+	release chan struct{}
+	written chan AuditEntry
+}
+
+func (s *blockingSink) WriteAudit(entry AuditEntry) error {
+	<-s.release
+	s.written <- entry
+	return nil
+}
+
+// unreadBody fails the test if a handler reads it.
+type unreadBody struct{ t *testing.T }
+
+func (b unreadBody) Read(p []byte) (int, error) {
+	b.t.Error("request body read before a validation slot was free")
+	return 0, io.EOF
+}
+
+func TestAuditDoesNotHoldUpRequests(t *testing.T) {
+	sink := &blockingSink{release: make(chan struct{}), written: make(chan AuditEntry, 1)}
+	s := NewServer()
+	s.SetAuditSink(sink)
+	s.SetConcurrencyLimit(1, 0)
+	held, _ := s.limiter.reserve()
+	held.wait(context.Background())
+
+	// A saturated server turns validate-file away without reading the
+	// document, and answers before the sink has written the entry.
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/validate-file?fileType=gbfs", unreadBody{t}))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("saturated server answered %d", w.Code)
+	}
+	held.release()
+	close(sink.release)
+	if entry := <-sink.written; entry.Path != "/api/validate-file" || entry.Status != http.StatusServiceUnavailable {
+		t.Errorf("audited %+v", entry)
+	}
+	if err := s.CloseAudit(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestSQLAuditSink(t *testing.T) {
+	uris := []string{"sqlite:" + filepath.Join(t.TempDir(), "audit.db")}
+	// GBFS_TEST_POSTGRES_URL names a database the test may write to.
+	if uri := os.Getenv("GBFS_TEST_POSTGRES_URL"); uri != "" {
+		uris = append(uris, uri)
+	}
+	for _, uri := range uris {
+		if !IsAuditDB(uri) {
+			t.Fatalf("%s is not an audit database", uri)
+		}
+		sink, err := OpenAuditDB(uri)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer sink.Close()
+		at := time.Now()
+		entry := AuditEntry{Time: at, RemoteIP: "192.0.2.1", Method: http.MethodPost, Path: "/api/validator",
+			URL: "https://example.com/gbfs.json", Options: &ValidateOptions{Docked: true}, Status: http.StatusOK, Outcome: "valid"}
+		if err := sink.WriteAudit(entry); err != nil {
+			t.Fatal(err)
+		}
+		var options, outcome string
+		err = sink.db.QueryRow(sink.dialect.Rebind(`SELECT options, outcome FROM gbfs_audit WHERE at = ? AND remote_ip = ?`),
+			at.UnixNano(), "192.0.2.1").Scan(&options, &outcome)
+		if err != nil || outcome != "valid" || !strings.Contains(options, `"docked":true`) {
+			t.Errorf("%s: stored %q, %q, %v", sink.dialect.Name, options, outcome, err)
+		}
+	}
+	if IsAuditDB("audit.log") {
+		t.Error("a file was taken for a database")
+	}
+}
@@ -0,0 +1,143 @@
+package api
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gbfs-validator-go/pkg/validator"
+)
+
+// resultCacheSize bounds the number of cached results.
+const resultCacheSize = 256
+
+// cacheName identifies the server in Cache-Status headers (RFC 9211).
+const cacheName = "gbfs-validator"
+
+// cachedResult is a validation result and when it was computed.
+type cachedResult struct {
+	result *validator.ValidationResult
+	stored time.Time
+}
+
+// resultCache holds validation results by request for a fixed TTL.
+type resultCache struct {
+	ttl time.Duration
+
+	mu      sync.Mutex
+	entries map[string]cachedResult
+}
+
+// SetResultCache caches validation results per URL and options for ttl,
+// so repeated requests for the same feed do not refetch it. Requests with
+// credentials or custom headers are never cached. Zero disables caching.
+func (s *Server) SetResultCache(ttl time.Duration) {
+	if ttl <= 0 {
+		s.cache = nil
+		return
+	}
+	s.cache = &resultCache{ttl: ttl, entries: make(map[string]cachedResult)}
+}
+
+// cacheKey identifies a request by URL and options, or returns "" when the
+// request must not be cached.
+func cacheKey(req ValidateRequest) string {
+	if opts := req.Options; opts != nil && (opts.Auth != nil || len(opts.Headers) > 0) {
+		return ""
+	}
+	data, err := json.Marshal(req)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// get returns a result younger than the TTL.
+func (c *resultCache) get(key string, now time.Time) (cachedResult, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[key]
+	if !ok || now.Sub(entry.stored) >= c.ttl {
+		return cachedResult{}, false
+	}
+	return entry, true
+}
+
+// put stores a result, dropping expired entries and, when full, the oldest.
+func (c *resultCache) put(key string, result *validator.ValidationResult, now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for k, entry := range c.entries {
+		if now.Sub(entry.stored) >= c.ttl {
+			delete(c.entries, k)
+		}
+	}
+	if len(c.entries) >= resultCacheSize {
+		oldest := ""
+		for k, entry := range c.entries {
+			if oldest == "" || entry.stored.Before(c.entries[oldest].stored) {
+				oldest = k
+			}
+		}
+		delete(c.entries, oldest)
+	}
+	c.entries[key] = cachedResult{result: result, stored: now}
+}
+
+// noCache reports whether the request asks to bypass cached responses.
+func noCache(r *http.Request) bool {
+	for _, directive := range strings.Split(r.Header.Get("Cache-Control"), ",") {
+		switch strings.ToLower(strings.TrimSpace(directive)) {
+		case "no-cache", "no-store", "max-age=0":
+			return true
+		}
+	}
+	return false
+}
+
+// validateCached returns a cached result for the request or runs validate
+// and caches its result, setting Age, Cache-Status, and Cache-Control.
+func (s *Server) validateCached(w http.ResponseWriter, r *http.Request, req ValidateRequest, validate func() (*validator.ValidationResult, error)) (*validator.ValidationResult, error) {
+	key := ""
+	if s.cache != nil {
+		key = cacheKey(req)
+	}
+	if key == "" {
+		return validate()
+	}
+	w.Header().Set("Access-Control-Expose-Headers", "Age, Cache-Status")
+	now := time.Now()
+
+	forward := "uri-miss"
+	if noCache(r) {
+		forward = "request"
+	} else if entry, ok := s.cache.get(key, now); ok {
+		age := now.Sub(entry.stored)
+		remaining := int((s.cache.ttl - age).Seconds())
+		w.Header().Set("Age", strconv.Itoa(int(age.Seconds())))
+		w.Header().Set("Cache-Control", fmt.Sprintf("max-age=%d", remaining))
+		w.Header().Set("Cache-Status", fmt.Sprintf("%s; hit; ttl=%d", cacheName, remaining))
+		return entry.result, nil
+	}
+
+	result, err := validate()
+	if err != nil {
+		return nil, err
+	}
+	if result.Summary.Incomplete {
+		// A validation cut short by a timeout may finish next time.
+		return result, nil
+	}
+	s.cache.put(key, result, now)
+	w.Header().Set("Age", "0")
+	w.Header().Set("Cache-Control", fmt.Sprintf("max-age=%d", int(s.cache.ttl.Seconds())))
+	w.Header().Set("Cache-Status", fmt.Sprintf("%s; fwd=%s; stored", cacheName, forward))
+	return result, nil
+}
@@ -0,0 +1,62 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestResultCache(t *testing.T) {
+	var fetches atomic.Int32
+	feed := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches.Add(1)
+		w.Write([]byte(`{"last_updated":0,"ttl":0,"data":{"en":{"feeds":[]}}}`))
+	}))
+	defer feed.Close()
+
+	s := NewServer()
+	s.SetResultCache(time.Minute)
+	validate := func(body string, header http.Header) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodPost, "/api/validator", strings.NewReader(body))
+		for name, values := range header {
+			r.Header[name] = values
+		}
+		w := httptest.NewRecorder()
+		s.ServeHTTP(w, r)
+		if w.Code != http.StatusOK {
+			t.Fatalf("status %d: %s", w.Code, w.Body)
+		}
+		return w
+	}
+	body := `{"url":"` + feed.URL + `/gbfs.json"}`
+
+	first := validate(body, nil)
+	if got := first.Header().Get("Cache-Status"); got != "gbfs-validator; fwd=uri-miss; stored" {
+		t.Errorf("first Cache-Status = %q", got)
+	}
+	second := validate(body, nil)
+	if got := second.Header().Get("Cache-Status"); !strings.HasPrefix(got, "gbfs-validator; hit; ttl=") {
+		t.Errorf("second Cache-Status = %q", got)
+	}
+	if second.Header().Get("Age") == "" || second.Body.String() != first.Body.String() {
+		t.Errorf("cached response differs: Age %q", second.Header().Get("Age"))
+	}
+	if n := fetches.Load(); n != 1 {
+		t.Errorf("feed fetched %d times, want 1", n)
+	}
+
+	refreshed := validate(body, http.Header{"Cache-Control": {"no-cache"}})
+	if got := refreshed.Header().Get("Cache-Status"); got != "gbfs-validator; fwd=request; stored" {
+		t.Errorf("no-cache Cache-Status = %q", got)
+	}
+	withAuth := validate(`{"url":"`+feed.URL+`/gbfs.json","options":{"headers":{"X-Key":"k"}}}`, nil)
+	if got := withAuth.Header().Get("Cache-Status"); got != "" {
+		t.Errorf("request with headers was cached: %q", got)
+	}
+	if n := fetches.Load(); n != 3 {
+		t.Errorf("feed fetched %d times, want 3", n)
+	}
+}
@@ -0,0 +1,93 @@
+package api
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gbfs-validator-go/pkg/archive"
+)
+
+// defaultTrendWindow is the span returned when no start time is given.
+const defaultTrendWindow = 7 * 24 * time.Hour
+
+// TrendsResponse is a feed's archived history bucketed over time.
+type TrendsResponse struct {
+	FeedKey  string                `json:"feedKey"`
+	Interval archive.Interval      `json:"interval"`
+	From     time.Time             `json:"from"`
+	To       time.Time             `json:"to"`
+	Buckets  []archive.TrendBucket `json:"buckets"`
+}
+
+// trendStore returns the store trends are served from: the one set with
+// SetStore, else the archive.
+func (s *Server) trendStore() archive.Store {
+	if s.store != nil {
+		return s.store
+	}
+	if s.archive != nil {
+		return s.archive
+	}
+	return nil
+}
+
+// handleTrends returns error counts, score, availability, and fetch
+// latency for a feed over time. The feed ID is its archive key; the
+// optional query parameters are interval (hour or day) and RFC 3339 from
+// and to times.
+func (s *Server) handleTrends(w http.ResponseWriter, r *http.Request) {
+	store := s.trendStore()
+	if store == nil {
+		respondError(w, http.StatusNotFound, "no results store configured")
+		return
+	}
+
+	query := r.URL.Query()
+	interval := archive.Hourly
+	if v := query.Get("interval"); v != "" {
+		var err error
+		if interval, err = archive.ParseInterval(v); err != nil {
+			respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+
+	to := time.Now().UTC()
+	if v := query.Get("to"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, "to must be an RFC 3339 time")
+			return
+		}
+		to = t
+	}
+	from := to.Add(-defaultTrendWindow)
+	if v := query.Get("from"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, "from must be an RFC 3339 time")
+			return
+		}
+		from = t
+	}
+
+	id := r.PathValue("id")
+	if !s.authorizeFeed(w, r, id) {
+		return
+	}
+	buckets, err := store.Trends(r.Context(), id, interval, from, to)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if buckets == nil {
+		buckets = []archive.TrendBucket{}
+	}
+	respondJSON(w, http.StatusOK, TrendsResponse{
+		FeedKey:  id,
+		Interval: interval,
+		From:     from,
+		To:       to,
+		Buckets:  buckets,
+	})
+}
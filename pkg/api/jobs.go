@@ -0,0 +1,420 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gbfs-validator-go/pkg/archive"
+	"github.com/gbfs-validator-go/pkg/fetcher"
+	"github.com/gbfs-validator-go/pkg/validator"
+)
+
+const (
+	// maxJobs is how many jobs are kept; the oldest finished ones are
+	// dropped first.
+	maxJobs = 1000
+	// jobTimeout bounds a single background validation unless
+	// SetValidationTimeouts or the request sets a limit.
+	jobTimeout = 2 * time.Minute
+	// callbackAttempts is how many times a callback is tried before giving up.
+	callbackAttempts = 3
+	// callbackTimeout bounds one callback request.
+	callbackTimeout = 15 * time.Second
+	// saveTimeout bounds storing a finished job, which may have used up
+	// its own time.
+	saveTimeout = 30 * time.Second
+)
+
+// SignatureHeader carries the HMAC-SHA256 of a callback's timestamp and
+// body, as "sha256=<hex>", when the callback has a secret; see
+// SignPayload.
+const SignatureHeader = "X-GBFS-Signature-256"
+
+// TimestampHeader carries the Unix time, in seconds, at which a callback
+// was signed. It is part of the signed material, so a receiver can refuse
+// a captured callback replayed later.
+const TimestampHeader = "X-GBFS-Timestamp"
+
+// SignatureTolerance is how far a callback's timestamp may be from the
+// receiver's clock, either way, for VerifySignature to accept it. It
+// allows for clock skew between the hosts and for delivery time.
+const SignatureTolerance = 5 * time.Minute
+
+// JobStatus is the state of an asynchronous validation.
+type JobStatus string
+
+const (
+	JobPending JobStatus = "pending"
+	JobRunning JobStatus = "running"
+	JobDone    JobStatus = "done"
+	JobFailed  JobStatus = "failed"
+)
+
+// JobRequest starts an asynchronous validation.
+type JobRequest struct {
+	ValidateRequest
+	Callback *Callback `json:"callback,omitempty"`
+}
+
+// Callback is notified when a job finishes.
+type Callback struct {
+	URL string `json:"url"`
+	// Secret signs the timestamp and payload; see SignPayload.
+	Secret string `json:"secret,omitempty"`
+	// Summary sends only the result summary instead of the full result.
+	Summary bool `json:"summary,omitempty"`
+}
+
+// CallbackStatus reports delivery of a job's callback.
+type CallbackStatus struct {
+	URL        string     `json:"url"`
+	Attempts   int        `json:"attempts"`
+	Delivered  bool       `json:"delivered"`
+	StatusCode int        `json:"statusCode,omitempty"`
+	Error      string     `json:"error,omitempty"`
+	SentAt     *time.Time `json:"sentAt,omitempty"`
+}
+
+// Job is an asynchronous validation and its outcome.
+type Job struct {
+	ID     string    `json:"id"`
+	Status JobStatus `json:"status"`
+	URL    string    `json:"url,omitempty"`
+	// FeedKey identifies the feed in the archive and trend endpoints.
+	FeedKey    string                      `json:"feedKey"`
+	CreatedAt  time.Time                   `json:"createdAt"`
+	FinishedAt *time.Time                  `json:"finishedAt,omitempty"`
+	Result     *validator.ValidationResult `json:"result,omitempty"`
+	Error      string                      `json:"error,omitempty"`
+	Callback   *CallbackStatus             `json:"callback,omitempty"`
+}
+
+// CallbackPayload is the body posted to a job's callback URL.
+type CallbackPayload struct {
+	JobID   string                       `json:"jobId"`
+	Status  JobStatus                    `json:"status"`
+	URL     string                       `json:"url,omitempty"`
+	Result  *validator.ValidationResult  `json:"result,omitempty"`
+	Summary *validator.ValidationSummary `json:"summary,omitempty"`
+	Error   string                       `json:"error,omitempty"`
+}
+
+// jobStore holds jobs in memory.
+type jobStore struct {
+	mu    sync.Mutex
+	jobs  map[string]*Job
+	order []string
+}
+
+// add stores a job, evicting the oldest finished job when full. It reports
+// false, storing nothing, when every stored job is unfinished.
+func (st *jobStore) add(job *Job) bool {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	if st.jobs == nil {
+		st.jobs = make(map[string]*Job)
+	}
+	if len(st.order) >= maxJobs {
+		evicted := false
+		for i, id := range st.order {
+			if s := st.jobs[id].Status; s == JobDone || s == JobFailed {
+				delete(st.jobs, id)
+				st.order = append(st.order[:i], st.order[i+1:]...)
+				evicted = true
+				break
+			}
+		}
+		if !evicted {
+			return false
+		}
+	}
+	st.jobs[job.ID] = job
+	st.order = append(st.order, job.ID)
+	return true
+}
+
+// get returns a copy of a job.
+func (st *jobStore) get(id string) (Job, bool) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	job, ok := st.jobs[id]
+	if !ok {
+		return Job{}, false
+	}
+	return *job, true
+}
+
+// update applies fn to a job under the store lock.
+func (st *jobStore) update(id string, fn func(*Job)) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	if job, ok := st.jobs[id]; ok {
+		fn(job)
+	}
+}
+
+// newJobID returns a random job identifier.
+func newJobID() string {
+	b := make([]byte, 12)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// handleJobs starts an asynchronous validation and returns its job.
+func (s *Server) handleJobs(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		respondError(w, http.StatusMethodNotAllowed, "POST required")
+		return
+	}
+
+	var req JobRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	recordRequest(r, req.ValidateRequest)
+	if req.URL == "" && (req.Options == nil || len(req.Options.FeedURLs) == 0) {
+		respondError(w, http.StatusBadRequest, "URL or options.feedUrls is required")
+		return
+	}
+	if err := checkRulePacks(req.Options); err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := checkTimeouts(req.Options); err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := checkSeverities(req.Options); err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := checkFeedURLOverrides(req.Options); err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if req.Callback != nil {
+		u, err := url.Parse(req.Callback.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			respondError(w, http.StatusBadRequest, "callback.url must be an http or https URL")
+			return
+		}
+	}
+
+	sl, ok := s.limiter.reserve()
+	if !ok {
+		respondBusy(w)
+		return
+	}
+
+	job := &Job{
+		ID:        newJobID(),
+		Status:    JobPending,
+		URL:       fetcher.RedactURL(req.URL),
+		FeedKey:   archive.FeedKey(archive.Key(req.URL, feedURLs(req.Options))),
+		CreatedAt: time.Now().UTC(),
+	}
+	if req.Callback != nil {
+		job.Callback = &CallbackStatus{URL: fetcher.RedactURL(req.Callback.URL)}
+	}
+	if !s.jobs.add(job) {
+		sl.release()
+		w.Header().Set("Retry-After", strconv.Itoa(retryAfterSeconds))
+		respondError(w, http.StatusTooManyRequests, "too many unfinished jobs; retry later")
+		return
+	}
+	created := *job
+
+	go s.runJob(job.ID, req, sl)
+
+	w.Header().Set("Location", "/api/jobs/"+job.ID)
+	respondJSON(w, http.StatusAccepted, created)
+}
+
+// handleJob returns the state of a job.
+func (s *Server) handleJob(w http.ResponseWriter, r *http.Request) {
+	job, ok := s.jobs.get(r.PathValue("id"))
+	if !ok {
+		respondError(w, http.StatusNotFound, "job not found")
+		return
+	}
+	respondJSON(w, http.StatusOK, job)
+}
+
+// runJob waits for its slot, validates the feed, records the outcome, and
+// delivers the callback. The job's timeout starts once it has the slot,
+// as a queued job is not using its time; the wait itself is bounded by the
+// timeouts of the validations ahead of it.
+func (s *Server) runJob(id string, req JobRequest, sl *slot) {
+	timeout := s.validationTimeout(req.Options)
+	if timeout == 0 {
+		timeout = jobTimeout
+	}
+
+	var result *validator.ValidationResult
+	err := sl.wait(context.Background())
+	if err == nil {
+		s.jobs.update(id, func(j *Job) { j.Status = JobRunning })
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		v := validator.New(s.newFetcher(req.Options), s.validatorOptions(req.Options))
+		result, err = v.Validate(ctx, req.URL)
+		cancel()
+	}
+	sl.release()
+
+	finished := time.Now().UTC()
+	if result != nil {
+		s.saveJob(id, archive.Key(req.URL, feedURLs(req.Options)), finished, result)
+	}
+
+	payload := CallbackPayload{JobID: id, URL: fetcher.RedactURL(req.URL)}
+	s.jobs.update(id, func(j *Job) {
+		j.FinishedAt = &finished
+		if err != nil {
+			j.Status = JobFailed
+			j.Error = err.Error()
+		} else {
+			j.Status = JobDone
+			j.Result = result
+		}
+		payload.Status = j.Status
+		payload.Error = j.Error
+	})
+
+	if req.Callback == nil {
+		return
+	}
+	if result != nil {
+		if req.Callback.Summary {
+			payload.Summary = &result.Summary
+		} else {
+			payload.Result = result
+		}
+	}
+	status := s.deliverCallback(req.Callback, payload)
+	s.jobs.update(id, func(j *Job) { j.Callback = status })
+}
+
+// saveJob archives and stores a job's result. It does not use the job's
+// context, which has ended if the job timed out with a partial result.
+func (s *Server) saveJob(id, key string, finished time.Time, result *validator.ValidationResult) {
+	ctx, cancel := context.WithTimeout(context.Background(), saveTimeout)
+	defer cancel()
+	if s.archive != nil {
+		if err := s.archive.Save(ctx, key, finished, result); err != nil {
+			log.Printf("Failed to archive job %s: %v", id, err)
+		}
+	}
+	if s.store != nil {
+		if err := s.store.SaveRun(ctx, key, finished, result); err != nil {
+			log.Printf("Failed to store job %s: %v", id, err)
+		}
+	}
+}
+
+// feedURLs returns the explicit feed URLs of a request, if any.
+func feedURLs(opts *ValidateOptions) map[string]string {
+	if opts == nil {
+		return nil
+	}
+	return opts.FeedURLs
+}
+
+// deliverCallback posts the payload, retrying with backoff on failure. It
+// goes through the server's HTTP client and network settings and sends
+// the deployment's user agent and contact header, as fetches do.
+func (s *Server) deliverCallback(cb *Callback, payload CallbackPayload) *CallbackStatus {
+	status := &CallbackStatus{URL: fetcher.RedactURL(cb.URL)}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		status.Error = err.Error()
+		return status
+	}
+
+	var opts []fetcher.Option
+	if s.httpClient != nil {
+		opts = append(opts, fetcher.WithHTTPClient(s.httpClient))
+	}
+	opts = append(opts, s.fetcherOpts...)
+	f := fetcher.New(append(opts, fetcher.WithTimeout(callbackTimeout))...)
+	backoff := time.Second
+	for status.Attempts < callbackAttempts {
+		if status.Attempts > 0 {
+			time.Sleep(backoff)
+			backoff *= 2
+		}
+		status.Attempts++
+
+		code, err := postCallback(f, cb, body)
+		status.StatusCode = code
+		if err == nil {
+			now := time.Now().UTC()
+			status.Delivered = true
+			status.SentAt = &now
+			status.Error = ""
+			return status
+		}
+		status.Error = fetcher.RedactText(err.Error())
+	}
+	log.Printf("Callback for job %s failed after %d attempts: %s", payload.JobID, status.Attempts, status.Error)
+	return status
+}
+
+// postCallback sends one callback request.
+func postCallback(f *fetcher.Fetcher, cb *Callback, body []byte) (int, error) {
+	req, err := http.NewRequest(http.MethodPost, cb.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if cb.Secret != "" {
+		timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+		req.Header.Set(TimestampHeader, timestamp)
+		req.Header.Set(SignatureHeader, SignPayload(cb.Secret, timestamp, body))
+	}
+
+	resp, err := f.Send(req)
+	if err != nil {
+		return 0, err
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("callback returned status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// SignPayload returns the signature header value for a callback: "sha256="
+// followed by the hex HMAC-SHA256, keyed with secret, of the timestamp
+// header value, a ".", and the body.
+func SignPayload(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifySignature reports whether signature is valid for the timestamp,
+// body, and secret, and the timestamp is within SignatureTolerance of now.
+func VerifySignature(secret, timestamp string, body []byte, signature string, now time.Time) bool {
+	sec, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return false
+	}
+	if skew := now.Sub(time.Unix(sec, 0)).Abs(); skew > SignatureTolerance {
+		return false
+	}
+	return hmac.Equal([]byte(SignPayload(secret, timestamp, body)), []byte(signature))
+}
@@ -0,0 +1,215 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gbfs-validator-go/pkg/archive"
+	"github.com/gbfs-validator-go/pkg/fetcher"
+	"github.com/gbfs-validator-go/pkg/genfeed"
+	"github.com/gbfs-validator-go/pkg/validator"
+)
+
+func TestJobCallback(t *testing.T) {
+	cfg := genfeed.DefaultConfig()
+	cfg.Stations, cfg.Vehicles, cfg.Geofences = 5, 10, 1
+	feed, err := genfeed.Generate(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	feedServer := httptest.NewServer(feed.Handler())
+	defer feedServer.Close()
+
+	received := make(chan *http.Request, 1)
+	bodies := make(chan []byte, 1)
+	hook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		received <- r
+		bodies <- body
+	}))
+	defer hook.Close()
+
+	s := NewServer()
+	s.SetFetcherOptions(fetcher.WithUserAgent("ops-validator/2"), fetcher.WithContact("", "ops@example.com"))
+	req := `{"url":"` + feedServer.URL + `/gbfs.json","callback":{"url":"` + hook.URL + `","secret":"shh","summary":true}}`
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/jobs", strings.NewReader(req)))
+	if rec.Code != http.StatusAccepted {
+		t.Fatalf("status %d: %s", rec.Code, rec.Body)
+	}
+	var job Job
+	json.Unmarshal(rec.Body.Bytes(), &job)
+
+	select {
+	case r := <-received:
+		body := <-bodies
+		if !VerifySignature("shh", r.Header.Get(TimestampHeader), body, r.Header.Get(SignatureHeader), time.Now()) {
+			t.Error("callback signature does not verify")
+		}
+		if r.Header.Get("User-Agent") != "ops-validator/2" || r.Header.Get("From") != "ops@example.com" {
+			t.Errorf("callback identified as %q, from %q", r.Header.Get("User-Agent"), r.Header.Get("From"))
+		}
+		var payload CallbackPayload
+		if err := json.Unmarshal(body, &payload); err != nil {
+			t.Fatal(err)
+		}
+		if payload.JobID != job.ID || payload.Status != JobDone || payload.Summary == nil || payload.Result != nil {
+			t.Errorf("unexpected payload: %+v", payload)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("callback not delivered")
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		rec = httptest.NewRecorder()
+		s.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/jobs/"+job.ID, nil))
+		json.Unmarshal(rec.Body.Bytes(), &job)
+		if job.Callback != nil && job.Callback.Delivered {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("callback delivery not recorded: %+v", job.Callback)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if job.Result == nil {
+		t.Error("job result missing")
+	}
+}
+
+func TestVerifySignature(t *testing.T) {
+	signed := time.Unix(1700000000, 0)
+	body := []byte(`{"jobId":"a"}`)
+	sig := SignPayload("shh", "1700000000", body)
+	for _, tt := range []struct {
+		name      string
+		timestamp string
+		body      []byte
+		now       time.Time
+		want      bool
+	}{
+		{"fresh", "1700000000", body, signed.Add(time.Minute), true},
+		{"receiver clock behind", "1700000000", body, signed.Add(-SignatureTolerance), true},
+		{"replayed", "1700000000", body, signed.Add(SignatureTolerance + time.Second), false},
+		{"timestamp changed", "1700000060", body, signed, false},
+		{"body changed", "1700000000", []byte(`{"jobId":"b"}`), signed, false},
+		{"no timestamp", "", body, signed, false},
+	} {
+		if got := VerifySignature("shh", tt.timestamp, tt.body, sig, tt.now); got != tt.want {
+			t.Errorf("%s: VerifySignature = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestJobTimeoutStartsWithSlot(t *testing.T) {
+	feed, err := genfeed.Generate(genfeed.DefaultConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	feedServer := httptest.NewServer(feed.Handler())
+	defer feedServer.Close()
+
+	s := NewServer()
+	s.SetConcurrencyLimit(1, 1)
+	busy, _ := s.limiter.reserve()
+	busy.wait(context.Background())
+
+	body := `{"url":"` + feedServer.URL + `/gbfs.json","options":{"timeout":"1s"}}`
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/jobs", strings.NewReader(body)))
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("status %d: %s", w.Code, w.Body)
+	}
+	var job Job
+	json.Unmarshal(w.Body.Bytes(), &job)
+	time.Sleep(1500 * time.Millisecond)
+	busy.release()
+
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		job, _ = s.jobs.get(job.ID)
+		if job.Status == JobDone || job.Status == JobFailed {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("job did not finish: %+v", job)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if job.Status != JobDone {
+		t.Errorf("job queued past its timeout failed: %s", job.Error)
+	}
+}
+
+func TestJobsFull(t *testing.T) {
+	s := NewServer()
+	for i := 0; i < maxJobs; i++ {
+		s.jobs.add(&Job{ID: strconv.Itoa(i), Status: JobPending})
+	}
+	post := func() *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		s.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/jobs", strings.NewReader(`{"url":"http://127.0.0.1:1/gbfs.json"}`)))
+		return w
+	}
+	if w := post(); w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") == "" {
+		t.Errorf("full store answered %d, Retry-After %q", w.Code, w.Header().Get("Retry-After"))
+	}
+	s.jobs.update("0", func(j *Job) { j.Status = JobDone })
+	if w := post(); w.Code != http.StatusAccepted {
+		t.Errorf("store with a finished job answered %d: %s", w.Code, w.Body)
+	}
+	if _, ok := s.jobs.get("0"); ok {
+		t.Error("finished job was not evicted")
+	}
+}
+
+// contextStore refuses to save with an ended context, as a database would.
+type contextStore struct {
+	*archive.MemoryStore
+}
+
+func (c contextStore) SaveRun(ctx context.Context, feedURL string, at time.Time, result *validator.ValidationResult) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return c.MemoryStore.SaveRun(ctx, feedURL, at, result)
+}
+
+func TestTimedOutJobStored(t *testing.T) {
+	feed := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(300 * time.Millisecond)
+	}))
+	defer feed.Close()
+
+	s := NewServer()
+	store := contextStore{archive.NewMemoryStore()}
+	s.SetStore(store)
+	body := `{"url":"` + feed.URL + `/gbfs.json","options":{"timeout":"100ms"}}`
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/jobs", strings.NewReader(body)))
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("status %d: %s", w.Code, w.Body)
+	}
+	var job Job
+	json.Unmarshal(w.Body.Bytes(), &job)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if runs, _ := store.ListRuns(context.Background(), job.FeedKey); len(runs) == 1 {
+			break
+		}
+		if time.Now().After(deadline) {
+			current, _ := s.jobs.get(job.ID)
+			t.Fatalf("timed-out job was not stored: %+v", current)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,103 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+)
+
+// retryAfterSeconds is the Retry-After sent when the server is saturated.
+const retryAfterSeconds = 10
+
+// limiter caps concurrent validations. Validations beyond the cap wait in
+// a bounded queue; beyond that they are turned away.
+type limiter struct {
+	admitted chan struct{}
+	running  chan struct{}
+}
+
+// slot is a place in the limiter's queue, held until released.
+type slot struct {
+	l       *limiter
+	running bool
+}
+
+// SetConcurrencyLimit runs at most max validations at once, with up to
+// queue more waiting for a free slot. Further requests get 503 with a
+// Retry-After header. Zero max removes the limit.
+func (s *Server) SetConcurrencyLimit(max, queue int) {
+	if max <= 0 {
+		s.limiter = nil
+		return
+	}
+	if queue < 0 {
+		queue = 0
+	}
+	s.limiter = &limiter{
+		admitted: make(chan struct{}, max+queue),
+		running:  make(chan struct{}, max),
+	}
+}
+
+// reserve takes a place in the queue without waiting, or reports false
+// when the queue is full. A nil limiter admits everything.
+func (l *limiter) reserve() (*slot, bool) {
+	if l == nil {
+		return &slot{}, true
+	}
+	select {
+	case l.admitted <- struct{}{}:
+		return &slot{l: l}, true
+	default:
+		return nil, false
+	}
+}
+
+// wait blocks until the slot may run or ctx is done.
+func (s *slot) wait(ctx context.Context) error {
+	if s.l == nil {
+		return nil
+	}
+	select {
+	case s.l.running <- struct{}{}:
+		s.running = true
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// release gives the slot back.
+func (s *slot) release() {
+	if s.l == nil {
+		return
+	}
+	if s.running {
+		<-s.l.running
+	}
+	<-s.l.admitted
+}
+
+// acquireSlot reserves and waits for a validation slot until ctx is done,
+// which is the request's context or a validation deadline derived from
+// it. It responds with 503 and returns false when the server is
+// saturated, the client gave up waiting, or the deadline passed first.
+func (s *Server) acquireSlot(ctx context.Context, w http.ResponseWriter) (*slot, bool) {
+	sl, ok := s.limiter.reserve()
+	if !ok {
+		respondBusy(w)
+		return nil, false
+	}
+	if err := sl.wait(ctx); err != nil {
+		sl.release()
+		respondBusy(w)
+		return nil, false
+	}
+	return sl, true
+}
+
+// respondBusy tells the client to retry later.
+func respondBusy(w http.ResponseWriter) {
+	w.Header().Set("Retry-After", strconv.Itoa(retryAfterSeconds))
+	respondError(w, http.StatusServiceUnavailable, "too many validations in progress; retry later")
+}
@@ -0,0 +1,89 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestConcurrencyLimit(t *testing.T) {
+	started := make(chan struct{}, 1)
+	unblock := make(chan struct{})
+	feed := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case started <- struct{}{}:
+		default:
+		}
+		<-unblock
+		http.NotFound(w, r)
+	}))
+	defer feed.Close()
+
+	s := NewServer()
+	s.SetConcurrencyLimit(1, 0)
+	validate := func() *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodPost, "/api/validator", strings.NewReader(`{"url":"`+feed.URL+`/gbfs.json"}`))
+		w := httptest.NewRecorder()
+		s.ServeHTTP(w, r)
+		return w
+	}
+
+	done := make(chan *httptest.ResponseRecorder)
+	go func() { done <- validate() }()
+	select {
+	case <-started:
+	case <-time.After(5 * time.Second):
+		t.Fatal("first validation did not start")
+	}
+
+	busy := validate()
+	if busy.Code != http.StatusServiceUnavailable || busy.Header().Get("Retry-After") == "" {
+		t.Errorf("saturated server answered %d, Retry-After %q", busy.Code, busy.Header().Get("Retry-After"))
+	}
+	jobs := httptest.NewRecorder()
+	s.ServeHTTP(jobs, httptest.NewRequest(http.MethodPost, "/api/jobs", strings.NewReader(`{"url":"`+feed.URL+`/gbfs.json"}`)))
+	if jobs.Code != http.StatusServiceUnavailable {
+		t.Errorf("saturated server accepted a job: %d", jobs.Code)
+	}
+	for _, path := range []string{"/api/validate-file?fileType=gbfs", "/api/validate-upload"} {
+		w := httptest.NewRecorder()
+		s.ServeHTTP(w, httptest.NewRequest(http.MethodPost, path, strings.NewReader(`{}`)))
+		if w.Code != http.StatusServiceUnavailable {
+			t.Errorf("saturated server answered %s with %d", path, w.Code)
+		}
+	}
+
+	close(unblock)
+	if first := <-done; first.Code != http.StatusOK {
+		t.Errorf("first validation answered %d", first.Code)
+	}
+	if again := validate(); again.Code != http.StatusOK {
+		t.Errorf("slot was not released: %d", again.Code)
+	}
+}
+
+func TestSlotWaitCountsAgainstTimeout(t *testing.T) {
+	s := NewServer()
+	s.SetConcurrencyLimit(1, 1)
+	held, _ := s.limiter.reserve()
+	if err := held.wait(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	defer held.release()
+
+	// The request waits in the queue for the held slot only as long as
+	// its own timeout.
+	start := time.Now()
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/validator",
+		strings.NewReader(`{"url":"https://example.com/gbfs.json","options":{"timeout":"50ms"}}`)))
+	if w.Code != http.StatusServiceUnavailable || w.Header().Get("Retry-After") == "" {
+		t.Errorf("queued past its timeout: %d, Retry-After %q", w.Code, w.Header().Get("Retry-After"))
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("answered after %v", elapsed)
+	}
+}
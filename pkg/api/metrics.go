@@ -0,0 +1,103 @@
+package api
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gbfs-validator-go/pkg/monitor"
+)
+
+// handleMetrics exposes monitored feed availability in the Prometheus text
+// format. When an admin token is set, scrapers must send it as a bearer
+// token.
+func (s *Server) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	if s.monitor == nil {
+		respondError(w, http.StatusNotFound, "monitoring is not enabled")
+		return
+	}
+	if s.adminToken != "" && !s.isAdmin(r) {
+		respondError(w, http.StatusUnauthorized, "invalid admin token")
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	writeAvailabilityMetrics(w, s.monitor.Availability("", time.Now()))
+	writeStationMetrics(w, s.monitor.Status(""))
+}
+
+// writeAvailabilityMetrics writes availability ratios as gauges. Windows
+// without runs are omitted.
+func writeAvailabilityMetrics(w io.Writer, feeds []monitor.FeedAvailability) {
+	fmt.Fprintln(w, "# HELP gbfs_feed_availability_ratio Share of monitor runs in the window where every required file was fetched and every checked file was valid.")
+	fmt.Fprintln(w, "# TYPE gbfs_feed_availability_ratio gauge")
+	for _, f := range feeds {
+		for _, window := range monitor.Windows {
+			if a := f.Windows[window.Name]; a.Percent != nil {
+				fmt.Fprintf(w, "gbfs_feed_availability_ratio{%s} %g\n",
+					labels("feed", f.ID, "tenant", f.Tenant, "window", window.Name), *a.Percent/100)
+			}
+		}
+	}
+
+	fmt.Fprintln(w, "# HELP gbfs_file_availability_ratio Share of monitor runs in the window where the file was fetched and valid.")
+	fmt.Fprintln(w, "# TYPE gbfs_file_availability_ratio gauge")
+	for _, f := range feeds {
+		files := make([]string, 0, len(f.Files))
+		for file := range f.Files {
+			files = append(files, file)
+		}
+		sort.Strings(files)
+		for _, file := range files {
+			for _, window := range monitor.Windows {
+				if a := f.Files[file][window.Name]; a.Percent != nil {
+					fmt.Fprintf(w, "gbfs_file_availability_ratio{%s} %g\n",
+						labels("feed", f.ID, "tenant", f.Tenant, "file", file, "window", window.Name), *a.Percent/100)
+				}
+			}
+		}
+	}
+
+	fmt.Fprintln(w, "# HELP gbfs_feed_runs Monitor runs in the window.")
+	fmt.Fprintln(w, "# TYPE gbfs_feed_runs gauge")
+	for _, f := range feeds {
+		for _, window := range monitor.Windows {
+			fmt.Fprintf(w, "gbfs_feed_runs{%s} %d\n",
+				labels("feed", f.ID, "tenant", f.Tenant, "window", window.Name), f.Windows[window.Name].Runs)
+		}
+	}
+}
+
+// writeStationMetrics writes the number of frozen stations and persistent
+// vehicle IDs per feed.
+func writeStationMetrics(w io.Writer, feeds []monitor.FeedStatus) {
+	fmt.Fprintln(w, "# HELP gbfs_frozen_stations Stations whose availability has not changed for the feed's frozen-data period.")
+	fmt.Fprintln(w, "# TYPE gbfs_frozen_stations gauge")
+	for _, f := range feeds {
+		fmt.Fprintf(w, "gbfs_frozen_stations{%s} %d\n", labels("feed", f.ID, "tenant", f.Tenant), f.FrozenStations)
+	}
+
+	fmt.Fprintln(w, "# HELP gbfs_persistent_vehicle_ids Vehicle IDs that survived more trips than the feed's rotation threshold.")
+	fmt.Fprintln(w, "# TYPE gbfs_persistent_vehicle_ids gauge")
+	for _, f := range feeds {
+		fmt.Fprintf(w, "gbfs_persistent_vehicle_ids{%s} %d\n", labels("feed", f.ID, "tenant", f.Tenant), f.PersistentVehicleIDs)
+	}
+}
+
+// labelEscaper escapes Prometheus label values.
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// labels formats name/value pairs as a Prometheus label set, skipping
+// empty values.
+func labels(pairs ...string) string {
+	var parts []string
+	for i := 0; i+1 < len(pairs); i += 2 {
+		if pairs[i+1] == "" {
+			continue
+		}
+		parts = append(parts, pairs[i]+`="`+labelEscaper.Replace(pairs[i+1])+`"`)
+	}
+	return strings.Join(parts, ",")
+}
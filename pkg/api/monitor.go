@@ -0,0 +1,279 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gbfs-validator-go/pkg/monitor"
+	"github.com/gbfs-validator-go/pkg/validator"
+)
+
+// SetMonitor exposes a running monitor's feeds. Tenants see and, with an
+// editor key, manage their own feeds; the admin token covers every feed.
+func (s *Server) SetMonitor(m *monitor.Monitor) {
+	s.monitor = m
+}
+
+// principal is the caller of a monitor endpoint. Tenant is empty for the
+// admin.
+type principal struct {
+	tenant string
+	role   monitor.Role
+}
+
+// tenantScope resolves the caller of a monitor endpoint, writing an error
+// response when the request is not authorized.
+func (s *Server) tenantScope(w http.ResponseWriter, r *http.Request) (principal, bool) {
+	if s.monitor == nil {
+		respondError(w, http.StatusNotFound, "monitoring is not enabled")
+		return principal{}, false
+	}
+	if s.isAdmin(r) {
+		return principal{role: monitor.RoleAdmin}, true
+	}
+	t, role, found := s.monitor.TenantForKey(bearerToken(r))
+	if !found {
+		respondError(w, http.StatusUnauthorized, "a tenant API key or admin token is required")
+		return principal{}, false
+	}
+	return principal{tenant: t.ID, role: role}, true
+}
+
+// scopedFeed resolves the caller and the feed named in the path, checking
+// that the caller may see it and, when edit is set, change it.
+func (s *Server) scopedFeed(w http.ResponseWriter, r *http.Request, edit bool) (principal, monitor.FeedStatus, bool) {
+	p, ok := s.tenantScope(w, r)
+	if !ok {
+		return p, monitor.FeedStatus{}, false
+	}
+	status, err := s.monitor.FeedStatus(r.PathValue("id"))
+	if err != nil || (p.tenant != "" && status.Tenant != p.tenant) {
+		respondError(w, http.StatusNotFound, "feed not found")
+		return p, status, false
+	}
+	if edit && !p.role.CanEdit() {
+		respondError(w, http.StatusForbidden, "this key may not change feeds")
+		return p, status, false
+	}
+	return p, status, true
+}
+
+// handleMonitorFeeds returns the latest status of the caller's monitored
+// feeds, with their open incidents.
+func (s *Server) handleMonitorFeeds(w http.ResponseWriter, r *http.Request) {
+	p, ok := s.tenantScope(w, r)
+	if !ok {
+		return
+	}
+	feeds := s.monitor.Status(p.tenant)
+	if feeds == nil {
+		feeds = []monitor.FeedStatus{}
+	}
+	respondJSON(w, http.StatusOK, map[string]interface{}{"tenant": p.tenant, "feeds": feeds})
+}
+
+// MonitoredFeedResponse is a monitored feed with its last result.
+type MonitoredFeedResponse struct {
+	monitor.FeedStatus
+	Result *validator.ValidationResult `json:"result,omitempty"`
+}
+
+// handleMonitorFeed returns a feed's status and the result of its last run.
+func (s *Server) handleMonitorFeed(w http.ResponseWriter, r *http.Request) {
+	_, status, ok := s.scopedFeed(w, r, false)
+	if !ok {
+		return
+	}
+	result, err := s.monitor.LastResult(status.ID)
+	if err != nil {
+		respondMonitorError(w, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, MonitoredFeedResponse{FeedStatus: status, Result: result})
+}
+
+// handleMonitorAvailability returns rolling availability of the caller's
+// monitored feeds.
+func (s *Server) handleMonitorAvailability(w http.ResponseWriter, r *http.Request) {
+	p, ok := s.tenantScope(w, r)
+	if !ok {
+		return
+	}
+	feeds := s.monitor.Availability(p.tenant, time.Now())
+	if feeds == nil {
+		feeds = []monitor.FeedAvailability{}
+	}
+	respondJSON(w, http.StatusOK, map[string]interface{}{"tenant": p.tenant, "feeds": feeds})
+}
+
+// handleMonitorFeedAvailability returns rolling availability of one feed
+// and its files.
+func (s *Server) handleMonitorFeedAvailability(w http.ResponseWriter, r *http.Request) {
+	_, status, ok := s.scopedFeed(w, r, false)
+	if !ok {
+		return
+	}
+	availability, err := s.monitor.FeedAvailability(status.ID, time.Now())
+	if err != nil {
+		respondMonitorError(w, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, availability)
+}
+
+// handleMonitorFeedChurn returns how often a feed's station availability
+// changes and which stations look frozen.
+func (s *Server) handleMonitorFeedChurn(w http.ResponseWriter, r *http.Request) {
+	_, status, ok := s.scopedFeed(w, r, false)
+	if !ok {
+		return
+	}
+	churn, err := s.monitor.Churn(status.ID, time.Now())
+	if err != nil {
+		respondMonitorError(w, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, churn)
+}
+
+// handleMonitorFeedRotation returns the feed's vehicle IDs that persist
+// across trips.
+func (s *Server) handleMonitorFeedRotation(w http.ResponseWriter, r *http.Request) {
+	_, status, ok := s.scopedFeed(w, r, false)
+	if !ok {
+		return
+	}
+	rotation, err := s.monitor.Rotation(status.ID)
+	if err != nil {
+		respondMonitorError(w, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, rotation)
+}
+
+// handleMonitorFeedUnchanged returns how long the content of the feed's
+// files has stayed the same.
+func (s *Server) handleMonitorFeedUnchanged(w http.ResponseWriter, r *http.Request) {
+	_, status, ok := s.scopedFeed(w, r, false)
+	if !ok {
+		return
+	}
+	unchanged, err := s.monitor.Unchanged(status.ID, time.Now())
+	if err != nil {
+		respondMonitorError(w, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, unchanged)
+}
+
+// handleAddMonitorFeed starts monitoring a feed. Tenant callers always add
+// to their own tenant; the admin may name any tenant.
+func (s *Server) handleAddMonitorFeed(w http.ResponseWriter, r *http.Request) {
+	p, ok := s.tenantScope(w, r)
+	if !ok {
+		return
+	}
+	if !p.role.CanEdit() {
+		respondError(w, http.StatusForbidden, "this key may not change feeds")
+		return
+	}
+	var f monitor.Feed
+	if err := json.NewDecoder(r.Body).Decode(&f); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if p.tenant != "" {
+		f.Tenant = p.tenant
+	}
+	status, err := s.monitor.AddFeed(f)
+	if err != nil {
+		respondMonitorError(w, err)
+		return
+	}
+	w.Header().Set("Location", "/api/monitor/feeds/"+status.ID)
+	respondJSON(w, http.StatusCreated, status)
+}
+
+// handleUpdateMonitorFeed replaces a managed feed's settings.
+func (s *Server) handleUpdateMonitorFeed(w http.ResponseWriter, r *http.Request) {
+	_, current, ok := s.scopedFeed(w, r, true)
+	if !ok {
+		return
+	}
+	var f monitor.Feed
+	if err := json.NewDecoder(r.Body).Decode(&f); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	status, err := s.monitor.UpdateFeed(current.ID, f)
+	if err != nil {
+		respondMonitorError(w, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, status)
+}
+
+// handleDeleteMonitorFeed stops monitoring a managed feed.
+func (s *Server) handleDeleteMonitorFeed(w http.ResponseWriter, r *http.Request) {
+	_, current, ok := s.scopedFeed(w, r, true)
+	if !ok {
+		return
+	}
+	if err := s.monitor.RemoveFeed(current.ID); err != nil {
+		respondMonitorError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handlePauseMonitorFeed returns a handler that pauses or resumes a feed.
+func (s *Server) handlePauseMonitorFeed(paused bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		_, current, ok := s.scopedFeed(w, r, true)
+		if !ok {
+			return
+		}
+		status, err := s.monitor.SetPaused(current.ID, paused)
+		if err != nil {
+			respondMonitorError(w, err)
+			return
+		}
+		respondJSON(w, http.StatusOK, status)
+	}
+}
+
+// respondMonitorError maps monitor errors to HTTP statuses.
+func respondMonitorError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, monitor.ErrFeedNotFound):
+		respondError(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, monitor.ErrFeedExists), errors.Is(err, monitor.ErrFeedNotManaged):
+		respondError(w, http.StatusConflict, err.Error())
+	case errors.Is(err, monitor.ErrInvalidFeed):
+		respondError(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, monitor.ErrNotLeader):
+		respondError(w, http.StatusServiceUnavailable, err.Error())
+	default:
+		respondError(w, http.StatusInternalServerError, err.Error())
+	}
+}
+
+// authorizeFeed checks that a request may read a feed's history. Access is
+// open unless the monitor has tenants, in which case only the admin and the
+// owning tenant may read it.
+func (s *Server) authorizeFeed(w http.ResponseWriter, r *http.Request, feedKey string) bool {
+	if s.monitor == nil || len(s.monitor.Tenants()) == 0 {
+		return true
+	}
+	p, ok := s.tenantScope(w, r)
+	if !ok {
+		return false
+	}
+	if p.tenant != "" && !s.monitor.OwnsFeedKey(p.tenant, feedKey) {
+		respondError(w, http.StatusNotFound, "feed not found")
+		return false
+	}
+	return true
+}
@@ -0,0 +1,123 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gbfs-validator-go/pkg/monitor"
+	"github.com/gbfs-validator-go/pkg/secret"
+)
+
+func TestMonitorFeedAPI(t *testing.T) {
+	statePath := filepath.Join(t.TempDir(), "state.json")
+	key, _ := secret.GenerateKey()
+	box, err := secret.ParseKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := monitor.New([]monitor.Feed{{ID: "own", URL: "https://example.com/own/gbfs.json"}}, monitor.WithTenants([]monitor.Tenant{
+		{ID: "acme", APIKeys: []string{"acme-view"}, EditorKeys: []string{"acme-edit"}},
+		{ID: "zoom", EditorKeys: []string{"zoom-edit"}},
+	}), monitor.WithSecrets(box))
+	if err := m.OpenState(statePath); err != nil {
+		t.Fatal(err)
+	}
+	s := NewServer()
+	s.SetAdminToken("admin")
+	s.SetMonitor(m)
+
+	do := func(method, path, key, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		if key != "" {
+			req.Header.Set("Authorization", "Bearer "+key)
+		}
+		rec := httptest.NewRecorder()
+		s.ServeHTTP(rec, req)
+		return rec
+	}
+
+	feed := `{"id":"bikes","url":"https://example.com/bikes/gbfs.json","tenant":"zoom","auth":{"type":"bearer_token","bearerToken":{"token":"s3cret"}}}`
+	if rec := do(http.MethodPost, "/api/monitor/feeds", "acme-view", feed); rec.Code != http.StatusForbidden {
+		t.Errorf("viewer add: status %d", rec.Code)
+	}
+	rec := do(http.MethodPost, "/api/monitor/feeds", "acme-edit", feed)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("editor add: status %d: %s", rec.Code, rec.Body)
+	}
+	if strings.Contains(rec.Body.String(), "s3cret") {
+		t.Errorf("response leaks the token: %s", rec.Body)
+	}
+	var status monitor.FeedStatus
+	json.Unmarshal(rec.Body.Bytes(), &status)
+	if status.Tenant != "acme" || !status.Managed || !status.Auth.Configured {
+		t.Errorf("added feed = %+v, want a managed acme feed", status)
+	}
+
+	if rec := do(http.MethodGet, "/api/monitor/feeds/bikes", "zoom-edit", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("other tenant read: status %d", rec.Code)
+	}
+	if rec := do(http.MethodGet, "/api/monitor/feeds/bikes", "acme-view", ""); rec.Code != http.StatusOK {
+		t.Errorf("viewer read: status %d", rec.Code)
+	}
+	if rec := do(http.MethodPut, "/api/monitor/feeds/own", "admin", `{"url":"https://example.com/x"}`); rec.Code != http.StatusConflict {
+		t.Errorf("update of a config feed: status %d", rec.Code)
+	}
+	if rec := do(http.MethodPost, "/api/monitor/feeds/own/pause", "admin", ""); rec.Code != http.StatusOK {
+		t.Errorf("admin pause: status %d", rec.Code)
+	}
+	if rec := do(http.MethodPut, "/api/monitor/feeds/bikes", "acme-edit", `{"url":"https://example.com/bikes/v2/gbfs.json"}`); rec.Code != http.StatusOK {
+		t.Errorf("editor update: status %d: %s", rec.Code, rec.Body)
+	}
+
+	if err := monitor.New(nil, monitor.WithTenants(m.Tenants())).OpenState(statePath); !errors.Is(err, secret.ErrNoKey) {
+		t.Errorf("opening sealed state without a key: %v", err)
+	}
+	restored := monitor.New(nil, monitor.WithTenants(m.Tenants()), monitor.WithSecrets(box))
+	if err := restored.OpenState(statePath); err != nil {
+		t.Fatal(err)
+	}
+	status, err = restored.FeedStatus("bikes")
+	if err != nil || status.URL != "https://example.com/bikes/v2/gbfs.json" || !status.Auth.Configured {
+		t.Errorf("restored feed = %+v, %v", status, err)
+	}
+	data, _ := os.ReadFile(statePath)
+	if !strings.Contains(string(data), `"own"`) {
+		t.Errorf("state does not record the paused config feed: %s", data)
+	}
+	if strings.Contains(string(data), "s3cret") {
+		t.Errorf("state stores the token in plain text: %s", data)
+	}
+
+	if rec := do(http.MethodDelete, "/api/monitor/feeds/bikes", "acme-edit", ""); rec.Code != http.StatusNoContent {
+		t.Errorf("editor delete: status %d", rec.Code)
+	}
+	if rec := do(http.MethodGet, "/api/monitor/feeds/bikes", "admin", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("read after delete: status %d", rec.Code)
+	}
+}
+
+func TestMetrics(t *testing.T) {
+	s := NewServer()
+	s.SetAdminToken("admin")
+	s.SetMonitor(monitor.New([]monitor.Feed{{ID: `a"b`, URL: "https://example.com/gbfs.json"}}))
+
+	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, req)
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("metrics without token: status %d", rec.Code)
+	}
+
+	req.Header.Set("Authorization", "Bearer admin")
+	rec = httptest.NewRecorder()
+	s.ServeHTTP(rec, req)
+	if !strings.Contains(rec.Body.String(), `gbfs_feed_runs{feed="a\"b",window="24h"} 0`) {
+		t.Errorf("unexpected metrics:\n%s", rec.Body)
+	}
+}
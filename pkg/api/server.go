@@ -2,12 +2,25 @@
 package api
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
 	"net/http"
 	"os"
+	"sort"
+	"time"
 
+	"github.com/gbfs-validator-go/pkg/archive"
+	"github.com/gbfs-validator-go/pkg/coerce"
 	"github.com/gbfs-validator-go/pkg/fetcher"
 	"github.com/gbfs-validator-go/pkg/gbfs"
+	"github.com/gbfs-validator-go/pkg/monitor"
+	"github.com/gbfs-validator-go/pkg/report"
+	"github.com/gbfs-validator-go/pkg/schema"
+	"github.com/gbfs-validator-go/pkg/signing"
 	"github.com/gbfs-validator-go/pkg/validator"
 	"github.com/gbfs-validator-go/pkg/version"
 )
@@ -16,6 +29,26 @@ import (
 type Server struct {
 	mux        *http.ServeMux
 	staticFS   http.Handler
+	schemas    *schema.Bundle
+	catalog    validator.Catalog
+
+	audit          *auditWriter
+	trustedProxies []*net.IPNet
+
+	jobs       jobStore
+	archive    *archive.Archive
+	store      archive.Store
+	adminToken string
+	signer     *signing.Signer
+	monitor    *monitor.Monitor
+
+	fetcherOpts []fetcher.Option
+	httpClient  *http.Client
+	cache       *resultCache
+	limiter     *limiter
+	// timeout and fileTimeout are set by SetValidationTimeouts.
+	timeout     time.Duration
+	fileTimeout time.Duration
 }
 
 // NewServer builds a server with API routes only.
@@ -37,10 +70,43 @@ func NewServerWithStatic(staticDir string) *Server {
 	return s
 }
 
+// SetSchemas overrides the embedded schema bundle used for validation.
+func (s *Server) SetSchemas(b *schema.Bundle) {
+	s.schemas = b
+}
+
+// SetCatalog checks every validated feed's system_id against a feed
+// catalog.
+func (s *Server) SetCatalog(c validator.Catalog) {
+	s.catalog = c
+}
+
+// WarmSchemas compiles the schemas of the given versions, or of every
+// version, before the first request needs them.
+func (s *Server) WarmSchemas(versions ...string) error {
+	b := s.schemas
+	if b == nil {
+		b = validator.DefaultSchemas()
+	}
+	return b.Warm(versions...)
+}
+
+// SetArchive stores the results and snapshots of asynchronous jobs, and
+// serves trends from them unless SetStore selects another store.
+func (s *Server) SetArchive(a *archive.Archive) {
+	s.archive = a
+}
+
+// SetStore also stores the results of asynchronous jobs in st, such as a
+// database opened with archive.OpenStore, and serves trends from it.
+func (s *Server) SetStore(st archive.Store) {
+	s.store = st
+}
+
 // ServeHTTP adds CORS headers and dispatches to routes.
 func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Access-Control-Allow-Origin", "*")
-	w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
+	w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
 	w.Header().Set("Access-Control-Allow-Headers", "*")
 
 	if r.Method == "OPTIONS" {
@@ -53,15 +119,39 @@ func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 
 // setupRoutes registers API and static routes.
 func (s *Server) setupRoutes() {
-	s.mux.HandleFunc("/api/validator", s.handleValidate)
-	s.mux.HandleFunc("/api/feed", s.handleFeed)
-	s.mux.HandleFunc("/api/validator-summary", s.handleValidatorSummary)
+	s.mux.HandleFunc("/api/validator", s.audited(s.handleValidate))
+	s.mux.HandleFunc("/api/feed", s.audited(s.handleFeed))
+	s.mux.HandleFunc("/api/validator-summary", s.audited(s.handleValidatorSummary))
+	s.mux.HandleFunc("POST /api/validate-file", s.audited(s.handleValidateFile))
+	s.mux.HandleFunc("POST /api/validate-upload", s.audited(s.handleValidateUpload))
+	s.mux.HandleFunc("/api/jobs", s.audited(s.handleJobs))
+	s.mux.HandleFunc("POST /api/coverage", s.audited(s.handleCoverage))
+	s.mux.HandleFunc("POST /api/compare", s.audited(s.handleCompare))
+	s.mux.HandleFunc("POST /api/mds-check", s.audited(s.handleMDSCheck))
+	s.mux.HandleFunc("GET /api/jobs/{id}", s.handleJob)
+	s.mux.HandleFunc("POST /api/admin/prune", s.handlePrune)
+	s.mux.HandleFunc("GET /api/feeds/{id}/trends", s.handleTrends)
+	s.mux.HandleFunc("GET /api/monitor/feeds", s.handleMonitorFeeds)
+	s.mux.HandleFunc("POST /api/monitor/feeds", s.handleAddMonitorFeed)
+	s.mux.HandleFunc("GET /api/monitor/feeds/{id}", s.handleMonitorFeed)
+	s.mux.HandleFunc("GET /api/monitor/feeds/{id}/availability", s.handleMonitorFeedAvailability)
+	s.mux.HandleFunc("GET /api/monitor/feeds/{id}/churn", s.handleMonitorFeedChurn)
+	s.mux.HandleFunc("GET /api/monitor/feeds/{id}/rotation", s.handleMonitorFeedRotation)
+	s.mux.HandleFunc("GET /api/monitor/feeds/{id}/unchanged", s.handleMonitorFeedUnchanged)
+	s.mux.HandleFunc("GET /api/monitor/availability", s.handleMonitorAvailability)
+	s.mux.HandleFunc("PUT /api/monitor/feeds/{id}", s.handleUpdateMonitorFeed)
+	s.mux.HandleFunc("DELETE /api/monitor/feeds/{id}", s.handleDeleteMonitorFeed)
+	s.mux.HandleFunc("POST /api/monitor/feeds/{id}/pause", s.handlePauseMonitorFeed(true))
+	s.mux.HandleFunc("POST /api/monitor/feeds/{id}/resume", s.handlePauseMonitorFeed(false))
 	
 	s.mux.HandleFunc("/api/gbfs", s.handleGBFS)
 	s.mux.HandleFunc("/api/proxy", s.handleProxy)
 	s.mux.HandleFunc("/api/config", s.handleConfig)
+	s.mux.HandleFunc("GET /api/rules", s.handleRules)
+	s.mux.HandleFunc("GET /api/signing-key", s.handleSigningKey)
 
 	s.mux.HandleFunc("/health", s.handleHealth)
+	s.mux.HandleFunc("GET /metrics", s.handleMetrics)
 	
 	if s.staticFS != nil {
 		s.mux.Handle("/", s.staticFS)
@@ -84,6 +174,78 @@ type ValidateOptions struct {
 	LenientMode bool `json:"lenientMode"`
 	
 	CoerceOptions *CoerceOptions `json:"coerceOptions,omitempty"`
+
+	Profile                    string `json:"profile,omitempty"`
+	MissingRecommendedSeverity string `json:"missingRecommendedSeverity,omitempty"`
+	// ReferencedFileSeverity sets, by check ID, the severity of optional
+	// files referenced by ID but absent.
+	ReferencedFileSeverity map[string]string `json:"referencedFileSeverity,omitempty"`
+
+	// TreatWarningsAsErrors fails the feed on warnings as well as errors.
+	TreatWarningsAsErrors bool `json:"treatWarningsAsErrors,omitempty"`
+
+	Languages []string `json:"languages,omitempty"`
+
+	FeedURLOverrides map[string]string `json:"feedUrlOverrides,omitempty"`
+
+	// FeedURLs validates these feed URLs directly when the feed has no
+	// gbfs.json; url may then be left empty.
+	FeedURLs map[string]string `json:"feedUrls,omitempty"`
+
+	Extensions []validator.Extension `json:"extensions,omitempty"`
+
+	DepotThreshold float64 `json:"depotThreshold,omitempty"`
+
+	// Currencies lists the currencies pricing plans may mix.
+	Currencies []string `json:"currencies,omitempty"`
+
+	// CheckImages probes image URLs; ImageMaxBytes overrides the size limit.
+	CheckImages   bool  `json:"checkImages,omitempty"`
+	ImageMaxBytes int64 `json:"imageMaxBytes,omitempty"`
+
+	// CheckAlertURLs probes alert URLs.
+	CheckAlertURLs bool `json:"checkAlertUrls,omitempty"`
+
+	// CompareVersions compares the feed with the other versions listed in
+	// gbfs_versions.json.
+	CompareVersions bool `json:"compareVersions,omitempty"`
+
+	// SameOrigin warns about feeds served from another origin than gbfs.json.
+	SameOrigin bool `json:"sameOrigin,omitempty"`
+
+	// DataQuality adds warnings about valid but hard to use data.
+	DataQuality bool `json:"dataQuality,omitempty"`
+
+	// RulePacks are regulatory rule packs evaluated after validation.
+	RulePacks []validator.RulePack `json:"rulePacks,omitempty"`
+
+	// SizeBudgets maps file names to the size in bytes above which a file
+	// raises a warning, overriding the defaults.
+	SizeBudgets map[string]int64 `json:"sizeBudgets,omitempty"`
+
+	// MinCoordinateDecimals and MaxCoordinateDecimals bound the decimal
+	// places of coordinates; 0 selects 4 and 8, a negative value disables.
+	MinCoordinateDecimals int `json:"minCoordinateDecimals,omitempty"`
+	MaxCoordinateDecimals int `json:"maxCoordinateDecimals,omitempty"`
+
+	// SampleSize checks only that many entries of large arrays, the first
+	// ones or random ones with SampleRandom.
+	SampleSize   int  `json:"sampleSize,omitempty"`
+	SampleRandom bool `json:"sampleRandom,omitempty"`
+
+	// Headers are sent with every fetch in addition to Auth, which wins
+	// when both set the same header.
+	Headers   map[string]string `json:"headers,omitempty"`
+	UserAgent string            `json:"userAgent,omitempty"`
+
+	// HTTP1 disables HTTP/2 for servers with broken HTTP/2 support.
+	HTTP1 bool `json:"http1,omitempty"`
+
+	// Timeout and FileTimeout shorten the server's limits on the whole
+	// validation and on each file request, as durations such as "20s";
+	// see Server.SetValidationTimeouts.
+	Timeout     string `json:"timeout,omitempty"`
+	FileTimeout string `json:"fileTimeout,omitempty"`
 }
 
 // CoerceOptions selects coercions when lenient mode is on.
@@ -102,44 +264,285 @@ func (s *Server) handleValidate(w http.ResponseWriter, r *http.Request) {
 		respondError(w, http.StatusBadRequest, "Invalid request body")
 		return
 	}
+	recordRequest(r, req)
 
-	if req.URL == "" {
-		respondError(w, http.StatusBadRequest, "URL is required")
+	if err := checkRulePacks(req.Options); err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	// A dry run lists the files and checks the validation would involve
+	// without fetching anything.
+	if r.URL.Query().Get("dryRun") == "true" {
+		respondJSON(w, http.StatusOK, validator.Plan(req.URL, s.validatorOptions(req.Options)))
+		return
+	}
+	if req.URL == "" && (req.Options == nil || len(req.Options.FeedURLs) == 0) {
+		respondError(w, http.StatusBadRequest, "URL or options.feedUrls is required")
+		return
+	}
+	format := r.URL.Query().Get("format")
+	if _, ok := report.Lookup(format); format != "" && !ok {
+		respondError(w, http.StatusBadRequest, "Unknown format: "+format)
 		return
 	}
 
-	fetcherOpts := []fetcher.Option{}
-	if req.Options != nil && req.Options.Auth != nil {
-		fetcherOpts = append(fetcherOpts, fetcher.WithAuth(req.Options.Auth))
-	}
-	f := fetcher.New(fetcherOpts...)
-
-	validatorOpts := validator.Options{}
-	if req.Options != nil {
-		validatorOpts.Docked = req.Options.Docked
-		validatorOpts.Freefloating = req.Options.Freefloating
-		validatorOpts.Version = req.Options.Version
-		validatorOpts.LenientMode = req.Options.LenientMode
-		
-		if req.Options.CoerceOptions != nil {
-			validatorOpts.CoerceOptions = &validator.CoerceOptions{
-				CoerceBooleans:       req.Options.CoerceOptions.CoerceBooleans,
-				CoerceTimestamps:     req.Options.CoerceOptions.CoerceTimestamps,
-				CoerceNumericStrings: req.Options.CoerceOptions.CoerceNumericStrings,
-				CoerceCoordinates:    req.Options.CoerceOptions.CoerceCoordinates,
-				TreatNullAsAbsent:    req.Options.CoerceOptions.TreatNullAsAbsent,
-			}
+	result, ok := s.validate(w, r, req)
+	if !ok {
+		return
+	}
+	recordOutcome(r, result)
+
+	s.respondResult(w, format, result)
+}
+
+// maxFileBody bounds the document accepted by /api/validate-file.
+const maxFileBody = 64 << 20
+
+// handleValidateFile validates one feed document sent as the request body.
+// The fileType query parameter names the file, e.g. station_status, and
+// version the GBFS version when the document does not declare it. lenient
+// and format work as for /api/validator.
+func (s *Server) handleValidateFile(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	fileType := query.Get("fileType")
+	if fileType == "" {
+		respondError(w, http.StatusBadRequest, "fileType is required")
+		return
+	}
+	format := query.Get("format")
+	if _, ok := report.Lookup(format); format != "" && !ok {
+		respondError(w, http.StatusBadRequest, "Unknown format: "+format)
+		return
+	}
+	sl, ok := s.acquireSlot(r.Context(), w)
+	if !ok {
+		return
+	}
+	defer sl.release()
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxFileBody))
+	if err != nil {
+		respondError(w, http.StatusRequestEntityTooLarge, "Document is larger than 64 MiB")
+		return
+	}
+
+	v := validator.NewOffline(s.validatorOptions(&ValidateOptions{LenientMode: query.Get("lenient") == "true"}))
+	result, err := v.ValidateFile(fileType, query.Get("version"), body)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	recordOutcome(r, result)
+	s.respondResult(w, format, result)
+}
+
+// respondResult writes a validation result in a format registered with
+// pkg/report. JSON is the default. With a signer set, the body carries a
+// detached signature.
+func (s *Server) respondResult(w http.ResponseWriter, format string, result *validator.ValidationResult) {
+	var buf bytes.Buffer
+	contentType := "application/json"
+	if format == "" || format == "json" {
+		if err := json.NewEncoder(&buf).Encode(result); err != nil {
+			respondError(w, http.StatusInternalServerError, err.Error())
+			return
 		}
+	} else {
+		f, _ := report.Lookup(format)
+		if err := f.Renderer.Render(result, &buf); err != nil {
+			respondError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		contentType = f.ContentType
 	}
-	v := validator.New(f, validatorOpts)
+	s.sign(w, buf.Bytes())
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(http.StatusOK)
+	w.Write(buf.Bytes())
+}
 
-	result, err := v.Validate(r.Context(), req.URL)
+// validate runs a request's validation, from the result cache when it is
+// enabled, within the concurrency limit. It responds with the error and
+// returns false when the validation could not run.
+func (s *Server) validate(w http.ResponseWriter, r *http.Request, req ValidateRequest) (*validator.ValidationResult, bool) {
+	if err := checkTimeouts(req.Options); err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return nil, false
+	}
+	if err := checkSeverities(req.Options); err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return nil, false
+	}
+	if err := checkFeedURLOverrides(req.Options); err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return nil, false
+	}
+	busy := false
+	result, err := s.validateCached(w, r, req, func() (*validator.ValidationResult, error) {
+		// The wait for a slot counts against the validation timeout, so a
+		// saturated server answers within it.
+		ctx, cancel := s.validationContext(r.Context(), req.Options)
+		defer cancel()
+		sl, ok := s.acquireSlot(ctx, w)
+		if !ok {
+			busy = true
+			return nil, errors.New("server busy")
+		}
+		defer sl.release()
+		v := validator.New(s.newFetcher(req.Options), s.validatorOptions(req.Options))
+		return v.Validate(ctx, req.URL)
+	})
+	if busy {
+		return nil, false
+	}
 	if err != nil {
 		respondError(w, http.StatusInternalServerError, err.Error())
-		return
+		return nil, false
+	}
+	return result, true
+}
+
+// SetFetcherOptions applies network settings, such as a DNS resolver or IP
+// version, and identification, such as a user agent or contact header, to
+// every fetch the server makes.
+func (s *Server) SetFetcherOptions(opts ...fetcher.Option) {
+	s.fetcherOpts = opts
+}
+
+// SetHTTPClient sends every fetch the server makes through c, such as a
+// client with instrumentation, a cache, or a test transport. Network
+// settings from SetFetcherOptions that configure the default transport do
+// not apply to c.
+func (s *Server) SetHTTPClient(c *http.Client) {
+	s.httpClient = c
+}
+
+// newFetcher builds a fetcher with the server's HTTP client, network
+// settings, and file timeout and the request's auth, headers, and user
+// agent.
+func (s *Server) newFetcher(opts *ValidateOptions) *fetcher.Fetcher {
+	var fetcherOpts []fetcher.Option
+	if s.httpClient != nil {
+		fetcherOpts = append(fetcherOpts, fetcher.WithHTTPClient(s.httpClient))
+	}
+	fetcherOpts = append(fetcherOpts, s.fetcherOpts...)
+	if d := s.fileTimeoutFor(opts); d > 0 {
+		fetcherOpts = append(fetcherOpts, fetcher.WithTimeout(d))
+	}
+	if opts != nil {
+		if opts.Auth != nil {
+			fetcherOpts = append(fetcherOpts, fetcher.WithAuth(opts.Auth))
+		}
+		if len(opts.Headers) > 0 {
+			fetcherOpts = append(fetcherOpts, fetcher.WithHeaders(opts.Headers))
+		}
+		if opts.UserAgent != "" {
+			fetcherOpts = append(fetcherOpts, fetcher.WithUserAgent(opts.UserAgent))
+		}
+		if opts.HTTP1 {
+			fetcherOpts = append(fetcherOpts, fetcher.WithHTTP1())
+		}
+	}
+	return fetcher.New(fetcherOpts...)
+}
+
+// checkSeverities reports an unknown profile, or an unknown severity or
+// check in the severity overrides of the options.
+func checkSeverities(opts *ValidateOptions) error {
+	if opts == nil {
+		return nil
+	}
+	if err := validator.CheckProfile(opts.Profile); err != nil {
+		return fmt.Errorf("profile: %w", err)
+	}
+	if opts.MissingRecommendedSeverity != "" {
+		if _, err := validator.ParseSeverity(opts.MissingRecommendedSeverity); err != nil {
+			return fmt.Errorf("missingRecommendedSeverity: %w", err)
+		}
+	}
+	for check, severity := range opts.ReferencedFileSeverity {
+		if err := validator.CheckReferencedFileSeverity(check, severity); err != nil {
+			return fmt.Errorf("referencedFileSeverity: %w", err)
+		}
+	}
+	return nil
+}
+
+// checkFeedURLOverrides reports an unknown feed name or malformed URL in
+// the feed URL overrides of the options.
+func checkFeedURLOverrides(opts *ValidateOptions) error {
+	if opts == nil {
+		return nil
+	}
+	for name, u := range opts.FeedURLOverrides {
+		if err := validator.CheckFeedURLOverride(name, u); err != nil {
+			return fmt.Errorf("feedUrlOverrides: %w", err)
+		}
+	}
+	return nil
+}
+
+// checkRulePacks reports the first malformed rule pack in the options.
+func checkRulePacks(opts *ValidateOptions) error {
+	if opts == nil {
+		return nil
+	}
+	for _, pack := range opts.RulePacks {
+		if err := pack.Validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validatorOptions converts request options to validator options.
+func (s *Server) validatorOptions(opts *ValidateOptions) validator.Options {
+	validatorOpts := validator.Options{Schemas: s.schemas, Catalog: s.catalog}
+	if opts == nil {
+		return validatorOpts
+	}
+
+	validatorOpts.Docked = opts.Docked
+	validatorOpts.Freefloating = opts.Freefloating
+	validatorOpts.Version = opts.Version
+	validatorOpts.LenientMode = opts.LenientMode
+	validatorOpts.Profile = opts.Profile
+	validatorOpts.Languages = opts.Languages
+	validatorOpts.FeedURLOverrides = opts.FeedURLOverrides
+	validatorOpts.FeedURLs = opts.FeedURLs
+	validatorOpts.Extensions = opts.Extensions
+	validatorOpts.RulePacks = opts.RulePacks
+	validatorOpts.DepotThreshold = opts.DepotThreshold
+	validatorOpts.Currencies = opts.Currencies
+	validatorOpts.CheckImages = opts.CheckImages
+	validatorOpts.CheckAlertURLs = opts.CheckAlertURLs
+	validatorOpts.CompareVersions = opts.CompareVersions
+	validatorOpts.ImageMaxBytes = opts.ImageMaxBytes
+	validatorOpts.SameOrigin = opts.SameOrigin
+	validatorOpts.DataQuality = opts.DataQuality
+	validatorOpts.TreatWarningsAsErrors = opts.TreatWarningsAsErrors
+	validatorOpts.SizeBudgets = opts.SizeBudgets
+	validatorOpts.MinCoordinateDecimals = opts.MinCoordinateDecimals
+	validatorOpts.MaxCoordinateDecimals = opts.MaxCoordinateDecimals
+	validatorOpts.SampleSize = opts.SampleSize
+	validatorOpts.SampleRandom = opts.SampleRandom
+	validatorOpts.MissingRecommendedSeverity = validator.ValidationSeverity(opts.MissingRecommendedSeverity)
+	if len(opts.ReferencedFileSeverity) > 0 {
+		validatorOpts.ReferencedFileSeverity = make(map[string]validator.ValidationSeverity, len(opts.ReferencedFileSeverity))
+		for check, severity := range opts.ReferencedFileSeverity {
+			validatorOpts.ReferencedFileSeverity[check] = validator.ValidationSeverity(severity)
+		}
 	}
 
-	respondJSON(w, http.StatusOK, result)
+	if opts.CoerceOptions != nil {
+		validatorOpts.CoerceOptions = &validator.CoerceOptions{
+			CoerceBooleans:       opts.CoerceOptions.CoerceBooleans,
+			CoerceTimestamps:     opts.CoerceOptions.CoerceTimestamps,
+			CoerceNumericStrings: opts.CoerceOptions.CoerceNumericStrings,
+			CoerceCoordinates:    opts.CoerceOptions.CoerceCoordinates,
+			TreatNullAsAbsent:    opts.CoerceOptions.TreatNullAsAbsent,
+		}
+	}
+	return validatorOpts
 }
 
 // FeedResponse returns feed data for the viewer.
@@ -171,17 +574,14 @@ func (s *Server) handleFeed(w http.ResponseWriter, r *http.Request) {
 		respondError(w, http.StatusBadRequest, "Invalid request body")
 		return
 	}
+	recordRequest(r, req)
 
 	if req.URL == "" {
 		respondError(w, http.StatusBadRequest, "URL is required")
 		return
 	}
 
-	fetcherOpts := []fetcher.Option{}
-	if req.Options != nil && req.Options.Auth != nil {
-		fetcherOpts = append(fetcherOpts, fetcher.WithAuth(req.Options.Auth))
-	}
-	f := fetcher.New(fetcherOpts...)
+	f := s.newFetcher(req.Options)
 
 	var gbfsFeed gbfs.GBFSFeed
 	result := f.FetchJSON(r.Context(), req.URL, &gbfsFeed)
@@ -245,6 +645,8 @@ func (s *Server) handleFeed(w http.ResponseWriter, r *http.Request) {
 type ValidationSummaryResponse struct {
 	Summary      validator.ValidationSummary `json:"summary"`
 	FilesSummary []FileSummary               `json:"filesSummary"`
+	// Coercions summarizes lenient-mode coercions across all files.
+	Coercions *coerce.CoercionSummary `json:"coercions,omitempty"`
 }
 
 // FileSummary aggregates errors for a single file.
@@ -252,9 +654,12 @@ type FileSummary struct {
 	Required      bool           `json:"required"`
 	Exists        bool           `json:"exists"`
 	File          string         `json:"file"`
+	Status        validator.FileStatus `json:"status"`
 	HasErrors     bool           `json:"hasErrors"`
 	ErrorsCount   int            `json:"errorsCount"`
 	GroupedErrors []GroupedError `json:"groupedErrors"`
+	// Coercions summarizes the file's coercions by field and type.
+	Coercions *coerce.CoercionSummary `json:"coercions,omitempty"`
 }
 
 // GroupedError counts identical errors.
@@ -272,53 +677,31 @@ func (s *Server) handleValidatorSummary(w http.ResponseWriter, r *http.Request)
 		respondError(w, http.StatusBadRequest, "Invalid request body")
 		return
 	}
+	recordRequest(r, req)
 
-	if req.URL == "" {
-		respondError(w, http.StatusBadRequest, "URL is required")
+	if req.URL == "" && (req.Options == nil || len(req.Options.FeedURLs) == 0) {
+		respondError(w, http.StatusBadRequest, "URL or options.feedUrls is required")
 		return
 	}
 
-	fetcherOpts := []fetcher.Option{}
-	if req.Options != nil && req.Options.Auth != nil {
-		fetcherOpts = append(fetcherOpts, fetcher.WithAuth(req.Options.Auth))
-	}
-	f := fetcher.New(fetcherOpts...)
-
-	validatorOpts := validator.Options{}
-	if req.Options != nil {
-		validatorOpts.Docked = req.Options.Docked
-		validatorOpts.Freefloating = req.Options.Freefloating
-		validatorOpts.Version = req.Options.Version
-		validatorOpts.LenientMode = req.Options.LenientMode
-		
-		if req.Options.CoerceOptions != nil {
-			validatorOpts.CoerceOptions = &validator.CoerceOptions{
-				CoerceBooleans:       req.Options.CoerceOptions.CoerceBooleans,
-				CoerceTimestamps:     req.Options.CoerceOptions.CoerceTimestamps,
-				CoerceNumericStrings: req.Options.CoerceOptions.CoerceNumericStrings,
-				CoerceCoordinates:    req.Options.CoerceOptions.CoerceCoordinates,
-				TreatNullAsAbsent:    req.Options.CoerceOptions.TreatNullAsAbsent,
-			}
-		}
-	}
-	v := validator.New(f, validatorOpts)
-
-	result, err := v.Validate(r.Context(), req.URL)
-	if err != nil {
-		respondError(w, http.StatusInternalServerError, err.Error())
+	result, ok := s.validate(w, r, req)
+	if !ok {
 		return
 	}
+	recordOutcome(r, result)
 
 	response := ValidationSummaryResponse{
 		Summary:      result.Summary,
 		FilesSummary: make([]FileSummary, 0, len(result.Files)),
 	}
+	var all coerce.CoercionLog
 
 	for _, file := range result.Files {
 		fileSummary := FileSummary{
 			Required:    file.Required,
 			Exists:      file.Exists,
 			File:        file.File,
+			Status:      file.Status,
 			HasErrors:   file.HasErrors,
 			ErrorsCount: file.ErrorsCount,
 		}
@@ -341,9 +724,27 @@ func (s *Server) handleValidatorSummary(w http.ResponseWriter, r *http.Request)
 		for _, group := range errorGroups {
 			fileSummary.GroupedErrors = append(fileSummary.GroupedErrors, *group)
 		}
+		sort.Slice(fileSummary.GroupedErrors, func(i, j int) bool {
+			a, b := fileSummary.GroupedErrors[i], fileSummary.GroupedErrors[j]
+			if a.Count != b.Count {
+				return a.Count > b.Count
+			}
+			return a.Keyword+a.Message+a.SchemaPath < b.Keyword+b.Message+b.SchemaPath
+		})
+
+		if len(file.Coercions) > 0 {
+			log := coerce.CoercionLog{Coercions: file.Coercions}
+			summary := log.Summarize()
+			fileSummary.Coercions = &summary
+			all.Coercions = append(all.Coercions, file.Coercions...)
+		}
 
 		response.FilesSummary = append(response.FilesSummary, fileSummary)
 	}
+	if len(all.Coercions) > 0 {
+		summary := all.Summarize()
+		response.Coercions = &summary
+	}
 
 	respondJSON(w, http.StatusOK, response)
 }
@@ -353,6 +754,25 @@ func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
 	respondJSON(w, http.StatusOK, map[string]string{"status": "healthy"})
 }
 
+// handleRules lists the built-in checks, optionally only those that apply
+// to the version query parameter.
+func (s *Server) handleRules(w http.ResponseWriter, r *http.Request) {
+	ver := r.URL.Query().Get("version")
+	if ver != "" {
+		if _, ok := version.GetConfig(ver); !ok {
+			respondError(w, http.StatusBadRequest, "Unknown version: "+ver)
+			return
+		}
+	}
+	rules := []validator.Check{}
+	for _, c := range validator.Checks() {
+		if ver == "" || c.AppliesTo(ver) {
+			rules = append(rules, c)
+		}
+	}
+	respondJSON(w, http.StatusOK, rules)
+}
+
 // handleConfig returns client configuration derived from environment.
 func (s *Server) handleConfig(w http.ResponseWriter, r *http.Request) {
 	respondJSON(w, http.StatusOK, map[string]string{
@@ -0,0 +1,378 @@
+package api
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gbfs-validator-go/pkg/scaffold"
+	"github.com/gbfs-validator-go/pkg/signing"
+	"github.com/gbfs-validator-go/pkg/validator"
+	"github.com/gbfs-validator-go/pkg/version"
+)
+
+func TestValidatorSummaryCoercions(t *testing.T) {
+	feed := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/gbfs.json":
+			fmt.Fprintf(w, `{"last_updated":0,"ttl":0,"version":"2.3","data":{"en":{"feeds":[
+				{"name":"station_status","url":"http://%s/station_status.json"}]}}}`, r.Host)
+		case "/station_status.json":
+			w.Write([]byte(`{"last_updated":0,"ttl":0,"version":"2.3","data":{"stations":[
+				{"station_id":"a","num_bikes_available":"3","num_docks_available":2,"is_installed":1,"is_renting":1,"is_returning":0,"last_reported":0},
+				{"station_id":"b","num_bikes_available":"1","num_docks_available":4,"is_installed":1,"is_renting":1,"is_returning":1,"last_reported":0}]}}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer feed.Close()
+
+	body := `{"url":"` + feed.URL + `/gbfs.json","options":{"lenientMode":true}}`
+	w := httptest.NewRecorder()
+	NewServer().ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/validator-summary", strings.NewReader(body)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", w.Code, w.Body)
+	}
+	var resp ValidationSummaryResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Coercions == nil || resp.Coercions.TotalCoercions != 8 {
+		t.Fatalf("coercions = %+v", resp.Coercions)
+	}
+	for _, f := range resp.FilesSummary {
+		if f.File != "station_status.json" {
+			if f.Coercions != nil {
+				t.Errorf("%s has coercions %+v", f.File, f.Coercions)
+			}
+			continue
+		}
+		if f.Coercions == nil || f.Coercions.ByField["num_bikes_available"] != 2 || f.Coercions.ByField["is_renting"] != 2 {
+			t.Errorf("station_status.json coercions = %+v", f.Coercions)
+		}
+	}
+	if got := resp.Summary.CoercionSummary; got == nil || got.ByField["is_installed"] != 2 {
+		t.Errorf("summary coercions = %+v", got)
+	}
+}
+
+func TestValidateFormat(t *testing.T) {
+	feed := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"last_updated":0,"ttl":0,"version":"2.3","data":{"en":{"feeds":[]}}}`)
+	}))
+	defer feed.Close()
+	body := `{"url":"` + feed.URL + `/gbfs.json"}`
+
+	w := httptest.NewRecorder()
+	NewServer().ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/validator?format=junit", strings.NewReader(body)))
+	if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "application/xml" {
+		t.Fatalf("status %d, content type %q", w.Code, w.Header().Get("Content-Type"))
+	}
+	if !strings.Contains(w.Body.String(), "<testsuites") {
+		t.Errorf("not a JUnit report:\n%s", w.Body)
+	}
+
+	w = httptest.NewRecorder()
+	NewServer().ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/validator?format=pdf", strings.NewReader(body)))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("unknown format: status %d", w.Code)
+	}
+}
+
+type countingTransport struct{ requests int }
+
+func (c *countingTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	c.requests++
+	return http.DefaultTransport.RoundTrip(r)
+}
+
+func TestSetHTTPClient(t *testing.T) {
+	feed := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"last_updated":0,"ttl":0,"version":"2.3","data":{"en":{"feeds":[]}}}`)
+	}))
+	defer feed.Close()
+
+	transport := &countingTransport{}
+	server := NewServer()
+	server.SetHTTPClient(&http.Client{Transport: transport})
+	w := httptest.NewRecorder()
+	server.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/validator", strings.NewReader(`{"url":"`+feed.URL+`/gbfs.json"}`)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", w.Code, w.Body)
+	}
+	if transport.requests == 0 {
+		t.Error("the validation did not fetch through the server's client")
+	}
+}
+
+func TestValidateDryRun(t *testing.T) {
+	w := httptest.NewRecorder()
+	body := `{"url":"http://unreachable.invalid/gbfs.json","options":{"version":"2.3"}}`
+	NewServer().ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/validator?dryRun=true", strings.NewReader(body)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", w.Code, w.Body)
+	}
+	var plan validator.ValidationPlan
+	if err := json.Unmarshal(w.Body.Bytes(), &plan); err != nil {
+		t.Fatal(err)
+	}
+	if plan.Version != "2.3" || len(plan.Files) == 0 || len(plan.Checks) == 0 {
+		t.Errorf("plan = %+v", plan)
+	}
+}
+
+func TestRules(t *testing.T) {
+	w := httptest.NewRecorder()
+	NewServer().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/rules?version=2.3", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", w.Code, w.Body)
+	}
+	var rules []validator.Check
+	if err := json.Unmarshal(w.Body.Bytes(), &rules); err != nil {
+		t.Fatal(err)
+	}
+	if len(rules) == 0 || len(rules) >= len(validator.Checks()) {
+		t.Errorf("got %d of %d rules for 2.3", len(rules), len(validator.Checks()))
+	}
+	for _, r := range rules {
+		if r.ID == "selfReference" {
+			t.Error("selfReference applies from 3.0")
+		}
+	}
+
+	w = httptest.NewRecorder()
+	NewServer().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/rules?version=9.9", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("unknown version: status %d", w.Code)
+	}
+}
+
+func TestValidateFile(t *testing.T) {
+	body := `{"last_updated":1700000000,"ttl":0,"version":"2.3","data":{"system_id":"s","language":"en","name":"S","timezone":"Europe/Paris"}}`
+	w := httptest.NewRecorder()
+	NewServer().ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/validate-file?fileType=system_information", strings.NewReader(body)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", w.Code, w.Body)
+	}
+	var result validator.ValidationResult
+	if err := json.Unmarshal(w.Body.Bytes(), &result); err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Files) != 1 || result.Files[0].File != "system_information.json" || result.Summary.ErrorsCount != 0 {
+		t.Errorf("result = %+v", result)
+	}
+
+	w = httptest.NewRecorder()
+	NewServer().ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/validate-file?fileType=nope&version=2.3", strings.NewReader(body)))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("unknown file type: status %d", w.Code)
+	}
+}
+
+func TestSignedResults(t *testing.T) {
+	server := NewServer()
+	w := httptest.NewRecorder()
+	server.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/signing-key", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("signing key without a signer: status %d", w.Code)
+	}
+
+	seed, _ := signing.GenerateKey()
+	signer, err := signing.ParseKey(seed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server.SetSigner(signer)
+	w = httptest.NewRecorder()
+	server.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/signing-key", nil))
+	var key signing.PublicKey
+	if err := json.Unmarshal(w.Body.Bytes(), &key); err != nil {
+		t.Fatal(err)
+	}
+	pub, err := signing.ParsePublicKey(key.Key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	body := `{"last_updated":1700000000,"ttl":0,"version":"2.3","data":{"system_id":"s","language":"en","name":"S","timezone":"Europe/Paris"}}`
+	for _, format := range []string{"", "junit"} {
+		w = httptest.NewRecorder()
+		server.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/validate-file?fileType=system_information&format="+format, strings.NewReader(body)))
+		if w.Code != http.StatusOK {
+			t.Fatalf("status %d: %s", w.Code, w.Body)
+		}
+		if got := w.Header().Get(signing.KeyIDHeader); got != key.KeyID {
+			t.Errorf("format %q: key ID %q, want %q", format, got, key.KeyID)
+		}
+		if err := signing.Verify(pub, w.Body.Bytes(), w.Header().Get(signing.SignatureHeader)); err != nil {
+			t.Errorf("format %q: %v", format, err)
+		}
+		if format == "" {
+			var result validator.ValidationResult
+			if err := json.Unmarshal(w.Body.Bytes(), &result); err != nil || result.ValidatedAt == nil {
+				t.Errorf("signed result carries no validation time: %v", err)
+			}
+		}
+	}
+}
+
+func TestValidateUpload(t *testing.T) {
+	docs, err := scaffold.Skeleton("2.3", "https://example.com", version.Options{Docked: true}, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var archive bytes.Buffer
+	zw := zip.NewWriter(&archive)
+	for _, name := range []string{"gbfs.json", "station_information.json", "station_status.json"} {
+		f, _ := zw.Create("feed/" + name)
+		f.Write(docs[name])
+	}
+	zw.Close()
+
+	upload := func(files map[string][]byte) *httptest.ResponseRecorder {
+		var body bytes.Buffer
+		mw := multipart.NewWriter(&body)
+		mw.WriteField("options", `{"docked":true}`)
+		for name, data := range files {
+			part, _ := mw.CreateFormFile("files", name)
+			part.Write(data)
+		}
+		mw.Close()
+		req := httptest.NewRequest(http.MethodPost, "/api/validate-upload", &body)
+		req.Header.Set("Content-Type", mw.FormDataContentType())
+		w := httptest.NewRecorder()
+		NewServer().ServeHTTP(w, req)
+		return w
+	}
+
+	w := upload(map[string][]byte{"feed.zip": archive.Bytes(), "system_information.json": docs["system_information.json"]})
+	if w.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", w.Code, w.Body)
+	}
+	var result validator.ValidationResult
+	if err := json.Unmarshal(w.Body.Bytes(), &result); err != nil {
+		t.Fatal(err)
+	}
+	if result.Summary.HasErrors || len(result.Files) < 4 {
+		t.Errorf("summary %+v, %d files", result.Summary, len(result.Files))
+	}
+
+	w = upload(map[string][]byte{"feed.zip": archive.Bytes(), "station_status.json": docs["station_status.json"]})
+	if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), "twice") {
+		t.Errorf("duplicate file: status %d: %s", w.Code, w.Body)
+	}
+
+	var stray bytes.Buffer
+	zw = zip.NewWriter(&stray)
+	f, _ := zw.Create("passwords.json")
+	f.Write([]byte(`{}`))
+	zw.Close()
+	w = upload(map[string][]byte{"feed.zip": stray.Bytes()})
+	if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), "not a GBFS feed file") {
+		t.Errorf("non-feed entry: status %d: %s", w.Code, w.Body)
+	}
+
+	var bomb bytes.Buffer
+	zw = zip.NewWriter(&bomb)
+	f, _ = zw.Create("gbfs.json")
+	f.Write(bytes.Repeat([]byte(" "), maxUploadInflated+1))
+	zw.Close()
+	w = upload(map[string][]byte{"feed.zip": bomb.Bytes()})
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("oversized zip: status %d: %s", w.Code, w.Body)
+	}
+}
+
+func TestValidationTimeouts(t *testing.T) {
+	feed := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/gbfs.json":
+			fmt.Fprintf(w, `{"last_updated":0,"ttl":0,"version":"2.3","data":{"en":{"feeds":[
+				{"name":"station_status","url":"http://%s/station_status.json"}]}}}`, r.Host)
+		case "/station_status.json":
+			select {
+			case <-r.Context().Done():
+			case <-time.After(5 * time.Second):
+			}
+		}
+	}))
+	defer feed.Close()
+
+	validate := func(server *Server, options string) (int, validator.ValidationResult) {
+		body := `{"url":"` + feed.URL + `/gbfs.json","options":{` + options + `}}`
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/validator", strings.NewReader(body)))
+		var result validator.ValidationResult
+		json.Unmarshal(w.Body.Bytes(), &result)
+		return w.Code, result
+	}
+	status := func(result validator.ValidationResult) validator.FileValidationResult {
+		for _, f := range result.Files {
+			if f.File == "station_status.json" {
+				return f
+			}
+		}
+		return validator.FileValidationResult{}
+	}
+
+	server := NewServer()
+	server.SetValidationTimeouts(200*time.Millisecond, 0)
+	start := time.Now()
+	code, result := validate(server, `"timeout":"1h"`)
+	if code != http.StatusOK || !result.Summary.Incomplete || status(result).Status != validator.FileStatusNotChecked {
+		t.Errorf("server timeout: status %d, summary %+v, file %+v", code, result.Summary, status(result))
+	}
+	if elapsed := time.Since(start); elapsed > 3*time.Second {
+		t.Errorf("a request lengthened the server's timeout to %v", elapsed)
+	}
+
+	code, result = validate(NewServer(), `"timeout":"200ms"`)
+	if code != http.StatusOK || !result.Summary.Incomplete {
+		t.Errorf("request timeout: status %d, summary %+v", code, result.Summary)
+	}
+
+	code, result = validate(NewServer(), `"fileTimeout":"200ms"`)
+	if f := status(result); code != http.StatusOK || result.Summary.Incomplete || f.FailureKind != "timeout" {
+		t.Errorf("file timeout: status %d, summary %+v, file %+v", code, result.Summary, f)
+	}
+
+	if code, _ := validate(NewServer(), `"timeout":"soon"`); code != http.StatusBadRequest {
+		t.Errorf("invalid timeout: status %d", code)
+	}
+}
+
+func TestHTTPServer(t *testing.T) {
+	server := NewServer()
+	if hs := server.HTTPServer(":8080"); hs.WriteTimeout != time.Minute || hs.ReadHeaderTimeout == 0 || hs.ReadTimeout == 0 {
+		t.Errorf("default timeouts: %+v", hs)
+	}
+	server.SetValidationTimeouts(2*time.Minute, 0)
+	if hs := server.HTTPServer(":8080"); hs.WriteTimeout <= 2*time.Minute || hs.Handler != server {
+		t.Errorf("write timeout %v does not exceed the validation timeout", hs.WriteTimeout)
+	}
+}
+
+func TestOverridesChecked(t *testing.T) {
+	for _, options := range []string{
+		`{"profile":"Strict"}`,
+		`{"missingRecommendedSeverity":"loud"}`,
+		`{"referencedFileSeverity":{"conditionalRegions":"fatal"}}`,
+		`{"referencedFileSeverity":{"schema":"warning"}}`,
+		`{"feedUrlOverrides":{"station_stauts":"https://staging.example.com/status.json"}}`,
+		`{"feedUrlOverrides":{"station_status":"status.json"}}`,
+	} {
+		body := `{"url":"https://example.com/gbfs.json","options":` + options + `}`
+		w := httptest.NewRecorder()
+		NewServer().ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/validator", strings.NewReader(body)))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: status %d: %s", options, w.Code, w.Body)
+		}
+	}
+}
@@ -0,0 +1,35 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gbfs-validator-go/pkg/signing"
+)
+
+// SetSigner signs the results of /api/validator, /api/validate-file, and
+// /api/validate-upload, in any format, with a detached Ed25519 signature
+// of the response body in the X-GBFS-Signature header. The public key is
+// served at /api/signing-key. JSON results also carry the validated feed
+// URL and the validation time, so a signed report cannot pass for another
+// feed or a later run.
+func (s *Server) SetSigner(signer *signing.Signer) {
+	s.signer = signer
+}
+
+// sign adds the signature headers for body when a signer is set.
+func (s *Server) sign(w http.ResponseWriter, body []byte) {
+	if s.signer == nil {
+		return
+	}
+	w.Header().Set(signing.SignatureHeader, s.signer.Sign(body))
+	w.Header().Set(signing.KeyIDHeader, s.signer.Public().KeyID)
+}
+
+// handleSigningKey returns the public key that verifies signed results.
+func (s *Server) handleSigningKey(w http.ResponseWriter, r *http.Request) {
+	if s.signer == nil {
+		respondError(w, http.StatusNotFound, "Result signing is not enabled")
+		return
+	}
+	respondJSON(w, http.StatusOK, s.signer.Public())
+}
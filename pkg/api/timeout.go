@@ -0,0 +1,99 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// SetValidationTimeouts bounds the validations the server runs: total is
+// the wall-clock limit of each validation and perFile the limit of each
+// file request. Requests may shorten either with the timeout and
+// fileTimeout options. A validation that reaches a limit still returns
+// its result: files the wall-clock limit cut short have the not_checked
+// status and mark the summary incomplete, and file requests that took
+// longer than perFile fail with the timeout failure kind. Zero leaves
+// validations unbounded, apart from the two minutes of asynchronous jobs,
+// and file requests at the fetcher's 30 seconds.
+func (s *Server) SetValidationTimeouts(total, perFile time.Duration) {
+	s.timeout = total
+	s.fileTimeout = perFile
+}
+
+// ValidationTimeout returns the wall-clock limit of SetValidationTimeouts,
+// which an HTTP server's write timeout should exceed so that results of
+// validations cut short are delivered.
+func (s *Server) ValidationTimeout() time.Duration {
+	return s.timeout
+}
+
+// HTTPServer returns an HTTP server for s on addr with read, header, write,
+// and idle timeouts. The write timeout exceeds the validation timeout by
+// enough to send the results of validations it cut short, so it should be
+// called after SetValidationTimeouts.
+func (s *Server) HTTPServer(addr string) *http.Server {
+	writeTimeout := 60 * time.Second
+	if t := s.ValidationTimeout() + 10*time.Second; t > writeTimeout {
+		writeTimeout = t
+	}
+	return &http.Server{
+		Addr:              addr,
+		Handler:           s,
+		ReadHeaderTimeout: 10 * time.Second,
+		ReadTimeout:       15 * time.Second,
+		WriteTimeout:      writeTimeout,
+		IdleTimeout:       60 * time.Second,
+	}
+}
+
+// checkTimeouts reports malformed timeout options.
+func checkTimeouts(opts *ValidateOptions) error {
+	if opts == nil {
+		return nil
+	}
+	for name, value := range map[string]string{"timeout": opts.Timeout, "fileTimeout": opts.FileTimeout} {
+		if value == "" {
+			continue
+		}
+		if d, err := time.ParseDuration(value); err != nil || d <= 0 {
+			return fmt.Errorf("%s must be a positive duration such as 30s, not %q", name, value)
+		}
+	}
+	return nil
+}
+
+// limit returns the shorter of the server's limit and the request's, which
+// checkTimeouts has accepted; zero means no limit.
+func limit(server time.Duration, request string) time.Duration {
+	d, err := time.ParseDuration(request)
+	if err != nil || d <= 0 || (server > 0 && d > server) {
+		return server
+	}
+	return d
+}
+
+// validationTimeout returns the wall-clock limit of a validation.
+func (s *Server) validationTimeout(opts *ValidateOptions) time.Duration {
+	if opts == nil {
+		return s.timeout
+	}
+	return limit(s.timeout, opts.Timeout)
+}
+
+// fileTimeoutFor returns the limit of each file request of a validation.
+func (s *Server) fileTimeoutFor(opts *ValidateOptions) time.Duration {
+	if opts == nil {
+		return s.fileTimeout
+	}
+	return limit(s.fileTimeout, opts.FileTimeout)
+}
+
+// validationContext bounds a validation derived from ctx by its wall-clock
+// limit, if any.
+func (s *Server) validationContext(ctx context.Context, opts *ValidateOptions) (context.Context, context.CancelFunc) {
+	if d := s.validationTimeout(opts); d > 0 {
+		return context.WithTimeout(ctx, d)
+	}
+	return context.WithCancel(ctx)
+}
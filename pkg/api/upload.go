@@ -0,0 +1,197 @@
+package api
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/gbfs-validator-go/pkg/report"
+	"github.com/gbfs-validator-go/pkg/validator"
+	"github.com/gbfs-validator-go/pkg/version"
+)
+
+const (
+	// maxUploadMemory is how much of an upload is held in memory; the rest
+	// of the parts spill to temporary files.
+	maxUploadMemory = 32 << 20
+	// maxUploadFiles bounds the feed files of an upload, zip entries
+	// included.
+	maxUploadFiles = 64
+	// maxUploadInflated bounds the feed files of an upload once unzipped.
+	maxUploadInflated = 256 << 20
+)
+
+// errUploadTooLarge reports an upload past maxUploadFiles or
+// maxUploadInflated.
+var errUploadTooLarge = errors.New("upload is larger than 256 MiB or 64 files once unzipped")
+
+// uploadBudget is what remains of an upload's limits.
+type uploadBudget struct {
+	files int
+	bytes int64
+}
+
+// take charges one file of n bytes to the budget.
+func (b *uploadBudget) take(n int64) error {
+	b.files--
+	b.bytes -= n
+	if b.files < 0 || b.bytes < 0 {
+		return errUploadTooLarge
+	}
+	return nil
+}
+
+// feedNames holds the feed names of every supported version.
+var feedNames = func() map[string]bool {
+	names := make(map[string]bool)
+	for _, v := range version.SupportedVersions() {
+		for _, name := range version.FeedNames(v) {
+			names[name] = true
+		}
+	}
+	return names
+}()
+
+// handleValidateUpload validates feed files uploaded as multipart/form-data,
+// so the viewer can validate a feed that is not publicly hosted. Every file
+// part is a feed file named by its file name, or a zip of them. An optional
+// "options" field holds ValidateOptions as JSON.
+func (s *Server) handleValidateUpload(w http.ResponseWriter, r *http.Request) {
+	sl, ok := s.acquireSlot(r.Context(), w)
+	if !ok {
+		return
+	}
+	defer sl.release()
+	r.Body = http.MaxBytesReader(w, r.Body, maxFileBody)
+	if err := r.ParseMultipartForm(maxUploadMemory); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			respondError(w, http.StatusRequestEntityTooLarge, "Upload is larger than 64 MiB")
+			return
+		}
+		respondError(w, http.StatusBadRequest, "Expected a multipart/form-data upload")
+		return
+	}
+	defer r.MultipartForm.RemoveAll()
+
+	format := r.FormValue("format")
+	if _, ok := report.Lookup(format); format != "" && !ok {
+		respondError(w, http.StatusBadRequest, "Unknown format: "+format)
+		return
+	}
+	var opts ValidateOptions
+	if raw := r.FormValue("options"); raw != "" {
+		if err := json.Unmarshal([]byte(raw), &opts); err != nil {
+			respondError(w, http.StatusBadRequest, "Invalid options")
+			return
+		}
+	}
+	recordRequest(r, ValidateRequest{Options: &opts})
+	if err := checkTimeouts(&opts); err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := checkSeverities(&opts); err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	docs := make(map[string][]byte)
+	budget := &uploadBudget{files: maxUploadFiles, bytes: maxUploadInflated}
+	for _, headers := range r.MultipartForm.File {
+		for _, h := range headers {
+			if err := readUpload(h, docs, budget); err != nil {
+				status := http.StatusBadRequest
+				if errors.Is(err, errUploadTooLarge) {
+					status = http.StatusRequestEntityTooLarge
+				}
+				respondError(w, status, err.Error())
+				return
+			}
+		}
+	}
+
+	ctx, cancel := s.validationContext(r.Context(), &opts)
+	defer cancel()
+	v := validator.New(s.newFetcher(&ValidateOptions{FileTimeout: opts.FileTimeout}), s.validatorOptions(&opts))
+	result, err := v.ValidateDocuments(ctx, docs)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	recordOutcome(r, result)
+	s.respondResult(w, format, result)
+}
+
+// readUpload adds an uploaded file to docs, or every JSON file of an
+// uploaded zip, charging them to budget. Zip entries that are not named
+// after a feed are rejected before they are inflated.
+func readUpload(h *multipart.FileHeader, docs map[string][]byte, budget *uploadBudget) error {
+	f, err := h.Open()
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return err
+	}
+
+	if !strings.HasSuffix(strings.ToLower(h.Filename), ".zip") {
+		if err := budget.take(int64(len(data))); err != nil {
+			return err
+		}
+		return addDocument(docs, path.Base(h.Filename), data)
+	}
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return fmt.Errorf("%s is not a valid zip: %v", h.Filename, err)
+	}
+	for _, entry := range archive.File {
+		name := path.Base(entry.Name)
+		if entry.FileInfo().IsDir() || !strings.HasSuffix(name, ".json") || strings.HasPrefix(name, ".") {
+			continue
+		}
+		if !feedNames[strings.TrimSuffix(name, ".json")] {
+			return fmt.Errorf("%s in %s is not a GBFS feed file", entry.Name, h.Filename)
+		}
+		if entry.UncompressedSize64 > uint64(budget.bytes) {
+			return errUploadTooLarge
+		}
+		rc, err := entry.Open()
+		if err != nil {
+			return fmt.Errorf("%s: %v", entry.Name, err)
+		}
+		// The declared size may lie; read at most one byte past the budget.
+		data, err := io.ReadAll(io.LimitReader(rc, budget.bytes+1))
+		rc.Close()
+		if err != nil {
+			return fmt.Errorf("%s: %v", entry.Name, err)
+		}
+		if err := budget.take(int64(len(data))); err != nil {
+			return err
+		}
+		if err := addDocument(docs, name, data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// addDocument adds one file to docs, rejecting a second file of the same
+// name.
+func addDocument(docs map[string][]byte, name string, data []byte) error {
+	key := strings.TrimSuffix(name, ".json")
+	if _, dup := docs[key]; dup {
+		return fmt.Errorf("%s was uploaded twice", key+".json")
+	}
+	docs[key] = data
+	return nil
+}
@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"github.com/gbfs-validator-go/pkg/fetcher"
+	"github.com/gbfs-validator-go/pkg/gbfs"
 )
 
 // ViewerRequest is the JSON body for /api/gbfs.
@@ -25,6 +26,8 @@ type ViewerResponse struct {
 	VehicleTypes   []interface{}          `json:"vehicleTypes"`
 	GeofencingZones interface{}           `json:"geofencingZones"`
 	FeedURLs       map[string]string      `json:"feedUrls"`
+	Format         gbfs.GBFSFormat        `json:"format,omitempty"`
+	Language       string                 `json:"language,omitempty"`
 }
 
 // Station merges station_information and station_status.
@@ -73,21 +76,25 @@ func (s *Server) handleGBFS(w http.ResponseWriter, r *http.Request) {
 	}
 
 	ctx := r.Context()
-	f := fetcher.New()
+	f := s.newFetcher(nil)
 	
-	var gbfsData map[string]interface{}
-	result := f.FetchJSON(ctx, req.URL, &gbfsData)
+	var autodiscovery gbfs.GBFSFeed
+	result := f.FetchJSON(ctx, req.URL, &autodiscovery)
 	if result.Error != nil {
 		respondError(w, http.StatusBadGateway, "Failed to fetch GBFS: "+result.Error.Error())
 		return
 	}
 
 	version := "1.0"
-	if v, ok := gbfsData["version"].(string); ok {
-		version = v
+	if autodiscovery.Version != "" {
+		version = autodiscovery.Version
 	}
 
-	feedURLs := extractFeedURLs(gbfsData)
+	var language string
+	if langs := autodiscovery.Data.LanguageCodes(); len(langs) > 0 {
+		language = langs[0]
+	}
+	feedURLs := extractFeedURLs(autodiscovery.Data.FeedsFor(language))
 	if len(feedURLs) == 0 {
 		respondError(w, http.StatusBadRequest, "No feeds found in autodiscovery")
 		return
@@ -135,6 +142,8 @@ func (s *Server) handleGBFS(w http.ResponseWriter, r *http.Request) {
 		Vehicles:  []Vehicle{},
 		VehicleTypes: []interface{}{},
 		FeedURLs:  feedURLs,
+		Format:    autodiscovery.Data.Format,
+		Language:  language,
 	}
 
 	if sysInfo, ok := feeds["system_information"]; ok {
@@ -179,7 +188,7 @@ func (s *Server) handleProxy(w http.ResponseWriter, r *http.Request) {
 	client := &http.Client{Timeout: 15 * time.Second}
 	resp, err := client.Get(targetURL)
 	if err != nil {
-		respondError(w, http.StatusBadGateway, "Failed to fetch: "+err.Error())
+		respondError(w, http.StatusBadGateway, "Failed to fetch: "+fetcher.RedactText(err.Error()))
 		return
 	}
 	defer resp.Body.Close()
@@ -194,44 +203,14 @@ func (s *Server) handleProxy(w http.ResponseWriter, r *http.Request) {
 	w.Write(body)
 }
 
-// extractFeedURLs reads feed URLs from autodiscovery data.
-func extractFeedURLs(gbfs map[string]interface{}) map[string]string {
+// extractFeedURLs maps feed names to URLs, skipping incomplete entries.
+func extractFeedURLs(feeds []gbfs.FeedInfo) map[string]string {
 	urls := make(map[string]string)
-
-	data, ok := gbfs["data"].(map[string]interface{})
-	if !ok {
-		return urls
-	}
-
-	for _, value := range data {
-		if langData, ok := value.(map[string]interface{}); ok {
-			if feeds, ok := langData["feeds"].([]interface{}); ok {
-				for _, f := range feeds {
-					if feed, ok := f.(map[string]interface{}); ok {
-						name, _ := feed["name"].(string)
-						url, _ := feed["url"].(string)
-						if name != "" && url != "" {
-							urls[name] = url
-						}
-					}
-				}
-				return urls
-			}
+	for _, feed := range feeds {
+		if feed.Name != "" && feed.URL != "" {
+			urls[feed.Name] = feed.URL
 		}
 	}
-
-	if feeds, ok := data["feeds"].([]interface{}); ok {
-		for _, f := range feeds {
-			if feed, ok := f.(map[string]interface{}); ok {
-				name, _ := feed["name"].(string)
-				url, _ := feed["url"].(string)
-				if name != "" && url != "" {
-					urls[name] = url
-				}
-			}
-		}
-	}
-
 	return urls
 }
 
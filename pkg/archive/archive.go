@@ -0,0 +1,281 @@
+package archive
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gbfs-validator-go/pkg/validator"
+)
+
+// runTimeFormat names run directories so they sort chronologically.
+const runTimeFormat = "20060102T150405Z"
+
+// Archive stores runs under <prefix>/<feed hash>/<run time>/: result.json
+// holds the validation result and snapshot/<file> the fetched payloads.
+type Archive struct {
+	store     ObjectStore
+	prefix    string
+	retention Retention
+}
+
+// New returns an archive writing to store under prefix.
+func New(store ObjectStore, prefix string) *Archive {
+	return &Archive{store: store, prefix: strings.Trim(prefix, "/")}
+}
+
+// OpenURI opens the store named by uri; see Open.
+func OpenURI(uri string) (*Archive, error) {
+	store, prefix, err := Open(uri)
+	if err != nil {
+		return nil, err
+	}
+	return New(store, prefix), nil
+}
+
+// FeedKey returns the directory name used for a feed URL.
+func FeedKey(feedURL string) string {
+	sum := sha256.Sum256([]byte(feedURL))
+	return hex.EncodeToString(sum[:16])
+}
+
+// Key identifies a validated feed: its gbfs.json URL, or its explicit feed
+// URLs when it has none.
+func Key(gbfsURL string, feedURLs map[string]string) string {
+	if gbfsURL != "" {
+		return gbfsURL
+	}
+	urls := make([]string, 0, len(feedURLs))
+	for _, u := range feedURLs {
+		urls = append(urls, u)
+	}
+	sort.Strings(urls)
+	return strings.Join(urls, " ")
+}
+
+// feedDir returns the key prefix of a feed's runs, ending in "/".
+func (a *Archive) feedDir(feedKey string) string {
+	return path.Join(a.prefix, feedKey) + "/"
+}
+
+// runDir returns the key prefix of one run, ending in "/".
+func (a *Archive) runDir(feedKey string, at time.Time) string {
+	return a.feedDir(feedKey) + at.UTC().Format(runTimeFormat) + "/"
+}
+
+// Save stores a validation result and a snapshot of every file it fetched,
+// then prunes the feed's older runs if a retention policy is set.
+func (a *Archive) Save(ctx context.Context, feedURL string, at time.Time, result *validator.ValidationResult) error {
+	dir := a.runDir(FeedKey(feedURL), at)
+	for _, f := range result.Files {
+		if !f.Exists || len(f.RawData) == 0 {
+			continue
+		}
+		name := f.File
+		if !strings.HasSuffix(name, ".json") {
+			name += ".json"
+		}
+		if err := a.store.Put(ctx, dir+"snapshot/"+name, f.RawData); err != nil {
+			return err
+		}
+	}
+
+	// A result in memory is in the current format, even when it was built
+	// without setting the version.
+	stored := *result
+	stored.ResultSchemaVersion = validator.ResultSchemaVersion
+	data, err := json.Marshal(&stored)
+	if err != nil {
+		return err
+	}
+	if err := a.store.Put(ctx, dir+"result.json", data); err != nil {
+		return err
+	}
+
+	if a.retention.IsZero() {
+		return nil
+	}
+	_, err = a.pruneFeed(ctx, feedURL, a.retention, at)
+	return err
+}
+
+// Runs returns the times of a feed's stored runs, oldest first.
+func (a *Archive) Runs(ctx context.Context, feedURL string) ([]time.Time, error) {
+	return a.RunsByKey(ctx, FeedKey(feedURL))
+}
+
+// RunsByKey is Runs for a feed identified by FeedKey.
+func (a *Archive) RunsByKey(ctx context.Context, feedKey string) ([]time.Time, error) {
+	dir := a.feedDir(feedKey)
+	keys, err := a.store.List(ctx, dir)
+	if err != nil {
+		return nil, err
+	}
+	var runs []time.Time
+	for _, key := range keys {
+		rest := strings.TrimPrefix(key, dir)
+		stamp, file, ok := strings.Cut(rest, "/")
+		if !ok || file != "result.json" {
+			continue
+		}
+		if t, err := time.Parse(runTimeFormat, stamp); err == nil {
+			runs = append(runs, t)
+		}
+	}
+	sort.Slice(runs, func(i, j int) bool { return runs[i].Before(runs[j]) })
+	return runs, nil
+}
+
+// Result loads the validation result stored for a run.
+func (a *Archive) Result(ctx context.Context, feedURL string, at time.Time) (*validator.ValidationResult, error) {
+	return a.ResultByKey(ctx, FeedKey(feedURL), at)
+}
+
+// ResultByKey is Result for a feed identified by FeedKey.
+func (a *Archive) ResultByKey(ctx context.Context, feedKey string, at time.Time) (*validator.ValidationResult, error) {
+	data, err := a.store.Get(ctx, a.runDir(feedKey, at)+"result.json")
+	if err != nil {
+		return nil, err
+	}
+	return validator.UpgradeResult(data)
+}
+
+// Snapshot loads a file payload stored for a run, e.g. "station_status.json".
+func (a *Archive) Snapshot(ctx context.Context, feedURL string, at time.Time, file string) ([]byte, error) {
+	return a.store.Get(ctx, a.runDir(FeedKey(feedURL), at)+"snapshot/"+file)
+}
+
+// Retention limits how many runs are kept per feed. Zero fields are
+// unlimited.
+type Retention struct {
+	// KeepRuns keeps only the newest runs.
+	KeepRuns int `json:"keepRuns,omitempty"`
+	// MaxAge drops runs older than this.
+	MaxAge time.Duration `json:"maxAge,omitempty"`
+}
+
+// IsZero reports whether the policy keeps everything.
+func (r Retention) IsZero() bool {
+	return r.KeepRuns <= 0 && r.MaxAge <= 0
+}
+
+// PruneReport summarizes a prune.
+type PruneReport struct {
+	Feeds       int `json:"feeds"`
+	RunsDeleted int `json:"runsDeleted"`
+	RunsKept    int `json:"runsKept"`
+	// ObjectsDeleted counts results and snapshot files.
+	ObjectsDeleted int `json:"objectsDeleted"`
+}
+
+// SetRetention sets the policy applied to a feed after each Save.
+func (a *Archive) SetRetention(r Retention) {
+	a.retention = r
+}
+
+// Retention returns the policy set with SetRetention.
+func (a *Archive) Retention() Retention {
+	return a.retention
+}
+
+// Prune applies the retention policy to every feed in the archive.
+func (a *Archive) Prune(ctx context.Context, r Retention, now time.Time) (PruneReport, error) {
+	var report PruneReport
+	root := a.prefix
+	if root != "" {
+		root += "/"
+	}
+	keys, err := a.store.List(ctx, root)
+	if err != nil {
+		return report, err
+	}
+
+	byFeed := make(map[string][]string)
+	for _, key := range keys {
+		feed, _, ok := strings.Cut(strings.TrimPrefix(key, root), "/")
+		if ok {
+			byFeed[root+feed+"/"] = append(byFeed[root+feed+"/"], key)
+		}
+	}
+	feeds := make([]string, 0, len(byFeed))
+	for dir := range byFeed {
+		feeds = append(feeds, dir)
+	}
+	sort.Strings(feeds)
+
+	for _, dir := range feeds {
+		report.Feeds++
+		if err := a.pruneKeys(ctx, dir, byFeed[dir], r, now, &report); err != nil {
+			return report, err
+		}
+	}
+	return report, nil
+}
+
+// pruneFeed applies the retention policy to one feed.
+func (a *Archive) pruneFeed(ctx context.Context, feedURL string, r Retention, now time.Time) (PruneReport, error) {
+	report := PruneReport{Feeds: 1}
+	dir := a.feedDir(FeedKey(feedURL))
+	keys, err := a.store.List(ctx, dir)
+	if err != nil {
+		return report, err
+	}
+	return report, a.pruneKeys(ctx, dir, keys, r, now, &report)
+}
+
+// pruneKeys deletes the objects of runs under dir that r does not keep.
+func (a *Archive) pruneKeys(ctx context.Context, dir string, keys []string, r Retention, now time.Time, report *PruneReport) error {
+	runs := make(map[string][]string)
+	for _, key := range keys {
+		stamp, _, ok := strings.Cut(strings.TrimPrefix(key, dir), "/")
+		if ok {
+			runs[stamp] = append(runs[stamp], key)
+		}
+	}
+	stamps := make([]string, 0, len(runs))
+	for stamp := range runs {
+		stamps = append(stamps, stamp)
+	}
+	// Newest first; the time format sorts chronologically.
+	sort.Sort(sort.Reverse(sort.StringSlice(stamps)))
+
+	for i, stamp := range stamps {
+		keep := r.KeepRuns <= 0 || i < r.KeepRuns
+		if r.MaxAge > 0 {
+			if t, err := time.Parse(runTimeFormat, stamp); err == nil && now.Sub(t) > r.MaxAge {
+				keep = false
+			}
+		}
+		if keep {
+			report.RunsKept++
+			continue
+		}
+		for _, key := range runs[stamp] {
+			if err := a.store.Delete(ctx, key); err != nil {
+				return err
+			}
+			report.ObjectsDeleted++
+		}
+		report.RunsDeleted++
+	}
+	return nil
+}
+
+// ParseAge parses a retention age such as "30d", "12h", or "90m".
+func ParseAge(s string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("invalid age %q", s)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	return time.ParseDuration(s)
+}
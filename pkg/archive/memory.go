@@ -0,0 +1,86 @@
+package archive
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gbfs-validator-go/pkg/validator"
+)
+
+// MemoryStore keeps results in memory, for tests and deployments that do
+// not need history to survive a restart.
+type MemoryStore struct {
+	mu sync.Mutex
+	// runs holds each feed's results by run time, serialized so that
+	// callers cannot change a stored result.
+	runs map[string]map[time.Time][]byte
+}
+
+// NewMemoryStore returns an empty store.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{runs: make(map[string]map[time.Time][]byte)}
+}
+
+// SaveRun stores a result.
+func (m *MemoryStore) SaveRun(ctx context.Context, feedURL string, at time.Time, result *validator.ValidationResult) error {
+	stored := *result
+	stored.ResultSchemaVersion = validator.ResultSchemaVersion
+	data, err := json.Marshal(&stored)
+	if err != nil {
+		return err
+	}
+	key := FeedKey(feedURL)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.runs[key] == nil {
+		m.runs[key] = make(map[time.Time][]byte)
+	}
+	m.runs[key][at.UTC()] = data
+	return nil
+}
+
+// GetRun loads a result.
+func (m *MemoryStore) GetRun(ctx context.Context, feedKey string, at time.Time) (*validator.ValidationResult, error) {
+	m.mu.Lock()
+	data, ok := m.runs[feedKey][at.UTC()]
+	m.mu.Unlock()
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return validator.UpgradeResult(data)
+}
+
+// ListRuns returns a feed's run times, oldest first.
+func (m *MemoryStore) ListRuns(ctx context.Context, feedKey string) ([]time.Time, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	runs := make([]time.Time, 0, len(m.runs[feedKey]))
+	for at := range m.runs[feedKey] {
+		runs = append(runs, at)
+	}
+	sort.Slice(runs, func(i, j int) bool { return runs[i].Before(runs[j]) })
+	return runs, nil
+}
+
+// Trends buckets a feed's runs in [from, to) by interval.
+func (m *MemoryStore) Trends(ctx context.Context, feedKey string, interval Interval, from, to time.Time) ([]TrendBucket, error) {
+	runs, err := m.ListRuns(ctx, feedKey)
+	if err != nil {
+		return nil, err
+	}
+	var stats []runStats
+	for _, at := range runs {
+		if at.Before(from) || !at.Before(to) {
+			continue
+		}
+		result, err := m.GetRun(ctx, feedKey, at)
+		if err != nil {
+			return nil, err
+		}
+		stats = append(stats, runStats{At: at, RunStats: Stats(result)})
+	}
+	return bucketTrends(stats, interval), nil
+}
@@ -0,0 +1,112 @@
+package archive
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// migrationFiles holds each dialect's schema migrations, named
+// NNNN_description.sql and applied in order of NNNN.
+//
+//go:embed migrations
+var migrationFiles embed.FS
+
+// migration is one schema change.
+type migration struct {
+	Version int
+	Name    string
+	SQL     string
+}
+
+// migrations returns the dialect's migrations, oldest first.
+func migrations(d Dialect) ([]migration, error) {
+	dir := path.Join("migrations", d.dir)
+	entries, err := fs.ReadDir(migrationFiles, dir)
+	if err != nil {
+		return nil, err
+	}
+	var out []migration
+	for _, e := range entries {
+		prefix, _, ok := strings.Cut(e.Name(), "_")
+		version, err := strconv.Atoi(prefix)
+		if !ok || err != nil || !strings.HasSuffix(e.Name(), ".sql") {
+			return nil, fmt.Errorf("migration %s: name is not NNNN_description.sql", e.Name())
+		}
+		data, err := fs.ReadFile(migrationFiles, path.Join(dir, e.Name()))
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, migration{Version: version, Name: e.Name(), SQL: string(data)})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Version < out[j].Version })
+	return out, nil
+}
+
+// Migrate applies the migrations that db has not yet applied, recording
+// them in gbfs_schema_migrations. The schema holds the results of
+// SQLStore and the tables that replicas sharing the database coordinate
+// through. It runs in one transaction, which on Postgres first takes an
+// advisory lock so that replicas starting together apply each migration
+// once.
+func Migrate(ctx context.Context, db *sql.DB, d Dialect) error {
+	all, err := migrations(d)
+	if err != nil {
+		return err
+	}
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if d.lock != "" {
+		if _, err := tx.ExecContext(ctx, d.lock); err != nil {
+			return fmt.Errorf("locking migrations: %w", err)
+		}
+	}
+	if _, err := tx.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS gbfs_schema_migrations (
+		version    INTEGER PRIMARY KEY,
+		applied_at BIGINT NOT NULL
+	)`); err != nil {
+		return err
+	}
+
+	applied := make(map[int]bool)
+	rows, err := tx.QueryContext(ctx, `SELECT version FROM gbfs_schema_migrations`)
+	if err != nil {
+		return err
+	}
+	for rows.Next() {
+		var version int
+		if err := rows.Scan(&version); err != nil {
+			rows.Close()
+			return err
+		}
+		applied[version] = true
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, m := range all {
+		if applied[m.Version] {
+			continue
+		}
+		if _, err := tx.ExecContext(ctx, m.SQL); err != nil {
+			return fmt.Errorf("migration %s: %w", m.Name, err)
+		}
+		if _, err := tx.ExecContext(ctx, d.Rebind(`INSERT INTO gbfs_schema_migrations (version, applied_at) VALUES (?, ?)`),
+			m.Version, time.Now().UnixNano()); err != nil {
+			return fmt.Errorf("migration %s: %w", m.Name, err)
+		}
+	}
+	return tx.Commit()
+}
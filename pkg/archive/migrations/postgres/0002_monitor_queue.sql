@@ -0,0 +1,11 @@
+-- The monitor's shared schedule: one row per feed, leased to a replica
+-- while it checks the feed. Times are Unix nanoseconds.
+CREATE TABLE IF NOT EXISTS gbfs_monitor_queue (
+	feed_id      TEXT PRIMARY KEY,
+	interval_ns  BIGINT NOT NULL,
+	next_run     BIGINT NOT NULL,
+	locked_until BIGINT NOT NULL DEFAULT 0,
+	owner        TEXT NOT NULL DEFAULT ''
+);
+
+CREATE INDEX IF NOT EXISTS gbfs_monitor_queue_next_run ON gbfs_monitor_queue (next_run);
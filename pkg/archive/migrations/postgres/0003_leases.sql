@@ -0,0 +1,7 @@
+-- Named leases, such as the monitor's scheduler lease, each held by one
+-- replica until expires_at (Unix nanoseconds).
+CREATE TABLE IF NOT EXISTS gbfs_leases (
+	name       TEXT PRIMARY KEY,
+	holder     TEXT NOT NULL,
+	expires_at BIGINT NOT NULL
+);
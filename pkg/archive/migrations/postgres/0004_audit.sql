@@ -0,0 +1,16 @@
+-- The API server's audit log of validation requests, one row per request;
+-- at is Unix nanoseconds and options the request's redacted options as JSON.
+CREATE TABLE IF NOT EXISTS gbfs_audit (
+	id          BIGSERIAL PRIMARY KEY,
+	at          BIGINT NOT NULL,
+	remote_ip   TEXT NOT NULL,
+	method      TEXT NOT NULL,
+	path        TEXT NOT NULL,
+	url         TEXT NOT NULL,
+	options     TEXT,
+	duration_ms BIGINT NOT NULL,
+	status      INTEGER NOT NULL,
+	outcome     TEXT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS gbfs_audit_at ON gbfs_audit (at);
@@ -0,0 +1,7 @@
+-- Each monitored feed's incident state as JSON: its open incidents and the
+-- consecutive failing and passing runs of each condition, left by whichever
+-- replica checked it last.
+CREATE TABLE IF NOT EXISTS gbfs_monitor_incidents (
+	feed_id TEXT PRIMARY KEY,
+	state   TEXT NOT NULL
+);
@@ -0,0 +1,13 @@
+-- Runs, with the trend measures stored beside the result so that Trends
+-- does not decode results. run_at holds Unix nanoseconds.
+CREATE TABLE IF NOT EXISTS gbfs_runs (
+	feed_key  TEXT NOT NULL,
+	feed_url  TEXT NOT NULL,
+	run_at    BIGINT NOT NULL,
+	errors    INTEGER NOT NULL,
+	score     DOUBLE PRECISION NOT NULL,
+	available INTEGER NOT NULL,
+	fetch_ms  BIGINT NOT NULL,
+	result    BLOB NOT NULL,
+	PRIMARY KEY (feed_key, run_at)
+);
@@ -0,0 +1,10 @@
+-- The monitor's feeds added through the API, as JSON with credentials
+-- sealed, and the paused state of any feed. feed is NULL for feeds from a
+-- configuration file, which only have their paused state here; added_at
+-- (Unix nanoseconds) orders the feeds.
+CREATE TABLE IF NOT EXISTS gbfs_monitor_feeds (
+	feed_id  TEXT PRIMARY KEY,
+	feed     TEXT,
+	paused   BOOLEAN NOT NULL DEFAULT FALSE,
+	added_at BIGINT NOT NULL
+);
@@ -0,0 +1,153 @@
+package archive
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/gbfs-validator-go/pkg/validator"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/stdlib"
+	_ "modernc.org/sqlite"
+)
+
+// Store keeps the validation results of runs, for the history and trend
+// features of the monitor and API server. Archive implements it over
+// object storage, SQLStore over a database, and MemoryStore in memory.
+// Feeds are identified by the key FeedKey returns for their URL.
+type Store interface {
+	// SaveRun stores the result of a run of feedURL started at at,
+	// replacing any result stored for the same run.
+	SaveRun(ctx context.Context, feedURL string, at time.Time, result *validator.ValidationResult) error
+	// GetRun loads the result of a run, or returns ErrNotFound.
+	GetRun(ctx context.Context, feedKey string, at time.Time) (*validator.ValidationResult, error)
+	// ListRuns returns the start times of a feed's runs, oldest first.
+	ListRuns(ctx context.Context, feedKey string) ([]time.Time, error)
+	// Trends buckets a feed's runs in [from, to) by interval, oldest
+	// first, omitting intervals without runs.
+	Trends(ctx context.Context, feedKey string, interval Interval, from, to time.Time) ([]TrendBucket, error)
+}
+
+var (
+	_ Store = (*Archive)(nil)
+	_ Store = (*MemoryStore)(nil)
+	_ Store = (*SQLStore)(nil)
+)
+
+// SaveRun is Save.
+func (a *Archive) SaveRun(ctx context.Context, feedURL string, at time.Time, result *validator.ValidationResult) error {
+	return a.Save(ctx, feedURL, at, result)
+}
+
+// GetRun is ResultByKey.
+func (a *Archive) GetRun(ctx context.Context, feedKey string, at time.Time) (*validator.ValidationResult, error) {
+	return a.ResultByKey(ctx, feedKey, at)
+}
+
+// ListRuns is RunsByKey.
+func (a *Archive) ListRuns(ctx context.Context, feedKey string) ([]time.Time, error) {
+	return a.RunsByKey(ctx, feedKey)
+}
+
+// OpenStore returns the results store named by uri:
+//
+//	memory:                      results in memory, lost on exit
+//	sqlite:path                  a SQLite database file
+//	postgres://user@host/db      a Postgres database
+//
+// Any other uri opens an archive; see Open. Database URIs may set the
+// connection pool with the query parameters pool_max_conns,
+// pool_max_idle_conns, pool_max_conn_lifetime, and
+// pool_max_conn_idle_time, the last two as durations such as 30m; the
+// other parameters, such as sslmode and connect_timeout, are those of
+// libpq. Use sslmode=require or verify-full where the server may ask for
+// a cleartext password. libpq keyword/value connection strings, such as
+// host=db dbname=gbfs, are refused rather than taken for an archive path.
+func OpenStore(uri string) (Store, error) {
+	if keywordDSN.MatchString(uri) {
+		return nil, errKeywordDSN
+	}
+	u, err := url.Parse(uri)
+	if err != nil {
+		return OpenURI(uri)
+	}
+	switch u.Scheme {
+	case "memory":
+		return NewMemoryStore(), nil
+	case "sqlite", "postgres", "postgresql":
+		db, d, err := OpenDB(uri)
+		if err != nil {
+			return nil, err
+		}
+		store, err := NewSQLStore(context.Background(), db, d)
+		if err != nil {
+			db.Close()
+			return nil, err
+		}
+		return store, nil
+	}
+	return OpenURI(uri)
+}
+
+// OpenDB opens the sqlite: or postgres:// database of uri, as OpenStore
+// does, with its connection pool configured. The schema is not migrated;
+// see Migrate.
+func OpenDB(uri string) (*sql.DB, Dialect, error) {
+	d, dsn := Postgres, uri
+	if rest, ok := strings.CutPrefix(uri, "sqlite:"); ok {
+		d, dsn = SQLite, strings.TrimPrefix(rest, "//")
+	} else if !strings.HasPrefix(uri, "postgres://") && !strings.HasPrefix(uri, "postgresql://") {
+		if keywordDSN.MatchString(uri) {
+			return nil, d, errKeywordDSN
+		}
+		return nil, d, errors.New("unsupported database; want sqlite:path or postgres://")
+	}
+	dsn, pool, err := parsePool(dsn, d.pool)
+	if err != nil {
+		return nil, d, err
+	}
+	var db *sql.DB
+	if d == SQLite {
+		db, err = openSQLite(dsn)
+	} else {
+		db, err = openPostgres(dsn)
+	}
+	if err != nil {
+		return nil, d, err
+	}
+	pool.apply(db)
+	return db, d, nil
+}
+
+// keywordDSN matches a libpq keyword/value connection string.
+var keywordDSN = regexp.MustCompile(`^\s*[a-z_]+\s*=`)
+
+// errKeywordDSN refuses a keyword/value connection string, which names no
+// database type and leaves nowhere for the pool parameters.
+var errKeywordDSN = errors.New("keyword/value connection strings such as host=db dbname=gbfs are not supported; use a postgres://user@host/db URL")
+
+// openPostgres opens a Postgres database through pgx.
+func openPostgres(dsn string) (*sql.DB, error) {
+	cfg, err := pgx.ParseConfig(dsn)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := cfg.RuntimeParams["application_name"]; !ok {
+		cfg.RuntimeParams["application_name"] = "gbfs-validator"
+	}
+	return stdlib.OpenDB(*cfg), nil
+}
+
+// openSQLite opens a SQLite database file, creating it if needed. Writers
+// wait up to five seconds for the file lock instead of failing at once.
+func openSQLite(path string) (*sql.DB, error) {
+	if path == "" {
+		return nil, fmt.Errorf("sqlite: needs a database file, as sqlite:path")
+	}
+	return sql.Open("sqlite", "file:"+path+"?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)")
+}
@@ -0,0 +1,263 @@
+package archive
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gbfs-validator-go/pkg/validator"
+)
+
+// Dialect adapts SQLStore queries to a database.
+type Dialect struct {
+	Name string
+	// dir is the directory of the dialect's migrations.
+	dir string
+	// lock, if set, serializes migrations across processes for the rest
+	// of the transaction.
+	lock string
+	// numbered selects $1, $2, ... parameters instead of ?.
+	numbered bool
+	// pool is the default connection pool configuration.
+	pool Pool
+}
+
+// Dialects supported by SQLStore. SQLite allows one connection, as it
+// locks the database file for each writer; the Postgres lock key is
+// arbitrary but fixed.
+var (
+	SQLite   = Dialect{Name: "SQLite", dir: "sqlite", pool: Pool{MaxOpenConns: 1}}
+	Postgres = Dialect{Name: "Postgres", dir: "postgres", lock: "SELECT pg_advisory_xact_lock(4742135083)", numbered: true,
+		pool: Pool{MaxOpenConns: 10, MaxIdleConns: 5, ConnMaxLifetime: 30 * time.Minute, ConnMaxIdleTime: 5 * time.Minute}}
+)
+
+// Rebind rewrites the ? parameters of query for the dialect.
+func (d Dialect) Rebind(query string) string {
+	if !d.numbered {
+		return query
+	}
+	var b strings.Builder
+	n := 0
+	for _, r := range query {
+		if r == '?' {
+			n++
+			b.WriteString("$" + strconv.Itoa(n))
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// SQLStore keeps results in a SQLite or Postgres database, in the
+// gbfs_runs table. Replicas may share a database: writes of the same run
+// are upserts, and writes the database aborts to resolve a conflict are
+// retried.
+type SQLStore struct {
+	db      *sql.DB
+	dialect Dialect
+}
+
+// NewSQLStore returns a store in db, applying the schema migrations the
+// database needs.
+func NewSQLStore(ctx context.Context, db *sql.DB, d Dialect) (*SQLStore, error) {
+	if err := Migrate(ctx, db, d); err != nil {
+		return nil, fmt.Errorf("migrating %s database: %w", d.Name, err)
+	}
+	return &SQLStore{db: db, dialect: d}, nil
+}
+
+// Close closes the database.
+func (s *SQLStore) Close() error {
+	return s.db.Close()
+}
+
+// SaveRun stores a result and its trend measures.
+func (s *SQLStore) SaveRun(ctx context.Context, feedURL string, at time.Time, result *validator.ValidationResult) error {
+	stored := *result
+	stored.ResultSchemaVersion = validator.ResultSchemaVersion
+	data, err := json.Marshal(&stored)
+	if err != nil {
+		return err
+	}
+	stats := Stats(result)
+	available := 0
+	if stats.Available {
+		available = 1
+	}
+	query := s.dialect.Rebind(`INSERT INTO gbfs_runs
+		(feed_key, feed_url, run_at, errors, score, available, fetch_ms, result)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (feed_key, run_at) DO UPDATE SET
+		feed_url = excluded.feed_url, errors = excluded.errors, score = excluded.score,
+		available = excluded.available, fetch_ms = excluded.fetch_ms, result = excluded.result`)
+	for attempt := 0; ; attempt++ {
+		_, err = s.db.ExecContext(ctx, query,
+			FeedKey(feedURL), feedURL, at.UnixNano(), stats.Errors, stats.Score, available, stats.FetchMs, data)
+		if err == nil || attempt == saveAttempts-1 || !retryable(err) {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(time.Duration(attempt+1) * 50 * time.Millisecond):
+		}
+	}
+}
+
+// saveAttempts bounds the retries of a conflicting write.
+const saveAttempts = 4
+
+// retryable reports whether the database aborted a statement that may
+// succeed if run again: a serialization failure or a deadlock.
+func retryable(err error) bool {
+	var state interface{ SQLState() string }
+	if !errors.As(err, &state) {
+		return false
+	}
+	switch state.SQLState() {
+	case "40001", "40P01":
+		return true
+	}
+	return false
+}
+
+// GetRun loads a result.
+func (s *SQLStore) GetRun(ctx context.Context, feedKey string, at time.Time) (*validator.ValidationResult, error) {
+	var data []byte
+	err := s.db.QueryRowContext(ctx, s.dialect.Rebind(`SELECT result FROM gbfs_runs WHERE feed_key = ? AND run_at = ?`),
+		feedKey, at.UnixNano()).Scan(&data)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return validator.UpgradeResult(data)
+}
+
+// ListRuns returns a feed's run times, oldest first.
+func (s *SQLStore) ListRuns(ctx context.Context, feedKey string) ([]time.Time, error) {
+	rows, err := s.db.QueryContext(ctx, s.dialect.Rebind(`SELECT run_at FROM gbfs_runs WHERE feed_key = ? ORDER BY run_at`), feedKey)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var runs []time.Time
+	for rows.Next() {
+		var at int64
+		if err := rows.Scan(&at); err != nil {
+			return nil, err
+		}
+		runs = append(runs, time.Unix(0, at).UTC())
+	}
+	return runs, rows.Err()
+}
+
+// Trends buckets a feed's runs in [from, to) by interval from the stored
+// trend measures.
+func (s *SQLStore) Trends(ctx context.Context, feedKey string, interval Interval, from, to time.Time) ([]TrendBucket, error) {
+	rows, err := s.db.QueryContext(ctx, s.dialect.Rebind(`SELECT run_at, errors, score, available, fetch_ms FROM gbfs_runs
+		WHERE feed_key = ? AND run_at >= ? AND run_at < ? ORDER BY run_at`), feedKey, from.UnixNano(), to.UnixNano())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var stats []runStats
+	for rows.Next() {
+		var run runStats
+		var at, available int64
+		if err := rows.Scan(&at, &run.Errors, &run.Score, &available, &run.FetchMs); err != nil {
+			return nil, err
+		}
+		run.At = time.Unix(0, at).UTC()
+		run.Available = available != 0
+		stats = append(stats, run)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return bucketTrends(stats, interval), nil
+}
+
+// Pool configures a store's database connection pool; zero fields keep
+// the dialect's default.
+type Pool struct {
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+	ConnMaxIdleTime time.Duration
+}
+
+// apply sets the pool's nonzero limits on db.
+func (p Pool) apply(db *sql.DB) {
+	if p.MaxOpenConns > 0 {
+		db.SetMaxOpenConns(p.MaxOpenConns)
+	}
+	if p.MaxIdleConns > 0 {
+		db.SetMaxIdleConns(p.MaxIdleConns)
+	}
+	if p.ConnMaxLifetime > 0 {
+		db.SetConnMaxLifetime(p.ConnMaxLifetime)
+	}
+	if p.ConnMaxIdleTime > 0 {
+		db.SetConnMaxIdleTime(p.ConnMaxIdleTime)
+	}
+}
+
+// parsePool removes the pool_max_conns, pool_max_idle_conns,
+// pool_max_conn_lifetime, and pool_max_conn_idle_time parameters from the
+// query of dsn, returning the remaining DSN and the pool they configure
+// over the default. dsn is a URL or a SQLite path; OpenDB refuses libpq
+// keyword/value strings before they get here.
+func parsePool(dsn string, pool Pool) (string, Pool, error) {
+	base, rawQuery, ok := strings.Cut(dsn, "?")
+	if !ok {
+		return dsn, pool, nil
+	}
+	query, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return "", pool, err
+	}
+	for key, values := range query {
+		if !strings.HasPrefix(key, "pool_") {
+			continue
+		}
+		value := values[len(values)-1]
+		switch key {
+		case "pool_max_conns", "pool_max_idle_conns":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 {
+				return "", pool, fmt.Errorf("invalid %s %q", key, value)
+			}
+			if key == "pool_max_conns" {
+				pool.MaxOpenConns = n
+			} else {
+				pool.MaxIdleConns = n
+			}
+		case "pool_max_conn_lifetime", "pool_max_conn_idle_time":
+			d, err := time.ParseDuration(value)
+			if err != nil || d <= 0 {
+				return "", pool, fmt.Errorf("invalid %s %q", key, value)
+			}
+			if key == "pool_max_conn_lifetime" {
+				pool.ConnMaxLifetime = d
+			} else {
+				pool.ConnMaxIdleTime = d
+			}
+		default:
+			return "", pool, fmt.Errorf("unsupported pool parameter %q", key)
+		}
+		delete(query, key)
+	}
+	if len(query) == 0 {
+		return base, pool, nil
+	}
+	return base + "?" + query.Encode(), pool, nil
+}
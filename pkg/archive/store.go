@@ -0,0 +1,137 @@
+// Package archive persists validation results and feed snapshots in object
+// storage (S3, GCS, MinIO) or a local directory, keyed by feed URL hash and
+// run time.
+package archive
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ErrNotFound is returned when an object does not exist.
+var ErrNotFound = errors.New("object not found")
+
+// ObjectStore is a flat key/value blob store. Keys use "/" separators.
+type ObjectStore interface {
+	Put(ctx context.Context, key string, data []byte) error
+	Get(ctx context.Context, key string) ([]byte, error)
+	// List returns the keys starting with prefix, sorted.
+	List(ctx context.Context, prefix string) ([]string, error)
+	Delete(ctx context.Context, key string) error
+}
+
+// Open returns the store named by uri:
+//
+//	s3://bucket/prefix   S3 or MinIO, configured from AWS_* variables
+//	gs://bucket/prefix   GCS through its S3-compatible API with HMAC keys
+//	file:///dir or dir   a local directory
+//
+// The path portion of s3:// and gs:// URIs is returned as the key prefix.
+func Open(uri string) (ObjectStore, string, error) {
+	u, err := url.Parse(uri)
+	if err != nil || u.Scheme == "" || u.Scheme == "file" {
+		dir := uri
+		if err == nil && u.Scheme == "file" {
+			dir = u.Path
+		}
+		return &DirStore{Root: dir}, "", nil
+	}
+
+	prefix := strings.Trim(u.Path, "/")
+	switch u.Scheme {
+	case "s3":
+		cfg := S3ConfigFromEnv()
+		cfg.Bucket = u.Host
+		return NewS3Store(cfg), prefix, nil
+	case "gs":
+		cfg := GCSConfigFromEnv()
+		cfg.Bucket = u.Host
+		return NewS3Store(cfg), prefix, nil
+	default:
+		return nil, "", fmt.Errorf("unsupported archive scheme %q", u.Scheme)
+	}
+}
+
+// DirStore keeps objects as files under Root.
+type DirStore struct {
+	Root string
+}
+
+// path maps a key to a file path, rejecting keys that escape Root.
+func (d *DirStore) path(key string) (string, error) {
+	clean := filepath.Clean(filepath.FromSlash(key))
+	if clean == "." || filepath.IsAbs(clean) || strings.HasPrefix(clean, "..") {
+		return "", fmt.Errorf("invalid key %q", key)
+	}
+	return filepath.Join(d.Root, clean), nil
+}
+
+// Put writes an object, creating directories as needed.
+func (d *DirStore) Put(ctx context.Context, key string, data []byte) error {
+	p, err := d.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(p, data, 0o644)
+}
+
+// Get reads an object.
+func (d *DirStore) Get(ctx context.Context, key string) ([]byte, error) {
+	p, err := d.path(key)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(p)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	return data, err
+}
+
+// List walks Root for keys starting with prefix.
+func (d *DirStore) List(ctx context.Context, prefix string) ([]string, error) {
+	var keys []string
+	err := filepath.WalkDir(d.Root, func(p string, e fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		if e.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(d.Root, p)
+		if err != nil {
+			return err
+		}
+		if key := filepath.ToSlash(rel); strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+		return nil
+	})
+	sort.Strings(keys)
+	return keys, err
+}
+
+// Delete removes an object. Missing objects are not an error.
+func (d *DirStore) Delete(ctx context.Context, key string) error {
+	p, err := d.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(p); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
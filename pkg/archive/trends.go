@@ -0,0 +1,147 @@
+package archive
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/gbfs-validator-go/pkg/validator"
+)
+
+// Interval is the width of a trend bucket.
+type Interval string
+
+const (
+	Hourly Interval = "hour"
+	Daily  Interval = "day"
+)
+
+// truncate returns the start of the bucket containing t.
+func (i Interval) truncate(t time.Time) time.Time {
+	t = t.UTC()
+	if i == Daily {
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	}
+	return t.Truncate(time.Hour)
+}
+
+// ParseInterval parses "hour" or "day".
+func ParseInterval(s string) (Interval, error) {
+	switch Interval(s) {
+	case Hourly, Daily:
+		return Interval(s), nil
+	}
+	return "", fmt.Errorf("invalid interval %q (want hour or day)", s)
+}
+
+// RunStats are the trend measures of one run.
+type RunStats struct {
+	Errors int `json:"errors"`
+	// Score is the percentage of checked files that are valid.
+	Score float64 `json:"score"`
+	// Available reports whether every required file could be fetched.
+	Available bool `json:"available"`
+	// FetchMs is the total time spent fetching files.
+	FetchMs int64 `json:"fetchMs"`
+}
+
+// Stats computes the trend measures of a result.
+func Stats(result *validator.ValidationResult) RunStats {
+	stats := RunStats{Errors: result.Summary.ErrorsCount, Available: true}
+	checked, valid := 0, 0
+	for _, f := range result.Files {
+		if f.Timing != nil {
+			stats.FetchMs += f.Timing.FetchMs
+		}
+		switch f.Status {
+		case validator.FileStatusAbsent:
+			continue
+		case validator.FileStatusValid:
+			valid++
+		case validator.FileStatusMissing:
+			stats.Available = false
+		}
+		checked++
+	}
+	if checked > 0 {
+		stats.Score = 100 * float64(valid) / float64(checked)
+	}
+	return stats
+}
+
+// TrendBucket aggregates the runs that started in one interval.
+type TrendBucket struct {
+	Start        time.Time `json:"start"`
+	Runs         int       `json:"runs"`
+	ErrorsAvg    float64   `json:"errorsAvg"`
+	ErrorsMax    int       `json:"errorsMax"`
+	ScoreAvg     float64   `json:"scoreAvg"`
+	Availability float64   `json:"availability"`
+	FetchMsAvg   float64   `json:"fetchMsAvg"`
+	FetchMsMax   int64     `json:"fetchMsMax"`
+}
+
+// Trends buckets a feed's runs in [from, to) by interval, oldest first.
+// Intervals without runs are omitted.
+func (a *Archive) Trends(ctx context.Context, feedKey string, interval Interval, from, to time.Time) ([]TrendBucket, error) {
+	runs, err := a.RunsByKey(ctx, feedKey)
+	if err != nil {
+		return nil, err
+	}
+	var stats []runStats
+	for _, at := range runs {
+		if at.Before(from) || !at.Before(to) {
+			continue
+		}
+		result, err := a.ResultByKey(ctx, feedKey, at)
+		if err != nil {
+			return nil, err
+		}
+		stats = append(stats, runStats{At: at, RunStats: Stats(result)})
+	}
+	return bucketTrends(stats, interval), nil
+}
+
+// runStats are the trend measures of a run and when it started.
+type runStats struct {
+	At time.Time
+	RunStats
+}
+
+// bucketTrends aggregates runs, oldest first, into buckets of interval.
+func bucketTrends(runs []runStats, interval Interval) []TrendBucket {
+	var buckets []TrendBucket
+	var available int
+	flush := func() {
+		if len(buckets) == 0 {
+			return
+		}
+		b := &buckets[len(buckets)-1]
+		n := float64(b.Runs)
+		b.ErrorsAvg /= n
+		b.ScoreAvg /= n
+		b.FetchMsAvg /= n
+		b.Availability = float64(available) / n
+		available = 0
+	}
+
+	for _, run := range runs {
+		start := interval.truncate(run.At)
+		if len(buckets) == 0 || !buckets[len(buckets)-1].Start.Equal(start) {
+			flush()
+			buckets = append(buckets, TrendBucket{Start: start})
+		}
+		b := &buckets[len(buckets)-1]
+		b.Runs++
+		b.ErrorsAvg += float64(run.Errors)
+		b.ErrorsMax = max(b.ErrorsMax, run.Errors)
+		b.ScoreAvg += run.Score
+		b.FetchMsAvg += float64(run.FetchMs)
+		b.FetchMsMax = max(b.FetchMsMax, run.FetchMs)
+		if run.Available {
+			available++
+		}
+	}
+	flush()
+	return buckets
+}
@@ -0,0 +1,141 @@
+// Package catalog reads the systems.csv catalog of public GBFS feeds
+// maintained by MobilityData, to look up the system a feed is registered
+// as.
+package catalog
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/gbfs-validator-go/pkg/fetcher"
+)
+
+// DefaultURL is where MobilityData publishes the catalog.
+const DefaultURL = "https://raw.githubusercontent.com/MobilityData/gbfs/master/systems.csv"
+
+// System is one catalog entry.
+type System struct {
+	ID               string `json:"id"`
+	Name             string `json:"name"`
+	Location         string `json:"location,omitempty"`
+	CountryCode      string `json:"countryCode,omitempty"`
+	AutoDiscoveryURL string `json:"autoDiscoveryUrl"`
+}
+
+// Catalog is a parsed catalog, indexed by auto-discovery URL.
+type Catalog struct {
+	Systems []System
+	byURL   map[string]int
+}
+
+// columns maps System fields to catalog column names.
+var columns = map[string]string{
+	"id":       "System ID",
+	"name":     "Name",
+	"location": "Location",
+	"country":  "Country Code",
+	"url":      "Auto-Discovery URL",
+}
+
+// Parse reads a catalog in the systems.csv layout. Columns are found by
+// header name, so their order does not matter; "System ID" and
+// "Auto-Discovery URL" are required.
+func Parse(r io.Reader) (*Catalog, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	header, err := cr.Read()
+	if err != nil {
+		return nil, fmt.Errorf("reading catalog header: %w", err)
+	}
+	index := make(map[string]int)
+	for i, name := range header {
+		for key, column := range columns {
+			if strings.EqualFold(strings.TrimSpace(name), column) {
+				index[key] = i
+			}
+		}
+	}
+	for _, key := range []string{"id", "url"} {
+		if _, ok := index[key]; !ok {
+			return nil, fmt.Errorf("catalog has no %q column", columns[key])
+		}
+	}
+
+	c := &Catalog{byURL: make(map[string]int)}
+	for {
+		record, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("reading catalog: %w", err)
+		}
+		field := func(key string) string {
+			i, ok := index[key]
+			if !ok || i >= len(record) {
+				return ""
+			}
+			return strings.TrimSpace(record[i])
+		}
+		s := System{ID: field("id"), Name: field("name"), Location: field("location"),
+			CountryCode: field("country"), AutoDiscoveryURL: field("url")}
+		if s.AutoDiscoveryURL == "" {
+			continue
+		}
+		c.byURL[normalizeURL(s.AutoDiscoveryURL)] = len(c.Systems)
+		c.Systems = append(c.Systems, s)
+	}
+	return c, nil
+}
+
+// Load reads a catalog from a local file or an http(s) URL.
+func Load(ctx context.Context, location string) (*Catalog, error) {
+	if strings.HasPrefix(location, "http://") || strings.HasPrefix(location, "https://") {
+		result := fetcher.New().Fetch(ctx, location)
+		if result.Error != nil {
+			return nil, result.Error
+		}
+		if !result.Exists {
+			return nil, fmt.Errorf("%s was not found", fetcher.RedactURL(location))
+		}
+		return Parse(bytes.NewReader(result.Body))
+	}
+	f, err := os.Open(location)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Parse(f)
+}
+
+// Lookup returns the system registered with the auto-discovery URL
+// gbfsURL. URLs match regardless of scheme, host case, and a trailing
+// slash.
+func (c *Catalog) Lookup(gbfsURL string) (System, bool) {
+	i, ok := c.byURL[normalizeURL(gbfsURL)]
+	if !ok {
+		return System{}, false
+	}
+	return c.Systems[i], true
+}
+
+// SystemID returns the system_id registered for gbfsURL.
+func (c *Catalog) SystemID(gbfsURL string) (string, bool) {
+	s, ok := c.Lookup(gbfsURL)
+	return s.ID, ok && s.ID != ""
+}
+
+// normalizeURL drops the parts of a URL that do not identify a feed.
+func normalizeURL(u string) string {
+	u = strings.TrimSpace(u)
+	if i := strings.Index(u, "://"); i >= 0 {
+		u = u[i+3:]
+	}
+	host, path, _ := strings.Cut(u, "/")
+	return strings.TrimSuffix(strings.ToLower(host)+"/"+path, "/")
+}
@@ -0,0 +1,35 @@
+package catalog
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	csv := `Country Code,Name,Location,System ID,URL,Auto-Discovery URL,Supported Versions
+US,Example Bikes,"Austin, TX",example_austin,https://example.com,https://GBFS.example.com/austin/gbfs.json,2.3
+FR,Vélo,Paris,velo_paris,https://velo.example,https://velo.example/gbfs/,3.0
+`
+	c, err := Parse(strings.NewReader(csv))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if len(c.Systems) != 2 || c.Systems[0].Location != "Austin, TX" {
+		t.Fatalf("unexpected systems: %+v", c.Systems)
+	}
+
+	for url, want := range map[string]string{
+		"https://gbfs.example.com/austin/gbfs.json": "example_austin",
+		"http://gbfs.example.com/austin/gbfs.json/": "example_austin",
+		"https://velo.example/gbfs":                 "velo_paris",
+		"https://other.example/gbfs.json":           "",
+	} {
+		if got, _ := c.SystemID(url); got != want {
+			t.Errorf("SystemID(%s) = %q, want %q", url, got, want)
+		}
+	}
+
+	if _, err := Parse(strings.NewReader("Name,URL\nA,https://a.example\n")); err == nil {
+		t.Error("expected an error for a catalog without required columns")
+	}
+}
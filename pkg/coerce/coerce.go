@@ -4,6 +4,7 @@ package coerce
 import (
 	"encoding/json"
 	"fmt"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -653,6 +654,16 @@ func (log *CoercionLog) Summarize() CoercionSummary {
 	for _, d := range detailMap {
 		summary.Details = append(summary.Details, *d)
 	}
+	sort.Slice(summary.Details, func(i, j int) bool {
+		a, b := summary.Details[i], summary.Details[j]
+		if a.Field != b.Field {
+			return a.Field < b.Field
+		}
+		if a.FromType != b.FromType {
+			return a.FromType < b.FromType
+		}
+		return a.ToType < b.ToType
+	})
 
 	return summary
 }
@@ -0,0 +1,197 @@
+// Package compare validates several operators' feeds and tabulates the
+// results so they can be benchmarked against each other.
+package compare
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gbfs-validator-go/pkg/archive"
+	"github.com/gbfs-validator-go/pkg/gbfs"
+	"github.com/gbfs-validator-go/pkg/validator"
+)
+
+// parallelism is how many feeds are validated at once.
+const parallelism = 4
+
+// ValidateFunc validates the feed at url.
+type ValidateFunc func(ctx context.Context, url string) (*validator.ValidationResult, error)
+
+// Row summarizes one feed.
+type Row struct {
+	URL      string `json:"url"`
+	SystemID string `json:"systemId,omitempty"`
+	Name     string `json:"name,omitempty"`
+	Version  string `json:"version,omitempty"`
+	Valid    bool   `json:"valid"`
+	// Score is the percentage of checked files that are valid.
+	Score    float64 `json:"score"`
+	Errors   int     `json:"errors"`
+	Warnings int     `json:"warnings"`
+	// Files lists the files the feed publishes.
+	Files    []string `json:"files"`
+	Stations int      `json:"stations"`
+	// FleetSize counts vehicles available at stations plus vehicles listed
+	// away from a station.
+	FleetSize  int                                                  `json:"fleetSize"`
+	Categories map[validator.ErrorCategory]*validator.CategoryCount `json:"categories,omitempty"`
+	// Error is set when the feed could not be validated at all.
+	Error string `json:"error,omitempty"`
+}
+
+// Report is a comparison of several feeds.
+type Report struct {
+	Rows []Row `json:"rows"`
+	// Categories lists the error categories any feed has issues in, in
+	// the validator's category order, as the columns of a table.
+	Categories []validator.ErrorCategory `json:"categories"`
+}
+
+// Feeds validates each URL and returns one row per feed, in the order
+// given.
+func Feeds(ctx context.Context, urls []string, validate ValidateFunc) *Report {
+	report := &Report{Rows: make([]Row, len(urls))}
+	sem := make(chan struct{}, parallelism)
+	var wg sync.WaitGroup
+	for i, url := range urls {
+		wg.Add(1)
+		go func(i int, url string) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			result, err := validate(ctx, url)
+			if err != nil {
+				report.Rows[i] = Row{URL: url, Error: err.Error()}
+				return
+			}
+			report.Rows[i] = Summarize(url, result)
+		}(i, url)
+	}
+	wg.Wait()
+
+	seen := make(map[validator.ErrorCategory]bool)
+	for _, row := range report.Rows {
+		for c := range row.Categories {
+			seen[c] = true
+		}
+	}
+	for _, c := range validator.ErrorCategories() {
+		if seen[c] {
+			report.Categories = append(report.Categories, c)
+		}
+	}
+	return report
+}
+
+// Summarize builds the row for one validation result.
+func Summarize(url string, result *validator.ValidationResult) Row {
+	row := Row{
+		URL:        url,
+		Version:    result.Summary.Version.Validated,
+		Valid:      !result.Summary.HasErrors,
+		Score:      archive.Stats(result).Score,
+		Errors:     result.Summary.ErrorsCount,
+		Categories: result.Summary.Categories,
+	}
+	for _, c := range result.Summary.Categories {
+		row.Warnings += c.Warnings
+	}
+
+	// Files repeat per language when several are validated; the first
+	// language is counted.
+	seen := make(map[string]bool)
+	for _, f := range result.Files {
+		if !f.Exists || seen[f.File] {
+			continue
+		}
+		seen[f.File] = true
+		row.Files = append(row.Files, strings.TrimSuffix(f.File, ".json"))
+		data := f.RawData
+		if f.CoercedData != nil {
+			data = f.CoercedData
+		}
+		switch f.File {
+		case "system_information.json":
+			var info gbfs.SystemInformation
+			if json.Unmarshal(data, &info) == nil {
+				row.SystemID, row.Name = info.Data.SystemID, info.Data.Name.Default()
+			}
+		case "station_information.json":
+			var info gbfs.StationInformation
+			if json.Unmarshal(data, &info) == nil {
+				row.Stations = len(info.Data.Stations)
+			}
+		case "station_status.json":
+			var status gbfs.StationStatus
+			if json.Unmarshal(data, &status) == nil {
+				for _, s := range status.Data.Stations {
+					row.FleetSize += s.Available()
+				}
+			}
+		case "vehicle_status.json", "free_bike_status.json":
+			var status gbfs.VehicleStatus
+			if json.Unmarshal(data, &status) == nil {
+				for _, v := range status.Data.GetVehicles() {
+					if v.StationID == "" {
+						row.FleetSize++
+					}
+				}
+			}
+		}
+	}
+	return row
+}
+
+// Header returns the table columns: fixed measures followed by the error
+// count of each category in Categories.
+func (r *Report) Header() []string {
+	header := []string{"url", "system_id", "name", "version", "valid", "score", "errors", "warnings", "files", "stations", "fleet_size"}
+	for _, c := range r.Categories {
+		header = append(header, string(c))
+	}
+	return append(header, "error")
+}
+
+// Table returns the rows as strings matching Header.
+func (r *Report) Table() [][]string {
+	var table [][]string
+	for _, row := range r.Rows {
+		cells := []string{
+			row.URL, row.SystemID, row.Name, row.Version,
+			strconv.FormatBool(row.Valid),
+			strconv.FormatFloat(row.Score, 'f', 1, 64),
+			strconv.Itoa(row.Errors),
+			strconv.Itoa(row.Warnings),
+			strconv.Itoa(len(row.Files)),
+			strconv.Itoa(row.Stations),
+			strconv.Itoa(row.FleetSize),
+		}
+		for _, c := range r.Categories {
+			n := 0
+			if count, ok := row.Categories[c]; ok {
+				n = count.Errors
+			}
+			cells = append(cells, strconv.Itoa(n))
+		}
+		table = append(table, append(cells, row.Error))
+	}
+	return table
+}
+
+// WriteCSV writes the comparison table as CSV.
+func (r *Report) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(r.Header()); err != nil {
+		return err
+	}
+	if err := cw.WriteAll(r.Table()); err != nil {
+		return err
+	}
+	cw.Flush()
+	return cw.Error()
+}
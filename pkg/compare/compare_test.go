@@ -0,0 +1,52 @@
+package compare
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/gbfs-validator-go/pkg/validator"
+)
+
+func TestFeeds(t *testing.T) {
+	results := map[string]*validator.ValidationResult{
+		"https://a.example/gbfs.json": {
+			Summary: validator.ValidationSummary{
+				Version:    validator.VersionInfo{Validated: "3.0"},
+				Categories: map[validator.ErrorCategory]*validator.CategoryCount{validator.CategorySemantic: {Warnings: 2}},
+			},
+			Files: []validator.FileValidationResult{
+				{File: "system_information.json", Exists: true, Status: validator.FileStatusValid,
+					RawData: []byte(`{"data":{"system_id":"a","name":[{"text":"Alpha","language":"en"}]}}`)},
+				{File: "station_information.json", Exists: true, Status: validator.FileStatusValid,
+					RawData: []byte(`{"data":{"stations":[{"station_id":"s1"},{"station_id":"s2"}]}}`)},
+				{File: "station_status.json", Exists: true, Status: validator.FileStatusValid,
+					RawData: []byte(`{"data":{"stations":[{"station_id":"s1","num_vehicles_available":3},{"station_id":"s2","num_vehicles_available":1}]}}`)},
+				{File: "vehicle_status.json", Exists: true, Status: validator.FileStatusInvalid,
+					RawData: []byte(`{"data":{"vehicles":[{"vehicle_id":"v1"},{"vehicle_id":"v2","station_id":"s1"}]}}`)},
+				{File: "geofencing_zones.json", Status: validator.FileStatusAbsent},
+			},
+		},
+	}
+	validate := func(ctx context.Context, url string) (*validator.ValidationResult, error) {
+		if r, ok := results[url]; ok {
+			return r, nil
+		}
+		return nil, errors.New("unreachable")
+	}
+
+	report := Feeds(context.Background(), []string{"https://a.example/gbfs.json", "https://b.example/gbfs.json"}, validate)
+	if len(report.Rows) != 2 {
+		t.Fatalf("expected 2 rows, got %d", len(report.Rows))
+	}
+	a := report.Rows[0]
+	if a.Name != "Alpha" || a.Stations != 2 || a.FleetSize != 5 || a.Warnings != 2 || a.Score != 75 || len(a.Files) != 4 {
+		t.Errorf("unexpected row: %+v", a)
+	}
+	if report.Rows[1].Error != "unreachable" {
+		t.Errorf("expected the second feed to fail, got %+v", report.Rows[1])
+	}
+	if len(report.Categories) != 1 || report.Categories[0] != validator.CategorySemantic {
+		t.Errorf("categories = %v", report.Categories)
+	}
+}
@@ -0,0 +1,267 @@
+// Package coverage measures how much of a system's fleet and service area
+// its geofencing zones cover.
+package coverage
+
+import (
+	"math"
+	"sort"
+
+	"github.com/gbfs-validator-go/pkg/gbfs"
+)
+
+// rows is the number of latitude bands areas are integrated over.
+const rows = 2000
+
+// kmPerDegree is the length of a degree of latitude.
+const kmPerDegree = 111.32
+
+// Point is a station or vehicle outside every zone.
+type Point struct {
+	Kind string  `json:"kind"`
+	ID   string  `json:"id"`
+	Lat  float64 `json:"lat"`
+	Lon  float64 `json:"lon"`
+}
+
+// Report compares the area covered by geofencing zones with where the
+// fleet actually is.
+type Report struct {
+	Zones int `json:"zones"`
+	// ZoneAreaKm2 is the area of the union of all zones, counting overlaps
+	// once.
+	ZoneAreaKm2 float64 `json:"zoneAreaKm2"`
+	// HullAreaKm2 is the area of the convex hull of every station and
+	// vehicle position, a proxy for the service area.
+	HullAreaKm2 float64 `json:"hullAreaKm2"`
+	// HullCoveredPercent is the share of the hull inside some zone.
+	HullCoveredPercent float64 `json:"hullCoveredPercent"`
+
+	Stations               int     `json:"stations"`
+	StationsCoveredPercent float64 `json:"stationsCoveredPercent"`
+	Vehicles               int     `json:"vehicles"`
+	// FleetCoveredPercent is the share of vehicles inside some zone.
+	FleetCoveredPercent float64 `json:"fleetCoveredPercent"`
+	// Outside lists the stations and then vehicles outside every zone.
+	Outside []Point `json:"outside,omitempty"`
+}
+
+// Analyze computes coverage from a system's files; any may be nil.
+// Vehicles without a position, such as those docked at a station, are not
+// counted.
+func Analyze(zones *gbfs.GeofencingZones, stations *gbfs.StationInformation, vehicles *gbfs.VehicleStatus) *Report {
+	var shapes []gbfs.MultiPolygon
+	if zones != nil {
+		for _, f := range zones.Data.GeofencingZones.Features {
+			if shape, err := f.Geometry.MultiPolygon(); err == nil && shape.Validate() == nil {
+				shapes = append(shapes, shape)
+			}
+		}
+	}
+	report := &Report{Zones: len(shapes)}
+	inside := func(lon, lat float64) bool {
+		for _, s := range shapes {
+			if s.Contains(gbfs.Position{lon, lat}) {
+				return true
+			}
+		}
+		return false
+	}
+
+	var positions []gbfs.Position
+	var covered int
+	if stations != nil {
+		for _, s := range stations.Data.Stations {
+			report.Stations++
+			positions = append(positions, gbfs.Position{s.Lon, s.Lat})
+			if inside(s.Lon, s.Lat) {
+				covered++
+			} else {
+				report.Outside = append(report.Outside, Point{Kind: "station", ID: s.StationID, Lat: s.Lat, Lon: s.Lon})
+			}
+		}
+	}
+	report.StationsCoveredPercent = percent(covered, report.Stations)
+
+	covered = 0
+	if vehicles != nil {
+		for _, v := range vehicles.Data.GetVehicles() {
+			if v.Lat == 0 && v.Lon == 0 {
+				continue
+			}
+			report.Vehicles++
+			positions = append(positions, gbfs.Position{v.Lon, v.Lat})
+			if inside(v.Lon, v.Lat) {
+				covered++
+			} else {
+				id := v.VehicleID
+				if id == "" {
+					id = v.BikeID
+				}
+				report.Outside = append(report.Outside, Point{Kind: "vehicle", ID: id, Lat: v.Lat, Lon: v.Lon})
+			}
+		}
+	}
+	report.FleetCoveredPercent = percent(covered, report.Vehicles)
+
+	hull := convexHull(positions)
+	var hullCovered float64
+	report.ZoneAreaKm2, report.HullAreaKm2, hullCovered = areas(shapes, hull)
+	if report.HullAreaKm2 > 0 {
+		report.HullCoveredPercent = round(100 * hullCovered / report.HullAreaKm2)
+	}
+	report.ZoneAreaKm2 = round(report.ZoneAreaKm2)
+	report.HullAreaKm2 = round(report.HullAreaKm2)
+	return report
+}
+
+// percent returns n as a percentage of total, or 0 when total is 0.
+func percent(n, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return round(100 * float64(n) / float64(total))
+}
+
+// round rounds to two decimals.
+func round(v float64) float64 {
+	return math.Round(v*100) / 100
+}
+
+// convexHull returns the hull of points in counter-clockwise order, using
+// Andrew's monotone chain.
+func convexHull(points []gbfs.Position) []gbfs.Position {
+	pts := append([]gbfs.Position(nil), points...)
+	sort.Slice(pts, func(i, j int) bool {
+		if pts[i][0] != pts[j][0] {
+			return pts[i][0] < pts[j][0]
+		}
+		return pts[i][1] < pts[j][1]
+	})
+	if len(pts) < 3 {
+		return pts
+	}
+	turn := func(o, a, b gbfs.Position) float64 {
+		return (a[0]-o[0])*(b[1]-o[1]) - (a[1]-o[1])*(b[0]-o[0])
+	}
+	hull := make([]gbfs.Position, 0, 2*len(pts))
+	for _, p := range pts {
+		for len(hull) >= 2 && turn(hull[len(hull)-2], hull[len(hull)-1], p) <= 0 {
+			hull = hull[:len(hull)-1]
+		}
+		hull = append(hull, p)
+	}
+	lower := len(hull) + 1
+	for i := len(pts) - 2; i >= 0; i-- {
+		p := pts[i]
+		for len(hull) >= lower && turn(hull[len(hull)-2], hull[len(hull)-1], p) <= 0 {
+			hull = hull[:len(hull)-1]
+		}
+		hull = append(hull, p)
+	}
+	return hull[:len(hull)-1]
+}
+
+// interval is a span of longitudes.
+type interval struct{ from, to float64 }
+
+// areas integrates, band by band of latitude, the area of the union of the
+// zones, of the hull, and of their intersection, in square kilometers.
+func areas(shapes []gbfs.MultiPolygon, hull []gbfs.Position) (zoneArea, hullArea, overlap float64) {
+	var hullRing gbfs.Ring
+	if len(hull) >= 3 {
+		hullRing = append(append(hullRing, hull...), hull[0])
+	}
+	south, north := math.Inf(1), math.Inf(-1)
+	for _, s := range shapes {
+		sw, ne := s.Bounds()
+		south, north = math.Min(south, sw[1]), math.Max(north, ne[1])
+	}
+	for _, p := range hullRing {
+		south, north = math.Min(south, p[1]), math.Max(north, p[1])
+	}
+	if south >= north {
+		return 0, 0, 0
+	}
+
+	step := (north - south) / rows
+	for i := 0; i < rows; i++ {
+		lat := south + (float64(i)+0.5)*step
+		var zone []interval
+		for _, s := range shapes {
+			for _, polygon := range s {
+				zone = append(zone, spans(polygon, lat)...)
+			}
+		}
+		zone = union(zone)
+		var hullSpans []interval
+		if hullRing != nil {
+			hullSpans = spans(gbfs.Polygon{hullRing}, lat)
+		}
+
+		bandKm2 := step * kmPerDegree * kmPerDegree * math.Cos(lat*math.Pi/180)
+		zoneArea += length(zone) * bandKm2
+		hullArea += length(hullSpans) * bandKm2
+		overlap += length(intersect(zone, hullSpans)) * bandKm2
+	}
+	return zoneArea, hullArea, overlap
+}
+
+// spans returns the longitudes inside a polygon along a latitude, by the
+// even-odd rule so holes are excluded.
+func spans(p gbfs.Polygon, lat float64) []interval {
+	var xs []float64
+	for _, ring := range p {
+		for i := 1; i < len(ring); i++ {
+			a, b := ring[i-1], ring[i]
+			if (a[1] > lat) != (b[1] > lat) {
+				xs = append(xs, a[0]+(lat-a[1])*(b[0]-a[0])/(b[1]-a[1]))
+			}
+		}
+	}
+	sort.Float64s(xs)
+	var out []interval
+	for i := 0; i+1 < len(xs); i += 2 {
+		out = append(out, interval{xs[i], xs[i+1]})
+	}
+	return out
+}
+
+// union merges overlapping intervals.
+func union(in []interval) []interval {
+	sort.Slice(in, func(i, j int) bool { return in[i].from < in[j].from })
+	var out []interval
+	for _, iv := range in {
+		if n := len(out); n > 0 && iv.from <= out[n-1].to {
+			out[n-1].to = math.Max(out[n-1].to, iv.to)
+			continue
+		}
+		out = append(out, iv)
+	}
+	return out
+}
+
+// intersect returns the overlap of two sorted, disjoint interval lists.
+func intersect(a, b []interval) []interval {
+	var out []interval
+	for i, j := 0, 0; i < len(a) && j < len(b); {
+		from, to := math.Max(a[i].from, b[j].from), math.Min(a[i].to, b[j].to)
+		if from < to {
+			out = append(out, interval{from, to})
+		}
+		if a[i].to < b[j].to {
+			i++
+		} else {
+			j++
+		}
+	}
+	return out
+}
+
+// length sums the widths of intervals.
+func length(in []interval) float64 {
+	var total float64
+	for _, iv := range in {
+		total += iv.to - iv.from
+	}
+	return total
+}
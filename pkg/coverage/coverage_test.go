@@ -0,0 +1,52 @@
+package coverage
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+
+	"github.com/gbfs-validator-go/pkg/gbfs"
+)
+
+func TestAnalyze(t *testing.T) {
+	var zones gbfs.GeofencingZones
+	err := json.Unmarshal([]byte(`{"data":{"geofencing_zones":{"type":"FeatureCollection","features":[
+		{"type":"Feature","geometry":{"type":"Polygon","coordinates":[[[0,0],[0.1,0],[0.1,0.1],[0,0.1],[0,0]]]},"properties":{}},
+		{"type":"Feature","geometry":{"type":"Polygon","coordinates":[[[0.05,0],[0.15,0],[0.15,0.1],[0.05,0.1],[0.05,0]]]},"properties":{}}
+	]}}}`), &zones)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stations := &gbfs.StationInformation{}
+	stations.Data.Stations = []gbfs.Station{
+		{StationID: "in", Lat: 0.05, Lon: 0.05},
+		{StationID: "out", Lat: 0.05, Lon: 0.2},
+	}
+	vehicles := &gbfs.VehicleStatus{}
+	vehicles.Data.Vehicles = []gbfs.Vehicle{
+		{VehicleID: "a", Lat: 0.01, Lon: 0.01},
+		{VehicleID: "b", Lat: 0.09, Lon: 0.14},
+		{VehicleID: "c", Lat: 0.2, Lon: 0.05},
+		{VehicleID: "docked"},
+	}
+
+	report := Analyze(&zones, stations, vehicles)
+
+	// The zones overlap by half, so their union is 0.15 by 0.1 degrees.
+	want := 0.15 * 0.1 * kmPerDegree * kmPerDegree
+	if math.Abs(report.ZoneAreaKm2-want)/want > 0.01 {
+		t.Errorf("zone area = %.2f km², want about %.2f", report.ZoneAreaKm2, want)
+	}
+	if report.Stations != 2 || report.StationsCoveredPercent != 50 {
+		t.Errorf("stations = %d, %.2f%% covered", report.Stations, report.StationsCoveredPercent)
+	}
+	if report.Vehicles != 3 || report.FleetCoveredPercent != 66.67 {
+		t.Errorf("vehicles = %d, %.2f%% covered", report.Vehicles, report.FleetCoveredPercent)
+	}
+	if len(report.Outside) != 2 || report.Outside[0].ID != "out" || report.Outside[1].ID != "c" {
+		t.Errorf("outside = %+v", report.Outside)
+	}
+	if report.HullCoveredPercent <= 0 || report.HullCoveredPercent >= 100 {
+		t.Errorf("hull covered = %.2f%%", report.HullCoveredPercent)
+	}
+}
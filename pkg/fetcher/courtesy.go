@@ -0,0 +1,345 @@
+package fetcher
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrDisallowed is returned, wrapped, for URLs robots.txt disallows.
+var ErrDisallowed = errors.New("disallowed by robots.txt")
+
+const (
+	// robotsSizeLimit is how much of a robots.txt is read, as RFC 9309
+	// allows.
+	robotsSizeLimit = 500 << 10
+	// robotsTTL is how long a robots.txt is cached; RFC 9309 asks not to
+	// use a cached copy for more than 24 hours.
+	robotsTTL = 24 * time.Hour
+)
+
+// Courtesy keeps bulk runs, such as validating many feeds, from overloading
+// feed hosts: it honors robots.txt and spaces out requests to each host.
+// The fetchers of a run share one Courtesy through WithCourtesy.
+type Courtesy struct {
+	// Robots skips URLs that the host's robots.txt disallows for the
+	// fetcher's user agent.
+	Robots bool
+	// Intervals is the minimum time between requests to a host, by host
+	// name. A name also covers its subdomains; "*" applies to hosts not
+	// listed.
+	Intervals map[string]time.Duration
+
+	mu     sync.Mutex
+	next   map[string]time.Time
+	robots map[string]*robotsEntry
+}
+
+// WithCourtesy makes the fetcher wait for and honor c before every request,
+// including probes.
+func WithCourtesy(c *Courtesy) Option {
+	return func(f *Fetcher) {
+		f.courtesy = c
+	}
+}
+
+// ParseIntervals reads a per-host polling registry: one "host interval"
+// pair per line, such as "gbfs.example.com 30s", with # comments. The host
+// "*" sets the interval for hosts not listed.
+func ParseIntervals(r io.Reader) (map[string]time.Duration, error) {
+	intervals := make(map[string]time.Duration)
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("line %d: expected host and interval", n)
+		}
+		d, err := time.ParseDuration(fields[1])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", n, err)
+		}
+		intervals[strings.ToLower(fields[0])] = d
+	}
+	return intervals, scanner.Err()
+}
+
+// LoadIntervals reads a per-host polling registry file; see ParseIntervals.
+func LoadIntervals(path string) (map[string]time.Duration, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ParseIntervals(f)
+}
+
+// interval returns the minimum time between requests to host and the key
+// its requests are spaced under: the registry name that matched, so that
+// subdomains share their parent's slots, or host itself for "*".
+func (c *Courtesy) interval(host string) (string, time.Duration) {
+	host = strings.ToLower(host)
+	for name := host; name != ""; {
+		if d, ok := c.Intervals[name]; ok {
+			return name, d
+		}
+		_, name, _ = strings.Cut(name, ".")
+	}
+	return host, c.Intervals["*"]
+}
+
+// wait blocks until a request to host is due and reserves the next slot. A
+// caller whose ctx ends first gives its slot back unless a later caller
+// has already reserved the one after it.
+func (c *Courtesy) wait(ctx context.Context, host string) error {
+	key, interval := c.interval(host)
+	if interval <= 0 {
+		return nil
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	c.mu.Lock()
+	if c.next == nil {
+		c.next = make(map[string]time.Time)
+	}
+	prev := c.next[key]
+	at := prev
+	if now := time.Now(); at.Before(now) {
+		at = now
+	}
+	reserved := at.Add(interval)
+	c.next[key] = reserved
+	c.mu.Unlock()
+
+	delay := time.Until(at)
+	if delay <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		c.mu.Lock()
+		if c.next[key].Equal(reserved) {
+			c.next[key] = prev
+		}
+		c.mu.Unlock()
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// admit waits until f may request u and checks u against robots.txt.
+func (f *Fetcher) admit(ctx context.Context, u *url.URL) error {
+	c := f.courtesy
+	if c == nil {
+		return nil
+	}
+	if c.Robots {
+		rules := c.robotsFor(ctx, f, u)
+		if !rules.allows(productToken(f.userAgent), requestPath(u)) {
+			return fmt.Errorf("%s: %w", RedactURL(u.String()), ErrDisallowed)
+		}
+	}
+	return c.wait(ctx, u.Hostname())
+}
+
+// robotsEntry caches the robots.txt of one origin.
+type robotsEntry struct {
+	// lock is held, as a one-slot semaphore so that waiters can give up,
+	// while the rules are read or fetched.
+	lock    chan struct{}
+	rules   *robotsRules
+	expires time.Time
+}
+
+// robotsFor returns the robots.txt rules of u's origin, fetching them at
+// most once per robotsTTL per Courtesy. A fetch that did not get an answer
+// from the server is not cached, so that one caller's cancellation does
+// not decide for the others.
+func (c *Courtesy) robotsFor(ctx context.Context, f *Fetcher, u *url.URL) *robotsRules {
+	origin := u.Scheme + "://" + strings.ToLower(u.Host)
+	c.mu.Lock()
+	if c.robots == nil {
+		c.robots = make(map[string]*robotsEntry)
+	}
+	entry, ok := c.robots[origin]
+	if !ok {
+		entry = &robotsEntry{lock: make(chan struct{}, 1)}
+		c.robots[origin] = entry
+	}
+	c.mu.Unlock()
+
+	select {
+	case entry.lock <- struct{}{}:
+	case <-ctx.Done():
+		return &robotsRules{}
+	}
+	defer func() { <-entry.lock }()
+	if entry.rules != nil && time.Now().Before(entry.expires) {
+		return entry.rules
+	}
+	rules, answered := c.fetchRobots(ctx, f, u.Hostname(), origin+"/robots.txt")
+	if answered {
+		entry.rules, entry.expires = rules, time.Now().Add(robotsTTL)
+	}
+	return rules
+}
+
+// fetchRobots fetches and parses a robots.txt, reporting whether the
+// server answered. As RFC 9309 requires, a missing file allows everything
+// and a server error disallows everything; an unreachable host allows the
+// request, whose own failure is reported.
+func (c *Courtesy) fetchRobots(ctx context.Context, f *Fetcher, host, robotsURL string) (*robotsRules, bool) {
+	if err := c.wait(ctx, host); err != nil {
+		return &robotsRules{}, false
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, robotsURL, nil)
+	if err != nil {
+		return &robotsRules{}, false
+	}
+	req.Header.Set("User-Agent", f.userAgent)
+	f.setContact(req)
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return &robotsRules{}, false
+	}
+	defer resp.Body.Close()
+	switch {
+	case resp.StatusCode >= 500:
+		return &robotsRules{disallowAll: true}, true
+	case resp.StatusCode < 200 || resp.StatusCode > 299:
+		return &robotsRules{}, true
+	}
+	rules := parseRobots(io.LimitReader(resp.Body, robotsSizeLimit))
+	return rules, ctx.Err() == nil
+}
+
+// robotsRules holds the groups of a robots.txt.
+type robotsRules struct {
+	groups      []robotsGroup
+	disallowAll bool
+}
+
+// robotsGroup is a run of user-agent lines and the rules that follow them.
+type robotsGroup struct {
+	agents []string
+	rules  []robotsRule
+}
+
+// robotsRule is one allow or disallow line.
+type robotsRule struct {
+	allow   bool
+	pattern string
+}
+
+// parseRobots reads robots.txt groups, ignoring lines it does not know.
+func parseRobots(r io.Reader) *robotsRules {
+	rules := &robotsRules{}
+	var current *robotsGroup
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		switch strings.ToLower(strings.TrimSpace(key)) {
+		case "user-agent":
+			if current == nil || len(current.rules) > 0 {
+				rules.groups = append(rules.groups, robotsGroup{})
+				current = &rules.groups[len(rules.groups)-1]
+			}
+			current.agents = append(current.agents, strings.ToLower(value))
+		case "allow", "disallow":
+			if current == nil || value == "" {
+				continue
+			}
+			current.rules = append(current.rules, robotsRule{allow: strings.EqualFold(strings.TrimSpace(key), "allow"), pattern: value})
+		}
+	}
+	return rules
+}
+
+// allows reports whether the user agent token may fetch path: the rules of
+// the groups naming the token apply, or else those of the "*" groups, and
+// the longest matching rule wins, allow winning ties.
+func (r *robotsRules) allows(token, path string) bool {
+	if r.disallowAll {
+		return false
+	}
+	token = strings.ToLower(token)
+	var named, any []robotsRule
+	for _, g := range r.groups {
+		for _, agent := range g.agents {
+			switch agent {
+			case token:
+				named = append(named, g.rules...)
+			case "*":
+				any = append(any, g.rules...)
+			}
+		}
+	}
+	rules := named
+	if len(named) == 0 {
+		rules = any
+	}
+
+	allowed, longest := true, -1
+	for _, rule := range rules {
+		if !robotsMatch(rule.pattern, path) {
+			continue
+		}
+		if n := len(rule.pattern); n > longest || (n == longest && rule.allow) {
+			allowed, longest = rule.allow, n
+		}
+	}
+	return allowed
+}
+
+// robotsMatch matches path against a robots.txt pattern, where * matches
+// any characters and a trailing $ anchors the end.
+func robotsMatch(pattern, path string) bool {
+	expr := regexp.QuoteMeta(pattern)
+	expr = strings.ReplaceAll(expr, `\*`, ".*")
+	if strings.HasSuffix(expr, `\$`) {
+		expr = strings.TrimSuffix(expr, `\$`) + "$"
+	}
+	matched, err := regexp.MatchString("^"+expr, path)
+	return err == nil && matched
+}
+
+// productToken returns the name robots.txt groups address a user agent by,
+// such as GBFS-Validator-Go for GBFS-Validator-Go/1.0.
+func productToken(userAgent string) string {
+	token, _, _ := strings.Cut(strings.TrimSpace(userAgent), "/")
+	token, _, _ = strings.Cut(token, " ")
+	return token
+}
+
+// requestPath returns the path and query robots.txt rules match against.
+func requestPath(u *url.URL) string {
+	path := u.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	if u.RawQuery != "" {
+		path += "?" + u.RawQuery
+	}
+	return path
+}
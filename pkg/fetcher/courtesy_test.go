@@ -0,0 +1,111 @@
+package fetcher
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCourtesyRobots(t *testing.T) {
+	var robotsRequests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/robots.txt" {
+			robotsRequests.Add(1)
+			w.Write([]byte("User-agent: *\nDisallow: /\n\nUser-agent: GBFS-Validator-Go\nDisallow: /private/\nAllow: /private/gbfs.json$\n"))
+			return
+		}
+		w.Write([]byte(`{}`))
+	}))
+	defer srv.Close()
+
+	c := &Courtesy{Robots: true}
+	for path, allowed := range map[string]bool{
+		"/gbfs.json":              true,
+		"/private/station.json":   false,
+		"/private/gbfs.json":      true,
+		"/private/gbfs.json?lang": false,
+	} {
+		result := New(WithCourtesy(c)).Fetch(context.Background(), srv.URL+path)
+		if got := result.Error == nil; got != allowed {
+			t.Errorf("%s: allowed %v, want %v (%v)", path, got, allowed, result.Error)
+		}
+		if !allowed && (!errors.Is(result.Error, ErrDisallowed) || result.FailureKind != FailureRobots) {
+			t.Errorf("%s: error %v, failure %q", path, result.Error, result.FailureKind)
+		}
+	}
+	if n := robotsRequests.Load(); n != 1 {
+		t.Errorf("robots.txt fetched %d times, want once", n)
+	}
+	c.robots[srv.URL].expires = time.Now()
+	New(WithCourtesy(c)).Fetch(context.Background(), srv.URL+"/gbfs.json")
+	if n := robotsRequests.Load(); n != 2 {
+		t.Errorf("expired robots.txt fetched %d times in all, want twice", n)
+	}
+
+	// A caller that gives up does not leave rules behind for the others.
+	fresh := &Courtesy{Robots: true}
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+	New(WithCourtesy(fresh)).Fetch(cancelled, srv.URL+"/private/station.json")
+	if result := New(WithCourtesy(fresh)).Fetch(context.Background(), srv.URL+"/private/station.json"); !errors.Is(result.Error, ErrDisallowed) {
+		t.Errorf("after a cancelled fetch, got %v; want robots.txt honored", result.Error)
+	}
+
+	other := New(WithCourtesy(c), WithUserAgent("bulk-scanner/2"))
+	if result := other.Fetch(context.Background(), srv.URL+"/gbfs.json"); !errors.Is(result.Error, ErrDisallowed) {
+		t.Errorf("the * group should apply to other agents, got %v", result.Error)
+	}
+	if probe := other.Probe(context.Background(), srv.URL+"/logo.png"); !errors.Is(probe.Error, ErrDisallowed) {
+		t.Errorf("probe should honor robots.txt, got %v", probe.Error)
+	}
+}
+
+func TestCourtesyIntervals(t *testing.T) {
+	intervals, err := ParseIntervals(strings.NewReader("# registry\nexample.com 40ms\n* 0s\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := &Courtesy{Intervals: intervals}
+	if key, got := c.interval("gbfs.Example.com"); key != "example.com" || got != 40*time.Millisecond {
+		t.Errorf("subdomain interval = %s, %v", key, got)
+	}
+	if key, got := c.interval("other.org"); key != "other.org" || got != 0 {
+		t.Errorf("default interval = %s, %v", key, got)
+	}
+
+	// Subdomains of one registry entry share its slots.
+	start := time.Now()
+	for _, host := range []string{"a.example.com", "b.example.com", "example.com"} {
+		if err := c.wait(context.Background(), host); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 80*time.Millisecond {
+		t.Errorf("three requests took %v, want at least two intervals", elapsed)
+	}
+
+	// A cancelled caller gives its slot back.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	before := c.next["example.com"]
+	if err := c.wait(ctx, "gbfs.example.com"); err == nil {
+		t.Error("expected the cancelled wait to fail")
+	}
+	ctx, cancel = context.WithTimeout(context.Background(), 5*time.Millisecond)
+	defer cancel()
+	if err := c.wait(ctx, "gbfs.example.com"); err == nil {
+		t.Error("expected the expired wait to fail")
+	}
+	if after := c.next["example.com"]; !after.Equal(before) {
+		t.Errorf("next slot moved from %v to %v", before, after)
+	}
+
+	if _, err := ParseIntervals(strings.NewReader("example.com soon\n")); err == nil {
+		t.Error("expected an error for an invalid interval")
+	}
+}
@@ -0,0 +1,98 @@
+package fetcher
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+)
+
+// defaultDialTimeout bounds connection setup, separately from the overall
+// request timeout.
+const defaultDialTimeout = 10 * time.Second
+
+// dialConfig is how a fetcher's transport connects to hosts, as set by
+// the transport options. It is comparable, so that fetchers with the same
+// configuration can share a transport.
+type dialConfig struct {
+	timeout time.Duration
+	// network restricts dials to tcp4 or tcp6 when set; see WithIPVersion.
+	network string
+	// resolver is the address of the DNS server to use, if not the
+	// system's.
+	resolver string
+	// http1 disables HTTP/2; see WithHTTP1.
+	http1 bool
+}
+
+// dialContext returns a dial function for the configuration. Go dials IPv6
+// and IPv4 addresses in parallel (happy eyeballs), so a host with a broken
+// AAAA record still connects over IPv4 after the fallback delay.
+func (c dialConfig) dialContext() func(ctx context.Context, network, addr string) (net.Conn, error) {
+	d := &net.Dialer{
+		Timeout:   c.timeout,
+		KeepAlive: 30 * time.Second,
+	}
+	if c.resolver != "" {
+		d.Resolver = &net.Resolver{
+			PreferGo: true,
+			Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+				rd := net.Dialer{Timeout: c.timeout}
+				return rd.DialContext(ctx, network, c.resolver)
+			},
+		}
+	}
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		if c.network != "" && strings.HasPrefix(network, "tcp") {
+			network = c.network
+		}
+		return d.DialContext(ctx, network, addr)
+	}
+}
+
+// WithDialTimeout bounds how long connecting to a host may take.
+func WithDialTimeout(timeout time.Duration) Option {
+	return func(f *Fetcher) {
+		f.dial.timeout = timeout
+	}
+}
+
+// WithIPVersion restricts connections to IPv4 (4) or IPv6 (6). Zero allows
+// both.
+func WithIPVersion(version int) Option {
+	return func(f *Fetcher) {
+		switch version {
+		case 4:
+			f.dial.network = "tcp4"
+		case 6:
+			f.dial.network = "tcp6"
+		default:
+			f.dial.network = ""
+		}
+	}
+}
+
+// WithResolver resolves host names through the DNS server at addr, as
+// host or host:port, instead of the system resolver.
+func WithResolver(addr string) Option {
+	if _, _, err := net.SplitHostPort(addr); err != nil {
+		addr = net.JoinHostPort(addr, "53")
+	}
+	return func(f *Fetcher) {
+		f.dial.resolver = addr
+	}
+}
+
+// ParseIPVersion parses an IP version flag value: "", "4", or "6".
+func ParseIPVersion(s string) (int, error) {
+	switch s {
+	case "", "any":
+		return 0, nil
+	case "4", "ipv4":
+		return 4, nil
+	case "6", "ipv6":
+		return 6, nil
+	}
+	return 0, fmt.Errorf("invalid IP version %q: use 4 or 6", s)
+}
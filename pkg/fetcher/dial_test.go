@@ -0,0 +1,46 @@
+package fetcher
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestDialOptions(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	if result := New(WithIPVersion(4)).Fetch(context.Background(), server.URL); result.Error != nil {
+		t.Errorf("IPv4 fetch of an IPv4 server failed: %v", result.Error)
+	}
+	if result := New(WithIPVersion(6)).Fetch(context.Background(), server.URL); result.Error == nil {
+		t.Error("expected an IPv6-only fetch of an IPv4 address to fail")
+	}
+
+	listener, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	f := New(WithResolver(listener.LocalAddr().String()), WithDialTimeout(100*time.Millisecond))
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if result := f.Fetch(ctx, "http://feed.example/gbfs.json"); result.FailureKind != FailureDNS {
+		t.Errorf("expected a DNS failure from an unresponsive resolver, got %q: %v", result.FailureKind, result.Error)
+	}
+
+	for _, tc := range []struct {
+		in   string
+		want int
+	}{{"", 0}, {"4", 4}, {"ipv6", 6}} {
+		if got, err := ParseIPVersion(tc.in); err != nil || got != tc.want {
+			t.Errorf("ParseIPVersion(%q) = %d, %v", tc.in, got, err)
+		}
+	}
+	if _, err := ParseIPVersion("5"); err == nil {
+		t.Error("expected an error for IP version 5")
+	}
+}
@@ -0,0 +1,78 @@
+package fetcher
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"net"
+	"net/http"
+	"syscall"
+)
+
+// FailureKind classifies why a fetch failed, so that network problems can
+// be told apart from feed bugs.
+type FailureKind string
+
+const (
+	// FailureDNS means the host name could not be resolved.
+	FailureDNS FailureKind = "dns"
+	// FailureRefused means the host actively refused the connection.
+	FailureRefused FailureKind = "connection_refused"
+	// FailureTLS means the TLS handshake or certificate check failed.
+	FailureTLS FailureKind = "tls"
+	// FailureTimeout means the request did not complete in time.
+	FailureTimeout FailureKind = "timeout"
+	// FailureNotFound means the server answered 404.
+	FailureNotFound FailureKind = "not_found"
+	// FailureHTTPStatus means the server answered with another non-200 status.
+	FailureHTTPStatus FailureKind = "http_status"
+	// FailureAuth means credentials could not be applied, e.g. an OAuth
+	// token request failed.
+	FailureAuth FailureKind = "auth"
+	// FailureRequest means the URL could not be turned into a request.
+	FailureRequest FailureKind = "request"
+	// FailureRobots means robots.txt disallows the URL; see Courtesy.
+	FailureRobots FailureKind = "robots"
+	// FailureNetwork covers other connection errors, such as resets.
+	FailureNetwork FailureKind = "network"
+)
+
+// statusFailure classifies a non-200 response status.
+func statusFailure(status int) FailureKind {
+	if status == http.StatusNotFound {
+		return FailureNotFound
+	}
+	return FailureHTTPStatus
+}
+
+// ClassifyError returns the kind of a transport error.
+func ClassifyError(err error) FailureKind {
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return FailureDNS
+	}
+	if errors.Is(err, syscall.ECONNREFUSED) {
+		return FailureRefused
+	}
+	var (
+		certErr      *tls.CertificateVerificationError
+		alertErr     tls.AlertError
+		recordErr    tls.RecordHeaderError
+		authorityErr x509.UnknownAuthorityError
+		hostnameErr  x509.HostnameError
+		invalidErr   x509.CertificateInvalidError
+	)
+	if errors.As(err, &certErr) || errors.As(err, &alertErr) || errors.As(err, &recordErr) ||
+		errors.As(err, &authorityErr) || errors.As(err, &hostnameErr) || errors.As(err, &invalidErr) {
+		return FailureTLS
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return FailureTimeout
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return FailureTimeout
+	}
+	return FailureNetwork
+}
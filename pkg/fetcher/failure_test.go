@@ -0,0 +1,58 @@
+package fetcher
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestFailureKind(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/missing":
+			http.NotFound(w, r)
+		case "/broken":
+			w.WriteHeader(http.StatusBadGateway)
+		case "/slow":
+			time.Sleep(200 * time.Millisecond)
+		}
+	}))
+	defer server.Close()
+	tlsServer := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer tlsServer.Close()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	refused := "http://" + listener.Addr().String() + "/"
+	listener.Close()
+
+	// Only the slow case runs against a short timeout, so that a TLS
+	// handshake under the race detector is not mistaken for one.
+	for _, tc := range []struct {
+		url     string
+		timeout time.Duration
+		want    FailureKind
+	}{
+		{server.URL + "/ok", 5 * time.Second, ""},
+		{server.URL + "/missing", 5 * time.Second, FailureNotFound},
+		{server.URL + "/broken", 5 * time.Second, FailureHTTPStatus},
+		{server.URL + "/slow", 50 * time.Millisecond, FailureTimeout},
+		{tlsServer.URL, 5 * time.Second, FailureTLS},
+		{refused, 5 * time.Second, FailureRefused},
+		{"http://%zz", 5 * time.Second, FailureRequest},
+	} {
+		f := New(WithTimeout(tc.timeout))
+		if got := f.Fetch(context.Background(), tc.url).FailureKind; got != tc.want {
+			t.Errorf("%s: got failure kind %q, want %q", tc.url, got, tc.want)
+		}
+	}
+
+	if got := ClassifyError(&net.DNSError{Err: "no such host", Name: "feed.invalid", IsNotFound: true}); got != FailureDNS {
+		t.Errorf("got %q for a DNS error", got)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"context"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -60,8 +61,17 @@ type HeaderConfig struct {
 // Fetcher wraps an HTTP client with auth support.
 type Fetcher struct {
 	client    *http.Client
+	transport *http.Transport
+	dial      dialConfig
+	counters  connCounters
+	recorder  *Recorder
+	courtesy  *Courtesy
 	auth      *AuthConfig
 	userAgent string
+	// contactHeader and contact identify who is polling; see WithContact.
+	contactHeader string
+	contact       string
+	headers   map[string]string
 	token     string // Cached OAuth token
 }
 
@@ -89,12 +99,55 @@ func WithUserAgent(ua string) Option {
 	}
 }
 
-// New constructs a Fetcher with options applied.
+// WithHeaders adds headers to every request. Authentication configured with
+// WithAuth takes precedence over a header of the same name.
+func WithHeaders(headers map[string]string) Option {
+	return func(f *Fetcher) {
+		f.headers = headers
+	}
+}
+
+// WithContact sends value, such as an email address or URL feed providers
+// can reach the operator at, in header with every request, including probes
+// and token requests. An empty header selects From. Headers set with
+// WithHeaders do not replace it.
+func WithContact(header, value string) Option {
+	return func(f *Fetcher) {
+		if header == "" {
+			header = "From"
+		}
+		f.contactHeader = header
+		f.contact = value
+	}
+}
+
+// WithHTTPClient sends requests through c, such as a client with
+// instrumentation, a cache, or a test transport, instead of the default
+// client. The fetcher uses a copy of c, so WithTimeout given after it does
+// not change c, and keeps the default 30 second timeout if c has none;
+// transport options such as WithHTTP1, WithDialTimeout,
+// WithIPVersion, and WithResolver only configure the default transport and
+// have no effect on c.
+func WithHTTPClient(c *http.Client) Option {
+	return func(f *Fetcher) {
+		if c == nil {
+			return
+		}
+		copied := *c
+		if copied.Timeout == 0 {
+			copied.Timeout = f.client.Timeout
+		}
+		f.client = &copied
+	}
+}
+
+// New constructs a Fetcher with options applied. Fetchers with the same
+// transport options share one transport, and so its idle connections.
 func New(opts ...Option) *Fetcher {
+	client := &http.Client{Timeout: 30 * time.Second}
 	f := &Fetcher{
-		client: &http.Client{
-			Timeout: 30 * time.Second,
-		},
+		client:    client,
+		dial:      dialConfig{timeout: defaultDialTimeout},
 		userAgent: "GBFS-Validator-Go/1.0",
 	}
 
@@ -102,6 +155,10 @@ func New(opts ...Option) *Fetcher {
 		opt(f)
 	}
 
+	if f.client == client {
+		f.transport = sharedTransport(f.dial)
+		f.client.Transport = f.transport
+	}
 	return f
 }
 
@@ -112,48 +169,104 @@ type FetchResult struct {
 	StatusCode int
 	Error      error
 	Exists     bool
+	// FailureKind classifies a failed fetch, including a 404; it is empty
+	// on success.
+	FailureKind FailureKind
+	// Headers are the request headers sent, with secret values redacted.
+	Headers map[string]string
+	// FinalURL is the URL that answered after redirects, redacted.
+	FinalURL string
+
+	responseHeader http.Header
+	proto          string
 }
 
-// Fetch retrieves a URL and returns the raw response body.
+// Fetch retrieves a URL and returns the raw response body. The URL and
+// error in the result have credentials redacted.
 func (f *Fetcher) Fetch(ctx context.Context, targetURL string) *FetchResult {
+	start := time.Now()
+	result := f.fetch(ctx, targetURL)
+	if f.recorder != nil {
+		f.recorder.record(f, start, result)
+	}
+	result.URL = RedactURL(result.URL)
+	result.FinalURL = RedactURL(result.FinalURL)
+	result.Error = f.redactError(result.Error)
+	return result
+}
+
+// setContact sets the header configured with WithContact.
+func (f *Fetcher) setContact(req *http.Request) {
+	if f.contact != "" {
+		req.Header.Set(f.contactHeader, f.contact)
+	}
+}
+
+// fetch performs the request for Fetch.
+func (f *Fetcher) fetch(ctx context.Context, targetURL string) *FetchResult {
 	result := &FetchResult{URL: targetURL}
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, targetURL, nil)
+	req, err := http.NewRequestWithContext(f.traced(ctx), http.MethodGet, targetURL, nil)
 	if err != nil {
 		result.Error = fmt.Errorf("failed to create request: %w", err)
+		result.FailureKind = FailureRequest
+		return result
+	}
+	if err := f.admit(ctx, req.URL); err != nil {
+		result.Error = err
+		result.FailureKind = FailureRobots
+		if !errors.Is(err, ErrDisallowed) {
+			result.FailureKind = ClassifyError(err)
+		}
 		return result
 	}
 
 	req.Header.Set("User-Agent", f.userAgent)
 	req.Header.Set("Accept", "application/json")
+	for key, value := range f.headers {
+		if key != "" {
+			req.Header.Set(key, value)
+		}
+	}
+	f.setContact(req)
 
 	if err := f.applyAuth(ctx, req); err != nil {
 		result.Error = fmt.Errorf("failed to apply authentication: %w", err)
+		result.FailureKind = FailureAuth
 		return result
 	}
+	result.Headers = f.redactHeaders(req.Header)
 
 	resp, err := f.client.Do(req)
 	if err != nil {
 		result.Error = fmt.Errorf("failed to fetch URL: %w", err)
+		result.FailureKind = ClassifyError(err)
 		return result
 	}
 	defer resp.Body.Close()
+	f.countProtocol(resp)
 
 	result.StatusCode = resp.StatusCode
+	result.responseHeader = resp.Header
+	result.proto = resp.Proto
+	result.FinalURL = resp.Request.URL.String()
 
 	if resp.StatusCode == http.StatusNotFound {
 		result.Exists = false
+		result.FailureKind = FailureNotFound
 		return result
 	}
 
 	if resp.StatusCode != http.StatusOK {
 		result.Error = fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+		result.FailureKind = statusFailure(resp.StatusCode)
 		return result
 	}
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
 		result.Error = fmt.Errorf("failed to read response body: %w", err)
+		result.FailureKind = ClassifyError(err)
 		return result
 	}
 
@@ -233,7 +346,9 @@ func (f *Fetcher) getOAuthToken(ctx context.Context) (string, error) {
 	}
 
 	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("User-Agent", f.userAgent)
 	req.SetBasicAuth(cfg.User, cfg.Password)
+	f.setContact(req)
 
 	resp, err := f.client.Do(req)
 	if err != nil {
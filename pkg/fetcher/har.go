@@ -0,0 +1,190 @@
+package fetcher
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Recorder captures a Fetcher's requests and responses for export as a
+// HAR 1.2 archive. Credentials are redacted as they are recorded.
+type Recorder struct {
+	mu      sync.Mutex
+	entries []harEntry
+}
+
+// NewRecorder returns an empty Recorder.
+func NewRecorder() *Recorder {
+	return &Recorder{}
+}
+
+// WithRecorder records every fetch into r.
+func WithRecorder(r *Recorder) Option {
+	return func(f *Fetcher) {
+		f.recorder = r
+	}
+}
+
+type harLog struct {
+	Log struct {
+		Version string     `json:"version"`
+		Creator harCreator `json:"creator"`
+		Entries []harEntry `json:"entries"`
+	} `json:"log"`
+}
+
+type harCreator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type harEntry struct {
+	StartedDateTime time.Time   `json:"startedDateTime"`
+	Time            float64     `json:"time"`
+	Request         harRequest  `json:"request"`
+	Response        harResponse `json:"response"`
+	Cache           struct{}    `json:"cache"`
+	Timings         harTimings  `json:"timings"`
+	Comment         string      `json:"comment,omitempty"`
+}
+
+type harRequest struct {
+	Method      string      `json:"method"`
+	URL         string      `json:"url"`
+	HTTPVersion string      `json:"httpVersion"`
+	Headers     []harHeader `json:"headers"`
+	QueryString []harHeader `json:"queryString"`
+	Cookies     []harHeader `json:"cookies"`
+	HeadersSize int         `json:"headersSize"`
+	BodySize    int         `json:"bodySize"`
+}
+
+type harResponse struct {
+	Status      int         `json:"status"`
+	StatusText  string      `json:"statusText"`
+	HTTPVersion string      `json:"httpVersion"`
+	Headers     []harHeader `json:"headers"`
+	Cookies     []harHeader `json:"cookies"`
+	Content     harContent  `json:"content"`
+	RedirectURL string      `json:"redirectURL"`
+	HeadersSize int         `json:"headersSize"`
+	BodySize    int         `json:"bodySize"`
+}
+
+type harContent struct {
+	Size     int    `json:"size"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text,omitempty"`
+}
+
+type harHeader struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type harTimings struct {
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+}
+
+// harHeaders converts redacted headers to sorted HAR name/value pairs.
+func harHeaders(headers map[string]string) []harHeader {
+	out := make([]harHeader, 0, len(headers))
+	for name, value := range headers {
+		out = append(out, harHeader{Name: name, Value: value})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// record adds a finished fetch. The request URL, headers, and response
+// body are redacted with the fetcher's credentials.
+func (r *Recorder) record(f *Fetcher, start time.Time, result *FetchResult) {
+	elapsed := float64(time.Since(start).Microseconds()) / 1000
+	entry := harEntry{
+		StartedDateTime: start,
+		Time:            elapsed,
+		Request: harRequest{
+			Method:      http.MethodGet,
+			URL:         f.Redact(RedactURL(result.URL)),
+			HTTPVersion: "HTTP/1.1",
+			Headers:     harHeaders(result.Headers),
+			QueryString: []harHeader{},
+			Cookies:     []harHeader{},
+			HeadersSize: -1,
+			BodySize:    0,
+		},
+		Response: harResponse{
+			Status:      result.StatusCode,
+			StatusText:  http.StatusText(result.StatusCode),
+			HTTPVersion: result.proto,
+			Headers:     []harHeader{},
+			Cookies:     []harHeader{},
+			Content:     harContent{Size: len(result.Body), MimeType: "x-unknown", Text: f.Redact(string(result.Body))},
+			HeadersSize: -1,
+			BodySize:    len(result.Body),
+		},
+		Timings: harTimings{Wait: elapsed},
+	}
+	if u, err := url.Parse(entry.Request.URL); err == nil {
+		for name, values := range u.Query() {
+			for _, value := range values {
+				entry.Request.QueryString = append(entry.Request.QueryString, harHeader{Name: name, Value: value})
+			}
+		}
+		sort.Slice(entry.Request.QueryString, func(i, j int) bool {
+			return entry.Request.QueryString[i].Name < entry.Request.QueryString[j].Name
+		})
+	}
+	if result.proto != "" {
+		entry.Request.HTTPVersion = result.proto
+	}
+	if result.responseHeader != nil {
+		headers := f.redactHeaders(result.responseHeader)
+		if _, ok := headers["Set-Cookie"]; ok {
+			headers["Set-Cookie"] = Redacted
+		}
+		entry.Response.Headers = harHeaders(headers)
+		if ct := result.responseHeader.Get("Content-Type"); ct != "" {
+			entry.Response.Content.MimeType = ct
+		}
+	}
+	if result.Error != nil {
+		entry.Comment = f.Redact(result.Error.Error())
+	}
+
+	r.mu.Lock()
+	r.entries = append(r.entries, entry)
+	r.mu.Unlock()
+}
+
+// Len returns the number of recorded requests.
+func (r *Recorder) Len() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.entries)
+}
+
+// WriteHAR writes the recorded requests as a HAR 1.2 archive, in the order
+// they started.
+func (r *Recorder) WriteHAR(w io.Writer) error {
+	r.mu.Lock()
+	entries := append([]harEntry(nil), r.entries...)
+	r.mu.Unlock()
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].StartedDateTime.Before(entries[j].StartedDateTime)
+	})
+
+	var doc harLog
+	doc.Log.Version = "1.2"
+	doc.Log.Creator = harCreator{Name: "GBFS-Validator-Go", Version: "1.0"}
+	doc.Log.Entries = entries
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(doc)
+}
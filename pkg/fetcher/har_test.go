@@ -0,0 +1,54 @@
+package fetcher
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRecorder(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Set-Cookie", "session=abc123")
+		w.Write([]byte(`{"echo":"` + r.Header.Get("Authorization") + `"}`))
+	}))
+	defer server.Close()
+
+	recorder := NewRecorder()
+	f := New(WithRecorder(recorder), WithAuth(&AuthConfig{
+		Type:        AuthBearerToken,
+		BearerToken: &BearerTokenConfig{Token: "s3cret-token"},
+	}))
+	f.Fetch(context.Background(), server.URL+"/gbfs.json?key=abc")
+	f.Fetch(context.Background(), server.URL+"/missing")
+
+	var buf bytes.Buffer
+	if err := recorder.WriteHAR(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(buf.String(), "s3cret-token") || strings.Contains(buf.String(), "abc123") {
+		t.Fatalf("HAR contains credentials:\n%s", buf.String())
+	}
+	var doc harLog
+	if err := json.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatal(err)
+	}
+	if doc.Log.Version != "1.2" || len(doc.Log.Entries) != 2 {
+		t.Fatalf("unexpected HAR log: %+v", doc.Log)
+	}
+	first, second := doc.Log.Entries[0], doc.Log.Entries[1]
+	if first.Response.Status != 200 || first.Response.Content.MimeType != "application/json" || first.Response.Content.Text == "" {
+		t.Errorf("unexpected first entry: %+v", first.Response)
+	}
+	if second.Response.Status != 404 {
+		t.Errorf("expected the 404 to be recorded, got %+v", second.Response)
+	}
+}
@@ -0,0 +1,73 @@
+package fetcher
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+)
+
+// ProbeResult describes a resource checked without downloading it.
+type ProbeResult struct {
+	URL         string
+	StatusCode  int
+	ContentType string
+	// ContentLength is -1 when the server does not report a size.
+	ContentLength int64
+	Error         error
+}
+
+// Probe checks that a URL is reachable with a HEAD request, falling back to
+// GET for servers that reject HEAD. The body is never read. Feed
+// credentials and headers are not sent, since probed URLs such as images
+// are often hosted by third parties.
+func (f *Fetcher) Probe(ctx context.Context, targetURL string) *ProbeResult {
+	result := &ProbeResult{URL: RedactURL(targetURL), ContentLength: -1}
+	resp, err := f.probe(ctx, http.MethodHead, targetURL)
+	if err == nil && (resp.StatusCode == http.StatusMethodNotAllowed || resp.StatusCode == http.StatusNotImplemented) {
+		resp.Body.Close()
+		resp, err = f.probe(ctx, http.MethodGet, targetURL)
+	}
+	if err != nil {
+		result.Error = f.redactError(err)
+		return result
+	}
+	resp.Body.Close()
+
+	result.StatusCode = resp.StatusCode
+	result.ContentType = resp.Header.Get("Content-Type")
+	result.ContentLength = resp.ContentLength
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		result.Error = fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+	return result
+}
+
+// probe sends one request for Probe.
+func (f *Fetcher) probe(ctx context.Context, method, targetURL string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, targetURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	if err := f.admit(ctx, req.URL); err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", f.userAgent)
+	f.setContact(req)
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch URL: %w", err)
+	}
+	return resp, nil
+}
+
+// Send sends a request the validator makes on its own behalf, such as a
+// webhook, through the fetcher's client with its user agent and contact
+// header. Feed credentials and headers are not sent, and robots.txt and
+// host intervals do not apply.
+func (f *Fetcher) Send(req *http.Request) (*http.Response, error) {
+	if req.Header.Get("User-Agent") == "" {
+		req.Header.Set("User-Agent", f.userAgent)
+	}
+	f.setContact(req)
+	return f.client.Do(req)
+}
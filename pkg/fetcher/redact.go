@@ -0,0 +1,182 @@
+package fetcher
+
+import (
+	"encoding/base64"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// Redacted replaces secret values in redacted output.
+const Redacted = "REDACTED"
+
+// sensitiveParams are query parameter name fragments whose values are
+// treated as credentials.
+var sensitiveParams = []string{"token", "key", "secret", "password", "passwd", "signature", "sig", "auth", "credential", "session"}
+
+// urlPattern finds URLs embedded in free text such as error messages.
+var urlPattern = regexp.MustCompile(`[a-zA-Z][a-zA-Z0-9+.-]*://[^\s"'<>]+`)
+
+// isSensitiveParam reports whether a query parameter likely holds a secret.
+func isSensitiveParam(name string) bool {
+	name = strings.ToLower(name)
+	for _, s := range sensitiveParams {
+		if strings.Contains(name, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// RedactURL hides the password in userinfo and the values of query
+// parameters that look like credentials. Unparseable input is returned
+// unchanged.
+func RedactURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil || (u.User == nil && u.RawQuery == "") {
+		return raw
+	}
+
+	if u.User != nil {
+		if _, ok := u.User.Password(); ok {
+			u.User = url.UserPassword(u.User.Username(), Redacted)
+		}
+	}
+
+	if u.RawQuery != "" {
+		query := u.Query()
+		changed := false
+		for name, values := range query {
+			if !isSensitiveParam(name) {
+				continue
+			}
+			for i := range values {
+				values[i] = Redacted
+			}
+			changed = true
+		}
+		if changed {
+			u.RawQuery = query.Encode()
+		}
+	}
+	return u.String()
+}
+
+// RedactText redacts every URL in s, such as those embedded in error
+// messages from net/http.
+func RedactText(s string) string {
+	return urlPattern.ReplaceAllStringFunc(s, RedactURL)
+}
+
+// Redacted returns a copy of the config with every secret replaced, safe to
+// log or serialize.
+func (a *AuthConfig) Redacted() *AuthConfig {
+	if a == nil {
+		return nil
+	}
+	out := &AuthConfig{Type: a.Type}
+	if a.BasicAuth != nil {
+		out.BasicAuth = &BasicAuthConfig{User: a.BasicAuth.User, Password: Redacted}
+	}
+	if a.BearerToken != nil {
+		out.BearerToken = &BearerTokenConfig{Token: Redacted}
+	}
+	if a.OAuthClientCredentials != nil {
+		out.OAuthClientCredentials = &OAuthConfig{
+			User:     a.OAuthClientCredentials.User,
+			Password: Redacted,
+			TokenURL: RedactURL(a.OAuthClientCredentials.TokenURL),
+		}
+	}
+	for _, h := range a.Headers {
+		out.Headers = append(out.Headers, HeaderConfig{Key: h.Key, Value: Redacted})
+	}
+	return out
+}
+
+// secrets returns the credential values the fetcher may send, longest first
+// so overlapping values are replaced whole.
+func (f *Fetcher) secrets() []string {
+	var secrets []string
+	add := func(s string) {
+		if len(s) >= 4 {
+			secrets = append(secrets, s)
+		}
+	}
+
+	if a := f.auth; a != nil {
+		if a.BasicAuth != nil {
+			add(a.BasicAuth.Password)
+			add(base64.StdEncoding.EncodeToString([]byte(a.BasicAuth.User + ":" + a.BasicAuth.Password)))
+		}
+		if a.BearerToken != nil {
+			add(a.BearerToken.Token)
+		}
+		if a.OAuthClientCredentials != nil {
+			add(a.OAuthClientCredentials.Password)
+		}
+		for _, h := range a.Headers {
+			add(h.Value)
+		}
+	}
+	add(f.token)
+
+	for i := 1; i < len(secrets); i++ {
+		for j := i; j > 0 && len(secrets[j]) > len(secrets[j-1]); j-- {
+			secrets[j], secrets[j-1] = secrets[j-1], secrets[j]
+		}
+	}
+	return secrets
+}
+
+// Redact removes configured credentials and sensitive URL parts from s.
+func (f *Fetcher) Redact(s string) string {
+	s = RedactText(s)
+	for _, secret := range f.secrets() {
+		s = strings.ReplaceAll(s, secret, Redacted)
+	}
+	return s
+}
+
+// sensitiveHeaders always have their values redacted.
+var sensitiveHeaders = map[string]bool{
+	"Authorization":       true,
+	"Proxy-Authorization": true,
+	"Cookie":              true,
+}
+
+// redactHeaders returns the request headers with credential values replaced.
+func (f *Fetcher) redactHeaders(h http.Header) map[string]string {
+	out := make(map[string]string, len(h))
+	for key := range h {
+		value := h.Get(key)
+		if sensitiveHeaders[key] || isSensitiveParam(key) {
+			value = Redacted
+		}
+		out[key] = f.Redact(value)
+	}
+	return out
+}
+
+// redactedError keeps the wrapped error for errors.Is/As while printing a
+// redacted message.
+type redactedError struct {
+	msg string
+	err error
+}
+
+func (e *redactedError) Error() string { return e.msg }
+func (e *redactedError) Unwrap() error { return e.err }
+
+// redactError returns err with credentials removed from its message.
+func (f *Fetcher) redactError(err error) error {
+	if err == nil {
+		return nil
+	}
+	msg := f.Redact(err.Error())
+	if msg == err.Error() {
+		return err
+	}
+	return &redactedError{msg: msg, err: err}
+}
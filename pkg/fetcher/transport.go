@@ -0,0 +1,100 @@
+package fetcher
+
+import (
+	"context"
+	"crypto/tls"
+	"net/http"
+	"net/http/httptrace"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// maxIdleConnsPerHost keeps enough idle connections for the parallel file
+// requests of a run, which usually all go to one host.
+const maxIdleConnsPerHost = 16
+
+// newTransport returns a transport tuned for many requests to few hosts.
+func newTransport() *http.Transport {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	t.MaxIdleConns = 100
+	t.MaxIdleConnsPerHost = maxIdleConnsPerHost
+	t.IdleConnTimeout = 90 * time.Second
+	t.ForceAttemptHTTP2 = true
+	return t
+}
+
+// transports holds a transport per dial configuration, shared by the
+// fetchers using it, so that fetchers created for each validation reuse
+// connections rather than each leaving its own idle ones open.
+var transports = struct {
+	sync.Mutex
+	m map[dialConfig]*http.Transport
+}{m: make(map[dialConfig]*http.Transport)}
+
+// sharedTransport returns the transport of a dial configuration, creating
+// it on first use.
+func sharedTransport(c dialConfig) *http.Transport {
+	transports.Lock()
+	defer transports.Unlock()
+	if t, ok := transports.m[c]; ok {
+		return t
+	}
+	t := newTransport()
+	t.DialContext = c.dialContext()
+	if c.http1 {
+		t.ForceAttemptHTTP2 = false
+		t.TLSNextProto = map[string]func(string, *tls.Conn) http.RoundTripper{}
+	}
+	transports.m[c] = t
+	return t
+}
+
+// WithHTTP1 disables HTTP/2, for servers with broken HTTP/2 support.
+func WithHTTP1() Option {
+	return func(f *Fetcher) {
+		f.dial.http1 = true
+	}
+}
+
+// ConnStats counts connection use by a Fetcher's requests.
+type ConnStats struct {
+	Requests int64 `json:"requests"`
+	// Reused counts requests sent on an already open connection.
+	Reused int64 `json:"reused"`
+	// HTTP2 counts responses received over HTTP/2.
+	HTTP2 int64 `json:"http2"`
+}
+
+// connCounters accumulates ConnStats across goroutines.
+type connCounters struct {
+	requests, reused, http2 atomic.Int64
+}
+
+// Stats reports connection use since the Fetcher was created.
+func (f *Fetcher) Stats() ConnStats {
+	return ConnStats{
+		Requests: f.counters.requests.Load(),
+		Reused:   f.counters.reused.Load(),
+		HTTP2:    f.counters.http2.Load(),
+	}
+}
+
+// traced returns ctx with a trace that counts connection reuse.
+func (f *Fetcher) traced(ctx context.Context) context.Context {
+	return httptrace.WithClientTrace(ctx, &httptrace.ClientTrace{
+		GotConn: func(info httptrace.GotConnInfo) {
+			f.counters.requests.Add(1)
+			if info.Reused {
+				f.counters.reused.Add(1)
+			}
+		},
+	})
+}
+
+// countProtocol records the protocol of a response.
+func (f *Fetcher) countProtocol(resp *http.Response) {
+	if resp.ProtoMajor == 2 {
+		f.counters.http2.Add(1)
+	}
+}
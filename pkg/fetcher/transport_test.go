@@ -0,0 +1,95 @@
+package fetcher
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestConnStats(t *testing.T) {
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{}`))
+	}))
+	server.EnableHTTP2 = true
+	server.StartTLS()
+	defer server.Close()
+	trusted := server.Client().Transport.(*http.Transport).TLSClientConfig
+
+	for _, tc := range []struct {
+		name  string
+		opts  []Option
+		http2 int64
+	}{
+		{"default", nil, 3},
+		{"http1", []Option{WithHTTP1()}, 0},
+	} {
+		f := New(tc.opts...)
+		// Trust the test server on a copy, leaving the shared transport be.
+		f.transport = f.transport.Clone()
+		f.transport.TLSClientConfig = trusted.Clone()
+		f.client.Transport = f.transport
+		for i := 0; i < 3; i++ {
+			if result := f.Fetch(context.Background(), server.URL); result.Error != nil {
+				t.Fatalf("%s: %v", tc.name, result.Error)
+			}
+		}
+		stats := f.Stats()
+		if stats.Requests != 3 || stats.Reused != 2 || stats.HTTP2 != tc.http2 {
+			t.Errorf("%s: unexpected stats %+v", tc.name, stats)
+		}
+	}
+}
+
+func TestSharedTransport(t *testing.T) {
+	if New().transport != New(WithTimeout(time.Second)).transport {
+		t.Error("fetchers with the default transport options do not share a transport")
+	}
+	if New(WithHTTP1()).transport != New(WithHTTP1()).transport {
+		t.Error("HTTP/1 fetchers do not share a transport")
+	}
+	if New().transport == New(WithHTTP1()).transport || New().transport == New(WithIPVersion(4)).transport {
+		t.Error("fetchers with different transport options share a transport")
+	}
+}
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (fn roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) { return fn(r) }
+
+func TestWithHTTPClient(t *testing.T) {
+	var seen []string
+	client := &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		seen = append(seen, r.URL.String())
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{"Content-Type": {"application/json"}},
+			Body:       io.NopCloser(strings.NewReader(`{"ok":true}`)),
+			Request:    r,
+		}, nil
+	})}
+
+	f := New(WithHTTPClient(client), WithTimeout(time.Second))
+	result := f.Fetch(context.Background(), "https://feed.example/gbfs.json")
+	if result.Error != nil || string(result.Body) != `{"ok":true}` {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+	if len(seen) != 1 || seen[0] != "https://feed.example/gbfs.json" {
+		t.Errorf("custom transport saw %v", seen)
+	}
+	if client.Timeout != 0 {
+		t.Errorf("WithTimeout changed the caller's client timeout to %v", client.Timeout)
+	}
+	if f.client.Timeout != time.Second {
+		t.Errorf("timeout = %v, want WithTimeout's 1s", f.client.Timeout)
+	}
+	if f := New(WithHTTPClient(client)); f.client.Timeout != 30*time.Second {
+		t.Errorf("client without a timeout: timeout = %v, want the default 30s", f.client.Timeout)
+	}
+	if f := New(WithHTTPClient(&http.Client{Timeout: time.Minute})); f.client.Timeout != time.Minute {
+		t.Errorf("client timeout = %v, want its own 1m", f.client.Timeout)
+	}
+}
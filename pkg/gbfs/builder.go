@@ -0,0 +1,288 @@
+package gbfs
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/gbfs-validator-go/pkg/version"
+)
+
+// Builder constructs feed documents for one GBFS version. Each constructor
+// fills in the common header and rewrites version-specific fields: timestamps
+// become POSIX integers before v3 and RFC3339 strings from v3, localized text
+// becomes a plain string or a language-tagged array, and fields renamed in v3
+// (bikes/vehicles, num_bikes_*/num_vehicles_*) move to the version's name.
+type Builder struct {
+	version  Version
+	language string
+	ttl      int
+	now      time.Time
+}
+
+// NewBuilder returns a Builder for ver whose text defaults to lang.
+func NewBuilder(ver Version, lang string) *Builder {
+	return &Builder{version: ver, language: lang, now: time.Now()}
+}
+
+// WithTTL sets the ttl written to every header.
+func (b *Builder) WithTTL(ttl int) *Builder {
+	b.ttl = ttl
+	return b
+}
+
+// WithTime sets last_updated for every header.
+func (b *Builder) WithTime(now time.Time) *Builder {
+	b.now = now
+	return b
+}
+
+// Version returns the version being built.
+func (b *Builder) Version() Version {
+	return b.version
+}
+
+// v3 reports whether the builder targets GBFS 3.x.
+func (b *Builder) v3() bool {
+	return strings.HasPrefix(string(b.version), "3.")
+}
+
+// Header returns the common header. Version is left empty for 1.0, which has
+// no version field.
+func (b *Builder) Header() CommonHeader {
+	h := CommonHeader{LastUpdated: b.Timestamp(b.now), TTL: b.ttl}
+	if b.version != V1_0 {
+		h.Version = string(b.version)
+	}
+	return h
+}
+
+// Timestamp encodes t in the version's timestamp format.
+func (b *Builder) Timestamp(t time.Time) Timestamp {
+	if b.v3() {
+		t = t.UTC()
+	}
+	return Timestamp{Time: t, IsUnix: !b.v3()}
+}
+
+// Text returns s as localized text in the builder's language.
+func (b *Builder) Text(s string) LocalizedText {
+	return b.localize(Text(s))
+}
+
+// localize converts text to the version's form. Before v3 only one language
+// can be carried, so the builder's language is picked.
+func (b *Builder) localize(t LocalizedText) LocalizedText {
+	if len(t) == 0 {
+		return t
+	}
+	if !b.v3() {
+		return Text(t.Get(b.language))
+	}
+	out := make(LocalizedText, len(t))
+	for i, v := range t {
+		if v.Language == "" {
+			v.Language = b.language
+		}
+		out[i] = v
+	}
+	return out
+}
+
+// timestamp re-encodes t unless it is unset.
+func (b *Builder) timestamp(t Timestamp) Timestamp {
+	if t.Time.IsZero() {
+		return t
+	}
+	return b.Timestamp(t.Time)
+}
+
+// GBFS builds gbfs.json listing feeds: a language-keyed block before v3 and
+// a flat list from v3.
+func (b *Builder) GBFS(feeds []FeedInfo) *GBFSFeed {
+	doc := &GBFSFeed{CommonHeader: b.Header()}
+	if b.v3() {
+		doc.Data.Feeds = feeds
+		doc.Data.Format = FormatFeedList
+	} else {
+		doc.Data.Format = FormatLanguageMap
+		doc.Data.Languages = map[string]LanguageFeeds{b.language: {Feeds: feeds}}
+		doc.Data.Feeds = feeds
+	}
+	return doc
+}
+
+// SystemInformation builds system_information.json, filling in language (v2)
+// or languages (v3) when unset.
+func (b *Builder) SystemInformation(data SystemInfoData) *SystemInformation {
+	data.Name = b.localize(data.Name)
+	data.ShortName = b.localize(data.ShortName)
+	data.Operator = b.localize(data.Operator)
+	if b.v3() {
+		data.Language = ""
+		if len(data.Languages) == 0 {
+			data.Languages = []string{b.language}
+		}
+	} else {
+		data.Languages = nil
+		if data.Language == "" {
+			data.Language = b.language
+		}
+	}
+	return &SystemInformation{CommonHeader: b.Header(), Data: data}
+}
+
+// VehicleTypes builds vehicle_types.json. return_constraint is dropped
+// before v2.3 and filled from a draft return_type from v2.3.
+func (b *Builder) VehicleTypes(types []VehicleType) *VehicleTypes {
+	out := make([]VehicleType, len(types))
+	for i, t := range types {
+		t.Name = b.localize(t.Name)
+		t.Make = b.localize(t.Make)
+		t.Model = b.localize(t.Model)
+		t.Description = b.localize(t.Description)
+		switch {
+		case !version.AtLeast(string(b.version), "2.3"):
+			t.ReturnConstraint = ""
+		case t.ReturnConstraint == "":
+			t.ReturnConstraint = ReturnConstraintFromType(t.ReturnType)
+		}
+		t.ReturnType = nil
+		out[i] = t
+	}
+	return &VehicleTypes{CommonHeader: b.Header(), Data: VehicleTypesData{VehicleTypes: out}}
+}
+
+// StationInformation builds station_information.json.
+func (b *Builder) StationInformation(stations []Station) *StationInformation {
+	out := make([]Station, len(stations))
+	for i, s := range stations {
+		s.Name = b.localize(s.Name)
+		s.ShortName = b.localize(s.ShortName)
+		out[i] = s
+	}
+	return &StationInformation{CommonHeader: b.Header(), Data: StationInfoData{Stations: out}}
+}
+
+// StationStatus builds station_status.json, moving vehicle counts to
+// num_bikes_* before v3 and num_vehicles_* from v3.
+func (b *Builder) StationStatus(stations []StationStatusEntry) *StationStatus {
+	out := make([]StationStatusEntry, len(stations))
+	for i, s := range stations {
+		if b.v3() {
+			s.NumVehiclesAvailable = firstSet(s.NumVehiclesAvailable, s.NumBikesAvailable)
+			s.NumVehiclesDisabled = firstSet(s.NumVehiclesDisabled, s.NumBikesDisabled)
+			s.NumBikesAvailable, s.NumBikesDisabled = nil, nil
+		} else {
+			s.NumBikesAvailable = firstSet(s.NumBikesAvailable, s.NumVehiclesAvailable)
+			s.NumBikesDisabled = firstSet(s.NumBikesDisabled, s.NumVehiclesDisabled)
+			s.NumVehiclesAvailable, s.NumVehiclesDisabled = nil, nil
+		}
+		s.LastReported = b.timestamp(s.LastReported)
+		out[i] = s
+	}
+	return &StationStatus{CommonHeader: b.Header(), Data: StationStatusData{Stations: out}}
+}
+
+// VehicleStatus builds vehicle_status.json (v3) or free_bike_status.json,
+// using vehicle_id or bike_id and the vehicles or bikes list accordingly.
+func (b *Builder) VehicleStatus(vehicles []Vehicle) *VehicleStatus {
+	out := make([]Vehicle, len(vehicles))
+	for i, v := range vehicles {
+		id := v.GetID()
+		if b.v3() {
+			v.VehicleID, v.BikeID = id, ""
+		} else {
+			v.BikeID, v.VehicleID = id, ""
+		}
+		v.LastReported = b.timestamp(v.LastReported)
+		out[i] = v
+	}
+
+	doc := &VehicleStatus{CommonHeader: b.Header()}
+	if b.v3() {
+		doc.Data.Vehicles = out
+	} else {
+		doc.Data.Bikes = out
+	}
+	return doc
+}
+
+// VehicleStatusFileName returns the vehicle status file name for the version.
+func (b *Builder) VehicleStatusFileName() string {
+	if b.v3() {
+		return "vehicle_status"
+	}
+	return "free_bike_status"
+}
+
+// SystemPricingPlans builds system_pricing_plans.json.
+func (b *Builder) SystemPricingPlans(plans []PricingPlan) *SystemPricingPlans {
+	out := make([]PricingPlan, len(plans))
+	for i, p := range plans {
+		p.Name = b.localize(p.Name)
+		p.Description = b.localize(p.Description)
+		out[i] = p
+	}
+	return &SystemPricingPlans{CommonHeader: b.Header(), Data: PricingPlansData{Plans: out}}
+}
+
+// SystemRegions builds system_regions.json.
+func (b *Builder) SystemRegions(regions []Region) *SystemRegions {
+	out := make([]Region, len(regions))
+	for i, r := range regions {
+		r.Name = b.localize(r.Name)
+		out[i] = r
+	}
+	return &SystemRegions{CommonHeader: b.Header(), Data: RegionsData{Regions: out}}
+}
+
+// SystemAlerts builds system_alerts.json.
+func (b *Builder) SystemAlerts(alerts []Alert) *SystemAlerts {
+	out := make([]Alert, len(alerts))
+	for i, a := range alerts {
+		a.Summary = b.localize(a.Summary)
+		a.Description = b.localize(a.Description)
+		a.URL = b.localize(a.URL)
+		a.LastUpdated = b.timestamp(a.LastUpdated)
+		times := make([]AlertTime, len(a.Times))
+		for j, t := range a.Times {
+			times[j] = AlertTime{Start: b.timestamp(t.Start), End: b.timestamp(t.End)}
+		}
+		a.Times = times
+		out[i] = a
+	}
+	return &SystemAlerts{CommonHeader: b.Header(), Data: AlertsData{Alerts: out}}
+}
+
+// GeofencingZones builds geofencing_zones.json. Global rules are dropped
+// before v3, which does not define them.
+func (b *Builder) GeofencingZones(features []GeoJSONFeature, globalRules []GeofencingRule) *GeofencingZones {
+	out := make([]GeoJSONFeature, len(features))
+	for i, f := range features {
+		f.Type = "Feature"
+		f.Properties.Name = b.localize(f.Properties.Name)
+		out[i] = f
+	}
+	doc := &GeofencingZones{CommonHeader: b.Header()}
+	doc.Data.GeofencingZones = GeoJSONFeatureCollection{Type: "FeatureCollection", Features: out}
+	if b.v3() {
+		doc.Data.GlobalRules = globalRules
+	}
+	return doc
+}
+
+// Marshal encodes a built document as indented JSON.
+func (b *Builder) Marshal(doc interface{}) ([]byte, error) {
+	return json.MarshalIndent(doc, "", "  ")
+}
+
+// firstSet returns the first non-nil pointer.
+func firstSet[T any](values ...*T) *T {
+	for _, v := range values {
+		if v != nil {
+			return v
+		}
+	}
+	return nil
+}
@@ -0,0 +1,189 @@
+package gbfs_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gbfs-validator-go/pkg/fetcher"
+	"github.com/gbfs-validator-go/pkg/gbfs"
+	"github.com/gbfs-validator-go/pkg/validator"
+)
+
+// buildFeed produces a small docked and free-floating feed with b.
+func buildFeed(b *gbfs.Builder, baseURL string) map[string]interface{} {
+	now := time.Now()
+	docs := map[string]interface{}{
+		"system_information": b.SystemInformation(gbfs.SystemInfoData{
+			SystemID:         "builder_test",
+			Name:             b.Text("Builder Bikes"),
+			Timezone:         "Europe/Paris",
+			OpeningHours:     "24/7",
+			FeedContactEmail: "feeds@example.com",
+		}),
+		"vehicle_types": b.VehicleTypes([]gbfs.VehicleType{{
+			VehicleTypeID:        "bike",
+			FormFactor:           "bicycle",
+			PropulsionType:       "human",
+			Name:                 b.Text("Bike"),
+			DefaultPricingPlanID: "basic",
+		}}),
+		"system_pricing_plans": b.SystemPricingPlans([]gbfs.PricingPlan{{
+			PlanID:      "basic",
+			Name:        b.Text("Basic"),
+			Currency:    "EUR",
+			Description: b.Text("Pay as you go"),
+		}}),
+		"station_information": b.StationInformation([]gbfs.Station{{
+			StationID: "s1",
+			Name:      b.Text("Central"),
+			Lat:       48.85,
+			Lon:       2.35,
+			Capacity:  gbfs.Ptr(10),
+		}}),
+		"station_status": b.StationStatus([]gbfs.StationStatusEntry{{
+			StationID:            "s1",
+			NumVehiclesAvailable: gbfs.Ptr(3),
+			NumDocksAvailable:    gbfs.Ptr(7),
+			IsInstalled:          gbfs.Ptr(true),
+			IsRenting:            gbfs.Ptr(true),
+			IsReturning:          gbfs.Ptr(true),
+			LastReported:         gbfs.Timestamp{Time: now},
+			VehicleTypesAvailable: []gbfs.VehicleTypeAvailable{
+				{VehicleTypeID: "bike", Count: 3},
+			},
+		}}),
+		b.VehicleStatusFileName(): b.VehicleStatus([]gbfs.Vehicle{{
+			VehicleID:     "v1",
+			Lat:           48.86,
+			Lon:           2.34,
+			IsReserved:    false,
+			IsDisabled:    false,
+			VehicleTypeID: "bike",
+			LastReported:  gbfs.Timestamp{Time: now},
+		}}),
+	}
+
+	var feeds []gbfs.FeedInfo
+	for name := range docs {
+		feeds = append(feeds, gbfs.FeedInfo{Name: name, URL: baseURL + "/" + name + ".json"})
+	}
+	docs["gbfs"] = b.GBFS(feeds)
+	return docs
+}
+
+// TestBuilderFeedValidates checks built feeds pass validation in both the
+// v2 and v3 layouts.
+func TestBuilderFeedValidates(t *testing.T) {
+	for _, ver := range []gbfs.Version{gbfs.V2_3, gbfs.V3_0} {
+		t.Run(string(ver), func(t *testing.T) {
+			b := gbfs.NewBuilder(ver, "en").WithTTL(60)
+
+			var docs map[string]interface{}
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				doc, ok := docs[strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/"), ".json")]
+				if !ok {
+					http.NotFound(w, r)
+					return
+				}
+				data, err := b.Marshal(doc)
+				if err != nil {
+					http.Error(w, err.Error(), http.StatusInternalServerError)
+					return
+				}
+				w.Header().Set("Content-Type", "application/json")
+				w.Write(data)
+			}))
+			defer server.Close()
+			docs = buildFeed(b, server.URL)
+
+			ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+			defer cancel()
+
+			v := validator.New(fetcher.New(), validator.Options{Docked: true, Freefloating: true})
+			result, err := v.Validate(ctx, server.URL+"/gbfs.json")
+			if err != nil {
+				t.Fatalf("Validation failed: %v", err)
+			}
+			if result.Summary.Version.Detected != string(ver) {
+				t.Errorf("expected version %s, detected %s", ver, result.Summary.Version.Detected)
+			}
+			for _, file := range result.Files {
+				for _, e := range file.Errors {
+					if e.Severity == validator.SeverityError {
+						t.Errorf("%s: %s at %s", file.File, e.Message, e.InstancePath)
+					}
+				}
+			}
+		})
+	}
+}
+
+// TestBuilderSerialization checks version-specific encodings.
+func TestBuilderSerialization(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	encode := func(b *gbfs.Builder, doc interface{}) map[string]interface{} {
+		data, err := b.Marshal(doc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var out map[string]interface{}
+		json.Unmarshal(data, &out)
+		return out
+	}
+
+	v2 := gbfs.NewBuilder(gbfs.V2_2, "fr").WithTime(now)
+	doc := encode(v2, v2.GBFS([]gbfs.FeedInfo{{Name: "system_information", URL: "http://example.com/si.json"}}))
+	if _, ok := doc["last_updated"].(float64); !ok {
+		t.Errorf("v2 last_updated should be POSIX, got %v", doc["last_updated"])
+	}
+	if _, ok := doc["data"].(map[string]interface{})["fr"]; !ok {
+		t.Errorf("v2 gbfs.json should be keyed by language, got %v", doc["data"])
+	}
+	if name := v2.Text("Vélo").Get("fr"); name != "Vélo" {
+		t.Errorf("unexpected text %q", name)
+	}
+
+	v3 := gbfs.NewBuilder(gbfs.V3_0, "fr").WithTime(now)
+	doc = encode(v3, v3.StationInformation([]gbfs.Station{{StationID: "s1", Name: gbfs.Text("Gare")}}))
+	if doc["last_updated"] != "2023-11-14T22:13:20Z" {
+		t.Errorf("v3 last_updated should be RFC3339, got %v", doc["last_updated"])
+	}
+	name := doc["data"].(map[string]interface{})["stations"].([]interface{})[0].(map[string]interface{})["name"]
+	if _, ok := name.([]interface{}); !ok {
+		t.Errorf("v3 name should be localized, got %v", name)
+	}
+
+	v1 := gbfs.NewBuilder(gbfs.V1_0, "en")
+	if _, ok := encode(v1, v1.GBFS(nil))["version"]; ok {
+		t.Error("1.0 documents should omit version")
+	}
+}
+
+// TestBuilderReturnConstraint checks return_constraint is written only from
+// v2.3 and that draft return_type values are never written.
+func TestBuilderReturnConstraint(t *testing.T) {
+	types := []gbfs.VehicleType{
+		{VehicleTypeID: "a", ReturnConstraint: "any_station", ReturnType: []string{"free_floating"}},
+		{VehicleTypeID: "b", ReturnType: []string{"free_floating", "station"}},
+	}
+	for _, tc := range []struct {
+		ver  gbfs.Version
+		want []string
+	}{
+		{gbfs.V2_2, []string{"", ""}},
+		{gbfs.V2_3, []string{"any_station", "hybrid"}},
+		{gbfs.V3_0, []string{"any_station", "hybrid"}},
+	} {
+		built := gbfs.NewBuilder(tc.ver, "en").VehicleTypes(types).Data.VehicleTypes
+		for i, vt := range built {
+			if vt.ReturnConstraint != tc.want[i] || vt.ReturnType != nil {
+				t.Errorf("%s: vehicle type %s has return_constraint %q and return_type %v", tc.ver, vt.VehicleTypeID, vt.ReturnConstraint, vt.ReturnType)
+			}
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package gbfs
+
+// SystemHours represents system_hours.json (v1.0 to v2.3).
+type SystemHours struct {
+	CommonHeader
+	Data SystemHoursData `json:"data"`
+}
+
+// SystemHoursData wraps rental hour entries.
+type SystemHoursData struct {
+	RentalHours []RentalHours `json:"rental_hours"`
+}
+
+// RentalHours describes when the system rents to a set of user types.
+type RentalHours struct {
+	UserTypes []string `json:"user_types"`
+	Days      []string `json:"days"`
+	StartTime string   `json:"start_time"`
+	EndTime   string   `json:"end_time"`
+}
+
+// SystemCalendar represents system_calendar.json (v1.0 to v2.3).
+type SystemCalendar struct {
+	CommonHeader
+	Data SystemCalendarData `json:"data"`
+}
+
+// SystemCalendarData wraps operating calendar entries.
+type SystemCalendarData struct {
+	Calendars []Calendar `json:"calendars"`
+}
+
+// Calendar is an operating season. Years are optional and absent when the
+// season repeats every year.
+type Calendar struct {
+	StartMonth int  `json:"start_month"`
+	StartDay   int  `json:"start_day"`
+	StartYear  *int `json:"start_year,omitempty"`
+	EndMonth   int  `json:"end_month"`
+	EndDay     int  `json:"end_day"`
+	EndYear    *int `json:"end_year,omitempty"`
+}
+
+// VehicleAvailability represents vehicle_availability.json (v3.1).
+type VehicleAvailability struct {
+	CommonHeader
+	Data VehicleAvailabilityData `json:"data"`
+}
+
+// VehicleAvailabilityData wraps reservable vehicle entries.
+type VehicleAvailabilityData struct {
+	Vehicles []AvailableVehicle `json:"vehicles"`
+}
+
+// AvailableVehicle lists the future windows a vehicle can be reserved in.
+type AvailableVehicle struct {
+	VehicleID        string         `json:"vehicle_id"`
+	VehicleTypeID    string         `json:"vehicle_type_id"`
+	StationID        string         `json:"station_id"`
+	PricingPlanID    string         `json:"pricing_plan_id,omitempty"`
+	VehicleEquipment []string       `json:"vehicle_equipment,omitempty"`
+	Availabilities   []Availability `json:"availabilities"`
+}
+
+// Availability is a reservable window; Until is nil when open-ended.
+type Availability struct {
+	From  Timestamp  `json:"from"`
+	Until *Timestamp `json:"until,omitempty"`
+}
@@ -0,0 +1,62 @@
+package gbfs
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// fileTypes maps GBFS file names to constructors for their typed structs.
+// Timestamps keep the POSIX or RFC3339 encoding they were read with, so a
+// decoded file marshals back in its version's format.
+var fileTypes = map[string]func() interface{}{
+	"gbfs":                 func() interface{} { return new(GBFSFeed) },
+	"gbfs_versions":        func() interface{} { return new(GBFSVersions) },
+	"manifest":             func() interface{} { return new(Manifest) },
+	"system_information":   func() interface{} { return new(SystemInformation) },
+	"vehicle_types":        func() interface{} { return new(VehicleTypes) },
+	"station_information":  func() interface{} { return new(StationInformation) },
+	"station_status":       func() interface{} { return new(StationStatus) },
+	"free_bike_status":     func() interface{} { return new(VehicleStatus) },
+	"vehicle_status":       func() interface{} { return new(VehicleStatus) },
+	"vehicle_availability": func() interface{} { return new(VehicleAvailability) },
+	"system_hours":         func() interface{} { return new(SystemHours) },
+	"system_calendar":      func() interface{} { return new(SystemCalendar) },
+	"system_regions":       func() interface{} { return new(SystemRegions) },
+	"system_pricing_plans": func() interface{} { return new(SystemPricingPlans) },
+	"system_alerts":        func() interface{} { return new(SystemAlerts) },
+	"geofencing_zones":     func() interface{} { return new(GeofencingZones) },
+}
+
+// FileNames returns the GBFS file names with typed structs, sorted.
+func FileNames() []string {
+	names := make([]string, 0, len(fileTypes))
+	for name := range fileTypes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// NewFile returns a pointer to an empty struct for the named file. The name
+// may include the .json suffix.
+func NewFile(name string) (interface{}, bool) {
+	ctor, ok := fileTypes[strings.TrimSuffix(name, ".json")]
+	if !ok {
+		return nil, false
+	}
+	return ctor(), true
+}
+
+// Decode unmarshals data into the typed struct for the named file.
+func Decode(name string, data []byte) (interface{}, error) {
+	v, ok := NewFile(name)
+	if !ok {
+		return nil, fmt.Errorf("unknown GBFS file %q", name)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return nil, fmt.Errorf("decode %s: %w", name, err)
+	}
+	return v, nil
+}
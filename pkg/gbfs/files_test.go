@@ -0,0 +1,109 @@
+package gbfs_test
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gbfs-validator-go/pkg/gbfs"
+	"github.com/gbfs-validator-go/pkg/scaffold"
+	"github.com/gbfs-validator-go/pkg/version"
+)
+
+// TestDecodeAllFiles checks every generated skeleton file decodes into its
+// typed struct and re-encodes last_updated in the version's format.
+func TestDecodeAllFiles(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	for _, ver := range version.SupportedVersions() {
+		files, err := scaffold.Skeleton(ver, "http://example.com/", version.Options{}, now)
+		if err != nil {
+			t.Fatalf("%s: Skeleton failed: %v", ver, err)
+		}
+		for name, data := range files {
+			v, err := gbfs.Decode(name, data)
+			if err != nil {
+				t.Errorf("%s/%s: %v", ver, name, err)
+				continue
+			}
+			out, err := json.Marshal(v)
+			if err != nil {
+				t.Errorf("%s/%s: marshal failed: %v", ver, name, err)
+				continue
+			}
+			var in, round struct {
+				LastUpdated json.RawMessage `json:"last_updated"`
+			}
+			json.Unmarshal(data, &in)
+			json.Unmarshal(out, &round)
+			if string(in.LastUpdated) != string(round.LastUpdated) {
+				t.Errorf("%s/%s: last_updated %s re-encoded as %s", ver, name, in.LastUpdated, round.LastUpdated)
+			}
+		}
+	}
+}
+
+// TestDecodeNewFiles checks the calendar, hours, and availability files.
+func TestDecodeNewFiles(t *testing.T) {
+	v, err := gbfs.Decode("vehicle_availability.json", []byte(`{
+		"last_updated": "2024-01-01T00:00:00Z", "ttl": 0, "version": "3.1-RC2",
+		"data": {"vehicles": [{"vehicle_id": "v1", "vehicle_type_id": "t1", "station_id": "s1",
+			"availabilities": [{"from": "2024-01-01T10:00:00Z", "until": "2024-01-01T12:00:00Z"}, {"from": "2024-01-02T10:00:00Z"}]}]}
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	va := v.(*gbfs.VehicleAvailability)
+	windows := va.Data.Vehicles[0].Availabilities
+	if len(windows) != 2 || windows[0].Until == nil || windows[1].Until != nil {
+		t.Errorf("unexpected availabilities %+v", windows)
+	}
+
+	v, err = gbfs.Decode("system_calendar", []byte(`{"last_updated": 1700000000, "ttl": 0,
+		"data": {"calendars": [{"start_month": 4, "start_day": 1, "end_month": 10, "end_day": 31, "end_year": 2024}]}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cal := v.(*gbfs.SystemCalendar).Data.Calendars[0]
+	if cal.StartYear != nil || gbfs.Value(cal.EndYear) != 2024 {
+		t.Errorf("unexpected calendar %+v", cal)
+	}
+
+	if _, err := gbfs.Decode("system_hours", []byte(`{"data": {"rental_hours": "always"}}`)); err == nil {
+		t.Error("expected malformed system_hours to fail")
+	}
+	if _, err := gbfs.Decode("not_a_file", nil); err == nil || !strings.Contains(err.Error(), "unknown") {
+		t.Errorf("expected unknown file error, got %v", err)
+	}
+}
+
+// TestGBFSDataRoundTrip checks both gbfs.json layouts re-encode unchanged,
+// including empty feed lists and every v2 language.
+func TestGBFSDataRoundTrip(t *testing.T) {
+	tests := []struct {
+		input  string
+		format gbfs.GBFSFormat
+	}{
+		{`{"en":{"feeds":[{"name":"system_information","url":"http://example.com/en/si.json"}]},"fr":{"feeds":[{"name":"system_information","url":"http://example.com/fr/si.json"}]}}`, gbfs.FormatLanguageMap},
+		{`{"feeds":[{"name":"system_information","url":"http://example.com/si.json"}]}`, gbfs.FormatFeedList},
+		{`{"feeds":[]}`, gbfs.FormatFeedList},
+		{`{}`, gbfs.FormatLanguageMap},
+	}
+
+	for _, tt := range tests {
+		var data gbfs.GBFSData
+		if err := json.Unmarshal([]byte(tt.input), &data); err != nil {
+			t.Fatalf("unmarshal %s: %v", tt.input, err)
+		}
+		if data.Format != tt.format {
+			t.Errorf("%s: detected %q, want %q", tt.input, data.Format, tt.format)
+		}
+		out, err := json.Marshal(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(out) != tt.input {
+			t.Errorf("round trip: got %s, want %s", out, tt.input)
+		}
+	}
+}
@@ -0,0 +1,189 @@
+package gbfs
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Geometry types used by GBFS.
+const (
+	GeometryPoint        = "Point"
+	GeometryPolygon      = "Polygon"
+	GeometryMultiPolygon = "MultiPolygon"
+)
+
+// Position is a GeoJSON [longitude, latitude] pair. Extra elements such as
+// altitude are accepted and dropped.
+type Position [2]float64
+
+// Lon returns the longitude.
+func (p Position) Lon() float64 { return p[0] }
+
+// Lat returns the latitude.
+func (p Position) Lat() float64 { return p[1] }
+
+// UnmarshalJSON accepts arrays of two or more numbers.
+func (p *Position) UnmarshalJSON(data []byte) error {
+	var coords []float64
+	if err := json.Unmarshal(data, &coords); err != nil {
+		return err
+	}
+	if len(coords) < 2 {
+		return fmt.Errorf("position needs longitude and latitude, got %d values", len(coords))
+	}
+	p[0], p[1] = coords[0], coords[1]
+	return nil
+}
+
+// Validate checks the position is within WGS84 bounds.
+func (p Position) Validate() error {
+	if p.Lon() < -180 || p.Lon() > 180 {
+		return fmt.Errorf("longitude %v out of range [-180, 180]", p.Lon())
+	}
+	if p.Lat() < -90 || p.Lat() > 90 {
+		return fmt.Errorf("latitude %v out of range [-90, 90]", p.Lat())
+	}
+	return nil
+}
+
+// Ring is a closed linear ring of positions.
+type Ring []Position
+
+// Validate checks the ring has at least four positions, is closed, and that
+// every position is in range.
+func (r Ring) Validate() error {
+	if len(r) < 4 {
+		return fmt.Errorf("linear ring needs at least 4 positions, got %d", len(r))
+	}
+	if r[0] != r[len(r)-1] {
+		return fmt.Errorf("linear ring is not closed: first position %v differs from last %v", r[0], r[len(r)-1])
+	}
+	for i, p := range r {
+		if err := p.Validate(); err != nil {
+			return fmt.Errorf("position %d: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// Polygon is an exterior ring followed by optional holes.
+type Polygon []Ring
+
+// Validate checks the polygon has an exterior ring and every ring is valid.
+func (p Polygon) Validate() error {
+	if len(p) == 0 {
+		return fmt.Errorf("polygon has no rings")
+	}
+	for i, r := range p {
+		if err := r.Validate(); err != nil {
+			return fmt.Errorf("ring %d: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// MultiPolygon is a list of polygons.
+type MultiPolygon []Polygon
+
+// Validate checks every polygon.
+func (m MultiPolygon) Validate() error {
+	if len(m) == 0 {
+		return fmt.Errorf("multipolygon has no polygons")
+	}
+	for i, p := range m {
+		if err := p.Validate(); err != nil {
+			return fmt.Errorf("polygon %d: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// Bounds returns the south-west and north-east corners of the polygons.
+func (m MultiPolygon) Bounds() (Position, Position) {
+	sw := Position{180, 90}
+	ne := Position{-180, -90}
+	for _, polygon := range m {
+		for _, ring := range polygon {
+			for _, p := range ring {
+				sw[0], sw[1] = min(sw[0], p[0]), min(sw[1], p[1])
+				ne[0], ne[1] = max(ne[0], p[0]), max(ne[1], p[1])
+			}
+		}
+	}
+	return sw, ne
+}
+
+// Point parses the coordinates of a Point geometry.
+func (g *GeoJSON) Point() (Position, error) {
+	if g.Type != GeometryPoint {
+		return Position{}, fmt.Errorf("geometry is %s, not %s", g.Type, GeometryPoint)
+	}
+	var p Position
+	if err := json.Unmarshal(g.Coordinates, &p); err != nil {
+		return Position{}, fmt.Errorf("invalid Point coordinates: %w", err)
+	}
+	return p, nil
+}
+
+// Polygon parses the coordinates of a Polygon geometry.
+func (g *GeoJSON) Polygon() (Polygon, error) {
+	if g.Type != GeometryPolygon {
+		return nil, fmt.Errorf("geometry is %s, not %s", g.Type, GeometryPolygon)
+	}
+	var p Polygon
+	if err := json.Unmarshal(g.Coordinates, &p); err != nil {
+		return nil, fmt.Errorf("invalid Polygon coordinates: %w", err)
+	}
+	return p, nil
+}
+
+// MultiPolygon parses the coordinates of a MultiPolygon geometry. A Polygon
+// is returned as a single-element MultiPolygon.
+func (g *GeoJSON) MultiPolygon() (MultiPolygon, error) {
+	if g.Type == GeometryPolygon {
+		p, err := g.Polygon()
+		if err != nil {
+			return nil, err
+		}
+		return MultiPolygon{p}, nil
+	}
+	if g.Type != GeometryMultiPolygon {
+		return nil, fmt.Errorf("geometry is %s, not %s", g.Type, GeometryMultiPolygon)
+	}
+	var m MultiPolygon
+	if err := json.Unmarshal(g.Coordinates, &m); err != nil {
+		return nil, fmt.Errorf("invalid MultiPolygon coordinates: %w", err)
+	}
+	return m, nil
+}
+
+// Parsed returns the typed coordinates: a Position, Polygon, or MultiPolygon.
+func (g *GeoJSON) Parsed() (interface{}, error) {
+	switch g.Type {
+	case GeometryPoint:
+		return g.Point()
+	case GeometryPolygon:
+		return g.Polygon()
+	case GeometryMultiPolygon:
+		return g.MultiPolygon()
+	default:
+		return nil, fmt.Errorf("unsupported geometry type %q", g.Type)
+	}
+}
+
+// Validate parses the coordinates and checks ranges and ring closure.
+func (g *GeoJSON) Validate() error {
+	parsed, err := g.Parsed()
+	if err != nil {
+		return err
+	}
+	switch c := parsed.(type) {
+	case Position:
+		return c.Validate()
+	case Polygon:
+		return c.Validate()
+	case MultiPolygon:
+		return c.Validate()
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package gbfs
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+// TestGeoJSONParsing checks typed parsing and validation of geometries.
+func TestGeoJSONParsing(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		wantErr bool
+	}{
+		{"point", `{"type":"Point","coordinates":[2.35,48.85]}`, false},
+		{"point with altitude", `{"type":"Point","coordinates":[2.35,48.85,35]}`, false},
+		{"point out of range", `{"type":"Point","coordinates":[48.85,200]}`, true},
+		{"polygon", `{"type":"Polygon","coordinates":[[[0,0],[1,0],[1,1],[0,0]]]}`, false},
+		{"open ring", `{"type":"Polygon","coordinates":[[[0,0],[1,0],[1,1],[0,1]]]}`, true},
+		{"multipolygon", `{"type":"MultiPolygon","coordinates":[[[[0,0],[1,0],[1,1],[0,0]]]]}`, false},
+		{"empty multipolygon", `{"type":"MultiPolygon","coordinates":[]}`, true},
+		{"wrong nesting", `{"type":"MultiPolygon","coordinates":[[0,0],[1,1]]}`, true},
+		{"unsupported type", `{"type":"LineString","coordinates":[[0,0],[1,1]]}`, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var g GeoJSON
+			if err := json.Unmarshal([]byte(tt.input), &g); err != nil {
+				t.Fatalf("unmarshal failed: %v", err)
+			}
+			err := g.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// TestMultiPolygonPromotion checks a Polygon is readable as a MultiPolygon.
+func TestMultiPolygonPromotion(t *testing.T) {
+	g := GeoJSON{Type: GeometryPolygon, Coordinates: json.RawMessage(`[[[0,0],[2,0],[2,1],[0,0]]]`)}
+	m, err := g.MultiPolygon()
+	if err != nil {
+		t.Fatalf("MultiPolygon failed: %v", err)
+	}
+	sw, ne := m.Bounds()
+	if sw != (Position{0, 0}) || ne != (Position{2, 1}) {
+		t.Errorf("unexpected bounds %v %v", sw, ne)
+	}
+}
+
+// TestOverlaps checks polygon overlap ignores shared boundaries.
+func TestOverlaps(t *testing.T) {
+	square := func(x0, y0, x1, y1 float64) MultiPolygon {
+		return MultiPolygon{{{{x0, y0}, {x1, y0}, {x1, y1}, {x0, y1}, {x0, y0}}}}
+	}
+	withHole := MultiPolygon{{
+		{{0, 0}, {10, 0}, {10, 10}, {0, 10}, {0, 0}},
+		{{4, 4}, {6, 4}, {6, 6}, {4, 6}, {4, 4}},
+	}}
+	tests := []struct {
+		name string
+		a, b MultiPolygon
+		want bool
+	}{
+		{"crossing", square(0, 0, 2, 2), square(1, 1, 3, 3), true},
+		{"identical", square(0, 0, 2, 2), square(0, 0, 2, 2), true},
+		{"nested", square(0, 0, 4, 4), square(1, 1, 2, 2), true},
+		{"shared edge", square(0, 0, 2, 2), square(2, 0, 4, 2), false},
+		{"edge-aligned", square(0, 0, 2, 2), square(1, 0, 3, 2), true},
+		{"edge-aligned inside", square(0, 0, 4, 4), square(0, 1, 2, 3), true},
+		{"shared partial edge", square(0, 0, 2, 2), square(2, 1, 4, 3), false},
+		{"disjoint", square(0, 0, 1, 1), square(5, 5, 6, 6), false},
+		{"inside hole", withHole, square(4.5, 4.5, 5.5, 5.5), false},
+		{"across hole edge", withHole, square(5, 5, 7, 7), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.a.Overlaps(tt.b); got != tt.want {
+				t.Errorf("a.Overlaps(b) = %v, want %v", got, tt.want)
+			}
+			if got := tt.b.Overlaps(tt.a); got != tt.want {
+				t.Errorf("b.Overlaps(a) = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
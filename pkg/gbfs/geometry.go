@@ -0,0 +1,185 @@
+package gbfs
+
+import (
+	"math"
+	"sort"
+)
+
+// Geometry predicates treat longitude and latitude as planar coordinates,
+// which is accurate enough for city-scale zones away from the antimeridian.
+
+// boundaryEpsilon is the distance in degrees, about 1 cm, within which a
+// point counts as on a boundary.
+const boundaryEpsilon = 1e-7
+
+// cross is the z component of (b-a) x (c-a).
+func cross(a, b, c Position) float64 {
+	return (b[0]-a[0])*(c[1]-a[1]) - (b[1]-a[1])*(c[0]-a[0])
+}
+
+// onSegment reports whether p lies on the segment ab.
+func onSegment(p, a, b Position) bool {
+	length := math.Hypot(b[0]-a[0], b[1]-a[1])
+	if math.Abs(cross(a, b, p)) > boundaryEpsilon*max(length, 1) {
+		return false
+	}
+	return p[0] >= min(a[0], b[0])-boundaryEpsilon && p[0] <= max(a[0], b[0])+boundaryEpsilon &&
+		p[1] >= min(a[1], b[1])-boundaryEpsilon && p[1] <= max(a[1], b[1])+boundaryEpsilon
+}
+
+// splits returns where segment cd meets segment ab, as fractions of the way
+// from a to b: the point where cd crosses ab's line, and each end of cd
+// that lies on ab, so that collinear segments split ab where they overlap.
+func splits(a, b, c, d Position) []float64 {
+	dx, dy := b[0]-a[0], b[1]-a[1]
+	var ts []float64
+	for _, p := range []Position{c, d} {
+		if onSegment(p, a, b) {
+			ts = append(ts, ((p[0]-a[0])*dx+(p[1]-a[1])*dy)/(dx*dx+dy*dy))
+		}
+	}
+	if d1, d2 := cross(c, d, a), cross(c, d, b); (d1 > 0 && d2 < 0) || (d1 < 0 && d2 > 0) {
+		ts = append(ts, d1/(d1-d2))
+	}
+	return ts
+}
+
+// onBoundary reports whether p lies on an edge of the ring.
+func (r Ring) onBoundary(p Position) bool {
+	for i := 1; i < len(r); i++ {
+		if onSegment(p, r[i-1], r[i]) {
+			return true
+		}
+	}
+	return false
+}
+
+// encloses reports whether p is inside the ring by ray casting. Points on
+// the boundary give either answer.
+func (r Ring) encloses(p Position) bool {
+	inside := false
+	for i, j := 0, len(r)-1; i < len(r); j, i = i, i+1 {
+		a, b := r[i], r[j]
+		if (a[1] > p[1]) != (b[1] > p[1]) && p[0] < (b[0]-a[0])*(p[1]-a[1])/(b[1]-a[1])+a[0] {
+			inside = !inside
+		}
+	}
+	return inside
+}
+
+// Contains reports whether p lies strictly inside the polygon: within the
+// exterior ring, outside every hole, and on no boundary.
+func (p Polygon) Contains(pt Position) bool {
+	if len(p) == 0 {
+		return false
+	}
+	for _, r := range p {
+		if r.onBoundary(pt) {
+			return false
+		}
+	}
+	if !p[0].encloses(pt) {
+		return false
+	}
+	for _, hole := range p[1:] {
+		if hole.encloses(pt) {
+			return false
+		}
+	}
+	return true
+}
+
+// Contains reports whether p lies strictly inside any of the polygons.
+func (m MultiPolygon) Contains(pt Position) bool {
+	for _, p := range m {
+		if p.Contains(pt) {
+			return true
+		}
+	}
+	return false
+}
+
+// centroid returns the area-weighted centroid of the exterior ring, or its
+// first vertex for a degenerate ring.
+func (p Polygon) centroid() Position {
+	if len(p) == 0 || len(p[0]) == 0 {
+		return Position{}
+	}
+	ring := p[0]
+	var area, x, y float64
+	for i := 1; i < len(ring); i++ {
+		a, b := ring[i-1], ring[i]
+		f := a[0]*b[1] - b[0]*a[1]
+		area += f
+		x += (a[0] + b[0]) * f
+		y += (a[1] + b[1]) * f
+	}
+	if area == 0 {
+		return ring[0]
+	}
+	return Position{x / (3 * area), y / (3 * area)}
+}
+
+// Overlaps reports whether the interiors of the polygons intersect. Zones
+// that only share a boundary do not overlap.
+func (m MultiPolygon) Overlaps(o MultiPolygon) bool {
+	msw, mne := m.Bounds()
+	osw, one := o.Bounds()
+	if msw[0] >= one[0] || osw[0] >= mne[0] || msw[1] >= one[1] || osw[1] >= mne[1] {
+		return false
+	}
+	for _, p := range m {
+		for _, q := range o {
+			if p.overlaps(q) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// overlaps reports whether the interiors of two polygons intersect.
+func (p Polygon) overlaps(q Polygon) bool {
+	if p.boundaryEnters(q) || q.boundaryEnters(p) {
+		return true
+	}
+	// Otherwise each boundary runs outside or along the other, so the
+	// polygons are disjoint, touch, or coincide; an interior point of one
+	// then lies in the other.
+	for _, pair := range [][2]Polygon{{p, q}, {q, p}} {
+		a, b := pair[0], pair[1]
+		if c := a.centroid(); a.Contains(c) && b.Contains(c) {
+			return true
+		}
+	}
+	return false
+}
+
+// boundaryEnters reports whether part of p's boundary lies strictly inside
+// q. Each edge of p is cut where q's boundary meets it, and the midpoint of
+// every piece tested, so that edges running along q's edges count only
+// where they leave them.
+func (p Polygon) boundaryEnters(q Polygon) bool {
+	for _, pr := range p {
+		for i := 1; i < len(pr); i++ {
+			a, b := pr[i-1], pr[i]
+			if a == b {
+				continue
+			}
+			ts := []float64{0, 1}
+			for _, qr := range q {
+				for j := 1; j < len(qr); j++ {
+					ts = append(ts, splits(a, b, qr[j-1], qr[j])...)
+				}
+			}
+			sort.Float64s(ts)
+			for k := 1; k < len(ts); k++ {
+				t := (ts[k-1] + ts[k]) / 2
+				if ts[k] > ts[k-1] && q.Contains(Position{a[0] + t*(b[0]-a[0]), a[1] + t*(b[1]-a[1])}) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,89 @@
+package gbfs
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// LocalizedText is a text field that is a plain string in v2 and an array of
+// LocalizedString in v3. A plain string is held as a single entry with no
+// language and marshals back to a string.
+type LocalizedText []LocalizedString
+
+// Text returns a LocalizedText holding a plain v2 string.
+func Text(s string) LocalizedText {
+	return LocalizedText{{Text: s}}
+}
+
+// UnmarshalJSON accepts a string, an array of localized strings, or null.
+func (t *LocalizedText) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if bytes.Equal(data, []byte("null")) {
+		*t = nil
+		return nil
+	}
+
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		*t = Text(s)
+		return nil
+	}
+
+	var values []LocalizedString
+	if err := json.Unmarshal(data, &values); err != nil {
+		return fmt.Errorf("localized text must be a string or an array of {text, language}: %w", err)
+	}
+	*t = values
+	return nil
+}
+
+// MarshalJSON writes a plain string for v2 text and an array otherwise.
+func (t LocalizedText) MarshalJSON() ([]byte, error) {
+	if t.IsPlain() {
+		return json.Marshal(t[0].Text)
+	}
+	return json.Marshal([]LocalizedString(t))
+}
+
+// IsPlain reports whether the text is a single untagged v2 string.
+func (t LocalizedText) IsPlain() bool {
+	return len(t) == 1 && t[0].Language == ""
+}
+
+// Default returns the first text, or "" when there is none.
+func (t LocalizedText) Default() string {
+	if len(t) == 0 {
+		return ""
+	}
+	return t[0].Text
+}
+
+// Get returns the text for lang, falling back to an entry sharing its primary
+// subtag (so "en" matches "en-US") and then to Default.
+func (t LocalizedText) Get(lang string) string {
+	for _, v := range t {
+		if strings.EqualFold(v.Language, lang) {
+			return v.Text
+		}
+	}
+	primary := strings.SplitN(lang, "-", 2)[0]
+	for _, v := range t {
+		if strings.EqualFold(strings.SplitN(v.Language, "-", 2)[0], primary) {
+			return v.Text
+		}
+	}
+	return t.Default()
+}
+
+// Languages returns the language tags present, in order.
+func (t LocalizedText) Languages() []string {
+	var langs []string
+	for _, v := range t {
+		if v.Language != "" {
+			langs = append(langs, v.Language)
+		}
+	}
+	return langs
+}
@@ -0,0 +1,55 @@
+package gbfs
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+// TestLocalizedTextRoundTrip checks v2 strings and v3 arrays both decode and
+// re-encode in their original form.
+func TestLocalizedTextRoundTrip(t *testing.T) {
+	for _, input := range []string{
+		`"Main St"`,
+		`[{"text":"Main St","language":"en"},{"text":"Rue Principale","language":"fr"}]`,
+	} {
+		var text LocalizedText
+		if err := json.Unmarshal([]byte(input), &text); err != nil {
+			t.Fatalf("unmarshal %s: %v", input, err)
+		}
+		out, err := json.Marshal(text)
+		if err != nil {
+			t.Fatalf("marshal %s: %v", input, err)
+		}
+		if string(out) != input {
+			t.Errorf("round trip: got %s, want %s", out, input)
+		}
+	}
+}
+
+// TestLocalizedTextGet checks language lookup and fallbacks.
+func TestLocalizedTextGet(t *testing.T) {
+	text := LocalizedText{
+		{Text: "Main St", Language: "en-US"},
+		{Text: "Rue Principale", Language: "fr"},
+	}
+	tests := map[string]string{
+		"fr":    "Rue Principale",
+		"FR":    "Rue Principale",
+		"en":    "Main St",
+		"de":    "Main St",
+		"fr-CA": "Rue Principale",
+	}
+	for lang, want := range tests {
+		if got := text.Get(lang); got != want {
+			t.Errorf("Get(%q) = %q, want %q", lang, got, want)
+		}
+	}
+	if got := Text("Main St").Get("fr"); got != "Main St" {
+		t.Errorf("plain text Get = %q", got)
+	}
+
+	var invalid LocalizedText
+	if err := json.Unmarshal([]byte(`42`), &invalid); err == nil {
+		t.Error("expected a number to be rejected")
+	}
+}
@@ -0,0 +1,18 @@
+package gbfs
+
+// Optional fields are pointers so an absent value is distinguishable from
+// zero or false. Ptr and Value build and read them.
+
+// Ptr returns a pointer to v.
+func Ptr[T any](v T) *T {
+	return &v
+}
+
+// Value returns the value p points to, or the zero value when p is nil.
+func Value[T any](p *T) T {
+	if p == nil {
+		var zero T
+		return zero
+	}
+	return *p
+}
@@ -0,0 +1,51 @@
+package gbfs
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+// TestOptionalFields checks absent fields stay nil while explicit zero and
+// false values are kept.
+func TestOptionalFields(t *testing.T) {
+	var entry StationStatusEntry
+	data := `{"station_id":"s1","num_vehicles_available":0,"is_installed":false}`
+	if err := json.Unmarshal([]byte(data), &entry); err != nil {
+		t.Fatal(err)
+	}
+
+	if entry.NumVehiclesAvailable == nil || *entry.NumVehiclesAvailable != 0 {
+		t.Errorf("expected explicit zero num_vehicles_available, got %v", entry.NumVehiclesAvailable)
+	}
+	if entry.IsInstalled == nil || *entry.IsInstalled {
+		t.Errorf("expected explicit false is_installed, got %v", entry.IsInstalled)
+	}
+	if entry.NumDocksAvailable != nil || entry.IsRenting != nil {
+		t.Error("expected absent fields to be nil")
+	}
+
+	out, err := json.Marshal(entry)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var round map[string]interface{}
+	json.Unmarshal(out, &round)
+	if _, ok := round["is_installed"]; !ok {
+		t.Error("explicit false is_installed was dropped on marshal")
+	}
+	if _, ok := round["is_renting"]; ok {
+		t.Error("absent is_renting was added on marshal")
+	}
+}
+
+// TestAvailable checks the v3 count is preferred over the v2 one.
+func TestAvailable(t *testing.T) {
+	entry := StationStatusEntry{NumBikesAvailable: Ptr(4), NumVehiclesAvailable: Ptr(0)}
+	if got := entry.Available(); got != 0 {
+		t.Errorf("Available() = %d, want 0", got)
+	}
+	entry.NumVehiclesAvailable = nil
+	if got := entry.Available(); got != 4 {
+		t.Errorf("Available() = %d, want 4", got)
+	}
+}
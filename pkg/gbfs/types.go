@@ -3,6 +3,8 @@ package gbfs
 
 import (
 	"encoding/json"
+	"fmt"
+	"sort"
 	"time"
 )
 
@@ -20,32 +22,78 @@ const (
 	V3_1_RC2 Version = "3.1-RC2"
 )
 
+// maxEpochSeconds is the largest POSIX value read as seconds (year 5138);
+// larger values are taken to be milliseconds.
+const maxEpochSeconds = 1e11
+
 // Timestamp parses POSIX or RFC3339 timestamps.
 type Timestamp struct {
 	Time   time.Time
 	IsUnix bool
+	// Issue describes a non-conformant encoding that was still accepted,
+	// such as a millisecond epoch or a POSIX time in a string. It is empty
+	// for conformant values.
+	Issue string
+}
+
+// Conformant reports whether the value was encoded as the spec requires.
+func (t Timestamp) Conformant() bool {
+	return t.Issue == ""
 }
 
-// UnmarshalJSON accepts RFC3339 strings or POSIX ints.
+// UnmarshalJSON accepts RFC3339 strings or POSIX ints. Millisecond epochs,
+// fractional seconds, and POSIX times in strings are accepted with Issue set.
 func (t *Timestamp) UnmarshalJSON(data []byte) error {
+	*t = Timestamp{}
+
 	var str string
 	if err := json.Unmarshal(data, &str); err == nil {
-		parsed, err := time.Parse(time.RFC3339, str)
-		if err == nil {
+		if parsed, err := time.Parse(time.RFC3339, str); err == nil {
 			t.Time = parsed
-			t.IsUnix = false
 			return nil
 		}
+		if err := t.setEpoch(json.Number(str)); err != nil {
+			return fmt.Errorf("invalid timestamp %q: want RFC3339 or POSIX seconds", str)
+		}
+		if t.Issue == "" {
+			t.Issue = "POSIX timestamp"
+		}
+		t.Issue += " encoded as a string"
+		return nil
+	}
+
+	var num json.Number
+	if err := json.Unmarshal(data, &num); err != nil {
+		return fmt.Errorf("invalid timestamp %s: want RFC3339 or POSIX seconds", data)
 	}
+	return t.setEpoch(num)
+}
 
-	var unix int64
-	if err := json.Unmarshal(data, &unix); err == nil {
-		t.Time = time.Unix(unix, 0)
-		t.IsUnix = true
+// setEpoch reads a POSIX value, flagging milliseconds and fractions.
+func (t *Timestamp) setEpoch(num json.Number) error {
+	t.IsUnix = true
+	if n, err := num.Int64(); err == nil {
+		if n >= maxEpochSeconds || n <= -maxEpochSeconds {
+			t.Time = time.UnixMilli(n)
+			t.Issue = "millisecond POSIX timestamp"
+			return nil
+		}
+		t.Time = time.Unix(n, 0)
 		return nil
 	}
 
-	return json.Unmarshal(data, &t.Time)
+	f, err := num.Float64()
+	if err != nil {
+		return err
+	}
+	if f >= maxEpochSeconds || f <= -maxEpochSeconds {
+		t.Time = time.UnixMilli(int64(f))
+		t.Issue = "millisecond POSIX timestamp"
+		return nil
+	}
+	t.Time = time.Unix(0, int64(f*float64(time.Second)))
+	t.Issue = "fractional POSIX timestamp"
+	return nil
 }
 
 // MarshalJSON outputs POSIX ints or RFC3339 strings.
@@ -66,7 +114,7 @@ type LocalizedString struct {
 type CommonHeader struct {
 	LastUpdated Timestamp `json:"last_updated"`
 	TTL         int       `json:"ttl"`
-	Version     string    `json:"version"`
+	Version     string    `json:"version,omitempty"`
 }
 
 // GBFSFeed represents the gbfs.json autodiscovery file.
@@ -75,30 +123,46 @@ type GBFSFeed struct {
 	Data GBFSData `json:"data"`
 }
 
-// GBFSData handles v2 language maps and v3 feed lists.
+// GBFSFormat identifies the layout of gbfs.json data.
+type GBFSFormat string
+
+const (
+	// FormatLanguageMap is the v1/v2 layout keyed by language code.
+	FormatLanguageMap GBFSFormat = "language_map"
+	// FormatFeedList is the v3 layout with a single feeds list.
+	FormatFeedList GBFSFormat = "feed_list"
+)
+
+// GBFSData handles v2 language maps and v3 feed lists. Feeds holds the v3
+// list, or the first language's feeds for v2; Format records which layout was
+// read and is written back by MarshalJSON.
 type GBFSData struct {
 	Feeds []FeedInfo `json:"feeds,omitempty"`
 
 	Languages map[string]LanguageFeeds `json:"-"`
+	Format    GBFSFormat               `json:"-"`
 }
 
-// UnmarshalJSON supports v2 and v3 formats.
+// UnmarshalJSON supports v2 and v3 formats. Data matching neither is left
+// empty for schema validation to report.
 func (d *GBFSData) UnmarshalJSON(data []byte) error {
-	type v3Format struct {
-		Feeds []FeedInfo `json:"feeds"`
+	*d = GBFSData{}
+
+	var v3 struct {
+		Feeds *[]FeedInfo `json:"feeds"`
 	}
-	var v3 v3Format
-	if err := json.Unmarshal(data, &v3); err == nil && len(v3.Feeds) > 0 {
-		d.Feeds = v3.Feeds
+	if err := json.Unmarshal(data, &v3); err == nil && v3.Feeds != nil {
+		d.Feeds = *v3.Feeds
+		d.Format = FormatFeedList
 		return nil
 	}
 
 	var v2 map[string]LanguageFeeds
 	if err := json.Unmarshal(data, &v2); err == nil {
 		d.Languages = v2
-		for _, langFeeds := range v2 {
-			d.Feeds = langFeeds.Feeds
-			break
+		d.Format = FormatLanguageMap
+		if langs := d.LanguageCodes(); len(langs) > 0 {
+			d.Feeds = v2[langs[0]].Feeds
 		}
 		return nil
 	}
@@ -106,6 +170,52 @@ func (d *GBFSData) UnmarshalJSON(data []byte) error {
 	return nil
 }
 
+// MarshalJSON writes the layout in Format. When Format is unset it writes
+// the language map if Languages is set and the feed list otherwise.
+func (d GBFSData) MarshalJSON() ([]byte, error) {
+	format := d.Format
+	if format == "" {
+		format = FormatFeedList
+		if len(d.Languages) > 0 {
+			format = FormatLanguageMap
+		}
+	}
+
+	if format == FormatLanguageMap {
+		languages := d.Languages
+		if languages == nil {
+			languages = map[string]LanguageFeeds{}
+		}
+		return json.Marshal(languages)
+	}
+	feeds := d.Feeds
+	if feeds == nil {
+		feeds = []FeedInfo{}
+	}
+	return json.Marshal(struct {
+		Feeds []FeedInfo `json:"feeds"`
+	}{feeds})
+}
+
+// LanguageCodes returns the v2 language keys in sorted order.
+func (d *GBFSData) LanguageCodes() []string {
+	langs := make([]string, 0, len(d.Languages))
+	for lang := range d.Languages {
+		langs = append(langs, lang)
+	}
+	sort.Strings(langs)
+	return langs
+}
+
+// FeedsFor returns the feeds for a v2 language, or the default feeds when
+// lang is empty.
+func (d *GBFSData) FeedsFor(lang string) []FeedInfo {
+	if lang == "" {
+		return d.Feeds
+	}
+	return d.Languages[lang].Feeds
+}
+
 // LanguageFeeds groups feeds by language.
 type LanguageFeeds struct {
 	Feeds []FeedInfo `json:"feeds"`
@@ -129,9 +239,9 @@ type SystemInfoData struct {
 	Language   string `json:"language,omitempty"`
 	Languages  []string `json:"languages,omitempty"`
 	
-	Name             interface{} `json:"name"`
-	ShortName        interface{} `json:"short_name,omitempty"`
-	Operator         interface{} `json:"operator,omitempty"`
+	Name             LocalizedText `json:"name"`
+	ShortName        LocalizedText `json:"short_name,omitempty"`
+	Operator         LocalizedText `json:"operator,omitempty"`
 	
 	URL              string `json:"url,omitempty"`
 	PurchaseURL      string `json:"purchase_url,omitempty"`
@@ -185,8 +295,8 @@ type StationInfoData struct {
 // Station describes a station entry.
 type Station struct {
 	StationID     string      `json:"station_id"`
-	Name          interface{} `json:"name"`
-	ShortName     interface{} `json:"short_name,omitempty"`
+	Name          LocalizedText `json:"name"`
+	ShortName     LocalizedText `json:"short_name,omitempty"`
 	Lat           float64     `json:"lat"`
 	Lon           float64     `json:"lon"`
 	Address       string      `json:"address,omitempty"`
@@ -194,15 +304,15 @@ type Station struct {
 	RegionID      string      `json:"region_id,omitempty"`
 	PostCode      string      `json:"post_code,omitempty"`
 	RentalMethods []string    `json:"rental_methods,omitempty"`
-	Capacity      int         `json:"capacity,omitempty"`
+	Capacity      *int        `json:"capacity,omitempty"`
 	
-	IsVirtualStation   bool        `json:"is_virtual_station,omitempty"`
+	IsVirtualStation   *bool       `json:"is_virtual_station,omitempty"`
 	StationArea        *GeoJSON    `json:"station_area,omitempty"`
 	ParkingType        string      `json:"parking_type,omitempty"`
-	ParkingHoop        bool        `json:"parking_hoop,omitempty"`
+	ParkingHoop        *bool       `json:"parking_hoop,omitempty"`
 	ContactPhone       string      `json:"contact_phone,omitempty"`
-	IsValetStation     bool        `json:"is_valet_station,omitempty"`
-	IsChargingStation  bool        `json:"is_charging_station,omitempty"`
+	IsValetStation     *bool       `json:"is_valet_station,omitempty"`
+	IsChargingStation  *bool       `json:"is_charging_station,omitempty"`
 	
 	VehicleTypesCapacity []VehicleTypeCapacity `json:"vehicle_types_capacity,omitempty"`
 	VehicleDocksCapacity []VehicleTypeCapacity `json:"vehicle_docks_capacity,omitempty"`
@@ -225,7 +335,8 @@ type RentalURIs struct {
 	Web     string `json:"web,omitempty"`
 }
 
-// GeoJSON holds a generic geometry value.
+// GeoJSON holds a geometry value. Coordinates stay raw so unknown shapes
+// round-trip; Point, Polygon, and MultiPolygon parse them.
 type GeoJSON struct {
 	Type        string          `json:"type"`
 	Coordinates json.RawMessage `json:"coordinates"`
@@ -245,21 +356,30 @@ type StationStatusData struct {
 // StationStatusEntry describes a station's status.
 type StationStatusEntry struct {
 	StationID             string    `json:"station_id"`
-	NumBikesAvailable     int       `json:"num_bikes_available,omitempty"`
-	NumVehiclesAvailable  int       `json:"num_vehicles_available,omitempty"`
-	NumBikesDisabled      int       `json:"num_bikes_disabled,omitempty"`
-	NumVehiclesDisabled   int       `json:"num_vehicles_disabled,omitempty"`
-	NumDocksAvailable     int       `json:"num_docks_available,omitempty"`
-	NumDocksDisabled      int       `json:"num_docks_disabled,omitempty"`
-	IsInstalled           bool      `json:"is_installed"`
-	IsRenting             bool      `json:"is_renting"`
-	IsReturning           bool      `json:"is_returning"`
+	NumBikesAvailable     *int      `json:"num_bikes_available,omitempty"`
+	NumVehiclesAvailable  *int      `json:"num_vehicles_available,omitempty"`
+	NumBikesDisabled      *int      `json:"num_bikes_disabled,omitempty"`
+	NumVehiclesDisabled   *int      `json:"num_vehicles_disabled,omitempty"`
+	NumDocksAvailable     *int      `json:"num_docks_available,omitempty"`
+	NumDocksDisabled      *int      `json:"num_docks_disabled,omitempty"`
+	IsInstalled           *bool     `json:"is_installed,omitempty"`
+	IsRenting             *bool     `json:"is_renting,omitempty"`
+	IsReturning           *bool     `json:"is_returning,omitempty"`
 	LastReported          Timestamp `json:"last_reported"`
 	
 	VehicleTypesAvailable []VehicleTypeAvailable `json:"vehicle_types_available,omitempty"`
 	VehicleDocksAvailable []VehicleDockAvailable `json:"vehicle_docks_available,omitempty"`
 }
 
+// Available returns num_vehicles_available when present, falling back to the
+// pre-v3 num_bikes_available.
+func (s *StationStatusEntry) Available() int {
+	if s.NumVehiclesAvailable != nil {
+		return *s.NumVehiclesAvailable
+	}
+	return Value(s.NumBikesAvailable)
+}
+
 // VehicleTypeAvailable counts vehicles by type.
 type VehicleTypeAvailable struct {
 	VehicleTypeID string `json:"vehicle_type_id"`
@@ -287,31 +407,71 @@ type VehicleTypesData struct {
 type VehicleType struct {
 	VehicleTypeID        string      `json:"vehicle_type_id"`
 	FormFactor           string      `json:"form_factor"`
-	RiderCapacity        int         `json:"rider_capacity,omitempty"`
+	RiderCapacity        *int        `json:"rider_capacity,omitempty"`
 	CargoVolumeCapacity  int         `json:"cargo_volume_capacity,omitempty"`
 	CargoLoadCapacity    int         `json:"cargo_load_capacity,omitempty"`
 	PropulsionType       string      `json:"propulsion_type"`
 	EcoLabels            []EcoLabel  `json:"eco_labels,omitempty"`
-	MaxRangeMeters       float64     `json:"max_range_meters,omitempty"`
-	Name                 interface{} `json:"name"` // string (v2.x) or []LocalizedString (v3.0+)
+	MaxRangeMeters       *float64    `json:"max_range_meters,omitempty"`
+	Name                 LocalizedText `json:"name"`
 	VehicleAccessories   []string    `json:"vehicle_accessories,omitempty"`
 	GCO2Km               int         `json:"g_CO2_km,omitempty"`
 	VehicleImage         string      `json:"vehicle_image,omitempty"`
-	Make                 interface{} `json:"make,omitempty"`
-	Model                interface{} `json:"model,omitempty"`
+	Make                 LocalizedText `json:"make,omitempty"`
+	Model                LocalizedText `json:"model,omitempty"`
 	Color                string      `json:"color,omitempty"`
-	Description          interface{} `json:"description,omitempty"`
+	Description          LocalizedText `json:"description,omitempty"`
 	WheelCount           int         `json:"wheel_count,omitempty"`
 	MaxPermittedSpeed    int         `json:"max_permitted_speed,omitempty"`
 	RatedPower           int         `json:"rated_power,omitempty"`
 	DefaultReserveTime   int         `json:"default_reserve_time,omitempty"`
 	ReturnConstraint     string      `json:"return_constraint,omitempty"`
+	// ReturnType is a draft name for return_constraint that no release
+	// defines. It is read for compatibility; Builder never writes it.
 	ReturnType           []string    `json:"return_type,omitempty"`
 	VehicleAssets        *VehicleAssets `json:"vehicle_assets,omitempty"`
 	DefaultPricingPlanID string      `json:"default_pricing_plan_id,omitempty"`
 	PricingPlanIDs       []string    `json:"pricing_plan_ids,omitempty"`
 }
 
+// ReturnConstraints lists the return_constraint values added in v2.3.
+var ReturnConstraints = []string{"free_floating", "roundtrip_station", "any_station", "hybrid"}
+
+// IsReturnConstraint reports whether s is a return_constraint value.
+func IsReturnConstraint(s string) bool {
+	for _, c := range ReturnConstraints {
+		if s == c {
+			return true
+		}
+	}
+	return false
+}
+
+// ReturnConstraintFromType maps draft return_type values to a
+// return_constraint, or "" when they do not map to one.
+func ReturnConstraintFromType(types []string) string {
+	free, station := false, ""
+	for _, t := range types {
+		switch t {
+		case "free_floating":
+			free = true
+		case "roundtrip", "roundtrip_station":
+			station = "roundtrip_station"
+		case "station", "any_station":
+			station = "any_station"
+		default:
+			return ""
+		}
+	}
+	switch {
+	case free && station != "":
+		return "hybrid"
+	case free:
+		return "free_floating"
+	}
+	return station
+}
+
 // EcoLabel contains eco label data.
 type EcoLabel struct {
 	CountryCode string `json:"country_code"`
@@ -356,8 +516,8 @@ type Vehicle struct {
 	RentalURIs         *RentalURIs `json:"rental_uris,omitempty"`
 	VehicleTypeID      string    `json:"vehicle_type_id,omitempty"`
 	LastReported       Timestamp `json:"last_reported,omitempty"`
-	CurrentRangeMeters float64   `json:"current_range_meters,omitempty"`
-	CurrentFuelPercent float64   `json:"current_fuel_percent,omitempty"`
+	CurrentRangeMeters *float64  `json:"current_range_meters,omitempty"`
+	CurrentFuelPercent *float64  `json:"current_fuel_percent,omitempty"`
 	StationID          string    `json:"station_id,omitempty"`
 	HomeStationID      string    `json:"home_station_id,omitempty"`
 	PricingPlanID      string    `json:"pricing_plan_id,omitempty"`
@@ -388,14 +548,14 @@ type PricingPlansData struct {
 type PricingPlan struct {
 	PlanID                   string      `json:"plan_id"`
 	URL                      string      `json:"url,omitempty"`
-	Name                     interface{} `json:"name"`
+	Name                     LocalizedText `json:"name"`
 	Currency                 string      `json:"currency"`
 	Price                    float64     `json:"price"`
 	IsTaxable                bool        `json:"is_taxable"`
-	Description              interface{} `json:"description"`
+	Description              LocalizedText `json:"description"`
 	PerKmPricing             []PricingSegment `json:"per_km_pricing,omitempty"`
 	PerMinPricing            []PricingSegment `json:"per_min_pricing,omitempty"`
-	SurgePricing             bool        `json:"surge_pricing,omitempty"`
+	SurgePricing             *bool       `json:"surge_pricing,omitempty"`
 	ReservationPriceFlatRate float64     `json:"reservation_price_flat_rate,omitempty"`
 	ReservationPricePerMin   float64     `json:"reservation_price_per_min,omitempty"`
 }
@@ -435,7 +595,7 @@ type GeoJSONFeature struct {
 
 // GeofencingProperties describes a geofence feature.
 type GeofencingProperties struct {
-	Name  interface{}      `json:"name,omitempty"`
+	Name  LocalizedText    `json:"name,omitempty"`
 	Start string           `json:"start,omitempty"`
 	End   string           `json:"end,omitempty"`
 	Rules []GeofencingRule `json:"rules,omitempty"`
@@ -447,8 +607,8 @@ type GeofencingRule struct {
 	RideStartAllowed  bool     `json:"ride_start_allowed"`
 	RideEndAllowed    bool     `json:"ride_end_allowed"`
 	RideThroughAllowed bool    `json:"ride_through_allowed"`
-	MaximumSpeedKph   int      `json:"maximum_speed_kph,omitempty"`
-	StationParking    bool     `json:"station_parking,omitempty"`
+	MaximumSpeedKph   *int     `json:"maximum_speed_kph,omitempty"`
+	StationParking    *bool    `json:"station_parking,omitempty"`
 }
 
 // SystemRegions represents system_regions.json.
@@ -465,7 +625,7 @@ type RegionsData struct {
 // Region describes a system region.
 type Region struct {
 	RegionID string      `json:"region_id"`
-	Name     interface{} `json:"name"`
+	Name     LocalizedText `json:"name"`
 }
 
 // SystemAlerts represents system_alerts.json.
@@ -486,9 +646,9 @@ type Alert struct {
 	Times       []AlertTime  `json:"times,omitempty"`
 	StationIDs  []string     `json:"station_ids,omitempty"`
 	RegionIDs   []string     `json:"region_ids,omitempty"`
-	URL         interface{}  `json:"url,omitempty"`
-	Summary     interface{}  `json:"summary"`
-	Description interface{}  `json:"description,omitempty"`
+	URL         LocalizedText `json:"url,omitempty"`
+	Summary     LocalizedText `json:"summary"`
+	Description LocalizedText `json:"description,omitempty"`
 	LastUpdated Timestamp    `json:"last_updated,omitempty"`
 }
 
@@ -0,0 +1,49 @@
+package gbfs
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+// TestTimestampEncodings checks tolerated encodings decode to the right time
+// and are flagged as non-conformant.
+func TestTimestampEncodings(t *testing.T) {
+	want := time.Unix(1700000000, 0)
+	tests := []struct {
+		input      string
+		conformant bool
+		isUnix     bool
+	}{
+		{`1700000000`, true, true},
+		{`"2023-11-14T22:13:20Z"`, true, false},
+		{`1700000000000`, false, true},
+		{`"1700000000"`, false, true},
+		{`"1700000000000"`, false, true},
+		{`1700000000.0`, false, true},
+	}
+
+	for _, tt := range tests {
+		var ts Timestamp
+		if err := json.Unmarshal([]byte(tt.input), &ts); err != nil {
+			t.Errorf("%s: %v", tt.input, err)
+			continue
+		}
+		if !ts.Time.Equal(want) {
+			t.Errorf("%s: got %v, want %v", tt.input, ts.Time, want)
+		}
+		if ts.Conformant() != tt.conformant {
+			t.Errorf("%s: conformant = %v, want %v (issue %q)", tt.input, ts.Conformant(), tt.conformant, ts.Issue)
+		}
+		if ts.IsUnix != tt.isUnix {
+			t.Errorf("%s: IsUnix = %v, want %v", tt.input, ts.IsUnix, tt.isUnix)
+		}
+	}
+
+	for _, bad := range []string{`"yesterday"`, `true`, `{}`} {
+		var ts Timestamp
+		if err := json.Unmarshal([]byte(bad), &ts); err == nil {
+			t.Errorf("%s: expected an error", bad)
+		}
+	}
+}
@@ -0,0 +1,194 @@
+package gbfsclient
+
+import (
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gbfs-validator-go/pkg/gbfs"
+)
+
+// moveThreshold is how far a vehicle must travel, in meters, to be reported
+// as moved rather than jittering in place.
+const moveThreshold = 1.0
+
+// ChangeKind names a difference between consecutive refreshes.
+type ChangeKind string
+
+const (
+	// ChangeAdded is a vehicle or station that was not in the previous copy.
+	ChangeAdded ChangeKind = "added"
+	// ChangeRemoved is a vehicle or station missing from the new copy.
+	ChangeRemoved ChangeKind = "removed"
+	// ChangeMoved is a vehicle whose position or station changed.
+	ChangeMoved ChangeKind = "moved"
+	// ChangeUpdated is a reservation, disabled, or availability change.
+	ChangeUpdated ChangeKind = "updated"
+)
+
+// VehicleChange describes one vehicle's difference. Before is nil for added
+// vehicles and After is nil for removed ones. Feeds that rotate vehicle IDs
+// report rotations as a removal plus an addition.
+type VehicleChange struct {
+	Kind      ChangeKind    `json:"kind"`
+	VehicleID string        `json:"vehicleId"`
+	Before    *gbfs.Vehicle `json:"before,omitempty"`
+	After     *gbfs.Vehicle `json:"after,omitempty"`
+}
+
+// StationChange describes one station's status difference.
+type StationChange struct {
+	Kind      ChangeKind               `json:"kind"`
+	StationID string                   `json:"stationId"`
+	Before    *gbfs.StationStatusEntry `json:"before,omitempty"`
+	After     *gbfs.StationStatusEntry `json:"after,omitempty"`
+}
+
+// Changes is the set of differences found by one refresh.
+type Changes struct {
+	At       time.Time       `json:"at"`
+	Vehicles []VehicleChange `json:"vehicles,omitempty"`
+	Stations []StationChange `json:"stations,omitempty"`
+}
+
+// Empty reports whether nothing changed.
+func (c *Changes) Empty() bool {
+	return len(c.Vehicles) == 0 && len(c.Stations) == 0
+}
+
+// subscribers holds change callbacks keyed by subscription ID.
+type subscribers struct {
+	mu   sync.Mutex
+	next int
+	fns  map[int]func(Changes)
+}
+
+// Subscribe calls fn with the differences found by each later refresh of
+// station_status and vehicle_status (or free_bike_status). Refreshes that
+// change nothing are not reported. The returned function unsubscribes.
+func (c *Client) Subscribe(fn func(Changes)) func() {
+	c.subs.mu.Lock()
+	defer c.subs.mu.Unlock()
+	if c.subs.fns == nil {
+		c.subs.fns = make(map[int]func(Changes))
+	}
+	id := c.subs.next
+	c.subs.next++
+	c.subs.fns[id] = fn
+
+	return func() {
+		c.subs.mu.Lock()
+		defer c.subs.mu.Unlock()
+		delete(c.subs.fns, id)
+	}
+}
+
+// notify delivers changes to every subscriber.
+func (c *Client) notify(changes Changes) {
+	if changes.Empty() {
+		return
+	}
+	c.subs.mu.Lock()
+	fns := make([]func(Changes), 0, len(c.subs.fns))
+	for _, fn := range c.subs.fns {
+		fns = append(fns, fn)
+	}
+	c.subs.mu.Unlock()
+
+	for _, fn := range fns {
+		fn(changes)
+	}
+}
+
+// diff records the differences between two copies of a file in changes.
+func diff(name string, before, after interface{}, changes *Changes) {
+	switch name {
+	case "station_status":
+		b, okB := before.(*gbfs.StationStatus)
+		a, okA := after.(*gbfs.StationStatus)
+		if okB && okA {
+			changes.Stations = append(changes.Stations, DiffStationStatus(b.Data.Stations, a.Data.Stations)...)
+		}
+	case "vehicle_status", "free_bike_status":
+		b, okB := before.(*gbfs.VehicleStatus)
+		a, okA := after.(*gbfs.VehicleStatus)
+		if okB && okA {
+			changes.Vehicles = append(changes.Vehicles, DiffVehicles(b.Data.GetVehicles(), a.Data.GetVehicles())...)
+		}
+	}
+}
+
+// DiffVehicles compares two vehicle lists by ID, ordered by vehicle ID.
+func DiffVehicles(before, after []gbfs.Vehicle) []VehicleChange {
+	old := make(map[string]gbfs.Vehicle, len(before))
+	for _, v := range before {
+		old[v.GetID()] = v
+	}
+
+	var changes []VehicleChange
+	seen := make(map[string]bool, len(after))
+	for _, v := range after {
+		v := v
+		id := v.GetID()
+		seen[id] = true
+
+		prev, ok := old[id]
+		switch {
+		case !ok:
+			changes = append(changes, VehicleChange{Kind: ChangeAdded, VehicleID: id, After: &v})
+		case prev.StationID != v.StationID || Distance(prev.Lat, prev.Lon, v.Lat, v.Lon) > moveThreshold:
+			changes = append(changes, VehicleChange{Kind: ChangeMoved, VehicleID: id, Before: &prev, After: &v})
+		case prev.IsReserved != v.IsReserved || prev.IsDisabled != v.IsDisabled ||
+			!reflect.DeepEqual(prev.CurrentRangeMeters, v.CurrentRangeMeters):
+			changes = append(changes, VehicleChange{Kind: ChangeUpdated, VehicleID: id, Before: &prev, After: &v})
+		}
+	}
+	for id, v := range old {
+		if !seen[id] {
+			v := v
+			changes = append(changes, VehicleChange{Kind: ChangeRemoved, VehicleID: id, Before: &v})
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool { return changes[i].VehicleID < changes[j].VehicleID })
+	return changes
+}
+
+// DiffStationStatus compares two station status lists by station ID,
+// reporting availability and renting/returning changes, ordered by station ID.
+func DiffStationStatus(before, after []gbfs.StationStatusEntry) []StationChange {
+	old := make(map[string]gbfs.StationStatusEntry, len(before))
+	for _, s := range before {
+		old[s.StationID] = s
+	}
+
+	var changes []StationChange
+	seen := make(map[string]bool, len(after))
+	for _, s := range after {
+		s := s
+		seen[s.StationID] = true
+
+		prev, ok := old[s.StationID]
+		switch {
+		case !ok:
+			changes = append(changes, StationChange{Kind: ChangeAdded, StationID: s.StationID, After: &s})
+		case prev.Available() != s.Available() ||
+			!reflect.DeepEqual(prev.NumDocksAvailable, s.NumDocksAvailable) ||
+			!reflect.DeepEqual(prev.IsInstalled, s.IsInstalled) ||
+			!reflect.DeepEqual(prev.IsRenting, s.IsRenting) ||
+			!reflect.DeepEqual(prev.IsReturning, s.IsReturning) ||
+			!reflect.DeepEqual(prev.VehicleTypesAvailable, s.VehicleTypesAvailable):
+			changes = append(changes, StationChange{Kind: ChangeUpdated, StationID: s.StationID, Before: &prev, After: &s})
+		}
+	}
+	for id, s := range old {
+		if !seen[id] {
+			s := s
+			changes = append(changes, StationChange{Kind: ChangeRemoved, StationID: id, Before: &s})
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool { return changes[i].StationID < changes[j].StationID })
+	return changes
+}
@@ -0,0 +1,117 @@
+package gbfsclient
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gbfs-validator-go/pkg/gbfs"
+)
+
+// TestSubscribe checks a refresh reports vehicle and station differences.
+func TestSubscribe(t *testing.T) {
+	b := gbfs.NewBuilder(gbfs.V3_0, "en").WithTTL(60)
+
+	var mu sync.Mutex
+	vehicles := []gbfs.Vehicle{
+		{VehicleID: "v1", Lat: 48.85, Lon: 2.35},
+		{VehicleID: "v2", Lat: 48.86, Lon: 2.36},
+		{VehicleID: "v3", Lat: 48.87, Lon: 2.37},
+	}
+	stations := []gbfs.StationStatusEntry{
+		{StationID: "s1", NumVehiclesAvailable: gbfs.Ptr(3), NumDocksAvailable: gbfs.Ptr(5)},
+		{StationID: "s2", NumVehiclesAvailable: gbfs.Ptr(1), NumDocksAvailable: gbfs.Ptr(7)},
+	}
+
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		var doc interface{}
+		switch strings.TrimPrefix(r.URL.Path, "/") {
+		case "gbfs.json":
+			doc = b.GBFS([]gbfs.FeedInfo{
+				{Name: "vehicle_status", URL: server.URL + "/vehicle_status.json"},
+				{Name: "station_status", URL: server.URL + "/station_status.json"},
+			})
+		case "vehicle_status.json":
+			doc = b.VehicleStatus(vehicles)
+		case "station_status.json":
+			doc = b.StationStatus(stations)
+		default:
+			http.NotFound(w, r)
+			return
+		}
+		data, _ := b.Marshal(doc)
+		w.Write(data)
+	}))
+	defer server.Close()
+
+	now := time.Now()
+	c := New(server.URL + "/gbfs.json")
+	c.now = func() time.Time { return now }
+
+	var received []Changes
+	unsubscribe := c.Subscribe(func(ch Changes) { received = append(received, ch) })
+
+	if err := c.Load(context.Background()); err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if len(received) != 0 {
+		t.Fatalf("initial load should not report changes, got %+v", received)
+	}
+
+	mu.Lock()
+	vehicles = []gbfs.Vehicle{
+		{VehicleID: "v1", Lat: 48.85, Lon: 2.35, IsReserved: true},
+		{VehicleID: "v2", Lat: 48.90, Lon: 2.36},
+		{VehicleID: "v4", Lat: 48.88, Lon: 2.38},
+	}
+	stations[0].NumVehiclesAvailable = gbfs.Ptr(2)
+	mu.Unlock()
+
+	now = now.Add(time.Minute)
+	if _, err := c.Refresh(context.Background()); err != nil {
+		t.Fatalf("Refresh failed: %v", err)
+	}
+	if len(received) != 1 {
+		t.Fatalf("expected one change set, got %d", len(received))
+	}
+
+	got := make(map[string]ChangeKind)
+	for _, v := range received[0].Vehicles {
+		got[v.VehicleID] = v.Kind
+	}
+	for _, s := range received[0].Stations {
+		got[s.StationID] = s.Kind
+	}
+	want := map[string]ChangeKind{
+		"v1": ChangeUpdated,
+		"v2": ChangeMoved,
+		"v3": ChangeRemoved,
+		"v4": ChangeAdded,
+		"s1": ChangeUpdated,
+	}
+	for id, kind := range want {
+		if got[id] != kind {
+			t.Errorf("%s: expected %s, got %q", id, kind, got[id])
+		}
+	}
+	if len(got) != len(want) {
+		t.Errorf("unexpected changes %v", got)
+	}
+
+	unsubscribe()
+	now = now.Add(time.Minute)
+	mu.Lock()
+	stations[1].IsRenting = gbfs.Ptr(false)
+	mu.Unlock()
+	c.Refresh(context.Background())
+	if len(received) != 1 {
+		t.Errorf("expected no delivery after unsubscribe, got %d change sets", len(received))
+	}
+}
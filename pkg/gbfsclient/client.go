@@ -0,0 +1,276 @@
+// Package gbfsclient loads a GBFS system into typed structs and keeps it
+// fresh by refetching each file when its ttl expires.
+package gbfsclient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gbfs-validator-go/pkg/fetcher"
+	"github.com/gbfs-validator-go/pkg/gbfs"
+)
+
+// defaultMinInterval keeps ttl 0 files from being refetched in a tight loop.
+const defaultMinInterval = 10 * time.Second
+
+// Client holds the latest copy of every file in a system.
+type Client struct {
+	url         string
+	fetcher     *fetcher.Fetcher
+	language    string
+	minInterval time.Duration
+	now         func() time.Time
+
+	mu      sync.RWMutex
+	version string
+	feeds   map[string]string
+	files   map[string]*file
+
+	subs subscribers
+}
+
+// file is a decoded document and when it must be refetched.
+type file struct {
+	doc     interface{}
+	expires time.Time
+}
+
+// Option configures a Client.
+type Option func(*Client)
+
+// WithFetcher sets the fetcher, e.g. one carrying authentication.
+func WithFetcher(f *fetcher.Fetcher) Option {
+	return func(c *Client) {
+		c.fetcher = f
+	}
+}
+
+// WithLanguage selects a v1/v2 language block. The first block in sorted
+// order is used by default.
+func WithLanguage(lang string) Option {
+	return func(c *Client) {
+		c.language = lang
+	}
+}
+
+// WithMinInterval sets the shortest time between refetches of a file,
+// regardless of its ttl.
+func WithMinInterval(d time.Duration) Option {
+	return func(c *Client) {
+		c.minInterval = d
+	}
+}
+
+// New returns a Client for the system whose gbfs.json is at gbfsURL. Call
+// Load before reading from it.
+func New(gbfsURL string, opts ...Option) *Client {
+	c := &Client{
+		url:         gbfsURL,
+		minInterval: defaultMinInterval,
+		now:         time.Now,
+		files:       make(map[string]*file),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	if c.fetcher == nil {
+		c.fetcher = fetcher.New()
+	}
+	return c
+}
+
+// Load fetches gbfs.json and every file it lists. Files that fail to load
+// are reported in the returned error; the rest remain available.
+func (c *Client) Load(ctx context.Context) error {
+	if err := c.loadAutodiscovery(ctx); err != nil {
+		return err
+	}
+
+	c.mu.RLock()
+	names := make([]string, 0, len(c.feeds))
+	for name := range c.feeds {
+		names = append(names, name)
+	}
+	c.mu.RUnlock()
+
+	return c.fetchFiles(ctx, names)
+}
+
+// Refresh refetches gbfs.json and files whose ttl has expired, returning the
+// names of the files it refetched.
+func (c *Client) Refresh(ctx context.Context) ([]string, error) {
+	c.mu.RLock()
+	discoveryDue := c.expired("gbfs", c.now())
+	c.mu.RUnlock()
+	if discoveryDue {
+		if err := c.loadAutodiscovery(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	now := c.now()
+	c.mu.RLock()
+	var names []string
+	for name := range c.feeds {
+		if c.expired(name, now) {
+			names = append(names, name)
+		}
+	}
+	c.mu.RUnlock()
+
+	sort.Strings(names)
+	return names, c.fetchFiles(ctx, names)
+}
+
+// Run refreshes expired files until ctx is done, calling onRefresh after
+// each refresh that fetched something.
+func (c *Client) Run(ctx context.Context, onRefresh func(names []string, err error)) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(c.untilNextExpiry()):
+		}
+
+		names, err := c.Refresh(ctx)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if onRefresh != nil && (len(names) > 0 || err != nil) {
+			onRefresh(names, err)
+		}
+	}
+}
+
+// untilNextExpiry returns how long until the earliest file expires.
+func (c *Client) untilNextExpiry() time.Duration {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	next := c.now().Add(c.minInterval)
+	for _, f := range c.files {
+		if f.expires.Before(next) {
+			next = f.expires
+		}
+	}
+	return max(next.Sub(c.now()), 0)
+}
+
+// expired reports whether a file is missing or past its ttl. The caller
+// holds c.mu.
+func (c *Client) expired(name string, now time.Time) bool {
+	f, ok := c.files[name]
+	return !ok || !now.Before(f.expires)
+}
+
+// loadAutodiscovery fetches gbfs.json and records the feed URLs.
+func (c *Client) loadAutodiscovery(ctx context.Context) error {
+	var feed gbfs.GBFSFeed
+	result := c.fetcher.FetchJSON(ctx, c.url, &feed)
+	if result.Error != nil {
+		return fmt.Errorf("gbfs.json: %w", result.Error)
+	}
+	if !result.Exists {
+		return fmt.Errorf("gbfs.json: not found at %s", fetcher.RedactURL(c.url))
+	}
+
+	lang := c.language
+	if lang == "" {
+		if langs := feed.Data.LanguageCodes(); len(langs) > 0 {
+			lang = langs[0]
+		}
+	}
+	feeds := make(map[string]string)
+	for _, f := range feed.Data.FeedsFor(lang) {
+		if _, known := gbfs.NewFile(f.Name); known && f.Name != "gbfs" {
+			feeds[f.Name] = f.URL
+		}
+	}
+	if len(feeds) == 0 {
+		return fmt.Errorf("gbfs.json: no feeds listed for language %q", lang)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.version = feed.Version
+	if c.version == "" {
+		c.version = string(gbfs.V1_0)
+	}
+	c.feeds = feeds
+	c.files["gbfs"] = &file{doc: &feed, expires: c.expiry(feed.TTL)}
+	for name := range c.files {
+		if _, listed := feeds[name]; !listed && name != "gbfs" {
+			delete(c.files, name)
+		}
+	}
+	return nil
+}
+
+// fetchFiles fetches and decodes the named files concurrently, then reports
+// what changed to subscribers.
+func (c *Client) fetchFiles(ctx context.Context, names []string) error {
+	var wg sync.WaitGroup
+	var changesMu sync.Mutex
+	changes := Changes{At: c.now()}
+	errs := make([]error, len(names))
+	for i, name := range names {
+		c.mu.RLock()
+		url := c.feeds[name]
+		c.mu.RUnlock()
+
+		wg.Add(1)
+		go func(i int, name, url string) {
+			defer wg.Done()
+			prev, doc, err := c.fetchFile(ctx, name, url)
+			errs[i] = err
+			if prev != nil && doc != nil {
+				changesMu.Lock()
+				diff(name, prev, doc, &changes)
+				changesMu.Unlock()
+			}
+		}(i, name, url)
+	}
+	wg.Wait()
+
+	c.notify(changes)
+	return errors.Join(errs...)
+}
+
+// fetchFile fetches, decodes, and stores one file, returning the copy it
+// replaced and the new one.
+func (c *Client) fetchFile(ctx context.Context, name, url string) (interface{}, interface{}, error) {
+	result := c.fetcher.Fetch(ctx, url)
+	if result.Error != nil {
+		return nil, nil, fmt.Errorf("%s: %w", name, result.Error)
+	}
+	if !result.Exists {
+		return nil, nil, fmt.Errorf("%s: not found at %s", name, fetcher.RedactURL(url))
+	}
+
+	doc, err := gbfs.Decode(name, result.Body)
+	if err != nil {
+		return nil, nil, err
+	}
+	var header gbfs.CommonHeader
+	json.Unmarshal(result.Body, &header)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var prev interface{}
+	if f, ok := c.files[name]; ok {
+		prev = f.doc
+	}
+	c.files[name] = &file{doc: doc, expires: c.expiry(header.TTL)}
+	return prev, doc, nil
+}
+
+// expiry returns when a file fetched now with the given ttl in seconds must
+// be refetched.
+func (c *Client) expiry(ttl int) time.Time {
+	return c.now().Add(max(time.Duration(ttl)*time.Second, c.minInterval))
+}
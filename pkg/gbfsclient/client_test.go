@@ -0,0 +1,121 @@
+package gbfsclient
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gbfs-validator-go/pkg/genfeed"
+)
+
+// countingServer serves a generated feed and counts requests per path.
+func countingServer(t *testing.T, ver string) (*httptest.Server, *genfeed.Feed, func(string) int) {
+	t.Helper()
+	cfg := genfeed.DefaultConfig()
+	cfg.Version = ver
+	cfg.Stations, cfg.Vehicles, cfg.Geofences = 20, 100, 2
+	feed, err := genfeed.Generate(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var mu sync.Mutex
+	counts := make(map[string]int)
+	handler := feed.Handler()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		counts[strings.TrimPrefix(r.URL.Path, "/")]++
+		mu.Unlock()
+		handler.ServeHTTP(w, r)
+	}))
+	t.Cleanup(server.Close)
+
+	return server, feed, func(path string) int {
+		mu.Lock()
+		defer mu.Unlock()
+		return counts[path]
+	}
+}
+
+// TestLoadAndLookups checks a loaded system exposes typed files and lookups.
+func TestLoadAndLookups(t *testing.T) {
+	for _, ver := range []string{"2.3", "3.0"} {
+		t.Run(ver, func(t *testing.T) {
+			server, _, _ := countingServer(t, ver)
+			c := New(server.URL + "/gbfs.json")
+			if err := c.Load(context.Background()); err != nil {
+				t.Fatalf("Load failed: %v", err)
+			}
+
+			if c.Version() != ver {
+				t.Errorf("expected version %s, got %s", ver, c.Version())
+			}
+			if c.SystemInformation() == nil || c.VehicleStatus() == nil || c.StationStatus() == nil {
+				t.Fatalf("expected core files to be loaded, got %v", c.Files())
+			}
+
+			first := c.StationInformation().Data.Stations[0]
+			station, ok := c.StationByID(first.StationID)
+			if !ok || station.Name.Default() != first.Name.Default() {
+				t.Errorf("StationByID(%q) = %+v, %v", first.StationID, station, ok)
+			}
+			if _, ok := c.StationStatusByID(first.StationID); !ok {
+				t.Errorf("StationStatusByID(%q) not found", first.StationID)
+			}
+			if _, ok := c.StationByID("missing"); ok {
+				t.Error("expected unknown station lookup to fail")
+			}
+
+			vehicle := c.VehicleStatus().Data.GetVehicles()[0]
+			near := c.VehiclesNear(vehicle.Lat, vehicle.Lon, 500)
+			if len(near) == 0 || near[0].GetID() != vehicle.GetID() {
+				t.Errorf("expected %s nearest to its own position, got %d vehicles", vehicle.GetID(), len(near))
+			}
+			for _, v := range near {
+				if d := Distance(vehicle.Lat, vehicle.Lon, v.Lat, v.Lon); d > 500 {
+					t.Errorf("vehicle %s is %.0fm away", v.GetID(), d)
+				}
+			}
+		})
+	}
+}
+
+// TestRefreshRespectsTTL checks files are only refetched once their ttl has
+// passed.
+func TestRefreshRespectsTTL(t *testing.T) {
+	server, _, count := countingServer(t, "3.0")
+
+	now := time.Now()
+	c := New(server.URL + "/gbfs.json")
+	c.now = func() time.Time { return now }
+	if err := c.Load(context.Background()); err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+
+	names, err := c.Refresh(context.Background())
+	if err != nil || len(names) != 0 {
+		t.Errorf("expected nothing to refresh before ttl, got %v (%v)", names, err)
+	}
+	if n := count("station_status.json"); n != 1 {
+		t.Errorf("expected 1 station_status request, got %d", n)
+	}
+
+	now = now.Add(61 * time.Second)
+	names, err = c.Refresh(context.Background())
+	if err != nil {
+		t.Fatalf("Refresh failed: %v", err)
+	}
+	if len(names) == 0 {
+		t.Error("expected files to refresh after ttl")
+	}
+	if n := count("station_status.json"); n != 2 {
+		t.Errorf("expected 2 station_status requests, got %d", n)
+	}
+	if n := count("gbfs.json"); n != 2 {
+		t.Errorf("expected gbfs.json to be refetched, got %d requests", n)
+	}
+}
@@ -0,0 +1,180 @@
+package gbfsclient
+
+import (
+	"math"
+	"sort"
+
+	"github.com/gbfs-validator-go/pkg/gbfs"
+)
+
+// earthRadiusMeters is the mean Earth radius used for distances.
+const earthRadiusMeters = 6371008.8
+
+// doc returns the latest decoded copy of a file, or nil.
+func (c *Client) doc(name string) interface{} {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if f, ok := c.files[name]; ok {
+		return f.doc
+	}
+	return nil
+}
+
+// Version returns the version declared by gbfs.json.
+func (c *Client) Version() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.version
+}
+
+// Files returns the names of the files currently loaded, sorted.
+func (c *Client) Files() []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	names := make([]string, 0, len(c.files))
+	for name := range c.files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Autodiscovery returns gbfs.json.
+func (c *Client) Autodiscovery() *gbfs.GBFSFeed {
+	feed, _ := c.doc("gbfs").(*gbfs.GBFSFeed)
+	return feed
+}
+
+// SystemInformation returns system_information.json, or nil if not loaded.
+func (c *Client) SystemInformation() *gbfs.SystemInformation {
+	doc, _ := c.doc("system_information").(*gbfs.SystemInformation)
+	return doc
+}
+
+// VehicleTypes returns vehicle_types.json, or nil if not loaded.
+func (c *Client) VehicleTypes() *gbfs.VehicleTypes {
+	doc, _ := c.doc("vehicle_types").(*gbfs.VehicleTypes)
+	return doc
+}
+
+// StationInformation returns station_information.json, or nil if not loaded.
+func (c *Client) StationInformation() *gbfs.StationInformation {
+	doc, _ := c.doc("station_information").(*gbfs.StationInformation)
+	return doc
+}
+
+// StationStatus returns station_status.json, or nil if not loaded.
+func (c *Client) StationStatus() *gbfs.StationStatus {
+	doc, _ := c.doc("station_status").(*gbfs.StationStatus)
+	return doc
+}
+
+// VehicleStatus returns vehicle_status.json or, before v3,
+// free_bike_status.json, or nil if neither is loaded.
+func (c *Client) VehicleStatus() *gbfs.VehicleStatus {
+	if doc, ok := c.doc("vehicle_status").(*gbfs.VehicleStatus); ok {
+		return doc
+	}
+	doc, _ := c.doc("free_bike_status").(*gbfs.VehicleStatus)
+	return doc
+}
+
+// SystemPricingPlans returns system_pricing_plans.json, or nil if not loaded.
+func (c *Client) SystemPricingPlans() *gbfs.SystemPricingPlans {
+	doc, _ := c.doc("system_pricing_plans").(*gbfs.SystemPricingPlans)
+	return doc
+}
+
+// SystemAlerts returns system_alerts.json, or nil if not loaded.
+func (c *Client) SystemAlerts() *gbfs.SystemAlerts {
+	doc, _ := c.doc("system_alerts").(*gbfs.SystemAlerts)
+	return doc
+}
+
+// SystemRegions returns system_regions.json, or nil if not loaded.
+func (c *Client) SystemRegions() *gbfs.SystemRegions {
+	doc, _ := c.doc("system_regions").(*gbfs.SystemRegions)
+	return doc
+}
+
+// GeofencingZones returns geofencing_zones.json, or nil if not loaded.
+func (c *Client) GeofencingZones() *gbfs.GeofencingZones {
+	doc, _ := c.doc("geofencing_zones").(*gbfs.GeofencingZones)
+	return doc
+}
+
+// StationByID returns a station from station_information.json.
+func (c *Client) StationByID(id string) (gbfs.Station, bool) {
+	if si := c.StationInformation(); si != nil {
+		for _, s := range si.Data.Stations {
+			if s.StationID == id {
+				return s, true
+			}
+		}
+	}
+	return gbfs.Station{}, false
+}
+
+// StationStatusByID returns a station's entry from station_status.json.
+func (c *Client) StationStatusByID(id string) (gbfs.StationStatusEntry, bool) {
+	if ss := c.StationStatus(); ss != nil {
+		for _, s := range ss.Data.Stations {
+			if s.StationID == id {
+				return s, true
+			}
+		}
+	}
+	return gbfs.StationStatusEntry{}, false
+}
+
+// VehicleTypeByID returns a vehicle type from vehicle_types.json.
+func (c *Client) VehicleTypeByID(id string) (gbfs.VehicleType, bool) {
+	if vt := c.VehicleTypes(); vt != nil {
+		for _, t := range vt.Data.VehicleTypes {
+			if t.VehicleTypeID == id {
+				return t, true
+			}
+		}
+	}
+	return gbfs.VehicleType{}, false
+}
+
+// VehiclesNear returns vehicles with a position within radius meters of
+// lat/lon, nearest first.
+func (c *Client) VehiclesNear(lat, lon, radius float64) []gbfs.Vehicle {
+	vs := c.VehicleStatus()
+	if vs == nil {
+		return nil
+	}
+
+	type near struct {
+		vehicle  gbfs.Vehicle
+		distance float64
+	}
+	var found []near
+	for _, v := range vs.Data.GetVehicles() {
+		if v.Lat == 0 && v.Lon == 0 {
+			continue
+		}
+		if d := Distance(lat, lon, v.Lat, v.Lon); d <= radius {
+			found = append(found, near{vehicle: v, distance: d})
+		}
+	}
+	sort.SliceStable(found, func(i, j int) bool { return found[i].distance < found[j].distance })
+
+	vehicles := make([]gbfs.Vehicle, len(found))
+	for i, n := range found {
+		vehicles[i] = n.vehicle
+	}
+	return vehicles
+}
+
+// Distance returns the great-circle distance in meters between two points.
+func Distance(lat1, lon1, lat2, lon2 float64) float64 {
+	toRad := func(deg float64) float64 { return deg * math.Pi / 180 }
+	dLat := toRad(lat2 - lat1)
+	dLon := toRad(lon2 - lon1)
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(toRad(lat1))*math.Cos(toRad(lat2))*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadiusMeters * math.Asin(math.Sqrt(a))
+}
@@ -0,0 +1,444 @@
+// Package genfeed generates synthetic GBFS feeds of configurable size for
+// benchmarking the validator and downstream consumers.
+package genfeed
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/rand"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gbfs-validator-go/pkg/fetcher"
+	"github.com/gbfs-validator-go/pkg/scaffold"
+	"github.com/gbfs-validator-go/pkg/version"
+)
+
+// BBox is a latitude/longitude bounding box.
+type BBox struct {
+	MinLat float64 `json:"minLat"`
+	MinLon float64 `json:"minLon"`
+	MaxLat float64 `json:"maxLat"`
+	MaxLon float64 `json:"maxLon"`
+}
+
+// ParseBBox parses "minLat,minLon,maxLat,maxLon".
+func ParseBBox(s string) (BBox, error) {
+	var b BBox
+	if _, err := fmt.Sscanf(strings.ReplaceAll(s, " ", ""), "%f,%f,%f,%f", &b.MinLat, &b.MinLon, &b.MaxLat, &b.MaxLon); err != nil {
+		return BBox{}, fmt.Errorf("bbox must be minLat,minLon,maxLat,maxLon: %w", err)
+	}
+	if b.MinLat >= b.MaxLat || b.MinLon >= b.MaxLon {
+		return BBox{}, fmt.Errorf("bbox minimums must be below maximums")
+	}
+	return b, nil
+}
+
+// Config sizes a synthetic feed.
+type Config struct {
+	Version   string
+	Stations  int
+	Vehicles  int
+	Geofences int
+	BBox      BBox
+	// Seed makes generation reproducible; equal configs yield equal feeds.
+	Seed int64
+	// Now is the last_updated time written to every file; zero means
+	// time.Now().
+	Now time.Time
+}
+
+// DefaultConfig returns a mid-sized v3.0 feed over central Paris.
+func DefaultConfig() Config {
+	return Config{
+		Version:   "3.0",
+		Stations:  500,
+		Vehicles:  2000,
+		Geofences: 20,
+		BBox:      BBox{MinLat: 48.80, MinLon: 2.25, MaxLat: 48.91, MaxLon: 2.42},
+		Seed:      1,
+	}
+}
+
+// Feed is a generated set of GBFS files.
+type Feed struct {
+	Version string
+	// Files holds every file except gbfs.json, keyed by feed name.
+	Files map[string][]byte
+
+	now time.Time
+}
+
+// vehicleType describes one generated vehicle type.
+type vehicleType struct {
+	id         string
+	name       string
+	formFactor string
+	propulsion string
+	maxRange   int
+}
+
+// vehicleTypes lists the vehicle types every generated feed offers.
+var vehicleTypes = []vehicleType{
+	{id: "bike", name: "Bike", formFactor: "bicycle", propulsion: "human"},
+	{id: "ebike", name: "E-Bike", formFactor: "bicycle", propulsion: "electric_assist", maxRange: 60000},
+	{id: "scooter", name: "Scooter", formFactor: "scooter_standing", propulsion: "electric", maxRange: 40000},
+}
+
+// generator holds state while rendering a feed.
+type generator struct {
+	cfg Config
+	rnd *rand.Rand
+	now time.Time
+}
+
+// Generate builds a synthetic feed. Versions before 2.1 are rejected because
+// they lack vehicle types and geofencing.
+func Generate(cfg Config) (*Feed, error) {
+	if _, ok := version.GetConfig(cfg.Version); !ok {
+		return nil, fmt.Errorf("unsupported GBFS version %q (supported: %v)", cfg.Version, version.SupportedVersions())
+	}
+	switch cfg.Version {
+	case "1.0", "1.1", "2.0":
+		return nil, fmt.Errorf("GBFS %s has no vehicle_types or geofencing_zones; use 2.1 or later", cfg.Version)
+	}
+	if cfg.Stations < 0 || cfg.Vehicles < 0 || cfg.Geofences < 0 {
+		return nil, fmt.Errorf("sizes must not be negative")
+	}
+	if cfg.BBox == (BBox{}) {
+		cfg.BBox = DefaultConfig().BBox
+	}
+	if cfg.Now.IsZero() {
+		cfg.Now = time.Now()
+	}
+
+	g := &generator{cfg: cfg, rnd: rand.New(rand.NewSource(cfg.Seed)), now: cfg.Now}
+	docs := map[string]interface{}{
+		"system_information":   g.systemInformation(),
+		"vehicle_types":        g.vehicleTypes(),
+		"system_pricing_plans": g.pricingPlans(),
+		"geofencing_zones":     g.geofencingZones(),
+	}
+	if cfg.Stations > 0 {
+		docs["station_information"], docs["station_status"] = g.stations()
+	}
+	if cfg.Vehicles > 0 {
+		docs[version.GetVehicleStatusFileName(cfg.Version)] = g.vehicles()
+	}
+
+	feed := &Feed{Version: cfg.Version, Files: make(map[string][]byte, len(docs)), now: cfg.Now}
+	for name, doc := range docs {
+		data, err := json.Marshal(doc)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		feed.Files[name] = data
+	}
+	return feed, nil
+}
+
+// GBFS renders gbfs.json with feed URLs under baseURL.
+func (f *Feed) GBFS(baseURL string) ([]byte, error) {
+	feeds := make(map[string]string, len(f.Files))
+	for name := range f.Files {
+		feeds[name] = fetcher.BuildFeedURL(baseURL, name)
+	}
+	return scaffold.GBFS(feeds, f.Version, "en", fetcher.BuildFeedURL(baseURL, "gbfs"), f.now)
+}
+
+// Handler serves gbfs.json, with URLs derived from the request host, and
+// every generated file at /<name>.json.
+func (f *Feed) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/"), ".json")
+
+		var data []byte
+		if name == "gbfs" || name == "" {
+			scheme := "http"
+			if r.TLS != nil {
+				scheme = "https"
+			}
+			var err error
+			data, err = f.GBFS(scheme + "://" + r.Host + "/")
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+		} else {
+			var ok bool
+			data, ok = f.Files[name]
+			if !ok {
+				http.NotFound(w, r)
+				return
+			}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(data)
+	})
+}
+
+// v3 reports whether the version uses v3 layouts.
+func (g *generator) v3() bool {
+	return version.IsV3OrLater(g.cfg.Version)
+}
+
+// document wraps data in the common header.
+func (g *generator) document(data map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"last_updated": g.timestamp(g.now),
+		"ttl":          60,
+		"version":      g.cfg.Version,
+		"data":         data,
+	}
+}
+
+// timestamp formats t for the version.
+func (g *generator) timestamp(t time.Time) interface{} {
+	if g.v3() {
+		return t.UTC().Format(time.RFC3339)
+	}
+	return t.Unix()
+}
+
+// text returns a plain string for v2 or a localized string list for v3.
+func (g *generator) text(value string) interface{} {
+	if g.v3() {
+		return []map[string]string{{"text": value, "language": "en"}}
+	}
+	return value
+}
+
+// point returns a random coordinate inside the bounding box.
+func (g *generator) point() (float64, float64) {
+	b := g.cfg.BBox
+	lat := b.MinLat + g.rnd.Float64()*(b.MaxLat-b.MinLat)
+	lon := b.MinLon + g.rnd.Float64()*(b.MaxLon-b.MinLon)
+	return round(lat, 6), round(lon, 6)
+}
+
+// systemInformation renders system_information.json.
+func (g *generator) systemInformation() map[string]interface{} {
+	data := map[string]interface{}{
+		"system_id": "synthetic",
+		"name":      g.text("Synthetic Mobility"),
+		"timezone":  "Europe/Paris",
+	}
+	if g.v3() {
+		data["languages"] = []string{"en"}
+		data["opening_hours"] = "Mo-Su 00:00-24:00"
+		data["feed_contact_email"] = "feed-contact@example.com"
+	} else {
+		data["language"] = "en"
+	}
+	return g.document(data)
+}
+
+// vehicleTypes renders vehicle_types.json.
+func (g *generator) vehicleTypes() map[string]interface{} {
+	types := make([]interface{}, 0, len(vehicleTypes))
+	for _, vt := range vehicleTypes {
+		formFactor := vt.formFactor
+		if formFactor == "scooter_standing" && !g.v3() && g.cfg.Version != "2.3" {
+			formFactor = "scooter"
+		}
+		t := map[string]interface{}{
+			"vehicle_type_id": vt.id,
+			"form_factor":     formFactor,
+			"propulsion_type": vt.propulsion,
+			"name":            g.text(vt.name),
+		}
+		if vt.maxRange > 0 {
+			t["max_range_meters"] = vt.maxRange
+		}
+		if g.v3() || g.cfg.Version == "2.3" {
+			t["default_pricing_plan_id"] = planFor(vt)
+		}
+		types = append(types, t)
+	}
+	return g.document(map[string]interface{}{"vehicle_types": types})
+}
+
+// planFor returns the pricing plan for a vehicle type.
+func planFor(vt vehicleType) string {
+	if vt.propulsion == "human" {
+		return "pay_as_you_go"
+	}
+	return "electric_per_minute"
+}
+
+// pricingPlans renders system_pricing_plans.json.
+func (g *generator) pricingPlans() map[string]interface{} {
+	return g.document(map[string]interface{}{
+		"plans": []interface{}{
+			map[string]interface{}{
+				"plan_id":     "pay_as_you_go",
+				"name":        g.text("Pay as you go"),
+				"currency":    "EUR",
+				"price":       1.0,
+				"is_taxable":  false,
+				"description": g.text("1 EUR to unlock."),
+			},
+			map[string]interface{}{
+				"plan_id":     "electric_per_minute",
+				"name":        g.text("Electric per minute"),
+				"currency":    "EUR",
+				"price":       1.0,
+				"is_taxable":  false,
+				"description": g.text("1 EUR to unlock, then 0.25 EUR per minute."),
+				"per_min_pricing": []interface{}{
+					map[string]interface{}{"start": 0, "rate": 0.25, "interval": 1},
+				},
+			},
+		},
+	})
+}
+
+// stations renders station_information.json and station_status.json.
+func (g *generator) stations() (map[string]interface{}, map[string]interface{}) {
+	info := make([]interface{}, 0, g.cfg.Stations)
+	status := make([]interface{}, 0, g.cfg.Stations)
+
+	for i := 0; i < g.cfg.Stations; i++ {
+		id := fmt.Sprintf("station_%d", i+1)
+		lat, lon := g.point()
+		capacity := 10 + g.rnd.Intn(31)
+		available := g.rnd.Intn(capacity + 1)
+		disabled := 0
+		if g.rnd.Intn(10) == 0 {
+			disabled = g.rnd.Intn(capacity-available+1) / 2
+		}
+		docks := capacity - available - disabled
+		installed := g.rnd.Intn(50) != 0
+		reported := g.now.Add(-time.Duration(g.rnd.Intn(300)) * time.Second)
+
+		info = append(info, map[string]interface{}{
+			"station_id": id,
+			"name":       g.text(fmt.Sprintf("Station %d", i+1)),
+			"lat":        lat,
+			"lon":        lon,
+			"capacity":   capacity,
+		})
+
+		s := map[string]interface{}{
+			"station_id":          id,
+			"num_docks_available": docks,
+			"is_installed":        installed,
+			"is_renting":          installed,
+			"is_returning":        installed,
+			"last_reported":       g.timestamp(reported),
+		}
+		if g.v3() {
+			s["num_vehicles_available"] = available
+			s["num_vehicles_disabled"] = disabled
+		} else {
+			s["num_bikes_available"] = available
+			s["num_bikes_disabled"] = disabled
+		}
+		status = append(status, s)
+	}
+
+	return g.document(map[string]interface{}{"stations": info}),
+		g.document(map[string]interface{}{"stations": status})
+}
+
+// vehicles renders vehicle_status.json or free_bike_status.json.
+func (g *generator) vehicles() map[string]interface{} {
+	idField, arrayField := "bike_id", "bikes"
+	if g.v3() {
+		idField, arrayField = "vehicle_id", "vehicles"
+	}
+
+	vehicles := make([]interface{}, 0, g.cfg.Vehicles)
+	for i := 0; i < g.cfg.Vehicles; i++ {
+		vt := vehicleTypes[g.rnd.Intn(len(vehicleTypes))]
+		lat, lon := g.point()
+		v := map[string]interface{}{
+			idField:           fmt.Sprintf("%x", g.rnd.Uint64()),
+			"lat":             lat,
+			"lon":             lon,
+			"is_reserved":     g.rnd.Intn(20) == 0,
+			"is_disabled":     g.rnd.Intn(30) == 0,
+			"vehicle_type_id": vt.id,
+			"pricing_plan_id": planFor(vt),
+			"last_reported":   g.timestamp(g.now.Add(-time.Duration(g.rnd.Intn(600)) * time.Second)),
+		}
+		if vt.maxRange > 0 {
+			v["current_range_meters"] = 1000 + g.rnd.Intn(vt.maxRange-1000)
+		}
+		vehicles = append(vehicles, v)
+	}
+
+	return g.document(map[string]interface{}{arrayField: vehicles})
+}
+
+// geofencingZones renders geofencing_zones.json with square no-parking and
+// slow zones scattered across the bounding box.
+func (g *generator) geofencingZones() map[string]interface{} {
+	b := g.cfg.BBox
+	half := math.Min(b.MaxLat-b.MinLat, b.MaxLon-b.MinLon) / 40
+
+	features := make([]interface{}, 0, g.cfg.Geofences)
+	for i := 0; i < g.cfg.Geofences; i++ {
+		lat, lon := g.point()
+		ring := [][]float64{
+			{round(lon-half, 6), round(lat-half, 6)},
+			{round(lon+half, 6), round(lat-half, 6)},
+			{round(lon+half, 6), round(lat+half, 6)},
+			{round(lon-half, 6), round(lat+half, 6)},
+			{round(lon-half, 6), round(lat-half, 6)},
+		}
+
+		slow := i%2 == 1
+		name := fmt.Sprintf("No parking zone %d", i+1)
+		if slow {
+			name = fmt.Sprintf("Slow zone %d", i+1)
+		}
+		features = append(features, map[string]interface{}{
+			"type": "Feature",
+			"geometry": map[string]interface{}{
+				"type":        "MultiPolygon",
+				"coordinates": [][][][]float64{{ring}},
+			},
+			"properties": map[string]interface{}{
+				"name":  g.text(name),
+				"rules": []interface{}{g.rule(!slow, slow)},
+			},
+		})
+	}
+
+	data := map[string]interface{}{
+		"geofencing_zones": map[string]interface{}{
+			"type":     "FeatureCollection",
+			"features": features,
+		},
+	}
+	if g.v3() {
+		data["global_rules"] = []interface{}{g.rule(false, false)}
+	}
+	return g.document(data)
+}
+
+// rule renders a geofencing rule. noParking forbids ending rides; slow caps
+// the speed.
+func (g *generator) rule(noParking, slow bool) map[string]interface{} {
+	r := map[string]interface{}{"ride_through_allowed": true}
+	if g.v3() {
+		r["ride_start_allowed"] = !noParking
+		r["ride_end_allowed"] = !noParking
+	} else {
+		r["ride_allowed"] = !noParking
+	}
+	if slow {
+		r["maximum_speed_kph"] = 10
+	}
+	return r
+}
+
+// round rounds f to the given number of decimal places.
+func round(f float64, places int) float64 {
+	p := math.Pow(10, float64(places))
+	return math.Round(f*p) / p
+}
@@ -0,0 +1,68 @@
+package genfeed_test
+
+import (
+	"bytes"
+	"context"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gbfs-validator-go/pkg/fetcher"
+	"github.com/gbfs-validator-go/pkg/genfeed"
+	"github.com/gbfs-validator-go/pkg/validator"
+)
+
+// TestGeneratedFeedValidates checks generated feeds pass validation.
+func TestGeneratedFeedValidates(t *testing.T) {
+	for _, ver := range []string{"2.2", "2.3", "3.0"} {
+		t.Run(ver, func(t *testing.T) {
+			cfg := genfeed.DefaultConfig()
+			cfg.Version = ver
+			cfg.Stations, cfg.Vehicles, cfg.Geofences = 50, 200, 5
+
+			feed, err := genfeed.Generate(cfg)
+			if err != nil {
+				t.Fatalf("Generate failed: %v", err)
+			}
+
+			server := httptest.NewServer(feed.Handler())
+			defer server.Close()
+
+			ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+			defer cancel()
+
+			v := validator.New(fetcher.New(), validator.Options{Docked: true, Freefloating: true})
+			result, err := v.Validate(ctx, server.URL+"/gbfs.json")
+			if err != nil {
+				t.Fatalf("Validation failed: %v", err)
+			}
+			for _, file := range result.Files {
+				for _, e := range file.Errors {
+					if e.Severity == validator.SeverityError {
+						t.Errorf("%s: %s at %s", file.File, e.Message, e.InstancePath)
+					}
+				}
+			}
+		})
+	}
+}
+
+// TestGenerateDeterministic checks equal seeds produce equal feeds.
+func TestGenerateDeterministic(t *testing.T) {
+	cfg := genfeed.DefaultConfig()
+	cfg.Now = time.Unix(1700000000, 0)
+
+	a, err := genfeed.Generate(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := genfeed.Generate(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, data := range a.Files {
+		if !bytes.Equal(data, b.Files[name]) {
+			t.Errorf("%s differs between runs with the same seed", name)
+		}
+	}
+}
@@ -120,29 +120,33 @@ func (t *Transformer) TransformStations(data []byte) (*GeoJSONFeatureCollection,
 	for _, station := range si.Data.Stations {
 		props := map[string]interface{}{
 			"station_id": station.StationID,
-			"name":       extractText(station.Name),
-			"capacity":   station.Capacity,
+			"name":       station.Name.Default(),
 		}
+		setOptional(props, "capacity", station.Capacity)
 
 		if status, ok := t.stationStatus[station.StationID]; ok {
-			props["num_bikes_available"] = status.NumBikesAvailable
-			props["num_vehicles_available"] = status.NumVehiclesAvailable
-			props["num_docks_available"] = status.NumDocksAvailable
-			props["is_installed"] = status.IsInstalled
-			props["is_renting"] = status.IsRenting
-			props["is_returning"] = status.IsReturning
-			
-			available := status.NumBikesAvailable
-			if status.NumVehiclesAvailable > 0 {
-				available = status.NumVehiclesAvailable
-			}
-			props["vehicles_available"] = available
+			setOptional(props, "num_bikes_available", status.NumBikesAvailable)
+			setOptional(props, "num_vehicles_available", status.NumVehiclesAvailable)
+			setOptional(props, "num_docks_available", status.NumDocksAvailable)
+			setOptional(props, "is_installed", status.IsInstalled)
+			setOptional(props, "is_renting", status.IsRenting)
+			setOptional(props, "is_returning", status.IsReturning)
+			props["vehicles_available"] = status.Available()
 		}
 
 		if station.Address != "" {
 			props["address"] = station.Address
 		}
 
+		if station.StationArea != nil {
+			if area, err := station.StationArea.MultiPolygon(); err == nil {
+				props["station_area"] = GeoJSONGeometry{
+					Type:        gbfs.GeometryMultiPolygon,
+					Coordinates: area,
+				}
+			}
+		}
+
 		feature := GeoJSONFeature{
 			Type:       "Feature",
 			Properties: props,
@@ -185,20 +189,16 @@ func (t *Transformer) TransformVehicles(data []byte) (*GeoJSONFeatureCollection,
 			props["vehicle_type_id"] = vehicle.VehicleTypeID
 			props["form_factor"] = vt.FormFactor
 			props["propulsion_type"] = vt.PropulsionType
-			props["vehicle_type_name"] = extractText(vt.Name)
+			props["vehicle_type_name"] = vt.Name.Default()
 		}
 
-		if vehicle.CurrentRangeMeters > 0 {
-			props["current_range_meters"] = vehicle.CurrentRangeMeters
-		}
-		if vehicle.CurrentFuelPercent > 0 {
-			props["current_fuel_percent"] = vehicle.CurrentFuelPercent
-		}
+		setOptional(props, "current_range_meters", vehicle.CurrentRangeMeters)
+		setOptional(props, "current_fuel_percent", vehicle.CurrentFuelPercent)
 
 		if vehicle.PricingPlanID != "" {
 			props["pricing_plan_id"] = vehicle.PricingPlanID
 			if pp, ok := t.pricingPlans[vehicle.PricingPlanID]; ok {
-				props["pricing_plan_name"] = extractText(pp.Name)
+				props["pricing_plan_name"] = pp.Name.Default()
 				props["price"] = pp.Price
 				props["currency"] = pp.Currency
 			}
@@ -218,7 +218,8 @@ func (t *Transformer) TransformVehicles(data []byte) (*GeoJSONFeatureCollection,
 	return fc, nil
 }
 
-// TransformGeofencingZones converts geofencing zones to GeoJSON.
+// TransformGeofencingZones converts geofencing zones to GeoJSON, skipping
+// features whose geometry cannot be parsed.
 func (t *Transformer) TransformGeofencingZones(data []byte) (*GeoJSONFeatureCollection, error) {
 	var gz gbfs.GeofencingZones
 	if err := json.Unmarshal(data, &gz); err != nil {
@@ -232,7 +233,7 @@ func (t *Transformer) TransformGeofencingZones(data []byte) (*GeoJSONFeatureColl
 
 	for _, feature := range gz.Data.GeofencingZones.Features {
 		props := make(map[string]interface{})
-		props["name"] = extractText(feature.Properties.Name)
+		props["name"] = feature.Properties.Name.Default()
 		if len(feature.Properties.Rules) > 0 {
 			props["rules"] = feature.Properties.Rules
 		}
@@ -243,12 +244,17 @@ func (t *Transformer) TransformGeofencingZones(data []byte) (*GeoJSONFeatureColl
 			props["end"] = feature.Properties.End
 		}
 
+		coords, err := feature.Geometry.Parsed()
+		if err != nil {
+			continue
+		}
+
 		geoFeature := GeoJSONFeature{
 			Type:       "Feature",
 			Properties: props,
 			Geometry: GeoJSONGeometry{
 				Type:        feature.Geometry.Type,
-				Coordinates: feature.Geometry.Coordinates,
+				Coordinates: coords,
 			},
 		}
 		fc.Features = append(fc.Features, geoFeature)
@@ -269,11 +275,7 @@ func (t *Transformer) CalculateSummary(stations, vehicles *GeoJSONFeatureCollect
 		summary.HasStationDetails = len(t.stationStatus) > 0
 
 		for _, status := range t.stationStatus {
-			available := status.NumBikesAvailable
-			if status.NumVehiclesAvailable > 0 {
-				available = status.NumVehiclesAvailable
-			}
-			summary.TotalVehiclesInStations += available
+			summary.TotalVehiclesInStations += status.Available()
 		}
 	}
 
@@ -351,25 +353,11 @@ func (t *Transformer) calculateBounds(stations, vehicles *GeoJSONFeatureCollecti
 	return bbox
 }
 
-// extractText reads a plain string or localized string array.
-func extractText(v interface{}) string {
-	switch t := v.(type) {
-	case string:
-		return t
-	case []interface{}:
-		for _, item := range t {
-			if m, ok := item.(map[string]interface{}); ok {
-				if text, ok := m["text"].(string); ok {
-					return text
-				}
-			}
-		}
-	case []gbfs.LocalizedString:
-		if len(t) > 0 {
-			return t[0].Text
-		}
+// setOptional sets props[key] when the feed provided a value.
+func setOptional[T any](props map[string]interface{}, key string, v *T) {
+	if v != nil {
+		props[key] = *v
 	}
-	return ""
 }
 
 // GetVehicleColor returns an RGB color for a form factor.
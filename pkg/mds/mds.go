@@ -0,0 +1,237 @@
+// Package mds cross-checks a GBFS feed against a Mobility Data
+// Specification (MDS) provider /vehicles snapshot, for agencies that
+// receive both from an operator.
+package mds
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/gbfs-validator-go/pkg/fetcher"
+	"github.com/gbfs-validator-go/pkg/gbfs"
+)
+
+// DefaultTolerancePercent is the fleet size difference tolerated before a
+// discrepancy is reported.
+const DefaultTolerancePercent = 20
+
+// maxPages bounds how many pages of /vehicles are followed.
+const maxPages = 1000
+
+// unknownType groups vehicles whose type cannot be determined.
+const unknownType = "unknown"
+
+// Vehicle is a vehicle in an MDS /vehicles response. MDS 1.x names the
+// state last_vehicle_state and 2.x vehicle_state.
+type Vehicle struct {
+	DeviceID         string   `json:"device_id"`
+	VehicleID        string   `json:"vehicle_id"`
+	VehicleType      string   `json:"vehicle_type"`
+	PropulsionTypes  []string `json:"propulsion_types"`
+	LastVehicleState string   `json:"last_vehicle_state"`
+	VehicleState     string   `json:"vehicle_state"`
+}
+
+// State returns the vehicle's last reported state, or "" if none.
+func (v Vehicle) State() string {
+	if v.VehicleState != "" {
+		return v.VehicleState
+	}
+	return v.LastVehicleState
+}
+
+// onStreet reports whether GBFS would list the vehicle: it is parked in
+// the public right of way, whether rentable or not. Vehicles without a
+// state are assumed to be.
+func (v Vehicle) onStreet() bool {
+	switch v.State() {
+	case "", "available", "reserved", "non_operational":
+		return true
+	}
+	return false
+}
+
+// page is one page of /vehicles. MDS 2.x lists vehicles at the top level
+// and 1.x under data.
+type page struct {
+	Vehicles []Vehicle `json:"vehicles"`
+	Data     struct {
+		Vehicles []Vehicle `json:"vehicles"`
+	} `json:"data"`
+	Links struct {
+		Next string `json:"next"`
+	} `json:"links"`
+}
+
+// AcceptHeader returns the media type MDS providers expect for a version.
+func AcceptHeader(version string) string {
+	if strings.HasPrefix(version, "1.") {
+		return "application/vnd.mds.provider+json;version=" + version
+	}
+	return "application/vnd.mds+json;version=" + version
+}
+
+// Fetch reads every page of an MDS /vehicles endpoint. The fetcher should
+// carry the provider's credentials and AcceptHeader.
+func Fetch(ctx context.Context, f *fetcher.Fetcher, url string) ([]Vehicle, error) {
+	var vehicles []Vehicle
+	seen := make(map[string]bool)
+	for n := 0; url != "" && n < maxPages; n++ {
+		if seen[url] {
+			break
+		}
+		seen[url] = true
+
+		var p page
+		result := f.FetchJSON(ctx, url, &p)
+		if result.Error != nil {
+			return nil, result.Error
+		}
+		if !result.Exists {
+			return nil, fmt.Errorf("%s: not found", result.URL)
+		}
+		vehicles = append(vehicles, p.Vehicles...)
+		vehicles = append(vehicles, p.Data.Vehicles...)
+		url = p.Links.Next
+	}
+	return vehicles, nil
+}
+
+// TypeCount compares the number of vehicles of one type.
+type TypeCount struct {
+	Type string `json:"type"`
+	GBFS int    `json:"gbfs"`
+	MDS  int    `json:"mds"`
+}
+
+// Report compares GBFS and MDS fleet sizes.
+type Report struct {
+	// GBFSVehicles counts vehicles listed away from a station plus vehicles
+	// available at stations.
+	GBFSVehicles int `json:"gbfsVehicles"`
+	// MDSVehicles counts vehicles in the public right of way: available,
+	// reserved, or non-operational.
+	MDSVehicles int `json:"mdsVehicles"`
+	// MDSTotal counts every vehicle in the snapshot, including those on a
+	// trip or removed.
+	MDSTotal          int         `json:"mdsTotal"`
+	DifferencePercent float64     `json:"differencePercent"`
+	TolerancePercent  float64     `json:"tolerancePercent"`
+	Types             []TypeCount `json:"types"`
+	// Discrepancies describes every difference beyond the tolerance.
+	Discrepancies []string `json:"discrepancies,omitempty"`
+}
+
+// CrossCheck compares a GBFS snapshot with MDS vehicles; any GBFS file may
+// be nil. A tolerance of zero selects DefaultTolerancePercent.
+func CrossCheck(vehicles *gbfs.VehicleStatus, stations *gbfs.StationStatus, types *gbfs.VehicleTypes, mdsVehicles []Vehicle, tolerancePercent float64) *Report {
+	if tolerancePercent <= 0 {
+		tolerancePercent = DefaultTolerancePercent
+	}
+	report := &Report{TolerancePercent: tolerancePercent, MDSTotal: len(mdsVehicles)}
+
+	formFactors := make(map[string]string)
+	if types != nil {
+		for _, t := range types.Data.VehicleTypes {
+			formFactors[t.VehicleTypeID] = normalizeType(t.FormFactor)
+		}
+	}
+	gbfsType := func(id string) string {
+		if ff, ok := formFactors[id]; ok {
+			return ff
+		}
+		return unknownType
+	}
+
+	counts := make(map[string]*TypeCount)
+	count := func(t string) *TypeCount {
+		if counts[t] == nil {
+			counts[t] = &TypeCount{Type: t}
+		}
+		return counts[t]
+	}
+	if vehicles != nil {
+		for _, v := range vehicles.Data.GetVehicles() {
+			if v.StationID != "" {
+				continue
+			}
+			report.GBFSVehicles++
+			count(gbfsType(v.VehicleTypeID)).GBFS++
+		}
+	}
+	if stations != nil {
+		for _, s := range stations.Data.Stations {
+			report.GBFSVehicles += s.Available()
+			typed := 0
+			for _, a := range s.VehicleTypesAvailable {
+				count(gbfsType(a.VehicleTypeID)).GBFS += a.Count
+				typed += a.Count
+			}
+			if rest := s.Available() - typed; rest > 0 {
+				count(unknownType).GBFS += rest
+			}
+		}
+	}
+	for _, v := range mdsVehicles {
+		if !v.onStreet() {
+			continue
+		}
+		report.MDSVehicles++
+		t := normalizeType(v.VehicleType)
+		if t == "" {
+			t = unknownType
+		}
+		count(t).MDS++
+	}
+
+	report.DifferencePercent = difference(report.GBFSVehicles, report.MDSVehicles)
+	if report.DifferencePercent > tolerancePercent {
+		report.Discrepancies = append(report.Discrepancies, fmt.Sprintf(
+			"GBFS lists %d vehicles but MDS reports %d in the right of way (%.1f%% apart)",
+			report.GBFSVehicles, report.MDSVehicles, report.DifferencePercent))
+	}
+
+	for _, c := range counts {
+		report.Types = append(report.Types, *c)
+	}
+	sort.Slice(report.Types, func(i, j int) bool { return report.Types[i].Type < report.Types[j].Type })
+	// Per-type counts are only comparable when both sides name every type.
+	if counts[unknownType] == nil {
+		for _, c := range report.Types {
+			switch {
+			case c.MDS == 0:
+				report.Discrepancies = append(report.Discrepancies, fmt.Sprintf("GBFS lists %d %s vehicles that MDS does not report", c.GBFS, c.Type))
+			case c.GBFS == 0:
+				report.Discrepancies = append(report.Discrepancies, fmt.Sprintf("MDS reports %d %s vehicles that GBFS does not list", c.MDS, c.Type))
+			case difference(c.GBFS, c.MDS) > tolerancePercent:
+				report.Discrepancies = append(report.Discrepancies, fmt.Sprintf(
+					"GBFS lists %d %s vehicles but MDS reports %d", c.GBFS, c.Type, c.MDS))
+			}
+		}
+	}
+	return report
+}
+
+// normalizeType maps GBFS form factors and MDS vehicle types to shared
+// names. Both specs split scooters into standing and seated in later
+// versions and call them scooter before.
+func normalizeType(t string) string {
+	switch t {
+	case "scooter", "scooter_standing", "scooter_seated":
+		return "scooter"
+	}
+	return t
+}
+
+// difference returns how far apart a and b are as a percentage of the
+// larger.
+func difference(a, b int) float64 {
+	larger := math.Max(float64(a), float64(b))
+	if larger == 0 {
+		return 0
+	}
+	return math.Round(1000*math.Abs(float64(a-b))/larger) / 10
+}
@@ -0,0 +1,76 @@
+package mds
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gbfs-validator-go/pkg/fetcher"
+	"github.com/gbfs-validator-go/pkg/gbfs"
+)
+
+func TestCrossCheck(t *testing.T) {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.Header.Get("Accept"), "application/vnd.mds+json") {
+			http.Error(w, "bad accept header", http.StatusNotAcceptable)
+			return
+		}
+		if r.URL.Query().Get("page") == "" {
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"vehicles": []Vehicle{
+					{DeviceID: "1", VehicleType: "scooter_standing", VehicleState: "available"},
+					{DeviceID: "2", VehicleType: "scooter_standing", VehicleState: "on_trip"},
+				},
+				"links": map[string]string{"next": server.URL + "/vehicles?page=2"},
+			})
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"vehicles": []Vehicle{
+				{DeviceID: "3", VehicleType: "bicycle", VehicleState: "available"},
+				{DeviceID: "4", VehicleType: "bicycle", VehicleState: "reserved"},
+				{DeviceID: "5", VehicleType: "bicycle", VehicleState: "non_operational"},
+			},
+		})
+	}))
+	defer server.Close()
+
+	f := fetcher.New(fetcher.WithHeaders(map[string]string{"Accept": AcceptHeader("2.0")}))
+	vehicles, err := Fetch(context.Background(), f, server.URL+"/vehicles")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(vehicles) != 5 {
+		t.Fatalf("expected 5 vehicles over two pages, got %d", len(vehicles))
+	}
+
+	types := &gbfs.VehicleTypes{Data: gbfs.VehicleTypesData{VehicleTypes: []gbfs.VehicleType{
+		{VehicleTypeID: "s", FormFactor: "scooter"},
+		{VehicleTypeID: "b", FormFactor: "bicycle"},
+	}}}
+	status := &gbfs.VehicleStatus{Data: gbfs.VehicleStatusData{Vehicles: []gbfs.Vehicle{
+		{VehicleID: "a", VehicleTypeID: "s"},
+		{VehicleID: "b", VehicleTypeID: "s"},
+		{VehicleID: "c", VehicleTypeID: "s"},
+	}}}
+	report := CrossCheck(status, nil, types, vehicles, 0)
+	if report.GBFSVehicles != 3 || report.MDSVehicles != 4 || report.MDSTotal != 5 {
+		t.Errorf("unexpected counts: %+v", report)
+	}
+	if report.DifferencePercent != 25 {
+		t.Errorf("difference = %v, want 25", report.DifferencePercent)
+	}
+	want := []string{"GBFS lists 3 vehicles", "MDS reports 3 bicycle vehicles that GBFS does not list", "GBFS lists 3 scooter vehicles but MDS reports 1"}
+	if len(report.Discrepancies) != len(want) {
+		t.Fatalf("discrepancies = %v", report.Discrepancies)
+	}
+	for i, w := range want {
+		if !strings.Contains(report.Discrepancies[i], w) {
+			t.Errorf("discrepancy %d = %q, want %q", i, report.Discrepancies[i], w)
+		}
+	}
+}
@@ -0,0 +1,360 @@
+// Package mockserver serves a known-good GBFS feed with configurable faults,
+// so consumers can integration-test against known-bad feeds.
+package mockserver
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gbfs-validator-go/pkg/scaffold"
+	"github.com/gbfs-validator-go/pkg/version"
+)
+
+// Config selects the served version and the faults to inject. File names may
+// be given with or without the .json suffix.
+type Config struct {
+	Version      string
+	Docked       bool
+	Freefloating bool
+
+	// Omit removes files from gbfs.json and serves 404 for them.
+	Omit []string
+	// Drop keeps files listed in gbfs.json but serves 404 for them.
+	Drop []string
+	// Corrupt serves truncated, unparseable JSON for these files.
+	Corrupt []string
+	// WrongTypes serves booleans and numbers as strings in these files.
+	WrongTypes []string
+
+	// Latency delays every response; Jitter adds up to that much more at
+	// random.
+	Latency time.Duration
+	Jitter  time.Duration
+
+	// Faults inject failures on some requests, e.g. an intermittent 500.
+	Faults []Fault
+}
+
+// FaultKind names an injected failure.
+type FaultKind string
+
+const (
+	// FaultError responds with 500 Internal Server Error.
+	FaultError FaultKind = "error"
+	// FaultTruncate cuts the body in half.
+	FaultTruncate FaultKind = "truncate"
+	// FaultSlow delays the response by Fault.Delay.
+	FaultSlow FaultKind = "slow"
+	// FaultExpired backdates last_updated so the file is past its ttl.
+	FaultExpired FaultKind = "expired"
+)
+
+// FaultKinds lists the supported fault kinds.
+func FaultKinds() []FaultKind {
+	return []FaultKind{FaultError, FaultTruncate, FaultSlow, FaultExpired}
+}
+
+// Fault injects one kind of failure on requests for a file.
+type Fault struct {
+	Kind FaultKind
+	// File limits the fault to one file; empty or "*" matches every file,
+	// including gbfs.json.
+	File string
+	// Script decides per request whether the fault fires, cycling through
+	// its characters: 'X' fires and '.' passes, so "..X" fails every third
+	// request. It takes precedence over Rate.
+	Script string
+	// Rate is the probability the fault fires when Script is empty; zero
+	// means always.
+	Rate float64
+	// Delay is how long FaultSlow holds the response.
+	Delay time.Duration
+}
+
+// ParseFault parses "kind[:file[:script-or-rate]]", e.g. "error:station_status:..X"
+// or "slow:*:0.25".
+func ParseFault(s string) (Fault, error) {
+	parts := strings.SplitN(s, ":", 3)
+	f := Fault{Kind: FaultKind(parts[0])}
+
+	known := false
+	for _, k := range FaultKinds() {
+		if f.Kind == k {
+			known = true
+		}
+	}
+	if !known {
+		return Fault{}, fmt.Errorf("unknown fault kind %q (want one of %v)", parts[0], FaultKinds())
+	}
+
+	if len(parts) > 1 {
+		f.File = parts[1]
+	}
+	if len(parts) > 2 {
+		if rate, err := strconv.ParseFloat(parts[2], 64); err == nil {
+			if rate < 0 || rate > 1 {
+				return Fault{}, fmt.Errorf("fault rate must be between 0 and 1, got %v", rate)
+			}
+			f.Rate = rate
+		} else if strings.Trim(parts[2], ".X") == "" {
+			f.Script = parts[2]
+		} else {
+			return Fault{}, fmt.Errorf("fault script must contain only '.' and 'X', got %q", parts[2])
+		}
+	}
+	return f, nil
+}
+
+// Server serves the mock feed.
+type Server struct {
+	cfg        Config
+	omit       map[string]bool
+	drop       map[string]bool
+	corrupt    map[string]bool
+	wrongTypes map[string]bool
+
+	mu       sync.Mutex
+	rnd      *rand.Rand
+	requests map[string]int
+}
+
+// New validates cfg and constructs a Server.
+func New(cfg Config) (*Server, error) {
+	if cfg.Version == "" {
+		cfg.Version = "3.0"
+	}
+	if _, ok := version.GetConfig(cfg.Version); !ok {
+		return nil, fmt.Errorf("unsupported GBFS version %q (supported: %v)", cfg.Version, version.SupportedVersions())
+	}
+	if cfg.Latency < 0 || cfg.Jitter < 0 {
+		return nil, fmt.Errorf("latency and jitter must not be negative")
+	}
+	for i := range cfg.Faults {
+		cfg.Faults[i].File = strings.TrimSuffix(cfg.Faults[i].File, ".json")
+		if cfg.Faults[i].Kind == FaultSlow && cfg.Faults[i].Delay <= 0 {
+			return nil, fmt.Errorf("slow fault on %q needs a positive delay", cfg.Faults[i].File)
+		}
+	}
+
+	return &Server{
+		cfg:        cfg,
+		omit:       fileSet(cfg.Omit),
+		drop:       fileSet(cfg.Drop),
+		corrupt:    fileSet(cfg.Corrupt),
+		wrongTypes: fileSet(cfg.WrongTypes),
+		rnd:        rand.New(rand.NewSource(time.Now().UnixNano())),
+		requests:   make(map[string]int),
+	}, nil
+}
+
+// fileSet normalizes file names into a set.
+func fileSet(names []string) map[string]bool {
+	set := make(map[string]bool, len(names))
+	for _, name := range names {
+		name = strings.TrimSuffix(strings.TrimSpace(name), ".json")
+		if name != "" {
+			set[name] = true
+		}
+	}
+	return set
+}
+
+// ServeHTTP serves gbfs.json and feed files, applying configured faults.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !s.delay(r) {
+		return
+	}
+
+	name := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/"), ".json")
+	if name == "" {
+		name = "gbfs"
+	}
+
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	files, err := s.files(scheme + "://" + r.Host + "/")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	data, ok := files[name]
+	if !ok || s.omit[name] || s.drop[name] {
+		http.NotFound(w, r)
+		return
+	}
+
+	if s.wrongTypes[name] {
+		data = wrongTypes(data)
+	}
+	if s.corrupt[name] {
+		data = data[:len(data)/2]
+	}
+
+	for _, f := range s.firing(name) {
+		switch f.Kind {
+		case FaultError:
+			http.Error(w, "injected fault", http.StatusInternalServerError)
+			return
+		case FaultSlow:
+			select {
+			case <-time.After(f.Delay):
+			case <-r.Context().Done():
+				return
+			}
+		case FaultTruncate:
+			data = data[:len(data)/2]
+		case FaultExpired:
+			data = expire(data)
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(data)
+}
+
+// firing counts a request for name and returns the faults that fire on it.
+func (s *Server) firing(name string) []Fault {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	n := s.requests[name]
+	s.requests[name] = n + 1
+
+	var faults []Fault
+	for _, f := range s.cfg.Faults {
+		if f.File != "" && f.File != "*" && f.File != name {
+			continue
+		}
+		switch {
+		case f.Script != "":
+			if f.Script[n%len(f.Script)] != 'X' {
+				continue
+			}
+		case f.Rate > 0:
+			if s.rnd.Float64() >= f.Rate {
+				continue
+			}
+		}
+		faults = append(faults, f)
+	}
+	return faults
+}
+
+// delay sleeps for the configured latency and reports whether the request is
+// still live.
+func (s *Server) delay(r *http.Request) bool {
+	d := s.cfg.Latency
+	if s.cfg.Jitter > 0 {
+		s.mu.Lock()
+		d += time.Duration(s.rnd.Int63n(int64(s.cfg.Jitter)))
+		s.mu.Unlock()
+	}
+	if d == 0 {
+		return true
+	}
+
+	select {
+	case <-time.After(d):
+		return true
+	case <-r.Context().Done():
+		return false
+	}
+}
+
+// files renders the feed keyed by name, with gbfs.json pointing at baseURL
+// and omitting files listed in Omit.
+func (s *Server) files(baseURL string) (map[string][]byte, error) {
+	opts := version.Options{Docked: s.cfg.Docked, Freefloating: s.cfg.Freefloating}
+	now := time.Now()
+
+	skeleton, err := scaffold.Skeleton(s.cfg.Version, baseURL, opts, now)
+	if err != nil {
+		return nil, err
+	}
+
+	files := make(map[string][]byte, len(skeleton))
+	feeds := make(map[string]string, len(skeleton))
+	for file, data := range skeleton {
+		name := strings.TrimSuffix(file, ".json")
+		files[name] = data
+		if name != "gbfs" && !s.omit[name] {
+			feeds[name] = baseURL + file
+		}
+	}
+
+	files["gbfs"], err = scaffold.GBFS(feeds, s.cfg.Version, scaffold.PlaceholderLanguage, baseURL+"gbfs.json", now)
+	if err != nil {
+		return nil, err
+	}
+	return files, nil
+}
+
+// expire backdates last_updated by an hour and caps ttl at a minute, keeping
+// the timestamp in its original POSIX or RFC3339 form.
+func expire(data []byte) []byte {
+	var doc map[string]interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return data
+	}
+
+	past := time.Now().Add(-time.Hour)
+	if _, ok := doc["last_updated"].(string); ok {
+		doc["last_updated"] = past.UTC().Format(time.RFC3339)
+	} else {
+		doc["last_updated"] = past.Unix()
+	}
+	doc["ttl"] = 60
+
+	out, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return data
+	}
+	return out
+}
+
+// wrongTypes rewrites booleans and numbers below the top-level data object
+// as strings.
+func wrongTypes(data []byte) []byte {
+	var doc map[string]interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return data
+	}
+	doc["data"] = stringify(doc["data"])
+
+	out, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return data
+	}
+	return out
+}
+
+// stringify recursively converts scalar non-string values to strings.
+func stringify(v interface{}) interface{} {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		for k, child := range t {
+			t[k] = stringify(child)
+		}
+		return t
+	case []interface{}:
+		for i, child := range t {
+			t[i] = stringify(child)
+		}
+		return t
+	case bool:
+		return strconv.FormatBool(t)
+	case float64:
+		return strconv.FormatFloat(t, 'f', -1, 64)
+	default:
+		return v
+	}
+}
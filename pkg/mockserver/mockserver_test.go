@@ -0,0 +1,168 @@
+package mockserver_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gbfs-validator-go/pkg/fetcher"
+	"github.com/gbfs-validator-go/pkg/mockserver"
+	"github.com/gbfs-validator-go/pkg/validator"
+)
+
+// TestInjectedFaults checks each fault surfaces as the expected file status
+// and keyword.
+func TestInjectedFaults(t *testing.T) {
+	srv, err := mockserver.New(mockserver.Config{
+		Version:      "3.0",
+		Docked:       true,
+		Freefloating: true,
+		Omit:         []string{"vehicle_status"},
+		Drop:         []string{"station_status.json"},
+		Corrupt:      []string{"system_pricing_plans"},
+		WrongTypes:   []string{"station_information"},
+	})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	server := httptest.NewServer(srv)
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	v := validator.New(fetcher.New(), validator.Options{Docked: true, Freefloating: true})
+	result, err := v.Validate(ctx, server.URL+"/gbfs.json")
+	if err != nil {
+		t.Fatalf("Validation failed: %v", err)
+	}
+
+	expected := map[string]struct {
+		status  validator.FileStatus
+		keyword string
+	}{
+		"gbfs.json":                 {status: validator.FileStatusValid},
+		"system_information.json":   {status: validator.FileStatusValid},
+		"vehicle_status.json":       {status: validator.FileStatusMissing, keyword: "required"},
+		"station_status.json":       {status: validator.FileStatusMissing, keyword: "fetch"},
+		"system_pricing_plans.json": {status: validator.FileStatusInvalid, keyword: "parse"},
+		"station_information.json":  {status: validator.FileStatusInvalid, keyword: "type"},
+	}
+
+	for _, file := range result.Files {
+		want, ok := expected[file.File]
+		if !ok {
+			continue
+		}
+		if file.Status != want.status {
+			t.Errorf("%s: expected status %s, got %s (%+v)", file.File, want.status, file.Status, file.Errors)
+		}
+		if want.keyword == "" {
+			continue
+		}
+		found := false
+		for _, e := range file.Errors {
+			if e.Keyword == want.keyword {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("%s: expected a %q issue, got %+v", file.File, want.keyword, file.Errors)
+		}
+	}
+}
+
+// TestScriptedFaults checks scripted faults fire on the scripted requests and
+// how the validator classifies each one.
+func TestScriptedFaults(t *testing.T) {
+	srv, err := mockserver.New(mockserver.Config{
+		Version: "2.3",
+		Docked:  true,
+		Faults: []mockserver.Fault{
+			{Kind: mockserver.FaultError, File: "station_status", Script: "X."},
+			{Kind: mockserver.FaultTruncate, File: "station_information", Script: ".X"},
+			{Kind: mockserver.FaultExpired, File: "system_information"},
+			{Kind: mockserver.FaultSlow, File: "vehicle_types", Delay: 2 * time.Second},
+		},
+	})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	server := httptest.NewServer(srv)
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	v := validator.New(fetcher.New(fetcher.WithTimeout(500*time.Millisecond)), validator.Options{Docked: true})
+
+	keywords := func(result *validator.ValidationResult) map[string]string {
+		found := make(map[string]string)
+		for _, file := range result.Files {
+			for _, e := range file.Errors {
+				found[file.File] = e.Keyword
+			}
+		}
+		return found
+	}
+
+	first, err := v.Validate(ctx, server.URL+"/gbfs.json")
+	if err != nil {
+		t.Fatalf("Validation failed: %v", err)
+	}
+	got := keywords(first)
+	if got["station_status.json"] != "fetch" {
+		t.Errorf("first run: expected station_status.json to report %q, got %q", "fetch", got["station_status.json"])
+	}
+	// An expired file is still a valid document; how stale data is handled
+	// is up to its consumer.
+	for _, file := range []string{"station_information.json", "system_information.json"} {
+		if _, ok := got[file]; ok {
+			t.Errorf("first run: %s should pass, got %q", file, got[file])
+		}
+	}
+	for _, file := range first.Files {
+		if file.File != "system_information.json" {
+			continue
+		}
+		var header struct {
+			LastUpdated int64 `json:"last_updated"`
+			TTL         int   `json:"ttl"`
+		}
+		if err := json.Unmarshal(file.RawData, &header); err != nil ||
+			time.Since(time.Unix(header.LastUpdated, 0)) < time.Hour || header.TTL != 60 {
+			t.Errorf("first run: system_information.json was not expired: %+v, %v", header, err)
+		}
+	}
+
+	second, err := v.Validate(ctx, server.URL+"/gbfs.json")
+	if err != nil {
+		t.Fatalf("Validation failed: %v", err)
+	}
+	got = keywords(second)
+	if _, ok := got["station_status.json"]; ok {
+		t.Errorf("second run: station_status.json should pass, got %q", got["station_status.json"])
+	}
+	if got["station_information.json"] != "parse" {
+		t.Errorf("second run: expected station_information.json to report parse, got %q", got["station_information.json"])
+	}
+}
+
+// TestParseFault checks the fault flag syntax.
+func TestParseFault(t *testing.T) {
+	f, err := mockserver.ParseFault("error:station_status:..X")
+	if err != nil || f.Kind != mockserver.FaultError || f.File != "station_status" || f.Script != "..X" {
+		t.Errorf("unexpected fault %+v (%v)", f, err)
+	}
+	f, err = mockserver.ParseFault("truncate:*:0.25")
+	if err != nil || f.Rate != 0.25 {
+		t.Errorf("unexpected fault %+v (%v)", f, err)
+	}
+	for _, bad := range []string{"explode", "error:x:abc", "error:x:2"} {
+		if _, err := mockserver.ParseFault(bad); err == nil {
+			t.Errorf("expected %q to be rejected", bad)
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package monitor
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/gbfs-validator-go/pkg/validator"
+)
+
+// defaultUnchangedAfter is used for feeds without an unchanged-file period.
+const defaultUnchangedAfter = time.Hour
+
+// fileState tracks the content hash of one file across polls.
+type fileState struct {
+	hash  string
+	since time.Time
+	ttl   int
+	polls int
+}
+
+// UnchangedFile describes a file whose content stayed the same.
+type UnchangedFile struct {
+	File string `json:"file"`
+	Hash string `json:"hash"`
+	// Since is when the current content was first fetched.
+	Since time.Time `json:"since"`
+	TTL   int       `json:"ttl"`
+	// Polls counts the runs that fetched the current content.
+	Polls int `json:"polls"`
+	// Stale is set when the content has not changed for the feed's
+	// unchanged-file period although its ttl promises fresher data.
+	Stale   bool   `json:"stale,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+// UnchangedReport lists the files of a feed by how long their content has
+// stayed the same.
+type UnchangedReport struct {
+	ID             string   `json:"id"`
+	UnchangedAfter Duration `json:"unchangedAfter"`
+	Stale          int      `json:"stale"`
+	// Files lists stale files first, then the rest, by name.
+	Files []UnchangedFile `json:"files"`
+}
+
+// fileTTL returns the ttl a file declares, or -1 when it has none.
+func fileTTL(data json.RawMessage) int {
+	var doc struct {
+		TTL *int `json:"ttl"`
+	}
+	if json.Unmarshal(data, &doc) != nil || doc.TTL == nil {
+		return -1
+	}
+	return *doc.TTL
+}
+
+// recordChangesLocked updates file content tracking from a run's hashes.
+// Files that were not fetched keep their state. The caller holds m.mu.
+func (m *Monitor) recordChangesLocked(id string, at time.Time, result *validator.ValidationResult) {
+	if result == nil {
+		return
+	}
+	files := m.changes[id]
+	if files == nil {
+		files = make(map[string]*fileState)
+		m.changes[id] = files
+	}
+	for _, f := range result.Files {
+		if !f.Exists || f.Hash == "" {
+			continue
+		}
+		st, ok := files[f.File]
+		if !ok || st.hash != f.Hash {
+			st = &fileState{hash: f.Hash, since: at, ttl: fileTTL(f.RawData)}
+			files[f.File] = st
+		}
+		st.polls++
+	}
+}
+
+// Unchanged reports how long the content of each of a feed's files has
+// stayed the same and which files look stale for their ttl.
+func (m *Monitor) Unchanged(id string, now time.Time) (UnchangedReport, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	i := m.indexLocked(id)
+	if i < 0 {
+		return UnchangedReport{}, ErrFeedNotFound
+	}
+	return m.unchangedLocked(m.feeds[i], now), nil
+}
+
+// unchangedLocked builds a feed's unchanged-file report. The caller holds
+// m.mu.
+func (m *Monitor) unchangedLocked(f Feed, now time.Time) UnchangedReport {
+	report := UnchangedReport{ID: f.ID, UnchangedAfter: f.UnchangedAfter, Files: []UnchangedFile{}}
+	for name, st := range m.changes[f.ID] {
+		uf := UnchangedFile{File: name, Hash: st.hash, Since: st.since, TTL: st.ttl, Polls: st.polls}
+		age := now.Sub(st.since)
+		if st.polls > 1 && st.ttl >= 0 && age >= time.Duration(f.UnchangedAfter) &&
+			time.Duration(st.ttl)*time.Second < time.Duration(f.UnchangedAfter) {
+			uf.Stale = true
+			uf.Message = fmt.Sprintf("%s unchanged for %s despite ttl=%d", name, age.Truncate(time.Minute), st.ttl)
+			report.Stale++
+		}
+		report.Files = append(report.Files, uf)
+	}
+	sort.Slice(report.Files, func(i, j int) bool {
+		a, b := report.Files[i], report.Files[j]
+		if a.Stale != b.Stale {
+			return a.Stale
+		}
+		return a.File < b.File
+	})
+	return report
+}
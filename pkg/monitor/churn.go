@@ -0,0 +1,148 @@
+package monitor
+
+import (
+	"encoding/json"
+	"sort"
+	"time"
+
+	"github.com/gbfs-validator-go/pkg/gbfs"
+	"github.com/gbfs-validator-go/pkg/validator"
+)
+
+// defaultFrozenAfter is used for feeds without a frozen-data period.
+const defaultFrozenAfter = 24 * time.Hour
+
+// stationState tracks how a station's availability changes across polls.
+type stationState struct {
+	values     string
+	firstSeen  time.Time
+	lastChange time.Time
+	polls      int
+	changes    int
+}
+
+// StationChurn describes how often one station's availability changed.
+type StationChurn struct {
+	ID      string `json:"id"`
+	Polls   int    `json:"polls"`
+	Changes int    `json:"changes"`
+	// LastChange is when the values last changed, or when the station was
+	// first seen if they never did.
+	LastChange time.Time `json:"lastChange"`
+	// Frozen is set when the values have not changed for the feed's
+	// frozen-data period.
+	Frozen bool `json:"frozen,omitempty"`
+}
+
+// ChurnReport summarizes station availability changes of a feed since the
+// monitor started.
+type ChurnReport struct {
+	ID          string   `json:"id"`
+	FrozenAfter Duration `json:"frozenAfter"`
+	// ChangeRate is the share of consecutive polls in which a station's
+	// values changed, across all stations.
+	ChangeRate float64 `json:"changeRate"`
+	Frozen     int     `json:"frozen"`
+	// Stations lists frozen stations first, then the rest, by ID.
+	Stations []StationChurn `json:"stations"`
+}
+
+// stationValues returns the availability numbers of a station as a
+// comparable string.
+func stationValues(s gbfs.StationStatusEntry) string {
+	data, _ := json.Marshal(struct {
+		Vehicles         int
+		VehiclesDisabled *int
+		BikesDisabled    *int
+		Docks            *int
+		DocksDisabled    *int
+		Types            []gbfs.VehicleTypeAvailable
+		DockTypes        []gbfs.VehicleDockAvailable
+	}{s.Available(), s.NumVehiclesDisabled, s.NumBikesDisabled, s.NumDocksAvailable, s.NumDocksDisabled,
+		s.VehicleTypesAvailable, s.VehicleDocksAvailable})
+	return string(data)
+}
+
+// recordChurnLocked updates station churn from a run's station_status.json.
+// Stations that are not installed are skipped, since their numbers are
+// expected to stay put, and stations no longer listed are forgotten. The
+// caller holds m.mu.
+func (m *Monitor) recordChurnLocked(id string, at time.Time, result *validator.ValidationResult) {
+	if result == nil {
+		return
+	}
+	var status gbfs.StationStatus
+	found := false
+	for _, f := range result.Files {
+		if f.File != "station_status.json" || !f.Exists {
+			continue
+		}
+		data := f.RawData
+		if f.CoercedData != nil {
+			data = f.CoercedData
+		}
+		found = json.Unmarshal(data, &status) == nil
+		break
+	}
+	if !found {
+		return
+	}
+
+	prev := m.churn[id]
+	next := make(map[string]*stationState, len(status.Data.Stations))
+	for _, s := range status.Data.Stations {
+		if s.IsInstalled != nil && !*s.IsInstalled {
+			continue
+		}
+		values := stationValues(s)
+		st, ok := prev[s.StationID]
+		if !ok {
+			st = &stationState{values: values, firstSeen: at, lastChange: at}
+		} else if st.values != values {
+			st.values, st.lastChange = values, at
+			st.changes++
+		}
+		st.polls++
+		next[s.StationID] = st
+	}
+	m.churn[id] = next
+}
+
+// Churn reports how often a feed's station availability changed and which
+// stations look frozen.
+func (m *Monitor) Churn(id string, now time.Time) (ChurnReport, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	i := m.indexLocked(id)
+	if i < 0 {
+		return ChurnReport{}, ErrFeedNotFound
+	}
+	return m.churnLocked(m.feeds[i], now), nil
+}
+
+// churnLocked builds a feed's churn report. The caller holds m.mu.
+func (m *Monitor) churnLocked(f Feed, now time.Time) ChurnReport {
+	report := ChurnReport{ID: f.ID, FrozenAfter: f.FrozenAfter, Stations: []StationChurn{}}
+	var intervals, changes int
+	for sid, st := range m.churn[f.ID] {
+		sc := StationChurn{ID: sid, Polls: st.polls, Changes: st.changes, LastChange: st.lastChange}
+		sc.Frozen = st.polls > 1 && now.Sub(st.lastChange) >= time.Duration(f.FrozenAfter)
+		if sc.Frozen {
+			report.Frozen++
+		}
+		intervals += st.polls - 1
+		changes += st.changes
+		report.Stations = append(report.Stations, sc)
+	}
+	if intervals > 0 {
+		report.ChangeRate = float64(changes) / float64(intervals)
+	}
+	sort.Slice(report.Stations, func(i, j int) bool {
+		a, b := report.Stations[i], report.Stations[j]
+		if a.Frozen != b.Frozen {
+			return a.Frozen
+		}
+		return a.ID < b.ID
+	})
+	return report
+}
@@ -0,0 +1,264 @@
+package monitor
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gbfs-validator-go/pkg/archive"
+)
+
+// electionTTL is how long a leader holds its lease without renewing it,
+// and so how long the other replicas wait to take over from a leader that
+// stopped. Leaders renew it three times per period.
+const electionTTL = 30 * time.Second
+
+// Election chooses the one replica that schedules checks, so that feeds
+// are not checked and alerted on once per replica. Leadership is a lease
+// that the leader renews and that lapses if it stops.
+type Election interface {
+	// Campaign makes candidate the leader for ttl if there is no leader,
+	// the lease lapsed, or candidate already leads, and reports whether
+	// candidate leads.
+	Campaign(ctx context.Context, candidate string, ttl time.Duration) (bool, error)
+	// Resign ends candidate's leadership, if it leads.
+	Resign(ctx context.Context, candidate string) error
+}
+
+// OpenElection returns the election named by uri: "memory:" for an
+// election between monitors in one process, or a postgres:// database URL
+// as accepted by archive.OpenStore.
+func OpenElection(uri string) (Election, error) {
+	if uri == "memory:" {
+		return NewMemoryElection(), nil
+	}
+	if !strings.HasPrefix(uri, "postgres://") && !strings.HasPrefix(uri, "postgresql://") {
+		return nil, fmt.Errorf("unsupported election %q; want memory: or postgres://", uri)
+	}
+	db, _, err := archive.OpenDB(uri)
+	if err != nil {
+		return nil, err
+	}
+	e, err := NewSQLElection(context.Background(), db, "monitor")
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return e, nil
+}
+
+// MemoryElection is an Election in memory.
+type MemoryElection struct {
+	mu      sync.Mutex
+	leader  string
+	expires time.Time
+	now     func() time.Time
+}
+
+// NewMemoryElection returns an election without a leader.
+func NewMemoryElection() *MemoryElection {
+	return &MemoryElection{now: time.Now}
+}
+
+// Campaign takes or renews the lease.
+func (e *MemoryElection) Campaign(ctx context.Context, candidate string, ttl time.Duration) (bool, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	now := e.now()
+	if e.leader != candidate && now.Before(e.expires) {
+		return false, nil
+	}
+	e.leader, e.expires = candidate, now.Add(ttl)
+	return true, nil
+}
+
+// Resign releases the lease.
+func (e *MemoryElection) Resign(ctx context.Context, candidate string) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.leader == candidate {
+		e.leader, e.expires = "", time.Time{}
+	}
+	return nil
+}
+
+// SQLElection is an Election over a lease in the gbfs_leases table of a
+// Postgres database.
+type SQLElection struct {
+	db   *sql.DB
+	name string
+}
+
+// NewSQLElection returns an election for the lease called name in db,
+// applying the schema migrations the database needs.
+func NewSQLElection(ctx context.Context, db *sql.DB, name string) (*SQLElection, error) {
+	if err := archive.Migrate(ctx, db, archive.Postgres); err != nil {
+		return nil, fmt.Errorf("migrating Postgres database: %w", err)
+	}
+	return &SQLElection{db: db, name: name}, nil
+}
+
+// Campaign takes or renews the lease in one upsert, which concurrent
+// candidates cannot both win.
+func (e *SQLElection) Campaign(ctx context.Context, candidate string, ttl time.Duration) (bool, error) {
+	res, err := e.db.ExecContext(ctx, `INSERT INTO gbfs_leases (name, holder, expires_at)
+		VALUES ($1, $2, `+pgNow+` + $3)
+		ON CONFLICT (name) DO UPDATE SET holder = excluded.holder, expires_at = excluded.expires_at
+		WHERE gbfs_leases.holder = excluded.holder OR gbfs_leases.expires_at <= `+pgNow,
+		e.name, candidate, int64(ttl))
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n == 1, err
+}
+
+// Resign releases the lease.
+func (e *SQLElection) Resign(ctx context.Context, candidate string) error {
+	_, err := e.db.ExecContext(ctx, `DELETE FROM gbfs_leases WHERE name = $1 AND holder = $2`, e.name, candidate)
+	return err
+}
+
+// Close closes the database.
+func (e *SQLElection) Close() error {
+	return e.db.Close()
+}
+
+// WithElection makes Run schedule checks only while this replica leads e.
+// The other replicas keep serving the feeds' configuration and, with
+// WithStore, their stored results. With a WithFeedStore store shared by
+// the replicas, any of them accepts feed changes, which the leader picks
+// up on its next sync, and a newly elected leader schedules the same
+// feeds; without one, followers refuse changes with ErrNotLeader.
+func WithElection(e Election) Option {
+	return func(m *Monitor) {
+		m.election = e
+	}
+}
+
+// Leading reports whether the monitor schedules checks: always without an
+// election or with a queue, and while it leads otherwise.
+func (m *Monitor) Leading() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.election == nil || m.queue != nil || m.leading
+}
+
+// campaignTimeout bounds one campaign, which must end before the next
+// one is due.
+const campaignTimeout = electionTTL / 4
+
+// leaseMargin is how long before its lease lapses a leader that has not
+// renewed it steps down. It exceeds the time between campaigns, so the
+// leader stops before another replica can be elected even if the
+// campaign that would notice comes late.
+const leaseMargin = electionTTL / 2
+
+// lead campaigns until ctx is done, scheduling checks while elected.
+func (m *Monitor) lead(ctx context.Context) error {
+	ticker := time.NewTicker(electionTTL / 3)
+	defer ticker.Stop()
+	return m.campaign(ctx, newOwnerID(), ticker.C, time.Now)
+}
+
+// campaign runs lead for candidate, campaigning at once and then on each
+// tick, with now as the clock. A replica that cannot reach the election
+// steps down, as its lease may have lapsed. So does a leader that has not
+// renewed its lease for electionTTL-leaseMargin, whatever state its last
+// campaign is in: campaigns run apart from this loop so that one that
+// hangs does not keep the leader scheduling past its lease.
+func (m *Monitor) campaign(ctx context.Context, candidate string, ticks <-chan time.Time, now func() time.Time) error {
+	type result struct {
+		leading bool
+		err     error
+		at      time.Time
+	}
+	var (
+		stop      context.CancelFunc
+		done      chan struct{}
+		lastRenew time.Time
+		// results holds the outcome of the one campaign in flight.
+		results  = make(chan result, 1)
+		inFlight bool
+	)
+	start := func() {
+		inFlight = true
+		go func() {
+			at := now()
+			campaignCtx, cancel := context.WithTimeout(ctx, campaignTimeout)
+			defer cancel()
+			leading, err := m.election.Campaign(campaignCtx, candidate, electionTTL)
+			results <- result{leading: leading, err: err, at: at}
+		}()
+	}
+	// lapsing reports whether a lease renewed at renewed is too close to
+	// lapsing to keep scheduling under.
+	lapsing := func(renewed time.Time) bool {
+		return !now().Before(renewed.Add(electionTTL - leaseMargin))
+	}
+	stepDown := func() {
+		stop()
+		<-done
+		stop = nil
+		m.mu.Lock()
+		m.leading = false
+		m.mu.Unlock()
+	}
+
+	start()
+	for {
+		select {
+		case <-ctx.Done():
+			if stop != nil {
+				stepDown()
+				resignCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+				if err := m.election.Resign(resignCtx, candidate); err != nil {
+					log.Printf("Failed to resign monitor leadership: %v", err)
+				}
+				cancel()
+			}
+			return ctx.Err()
+
+		case r := <-results:
+			inFlight = false
+			if r.err != nil && ctx.Err() == nil {
+				log.Printf("Failed to campaign for monitor leadership: %v", r.err)
+			}
+			leading := r.err == nil && r.leading && !lapsing(r.at)
+			switch {
+			case ctx.Err() != nil:
+			case leading:
+				lastRenew = r.at
+				if stop == nil {
+					log.Printf("Elected to schedule monitor checks")
+					m.mu.Lock()
+					m.leading = true
+					m.mu.Unlock()
+					var scheduleCtx context.Context
+					scheduleCtx, stop = context.WithCancel(ctx)
+					done = make(chan struct{})
+					go func() {
+						m.schedule(scheduleCtx)
+						close(done)
+					}()
+				}
+			case stop != nil:
+				log.Printf("No longer scheduling monitor checks")
+				stepDown()
+			}
+
+		case <-ticks:
+			if stop != nil && lapsing(lastRenew) {
+				log.Printf("Monitor leadership was not renewed since %s; no longer scheduling monitor checks", lastRenew.Format(time.RFC3339))
+				stepDown()
+			}
+			if !inFlight {
+				start()
+			}
+		}
+	}
+}
@@ -0,0 +1,406 @@
+package monitor
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gbfs-validator-go/pkg/archive"
+)
+
+const (
+	// feedSync is how often Run reloads the feeds from a FeedStore, picking
+	// up changes made through other replicas.
+	feedSync = 10 * time.Second
+	// feedStoreTimeout bounds each change written to a FeedStore.
+	feedStoreTimeout = 10 * time.Second
+)
+
+// FeedStore keeps the feeds added, changed, and paused through the API.
+// Replicas that share a store, such as a database opened with
+// OpenFeedStore, each accept such changes and load the others' with
+// SyncFeeds, so they all monitor the same feeds. Credentials are stored as
+// the monitor seals them with WithSecrets.
+type FeedStore interface {
+	// Feeds returns the managed feeds in the order they were added, and
+	// the paused state set for other feeds by ID.
+	Feeds(ctx context.Context) (managed []Feed, paused map[string]bool, err error)
+	// AddFeed stores a managed feed, or returns ErrFeedExists.
+	AddFeed(ctx context.Context, f Feed) error
+	// UpdateFeed replaces a managed feed, or returns ErrFeedNotFound.
+	UpdateFeed(ctx context.Context, f Feed) error
+	// RemoveFeed deletes a managed feed, or returns ErrFeedNotFound.
+	RemoveFeed(ctx context.Context, id string) error
+	// SetPaused pauses or resumes any feed, managed or not.
+	SetPaused(ctx context.Context, id string, paused bool) error
+}
+
+// OpenFeedStore returns the feed store named by uri: "memory:" for a store
+// shared by monitors in one process, or a sqlite: or postgres:// database
+// URL as accepted by archive.OpenStore.
+func OpenFeedStore(uri string) (FeedStore, error) {
+	if uri == "memory:" {
+		return NewMemoryFeedStore(), nil
+	}
+	if !strings.HasPrefix(uri, "sqlite:") && !strings.HasPrefix(uri, "postgres://") && !strings.HasPrefix(uri, "postgresql://") {
+		return nil, fmt.Errorf("unsupported feed store %q; want memory:, sqlite:, or postgres://", uri)
+	}
+	db, d, err := archive.OpenDB(uri)
+	if err != nil {
+		return nil, err
+	}
+	s, err := NewSQLFeedStore(context.Background(), db, d)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+// state is what a MemoryFeedStore holds, and the file OpenState writes.
+type state struct {
+	// Feeds are the managed feeds.
+	Feeds []Feed `json:"feeds"`
+	// Paused lists other feeds that were paused.
+	Paused []string `json:"paused,omitempty"`
+	// Resumed lists other feeds that were resumed, such as those paused in
+	// the configuration file.
+	Resumed []string `json:"resumed,omitempty"`
+}
+
+// MemoryFeedStore is a FeedStore in memory.
+type MemoryFeedStore struct {
+	mu sync.Mutex
+	st state
+	// save, if set, persists each change before the store applies it.
+	save func(state) error
+}
+
+// NewMemoryFeedStore returns an empty store.
+func NewMemoryFeedStore() *MemoryFeedStore {
+	return &MemoryFeedStore{st: state{Feeds: []Feed{}}}
+}
+
+// Feeds returns the stored feeds.
+func (s *MemoryFeedStore) Feeds(ctx context.Context) ([]Feed, map[string]bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	paused := make(map[string]bool)
+	for _, id := range s.st.Paused {
+		paused[id] = true
+	}
+	for _, id := range s.st.Resumed {
+		paused[id] = false
+	}
+	return slices.Clone(s.st.Feeds), paused, nil
+}
+
+// index returns the position of a managed feed, or -1. The caller holds
+// s.mu.
+func (s *MemoryFeedStore) index(id string) int {
+	return slices.IndexFunc(s.st.Feeds, func(f Feed) bool { return f.ID == id })
+}
+
+// apply saves a changed copy of the state and then keeps it. The caller
+// holds s.mu.
+func (s *MemoryFeedStore) apply(st state) error {
+	if s.save != nil {
+		if err := s.save(st); err != nil {
+			return err
+		}
+	}
+	s.st = st
+	return nil
+}
+
+// AddFeed stores a managed feed.
+func (s *MemoryFeedStore) AddFeed(ctx context.Context, f Feed) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.index(f.ID) >= 0 {
+		return ErrFeedExists
+	}
+	st := s.st
+	st.Feeds = append(slices.Clone(st.Feeds), f)
+	return s.apply(st)
+}
+
+// UpdateFeed replaces a managed feed.
+func (s *MemoryFeedStore) UpdateFeed(ctx context.Context, f Feed) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	i := s.index(f.ID)
+	if i < 0 {
+		return ErrFeedNotFound
+	}
+	st := s.st
+	st.Feeds = slices.Clone(st.Feeds)
+	st.Feeds[i] = f
+	return s.apply(st)
+}
+
+// RemoveFeed deletes a managed feed.
+func (s *MemoryFeedStore) RemoveFeed(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	i := s.index(id)
+	if i < 0 {
+		return ErrFeedNotFound
+	}
+	st := s.st
+	st.Feeds = slices.Delete(slices.Clone(st.Feeds), i, i+1)
+	return s.apply(st)
+}
+
+// SetPaused pauses or resumes a feed.
+func (s *MemoryFeedStore) SetPaused(ctx context.Context, id string, paused bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	st := s.st
+	if i := s.index(id); i >= 0 {
+		st.Feeds = slices.Clone(st.Feeds)
+		st.Feeds[i].Paused = paused
+		return s.apply(st)
+	}
+	is := func(other string) bool { return other == id }
+	st.Paused = slices.DeleteFunc(slices.Clone(st.Paused), is)
+	st.Resumed = slices.DeleteFunc(slices.Clone(st.Resumed), is)
+	if paused {
+		st.Paused = append(st.Paused, id)
+	} else {
+		st.Resumed = append(st.Resumed, id)
+	}
+	return s.apply(st)
+}
+
+// SQLFeedStore is a FeedStore in the gbfs_monitor_feeds table of a SQLite
+// or Postgres database. Every change is a single statement, so replicas
+// sharing the database cannot overwrite each other's changes to other
+// feeds.
+type SQLFeedStore struct {
+	db      *sql.DB
+	dialect archive.Dialect
+}
+
+// NewSQLFeedStore returns a feed store in db, applying the schema
+// migrations the database needs.
+func NewSQLFeedStore(ctx context.Context, db *sql.DB, d archive.Dialect) (*SQLFeedStore, error) {
+	if err := archive.Migrate(ctx, db, d); err != nil {
+		return nil, fmt.Errorf("migrating %s database: %w", d.Name, err)
+	}
+	return &SQLFeedStore{db: db, dialect: d}, nil
+}
+
+// Feeds returns the stored feeds.
+func (s *SQLFeedStore) Feeds(ctx context.Context) ([]Feed, map[string]bool, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT feed_id, feed, paused FROM gbfs_monitor_feeds ORDER BY added_at, feed_id`)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+	feeds := []Feed{}
+	paused := make(map[string]bool)
+	for rows.Next() {
+		var (
+			id       string
+			data     sql.NullString
+			isPaused bool
+		)
+		if err := rows.Scan(&id, &data, &isPaused); err != nil {
+			return nil, nil, err
+		}
+		if !data.Valid {
+			paused[id] = isPaused
+			continue
+		}
+		var f Feed
+		if err := json.Unmarshal([]byte(data.String), &f); err != nil {
+			return nil, nil, fmt.Errorf("feed %s: %w", id, err)
+		}
+		f.ID, f.Paused = id, isPaused
+		feeds = append(feeds, f)
+	}
+	return feeds, paused, rows.Err()
+}
+
+// AddFeed inserts a managed feed, taking over the row of a feed that only
+// had its paused state stored.
+func (s *SQLFeedStore) AddFeed(ctx context.Context, f Feed) error {
+	data, err := json.Marshal(f)
+	if err != nil {
+		return err
+	}
+	res, err := s.db.ExecContext(ctx, s.dialect.Rebind(`INSERT INTO gbfs_monitor_feeds (feed_id, feed, paused, added_at)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT (feed_id) DO UPDATE SET feed = excluded.feed, paused = excluded.paused, added_at = excluded.added_at
+		WHERE gbfs_monitor_feeds.feed IS NULL`), f.ID, string(data), f.Paused, time.Now().UnixNano())
+	return affected(res, err, ErrFeedExists)
+}
+
+// UpdateFeed replaces a managed feed.
+func (s *SQLFeedStore) UpdateFeed(ctx context.Context, f Feed) error {
+	data, err := json.Marshal(f)
+	if err != nil {
+		return err
+	}
+	res, err := s.db.ExecContext(ctx, s.dialect.Rebind(`UPDATE gbfs_monitor_feeds SET feed = ?, paused = ?
+		WHERE feed_id = ? AND feed IS NOT NULL`), string(data), f.Paused, f.ID)
+	return affected(res, err, ErrFeedNotFound)
+}
+
+// RemoveFeed deletes a managed feed.
+func (s *SQLFeedStore) RemoveFeed(ctx context.Context, id string) error {
+	res, err := s.db.ExecContext(ctx, s.dialect.Rebind(`DELETE FROM gbfs_monitor_feeds WHERE feed_id = ? AND feed IS NOT NULL`), id)
+	return affected(res, err, ErrFeedNotFound)
+}
+
+// SetPaused pauses or resumes a feed.
+func (s *SQLFeedStore) SetPaused(ctx context.Context, id string, paused bool) error {
+	_, err := s.db.ExecContext(ctx, s.dialect.Rebind(`INSERT INTO gbfs_monitor_feeds (feed_id, paused, added_at)
+		VALUES (?, ?, ?)
+		ON CONFLICT (feed_id) DO UPDATE SET paused = excluded.paused`), id, paused, time.Now().UnixNano())
+	return err
+}
+
+// Close closes the database.
+func (s *SQLFeedStore) Close() error {
+	return s.db.Close()
+}
+
+// affected returns errNone if a statement changed no row.
+func affected(res sql.Result, err error, errNone error) error {
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return errNone
+	}
+	return nil
+}
+
+// WithFeedStore keeps the feeds added, changed, and paused through the API
+// in s, loading the feeds it already holds on the first SyncFeeds.
+func WithFeedStore(s FeedStore) Option {
+	return func(m *Monitor) {
+		m.feedStore = s
+	}
+}
+
+// SyncFeeds reloads the managed feeds and paused states from the feed
+// store, scheduling feeds added or changed since the last sync and
+// forgetting removed ones. It does nothing without a feed store.
+func (m *Monitor) SyncFeeds(ctx context.Context) error {
+	if m.feedStore == nil {
+		return nil
+	}
+	m.changeMu.Lock()
+	defer m.changeMu.Unlock()
+	managed, paused, err := m.feedStore.Feeds(ctx)
+	if err != nil {
+		return err
+	}
+	for i := range managed {
+		if managed[i].Auth, err = m.secrets.OpenAuth(managed[i].Auth); err != nil {
+			return fmt.Errorf("feed %s: %w", managed[i].ID, err)
+		}
+		managed[i].normalize()
+		managed[i].managed = true
+	}
+
+	m.mu.Lock()
+	var queued []Feed
+	defer func() {
+		ctx := m.ctx
+		m.mu.Unlock()
+		m.enqueue(ctx, queued...)
+	}()
+	var feeds []Feed
+	for _, f := range m.feeds {
+		if f.managed {
+			continue
+		}
+		if p, ok := paused[f.ID]; ok {
+			f.Paused = p
+		}
+		feeds = append(feeds, f)
+	}
+	for _, f := range managed {
+		if slices.ContainsFunc(feeds, func(other Feed) bool { return other.ID == f.ID }) {
+			return fmt.Errorf("feed %s is also in the configuration file", f.ID)
+		}
+		feeds = append(feeds, f)
+	}
+
+	old := m.feeds
+	m.feeds = feeds
+	for _, f := range feeds {
+		i := slices.IndexFunc(old, func(other Feed) bool { return other.ID == f.ID })
+		if i < 0 || !reflect.DeepEqual(old[i], f) {
+			m.stopLocked(f.ID)
+			if m.startLocked(f) {
+				queued = append(queued, f)
+			}
+		}
+	}
+	for _, f := range old {
+		if m.indexLocked(f.ID) < 0 {
+			m.forgetLocked(f.ID)
+		}
+	}
+	return nil
+}
+
+// syncFeeds calls SyncFeeds every feedSync until ctx is done.
+func (m *Monitor) syncFeeds(ctx context.Context) {
+	ticker := time.NewTicker(feedSync)
+	defer ticker.Stop()
+	for {
+		if err := m.SyncFeeds(ctx); err != nil && ctx.Err() == nil {
+			log.Printf("Failed to load monitor feeds: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// change runs a change against the feed store, if any. The caller holds
+// m.changeMu but not m.mu.
+func (m *Monitor) change(change func(ctx context.Context, s FeedStore) error) error {
+	if m.feedStore == nil {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), feedStoreTimeout)
+	defer cancel()
+	return change(ctx, m.feedStore)
+}
+
+// sealed returns a managed feed with its credentials encrypted for the
+// feed store.
+func (m *Monitor) sealed(f Feed) (Feed, error) {
+	if f.Auth == nil {
+		return f, nil
+	}
+	if m.secrets == nil {
+		return Feed{}, fmt.Errorf("%w: %s", ErrInvalidFeed, errNoSecretKey)
+	}
+	sealed, err := m.secrets.SealAuth(f.Auth)
+	if err != nil {
+		return Feed{}, err
+	}
+	f.Auth = sealed
+	return f, nil
+}
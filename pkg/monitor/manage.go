@@ -0,0 +1,324 @@
+package monitor
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/gbfs-validator-go/pkg/archive"
+	"github.com/gbfs-validator-go/pkg/fetcher"
+	"github.com/gbfs-validator-go/pkg/validator"
+)
+
+var (
+	// ErrFeedNotFound is returned for an unknown feed ID.
+	ErrFeedNotFound = errors.New("feed not found")
+	// ErrFeedNotManaged is returned when changing a feed that comes from the
+	// configuration file.
+	ErrFeedNotManaged = errors.New("feed is defined in the configuration file")
+	// ErrFeedExists is returned when adding a feed whose ID is taken.
+	ErrFeedExists = errors.New("feed already exists")
+	// ErrInvalidFeed is returned for incomplete feed settings.
+	ErrInvalidFeed = errors.New("invalid feed")
+	// ErrNotLeader is returned when changing feeds on a replica that does
+	// not lead its WithElection election and has no WithFeedStore store
+	// through which the leader would see the change.
+	ErrNotLeader = errors.New("this replica does not schedule checks; change feeds on the leader")
+
+	errNoSecretKey = errors.New("credentials cannot be stored without a secret key")
+)
+
+// OpenState persists feeds added, changed, and paused through the API in
+// path, restoring any it already holds. Without it, or a WithFeedStore
+// store, such changes last until the process exits.
+func (m *Monitor) OpenState(path string) error {
+	s := NewMemoryFeedStore()
+	data, err := os.ReadFile(path)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+	case err != nil:
+		return err
+	default:
+		if err := json.Unmarshal(data, &s.st); err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+	}
+	s.save = func(st state) error { return writeState(path, st) }
+	m.feedStore = s
+	if err := m.SyncFeeds(context.Background()); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return nil
+}
+
+// writeState replaces the state file at path.
+func writeState(path string, st state) error {
+	data, err := json.MarshalIndent(st, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".monitor-state-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// writableLocked returns ErrNotLeader if feed changes made on this replica
+// would not reach the one that schedules checks. The caller holds m.mu.
+func (m *Monitor) writableLocked() error {
+	if m.election != nil && !m.leading && m.feedStore == nil {
+		return ErrNotLeader
+	}
+	return nil
+}
+
+// indexLocked returns the position of a feed, or -1. The caller holds m.mu.
+func (m *Monitor) indexLocked(id string) int {
+	for i, f := range m.feeds {
+		if f.ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// FeedStatus returns the latest state of one feed.
+func (m *Monitor) FeedStatus(id string) (FeedStatus, error) {
+	incidents := m.Incidents()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	i := m.indexLocked(id)
+	if i < 0 {
+		return FeedStatus{}, ErrFeedNotFound
+	}
+	status := m.statusLocked(m.feeds[i])
+	for _, e := range incidents {
+		if e.FeedID == id {
+			status.Incidents = append(status.Incidents, e)
+		}
+	}
+	return status, nil
+}
+
+// LastResult returns the result of a feed's last successful run, if any.
+// Replicas that have not checked the feed themselves, such as those not
+// elected to schedule checks, load it from the WithStore store.
+func (m *Monitor) LastResult(id string) (*validator.ValidationResult, error) {
+	m.mu.Lock()
+	i := m.indexLocked(id)
+	if i < 0 {
+		m.mu.Unlock()
+		return nil, ErrFeedNotFound
+	}
+	run, ok := m.status[id]
+	feedURL, store := m.feeds[i].URL, m.store
+	m.mu.Unlock()
+	if ok {
+		return run.result, nil
+	}
+	if store == nil {
+		return nil, nil
+	}
+	ctx := context.Background()
+	runs, err := store.ListRuns(ctx, archive.FeedKey(feedURL))
+	if err != nil || len(runs) == 0 {
+		return nil, err
+	}
+	return store.GetRun(ctx, archive.FeedKey(feedURL), runs[len(runs)-1])
+}
+
+// AddFeed starts monitoring a feed. Its tenant, if set, must be configured.
+// With WithElection but no WithFeedStore, only the leader changes feeds.
+func (m *Monitor) AddFeed(f Feed) (FeedStatus, error) {
+	if f.URL == "" {
+		return FeedStatus{}, fmt.Errorf("%w: no url", ErrInvalidFeed)
+	}
+	if f.Tenant != "" && !m.hasTenant(f.Tenant) {
+		return FeedStatus{}, fmt.Errorf("%w: unknown tenant %s", ErrInvalidFeed, f.Tenant)
+	}
+	f.normalize()
+	f.managed = true
+
+	m.changeMu.Lock()
+	defer m.changeMu.Unlock()
+	m.mu.Lock()
+	err := m.writableLocked()
+	if err == nil && m.indexLocked(f.ID) >= 0 {
+		err = ErrFeedExists
+	}
+	m.mu.Unlock()
+	if err != nil {
+		return FeedStatus{}, err
+	}
+	if err := m.change(func(ctx context.Context, s FeedStore) error {
+		stored, err := m.sealed(f)
+		if err != nil {
+			return err
+		}
+		return s.AddFeed(ctx, stored)
+	}); err != nil {
+		return FeedStatus{}, err
+	}
+
+	m.mu.Lock()
+	m.feeds = append(m.feeds, f)
+	queued := m.startLocked(f)
+	status, ctx := m.statusLocked(f), m.ctx
+	m.mu.Unlock()
+	if queued {
+		m.enqueue(ctx, f)
+	}
+	return status, nil
+}
+
+// UpdateFeed replaces a managed feed's settings, keeping its ID and tenant.
+// A nil Auth keeps the stored credentials, so clients never need to send
+// them back; an auth of type "none" removes them.
+func (m *Monitor) UpdateFeed(id string, f Feed) (FeedStatus, error) {
+	if f.URL == "" {
+		return FeedStatus{}, fmt.Errorf("%w: no url", ErrInvalidFeed)
+	}
+
+	m.changeMu.Lock()
+	defer m.changeMu.Unlock()
+	m.mu.Lock()
+	err := m.writableLocked()
+	i := m.indexLocked(id)
+	var old Feed
+	switch {
+	case err != nil:
+	case i < 0:
+		err = ErrFeedNotFound
+	case !m.feeds[i].managed:
+		err = ErrFeedNotManaged
+	default:
+		old = m.feeds[i]
+	}
+	m.mu.Unlock()
+	if err != nil {
+		return FeedStatus{}, err
+	}
+	f.ID, f.Tenant, f.managed = old.ID, old.Tenant, true
+	switch {
+	case f.Auth == nil:
+		f.Auth = old.Auth
+	case f.Auth.Type == fetcher.AuthNone:
+		f.Auth = nil
+	}
+	f.normalize()
+
+	if err := m.change(func(ctx context.Context, s FeedStore) error {
+		stored, err := m.sealed(f)
+		if err != nil {
+			return err
+		}
+		return s.UpdateFeed(ctx, stored)
+	}); err != nil {
+		return FeedStatus{}, err
+	}
+
+	m.mu.Lock()
+	m.feeds[i] = f
+	m.stopLocked(id)
+	queued := m.startLocked(f)
+	status, ctx := m.statusLocked(f), m.ctx
+	m.mu.Unlock()
+	if queued {
+		m.enqueue(ctx, f)
+	}
+	return status, nil
+}
+
+// RemoveFeed stops monitoring a managed feed and forgets its status.
+func (m *Monitor) RemoveFeed(id string) error {
+	m.changeMu.Lock()
+	defer m.changeMu.Unlock()
+	m.mu.Lock()
+	err := m.writableLocked()
+	i := m.indexLocked(id)
+	switch {
+	case err != nil:
+	case i < 0:
+		err = ErrFeedNotFound
+	case !m.feeds[i].managed:
+		err = ErrFeedNotManaged
+	}
+	m.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	if err := m.change(func(ctx context.Context, s FeedStore) error {
+		return s.RemoveFeed(ctx, id)
+	}); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.feeds = slices.Delete(m.feeds, i, i+1)
+	m.forgetLocked(id)
+	return nil
+}
+
+// forgetLocked stops a removed feed's schedule and forgets its status. The
+// caller holds m.mu.
+func (m *Monitor) forgetLocked(id string) {
+	m.stopLocked(id)
+	delete(m.status, id)
+	delete(m.history, id)
+	delete(m.churn, id)
+	delete(m.rotation, id)
+	delete(m.changes, id)
+}
+
+// SetPaused pauses or resumes checks of any feed. Open incidents stay open
+// while a feed is paused. The state is stored even if this replica already
+// has it, as another may have changed it since the last SyncFeeds.
+func (m *Monitor) SetPaused(id string, paused bool) (FeedStatus, error) {
+	m.changeMu.Lock()
+	defer m.changeMu.Unlock()
+	m.mu.Lock()
+	err := m.writableLocked()
+	i := m.indexLocked(id)
+	if err == nil && i < 0 {
+		err = ErrFeedNotFound
+	}
+	m.mu.Unlock()
+	if err != nil {
+		return FeedStatus{}, err
+	}
+	if err := m.change(func(ctx context.Context, s FeedStore) error {
+		return s.SetPaused(ctx, id, paused)
+	}); err != nil {
+		return FeedStatus{}, err
+	}
+
+	m.mu.Lock()
+	queued := false
+	if m.feeds[i].Paused != paused {
+		m.feeds[i].Paused = paused
+		m.stopLocked(id)
+		queued = m.startLocked(m.feeds[i])
+	}
+	f := m.feeds[i]
+	status, ctx := m.statusLocked(f), m.ctx
+	m.mu.Unlock()
+	if queued {
+		m.enqueue(ctx, f)
+	}
+	return status, nil
+}
@@ -0,0 +1,527 @@
+// Package monitor validates a set of feeds on a schedule, archives the
+// results, and raises incidents when they degrade.
+package monitor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/gbfs-validator-go/pkg/archive"
+	"github.com/gbfs-validator-go/pkg/fetcher"
+	"github.com/gbfs-validator-go/pkg/notify"
+	"github.com/gbfs-validator-go/pkg/secret"
+	"github.com/gbfs-validator-go/pkg/validator"
+)
+
+// defaultInterval is used for feeds without an interval.
+const defaultInterval = 5 * time.Minute
+
+// Duration is a time.Duration written in JSON as "30s", "5m", or "1d".
+type Duration time.Duration
+
+// UnmarshalJSON parses a duration string.
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	v, err := archive.ParseAge(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+	return nil
+}
+
+// MarshalJSON writes the duration as a string.
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// Feed is a monitored feed.
+type Feed struct {
+	// ID names the feed in incidents; it defaults to its archive key.
+	ID           string              `json:"id,omitempty"`
+	URL          string              `json:"url"`
+	Interval     Duration            `json:"interval,omitempty"`
+	Auth         *fetcher.AuthConfig `json:"auth,omitempty"`
+	Docked       bool                `json:"docked,omitempty"`
+	Freefloating bool                `json:"freefloating,omitempty"`
+	// Tenant is the ID of the tenant the feed belongs to, if any.
+	Tenant string `json:"tenant,omitempty"`
+	// Paused feeds are not checked.
+	Paused bool `json:"paused,omitempty"`
+	// FrozenAfter is how long a station's availability may stay unchanged
+	// before it is reported as frozen; it defaults to one day.
+	FrozenAfter Duration `json:"frozenAfter,omitempty"`
+	// RotationThreshold is how many trips a vehicle ID may survive before
+	// it is reported as not rotated; it defaults to 3.
+	RotationThreshold int `json:"rotationThreshold,omitempty"`
+	// UnchangedAfter is how long a file's content may stay the same while
+	// its ttl is shorter before it is reported as stale; it defaults to one
+	// hour.
+	UnchangedAfter Duration `json:"unchangedAfter,omitempty"`
+
+	// managed is set for feeds added through the API.
+	managed bool
+}
+
+// AlertConfig selects incident integrations.
+type AlertConfig struct {
+	PagerDuty *notify.PagerDuty `json:"pagerduty,omitempty"`
+	Opsgenie  *notify.Opsgenie  `json:"opsgenie,omitempty"`
+	// TriggerAfter is how many consecutive failing runs open an incident.
+	TriggerAfter int `json:"triggerAfter,omitempty"`
+	// ResolveAfter is how many consecutive passing runs resolve it.
+	ResolveAfter int `json:"resolveAfter,omitempty"`
+}
+
+// Alerters returns the configured integrations.
+func (c AlertConfig) Alerters() notify.Alerters {
+	var as notify.Alerters
+	if c.PagerDuty != nil {
+		as = append(as, c.PagerDuty)
+	}
+	if c.Opsgenie != nil {
+		as = append(as, c.Opsgenie)
+	}
+	return as
+}
+
+// Config is the monitor configuration file. Feeds and Alerts outside any
+// tenant are operated by the instance owner.
+type Config struct {
+	Feeds   []Feed      `json:"feeds"`
+	Alerts  AlertConfig `json:"alerts"`
+	Tenants []Tenant    `json:"tenants,omitempty"`
+}
+
+// LoadConfig reads a monitor configuration file.
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var cfg Config
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	for i := range cfg.Feeds {
+		if cfg.Feeds[i].URL == "" {
+			return nil, fmt.Errorf("%s: feed %d has no url", path, i)
+		}
+		cfg.Feeds[i].normalize()
+	}
+	tenants := make(map[string]bool)
+	for i := range cfg.Tenants {
+		if err := cfg.Tenants[i].validate(); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		if tenants[cfg.Tenants[i].ID] {
+			return nil, fmt.Errorf("%s: duplicate tenant %s", path, cfg.Tenants[i].ID)
+		}
+		tenants[cfg.Tenants[i].ID] = true
+	}
+	ids := make(map[string]bool)
+	for _, f := range cfg.AllFeeds() {
+		if ids[f.ID] {
+			return nil, fmt.Errorf("%s: duplicate feed id %s", path, f.ID)
+		}
+		ids[f.ID] = true
+	}
+	return &cfg, nil
+}
+
+// OpenSecrets decrypts the feed credentials and alert keys in the
+// configuration. Values sealed with secret.Box are only accepted when b is
+// set.
+func (c *Config) OpenSecrets(b *secret.Box) error {
+	open := func(feeds []Feed, alerts *AlertConfig) error {
+		for i := range feeds {
+			auth, err := b.OpenAuth(feeds[i].Auth)
+			if err != nil {
+				return fmt.Errorf("feed %s: %w", feeds[i].ID, err)
+			}
+			feeds[i].Auth = auth
+		}
+		var err error
+		if alerts.PagerDuty != nil {
+			if alerts.PagerDuty.RoutingKey, err = b.Open(alerts.PagerDuty.RoutingKey); err != nil {
+				return fmt.Errorf("pagerduty: %w", err)
+			}
+		}
+		if alerts.Opsgenie != nil {
+			if alerts.Opsgenie.APIKey, err = b.Open(alerts.Opsgenie.APIKey); err != nil {
+				return fmt.Errorf("opsgenie: %w", err)
+			}
+		}
+		return nil
+	}
+	if err := open(c.Feeds, &c.Alerts); err != nil {
+		return err
+	}
+	for i := range c.Tenants {
+		if err := open(c.Tenants[i].Feeds, &c.Tenants[i].Alerts); err != nil {
+			return fmt.Errorf("tenant %s: %w", c.Tenants[i].ID, err)
+		}
+	}
+	return nil
+}
+
+// AllFeeds returns the instance feeds followed by every tenant's feeds.
+func (c *Config) AllFeeds() []Feed {
+	feeds := append([]Feed(nil), c.Feeds...)
+	for _, t := range c.Tenants {
+		feeds = append(feeds, t.Feeds...)
+	}
+	return feeds
+}
+
+// normalize fills in defaults.
+func (f *Feed) normalize() {
+	if f.ID == "" {
+		f.ID = archive.FeedKey(f.URL)
+	}
+	if f.Interval <= 0 {
+		f.Interval = Duration(defaultInterval)
+	}
+	if f.FrozenAfter <= 0 {
+		f.FrozenAfter = Duration(defaultFrozenAfter)
+	}
+	if f.RotationThreshold <= 0 {
+		f.RotationThreshold = defaultRotationThreshold
+	}
+	if f.UnchangedAfter <= 0 {
+		f.UnchangedAfter = Duration(defaultUnchangedAfter)
+	}
+}
+
+// Monitor runs scheduled validations.
+type Monitor struct {
+	feeds   []Feed
+	options validator.Options
+	archive *archive.Archive
+	store   archive.Store
+	queue   Queue
+	// workers is the WithQueueWorkers limit.
+	workers int
+	alerter notify.Alerter
+	tracker *notify.Tracker
+
+	// election, if set, elects the replica that schedules checks; leading
+	// is set while this one does.
+	election Election
+	leading  bool
+
+	tenants        []Tenant
+	tenantAlerters map[string]notify.Alerter
+	fetcherOpts    []fetcher.Option
+
+	mu     sync.Mutex
+	status map[string]*runState
+	// history holds recent runs for availability reporting.
+	history map[string][]sample
+	// churn tracks station availability changes by feed and station.
+	churn map[string]map[string]*stationState
+	// rotation tracks vehicle IDs by feed and ID.
+	rotation map[string]map[string]*vehicleState
+	// changes tracks file content hashes by feed and file.
+	changes map[string]map[string]*fileState
+	// feedStore keeps feeds managed through the API; see WithFeedStore
+	// and OpenState.
+	feedStore FeedStore
+	// changeMu serializes changes to the feed list, SyncFeeds included,
+	// so they can read and write feedStore without holding mu.
+	changeMu sync.Mutex
+	secrets  *secret.Box
+	// ctx is set while Run is scheduling; runners cancel each feed's loop.
+	ctx     context.Context
+	runners map[string]context.CancelFunc
+	wg      sync.WaitGroup
+}
+
+// Option configures a Monitor.
+type Option func(*Monitor)
+
+// WithArchive stores every run in a.
+func WithArchive(a *archive.Archive) Option {
+	return func(m *Monitor) {
+		m.archive = a
+	}
+}
+
+// WithStore also stores every run's result in s, such as a database opened
+// with archive.OpenStore.
+func WithStore(s archive.Store) Option {
+	return func(m *Monitor) {
+		m.store = s
+	}
+}
+
+// WithAlerter sends incident events to a.
+func WithAlerter(a notify.Alerter) Option {
+	return func(m *Monitor) {
+		m.alerter = a
+	}
+}
+
+// WithHysteresis dampens flapping feeds; see notify.WithHysteresis.
+func WithHysteresis(triggerAfter, resolveAfter int) Option {
+	return func(m *Monitor) {
+		m.tracker = notify.NewTracker(notify.WithHysteresis(triggerAfter, resolveAfter))
+	}
+}
+
+// WithFetcherOptions applies fetcher options, such as a user agent or
+// contact header, to every feed check; a feed's credentials are added to
+// them.
+func WithFetcherOptions(opts ...fetcher.Option) Option {
+	return func(m *Monitor) {
+		m.fetcherOpts = opts
+	}
+}
+
+// WithOptions sets base validator options; per-feed settings override them.
+func WithOptions(opts validator.Options) Option {
+	return func(m *Monitor) {
+		m.options = opts
+	}
+}
+
+// WithSecrets encrypts the credentials of feeds kept in a feed store, such
+// as the file of OpenState. Without it, feeds with credentials cannot be
+// stored.
+func WithSecrets(b *secret.Box) Option {
+	return func(m *Monitor) {
+		m.secrets = b
+	}
+}
+
+// WithTenants adds tenants, their feeds, and their alert targets.
+func WithTenants(tenants []Tenant) Option {
+	return func(m *Monitor) {
+		for _, t := range tenants {
+			m.tenants = append(m.tenants, t)
+			if alerters := t.Alerts.Alerters(); len(alerters) > 0 {
+				m.tenantAlerters[t.ID] = alerters
+			}
+			for _, f := range t.Feeds {
+				f.Tenant = t.ID
+				f.normalize()
+				m.feeds = append(m.feeds, f)
+			}
+		}
+	}
+}
+
+// New returns a monitor for feeds.
+func New(feeds []Feed, opts ...Option) *Monitor {
+	m := &Monitor{
+		tracker:        notify.NewTracker(),
+		tenantAlerters: make(map[string]notify.Alerter),
+		status:         make(map[string]*runState),
+		history:        make(map[string][]sample),
+		churn:          make(map[string]map[string]*stationState),
+		rotation:       make(map[string]map[string]*vehicleState),
+		changes:        make(map[string]map[string]*fileState),
+		runners:        make(map[string]context.CancelFunc),
+	}
+	for _, f := range feeds {
+		f.normalize()
+		m.feeds = append(m.feeds, f)
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
+}
+
+// Incidents returns the open incidents: with WithQueue, those of every
+// replica sharing the queue.
+func (m *Monitor) Incidents() []notify.Event {
+	if m.queue != nil {
+		events, err := m.queue.OpenIncidents(context.Background())
+		if err == nil {
+			return events
+		}
+		log.Printf("Failed to load incidents from the queue: %v", err)
+	}
+	return m.tracker.Open()
+}
+
+// Run checks every feed that is not paused on its interval until ctx is
+// done. Feeds added or resumed while it runs are scheduled as well, as are
+// those changed through other replicas sharing its WithFeedStore store.
+// With WithQueue, the checks are shared with the other replicas using the
+// queue; otherwise, with WithElection, only the elected replica checks
+// feeds.
+func (m *Monitor) Run(ctx context.Context) error {
+	if m.feedStore != nil {
+		synced := make(chan struct{})
+		go func() {
+			m.syncFeeds(ctx)
+			close(synced)
+		}()
+		defer func() { <-synced }()
+	}
+	if m.election != nil && m.queue == nil {
+		return m.lead(ctx)
+	}
+	return m.schedule(ctx)
+}
+
+// schedule checks feeds until ctx is done.
+func (m *Monitor) schedule(ctx context.Context) error {
+	m.mu.Lock()
+	m.ctx = ctx
+	var queued []Feed
+	for _, f := range m.feeds {
+		if m.startLocked(f) {
+			queued = append(queued, f)
+		}
+	}
+	m.mu.Unlock()
+	m.enqueue(ctx, queued...)
+
+	if m.queue != nil {
+		m.work(ctx)
+	}
+	<-ctx.Done()
+	m.wg.Wait()
+	return ctx.Err()
+}
+
+// startLocked schedules a feed if Run is active. With a queue, it reports
+// that the feed is to be enqueued instead, which the caller does with
+// enqueue once it has released m.mu. The caller holds m.mu.
+func (m *Monitor) startLocked(f Feed) bool {
+	if m.ctx == nil || m.ctx.Err() != nil || f.Paused {
+		return false
+	}
+	if m.queue != nil {
+		return true
+	}
+	ctx, cancel := context.WithCancel(m.ctx)
+	m.runners[f.ID] = cancel
+	m.wg.Add(1)
+	go func() {
+		defer m.wg.Done()
+		ticker := time.NewTicker(time.Duration(f.Interval))
+		defer ticker.Stop()
+		for {
+			m.Check(ctx, f)
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	return false
+}
+
+// enqueue adds feeds that startLocked left to the queue. The caller must
+// not hold m.mu.
+func (m *Monitor) enqueue(ctx context.Context, feeds ...Feed) {
+	for _, f := range feeds {
+		if err := m.queue.Enqueue(ctx, f.ID, time.Duration(f.Interval)); err != nil {
+			log.Printf("Failed to enqueue %s: %v", f.ID, err)
+		}
+	}
+}
+
+// stopLocked stops a feed's schedule. The caller holds m.mu. With a queue,
+// the feed stays queued for the other replicas and this one stops
+// dequeuing it.
+func (m *Monitor) stopLocked(id string) {
+	if cancel, ok := m.runners[id]; ok {
+		cancel()
+		delete(m.runners, id)
+	}
+}
+
+// lastResult returns the result of a feed's last successful run, if any.
+func (m *Monitor) lastResult(id string) *validator.ValidationResult {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if run, ok := m.status[id]; ok && run.err == nil {
+		return run.result
+	}
+	return nil
+}
+
+// Check validates one feed, archives the result, and sends any incident
+// events it causes. Files unchanged since the feed's last run reuse its
+// schema findings.
+func (m *Monitor) Check(ctx context.Context, f Feed) (*validator.ValidationResult, error) {
+	opts := m.options
+	opts.Docked = opts.Docked || f.Docked
+	opts.Freefloating = opts.Freefloating || f.Freefloating
+	opts.Previous = m.lastResult(f.ID)
+
+	fetcherOpts := append([]fetcher.Option{}, m.fetcherOpts...)
+	if f.Auth != nil {
+		fetcherOpts = append(fetcherOpts, fetcher.WithAuth(f.Auth))
+	}
+	v := validator.New(fetcher.New(fetcherOpts...), opts)
+
+	at := time.Now()
+	result, err := v.Validate(ctx, f.URL)
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	m.record(f, at, result, err)
+
+	// With a queue, the feed's incident state is the queue's. If it cannot
+	// be loaded, the check neither stores nor alerts on a state it did not
+	// start from.
+	shared := true
+	if m.queue != nil {
+		st, err := m.queue.Incidents(ctx, f.ID)
+		if err != nil {
+			log.Printf("Failed to load incidents of %s; skipping its alerts: %v", f.ID, err)
+			shared = false
+		} else {
+			m.tracker.SetFeedState(f.ID, st)
+		}
+	}
+	var events []notify.Event
+	if err != nil {
+		events = m.tracker.ObserveError(f.ID, f.URL, err)
+	} else {
+		events = m.tracker.Observe(f.ID, f.URL, result)
+		if m.archive != nil {
+			if err := m.archive.Save(ctx, f.URL, at, result); err != nil {
+				log.Printf("Failed to archive %s: %v", f.ID, err)
+			}
+		}
+		if m.store != nil {
+			if err := m.store.SaveRun(ctx, f.URL, at, result); err != nil {
+				log.Printf("Failed to store %s: %v", f.ID, err)
+			}
+		}
+	}
+	if !shared {
+		return result, err
+	}
+	if m.queue != nil {
+		if err := m.queue.SetIncidents(ctx, f.ID, m.tracker.FeedState(f.ID)); err != nil {
+			log.Printf("Failed to store incidents of %s: %v", f.ID, err)
+		}
+	}
+
+	if alerter := m.alerterFor(f.Tenant); alerter != nil {
+		for _, e := range events {
+			if err := alerter.Send(ctx, e); err != nil {
+				log.Printf("Failed to send %s for %s: %v", e.Action, e.DedupKey, err)
+			}
+		}
+	}
+	return result, err
+}
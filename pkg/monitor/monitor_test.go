@@ -0,0 +1,923 @@
+package monitor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gbfs-validator-go/pkg/archive"
+	"github.com/gbfs-validator-go/pkg/genfeed"
+	"github.com/gbfs-validator-go/pkg/notify"
+	"github.com/gbfs-validator-go/pkg/validator"
+)
+
+// recorder collects sent events.
+type recorder struct{ events []notify.Event }
+
+func (r *recorder) Send(ctx context.Context, e notify.Event) error {
+	r.events = append(r.events, e)
+	return nil
+}
+
+func TestCheckOpensAndResolvesIncidents(t *testing.T) {
+	cfg := genfeed.DefaultConfig()
+	cfg.Stations, cfg.Vehicles, cfg.Geofences = 5, 10, 1
+	feed, err := genfeed.Generate(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var down atomic.Bool
+	handler := feed.Handler()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if down.Load() {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		handler.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+	rec := &recorder{}
+	a := archive.New(&archive.DirStore{Root: t.TempDir()}, "")
+	f := Feed{ID: "demo", URL: server.URL + "/gbfs.json"}
+	m := New([]Feed{f}, WithAlerter(rec), WithArchive(a))
+
+	if _, err := m.Check(ctx, f); err != nil {
+		t.Fatal(err)
+	}
+	if len(rec.events) != 0 {
+		t.Fatalf("healthy feed raised %+v", rec.events)
+	}
+
+	down.Store(true)
+	m.Check(ctx, f)
+	if len(rec.events) != 1 || rec.events[0].Action != notify.ActionTrigger || rec.events[0].File != "gbfs.json" {
+		t.Fatalf("outage events = %+v", rec.events)
+	}
+
+	down.Store(false)
+	m.Check(ctx, f)
+	if len(rec.events) != 2 || rec.events[1].Action != notify.ActionResolve {
+		t.Fatalf("recovery events = %+v", rec.events)
+	}
+	if runs, _ := a.Runs(ctx, f.URL); len(runs) == 0 {
+		t.Error("runs were not archived")
+	}
+}
+
+func TestTenants(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+
+	m := New([]Feed{{ID: "own", URL: server.URL + "/own/gbfs.json"}}, WithTenants([]Tenant{
+		{ID: "acme", APIKeys: []string{"acme-key"}, Feeds: []Feed{{ID: "acme-bikes", URL: server.URL + "/acme/gbfs.json"}}},
+		{ID: "zoom", APIKeys: []string{"zoom-key"}, Feeds: []Feed{{ID: "zoom-scooters", URL: server.URL + "/zoom/gbfs.json"}}},
+	}))
+
+	tenant, role, ok := m.TenantForKey("zoom-key")
+	if !ok || tenant.ID != "zoom" || role != RoleViewer {
+		t.Fatalf("TenantForKey = %v, %v, %v", tenant.ID, role, ok)
+	}
+	if _, _, ok := m.TenantForKey("wrong"); ok {
+		t.Error("unknown key matched a tenant")
+	}
+
+	m.Check(context.Background(), m.feeds[1])
+	status := m.Status("acme")
+	if len(status) != 1 || status[0].ID != "acme-bikes" || status[0].LastRun == nil {
+		t.Fatalf("acme status = %+v", status)
+	}
+	if len(status[0].Incidents) == 0 {
+		t.Errorf("acme incidents = %+v", status[0].Incidents)
+	}
+	if zoom := m.Status("zoom"); len(zoom) != 1 || zoom[0].LastRun != nil {
+		t.Errorf("zoom status = %+v", zoom)
+	}
+	if all := m.Status(""); len(all) != 3 {
+		t.Errorf("all status has %d feeds, want 3", len(all))
+	}
+
+	key := archive.FeedKey(server.URL + "/acme/gbfs.json")
+	if !m.OwnsFeedKey("acme", key) || m.OwnsFeedKey("zoom", key) {
+		t.Error("OwnsFeedKey does not scope feeds to their tenant")
+	}
+}
+
+func TestAvailability(t *testing.T) {
+	m := New([]Feed{{ID: "bikes", URL: "https://example.com/gbfs.json"}})
+	f := m.feeds[0]
+	now := time.Now()
+	valid := &validator.ValidationResult{Files: []validator.FileValidationResult{
+		{File: "gbfs.json", Status: validator.FileStatusValid},
+		{File: "station_status.json", Status: validator.FileStatusValid},
+	}}
+	invalid := &validator.ValidationResult{Files: []validator.FileValidationResult{
+		{File: "gbfs.json", Status: validator.FileStatusValid},
+		{File: "station_status.json", Status: validator.FileStatusInvalid},
+	}}
+
+	m.record(f, now.Add(-40*24*time.Hour), valid, nil)
+	m.record(f, now.Add(-3*24*time.Hour), nil, errors.New("timeout"))
+	m.record(f, now.Add(-2*time.Hour), invalid, nil)
+	m.record(f, now.Add(-time.Hour), valid, nil)
+
+	a, err := m.FeedAvailability("bikes", now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(m.history["bikes"]) != 3 {
+		t.Errorf("expected runs older than 30 days to be dropped, have %d", len(m.history["bikes"]))
+	}
+	if day := a.Windows["24h"]; day.Runs != 2 || *day.Percent != 50 {
+		t.Errorf("24h = %+v", day)
+	}
+	if week := a.Windows["7d"]; week.Runs != 3 || *week.Percent != 33.333 {
+		t.Errorf("7d = %+v", week)
+	}
+	if file := a.Files["gbfs.json"]["7d"]; file.Runs != 2 || *file.Percent != 100 {
+		t.Errorf("gbfs.json 7d = %+v", file)
+	}
+	if file := a.Files["station_status.json"]["24h"]; *file.Percent != 50 {
+		t.Errorf("station_status.json 24h = %+v", file)
+	}
+}
+
+func TestChurn(t *testing.T) {
+	m := New([]Feed{{ID: "bikes", URL: "https://example.com/gbfs.json", FrozenAfter: Duration(2 * time.Hour)}})
+	f := m.feeds[0]
+	poll := func(at time.Time, moving int) {
+		data := fmt.Sprintf(`{"data":{"stations":[
+			{"station_id":"busy","num_vehicles_available":%d,"num_docks_available":3},
+			{"station_id":"stuck","num_vehicles_available":4,"num_docks_available":3},
+			{"station_id":"closed","num_vehicles_available":0,"is_installed":false}]}}`, moving)
+		m.record(f, at, &validator.ValidationResult{Files: []validator.FileValidationResult{
+			{File: "station_status.json", Exists: true, Status: validator.FileStatusValid, RawData: []byte(data)},
+		}}, nil)
+	}
+	start := time.Now().Add(-3 * time.Hour)
+	for i := 0; i < 4; i++ {
+		poll(start.Add(time.Duration(i)*time.Hour), i%2)
+	}
+
+	report, err := m.Churn("bikes", start.Add(3*time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Frozen != 1 || len(report.Stations) != 2 || report.Stations[0].ID != "stuck" || !report.Stations[0].Frozen {
+		t.Fatalf("unexpected report: %+v", report)
+	}
+	if busy := report.Stations[1]; busy.Changes != 3 || busy.Polls != 4 {
+		t.Errorf("busy = %+v", busy)
+	}
+	if report.ChangeRate != 0.5 {
+		t.Errorf("change rate = %v, want 0.5", report.ChangeRate)
+	}
+	if status, _ := m.FeedStatus("bikes"); status.FrozenStations != 1 {
+		t.Errorf("status reports %d frozen stations", status.FrozenStations)
+	}
+}
+
+func TestRotation(t *testing.T) {
+	m := New([]Feed{{ID: "scooters", URL: "https://example.com/gbfs.json", RotationThreshold: 2}})
+	f := m.feeds[0]
+	poll := func(at time.Time, vehicles string) {
+		m.record(f, at, &validator.ValidationResult{
+			Summary: validator.ValidationSummary{Version: validator.VersionInfo{Validated: "3.0"}},
+			Files: []validator.FileValidationResult{
+				{File: "vehicle_status.json", Exists: true, Status: validator.FileStatusValid, RawData: []byte(`{"data":{"vehicles":[` + vehicles + `]}}`)},
+			},
+		}, nil)
+	}
+	start := time.Now()
+	// "sticky" is rented, returns, then moves a kilometer; "parked" only
+	// drifts a few meters.
+	poll(start, `{"vehicle_id":"sticky","lat":45.5,"lon":-73.6},{"vehicle_id":"parked","lat":45.5,"lon":-73.6}`)
+	poll(start.Add(time.Minute), `{"vehicle_id":"parked","lat":45.50001,"lon":-73.6}`)
+	poll(start.Add(2*time.Minute), `{"vehicle_id":"sticky","lat":45.51,"lon":-73.6},{"vehicle_id":"parked","lat":45.5,"lon":-73.6}`)
+	poll(start.Add(3*time.Minute), `{"vehicle_id":"sticky","lat":45.52,"lon":-73.6},{"vehicle_id":"parked","lat":45.5,"lon":-73.60001}`)
+
+	report, err := m.Rotation("scooters")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !report.Applicable || report.Vehicles != 2 {
+		t.Errorf("unexpected report: %+v", report)
+	}
+	if len(report.Persistent) != 1 || report.Persistent[0].ID != "sticky" || report.Persistent[0].Transitions != 2 {
+		t.Errorf("persistent = %+v", report.Persistent)
+	}
+}
+
+func TestUnchanged(t *testing.T) {
+	m := New([]Feed{{ID: "bikes", URL: "https://example.com/gbfs.json"}})
+	f := m.feeds[0]
+	poll := func(at time.Time, updated int) {
+		status := fmt.Sprintf(`{"last_updated":%d,"ttl":60,"data":{"stations":[]}}`, updated)
+		info := `{"last_updated":1,"ttl":86400,"data":{"stations":[]}}`
+		m.record(f, at, &validator.ValidationResult{Files: []validator.FileValidationResult{
+			{File: "station_status.json", Exists: true, RawData: []byte(status), Hash: fmt.Sprint(updated)},
+			{File: "station_information.json", Exists: true, RawData: []byte(info), Hash: "info"},
+		}}, nil)
+	}
+	start := time.Now().Add(-3 * time.Hour)
+	poll(start, 1)
+	poll(start.Add(time.Hour), 2)
+	poll(start.Add(2*time.Hour), 2)
+	poll(start.Add(3*time.Hour), 2)
+
+	report, err := m.Unchanged("bikes", start.Add(3*time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Stale != 1 || len(report.Files) != 2 {
+		t.Fatalf("unexpected report: %+v", report)
+	}
+	stale := report.Files[0]
+	if stale.File != "station_status.json" || !stale.Stale || stale.Polls != 3 || stale.TTL != 60 {
+		t.Errorf("station_status.json = %+v", stale)
+	}
+	if want := "station_status.json unchanged for 2h0m0s despite ttl=60"; stale.Message != want {
+		t.Errorf("message = %q, want %q", stale.Message, want)
+	}
+	if report.Files[1].Stale {
+		t.Errorf("station_information.json with a one-day ttl reported stale")
+	}
+}
+
+func TestQueueSharesChecks(t *testing.T) {
+	var hits atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/gbfs.json" {
+			hits.Add(1)
+		}
+		http.NotFound(w, r)
+	}))
+	defer server.Close()
+
+	q := NewMemoryQueue()
+	feeds := []Feed{{ID: "bikes", URL: server.URL + "/gbfs.json", Interval: Duration(time.Hour)}}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 2)
+	for i := 0; i < 2; i++ {
+		m := New(feeds, WithQueue(q))
+		go func() { done <- m.Run(ctx) }()
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for hits.Load() == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	time.Sleep(100 * time.Millisecond)
+	cancel()
+	<-done
+	<-done
+	if n := hits.Load(); n != 1 {
+		t.Errorf("feed checked %d times by two replicas, want 1", n)
+	}
+}
+
+func TestQueueWorkers(t *testing.T) {
+	var running, most atomic.Int32
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := running.Add(1)
+		defer running.Add(-1)
+		for m := most.Load(); n > m && !most.CompareAndSwap(m, n); m = most.Load() {
+		}
+		<-release
+		http.NotFound(w, r)
+	}))
+	defer server.Close()
+
+	q := NewMemoryQueue()
+	var feeds []Feed
+	for _, id := range []string{"a", "b", "c"} {
+		feeds = append(feeds, Feed{ID: id, URL: server.URL + "/" + id + "/gbfs.json", Interval: Duration(time.Hour)})
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	m := New(feeds, WithQueue(q), WithQueueWorkers(1))
+	done := make(chan error, 1)
+	go func() { done <- m.Run(ctx) }()
+	deadline := time.Now().Add(5 * time.Second)
+	for running.Load() == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	time.Sleep(100 * time.Millisecond)
+
+	// The busy replica left the other feeds for the rest.
+	for i := 0; i < 2; i++ {
+		if id, err := q.Dequeue(ctx, []string{"a", "b", "c"}, time.Minute); err != nil || id == "" {
+			t.Errorf("dequeue %d = %q, %v; want a feed the busy replica left", i, id, err)
+		}
+	}
+	close(release)
+	cancel()
+	<-done
+	if n := most.Load(); n != 1 {
+		t.Errorf("%d checks ran at once, want 1", n)
+	}
+}
+
+func TestQueueSharesIncidents(t *testing.T) {
+	cfg := genfeed.DefaultConfig()
+	cfg.Stations, cfg.Vehicles, cfg.Geofences = 5, 10, 1
+	feed, err := genfeed.Generate(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var down atomic.Bool
+	handler := feed.Handler()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if down.Load() {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		handler.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+
+	// Two replicas take turns checking a feed; the second failure in a row
+	// opens the incident whichever replica sees it.
+	ctx := context.Background()
+	q := NewMemoryQueue()
+	f := Feed{ID: "demo", URL: server.URL + "/gbfs.json"}
+	recA, recB := &recorder{}, &recorder{}
+	a := New([]Feed{f}, WithQueue(q), WithHysteresis(2, 1), WithAlerter(recA))
+	b := New([]Feed{f}, WithQueue(q), WithHysteresis(2, 1), WithAlerter(recB))
+
+	down.Store(true)
+	a.Check(ctx, f)
+	b.Check(ctx, f)
+	if len(recA.events) != 0 || len(recB.events) != 1 || recB.events[0].Action != notify.ActionTrigger {
+		t.Fatalf("outage events = %+v, %+v", recA.events, recB.events)
+	}
+	if open := a.Incidents(); len(open) != 1 || open[0].FeedID != "demo" {
+		t.Errorf("other replica's incidents = %+v", open)
+	}
+
+	down.Store(false)
+	a.Check(ctx, f)
+	if len(recA.events) != 1 || recA.events[0].Action != notify.ActionResolve {
+		t.Fatalf("recovery events = %+v", recA.events)
+	}
+	if open := b.Incidents(); len(open) != 0 {
+		t.Errorf("resolved incidents still open: %+v", open)
+	}
+}
+
+// unreadableQueue is a queue whose incident state cannot be loaded.
+type unreadableQueue struct {
+	*MemoryQueue
+	saved int
+}
+
+func (q *unreadableQueue) Incidents(ctx context.Context, feedID string) (notify.FeedState, error) {
+	return notify.FeedState{}, errors.New("connection refused")
+}
+
+func (q *unreadableQueue) SetIncidents(ctx context.Context, feedID string, st notify.FeedState) error {
+	q.saved++
+	return q.MemoryQueue.SetIncidents(ctx, feedID, st)
+}
+
+func TestCheckWithoutIncidentState(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	// A replica that cannot load a feed's shared incident state neither
+	// alerts from nor overwrites it.
+	q := &unreadableQueue{MemoryQueue: NewMemoryQueue()}
+	rec := &recorder{}
+	f := Feed{ID: "demo", URL: server.URL + "/gbfs.json"}
+	m := New([]Feed{f}, WithQueue(q), WithHysteresis(1, 1), WithAlerter(rec))
+	m.Check(context.Background(), f)
+	if len(rec.events) != 0 || q.saved != 0 {
+		t.Errorf("sent %+v and saved incidents %d times", rec.events, q.saved)
+	}
+}
+
+func TestMemoryQueue(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	q := NewMemoryQueue()
+	q.now = func() time.Time { return now }
+	q.Enqueue(ctx, "a", time.Minute)
+	q.Enqueue(ctx, "b", time.Hour)
+	ids := []string{"a", "b"}
+
+	first, _ := q.Dequeue(ctx, ids, 10*time.Minute)
+	second, _ := q.Dequeue(ctx, ids, 10*time.Minute)
+	if none, _ := q.Dequeue(ctx, ids, 10*time.Minute); first == second || none != "" {
+		t.Fatalf("dequeued %q, %q, %q", first, second, none)
+	}
+
+	// a is due again after its interval only once released; b's lease
+	// expires without a release.
+	now = now.Add(2 * time.Minute)
+	if id, _ := q.Dequeue(ctx, ids, 10*time.Minute); id != "" {
+		t.Errorf("leased feed %q dequeued", id)
+	}
+	q.Release(ctx, "a")
+	if id, _ := q.Dequeue(ctx, ids, 10*time.Minute); id != "a" {
+		t.Errorf("dequeued %q, want a", id)
+	}
+	// A renewed lease outlasts the one taken at dequeue.
+	now = now.Add(9 * time.Minute)
+	q.Renew(ctx, "a", 10*time.Minute)
+	now = now.Add(2 * time.Minute)
+	if id, _ := q.Dequeue(ctx, ids, 10*time.Minute); id != "" {
+		t.Errorf("renewed feed %q dequeued", id)
+	}
+	q.Release(ctx, "a")
+	// A replica that no longer monitors a leaves it to the others.
+	ids = []string{"b"}
+	now = now.Add(2 * time.Hour)
+	if id, _ := q.Dequeue(ctx, ids, 10*time.Minute); id != "b" {
+		t.Errorf("dequeued %q, want b", id)
+	}
+	if id, _ := q.Dequeue(ctx, []string{"a"}, 10*time.Minute); id != "a" {
+		t.Errorf("dequeued %q, want a", id)
+	}
+}
+
+func TestElection(t *testing.T) {
+	var hits atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/gbfs.json" {
+			hits.Add(1)
+		}
+		http.NotFound(w, r)
+	}))
+	defer server.Close()
+
+	e := NewMemoryElection()
+	feeds := []Feed{{ID: "bikes", URL: server.URL + "/gbfs.json", Interval: Duration(time.Hour)}}
+	ctx, cancel := context.WithCancel(context.Background())
+	replicas := []*Monitor{New(feeds, WithElection(e)), New(feeds, WithElection(e))}
+	done := make(chan error, len(replicas))
+	for _, m := range replicas {
+		go func() { done <- m.Run(ctx) }()
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for hits.Load() == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	time.Sleep(100 * time.Millisecond)
+	if replicas[0].Leading() == replicas[1].Leading() {
+		t.Errorf("leading = %v, %v; want one leader", replicas[0].Leading(), replicas[1].Leading())
+	}
+	leader, follower := replicas[0], replicas[1]
+	if follower.Leading() {
+		leader, follower = follower, leader
+	}
+	added := Feed{ID: "scooters", URL: server.URL + "/scooters/gbfs.json", Paused: true}
+	if _, err := follower.AddFeed(added); !errors.Is(err, ErrNotLeader) {
+		t.Errorf("follower AddFeed error = %v, want ErrNotLeader", err)
+	}
+	if _, err := follower.SetPaused("bikes", true); !errors.Is(err, ErrNotLeader) {
+		t.Errorf("follower SetPaused error = %v, want ErrNotLeader", err)
+	}
+	if _, err := leader.AddFeed(added); err != nil {
+		t.Errorf("leader AddFeed: %v", err)
+	}
+	cancel()
+	<-done
+	<-done
+	if n := hits.Load(); n != 1 {
+		t.Errorf("feed checked %d times by two replicas, want 1", n)
+	}
+	if e.leader != "" {
+		t.Errorf("leader %q did not resign", e.leader)
+	}
+}
+
+func TestElectionSharedFeeds(t *testing.T) {
+	e, feeds := NewMemoryElection(), NewMemoryFeedStore()
+	config := []Feed{{ID: "bikes", URL: "https://example.com/bikes/gbfs.json", Paused: true}}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	leader := New(config, WithElection(e), WithFeedStore(feeds))
+	go leader.Run(ctx)
+	deadline := time.Now().Add(5 * time.Second)
+	for !leader.Leading() && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	follower := New(config, WithElection(e), WithFeedStore(feeds))
+
+	added := Feed{ID: "scooters", URL: "https://example.com/scooters/gbfs.json", Paused: true}
+	if _, err := follower.AddFeed(added); err != nil {
+		t.Fatalf("follower AddFeed: %v", err)
+	}
+	if _, err := follower.SetPaused("bikes", false); err != nil {
+		t.Fatalf("follower SetPaused: %v", err)
+	}
+	if err := leader.SyncFeeds(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if status, err := leader.FeedStatus("scooters"); err != nil || !status.Managed {
+		t.Errorf("leader sees added feed as %+v, %v", status, err)
+	}
+	if status, _ := leader.FeedStatus("bikes"); status.Paused {
+		t.Error("leader did not see the feed resumed on the follower")
+	}
+
+	// A replica that starts later, or is elected later, monitors the same
+	// feeds.
+	next := New(config, WithFeedStore(feeds))
+	if err := next.SyncFeeds(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := next.FeedStatus("scooters"); err != nil {
+		t.Errorf("new replica: %v", err)
+	}
+	if err := leader.RemoveFeed("scooters"); err != nil {
+		t.Fatal(err)
+	}
+	if err := follower.SyncFeeds(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := follower.FeedStatus("scooters"); !errors.Is(err, ErrFeedNotFound) {
+		t.Errorf("follower still has the removed feed: %v", err)
+	}
+}
+
+func TestFeedStores(t *testing.T) {
+	ctx := context.Background()
+	sqlite, err := OpenFeedStore("sqlite:" + filepath.Join(t.TempDir(), "feeds.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sqlite.(*SQLFeedStore).Close()
+	stores := map[string]FeedStore{"memory": NewMemoryFeedStore(), "sqlite": sqlite}
+	for name, s := range stores {
+		t.Run(name, func(t *testing.T) {
+			if err := s.SetPaused(ctx, "own", true); err != nil {
+				t.Fatal(err)
+			}
+			if err := s.SetPaused(ctx, "other", false); err != nil {
+				t.Fatal(err)
+			}
+			for _, id := range []string{"b", "a"} {
+				if err := s.AddFeed(ctx, Feed{ID: id, URL: "https://example.com/" + id}); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := s.AddFeed(ctx, Feed{ID: "a"}); !errors.Is(err, ErrFeedExists) {
+				t.Errorf("adding a again: %v", err)
+			}
+			if err := s.UpdateFeed(ctx, Feed{ID: "a", URL: "https://example.com/a/v2"}); err != nil {
+				t.Fatal(err)
+			}
+			if err := s.UpdateFeed(ctx, Feed{ID: "own"}); !errors.Is(err, ErrFeedNotFound) {
+				t.Errorf("updating a feed that is not managed: %v", err)
+			}
+			if err := s.SetPaused(ctx, "b", true); err != nil {
+				t.Fatal(err)
+			}
+			if err := s.RemoveFeed(ctx, "missing"); !errors.Is(err, ErrFeedNotFound) {
+				t.Errorf("removing a missing feed: %v", err)
+			}
+
+			managed, paused, err := s.Feeds(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(managed) != 2 || managed[0].ID != "b" || !managed[0].Paused || managed[1].URL != "https://example.com/a/v2" {
+				t.Errorf("managed = %+v", managed)
+			}
+			if len(paused) != 2 || !paused["own"] || paused["other"] {
+				t.Errorf("paused = %v", paused)
+			}
+			if err := s.RemoveFeed(ctx, "b"); err != nil {
+				t.Fatal(err)
+			}
+			if managed, _, _ := s.Feeds(ctx); len(managed) != 1 {
+				t.Errorf("after removal: %+v", managed)
+			}
+		})
+	}
+}
+
+// gatedFeedStore holds AddFeed until the test lets it through.
+type gatedFeedStore struct {
+	FeedStore
+	entered chan struct{}
+	gate    chan struct{}
+}
+
+func (s *gatedFeedStore) AddFeed(ctx context.Context, f Feed) error {
+	s.entered <- struct{}{}
+	<-s.gate
+	return s.FeedStore.AddFeed(ctx, f)
+}
+
+// gatedQueue holds Enqueue until the test lets it through.
+type gatedQueue struct {
+	Queue
+	entered chan struct{}
+	gate    chan struct{}
+}
+
+func (q *gatedQueue) Enqueue(ctx context.Context, feedID string, interval time.Duration) error {
+	q.entered <- struct{}{}
+	<-q.gate
+	return q.Queue.Enqueue(ctx, feedID, interval)
+}
+
+func TestFeedChangesDoNotHoldState(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+
+	store := &gatedFeedStore{FeedStore: NewMemoryFeedStore(), entered: make(chan struct{}, 10), gate: make(chan struct{})}
+	q := &gatedQueue{Queue: NewMemoryQueue(), entered: make(chan struct{}, 10), gate: make(chan struct{})}
+	m := New([]Feed{{ID: "own", URL: server.URL + "/own/gbfs.json", Paused: true}}, WithFeedStore(store), WithQueue(q))
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- m.Run(ctx) }()
+	for running := false; !running; {
+		m.mu.Lock()
+		running = m.ctx != nil
+		m.mu.Unlock()
+	}
+
+	added := make(chan error, 1)
+	go func() {
+		_, err := m.AddFeed(Feed{ID: "new", URL: server.URL + "/new/gbfs.json", Interval: Duration(time.Hour)})
+		added <- err
+	}()
+	for _, held := range []struct {
+		name          string
+		entered, gate chan struct{}
+	}{{"feed store", store.entered, store.gate}, {"queue", q.entered, q.gate}} {
+		<-held.entered
+		status := make(chan error, 1)
+		go func() {
+			_, err := m.FeedStatus("own")
+			status <- err
+		}()
+		select {
+		case err := <-status:
+			if err != nil {
+				t.Fatal(err)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("FeedStatus waited for the %s", held.name)
+		}
+		close(held.gate)
+	}
+	if err := <-added; err != nil {
+		t.Fatal(err)
+	}
+	cancel()
+	<-done
+}
+
+func TestMemoryElection(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	e := NewMemoryElection()
+	e.now = func() time.Time { return now }
+	if ok, _ := e.Campaign(ctx, "a", time.Minute); !ok {
+		t.Fatal("a not elected")
+	}
+	if ok, _ := e.Campaign(ctx, "b", time.Minute); ok {
+		t.Fatal("b elected while a leads")
+	}
+	now = now.Add(50 * time.Second)
+	if ok, _ := e.Campaign(ctx, "a", time.Minute); !ok {
+		t.Fatal("a could not renew")
+	}
+	now = now.Add(61 * time.Second)
+	if ok, _ := e.Campaign(ctx, "b", time.Minute); !ok {
+		t.Fatal("b not elected after a's lease lapsed")
+	}
+	e.Resign(ctx, "a")
+	if ok, _ := e.Campaign(ctx, "a", time.Minute); ok {
+		t.Fatal("a's resignation ended b's lease")
+	}
+}
+
+// gatedElection holds each campaign until the test lets it through, and
+// reports whether it was elected. A campaign the test never lets through
+// hangs, whatever its context says.
+type gatedElection struct {
+	Election
+	gate    chan struct{}
+	elected chan bool
+}
+
+func newGatedElection(e Election) *gatedElection {
+	return &gatedElection{Election: e, gate: make(chan struct{}), elected: make(chan bool, 10)}
+}
+
+func (e *gatedElection) Campaign(ctx context.Context, candidate string, ttl time.Duration) (bool, error) {
+	<-e.gate
+	ok, err := e.Election.Campaign(ctx, candidate, ttl)
+	e.elected <- ok
+	return ok, err
+}
+
+func TestLeaderStepsDownBeforeLeaseLapses(t *testing.T) {
+	var clock atomic.Int64
+	clock.Store(time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC).UnixNano())
+	now := func() time.Time { return time.Unix(0, clock.Load()) }
+	advance := func(d time.Duration) { clock.Add(int64(d)) }
+	e := NewMemoryElection()
+	e.now = now
+	oldElection, nextElection := newGatedElection(e), newGatedElection(e)
+	old, next := New(nil, WithElection(oldElection)), New(nil, WithElection(nextElection))
+	oldTicks, nextTicks := make(chan time.Time), make(chan time.Time)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	defer close(oldElection.gate)
+	waitLeading := func(m *Monitor) {
+		deadline := time.Now().Add(5 * time.Second)
+		for !m.Leading() && time.Now().Before(deadline) {
+			time.Sleep(time.Millisecond)
+		}
+		if !m.Leading() {
+			t.Fatal("not leading")
+		}
+	}
+
+	go old.campaign(ctx, "old", oldTicks, now)
+	oldElection.gate <- struct{}{}
+	if !<-oldElection.elected {
+		t.Fatal("old not elected")
+	}
+	waitLeading(old)
+	go next.campaign(ctx, "next", nextTicks, now)
+	nextElection.gate <- struct{}{}
+	if <-nextElection.elected {
+		t.Fatal("next elected while old leads")
+	}
+
+	// Old's next campaign hangs. It keeps scheduling while its lease has
+	// time to run, and stops well before the lease lapses.
+	advance(electionTTL / 3)
+	oldTicks <- now()
+	if !old.Leading() {
+		t.Fatal("old stepped down with most of its lease left")
+	}
+	advance(electionTTL / 3)
+	oldTicks <- now()
+	oldTicks <- now()
+	if old.Leading() {
+		t.Fatal("old still leads with its lease about to lapse")
+	}
+	nextTicks <- now()
+	nextElection.gate <- struct{}{}
+	if <-nextElection.elected {
+		t.Fatal("next elected before old's lease lapsed")
+	}
+
+	advance(electionTTL / 3)
+	nextTicks <- now()
+	nextElection.gate <- struct{}{}
+	if !<-nextElection.elected {
+		t.Fatal("next not elected after old's lease lapsed")
+	}
+	waitLeading(next)
+	if old.Leading() {
+		t.Error("old and next both lead")
+	}
+}
+
+// TestPostgres runs the Postgres store, queue, election, and feed store against the
+// database GBFS_TEST_POSTGRES_URL names, which the test writes to.
+func TestPostgres(t *testing.T) {
+	uri := os.Getenv("GBFS_TEST_POSTGRES_URL")
+	if uri == "" {
+		t.Skip("GBFS_TEST_POSTGRES_URL is not set")
+	}
+	ctx := context.Background()
+	db, d, err := archive.OpenDB(uri)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	for i := 0; i < 2; i++ {
+		if err := archive.Migrate(ctx, db, d); err != nil {
+			t.Fatalf("migration %d: %v", i+1, err)
+		}
+	}
+	run := newOwnerID()
+
+	store, err := archive.NewSQLStore(ctx, db, d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	feedURL := "https://example.com/" + run + "/gbfs.json"
+	at := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	if err := store.SaveRun(ctx, feedURL, at, &validator.ValidationResult{Summary: validator.ValidationSummary{ErrorsCount: 2}}); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := store.GetRun(ctx, archive.FeedKey(feedURL), at); err != nil || got.Summary.ErrorsCount != 2 {
+		t.Errorf("GetRun = %+v, %v", got, err)
+	}
+
+	q1, err := NewSQLQueue(ctx, db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	q2 := &SQLQueue{db: db, owner: newOwnerID()}
+	a, b := run+`-a,"x"`, run+`-b\{}`
+	for _, id := range []string{a, b} {
+		if err := q1.Enqueue(ctx, id, time.Hour); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if id, err := q1.Dequeue(ctx, []string{b}, time.Minute); err != nil || id != b {
+		t.Errorf("Dequeue = %q, %v; want %q", id, err, b)
+	}
+	if id, _ := q2.Dequeue(ctx, []string{a, b}, time.Minute); id != a {
+		t.Errorf("Dequeue = %q, want %q", id, a)
+	}
+	if id, _ := q2.Dequeue(ctx, []string{a, b}, time.Minute); id != "" {
+		t.Errorf("leased feed %q dequeued", id)
+	}
+	if err := q2.Renew(ctx, a, time.Minute); err != nil {
+		t.Error(err)
+	}
+	if err := q1.Release(ctx, b); err != nil {
+		t.Error(err)
+	}
+	incident := notify.Event{Action: notify.ActionTrigger, DedupKey: notify.DedupKey(a, "gbfs.json", notify.ConditionUnavailable), FeedID: a}
+	if err := q1.SetIncidents(ctx, a, notify.FeedState{Open: []notify.Event{incident}}); err != nil {
+		t.Fatal(err)
+	}
+	if st, err := q2.Incidents(ctx, a); err != nil || len(st.Open) != 1 {
+		t.Errorf("Incidents = %+v, %v", st, err)
+	}
+	if open, err := q2.OpenIncidents(ctx); err != nil || !slices.ContainsFunc(open, func(e notify.Event) bool { return e.FeedID == a }) {
+		t.Errorf("OpenIncidents = %+v, %v", open, err)
+	}
+
+	e, err := NewSQLElection(ctx, db, "test-"+run)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := e.Campaign(ctx, "one", time.Minute); !ok || err != nil {
+		t.Errorf("first candidate: %v, %v", ok, err)
+	}
+	if ok, _ := e.Campaign(ctx, "two", time.Minute); ok {
+		t.Error("second candidate elected while the lease holds")
+	}
+	if ok, _ := e.Campaign(ctx, "one", time.Minute); !ok {
+		t.Error("leader could not renew")
+	}
+	if err := e.Resign(ctx, "one"); err != nil {
+		t.Fatal(err)
+	}
+	if ok, _ := e.Campaign(ctx, "two", time.Minute); !ok {
+		t.Error("second candidate not elected after resignation")
+	}
+
+	feeds, err := NewSQLFeedStore(ctx, db, d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := feeds.AddFeed(ctx, Feed{ID: a, URL: feedURL}); err != nil {
+		t.Fatal(err)
+	}
+	if err := feeds.AddFeed(ctx, Feed{ID: a, URL: feedURL}); !errors.Is(err, ErrFeedExists) {
+		t.Errorf("adding twice: %v", err)
+	}
+	if err := feeds.SetPaused(ctx, a, true); err != nil {
+		t.Fatal(err)
+	}
+	if managed, _, err := feeds.Feeds(ctx); err != nil || !slices.ContainsFunc(managed, func(f Feed) bool { return f.ID == a && f.Paused }) {
+		t.Errorf("Feeds = %+v, %v", managed, err)
+	}
+	if err := feeds.RemoveFeed(ctx, a); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestLastResultFromStore(t *testing.T) {
+	ctx := context.Background()
+	store := archive.NewMemoryStore()
+	feed := Feed{ID: "bikes", URL: "https://example.com/gbfs.json"}
+	at := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	for i, errors := range []int{4, 2} {
+		result := &validator.ValidationResult{Summary: validator.ValidationSummary{ErrorsCount: errors}}
+		if err := store.SaveRun(ctx, feed.URL, at.Add(time.Duration(i)*time.Minute), result); err != nil {
+			t.Fatal(err)
+		}
+	}
+	m := New([]Feed{feed}, WithStore(store))
+	result, err := m.LastResult("bikes")
+	if err != nil || result == nil || result.Summary.ErrorsCount != 2 {
+		t.Errorf("LastResult = %+v, %v", result, err)
+	}
+}
@@ -0,0 +1,438 @@
+package monitor
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"maps"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gbfs-validator-go/pkg/archive"
+	"github.com/gbfs-validator-go/pkg/notify"
+)
+
+const (
+	// queuePoll is how often a monitor with a queue looks for due feeds.
+	queuePoll = 5 * time.Second
+	// queueLease is how long a replica holds a feed it dequeued; it renews
+	// the lease while the check runs. A feed whose replica stops before
+	// finishing its check is due again once the lease expires.
+	queueLease = 10 * time.Minute
+	// queueWorkers is how many checks a monitor with a queue runs at once
+	// unless WithQueueWorkers says otherwise.
+	queueWorkers = 4
+)
+
+// Queue shares a monitor's schedule between replicas, so that each due
+// check runs on one of them. Every feed is due once per interval, and a
+// dequeued feed is leased to one replica until it is released or the lease
+// expires. Feeds stay queued while any replica may monitor them: a replica
+// that pauses or removes a feed stops dequeuing it, and a feed no replica
+// monitors is never handed out. The queue also holds each feed's incident
+// state, which the replica holding the lease reads and writes.
+type Queue interface {
+	// Enqueue schedules a feed every interval, first due now. A feed
+	// already queued keeps its next due time and takes the new interval.
+	Enqueue(ctx context.Context, feedID string, interval time.Duration) error
+	// Dequeue leases the one of feedIDs due longest to the caller,
+	// scheduling its next check one interval from now; it returns "" if
+	// none is due.
+	Dequeue(ctx context.Context, feedIDs []string, lease time.Duration) (string, error)
+	// Renew extends the caller's lease of a feed to lease from now, for a
+	// check that runs longer than its lease. A lease the caller no longer
+	// holds is left alone.
+	Renew(ctx context.Context, feedID string, lease time.Duration) error
+	// Release ends the caller's lease of a feed.
+	Release(ctx context.Context, feedID string) error
+	// Incidents returns the incident state the last check of a feed left.
+	Incidents(ctx context.Context, feedID string) (notify.FeedState, error)
+	// SetIncidents stores a feed's incident state.
+	SetIncidents(ctx context.Context, feedID string, st notify.FeedState) error
+	// OpenIncidents returns every feed's open incidents, ordered by dedup
+	// key.
+	OpenIncidents(ctx context.Context) ([]notify.Event, error)
+}
+
+// OpenQueue returns the queue named by uri: "memory:" for a queue shared
+// by monitors in one process, or a postgres:// database URL as accepted by
+// archive.OpenStore.
+func OpenQueue(uri string) (Queue, error) {
+	if uri == "memory:" {
+		return NewMemoryQueue(), nil
+	}
+	if !strings.HasPrefix(uri, "postgres://") && !strings.HasPrefix(uri, "postgresql://") {
+		return nil, fmt.Errorf("unsupported queue %q; want memory: or postgres://", uri)
+	}
+	db, _, err := archive.OpenDB(uri)
+	if err != nil {
+		return nil, err
+	}
+	q, err := NewSQLQueue(context.Background(), db)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return q, nil
+}
+
+// MemoryQueue is a Queue in memory.
+type MemoryQueue struct {
+	mu        sync.Mutex
+	feeds     map[string]*queued
+	incidents map[string]notify.FeedState
+	now       func() time.Time
+}
+
+// queued is a feed's schedule in a MemoryQueue.
+type queued struct {
+	interval    time.Duration
+	next        time.Time
+	lockedUntil time.Time
+}
+
+// NewMemoryQueue returns an empty queue.
+func NewMemoryQueue() *MemoryQueue {
+	return &MemoryQueue{feeds: make(map[string]*queued), incidents: make(map[string]notify.FeedState), now: time.Now}
+}
+
+// Enqueue schedules a feed.
+func (q *MemoryQueue) Enqueue(ctx context.Context, feedID string, interval time.Duration) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if f, ok := q.feeds[feedID]; ok {
+		f.interval = interval
+		return nil
+	}
+	q.feeds[feedID] = &queued{interval: interval, next: q.now()}
+	return nil
+}
+
+// Dequeue leases the feed due longest.
+func (q *MemoryQueue) Dequeue(ctx context.Context, feedIDs []string, lease time.Duration) (string, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	now := q.now()
+	var due []string
+	for _, id := range feedIDs {
+		f, ok := q.feeds[id]
+		if ok && !f.next.After(now) && !f.lockedUntil.After(now) {
+			due = append(due, id)
+		}
+	}
+	if len(due) == 0 {
+		return "", nil
+	}
+	sort.Slice(due, func(i, j int) bool { return q.feeds[due[i]].next.Before(q.feeds[due[j]].next) })
+	f := q.feeds[due[0]]
+	f.next = now.Add(f.interval)
+	f.lockedUntil = now.Add(lease)
+	return due[0], nil
+}
+
+// Renew extends a lease.
+func (q *MemoryQueue) Renew(ctx context.Context, feedID string, lease time.Duration) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if f, ok := q.feeds[feedID]; ok && !f.lockedUntil.IsZero() {
+		f.lockedUntil = q.now().Add(lease)
+	}
+	return nil
+}
+
+// Release ends a lease.
+func (q *MemoryQueue) Release(ctx context.Context, feedID string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if f, ok := q.feeds[feedID]; ok {
+		f.lockedUntil = time.Time{}
+	}
+	return nil
+}
+
+// Incidents returns a feed's incident state.
+func (q *MemoryQueue) Incidents(ctx context.Context, feedID string) (notify.FeedState, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	st := q.incidents[feedID]
+	return notify.FeedState{Open: slices.Clone(st.Open), Failures: maps.Clone(st.Failures), Successes: maps.Clone(st.Successes)}, nil
+}
+
+// SetIncidents stores a feed's incident state.
+func (q *MemoryQueue) SetIncidents(ctx context.Context, feedID string, st notify.FeedState) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.incidents[feedID] = notify.FeedState{Open: slices.Clone(st.Open), Failures: maps.Clone(st.Failures), Successes: maps.Clone(st.Successes)}
+	return nil
+}
+
+// OpenIncidents returns the open incidents.
+func (q *MemoryQueue) OpenIncidents(ctx context.Context) ([]notify.Event, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	events := []notify.Event{}
+	for _, st := range q.incidents {
+		events = append(events, st.Open...)
+	}
+	sortEvents(events)
+	return events, nil
+}
+
+// sortEvents orders events by dedup key.
+func sortEvents(events []notify.Event) {
+	sort.Slice(events, func(i, j int) bool { return events[i].DedupKey < events[j].DedupKey })
+}
+
+// pgNow is the database clock in Unix nanoseconds, so that replicas agree
+// on due times whatever their own clocks say.
+const pgNow = `CAST(EXTRACT(EPOCH FROM clock_timestamp()) * 1000000000 AS BIGINT)`
+
+// SQLQueue is a Queue in the gbfs_monitor_queue table of a Postgres
+// database. Replicas dequeue with FOR UPDATE SKIP LOCKED, so concurrent
+// dequeues take different feeds without waiting on each other.
+type SQLQueue struct {
+	db *sql.DB
+	// owner identifies this queue's leases.
+	owner string
+}
+
+// NewSQLQueue returns a queue in db, applying the schema migrations the
+// database needs.
+func NewSQLQueue(ctx context.Context, db *sql.DB) (*SQLQueue, error) {
+	if err := archive.Migrate(ctx, db, archive.Postgres); err != nil {
+		return nil, fmt.Errorf("migrating Postgres database: %w", err)
+	}
+	return &SQLQueue{db: db, owner: newOwnerID()}, nil
+}
+
+// newOwnerID returns a random replica identifier.
+func newOwnerID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// Enqueue schedules a feed.
+func (q *SQLQueue) Enqueue(ctx context.Context, feedID string, interval time.Duration) error {
+	_, err := q.db.ExecContext(ctx, `INSERT INTO gbfs_monitor_queue (feed_id, interval_ns, next_run)
+		VALUES ($1, $2, `+pgNow+`)
+		ON CONFLICT (feed_id) DO UPDATE SET interval_ns = excluded.interval_ns`, feedID, int64(interval))
+	return err
+}
+
+// Dequeue leases the feed due longest.
+func (q *SQLQueue) Dequeue(ctx context.Context, feedIDs []string, lease time.Duration) (string, error) {
+	if len(feedIDs) == 0 {
+		return "", nil
+	}
+	var feedID string
+	err := q.db.QueryRowContext(ctx, `UPDATE gbfs_monitor_queue
+		SET next_run = `+pgNow+` + interval_ns, locked_until = `+pgNow+` + $1, owner = $2
+		WHERE feed_id = (
+			SELECT feed_id FROM gbfs_monitor_queue
+			WHERE next_run <= `+pgNow+` AND locked_until <= `+pgNow+` AND feed_id = ANY($3::text[])
+			ORDER BY next_run LIMIT 1
+			FOR UPDATE SKIP LOCKED)
+		RETURNING feed_id`, int64(lease), q.owner, feedIDs).Scan(&feedID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	}
+	return feedID, err
+}
+
+// Renew extends the lease of a feed, if this queue still holds it.
+func (q *SQLQueue) Renew(ctx context.Context, feedID string, lease time.Duration) error {
+	_, err := q.db.ExecContext(ctx, `UPDATE gbfs_monitor_queue SET locked_until = `+pgNow+` + $1
+		WHERE feed_id = $2 AND owner = $3 AND locked_until > 0`, int64(lease), feedID, q.owner)
+	return err
+}
+
+// Release ends the lease of a feed, if this queue still holds it.
+func (q *SQLQueue) Release(ctx context.Context, feedID string) error {
+	_, err := q.db.ExecContext(ctx, `UPDATE gbfs_monitor_queue SET locked_until = 0
+		WHERE feed_id = $1 AND owner = $2`, feedID, q.owner)
+	return err
+}
+
+// Incidents returns a feed's incident state.
+func (q *SQLQueue) Incidents(ctx context.Context, feedID string) (notify.FeedState, error) {
+	var st notify.FeedState
+	var data string
+	err := q.db.QueryRowContext(ctx, `SELECT state FROM gbfs_monitor_incidents WHERE feed_id = $1`, feedID).Scan(&data)
+	if errors.Is(err, sql.ErrNoRows) {
+		return st, nil
+	}
+	if err != nil {
+		return st, err
+	}
+	err = json.Unmarshal([]byte(data), &st)
+	return st, err
+}
+
+// SetIncidents stores a feed's incident state.
+func (q *SQLQueue) SetIncidents(ctx context.Context, feedID string, st notify.FeedState) error {
+	data, err := json.Marshal(st)
+	if err != nil {
+		return err
+	}
+	_, err = q.db.ExecContext(ctx, `INSERT INTO gbfs_monitor_incidents (feed_id, state) VALUES ($1, $2)
+		ON CONFLICT (feed_id) DO UPDATE SET state = excluded.state`, feedID, string(data))
+	return err
+}
+
+// OpenIncidents returns the open incidents.
+func (q *SQLQueue) OpenIncidents(ctx context.Context) ([]notify.Event, error) {
+	rows, err := q.db.QueryContext(ctx, `SELECT feed_id, state FROM gbfs_monitor_incidents`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	events := []notify.Event{}
+	for rows.Next() {
+		var feedID, data string
+		if err := rows.Scan(&feedID, &data); err != nil {
+			return nil, err
+		}
+		var st notify.FeedState
+		if err := json.Unmarshal([]byte(data), &st); err != nil {
+			return nil, fmt.Errorf("feed %s: %w", feedID, err)
+		}
+		events = append(events, st.Open...)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	sortEvents(events)
+	return events, nil
+}
+
+// Close closes the database.
+func (q *SQLQueue) Close() error {
+	return q.db.Close()
+}
+
+// WithQueue shares the checks with other replicas through q: Run checks
+// the feeds that q hands it instead of every feed on its own timer, and
+// every replica sharing q works it. Incident and hysteresis state is kept
+// in q, so hysteresis counts every replica's runs of a feed, the replica
+// that runs a check sends the alerts it causes, and Incidents lists every
+// replica's open incidents. With WithElection as well, the election is not
+// needed and Run ignores it.
+//
+// Churn, rotation, unchanged-file and availability reports cover only the
+// checks each replica ran itself. Replicas should share a WithFeedStore
+// store, so that they all hand out the same feeds.
+func WithQueue(q Queue) Option {
+	return func(m *Monitor) {
+		m.queue = q
+	}
+}
+
+// WithQueueWorkers sets how many checks of feeds handed out by a WithQueue
+// queue the monitor runs at once; it dequeues a feed only while fewer are
+// running, leaving the others to replicas with capacity. The default is 4.
+func WithQueueWorkers(n int) Option {
+	return func(m *Monitor) {
+		m.workers = n
+	}
+}
+
+// work checks the feeds the queue hands out until ctx is done, running at
+// most m.workers checks at once.
+func (m *Monitor) work(ctx context.Context) {
+	workers := m.workers
+	if workers <= 0 {
+		workers = queueWorkers
+	}
+	slots := make(chan struct{}, workers)
+	ticker := time.NewTicker(queuePoll)
+	defer ticker.Stop()
+	for {
+		for ctx.Err() == nil {
+			select {
+			case slots <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+			m.mu.Lock()
+			var ids []string
+			for _, f := range m.feeds {
+				if !f.Paused {
+					ids = append(ids, f.ID)
+				}
+			}
+			m.mu.Unlock()
+			id, err := m.queue.Dequeue(ctx, ids, queueLease)
+			if err != nil || id == "" {
+				<-slots
+				if err != nil && ctx.Err() == nil {
+					log.Printf("Failed to dequeue a feed: %v", err)
+				}
+				break
+			}
+			m.mu.Lock()
+			i := m.indexLocked(id)
+			var f Feed
+			if i >= 0 {
+				f = m.feeds[i]
+			}
+			m.mu.Unlock()
+			if i < 0 || f.Paused {
+				// Removed or paused since the dequeue; the feed is due
+				// again in one interval.
+				<-slots
+				if err := m.queue.Release(ctx, id); err != nil {
+					log.Printf("Failed to release %s: %v", id, err)
+				}
+				continue
+			}
+			m.wg.Add(1)
+			go func() {
+				defer m.wg.Done()
+				defer func() { <-slots }()
+				renewCtx, stop := context.WithCancel(ctx)
+				renewed := make(chan struct{})
+				go func() {
+					m.renew(renewCtx, f.ID)
+					close(renewed)
+				}()
+				m.Check(ctx, f)
+				stop()
+				<-renewed
+				if err := m.queue.Release(context.WithoutCancel(ctx), f.ID); err != nil {
+					log.Printf("Failed to release %s: %v", f.ID, err)
+				}
+			}()
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// renew renews the lease of a feed being checked until ctx is done, so
+// that a check that runs longer than queueLease keeps its feed.
+func (m *Monitor) renew(ctx context.Context, feedID string) {
+	ticker := time.NewTicker(queueLease / 3)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := m.queue.Renew(ctx, feedID, queueLease); err != nil && ctx.Err() == nil {
+				log.Printf("Failed to renew the lease of %s: %v", feedID, err)
+			}
+		}
+	}
+}
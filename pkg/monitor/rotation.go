@@ -0,0 +1,166 @@
+package monitor
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gbfs-validator-go/pkg/gbfs"
+	"github.com/gbfs-validator-go/pkg/gbfsclient"
+	"github.com/gbfs-validator-go/pkg/validator"
+)
+
+// defaultRotationThreshold is used for feeds without a rotation threshold.
+const defaultRotationThreshold = 3
+
+// movedMeters is how far a vehicle must move between polls to count as a
+// trip rather than GPS drift.
+const movedMeters = 200
+
+// forgetVehicleAfter is how long an ID is remembered after it was last
+// listed, so that reuse after a long rental is still noticed.
+const forgetVehicleAfter = 7 * 24 * time.Hour
+
+// vehicleState tracks one vehicle ID across polls.
+type vehicleState struct {
+	lat, lon    float64
+	stationID   string
+	present     bool
+	firstSeen   time.Time
+	lastSeen    time.Time
+	transitions int
+}
+
+// PersistentVehicleID is a vehicle ID seen across several trips.
+type PersistentVehicleID struct {
+	ID string `json:"id"`
+	// Transitions counts the times the ID disappeared and came back, or
+	// moved to another place or station between polls.
+	Transitions int       `json:"transitions"`
+	FirstSeen   time.Time `json:"firstSeen"`
+	LastSeen    time.Time `json:"lastSeen"`
+}
+
+// RotationReport summarizes vehicle ID reuse of a feed since the monitor
+// started. GBFS 2.0 and later require vehicle IDs to rotate after every
+// trip, so an ID that survives several trips points to a privacy problem.
+type RotationReport struct {
+	ID string `json:"id"`
+	// Applicable is false when the feed's version predates the rotation
+	// requirement or it publishes no vehicles.
+	Applicable bool `json:"applicable"`
+	Threshold  int  `json:"threshold"`
+	// Vehicles counts the IDs tracked.
+	Vehicles int `json:"vehicles"`
+	// Persistent lists IDs with at least Threshold transitions, most first.
+	Persistent []PersistentVehicleID `json:"persistent"`
+}
+
+// vehicleID returns the ID of a vehicle in any version.
+func vehicleID(v gbfs.Vehicle) string {
+	if v.VehicleID != "" {
+		return v.VehicleID
+	}
+	return v.BikeID
+}
+
+// recordRotationLocked updates vehicle ID tracking from a run's vehicle
+// status file. The caller holds m.mu.
+func (m *Monitor) recordRotationLocked(id string, at time.Time, result *validator.ValidationResult) {
+	if result == nil || strings.HasPrefix(result.Summary.Version.Validated, "1.") {
+		return
+	}
+	var status gbfs.VehicleStatus
+	found := false
+	for _, f := range result.Files {
+		if (f.File != "vehicle_status.json" && f.File != "free_bike_status.json") || !f.Exists {
+			continue
+		}
+		data := f.RawData
+		if f.CoercedData != nil {
+			data = f.CoercedData
+		}
+		found = json.Unmarshal(data, &status) == nil
+		break
+	}
+	if !found {
+		return
+	}
+
+	vehicles := m.rotation[id]
+	if vehicles == nil {
+		vehicles = make(map[string]*vehicleState)
+		m.rotation[id] = vehicles
+	}
+	listed := make(map[string]bool)
+	for _, v := range status.Data.GetVehicles() {
+		vid := vehicleID(v)
+		if vid == "" {
+			continue
+		}
+		listed[vid] = true
+		st, ok := vehicles[vid]
+		if !ok {
+			vehicles[vid] = &vehicleState{lat: v.Lat, lon: v.Lon, stationID: v.StationID, present: true, firstSeen: at, lastSeen: at}
+			continue
+		}
+		switch {
+		case !st.present:
+			st.transitions++
+		case v.StationID != st.stationID:
+			st.transitions++
+		case v.StationID == "" && gbfsclient.Distance(st.lat, st.lon, v.Lat, v.Lon) > movedMeters:
+			st.transitions++
+		}
+		st.lat, st.lon, st.stationID = v.Lat, v.Lon, v.StationID
+		st.present, st.lastSeen = true, at
+	}
+	for vid, st := range vehicles {
+		if listed[vid] {
+			continue
+		}
+		st.present = false
+		if at.Sub(st.lastSeen) > forgetVehicleAfter {
+			delete(vehicles, vid)
+		}
+	}
+}
+
+// Rotation reports vehicle IDs of a feed that persist across trips.
+func (m *Monitor) Rotation(id string) (RotationReport, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	i := m.indexLocked(id)
+	if i < 0 {
+		return RotationReport{}, ErrFeedNotFound
+	}
+	return m.rotationLocked(m.feeds[i]), nil
+}
+
+// rotationLocked builds a feed's rotation report. The caller holds m.mu.
+func (m *Monitor) rotationLocked(f Feed) RotationReport {
+	vehicles, tracked := m.rotation[f.ID]
+	report := RotationReport{
+		ID:         f.ID,
+		Applicable: tracked && len(vehicles) > 0,
+		Threshold:  f.RotationThreshold,
+		Vehicles:   len(vehicles),
+		Persistent: []PersistentVehicleID{},
+	}
+	for vid, st := range vehicles {
+		if st.transitions >= f.RotationThreshold {
+			report.Persistent = append(report.Persistent, PersistentVehicleID{
+				ID: vid, Transitions: st.transitions, FirstSeen: st.firstSeen, LastSeen: st.lastSeen,
+			})
+		}
+	}
+	sort.Slice(report.Persistent, func(i, j int) bool {
+		a, b := report.Persistent[i], report.Persistent[j]
+		if a.Transitions != b.Transitions {
+			return a.Transitions > b.Transitions
+		}
+		return a.ID < b.ID
+	})
+	return report
+}
@@ -0,0 +1,160 @@
+package monitor
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/gbfs-validator-go/pkg/archive"
+	"github.com/gbfs-validator-go/pkg/validator"
+)
+
+// Window is a rolling period availability is reported over.
+type Window struct {
+	Name     string
+	Duration time.Duration
+}
+
+// Windows are the reported availability periods, shortest first. Samples
+// are kept for the longest.
+var Windows = []Window{
+	{Name: "24h", Duration: 24 * time.Hour},
+	{Name: "7d", Duration: 7 * 24 * time.Hour},
+	{Name: "30d", Duration: 30 * 24 * time.Hour},
+}
+
+// sample is the outcome of one run for availability reporting.
+type sample struct {
+	at time.Time
+	up bool
+	// files maps each published or required file to whether it was fetched
+	// and schema-valid.
+	files map[string]bool
+}
+
+// newSample classifies a run. A feed is up when it could be validated,
+// every required file was fetched, and every checked file is valid.
+func newSample(at time.Time, result *validator.ValidationResult, err error) sample {
+	s := sample{at: at}
+	if err != nil || result == nil {
+		return s
+	}
+	stats := archive.Stats(result)
+	s.up = stats.Available && stats.Score == 100
+	s.files = make(map[string]bool)
+	for _, f := range result.Files {
+		switch f.Status {
+		case validator.FileStatusAbsent, validator.FileStatusRecommendedMissing:
+			continue
+		}
+		up := f.Status == validator.FileStatusValid
+		if prev, ok := s.files[f.File]; ok {
+			up = up && prev
+		}
+		s.files[f.File] = up
+	}
+	return s
+}
+
+// Availability is the share of runs in a window that were up. Percent is
+// nil when the window holds no runs.
+type Availability struct {
+	Percent *float64 `json:"percent"`
+	Runs    int      `json:"runs"`
+}
+
+// FeedAvailability reports a feed's availability per window name, overall
+// and per file.
+type FeedAvailability struct {
+	ID      string                             `json:"id"`
+	Tenant  string                             `json:"tenant,omitempty"`
+	Windows map[string]Availability            `json:"windows"`
+	Files   map[string]map[string]Availability `json:"files"`
+}
+
+// recordSampleLocked adds a run to a feed's history and drops samples
+// older than the longest window. The caller holds m.mu.
+func (m *Monitor) recordSampleLocked(id string, s sample) {
+	history := append(m.history[id], s)
+	cutoff := s.at.Add(-Windows[len(Windows)-1].Duration)
+	drop := sort.Search(len(history), func(i int) bool { return history[i].at.After(cutoff) })
+	m.history[id] = history[drop:]
+}
+
+// Availability reports the availability of the tenant's feeds, or of every
+// feed when tenant is empty, ordered by feed ID. History is kept in memory
+// and starts over when the process restarts.
+func (m *Monitor) Availability(tenant string, now time.Time) []FeedAvailability {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var out []FeedAvailability
+	for _, f := range m.feeds {
+		if tenant != "" && f.Tenant != tenant {
+			continue
+		}
+		out = append(out, m.availabilityLocked(f, now))
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	return out
+}
+
+// FeedAvailability reports one feed's availability.
+func (m *Monitor) FeedAvailability(id string, now time.Time) (FeedAvailability, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	i := m.indexLocked(id)
+	if i < 0 {
+		return FeedAvailability{}, ErrFeedNotFound
+	}
+	return m.availabilityLocked(m.feeds[i], now), nil
+}
+
+// availabilityLocked computes a feed's availability. The caller holds m.mu.
+func (m *Monitor) availabilityLocked(f Feed, now time.Time) FeedAvailability {
+	fa := FeedAvailability{
+		ID:      f.ID,
+		Tenant:  f.Tenant,
+		Windows: make(map[string]Availability),
+		Files:   make(map[string]map[string]Availability),
+	}
+	history := m.history[f.ID]
+	for _, w := range Windows {
+		cutoff := now.Add(-w.Duration)
+		var runs, up int
+		fileRuns, fileUp := make(map[string]int), make(map[string]int)
+		for _, s := range history {
+			if !s.at.After(cutoff) {
+				continue
+			}
+			runs++
+			if s.up {
+				up++
+			}
+			for file, ok := range s.files {
+				fileRuns[file]++
+				if ok {
+					fileUp[file]++
+				}
+			}
+		}
+		fa.Windows[w.Name] = availability(up, runs)
+		for file, n := range fileRuns {
+			if fa.Files[file] == nil {
+				fa.Files[file] = make(map[string]Availability)
+			}
+			fa.Files[file][w.Name] = availability(fileUp[file], n)
+		}
+	}
+	return fa
+}
+
+// availability returns up of runs as a percentage rounded to three
+// decimals.
+func availability(up, runs int) Availability {
+	a := Availability{Runs: runs}
+	if runs > 0 {
+		p := math.Round(100000*float64(up)/float64(runs)) / 1000
+		a.Percent = &p
+	}
+	return a
+}
@@ -0,0 +1,234 @@
+package monitor
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/gbfs-validator-go/pkg/archive"
+	"github.com/gbfs-validator-go/pkg/fetcher"
+	"github.com/gbfs-validator-go/pkg/notify"
+	"github.com/gbfs-validator-go/pkg/validator"
+)
+
+// Tenant is an operator or project with its own feeds, API keys, and alert
+// targets.
+type Tenant struct {
+	ID   string `json:"id"`
+	Name string `json:"name,omitempty"`
+	// APIKeys authenticate the tenant's dashboard API requests.
+	APIKeys []string `json:"apiKeys,omitempty"`
+	// EditorKeys may also add, change, pause, and remove the tenant's feeds.
+	EditorKeys []string    `json:"editorKeys,omitempty"`
+	Alerts     AlertConfig `json:"alerts"`
+	Feeds      []Feed      `json:"feeds"`
+}
+
+// Role is what an API key may do.
+type Role string
+
+const (
+	// RoleAdmin manages every tenant's feeds.
+	RoleAdmin Role = "admin"
+	// RoleEditor manages one tenant's feeds.
+	RoleEditor Role = "editor"
+	// RoleViewer reads one tenant's feeds.
+	RoleViewer Role = "viewer"
+)
+
+// CanEdit reports whether the role may change feeds.
+func (r Role) CanEdit() bool {
+	return r == RoleAdmin || r == RoleEditor
+}
+
+// validate checks the tenant configuration and tags its feeds.
+func (t *Tenant) validate() error {
+	if t.ID == "" {
+		return fmt.Errorf("tenant without id")
+	}
+	for i := range t.Feeds {
+		if t.Feeds[i].URL == "" {
+			return fmt.Errorf("tenant %s: feed %d has no url", t.ID, i)
+		}
+		t.Feeds[i].Tenant = t.ID
+		t.Feeds[i].normalize()
+	}
+	return nil
+}
+
+// FeedStatus is the latest state of a monitored feed.
+type FeedStatus struct {
+	ID       string   `json:"id"`
+	Tenant   string   `json:"tenant,omitempty"`
+	URL      string   `json:"url"`
+	FeedKey  string   `json:"feedKey"`
+	Interval Duration `json:"interval"`
+	// Auth tells whether credentials are set; they are never returned.
+	Auth   AuthStatus `json:"auth"`
+	Paused bool       `json:"paused,omitempty"`
+	// Managed is set for feeds added through the API rather than the
+	// configuration file; only those can be changed or removed.
+	Managed bool       `json:"managed,omitempty"`
+	LastRun *time.Time `json:"lastRun,omitempty"`
+	// Error is set when the last run could not validate the feed.
+	Error string            `json:"error,omitempty"`
+	Stats *archive.RunStats `json:"stats,omitempty"`
+	// FrozenStations counts stations whose availability has not changed
+	// for the feed's FrozenAfter period.
+	FrozenStations int `json:"frozenStations,omitempty"`
+	// PersistentVehicleIDs counts vehicle IDs that survived more trips than
+	// the feed's RotationThreshold.
+	PersistentVehicleIDs int `json:"persistentVehicleIds,omitempty"`
+	// StaleFiles counts files whose content has not changed for the feed's
+	// UnchangedAfter period despite a shorter ttl.
+	StaleFiles int            `json:"staleFiles,omitempty"`
+	Incidents  []notify.Event `json:"incidents,omitempty"`
+}
+
+// AuthStatus describes a feed's credentials without revealing them.
+type AuthStatus struct {
+	Type       fetcher.AuthType `json:"type"`
+	Configured bool             `json:"configured"`
+}
+
+// authStatus describes a.
+func authStatus(a *fetcher.AuthConfig) AuthStatus {
+	if a == nil || a.Type == "" || a.Type == fetcher.AuthNone {
+		return AuthStatus{Type: fetcher.AuthNone}
+	}
+	return AuthStatus{Type: a.Type, Configured: true}
+}
+
+// runState is the outcome of a feed's last run.
+type runState struct {
+	at     time.Time
+	err    error
+	result *validator.ValidationResult
+}
+
+// record stores the outcome of a run.
+func (m *Monitor) record(f Feed, at time.Time, result *validator.ValidationResult, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.indexLocked(f.ID) < 0 {
+		return
+	}
+	m.status[f.ID] = &runState{at: at, err: err, result: result}
+	m.recordSampleLocked(f.ID, newSample(at, result, err))
+	m.recordChurnLocked(f.ID, at, result)
+	m.recordRotationLocked(f.ID, at, result)
+	m.recordChangesLocked(f.ID, at, result)
+}
+
+// statusLocked describes a feed. The caller holds m.mu.
+func (m *Monitor) statusLocked(f Feed) FeedStatus {
+	status := FeedStatus{
+		ID: f.ID, Tenant: f.Tenant, URL: fetcher.RedactURL(f.URL), FeedKey: archive.FeedKey(f.URL),
+		Interval: f.Interval, Auth: authStatus(f.Auth), Paused: f.Paused, Managed: f.managed,
+	}
+	if run, ok := m.status[f.ID]; ok {
+		at := run.at
+		status.LastRun = &at
+		if run.err != nil {
+			status.Error = fetcher.RedactText(run.err.Error())
+		} else {
+			stats := archive.Stats(run.result)
+			status.Stats = &stats
+		}
+		status.FrozenStations = m.churnLocked(f, time.Now()).Frozen
+		status.PersistentVehicleIDs = len(m.rotationLocked(f).Persistent)
+		status.StaleFiles = m.unchangedLocked(f, time.Now()).Stale
+	}
+	return status
+}
+
+// Status returns the latest state of the tenant's feeds, or of every feed
+// when tenant is empty, ordered by feed ID.
+func (m *Monitor) Status(tenant string) []FeedStatus {
+	incidents := make(map[string][]notify.Event)
+	for _, e := range m.Incidents() {
+		incidents[e.FeedID] = append(incidents[e.FeedID], e)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var out []FeedStatus
+	for _, f := range m.feeds {
+		if tenant != "" && f.Tenant != tenant {
+			continue
+		}
+		status := m.statusLocked(f)
+		status.Incidents = incidents[f.ID]
+		out = append(out, status)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	return out
+}
+
+// Tenants returns the configured tenants.
+func (m *Monitor) Tenants() []Tenant {
+	return m.tenants
+}
+
+// TenantForKey returns the tenant an API key belongs to and the role it
+// grants.
+func (m *Monitor) TenantForKey(key string) (Tenant, Role, bool) {
+	if key == "" {
+		return Tenant{}, "", false
+	}
+	for _, t := range m.tenants {
+		if keyMatches(key, t.EditorKeys) {
+			return t, RoleEditor, true
+		}
+		if keyMatches(key, t.APIKeys) {
+			return t, RoleViewer, true
+		}
+	}
+	return Tenant{}, "", false
+}
+
+// keyMatches compares key against each candidate in constant time.
+func keyMatches(key string, candidates []string) bool {
+	sum := sha256.Sum256([]byte(key))
+	for _, k := range candidates {
+		candidate := sha256.Sum256([]byte(k))
+		if subtle.ConstantTimeCompare(sum[:], candidate[:]) == 1 {
+			return true
+		}
+	}
+	return false
+}
+
+// hasTenant reports whether a tenant with the ID is configured.
+func (m *Monitor) hasTenant(id string) bool {
+	for _, t := range m.tenants {
+		if t.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// OwnsFeedKey reports whether the tenant monitors the feed with the given
+// archive key.
+func (m *Monitor) OwnsFeedKey(tenant, feedKey string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, f := range m.feeds {
+		if f.Tenant == tenant && archive.FeedKey(f.URL) == feedKey {
+			return true
+		}
+	}
+	return false
+}
+
+// alerterFor returns the alert targets for a tenant's feeds, falling back to
+// the monitor-wide targets.
+func (m *Monitor) alerterFor(tenant string) notify.Alerter {
+	if a, ok := m.tenantAlerters[tenant]; ok {
+		return a
+	}
+	return m.alerter
+}
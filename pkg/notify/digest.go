@@ -0,0 +1,178 @@
+// Package notify reports changes in archived validation results to people
+// and incident tools.
+package notify
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/gbfs-validator-go/pkg/archive"
+	"github.com/gbfs-validator-go/pkg/validator"
+)
+
+// Issue is a distinct validation problem, identified by file, keyword, and
+// message regardless of how many instances carry it.
+type Issue struct {
+	File     string                       `json:"file"`
+	Severity validator.ValidationSeverity `json:"severity"`
+	Keyword  string                       `json:"keyword,omitempty"`
+	Message  string                       `json:"message"`
+	Count    int                          `json:"count"`
+}
+
+// key identifies an issue across runs.
+func (i Issue) key() string {
+	return i.File + "\x00" + i.Keyword + "\x00" + i.Message
+}
+
+// Issues collects the distinct errors of a result, sorted by file and
+// message.
+func Issues(result *validator.ValidationResult) []Issue {
+	byKey := make(map[string]*Issue)
+	var order []string
+	for _, f := range result.Files {
+		for _, e := range f.Errors {
+			if e.Severity != validator.SeverityError {
+				continue
+			}
+			issue := Issue{File: f.File, Severity: e.Severity, Keyword: e.Keyword, Message: e.Message}
+			k := issue.key()
+			if existing, ok := byKey[k]; ok {
+				existing.Count++
+				continue
+			}
+			issue.Count = 1
+			byKey[k] = &issue
+			order = append(order, k)
+		}
+	}
+	issues := make([]Issue, 0, len(order))
+	for _, k := range order {
+		issues = append(issues, *byKey[k])
+	}
+	sort.SliceStable(issues, func(i, j int) bool {
+		if issues[i].File != issues[j].File {
+			return issues[i].File < issues[j].File
+		}
+		return issues[i].Message < issues[j].Message
+	})
+	return issues
+}
+
+// FeedDigest summarizes how one feed changed over a period.
+type FeedDigest struct {
+	URL string `json:"url"`
+	// Runs is how many runs were archived in the period.
+	Runs     int        `json:"runs"`
+	LatestAt *time.Time `json:"latestAt,omitempty"`
+	// Errors and Score are from the latest run; the Previous fields are from
+	// the last run before the period, if any.
+	Errors         int      `json:"errors"`
+	PreviousErrors *int     `json:"previousErrors,omitempty"`
+	Score          float64  `json:"score"`
+	PreviousScore  *float64 `json:"previousScore,omitempty"`
+	NewIssues      []Issue  `json:"newIssues,omitempty"`
+	ResolvedIssues []Issue  `json:"resolvedIssues,omitempty"`
+}
+
+// ScoreChange is the score difference from the previous period.
+func (d *FeedDigest) ScoreChange() float64 {
+	if d.PreviousScore == nil {
+		return 0
+	}
+	return d.Score - *d.PreviousScore
+}
+
+// Changed reports whether anything worth mentioning happened.
+func (d *FeedDigest) Changed() bool {
+	return len(d.NewIssues) > 0 || len(d.ResolvedIssues) > 0 || d.ScoreChange() != 0
+}
+
+// Digest covers a set of feeds over a period.
+type Digest struct {
+	From  time.Time    `json:"from"`
+	To    time.Time    `json:"to"`
+	Feeds []FeedDigest `json:"feeds"`
+}
+
+// BuildDigest compares each feed's latest run in [from, to) with its last
+// run before from.
+func BuildDigest(ctx context.Context, a *archive.Archive, feedURLs []string, from, to time.Time) (*Digest, error) {
+	digest := &Digest{From: from, To: to}
+	for _, feedURL := range feedURLs {
+		fd, err := buildFeedDigest(ctx, a, feedURL, from, to)
+		if err != nil {
+			return nil, err
+		}
+		digest.Feeds = append(digest.Feeds, fd)
+	}
+	return digest, nil
+}
+
+// buildFeedDigest summarizes one feed.
+func buildFeedDigest(ctx context.Context, a *archive.Archive, feedURL string, from, to time.Time) (FeedDigest, error) {
+	fd := FeedDigest{URL: feedURL}
+	runs, err := a.Runs(ctx, feedURL)
+	if err != nil {
+		return fd, err
+	}
+
+	var latest, previous *time.Time
+	for i := range runs {
+		at := runs[i]
+		switch {
+		case at.Before(from):
+			previous = &runs[i]
+		case at.Before(to):
+			fd.Runs++
+			latest = &runs[i]
+		}
+	}
+	if latest == nil {
+		return fd, nil
+	}
+	fd.LatestAt = latest
+
+	current, err := a.Result(ctx, feedURL, *latest)
+	if err != nil {
+		return fd, err
+	}
+	stats := archive.Stats(current)
+	fd.Errors, fd.Score = stats.Errors, stats.Score
+	currentIssues := Issues(current)
+
+	var previousIssues []Issue
+	if previous != nil {
+		before, err := a.Result(ctx, feedURL, *previous)
+		if err != nil && !errors.Is(err, archive.ErrNotFound) {
+			return fd, err
+		}
+		if before != nil {
+			prevStats := archive.Stats(before)
+			fd.PreviousErrors = &prevStats.Errors
+			fd.PreviousScore = &prevStats.Score
+			previousIssues = Issues(before)
+		}
+	}
+
+	fd.NewIssues = subtract(currentIssues, previousIssues)
+	fd.ResolvedIssues = subtract(previousIssues, currentIssues)
+	return fd, nil
+}
+
+// subtract returns the issues in a that are not in b.
+func subtract(a, b []Issue) []Issue {
+	seen := make(map[string]bool, len(b))
+	for _, i := range b {
+		seen[i.key()] = true
+	}
+	var out []Issue
+	for _, i := range a {
+		if !seen[i.key()] {
+			out = append(out, i)
+		}
+	}
+	return out
+}
@@ -0,0 +1,69 @@
+package notify
+
+import (
+	"context"
+	"net/smtp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gbfs-validator-go/pkg/archive"
+	"github.com/gbfs-validator-go/pkg/validator"
+)
+
+// result builds a run with one file carrying the given error messages.
+func result(messages ...string) *validator.ValidationResult {
+	f := validator.FileValidationResult{File: "station_status.json", Exists: true, Status: validator.FileStatusValid}
+	for _, m := range messages {
+		f.Errors = append(f.Errors, validator.ValidationError{Severity: validator.SeverityError, Message: m, Keyword: "required"})
+		f.Status = validator.FileStatusInvalid
+	}
+	return &validator.ValidationResult{
+		Summary: validator.ValidationSummary{ErrorsCount: len(messages)},
+		Files:   []validator.FileValidationResult{{File: "gbfs.json", Status: validator.FileStatusValid}, f},
+	}
+}
+
+func TestDigest(t *testing.T) {
+	ctx := context.Background()
+	a := archive.New(&archive.DirStore{Root: t.TempDir()}, "")
+	feed := "https://example.com/gbfs.json?token=abc"
+	to := time.Date(2024, 5, 8, 0, 0, 0, 0, time.UTC)
+	from := to.Add(-ScheduleDaily.Period())
+
+	a.Save(ctx, feed, from.Add(-time.Hour), result("a is required", "b is required"))
+	a.Save(ctx, feed, from.Add(time.Hour), result("b is required"))
+	a.Save(ctx, feed, from.Add(2*time.Hour), result("b is required", "c is required", "c is required"))
+
+	d, err := BuildDigest(ctx, a, []string{feed}, from, to)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fd := d.Feeds[0]
+	if fd.Runs != 2 || fd.Errors != 3 || *fd.PreviousErrors != 2 {
+		t.Errorf("digest = %+v", fd)
+	}
+	if len(fd.NewIssues) != 1 || fd.NewIssues[0].Message != "c is required" || fd.NewIssues[0].Count != 2 {
+		t.Errorf("new issues = %+v", fd.NewIssues)
+	}
+	if len(fd.ResolvedIssues) != 1 || fd.ResolvedIssues[0].Message != "a is required" {
+		t.Errorf("resolved issues = %+v", fd.ResolvedIssues)
+	}
+
+	var sent []byte
+	m := NewMailer(SMTPConfig{Host: "mail.example.com", Port: 25, From: "gbfs@example.com"})
+	m.send = func(addr string, _ smtp.Auth, from string, to []string, msg []byte) error {
+		sent = msg
+		return nil
+	}
+	group := RecipientGroup{Name: "ops", Recipients: []string{"ops@example.com"}, Schedule: ScheduleDaily}
+	if ok, err := m.SendDigest(group, d); !ok || err != nil {
+		t.Fatalf("SendDigest = %v, %v", ok, err)
+	}
+	body := string(sent)
+	for _, want := range []string{"Subject: GBFS daily digest: ops", "New failures:", "c is required (2 instances)", "Resolved:", "token=REDACTED"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("email missing %q:\n%s", want, body)
+		}
+	}
+}
@@ -0,0 +1,180 @@
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/smtp"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gbfs-validator-go/pkg/fetcher"
+)
+
+// Schedule is how often a recipient group receives a digest.
+type Schedule string
+
+const (
+	ScheduleDaily  Schedule = "daily"
+	ScheduleWeekly Schedule = "weekly"
+)
+
+// Period returns the span a digest on this schedule covers.
+func (s Schedule) Period() time.Duration {
+	if s == ScheduleWeekly {
+		return 7 * 24 * time.Hour
+	}
+	return 24 * time.Hour
+}
+
+// SMTPConfig addresses the mail server. The password is read from the
+// SMTP_PASSWORD environment variable when not set.
+type SMTPConfig struct {
+	Host     string `json:"host"`
+	Port     int    `json:"port"`
+	Username string `json:"username,omitempty"`
+	Password string `json:"password,omitempty"`
+	From     string `json:"from"`
+}
+
+// RecipientGroup receives a digest of its feeds on its schedule.
+type RecipientGroup struct {
+	Name       string   `json:"name"`
+	Recipients []string `json:"recipients"`
+	Schedule   Schedule `json:"schedule"`
+	Feeds      []string `json:"feeds"`
+	// OnlyChanges skips the email when no feed changed.
+	OnlyChanges bool `json:"onlyChanges,omitempty"`
+}
+
+// DigestConfig is the digest configuration file.
+type DigestConfig struct {
+	SMTP   SMTPConfig       `json:"smtp"`
+	Groups []RecipientGroup `json:"groups"`
+}
+
+// LoadDigestConfig reads a digest configuration file.
+func LoadDigestConfig(path string) (*DigestConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var cfg DigestConfig
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if cfg.SMTP.Password == "" {
+		cfg.SMTP.Password = os.Getenv("SMTP_PASSWORD")
+	}
+	if cfg.SMTP.Port == 0 {
+		cfg.SMTP.Port = 587
+	}
+	for i, g := range cfg.Groups {
+		switch g.Schedule {
+		case "":
+			cfg.Groups[i].Schedule = ScheduleDaily
+		case ScheduleDaily, ScheduleWeekly:
+		default:
+			return nil, fmt.Errorf("group %s: invalid schedule %q", g.Name, g.Schedule)
+		}
+		if len(g.Recipients) == 0 {
+			return nil, fmt.Errorf("group %s: no recipients", g.Name)
+		}
+	}
+	return &cfg, nil
+}
+
+// RenderDigest formats a digest as a plain-text email body.
+func RenderDigest(group string, d *Digest) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "GBFS validation digest for %s\n", group)
+	fmt.Fprintf(&b, "%s to %s\n", d.From.UTC().Format("2006-01-02 15:04"), d.To.UTC().Format("2006-01-02 15:04 MST"))
+
+	for _, f := range d.Feeds {
+		fmt.Fprintf(&b, "\n== %s\n", fetcher.RedactURL(f.URL))
+		if f.LatestAt == nil {
+			b.WriteString("No runs in this period.\n")
+			continue
+		}
+		fmt.Fprintf(&b, "Runs: %d, latest %s\n", f.Runs, f.LatestAt.UTC().Format(time.RFC3339))
+		fmt.Fprintf(&b, "Errors: %d", f.Errors)
+		if f.PreviousErrors != nil {
+			fmt.Fprintf(&b, " (was %d)", *f.PreviousErrors)
+		}
+		fmt.Fprintf(&b, "\nScore: %.1f", f.Score)
+		if f.PreviousScore != nil {
+			fmt.Fprintf(&b, " (%+.1f)", f.ScoreChange())
+		}
+		b.WriteString("\n")
+		writeIssues(&b, "New failures", f.NewIssues)
+		writeIssues(&b, "Resolved", f.ResolvedIssues)
+	}
+	return b.String()
+}
+
+// writeIssues lists issues under a heading, if there are any.
+func writeIssues(b *strings.Builder, heading string, issues []Issue) {
+	if len(issues) == 0 {
+		return
+	}
+	fmt.Fprintf(b, "%s:\n", heading)
+	for _, i := range issues {
+		fmt.Fprintf(b, "  - %s: %s", i.File, i.Message)
+		if i.Count > 1 {
+			fmt.Fprintf(b, " (%d instances)", i.Count)
+		}
+		b.WriteString("\n")
+	}
+}
+
+// anyChanged reports whether any feed in the digest changed.
+func anyChanged(d *Digest) bool {
+	for i := range d.Feeds {
+		if d.Feeds[i].Changed() {
+			return true
+		}
+	}
+	return false
+}
+
+// Mailer sends digests over SMTP.
+type Mailer struct {
+	cfg SMTPConfig
+	// send is smtp.SendMail, replaceable in tests.
+	send func(addr string, a smtp.Auth, from string, to []string, msg []byte) error
+}
+
+// NewMailer returns a mailer for cfg.
+func NewMailer(cfg SMTPConfig) *Mailer {
+	return &Mailer{cfg: cfg, send: smtp.SendMail}
+}
+
+// SendDigest emails a group its digest. It returns false without sending
+// when the group only wants changes and nothing changed.
+func (m *Mailer) SendDigest(group RecipientGroup, d *Digest) (bool, error) {
+	if group.OnlyChanges && !anyChanged(d) {
+		return false, nil
+	}
+
+	subject := fmt.Sprintf("GBFS %s digest: %s", group.Schedule, group.Name)
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", m.cfg.From)
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(group.Recipients, ", "))
+	fmt.Fprintf(&msg, "Subject: %s\r\n", subject)
+	fmt.Fprintf(&msg, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	msg.WriteString("MIME-Version: 1.0\r\nContent-Type: text/plain; charset=utf-8\r\n\r\n")
+	msg.WriteString(strings.ReplaceAll(RenderDigest(group.Name, d), "\n", "\r\n"))
+
+	var auth smtp.Auth
+	if m.cfg.Username != "" {
+		auth = smtp.PlainAuth("", m.cfg.Username, m.cfg.Password, m.cfg.Host)
+	}
+	addr := net.JoinHostPort(m.cfg.Host, strconv.Itoa(m.cfg.Port))
+	if err := m.send(addr, auth, m.cfg.From, group.Recipients, msg.Bytes()); err != nil {
+		return false, fmt.Errorf("sending digest to %s: %w", group.Name, err)
+	}
+	return true, nil
+}
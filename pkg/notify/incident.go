@@ -0,0 +1,418 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gbfs-validator-go/pkg/fetcher"
+	"github.com/gbfs-validator-go/pkg/validator"
+)
+
+// Action opens or closes an incident.
+type Action string
+
+const (
+	ActionTrigger Action = "trigger"
+	ActionResolve Action = "resolve"
+)
+
+// Condition is the kind of problem an incident tracks.
+type Condition string
+
+const (
+	// ConditionUnavailable is a required file that could not be fetched.
+	ConditionUnavailable Condition = "availability"
+	// ConditionInvalid is a file that fails schema validation.
+	ConditionInvalid Condition = "validity"
+)
+
+// Event opens or resolves the incident identified by DedupKey.
+type Event struct {
+	Action    Action    `json:"action"`
+	DedupKey  string    `json:"dedupKey"`
+	FeedID    string    `json:"feedId"`
+	FeedURL   string    `json:"feedUrl"`
+	File      string    `json:"file"`
+	Condition Condition `json:"condition"`
+	Summary   string    `json:"summary"`
+	Errors    int       `json:"errors,omitempty"`
+}
+
+// DedupKey identifies the incident for a condition of one file of a feed.
+func DedupKey(feedID, file string, c Condition) string {
+	return "gbfs/" + feedID + "/" + file + "/" + string(c)
+}
+
+// Alerter delivers incident events.
+type Alerter interface {
+	Send(ctx context.Context, e Event) error
+}
+
+// Alerters fans events out to several alerters.
+type Alerters []Alerter
+
+// Send delivers e to every alerter, returning the first error.
+func (as Alerters) Send(ctx context.Context, e Event) error {
+	var first error
+	for _, a := range as {
+		if err := a.Send(ctx, e); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
+
+// Tracker turns successive results of feeds into incident events, opening
+// an incident when a condition starts and resolving it when it clears. With
+// hysteresis, a condition must fail several runs in a row before it opens
+// and pass several in a row before it resolves, so flapping feeds do not
+// page on every transition.
+type Tracker struct {
+	mu           sync.Mutex
+	open         map[string]Event
+	failures     map[string]int
+	successes    map[string]int
+	triggerAfter int
+	resolveAfter int
+}
+
+// TrackerOption configures a Tracker.
+type TrackerOption func(*Tracker)
+
+// WithHysteresis requires triggerAfter consecutive failing runs to open an
+// incident and resolveAfter consecutive passing runs to resolve it. Values
+// below 1 are treated as 1.
+func WithHysteresis(triggerAfter, resolveAfter int) TrackerOption {
+	return func(t *Tracker) {
+		t.triggerAfter = max(triggerAfter, 1)
+		t.resolveAfter = max(resolveAfter, 1)
+	}
+}
+
+// NewTracker returns a tracker with no open incidents.
+func NewTracker(opts ...TrackerOption) *Tracker {
+	t := &Tracker{
+		open:         make(map[string]Event),
+		failures:     make(map[string]int),
+		successes:    make(map[string]int),
+		triggerAfter: 1,
+		resolveAfter: 1,
+	}
+	for _, opt := range opts {
+		opt(t)
+	}
+	return t
+}
+
+// Open returns the open incidents, ordered by dedup key.
+func (t *Tracker) Open() []Event {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	events := make([]Event, 0, len(t.open))
+	for _, e := range t.open {
+		events = append(events, e)
+	}
+	sort.Slice(events, func(i, j int) bool { return events[i].DedupKey < events[j].DedupKey })
+	return events
+}
+
+// FeedState is a Tracker's state for one feed: its open incidents and the
+// consecutive failing and passing runs counted toward opening or resolving
+// them. Monitors that take turns checking a feed hand it on, so that
+// hysteresis counts all of the feed's runs.
+type FeedState struct {
+	Open      []Event        `json:"open,omitempty"`
+	Failures  map[string]int `json:"failures,omitempty"`
+	Successes map[string]int `json:"successes,omitempty"`
+}
+
+// ownedBy reports whether a dedup key belongs to feedID, and not to a feed
+// whose ID merely starts with it.
+func ownedBy(key, feedID string) bool {
+	rest, ok := strings.CutPrefix(key, "gbfs/"+feedID+"/")
+	return ok && strings.Count(rest, "/") == 1
+}
+
+// FeedState returns the state of a feed's incidents.
+func (t *Tracker) FeedState(feedID string) FeedState {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	st := FeedState{Failures: make(map[string]int), Successes: make(map[string]int)}
+	for key, e := range t.open {
+		if ownedBy(key, feedID) {
+			st.Open = append(st.Open, e)
+		}
+	}
+	sort.Slice(st.Open, func(i, j int) bool { return st.Open[i].DedupKey < st.Open[j].DedupKey })
+	for key, n := range t.failures {
+		if n > 0 && ownedBy(key, feedID) {
+			st.Failures[key] = n
+		}
+	}
+	for key, n := range t.successes {
+		if n > 0 && ownedBy(key, feedID) {
+			st.Successes[key] = n
+		}
+	}
+	return st
+}
+
+// SetFeedState replaces the state of a feed's incidents.
+func (t *Tracker) SetFeedState(feedID string, st FeedState) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, m := range []map[string]int{t.failures, t.successes} {
+		for key := range m {
+			if ownedBy(key, feedID) {
+				delete(m, key)
+			}
+		}
+	}
+	for key := range t.open {
+		if ownedBy(key, feedID) {
+			delete(t.open, key)
+		}
+	}
+	for _, e := range st.Open {
+		t.open[e.DedupKey] = e
+	}
+	for key, n := range st.Failures {
+		t.failures[key] = n
+	}
+	for key, n := range st.Successes {
+		t.successes[key] = n
+	}
+}
+
+// Conditions returns the failing conditions in a result, keyed by dedup
+// key, and the set of keys the result says something about. Validity is not
+// assessed for files that could not be fetched.
+func Conditions(feedID, feedURL string, result *validator.ValidationResult) (failing map[string]Event, checked map[string]bool) {
+	failing = make(map[string]Event)
+	checked = make(map[string]bool)
+	for _, f := range result.Files {
+		availKey := DedupKey(feedID, f.File, ConditionUnavailable)
+		if f.Required {
+			checked[availKey] = true
+		}
+		if f.Status == validator.FileStatusMissing {
+			failing[availKey] = Event{
+				DedupKey: availKey, FeedID: feedID, FeedURL: feedURL, File: f.File,
+				Condition: ConditionUnavailable,
+				Summary:   fmt.Sprintf("%s is unavailable for %s", f.File, fetcher.RedactURL(feedURL)),
+			}
+			continue
+		}
+		if !f.Exists {
+			continue
+		}
+
+		validKey := DedupKey(feedID, f.File, ConditionInvalid)
+		checked[validKey] = true
+		if f.Status == validator.FileStatusInvalid {
+			failing[validKey] = Event{
+				DedupKey: validKey, FeedID: feedID, FeedURL: feedURL, File: f.File,
+				Condition: ConditionInvalid, Errors: f.ErrorsCount,
+				Summary: fmt.Sprintf("%s has %d validation errors for %s", f.File, f.ErrorsCount, fetcher.RedactURL(feedURL)),
+			}
+		}
+	}
+	return failing, checked
+}
+
+// Observe records a feed's latest result and returns the events it causes.
+func (t *Tracker) Observe(feedID, feedURL string, result *validator.ValidationResult) []Event {
+	failing, checked := Conditions(feedID, feedURL, result)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	var events []Event
+	for key := range checked {
+		e, fails := failing[key]
+		if !fails {
+			e = t.open[key]
+		}
+		if ev, ok := t.step(key, e, fails); ok {
+			events = append(events, ev)
+		}
+	}
+	sort.Slice(events, func(i, j int) bool { return events[i].DedupKey < events[j].DedupKey })
+	return events
+}
+
+// step counts one failing or passing run of a condition and returns the
+// event due, if any. The caller holds t.mu.
+func (t *Tracker) step(key string, e Event, fails bool) (Event, bool) {
+	_, isOpen := t.open[key]
+	if fails {
+		t.failures[key]++
+		t.successes[key] = 0
+		if isOpen || t.failures[key] < t.triggerAfter {
+			return Event{}, false
+		}
+		e.Action = ActionTrigger
+		t.open[key] = e
+		return e, true
+	}
+
+	t.failures[key] = 0
+	if !isOpen {
+		delete(t.successes, key)
+		return Event{}, false
+	}
+	t.successes[key]++
+	if t.successes[key] < t.resolveAfter {
+		return Event{}, false
+	}
+	delete(t.open, key)
+	delete(t.successes, key)
+	e.Action = ActionResolve
+	return e, true
+}
+
+// FeedUnreachable returns the event for a feed whose gbfs.json could not be
+// validated at all.
+func FeedUnreachable(feedID, feedURL string, err error) Event {
+	return Event{
+		DedupKey: DedupKey(feedID, "gbfs.json", ConditionUnavailable), FeedID: feedID, FeedURL: feedURL,
+		File: "gbfs.json", Condition: ConditionUnavailable,
+		Summary: fmt.Sprintf("%s could not be validated: %s", fetcher.RedactURL(feedURL), fetcher.RedactText(err.Error())),
+	}
+}
+
+// ObserveError records a failed run, counting toward the feed-level
+// incident.
+func (t *Tracker) ObserveError(feedID, feedURL string, err error) []Event {
+	e := FeedUnreachable(feedID, feedURL, err)
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if ev, ok := t.step(e.DedupKey, e, true); ok {
+		return []Event{ev}
+	}
+	return nil
+}
+
+// PagerDuty sends events to the PagerDuty Events API v2.
+type PagerDuty struct {
+	RoutingKey string `json:"routingKey"`
+	// Severity is the PagerDuty severity for triggered incidents; "error"
+	// by default.
+	Severity string `json:"severity,omitempty"`
+	// Endpoint overrides the Events API URL.
+	Endpoint string `json:"endpoint,omitempty"`
+}
+
+// Send enqueues a trigger or resolve event.
+func (p *PagerDuty) Send(ctx context.Context, e Event) error {
+	endpoint := p.Endpoint
+	if endpoint == "" {
+		endpoint = "https://events.pagerduty.com/v2/enqueue"
+	}
+	body := map[string]interface{}{
+		"routing_key":  p.RoutingKey,
+		"event_action": string(e.Action),
+		"dedup_key":    e.DedupKey,
+	}
+	if e.Action == ActionTrigger {
+		severity := p.Severity
+		if severity == "" {
+			severity = "error"
+		}
+		body["payload"] = map[string]interface{}{
+			"summary":   e.Summary,
+			"source":    fetcher.RedactURL(e.FeedURL),
+			"severity":  severity,
+			"component": e.File,
+			"class":     string(e.Condition),
+			"timestamp": time.Now().UTC().Format(time.RFC3339),
+		}
+	}
+	return postJSON(ctx, endpoint, nil, body)
+}
+
+// Opsgenie sends events to the Opsgenie Alert API, using the dedup key as
+// the alert alias.
+type Opsgenie struct {
+	APIKey string `json:"apiKey"`
+	// Priority is P1 to P5; P3 by default.
+	Priority string `json:"priority,omitempty"`
+	// Endpoint overrides the API base URL, e.g. for the EU instance.
+	Endpoint string `json:"endpoint,omitempty"`
+}
+
+// Send creates or closes an alert.
+func (o *Opsgenie) Send(ctx context.Context, e Event) error {
+	base := strings.TrimSuffix(o.Endpoint, "/")
+	if base == "" {
+		base = "https://api.opsgenie.com"
+	}
+	headers := map[string]string{"Authorization": "GenieKey " + o.APIKey}
+
+	if e.Action == ActionResolve {
+		endpoint := base + "/v2/alerts/" + url.PathEscape(e.DedupKey) + "/close?identifierType=alias"
+		return postJSON(ctx, endpoint, headers, map[string]string{"source": "gbfs-validator"})
+	}
+	priority := o.Priority
+	if priority == "" {
+		priority = "P3"
+	}
+	return postJSON(ctx, base+"/v2/alerts", headers, map[string]interface{}{
+		"message":  truncate(e.Summary, 130),
+		"alias":    e.DedupKey,
+		"priority": priority,
+		"source":   "gbfs-validator",
+		"entity":   fetcher.RedactURL(e.FeedURL),
+		"details": map[string]string{
+			"file":      e.File,
+			"condition": string(e.Condition),
+		},
+	})
+}
+
+// truncate shortens s to at most n characters, never splitting one.
+func truncate(s string, n int) string {
+	for i := range s {
+		if n == 0 {
+			return s[:i]
+		}
+		n--
+	}
+	return s
+}
+
+// postJSON posts body and fails on a non-2xx response.
+func postJSON(ctx context.Context, endpoint string, headers map[string]string, body interface{}) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	client := &http.Client{Timeout: 15 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s", fetcher.RedactText(err.Error()))
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("%s: status %d: %s", fetcher.RedactURL(endpoint), resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
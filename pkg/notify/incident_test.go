@@ -0,0 +1,169 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/gbfs-validator-go/pkg/validator"
+)
+
+// statusResult returns a run whose station_status has the given status.
+func statusResult(status validator.FileStatus) *validator.ValidationResult {
+	return &validator.ValidationResult{Files: []validator.FileValidationResult{
+		{File: "gbfs.json", Required: true, Exists: true, Status: validator.FileStatusValid},
+		{File: "station_status.json", Required: true, Exists: status != validator.FileStatusMissing, Status: status, ErrorsCount: 3},
+	}}
+}
+
+func TestTracker(t *testing.T) {
+	tr := NewTracker()
+	feed := "https://example.com/gbfs.json"
+
+	steps := []struct {
+		status validator.FileStatus
+		want   []Action
+	}{
+		{validator.FileStatusValid, nil},
+		{validator.FileStatusInvalid, []Action{ActionTrigger}},
+		{validator.FileStatusInvalid, nil},
+		{validator.FileStatusMissing, []Action{ActionTrigger}},
+		{validator.FileStatusValid, []Action{ActionResolve, ActionResolve}},
+	}
+	for i, step := range steps {
+		events := tr.Observe("f1", feed, statusResult(step.status))
+		if len(events) != len(step.want) {
+			t.Fatalf("step %d: events = %+v", i, events)
+		}
+		for j, e := range events {
+			if e.Action != step.want[j] {
+				t.Errorf("step %d: event %d = %s, want %s", i, j, e.Action, step.want[j])
+			}
+		}
+	}
+	if open := tr.Open(); len(open) != 0 {
+		t.Errorf("open incidents = %+v", open)
+	}
+}
+
+func TestPagerDutyAndOpsgenie(t *testing.T) {
+	var bodies []map[string]interface{}
+	var paths []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&body)
+		bodies = append(bodies, body)
+		paths = append(paths, r.URL.RequestURI())
+		if r.URL.Path != "/v2/enqueue" && r.Header.Get("Authorization") != "GenieKey og" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+	e := Event{Action: ActionTrigger, DedupKey: DedupKey("f1", "gbfs.json", ConditionUnavailable), File: "gbfs.json", Summary: "down"}
+	pd := &PagerDuty{RoutingKey: "rk", Endpoint: server.URL + "/v2/enqueue"}
+	og := &Opsgenie{APIKey: "og", Endpoint: server.URL}
+	if err := (Alerters{pd, og}).Send(ctx, e); err != nil {
+		t.Fatal(err)
+	}
+	e.Action = ActionResolve
+	if err := og.Send(ctx, e); err != nil {
+		t.Fatal(err)
+	}
+
+	if bodies[0]["dedup_key"] != e.DedupKey || bodies[0]["event_action"] != "trigger" {
+		t.Errorf("PagerDuty body = %v", bodies[0])
+	}
+	if bodies[1]["alias"] != e.DedupKey {
+		t.Errorf("Opsgenie body = %v", bodies[1])
+	}
+	if want := "/v2/alerts/gbfs%2Ff1%2Fgbfs.json%2Favailability/close?identifierType=alias"; paths[2] != want {
+		t.Errorf("Opsgenie close path = %s, want %s", paths[2], want)
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	for _, tc := range []struct {
+		s    string
+		n    int
+		want string
+	}{
+		{"down", 130, "down"},
+		{"station_status", 7, "station"},
+		{"Gare de l'Est – Île-de-France", 16, "Gare de l'Est – "},
+		{"自転車", 2, "自転"},
+	} {
+		if got := truncate(tc.s, tc.n); got != tc.want {
+			t.Errorf("truncate(%q, %d) = %q, want %q", tc.s, tc.n, got, tc.want)
+		}
+	}
+	if got := truncate(strings.Repeat("é", 200), 130); !utf8.ValidString(got) || utf8.RuneCountInString(got) != 130 {
+		t.Errorf("truncate kept %d characters, valid %v", utf8.RuneCountInString(got), utf8.ValidString(got))
+	}
+}
+
+func TestTrackerHysteresis(t *testing.T) {
+	tr := NewTracker(WithHysteresis(3, 2))
+	feed := "https://example.com/gbfs.json"
+	valid, invalid := validator.FileStatusValid, validator.FileStatusInvalid
+
+	// Intermittent failures never reach three in a row.
+	for i, status := range []validator.FileStatus{invalid, invalid, valid, invalid, valid} {
+		if events := tr.Observe("f1", feed, statusResult(status)); len(events) != 0 {
+			t.Fatalf("flap %d raised %+v", i, events)
+		}
+	}
+
+	steps := []struct {
+		status validator.FileStatus
+		want   Action
+	}{
+		{invalid, ""}, {invalid, ""}, {invalid, ActionTrigger},
+		{valid, ""}, {invalid, ""}, {valid, ""}, {valid, ActionResolve},
+	}
+	for i, step := range steps {
+		events := tr.Observe("f1", feed, statusResult(step.status))
+		var got Action
+		if len(events) == 1 {
+			got = events[0].Action
+		} else if len(events) > 1 {
+			t.Fatalf("step %d: events = %+v", i, events)
+		}
+		if got != step.want {
+			t.Errorf("step %d: action %q, want %q", i, got, step.want)
+		}
+	}
+}
+
+func TestFeedStateHandOff(t *testing.T) {
+	// Two trackers taking turns on a feed count its failures together.
+	a, b := NewTracker(WithHysteresis(2, 1)), NewTracker(WithHysteresis(2, 1))
+	feed := "https://example.com/gbfs.json"
+	invalid := statusResult(validator.FileStatusInvalid)
+
+	a.Observe("f1", feed, invalid)
+	a.Observe("f1/other", feed, invalid)
+	b.SetFeedState("f1", a.FeedState("f1"))
+	events := b.Observe("f1", feed, invalid)
+	if len(events) != 1 || events[0].Action != ActionTrigger {
+		t.Fatalf("second failure on another tracker raised %+v", events)
+	}
+
+	a.SetFeedState("f1", b.FeedState("f1"))
+	if open := a.FeedState("f1").Open; len(open) != 1 {
+		t.Errorf("handed-back open incidents = %+v", open)
+	}
+	if st := a.FeedState("f1/other"); st.Failures[DedupKey("f1/other", "station_status.json", ConditionInvalid)] != 1 {
+		t.Errorf("another feed's state changed: %+v", st)
+	}
+	if events := a.Observe("f1", feed, statusResult(validator.FileStatusValid)); len(events) != 1 || events[0].Action != ActionResolve {
+		t.Errorf("recovery raised %+v", events)
+	}
+}
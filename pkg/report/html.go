@@ -0,0 +1,57 @@
+package report
+
+import (
+	"html/template"
+	"io"
+
+	"github.com/gbfs-validator-go/pkg/validator"
+)
+
+// htmlTemplate is a standalone page with the summary, a table of files, and
+// each file's issues.
+var htmlTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"status":     status,
+	"fileStatus": fileStatus,
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>GBFS validation: {{status .}}</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; }
+th, td { border: 1px solid #ccc; padding: 0.3em 0.6em; text-align: left; }
+.error { color: #b00020; }
+.warning { color: #9a6700; }
+.info { color: #0969da; }
+code { background: #f3f3f3; }
+</style>
+</head>
+<body>
+<h1>GBFS validation: {{status .}}</h1>
+{{with .Summary}}<p>Version {{.Version.Validated}} (detected {{.Version.Detected}}): {{.ErrorsCount}} errors, {{.WarningsCount}} warnings, {{.InfosCount}} info.</p>
+{{if .Incomplete}}<p><strong>Incomplete:</strong> the deadline passed before every file was checked.</p>
+{{end}}{{if .Sampled}}<p><strong>Sampled:</strong> only some entries of large arrays were checked.</p>
+{{end}}{{end}}<table>
+<tr><th>File</th><th>Status</th><th>Errors</th><th>Warnings</th></tr>
+{{range .Files}}<tr><td>{{.File}}</td><td>{{fileStatus .}}</td><td>{{.ErrorsCount}}</td><td>{{.WarningsCount}}</td></tr>
+{{end}}</table>
+{{range .Files}}{{if .Errors}}<h2>{{.File}}</h2>
+<ul>
+{{range .Errors}}<li class="{{.Severity}}"><strong>{{.Severity}}</strong>: {{.Message}}{{if .InstancePath}} at <code>{{.InstancePath}}</code>{{end}}{{with .Spec}} (<a href="{{.URL}}">spec</a>){{end}}</li>
+{{end}}</ul>
+{{end}}{{end}}{{if .RulePacks}}<h2>Rule packs</h2>
+<ul>
+{{range .RulePacks}}<li>{{.Name}}: {{if .Passed}}pass{{else}}fail{{end}}{{if not .Passed}}
+<ul>
+{{range .Rules}}{{if not .Passed}}<li class="{{.Severity}}"><strong>{{.Severity}}</strong> {{.ID}}: {{.Message}}</li>
+{{end}}{{end}}</ul>{{end}}</li>
+{{end}}</ul>
+{{end}}</body>
+</html>
+`))
+
+// renderHTML writes the result as a standalone HTML page.
+func renderHTML(result *validator.ValidationResult, w io.Writer) error {
+	return htmlTemplate.Execute(w, result)
+}
@@ -0,0 +1,118 @@
+package report
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/gbfs-validator-go/pkg/validator"
+)
+
+// junitSuites is the root of a JUnit XML report.
+type junitSuites struct {
+	XMLName  xml.Name     `xml:"testsuites"`
+	Name     string       `xml:"name,attr"`
+	Tests    int          `xml:"tests,attr"`
+	Failures int          `xml:"failures,attr"`
+	Skipped  int          `xml:"skipped,attr"`
+	Suites   []junitSuite `xml:"testsuite"`
+}
+
+// junitSuite groups test cases.
+type junitSuite struct {
+	Name     string      `xml:"name,attr"`
+	Tests    int         `xml:"tests,attr"`
+	Failures int         `xml:"failures,attr"`
+	Skipped  int         `xml:"skipped,attr"`
+	Cases    []junitCase `xml:"testcase"`
+}
+
+// junitCase is one file or rule.
+type junitCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	Skipped   *junitSkipped `xml:"skipped,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+// junitFailure explains a failing test case.
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// junitSkipped marks a test case that did not run.
+type junitSkipped struct {
+	Message string `xml:"message,attr"`
+}
+
+// renderJUnit writes a JUnit XML report with one test case per file, failing
+// when the file has errors, and one per rule pack rule, so CI systems can
+// show feed problems as test failures. Warnings and info go to system-out.
+func renderJUnit(result *validator.ValidationResult, w io.Writer) error {
+	files := junitSuite{Name: "files"}
+	for _, file := range result.Files {
+		c := junitCase{Name: file.File, ClassName: "gbfs.files"}
+		var errs, others []string
+		for _, e := range file.Errors {
+			line := fmt.Sprintf("%s: %s", e.Severity, e.Message)
+			if e.InstancePath != "" {
+				line += " at " + e.InstancePath
+			}
+			if e.Severity == validator.SeverityError {
+				errs = append(errs, line)
+			} else {
+				others = append(others, line)
+			}
+		}
+		switch {
+		case file.Status == validator.FileStatusNotChecked:
+			c.Skipped = &junitSkipped{Message: fileStatus(file)}
+			files.Skipped++
+		case file.HasErrors || (!file.Exists && file.Required):
+			msg := fmt.Sprintf("%d errors", file.ErrorsCount)
+			if !file.Exists {
+				msg = fileStatus(file)
+			}
+			c.Failure = &junitFailure{Message: msg, Type: string(file.Status), Text: strings.Join(errs, "\n")}
+			files.Failures++
+		}
+		c.SystemOut = strings.Join(others, "\n")
+		files.Cases = append(files.Cases, c)
+	}
+	files.Tests = len(files.Cases)
+
+	report := junitSuites{Name: "gbfs-validator", Suites: []junitSuite{files}}
+	for _, pack := range result.RulePacks {
+		suite := junitSuite{Name: "rulepack." + pack.Name}
+		for _, rule := range pack.Rules {
+			c := junitCase{Name: rule.ID, ClassName: "gbfs.rulepacks." + pack.Name}
+			if !rule.Passed {
+				c.Failure = &junitFailure{Message: rule.Message, Type: string(rule.Severity), Text: strings.Join(rule.Examples, "\n")}
+				suite.Failures++
+			}
+			suite.Cases = append(suite.Cases, c)
+		}
+		suite.Tests = len(suite.Cases)
+		report.Suites = append(report.Suites, suite)
+	}
+	for _, suite := range report.Suites {
+		report.Tests += suite.Tests
+		report.Failures += suite.Failures
+		report.Skipped += suite.Skipped
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(report); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
@@ -0,0 +1,78 @@
+package report
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/gbfs-validator-go/pkg/validator"
+)
+
+// renderMarkdown writes a report suited to pull request comments and
+// issue trackers: a summary table of files, then each file's issues.
+func renderMarkdown(result *validator.ValidationResult, w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	s := result.Summary
+	fmt.Fprintf(bw, "# GBFS validation: %s\n\n", status(result))
+	fmt.Fprintf(bw, "Version %s (detected %s): %d errors, %d warnings, %d info.\n",
+		mdEscape(s.Version.Validated), mdEscape(s.Version.Detected), s.ErrorsCount, s.WarningsCount, s.InfosCount)
+	if s.Incomplete {
+		fmt.Fprintln(bw, "\n**Incomplete:** the deadline passed before every file was checked.")
+	}
+	if s.Sampled {
+		fmt.Fprintln(bw, "\n**Sampled:** only some entries of large arrays were checked.")
+	}
+
+	fmt.Fprintln(bw, "\n| File | Status | Errors | Warnings |")
+	fmt.Fprintln(bw, "| --- | --- | ---: | ---: |")
+	for _, file := range result.Files {
+		fmt.Fprintf(bw, "| %s | %s | %d | %d |\n", mdEscape(file.File), fileStatus(file), file.ErrorsCount, file.WarningsCount)
+	}
+
+	for _, file := range result.Files {
+		if len(file.Errors) == 0 {
+			continue
+		}
+		fmt.Fprintf(bw, "\n## %s\n\n", mdEscape(file.File))
+		for _, e := range file.Errors {
+			fmt.Fprintf(bw, "- **%s**: %s", e.Severity, mdEscape(e.Message))
+			if e.InstancePath != "" {
+				fmt.Fprintf(bw, " at `%s`", strings.ReplaceAll(e.InstancePath, "`", "'"))
+			}
+			if e.Spec != nil {
+				fmt.Fprintf(bw, " ([spec](%s))", e.Spec.URL)
+			}
+			fmt.Fprintln(bw)
+		}
+	}
+
+	if len(result.RulePacks) > 0 {
+		fmt.Fprintln(bw, "\n## Rule packs")
+		fmt.Fprintln(bw)
+		for _, pack := range result.RulePacks {
+			outcome := "pass"
+			if !pack.Passed {
+				outcome = "fail"
+			}
+			fmt.Fprintf(bw, "- %s: %s\n", mdEscape(pack.Name), outcome)
+			for _, rule := range pack.Rules {
+				if !rule.Passed {
+					fmt.Fprintf(bw, "  - **%s** %s: %s\n", rule.Severity, mdEscape(rule.ID), mdEscape(rule.Message))
+				}
+			}
+		}
+	}
+	return bw.Flush()
+}
+
+// mdReplacer escapes characters that would break a table cell or start
+// inline markup.
+var mdReplacer = strings.NewReplacer(
+	`\`, `\\`, "|", `\|`, "*", `\*`, "_", `\_`, "`", "\\`", "<", "&lt;", "\n", " ",
+)
+
+// mdEscape escapes text for Markdown.
+func mdEscape(s string) string {
+	return mdReplacer.Replace(s)
+}
@@ -0,0 +1,122 @@
+// Package report renders validation results in the output formats offered
+// by the CLI and the API.
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+
+	"github.com/gbfs-validator-go/pkg/validator"
+)
+
+// Renderer writes a validation result in one output format.
+type Renderer interface {
+	Render(result *validator.ValidationResult, w io.Writer) error
+}
+
+// RendererFunc adapts a function to the Renderer interface.
+type RendererFunc func(result *validator.ValidationResult, w io.Writer) error
+
+// Render calls f.
+func (f RendererFunc) Render(result *validator.ValidationResult, w io.Writer) error {
+	return f(result, w)
+}
+
+// Format is a registered renderer and the media type of its output.
+type Format struct {
+	Name        string
+	ContentType string
+	Renderer    Renderer
+}
+
+var (
+	mu      sync.RWMutex
+	formats = make(map[string]Format)
+)
+
+// Register makes a renderer available under name, replacing any renderer
+// already registered under it.
+func Register(name, contentType string, r Renderer) {
+	mu.Lock()
+	defer mu.Unlock()
+	formats[name] = Format{Name: name, ContentType: contentType, Renderer: r}
+}
+
+// Lookup returns the format registered under name.
+func Lookup(name string) (Format, bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+	f, ok := formats[name]
+	return f, ok
+}
+
+// Names lists the registered format names, sorted.
+func Names() []string {
+	mu.RLock()
+	defer mu.RUnlock()
+	names := make([]string, 0, len(formats))
+	for name := range formats {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Render writes result in the named format.
+func Render(name string, result *validator.ValidationResult, w io.Writer) error {
+	f, ok := Lookup(name)
+	if !ok {
+		return fmt.Errorf("unknown output format %q", name)
+	}
+	return f.Renderer.Render(result, w)
+}
+
+func init() {
+	Register("text", "text/plain; charset=utf-8", RendererFunc(renderText))
+	Register("json", "application/json", RendererFunc(renderJSON))
+	Register("markdown", "text/markdown; charset=utf-8", RendererFunc(renderMarkdown))
+	Register("html", "text/html; charset=utf-8", RendererFunc(renderHTML))
+	Register("csv", "text/csv; charset=utf-8", RendererFunc(renderCSV))
+	Register("junit", "application/xml", RendererFunc(renderJUnit))
+	Register("sarif", "application/sarif+json", RendererFunc(renderSARIF))
+}
+
+// renderJSON writes the result as indented JSON.
+func renderJSON(result *validator.ValidationResult, w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(result)
+}
+
+// renderCSV writes one row per issue; see validator.ValidationResult.WriteCSV.
+func renderCSV(result *validator.ValidationResult, w io.Writer) error {
+	return result.WriteCSV(w)
+}
+
+// status returns the overall outcome in words.
+func status(result *validator.ValidationResult) string {
+	if result.Summary.HasErrors {
+		return "INVALID"
+	}
+	return "VALID"
+}
+
+// fileStatus returns a file's outcome in words.
+func fileStatus(file validator.FileValidationResult) string {
+	switch {
+	case file.HasErrors:
+		return "invalid"
+	case file.Status == validator.FileStatusNotChecked:
+		return "not checked (timeout)"
+	case !file.Exists && file.Required:
+		return "missing (required)"
+	case !file.Exists && file.Status == validator.FileStatusRecommendedMissing:
+		return "missing (recommended)"
+	case !file.Exists:
+		return "not present (optional)"
+	}
+	return "valid"
+}
@@ -0,0 +1,135 @@
+package report
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/gbfs-validator-go/pkg/validator"
+)
+
+func testResult() *validator.ValidationResult {
+	return &validator.ValidationResult{
+		Summary: validator.ValidationSummary{
+			ValidatorVersion: "1.0.0",
+			Version:          validator.VersionInfo{Detected: "3.0", Validated: "3.0"},
+			HasErrors:        true,
+			ErrorsCount:      1,
+			WarningsCount:    1,
+		},
+		Files: []validator.FileValidationResult{
+			{File: "gbfs", URL: "https://example.com/gbfs.json", Required: true, Exists: true, Status: validator.FileStatusValid},
+			{File: "station_status", URL: "https://example.com/station_status.json", Required: true, Exists: true,
+				Status: validator.FileStatusInvalid, HasErrors: true, ErrorsCount: 1, WarningsCount: 1,
+				Errors: []validator.ValidationError{
+					{Severity: validator.SeverityError, Message: "missing property 'num_docks_available'", InstancePath: "/data/stations/0", Keyword: "required",
+						Spec: &validator.SpecReference{URL: "https://github.com/MobilityData/gbfs/blob/v3.0/gbfs.md#station_statusjson", Section: "station_statusjson"}},
+					{Severity: validator.SeverityWarning, Message: "last_reported is stale", InstancePath: "/data/stations/1/last_reported", Category: validator.CategoryFreshness},
+				}},
+			{File: "vehicle_types", Status: validator.FileStatusNotChecked},
+		},
+	}
+}
+
+func TestFormats(t *testing.T) {
+	want := []string{"csv", "html", "json", "junit", "markdown", "sarif", "text"}
+	if got := strings.Join(Names(), ","); got != strings.Join(want, ",") {
+		t.Fatalf("Names() = %s", got)
+	}
+
+	checks := map[string]func(t *testing.T, out []byte){
+		"text": func(t *testing.T, out []byte) {
+			for _, s := range []string{"Status: INVALID", "station_status: invalid", "vehicle_types: not checked"} {
+				if !bytes.Contains(out, []byte(s)) {
+					t.Errorf("missing %q in\n%s", s, out)
+				}
+			}
+		},
+		"json": func(t *testing.T, out []byte) {
+			var r validator.ValidationResult
+			if err := json.Unmarshal(out, &r); err != nil || len(r.Files) != 3 {
+				t.Errorf("round trip: %v, %d files", err, len(r.Files))
+			}
+		},
+		"markdown": func(t *testing.T, out []byte) {
+			if !bytes.Contains(out, []byte("| station\\_status | invalid | 1 | 1 |")) {
+				t.Errorf("missing table row in\n%s", out)
+			}
+			if !bytes.Contains(out, []byte("([spec](https://github.com/MobilityData/gbfs/blob/v3.0/gbfs.md#station_statusjson))")) {
+				t.Errorf("missing spec link in\n%s", out)
+			}
+		},
+		"html": func(t *testing.T, out []byte) {
+			if !bytes.Contains(out, []byte("missing property &#39;num_docks_available&#39;")) {
+				t.Errorf("message not escaped in\n%s", out)
+			}
+			if !bytes.Contains(out, []byte(`<a href="https://github.com/MobilityData/gbfs/blob/v3.0/gbfs.md#station_statusjson">spec</a>`)) {
+				t.Errorf("missing spec link in\n%s", out)
+			}
+		},
+		"csv": func(t *testing.T, out []byte) {
+			if lines := strings.Count(string(out), "\n"); lines != 3 {
+				t.Errorf("expected header and 2 rows, got %d lines", lines)
+			}
+		},
+		"junit": func(t *testing.T, out []byte) {
+			var suites junitSuites
+			if err := xml.Unmarshal(out, &suites); err != nil {
+				t.Fatal(err)
+			}
+			if suites.Tests != 3 || suites.Failures != 1 || suites.Skipped != 1 {
+				t.Errorf("tests=%d failures=%d skipped=%d", suites.Tests, suites.Failures, suites.Skipped)
+			}
+		},
+		"sarif": func(t *testing.T, out []byte) {
+			var log sarifLog
+			if err := json.Unmarshal(out, &log); err != nil {
+				t.Fatal(err)
+			}
+			results := log.Runs[0].Results
+			if len(results) != 2 || results[0].RuleID != "required" || results[1].Level != "warning" {
+				t.Errorf("unexpected results %+v", results)
+			}
+			if uri := results[0].Locations[0].PhysicalLocation.ArtifactLocation.URI; uri != "https://example.com/station_status.json" {
+				t.Errorf("uri = %s", uri)
+			}
+		},
+	}
+	for name, check := range checks {
+		t.Run(name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := Render(name, testResult(), &buf); err != nil {
+				t.Fatal(err)
+			}
+			check(t, buf.Bytes())
+		})
+	}
+
+	if err := Render("pdf", testResult(), io.Discard); err == nil {
+		t.Error("expected an error for an unknown format")
+	}
+}
+
+func TestRegister(t *testing.T) {
+	Register("count", "text/plain", RendererFunc(func(r *validator.ValidationResult, w io.Writer) error {
+		_, err := io.WriteString(w, "files="+string(rune('0'+len(r.Files))))
+		return err
+	}))
+	defer func() {
+		mu.Lock()
+		delete(formats, "count")
+		mu.Unlock()
+	}()
+
+	f, ok := Lookup("count")
+	if !ok || f.ContentType != "text/plain" {
+		t.Fatalf("Lookup = %+v, %v", f, ok)
+	}
+	var buf bytes.Buffer
+	if err := f.Renderer.Render(testResult(), &buf); err != nil || buf.String() != "files=3" {
+		t.Errorf("Render = %q, %v", buf.String(), err)
+	}
+}
@@ -0,0 +1,153 @@
+package report
+
+import (
+	"encoding/json"
+	"io"
+	"sort"
+
+	"github.com/gbfs-validator-go/pkg/validator"
+)
+
+// sarifSchema is the JSON schema of SARIF 2.1.0 logs.
+const sarifSchema = "https://json.schemastore.org/sarif-2.1.0.json"
+
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	Version        string      `json:"version,omitempty"`
+	InformationURI string      `json:"informationUri,omitempty"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID string `json:"id"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations,omitempty"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation *sarifPhysicalLocation `json:"physicalLocation,omitempty"`
+	LogicalLocations []sarifLogicalLocation `json:"logicalLocations,omitempty"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifLogicalLocation struct {
+	FullyQualifiedName string `json:"fullyQualifiedName"`
+}
+
+// sarifLevel maps a severity to a SARIF result level.
+func sarifLevel(severity validator.ValidationSeverity) string {
+	switch severity {
+	case validator.SeverityError:
+		return "error"
+	case validator.SeverityWarning:
+		return "warning"
+	default:
+		return "note"
+	}
+}
+
+// ruleID names the check behind a finding: its schema keyword, else its
+// category.
+func ruleID(e validator.ValidationError) string {
+	switch {
+	case e.Keyword != "":
+		return e.Keyword
+	case e.Category != "":
+		return string(e.Category)
+	}
+	return "gbfs"
+}
+
+// renderSARIF writes a SARIF 2.1.0 log for code scanning tools. Findings
+// are located by file URL and, within the file, by JSON pointer.
+func renderSARIF(result *validator.ValidationResult, w io.Writer) error {
+	run := sarifRun{
+		Tool: sarifTool{Driver: sarifDriver{
+			Name:           "gbfs-validator-go",
+			Version:        result.Summary.ValidatorVersion,
+			InformationURI: "https://github.com/MobilityData/gbfs",
+		}},
+		Results: []sarifResult{},
+	}
+
+	rules := make(map[string]bool)
+	for _, file := range result.Files {
+		uri := file.URL
+		if uri == "" {
+			uri = file.File + ".json"
+		}
+		for _, e := range file.Errors {
+			id := ruleID(e)
+			rules[id] = true
+			loc := sarifLocation{PhysicalLocation: &sarifPhysicalLocation{ArtifactLocation: sarifArtifactLocation{URI: uri}}}
+			if e.InstancePath != "" {
+				loc.LogicalLocations = []sarifLogicalLocation{{FullyQualifiedName: e.InstancePath}}
+			}
+			run.Results = append(run.Results, sarifResult{
+				RuleID:    id,
+				Level:     sarifLevel(e.Severity),
+				Message:   sarifMessage{Text: e.Message},
+				Locations: []sarifLocation{loc},
+			})
+		}
+	}
+	for _, pack := range result.RulePacks {
+		for _, rule := range pack.Rules {
+			if rule.Passed {
+				continue
+			}
+			id := pack.Name + "/" + rule.ID
+			rules[id] = true
+			run.Results = append(run.Results, sarifResult{
+				RuleID:  id,
+				Level:   sarifLevel(rule.Severity),
+				Message: sarifMessage{Text: rule.Message},
+			})
+		}
+	}
+
+	ids := make([]string, 0, len(rules))
+	for id := range rules {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	run.Tool.Driver.Rules = make([]sarifRule, len(ids))
+	for i, id := range ids {
+		run.Tool.Driver.Rules[i] = sarifRule{ID: id}
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(sarifLog{Schema: sarifSchema, Version: "2.1.0", Runs: []sarifRun{run}})
+}
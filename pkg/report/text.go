@@ -0,0 +1,56 @@
+package report
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/gbfs-validator-go/pkg/validator"
+)
+
+// renderText writes a plain-text report listing every issue.
+func renderText(result *validator.ValidationResult, w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	s := result.Summary
+	fmt.Fprintf(bw, "Version: detected=%s, validated=%s\n", s.Version.Detected, s.Version.Validated)
+	fmt.Fprintf(bw, "Status: %s (%d errors, %d warnings, %d info)\n", status(result), s.ErrorsCount, s.WarningsCount, s.InfosCount)
+	if s.Incomplete {
+		fmt.Fprintln(bw, "INCOMPLETE: the deadline passed before every file was checked")
+	}
+	if s.Sampled {
+		fmt.Fprintln(bw, "SAMPLED: only some entries of large arrays were checked")
+	}
+
+	fmt.Fprintln(bw, "\nFiles:")
+	for _, file := range result.Files {
+		fmt.Fprintf(bw, "  %s: %s\n", file.File, fileStatus(file))
+		for _, e := range file.Errors {
+			line := fmt.Sprintf("      %s: %s", e.Severity, e.Message)
+			if e.InstancePath != "" {
+				line += " at " + e.InstancePath
+			}
+			fmt.Fprintln(bw, line)
+		}
+	}
+
+	if len(result.RulePacks) > 0 {
+		fmt.Fprintln(bw, "\nRule packs:")
+		for _, pack := range result.RulePacks {
+			outcome := "PASS"
+			if !pack.Passed {
+				outcome = "FAIL"
+			}
+			fmt.Fprintf(bw, "  %s %s\n", outcome, pack.Name)
+			for _, rule := range pack.Rules {
+				if !rule.Passed {
+					fmt.Fprintf(bw, "      %s: %s: %s\n", rule.Severity, rule.ID, rule.Message)
+				}
+			}
+		}
+	}
+	if len(result.SuggestedGBFS) > 0 {
+		fmt.Fprintf(bw, "\nSuggested gbfs.json:\n%s\n", strings.TrimSpace(string(result.SuggestedGBFS)))
+	}
+	return bw.Flush()
+}
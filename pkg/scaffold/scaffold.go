@@ -0,0 +1,138 @@
+// Package scaffold helps new publishers bootstrap GBFS feeds.
+package scaffold
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gbfs-validator-go/pkg/fetcher"
+	"github.com/gbfs-validator-go/pkg/version"
+)
+
+// Discovery is the outcome of probing a base URL for feed files.
+type Discovery struct {
+	// Version is the GBFS version declared by the probed files, or empty if
+	// none declared one.
+	Version string
+	// Language is the system_information language, used for v1/v2 layouts.
+	Language string
+	// Feeds maps discovered feed names to their URLs.
+	Feeds map[string]string
+}
+
+// KnownFeeds lists every feed file name defined by any supported version,
+// excluding gbfs itself.
+func KnownFeeds() []string {
+	seen := make(map[string]bool)
+	var names []string
+	for _, ver := range version.SupportedVersions() {
+		for _, req := range version.GetFileRequirements(ver, version.Options{}) {
+			if !seen[req.File] {
+				seen[req.File] = true
+				names = append(names, req.File)
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Probe requests <baseURL>/<name>.json for every known feed file and records
+// those that respond. Files from other versions are dropped once the version
+// is known, so a v3 feed keeps vehicle_status rather than free_bike_status.
+func Probe(ctx context.Context, f *fetcher.Fetcher, baseURL, ver string) *Discovery {
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	bodies := make(map[string][]byte)
+	feeds := make(map[string]string)
+
+	for _, name := range KnownFeeds() {
+		name := name
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			url := fetcher.BuildFeedURL(baseURL, name)
+			result := f.Fetch(ctx, url)
+			if result.Error != nil || !result.Exists {
+				return
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			feeds[name] = url
+			bodies[name] = result.Body
+		}()
+	}
+	wg.Wait()
+
+	d := &Discovery{Version: ver, Feeds: feeds}
+
+	var si struct {
+		Version string `json:"version"`
+		Data    struct {
+			Language string `json:"language"`
+		} `json:"data"`
+	}
+	if body, ok := bodies["system_information"]; ok && json.Unmarshal(body, &si) == nil {
+		d.Language = si.Data.Language
+		if d.Version == "" {
+			d.Version = si.Version
+		}
+	}
+
+	if _, ok := version.GetConfig(d.Version); ok {
+		allowed := make(map[string]bool)
+		for _, req := range version.GetFileRequirements(d.Version, version.Options{}) {
+			allowed[req.File] = true
+		}
+		for name := range feeds {
+			if !allowed[name] {
+				delete(feeds, name)
+			}
+		}
+	}
+
+	return d
+}
+
+// GBFS renders a gbfs.json listing feeds in the layout ver expects: a flat
+// feed list for v3 and later, or a block under lang for earlier versions.
+// self is the URL the gbfs.json is published at, which v3 and later list
+// as the gbfs feed unless feeds has one; it is omitted when empty.
+func GBFS(feeds map[string]string, ver, lang, self string, now time.Time) ([]byte, error) {
+	type feed struct {
+		Name string `json:"name"`
+		URL  string `json:"url"`
+	}
+
+	list := make([]feed, 0, len(feeds)+1)
+	for name, url := range feeds {
+		list = append(list, feed{Name: name, URL: url})
+	}
+	if _, listed := feeds["gbfs"]; !listed && self != "" && version.IsV3OrLater(ver) {
+		list = append(list, feed{Name: "gbfs", URL: self})
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+
+	doc := map[string]interface{}{"ttl": 0}
+	if ver != "" && ver != "1.0" {
+		doc["version"] = ver
+	}
+
+	if version.IsV3OrLater(ver) {
+		doc["last_updated"] = now.UTC().Format(time.RFC3339)
+		doc["data"] = map[string]interface{}{"feeds": list}
+	} else {
+		if lang == "" {
+			lang = "en"
+		}
+		doc["last_updated"] = now.Unix()
+		doc["data"] = map[string]interface{}{
+			lang: map[string]interface{}{"feeds": list},
+		}
+	}
+
+	return json.MarshalIndent(doc, "", "  ")
+}
@@ -0,0 +1,84 @@
+package scaffold
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gbfs-validator-go/pkg/fetcher"
+)
+
+// TestProbe checks discovered files are filtered to the declared version.
+func TestProbe(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/system_information.json", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"version":"3.0","data":{"system_id":"s"}}`))
+	})
+	for _, name := range []string{"station_status", "free_bike_status", "vehicle_status"} {
+		mux.HandleFunc("/"+name+".json", func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(`{}`))
+		})
+	}
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	d := Probe(context.Background(), fetcher.New(), server.URL, "")
+	if d.Version != "3.0" {
+		t.Errorf("expected version 3.0, got %q", d.Version)
+	}
+	for _, name := range []string{"system_information", "station_status", "vehicle_status"} {
+		if _, ok := d.Feeds[name]; !ok {
+			t.Errorf("expected %s to be discovered", name)
+		}
+	}
+	if _, ok := d.Feeds["free_bike_status"]; ok {
+		t.Error("free_bike_status is not a v3.0 file and should be dropped")
+	}
+}
+
+// TestGBFSLayout checks v2 and v3 layouts.
+func TestGBFSLayout(t *testing.T) {
+	feeds := map[string]string{"system_information": "https://example.com/system_information.json"}
+	now := time.Unix(1700000000, 0)
+
+	self := "https://example.com/gbfs.json"
+	data, err := GBFS(feeds, "2.3", "fr", self, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var v2 struct {
+		LastUpdated int64 `json:"last_updated"`
+		Data        map[string]struct {
+			Feeds []map[string]string `json:"feeds"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(data, &v2); err != nil {
+		t.Fatal(err)
+	}
+	if v2.LastUpdated != now.Unix() || len(v2.Data["fr"].Feeds) != 1 {
+		t.Errorf("unexpected v2 gbfs.json: %s", data)
+	}
+
+	data, err = GBFS(feeds, "3.0", "", self, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var v3 struct {
+		LastUpdated string `json:"last_updated"`
+		Data        struct {
+			Feeds []map[string]string `json:"feeds"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(data, &v3); err != nil {
+		t.Fatal(err)
+	}
+	if v3.LastUpdated != "2023-11-14T22:13:20Z" || len(v3.Data.Feeds) != 2 {
+		t.Errorf("unexpected v3 gbfs.json: %s", data)
+	}
+	if first := v3.Data.Feeds[0]; first["name"] != "gbfs" || first["url"] != self {
+		t.Errorf("v3 gbfs.json does not list itself: %s", data)
+	}
+}
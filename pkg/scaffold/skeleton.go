@@ -0,0 +1,232 @@
+package scaffold
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/gbfs-validator-go/pkg/fetcher"
+	"github.com/gbfs-validator-go/pkg/version"
+)
+
+// Placeholder values used in skeleton files. Publishers are expected to
+// replace every one of them.
+const (
+	PlaceholderSystemID    = "example_system"
+	PlaceholderStationID   = "station_1"
+	PlaceholderVehicleID   = "vehicle_1"
+	PlaceholderVehicleType = "bike"
+	PlaceholderPlanID      = "plan_1"
+	PlaceholderEmail       = "feed-contact@example.com"
+	PlaceholderTimezone    = "Etc/UTC"
+	PlaceholderLanguage    = "en"
+)
+
+// DefaultTTL is the ttl of skeleton files: short enough for realtime files
+// and long enough that files which change rarely can be cached.
+const DefaultTTL = 60
+
+// skeleton renders placeholder documents for one version.
+type skeleton struct {
+	ver string
+	now time.Time
+}
+
+// Skeleton returns minimal example files for ver, keyed by file name with the
+// .json suffix, plus a gbfs.json whose URLs point at baseURL. When neither
+// Docked nor Freefloating is set, both station and vehicle files are emitted.
+func Skeleton(ver, baseURL string, opts version.Options, now time.Time) (map[string][]byte, error) {
+	if _, ok := version.GetConfig(ver); !ok {
+		return nil, fmt.Errorf("unsupported GBFS version %q (supported: %v)", ver, version.SupportedVersions())
+	}
+	if !opts.Docked && !opts.Freefloating {
+		opts.Docked = true
+		opts.Freefloating = true
+	}
+
+	s := &skeleton{ver: ver, now: now}
+	docs := map[string]interface{}{
+		"system_information": s.systemInformation(),
+	}
+	if s.hasFile("vehicle_types") {
+		docs["vehicle_types"] = s.vehicleTypes()
+		docs["system_pricing_plans"] = s.pricingPlans()
+	}
+	if opts.Docked {
+		docs["station_information"] = s.stationInformation()
+		docs["station_status"] = s.stationStatus()
+	}
+	if opts.Freefloating {
+		docs[version.GetVehicleStatusFileName(ver)] = s.vehicleStatus()
+	}
+
+	files := make(map[string][]byte, len(docs)+1)
+	feeds := make(map[string]string, len(docs))
+	for name, doc := range docs {
+		data, err := json.MarshalIndent(doc, "", "  ")
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		files[name+".json"] = data
+		feeds[name] = fetcher.BuildFeedURL(baseURL, name)
+	}
+
+	gbfsData, err := GBFS(feeds, ver, PlaceholderLanguage, fetcher.BuildFeedURL(baseURL, "gbfs"), now)
+	if err != nil {
+		return nil, fmt.Errorf("gbfs: %w", err)
+	}
+	files["gbfs.json"] = gbfsData
+
+	return files, nil
+}
+
+// hasFile reports whether the version defines a feed file.
+func (s *skeleton) hasFile(name string) bool {
+	for _, req := range version.GetFileRequirements(s.ver, version.Options{}) {
+		if req.File == name {
+			return true
+		}
+	}
+	return false
+}
+
+// v3 reports whether the version uses v3 layouts.
+func (s *skeleton) v3() bool {
+	return version.IsV3OrLater(s.ver)
+}
+
+// document wraps data in the common header.
+func (s *skeleton) document(data map[string]interface{}) map[string]interface{} {
+	doc := map[string]interface{}{
+		"last_updated": s.timestamp(),
+		"ttl":          DefaultTTL,
+		"data":         data,
+	}
+	if s.ver != "1.0" {
+		doc["version"] = s.ver
+	}
+	return doc
+}
+
+// timestamp returns now as RFC3339 for v3 or POSIX seconds otherwise.
+func (s *skeleton) timestamp() interface{} {
+	if s.v3() {
+		return s.now.UTC().Format(time.RFC3339)
+	}
+	return s.now.Unix()
+}
+
+// text returns a plain string for v1/v2 or a localized string list for v3.
+func (s *skeleton) text(value string) interface{} {
+	if s.v3() {
+		return []map[string]string{{"text": value, "language": PlaceholderLanguage}}
+	}
+	return value
+}
+
+// systemInformation renders system_information.json.
+func (s *skeleton) systemInformation() map[string]interface{} {
+	data := map[string]interface{}{
+		"system_id": PlaceholderSystemID,
+		"name":      s.text("Example System"),
+		"timezone":  PlaceholderTimezone,
+	}
+	if s.v3() {
+		data["languages"] = []string{PlaceholderLanguage}
+		data["opening_hours"] = "Mo-Su 00:00-24:00"
+		data["feed_contact_email"] = PlaceholderEmail
+	} else {
+		data["language"] = PlaceholderLanguage
+	}
+	return s.document(data)
+}
+
+// vehicleTypes renders vehicle_types.json.
+func (s *skeleton) vehicleTypes() map[string]interface{} {
+	vehicleType := map[string]interface{}{
+		"vehicle_type_id": PlaceholderVehicleType,
+		"form_factor":     "bicycle",
+		"propulsion_type": "human",
+		"name":            s.text("Example Bike"),
+	}
+	if s.v3() {
+		vehicleType["wheel_count"] = 2
+		vehicleType["default_pricing_plan_id"] = PlaceholderPlanID
+	}
+	return s.document(map[string]interface{}{
+		"vehicle_types": []interface{}{vehicleType},
+	})
+}
+
+// pricingPlans renders system_pricing_plans.json.
+func (s *skeleton) pricingPlans() map[string]interface{} {
+	return s.document(map[string]interface{}{
+		"plans": []interface{}{map[string]interface{}{
+			"plan_id":     PlaceholderPlanID,
+			"name":        s.text("Example Plan"),
+			"currency":    "USD",
+			"price":       0,
+			"is_taxable":  false,
+			"description": s.text("Replace with a description of this plan."),
+		}},
+	})
+}
+
+// stationInformation renders station_information.json.
+func (s *skeleton) stationInformation() map[string]interface{} {
+	return s.document(map[string]interface{}{
+		"stations": []interface{}{map[string]interface{}{
+			"station_id": PlaceholderStationID,
+			"name":       s.text("Example Station"),
+			"lat":        0.0,
+			"lon":        0.0,
+			"capacity":   10,
+		}},
+	})
+}
+
+// stationStatus renders station_status.json.
+func (s *skeleton) stationStatus() map[string]interface{} {
+	station := map[string]interface{}{
+		"station_id":   PlaceholderStationID,
+		"is_installed": true,
+		"is_renting":   true,
+		"is_returning": true,
+	}
+	if s.v3() {
+		station["num_vehicles_available"] = 0
+		station["num_docks_available"] = 10
+		station["last_reported"] = s.now.UTC().Format(time.RFC3339)
+	} else {
+		station["num_bikes_available"] = 0
+		station["num_docks_available"] = 10
+		station["last_reported"] = s.now.Unix()
+	}
+	return s.document(map[string]interface{}{
+		"stations": []interface{}{station},
+	})
+}
+
+// vehicleStatus renders vehicle_status.json or free_bike_status.json.
+func (s *skeleton) vehicleStatus() map[string]interface{} {
+	vehicle := map[string]interface{}{
+		"lat":         0.0,
+		"lon":         0.0,
+		"is_reserved": false,
+		"is_disabled": false,
+	}
+	if s.hasFile("vehicle_types") {
+		vehicle["vehicle_type_id"] = PlaceholderVehicleType
+	}
+
+	if s.v3() {
+		vehicle["vehicle_id"] = PlaceholderVehicleID
+		return s.document(map[string]interface{}{
+			"vehicles": []interface{}{vehicle},
+		})
+	}
+	vehicle["bike_id"] = PlaceholderVehicleID
+	return s.document(map[string]interface{}{
+		"bikes": []interface{}{vehicle},
+	})
+}
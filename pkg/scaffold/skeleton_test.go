@@ -0,0 +1,63 @@
+package scaffold_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gbfs-validator-go/pkg/fetcher"
+	"github.com/gbfs-validator-go/pkg/scaffold"
+	"github.com/gbfs-validator-go/pkg/validator"
+	"github.com/gbfs-validator-go/pkg/version"
+)
+
+// TestSkeletonValidates checks every version's skeleton, served over
+// HTTPS, validates without errors or warnings.
+func TestSkeletonValidates(t *testing.T) {
+	for _, ver := range version.SupportedVersions() {
+		t.Run(ver, func(t *testing.T) {
+			var files map[string][]byte
+			server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				data, ok := files[strings.TrimPrefix(r.URL.Path, "/")]
+				if !ok {
+					http.NotFound(w, r)
+					return
+				}
+				w.Write(data)
+			}))
+			defer server.Close()
+
+			var err error
+			files, err = scaffold.Skeleton(ver, server.URL, version.Options{}, time.Now())
+			if err != nil {
+				t.Fatalf("Skeleton failed: %v", err)
+			}
+
+			if self := `"url": "` + server.URL + `/gbfs.json"`; version.IsV3OrLater(ver) && !strings.Contains(string(files["gbfs.json"]), self) {
+				t.Errorf("gbfs.json does not list itself: %s", files["gbfs.json"])
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+			defer cancel()
+
+			v := validator.New(fetcher.New(fetcher.WithHTTPClient(server.Client())), validator.Options{Docked: true, Freefloating: true})
+			result, err := v.Validate(ctx, server.URL+"/gbfs.json")
+			if err != nil {
+				t.Fatalf("Validation failed: %v", err)
+			}
+			if result.Summary.Version.Detected != ver {
+				t.Errorf("expected detected version %s, got %s", ver, result.Summary.Version.Detected)
+			}
+			for _, file := range result.Files {
+				for _, e := range file.Errors {
+					if e.Severity != validator.SeverityInfo {
+						t.Errorf("%s: %s: %s at %s", file.File, e.Severity, e.Message, e.InstancePath)
+					}
+				}
+			}
+		})
+	}
+}
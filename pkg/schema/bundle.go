@@ -0,0 +1,295 @@
+package schema
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Bundle is a set of schema documents keyed by GBFS version and file name.
+type Bundle struct {
+	// Source describes where the bundle was loaded from.
+	Source string
+
+	mu       sync.Mutex
+	files    map[string]map[string][]byte
+	compiled map[string]*compiled
+}
+
+// compiled is a schema compiled at most once, however many goroutines ask
+// for it at the same time.
+type compiled struct {
+	once   sync.Once
+	data   []byte
+	schema *Schema
+	err    error
+}
+
+// NewBundle constructs an empty bundle.
+func NewBundle(source string) *Bundle {
+	return &Bundle{
+		Source:   source,
+		files:    make(map[string]map[string][]byte),
+		compiled: make(map[string]*compiled),
+	}
+}
+
+// Add stores a raw schema document for a version and feed file name.
+func (b *Bundle) Add(version, file string, data []byte) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	file = strings.TrimSuffix(file, ".json")
+	if b.files[version] == nil {
+		b.files[version] = make(map[string][]byte)
+	}
+	b.files[version][file] = data
+	delete(b.compiled, version+"/"+file)
+}
+
+// Has reports whether the bundle contains a schema for version and file.
+func (b *Bundle) Has(version, file string) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	_, ok := b.files[version][strings.TrimSuffix(file, ".json")]
+	return ok
+}
+
+// Versions lists versions present in the bundle.
+func (b *Bundle) Versions() []string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	versions := make([]string, 0, len(b.files))
+	for v := range b.files {
+		versions = append(versions, v)
+	}
+	sort.Strings(versions)
+	return versions
+}
+
+// Files lists feed file names present for a version.
+func (b *Bundle) Files(version string) []string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	files := make([]string, 0, len(b.files[version]))
+	for f := range b.files[version] {
+		files = append(files, f)
+	}
+	sort.Strings(files)
+	return files
+}
+
+// Schema returns the compiled schema for version and file, or nil if the
+// bundle has none. Each schema is compiled on first use; other schemas can
+// be compiled concurrently meanwhile.
+func (b *Bundle) Schema(version, file string) (*Schema, error) {
+	file = strings.TrimSuffix(file, ".json")
+	key := version + "/" + file
+
+	b.mu.Lock()
+	c, ok := b.compiled[key]
+	if !ok {
+		data, found := b.files[version][file]
+		if !found {
+			b.mu.Unlock()
+			return nil, nil
+		}
+		c = &compiled{data: data}
+		b.compiled[key] = c
+	}
+	b.mu.Unlock()
+
+	c.once.Do(func() {
+		c.schema, c.err = Compile(c.data)
+		if c.err != nil {
+			c.err = fmt.Errorf("%s: %w", key, c.err)
+		}
+		c.data = nil
+	})
+	return c.schema, c.err
+}
+
+// Warm compiles the schemas of the given versions, or of every version
+// when none are given, so that the first validation does not wait for
+// them. It returns the first compile error.
+func (b *Bundle) Warm(versions ...string) error {
+	if len(versions) == 0 {
+		versions = b.Versions()
+	}
+	var wg sync.WaitGroup
+	errs := make(chan error, 1)
+	for _, version := range versions {
+		for _, file := range b.Files(version) {
+			wg.Add(1)
+			go func(version, file string) {
+				defer wg.Done()
+				if _, err := b.Schema(version, file); err != nil {
+					select {
+					case errs <- err:
+					default:
+					}
+				}
+			}(version, file)
+		}
+	}
+	wg.Wait()
+	close(errs)
+	return <-errs
+}
+
+// Load reads a bundle from a directory or a .tar, .tar.gz, or .tgz archive.
+func Load(p string) (*Bundle, error) {
+	info, err := os.Stat(p)
+	if err != nil {
+		return nil, err
+	}
+
+	if info.IsDir() {
+		return LoadFS(os.DirFS(p), p)
+	}
+
+	f, err := os.Open(p)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var r io.Reader = f
+	if strings.HasSuffix(p, ".gz") || strings.HasSuffix(p, ".tgz") {
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open gzip stream: %w", err)
+		}
+		defer gz.Close()
+		r = gz
+	}
+
+	return LoadTar(r, p)
+}
+
+// Size limits on what Load, LoadFS, and LoadTar read, so that a crafted
+// bundle cannot exhaust memory.
+const (
+	// MaxSchemaSize bounds one schema document.
+	MaxSchemaSize = 4 << 20
+	// MaxBundleSize bounds the schema documents of a bundle together.
+	MaxBundleSize = 64 << 20
+)
+
+// readSchema reads the schema document name from r, counting its size
+// against the bundle's total.
+func readSchema(r io.Reader, name string, total *int64) ([]byte, error) {
+	data, err := io.ReadAll(io.LimitReader(r, MaxSchemaSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > MaxSchemaSize {
+		return nil, fmt.Errorf("%s: schema exceeds %d bytes", name, MaxSchemaSize)
+	}
+	*total += int64(len(data))
+	if *total > MaxBundleSize {
+		return nil, fmt.Errorf("schemas exceed %d bytes in total", MaxBundleSize)
+	}
+	return data, nil
+}
+
+// LoadFS reads every <version>/<file>.json document from fsys, failing
+// past MaxSchemaSize or MaxBundleSize.
+func LoadFS(fsys fs.FS, source string) (*Bundle, error) {
+	b := NewBundle(source)
+	var total int64
+
+	err := fs.WalkDir(fsys, ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		version, file, ok := splitSchemaPath(p)
+		if !ok {
+			return nil
+		}
+		f, err := fsys.Open(p)
+		if err != nil {
+			return err
+		}
+		data, err := readSchema(f, p, &total)
+		f.Close()
+		if err != nil {
+			return err
+		}
+		b.Add(version, file, data)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(b.files) == 0 {
+		return nil, fmt.Errorf("no schemas found in %s", source)
+	}
+	return b, nil
+}
+
+// LoadTar reads every <version>/<file>.json document from a tar stream,
+// failing past MaxSchemaSize or MaxBundleSize.
+func LoadTar(r io.Reader, source string) (*Bundle, error) {
+	b := NewBundle(source)
+	tr := tar.NewReader(r)
+	var total int64
+
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read archive: %w", err)
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		version, file, ok := splitSchemaPath(hdr.Name)
+		if !ok {
+			continue
+		}
+		data, err := readSchema(tr, hdr.Name, &total)
+		if err != nil {
+			return nil, err
+		}
+		b.Add(version, file, data)
+	}
+
+	if len(b.files) == 0 {
+		return nil, fmt.Errorf("no schemas found in %s", source)
+	}
+	return b, nil
+}
+
+// splitSchemaPath extracts the version and file name from paths such as
+// "v3.0/station_status.json" or "repo-main/v3.0/station_status.json".
+func splitSchemaPath(p string) (string, string, bool) {
+	if path.Ext(p) != ".json" {
+		return "", "", false
+	}
+
+	dir, file := path.Split(path.Clean(p))
+	version := path.Base(dir)
+	version = strings.TrimPrefix(version, "v")
+	if version == "" || version[0] < '0' || version[0] > '9' {
+		return "", "", false
+	}
+
+	return version, strings.TrimSuffix(file, ".json"), true
+}
@@ -0,0 +1,89 @@
+//go:build !(js && wasm)
+
+package schema
+
+import (
+	"compress/gzip"
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// DefaultRepo is the GitHub repository publishing the GBFS JSON schemas.
+const DefaultRepo = "MobilityData/gbfs-json-schema"
+
+// RemoteOptions selects a schema repository and git ref to download.
+type RemoteOptions struct {
+	// Repo is a GitHub "owner/name" path; empty selects DefaultRepo.
+	Repo string
+	// Ref is a branch, tag, or commit such as "master" or "v3.1-RC".
+	Ref string
+	// Client performs the download; nil selects http.DefaultClient.
+	Client *http.Client
+}
+
+// ArchiveURL returns the tarball URL for the configured repo and ref.
+func (o RemoteOptions) ArchiveURL() string {
+	repo := o.Repo
+	if repo == "" {
+		repo = DefaultRepo
+	}
+	return fmt.Sprintf("https://codeload.github.com/%s/tar.gz/%s", repo, url.PathEscape(o.Ref))
+}
+
+// FetchRef downloads the schema repository at a git ref and loads every
+// versioned schema it contains.
+func FetchRef(ctx context.Context, opts RemoteOptions) (*Bundle, error) {
+	if err := checkRef(opts.Ref); err != nil {
+		return nil, err
+	}
+
+	client := opts.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	archiveURL := opts.ArchiveURL()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, archiveURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download schemas: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to download schemas from %s: unexpected status code: %d", archiveURL, resp.StatusCode)
+	}
+
+	gz, err := gzip.NewReader(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open gzip stream: %w", err)
+	}
+	defer gz.Close()
+
+	return LoadTar(gz, archiveURL)
+}
+
+// checkRef reports a ref that git would not accept as a branch, tag, or
+// commit name, so that it cannot reach another path of the download host.
+func checkRef(ref string) error {
+	if ref == "" {
+		return fmt.Errorf("schema ref is required")
+	}
+	if strings.HasPrefix(ref, "-") || strings.HasPrefix(ref, "/") || strings.HasSuffix(ref, "/") ||
+		strings.Contains(ref, "..") || strings.ContainsAny(ref, " ~^:?*[\\") {
+		return fmt.Errorf("invalid schema ref %q", ref)
+	}
+	for _, r := range ref {
+		if r < 0x20 || r == 0x7f {
+			return fmt.Errorf("invalid schema ref %q", ref)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+//go:build !(js && wasm)
+
+package schema
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+// schemaTarball returns a gzipped tarball of files, as codeload serves a
+// repository.
+func schemaTarball(t *testing.T, files map[string]string) []byte {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for name, data := range files {
+		if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0o644, Size: int64(len(data)), Typeflag: tar.TypeReg}); err != nil {
+			t.Fatal(err)
+		}
+		tw.Write([]byte(data))
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	gz.Close()
+	return buf.Bytes()
+}
+
+// redirectClient returns a client that sends every request to server,
+// keeping the path.
+func redirectClient(server *httptest.Server) *http.Client {
+	target, _ := url.Parse(server.URL)
+	return &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		r = r.Clone(r.Context())
+		r.URL.Scheme, r.URL.Host = target.Scheme, target.Host
+		return http.DefaultTransport.RoundTrip(r)
+	})}
+}
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) { return f(r) }
+
+func TestFetchRef(t *testing.T) {
+	tarball := schemaTarball(t, map[string]string{
+		"gbfs-json-schema-v3.1-RC/v3.1-RC/gbfs.json":           `{"type": "object"}`,
+		"gbfs-json-schema-v3.1-RC/v3.1-RC/station_status.json": `{"type": "object"}`,
+		"gbfs-json-schema-v3.1-RC/README.md":                   `ignored`,
+	})
+	var paths []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.EscapedPath())
+		switch r.URL.EscapedPath() {
+		case "/MobilityData/gbfs-json-schema/tar.gz/v3.1-RC", "/acme/schemas/tar.gz/feature%2Fpricing":
+			w.Write(tarball)
+		case "/MobilityData/gbfs-json-schema/tar.gz/empty":
+			w.Write(schemaTarball(t, map[string]string{"README.md": "no schemas"}))
+		case "/MobilityData/gbfs-json-schema/tar.gz/garbled":
+			w.Write([]byte("not gzip"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+	client := redirectClient(server)
+	ctx := context.Background()
+
+	b, err := FetchRef(ctx, RemoteOptions{Ref: "v3.1-RC", Client: client})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !b.Has("3.1-RC", "gbfs") || !b.Has("3.1-RC", "station_status") || len(b.Versions()) != 1 {
+		t.Errorf("versions = %v", b.Versions())
+	}
+	if _, err := FetchRef(ctx, RemoteOptions{Repo: "acme/schemas", Ref: "feature/pricing", Client: client}); err != nil {
+		t.Errorf("branch with a slash: %v", err)
+	}
+
+	for _, tc := range []struct {
+		ref, want string
+	}{
+		{"", "ref is required"},
+		{"../../evil", "invalid schema ref"},
+		{"-x", "invalid schema ref"},
+		{"main~1", "invalid schema ref"},
+		{"v3 RC", "invalid schema ref"},
+		{"missing", "unexpected status code: 404"},
+		{"garbled", "gzip"},
+		{"empty", "no schemas found"},
+	} {
+		requests := len(paths)
+		_, err := FetchRef(ctx, RemoteOptions{Ref: tc.ref, Client: client})
+		if err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("ref %q: error = %v, want %q", tc.ref, err, tc.want)
+		}
+		if strings.Contains(tc.want, "ref") && len(paths) != requests {
+			t.Errorf("ref %q was requested", tc.ref)
+		}
+	}
+}
@@ -0,0 +1,573 @@
+// Package schema validates JSON documents against GBFS JSON Schemas.
+//
+// It implements the subset of JSON Schema draft-07 used by the published
+// GBFS schemas: type, properties, required, enum, const, pattern, format,
+// numeric and length bounds, items, contains, additional and pattern
+// properties, dependencies, combinators, if/then/else, and local $ref.
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/mail"
+	"net/url"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Error describes a single schema violation.
+type Error struct {
+	InstancePath string `json:"instancePath"`
+	SchemaPath   string `json:"schemaPath"`
+	Keyword      string `json:"keyword"`
+	Message      string `json:"message"`
+}
+
+// Schema is a parsed JSON Schema document.
+type Schema struct {
+	root map[string]interface{}
+
+	mu       sync.Mutex
+	patterns map[string]*regexp.Regexp
+}
+
+// Compile parses a JSON Schema document.
+func Compile(data []byte) (*Schema, error) {
+	var root map[string]interface{}
+	if err := json.Unmarshal(data, &root); err != nil {
+		return nil, fmt.Errorf("failed to parse schema: %w", err)
+	}
+
+	s := &Schema{
+		root:     root,
+		patterns: make(map[string]*regexp.Regexp),
+	}
+
+	if err := s.precompile(root); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// ID returns the schema's $id, if any.
+func (s *Schema) ID() string {
+	id, _ := s.root["$id"].(string)
+	return id
+}
+
+// Validate checks a decoded JSON document against the schema.
+func (s *Schema) Validate(doc interface{}) []Error {
+	var errs []Error
+	s.validate(s.root, doc, "", "#", &errs)
+	return errs
+}
+
+// ValidateJSON decodes data and validates it against the schema.
+func (s *Schema) ValidateJSON(data []byte) ([]Error, error) {
+	var doc interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	return s.Validate(doc), nil
+}
+
+// precompile walks the schema and compiles every pattern up front.
+func (s *Schema) precompile(node interface{}) error {
+	switch n := node.(type) {
+	case map[string]interface{}:
+		if p, ok := n["pattern"].(string); ok {
+			if _, err := s.regexp(p); err != nil {
+				return fmt.Errorf("invalid pattern %q: %w", p, err)
+			}
+		}
+		if pp, ok := n["patternProperties"].(map[string]interface{}); ok {
+			for p := range pp {
+				if _, err := s.regexp(p); err != nil {
+					return fmt.Errorf("invalid pattern %q: %w", p, err)
+				}
+			}
+		}
+		for _, v := range n {
+			if err := s.precompile(v); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		for _, v := range n {
+			if err := s.precompile(v); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// regexp returns a cached compiled pattern.
+func (s *Schema) regexp(pattern string) (*regexp.Regexp, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if re, ok := s.patterns[pattern]; ok {
+		return re, nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	s.patterns[pattern] = re
+	return re, nil
+}
+
+// resolveRef resolves a local JSON pointer reference such as #/definitions/x.
+func (s *Schema) resolveRef(ref string) (map[string]interface{}, bool) {
+	if !strings.HasPrefix(ref, "#") {
+		return nil, false
+	}
+
+	var node interface{} = s.root
+	for _, part := range strings.Split(strings.TrimPrefix(ref, "#"), "/") {
+		if part == "" {
+			continue
+		}
+		part = strings.ReplaceAll(strings.ReplaceAll(part, "~1", "/"), "~0", "~")
+		obj, ok := node.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		node = obj[part]
+	}
+
+	obj, ok := node.(map[string]interface{})
+	return obj, ok
+}
+
+// validate applies every keyword in node to value.
+func (s *Schema) validate(node map[string]interface{}, value interface{}, instPath, schemaPath string, errs *[]Error) {
+	add := func(keyword, message string) {
+		*errs = append(*errs, Error{
+			InstancePath: instPath,
+			SchemaPath:   schemaPath + "/" + keyword,
+			Keyword:      keyword,
+			Message:      message,
+		})
+	}
+
+	if ref, ok := node["$ref"].(string); ok {
+		if target, ok := s.resolveRef(ref); ok {
+			s.validate(target, value, instPath, ref, errs)
+		}
+		return
+	}
+
+	if t, ok := node["type"]; ok {
+		if !matchesType(t, value) {
+			add("type", "must be "+typeNames(t))
+			return
+		}
+	}
+
+	if enum, ok := node["enum"].([]interface{}); ok {
+		found := false
+		for _, e := range enum {
+			if jsonEqual(e, value) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			add("enum", "must be equal to one of the allowed values")
+		}
+	}
+
+	if c, ok := node["const"]; ok && !jsonEqual(c, value) {
+		add("const", fmt.Sprintf("must be equal to constant %s", compact(c)))
+	}
+
+	switch v := value.(type) {
+	case string:
+		s.validateString(node, v, add)
+	case float64:
+		validateNumber(node, v, add)
+	case []interface{}:
+		s.validateArray(node, v, instPath, schemaPath, errs, add)
+	case map[string]interface{}:
+		s.validateObject(node, v, instPath, schemaPath, errs, add)
+	}
+
+	s.validateCombinators(node, value, instPath, schemaPath, errs, add)
+}
+
+// validateString applies string keywords.
+func (s *Schema) validateString(node map[string]interface{}, v string, add func(string, string)) {
+	length := len([]rune(v))
+	if n, ok := number(node["minLength"]); ok && float64(length) < n {
+		add("minLength", fmt.Sprintf("must NOT have fewer than %v characters", n))
+	}
+	if n, ok := number(node["maxLength"]); ok && float64(length) > n {
+		add("maxLength", fmt.Sprintf("must NOT have more than %v characters", n))
+	}
+	if p, ok := node["pattern"].(string); ok {
+		if re, err := s.regexp(p); err == nil && !re.MatchString(v) {
+			add("pattern", fmt.Sprintf("must match pattern %q", p))
+		}
+	}
+	if f, ok := node["format"].(string); ok && !matchesFormat(f, v) {
+		add("format", fmt.Sprintf("must match format %q", f))
+	}
+}
+
+// validateNumber applies numeric keywords.
+func validateNumber(node map[string]interface{}, v float64, add func(string, string)) {
+	if n, ok := number(node["minimum"]); ok && v < n {
+		add("minimum", fmt.Sprintf("must be >= %v", n))
+	}
+	if n, ok := number(node["maximum"]); ok && v > n {
+		add("maximum", fmt.Sprintf("must be <= %v", n))
+	}
+	if n, ok := number(node["exclusiveMinimum"]); ok && v <= n {
+		add("exclusiveMinimum", fmt.Sprintf("must be > %v", n))
+	}
+	if n, ok := number(node["exclusiveMaximum"]); ok && v >= n {
+		add("exclusiveMaximum", fmt.Sprintf("must be < %v", n))
+	}
+	if n, ok := number(node["multipleOf"]); ok && n > 0 {
+		q := v / n
+		if math.Abs(q-math.Round(q)) > 1e-9 {
+			add("multipleOf", fmt.Sprintf("must be multiple of %v", n))
+		}
+	}
+}
+
+// validateArray applies array keywords.
+func (s *Schema) validateArray(node map[string]interface{}, v []interface{}, instPath, schemaPath string, errs *[]Error, add func(string, string)) {
+	if n, ok := number(node["minItems"]); ok && float64(len(v)) < n {
+		add("minItems", fmt.Sprintf("must NOT have fewer than %v items", n))
+	}
+	if n, ok := number(node["maxItems"]); ok && float64(len(v)) > n {
+		add("maxItems", fmt.Sprintf("must NOT have more than %v items", n))
+	}
+	if unique, _ := node["uniqueItems"].(bool); unique {
+		for i := range v {
+			for j := i + 1; j < len(v); j++ {
+				if jsonEqual(v[i], v[j]) {
+					add("uniqueItems", fmt.Sprintf("must NOT have duplicate items (items ## %d and %d are identical)", j, i))
+				}
+			}
+		}
+	}
+
+	switch items := node["items"].(type) {
+	case map[string]interface{}:
+		for i, item := range v {
+			s.validate(items, item, instPath+"/"+strconv.Itoa(i), schemaPath+"/items", errs)
+		}
+	case []interface{}:
+		for i, item := range v {
+			if i >= len(items) {
+				break
+			}
+			if sub, ok := items[i].(map[string]interface{}); ok {
+				s.validate(sub, item, instPath+"/"+strconv.Itoa(i), fmt.Sprintf("%s/items/%d", schemaPath, i), errs)
+			}
+		}
+	}
+
+	if contains, ok := node["contains"].(map[string]interface{}); ok {
+		found := false
+		for i, item := range v {
+			var sub []Error
+			s.validate(contains, item, instPath+"/"+strconv.Itoa(i), schemaPath+"/contains", &sub)
+			if len(sub) == 0 {
+				found = true
+				break
+			}
+		}
+		if !found {
+			add("contains", "must contain at least 1 valid item(s)")
+		}
+	}
+}
+
+// validateObject applies object keywords.
+func (s *Schema) validateObject(node map[string]interface{}, v map[string]interface{}, instPath, schemaPath string, errs *[]Error, add func(string, string)) {
+	if required, ok := node["required"].([]interface{}); ok {
+		for _, r := range required {
+			name, _ := r.(string)
+			if _, present := v[name]; !present {
+				add("required", fmt.Sprintf("must have required property '%s'", name))
+			}
+		}
+	}
+
+	if n, ok := number(node["minProperties"]); ok && float64(len(v)) < n {
+		add("minProperties", fmt.Sprintf("must NOT have fewer than %v properties", n))
+	}
+	if n, ok := number(node["maxProperties"]); ok && float64(len(v)) > n {
+		add("maxProperties", fmt.Sprintf("must NOT have more than %v properties", n))
+	}
+
+	props, _ := node["properties"].(map[string]interface{})
+	patternProps, _ := node["patternProperties"].(map[string]interface{})
+
+	for _, key := range sortedKeys(v) {
+		val := v[key]
+		childPath := instPath + "/" + escapePointer(key)
+		matched := false
+
+		if sub, ok := props[key].(map[string]interface{}); ok {
+			matched = true
+			s.validate(sub, val, childPath, schemaPath+"/properties/"+escapePointer(key), errs)
+		}
+
+		for pattern, raw := range patternProps {
+			re, err := s.regexp(pattern)
+			if err != nil || !re.MatchString(key) {
+				continue
+			}
+			matched = true
+			if sub, ok := raw.(map[string]interface{}); ok {
+				s.validate(sub, val, childPath, schemaPath+"/patternProperties/"+escapePointer(pattern), errs)
+			}
+		}
+
+		if matched {
+			continue
+		}
+
+		switch ap := node["additionalProperties"].(type) {
+		case bool:
+			if !ap {
+				*errs = append(*errs, Error{
+					InstancePath: instPath,
+					SchemaPath:   schemaPath + "/additionalProperties",
+					Keyword:      "additionalProperties",
+					Message:      fmt.Sprintf("must NOT have additional property '%s'", key),
+				})
+			}
+		case map[string]interface{}:
+			s.validate(ap, val, childPath, schemaPath+"/additionalProperties", errs)
+		}
+	}
+
+	if deps, ok := node["dependencies"].(map[string]interface{}); ok {
+		for _, key := range sortedKeys(deps) {
+			if _, present := v[key]; !present {
+				continue
+			}
+			switch dep := deps[key].(type) {
+			case []interface{}:
+				for _, d := range dep {
+					name, _ := d.(string)
+					if _, ok := v[name]; !ok {
+						add("dependencies", fmt.Sprintf("must have property %s when property %s is present", name, key))
+					}
+				}
+			case map[string]interface{}:
+				s.validate(dep, v, instPath, schemaPath+"/dependencies/"+escapePointer(key), errs)
+			}
+		}
+	}
+}
+
+// validateCombinators applies allOf, anyOf, oneOf, not, and if/then/else.
+func (s *Schema) validateCombinators(node map[string]interface{}, value interface{}, instPath, schemaPath string, errs *[]Error, add func(string, string)) {
+	if all, ok := node["allOf"].([]interface{}); ok {
+		for i, raw := range all {
+			if sub, ok := raw.(map[string]interface{}); ok {
+				s.validate(sub, value, instPath, fmt.Sprintf("%s/allOf/%d", schemaPath, i), errs)
+			}
+		}
+	}
+
+	if anyOf, ok := node["anyOf"].([]interface{}); ok {
+		var branchErrs []Error
+		passed := false
+		for i, raw := range anyOf {
+			sub, ok := raw.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			var e []Error
+			s.validate(sub, value, instPath, fmt.Sprintf("%s/anyOf/%d", schemaPath, i), &e)
+			if len(e) == 0 {
+				passed = true
+				break
+			}
+			if msg, ok := sub["errorMessage"].(string); ok {
+				e = []Error{{InstancePath: instPath, SchemaPath: fmt.Sprintf("%s/anyOf/%d", schemaPath, i), Keyword: "errorMessage", Message: msg}}
+			}
+			branchErrs = append(branchErrs, e...)
+		}
+		if !passed {
+			*errs = append(*errs, branchErrs...)
+			add("anyOf", "must match a schema in anyOf")
+		}
+	}
+
+	if oneOf, ok := node["oneOf"].([]interface{}); ok {
+		matches := 0
+		for i, raw := range oneOf {
+			sub, ok := raw.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			var e []Error
+			s.validate(sub, value, instPath, fmt.Sprintf("%s/oneOf/%d", schemaPath, i), &e)
+			if len(e) == 0 {
+				matches++
+			}
+		}
+		if matches != 1 {
+			add("oneOf", "must match exactly one schema in oneOf")
+		}
+	}
+
+	if not, ok := node["not"].(map[string]interface{}); ok {
+		var e []Error
+		s.validate(not, value, instPath, schemaPath+"/not", &e)
+		if len(e) == 0 {
+			add("not", "must NOT be valid")
+		}
+	}
+
+	if cond, ok := node["if"].(map[string]interface{}); ok {
+		var e []Error
+		s.validate(cond, value, instPath, schemaPath+"/if", &e)
+		if len(e) == 0 {
+			if then, ok := node["then"].(map[string]interface{}); ok {
+				before := len(*errs)
+				s.validate(then, value, instPath, schemaPath+"/then", errs)
+				if len(*errs) > before {
+					add("if", `must match "then" schema`)
+				}
+			}
+		} else if els, ok := node["else"].(map[string]interface{}); ok {
+			before := len(*errs)
+			s.validate(els, value, instPath, schemaPath+"/else", errs)
+			if len(*errs) > before {
+				add("if", `must match "else" schema`)
+			}
+		}
+	}
+}
+
+// matchesType reports whether value satisfies a type keyword.
+func matchesType(t interface{}, value interface{}) bool {
+	switch tt := t.(type) {
+	case string:
+		return matchesSingleType(tt, value)
+	case []interface{}:
+		for _, x := range tt {
+			if name, ok := x.(string); ok && matchesSingleType(name, value) {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// matchesSingleType reports whether value is of the named JSON type.
+func matchesSingleType(name string, value interface{}) bool {
+	switch name {
+	case "string":
+		_, ok := value.(string)
+		return ok
+	case "number":
+		_, ok := value.(float64)
+		return ok
+	case "integer":
+		f, ok := value.(float64)
+		return ok && f == math.Trunc(f)
+	case "boolean":
+		_, ok := value.(bool)
+		return ok
+	case "array":
+		_, ok := value.([]interface{})
+		return ok
+	case "object":
+		_, ok := value.(map[string]interface{})
+		return ok
+	case "null":
+		return value == nil
+	}
+	return true
+}
+
+// typeNames renders a type keyword for messages.
+func typeNames(t interface{}) string {
+	switch tt := t.(type) {
+	case string:
+		return tt
+	case []interface{}:
+		var names []string
+		for _, x := range tt {
+			if s, ok := x.(string); ok {
+				names = append(names, s)
+			}
+		}
+		return strings.Join(names, ",")
+	}
+	return fmt.Sprint(t)
+}
+
+// matchesFormat checks the string formats used by GBFS schemas.
+func matchesFormat(format, v string) bool {
+	switch format {
+	case "date-time":
+		_, err := time.Parse(time.RFC3339, v)
+		return err == nil
+	case "date":
+		_, err := time.Parse("2006-01-02", v)
+		return err == nil
+	case "uri":
+		u, err := url.Parse(v)
+		return err == nil && u.Scheme != ""
+	case "email":
+		_, err := mail.ParseAddress(v)
+		return err == nil
+	}
+	return true
+}
+
+// number reads a numeric schema value.
+func number(v interface{}) (float64, bool) {
+	f, ok := v.(float64)
+	return f, ok
+}
+
+// jsonEqual compares two decoded JSON values.
+func jsonEqual(a, b interface{}) bool {
+	return reflect.DeepEqual(a, b)
+}
+
+// compact renders a value as compact JSON for messages.
+func compact(v interface{}) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(b)
+}
+
+// escapePointer escapes a JSON pointer token.
+func escapePointer(s string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(s, "~", "~0"), "/", "~1")
+}
+
+// sortedKeys returns map keys in sorted order for stable output.
+func sortedKeys[T any](m map[string]T) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
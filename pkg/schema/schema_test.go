@@ -0,0 +1,127 @@
+package schema
+
+import (
+	"archive/tar"
+	"bytes"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+// TestValidateKeywords checks the keywords used by GBFS schemas.
+func TestValidateKeywords(t *testing.T) {
+	s, err := Compile([]byte(`{
+		"type": "object",
+		"required": ["id", "lat"],
+		"properties": {
+			"id": {"type": "string", "pattern": "^[a-z]+$"},
+			"lat": {"type": "number", "minimum": -90, "maximum": 90},
+			"kind": {"enum": ["dock", "virtual"]},
+			"url": {"type": "string", "format": "uri"}
+		},
+		"additionalProperties": false
+	}`))
+	if err != nil {
+		t.Fatalf("Compile failed: %v", err)
+	}
+
+	errs, err := s.ValidateJSON([]byte(`{"id": "A1", "lat": 120, "kind": "pole", "url": "nope", "extra": true}`))
+	if err != nil {
+		t.Fatalf("ValidateJSON failed: %v", err)
+	}
+
+	want := map[string]string{
+		"pattern":              "/id",
+		"maximum":              "/lat",
+		"enum":                 "/kind",
+		"format":               "/url",
+		"additionalProperties": "",
+	}
+	for _, e := range errs {
+		path, ok := want[e.Keyword]
+		if !ok {
+			t.Errorf("unexpected error %+v", e)
+			continue
+		}
+		if path != e.InstancePath {
+			t.Errorf("%s: expected path %q, got %q", e.Keyword, path, e.InstancePath)
+		}
+		delete(want, e.Keyword)
+	}
+	for keyword := range want {
+		t.Errorf("missing %s error", keyword)
+	}
+}
+
+// TestLoadFS checks versions and files are read from a directory layout.
+func TestLoadFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"v3.0/gbfs.json":               {Data: []byte(`{"type": "object"}`)},
+		"v3.0/station_status.json":     {Data: []byte(`{"type": "object"}`)},
+		"spec-main/v3.1-RC2/gbfs.json": {Data: []byte(`{"type": "object"}`)},
+		"README.md":                    {Data: []byte(`ignored`)},
+	}
+
+	b, err := LoadFS(fsys, "test")
+	if err != nil {
+		t.Fatalf("LoadFS failed: %v", err)
+	}
+
+	if !b.Has("3.0", "station_status") || !b.Has("3.1-RC2", "gbfs.json") {
+		t.Errorf("expected schemas for 3.0 and 3.1-RC2, got versions %v", b.Versions())
+	}
+
+	s, err := b.Schema("3.0", "gbfs")
+	if err != nil || s == nil {
+		t.Fatalf("expected compiled schema, got %v, %v", s, err)
+	}
+}
+
+// TestLoadLimits checks that oversized schemas and bundles are refused.
+func TestLoadLimits(t *testing.T) {
+	big := []byte(`{"description": "` + strings.Repeat("x", MaxSchemaSize) + `"}`)
+	if _, err := LoadFS(fstest.MapFS{"v3.0/gbfs.json": {Data: big}}, "test"); err == nil {
+		t.Error("LoadFS: expected an error for an oversized schema")
+	}
+
+	tarOf := func(files, size int) []byte {
+		var buf bytes.Buffer
+		tw := tar.NewWriter(&buf)
+		data := bytes.Repeat([]byte(" "), size)
+		for i := 0; i < files; i++ {
+			tw.WriteHeader(&tar.Header{Name: "v3.0/f" + strings.Repeat("x", i) + ".json", Mode: 0o644, Size: int64(size), Typeflag: tar.TypeReg})
+			tw.Write(data)
+		}
+		tw.Close()
+		return buf.Bytes()
+	}
+	if _, err := LoadTar(bytes.NewReader(tarOf(1, MaxSchemaSize+1)), "test"); err == nil {
+		t.Error("LoadTar: expected an error for an oversized schema")
+	}
+	if _, err := LoadTar(bytes.NewReader(tarOf(MaxBundleSize/MaxSchemaSize+1, MaxSchemaSize)), "test"); err == nil {
+		t.Error("LoadTar: expected an error for an oversized bundle")
+	}
+	if _, err := LoadTar(bytes.NewReader(tarOf(2, 16)), "test"); err != nil {
+		t.Errorf("LoadTar: %v", err)
+	}
+}
+
+// TestWarm checks that schemas compile once and report compile errors.
+func TestWarm(t *testing.T) {
+	b := NewBundle("test")
+	b.Add("3.0", "gbfs", []byte(`{"type": "object"}`))
+	b.Add("3.0", "station_status", []byte(`{"type": "object"}`))
+	b.Add("2.3", "gbfs", []byte(`{"type": "object", "pattern": "("}`))
+
+	if err := b.Warm("3.0"); err != nil {
+		t.Fatalf("Warm(3.0) failed: %v", err)
+	}
+	first, _ := b.Schema("3.0", "gbfs")
+	again, _ := b.Schema("3.0", "gbfs.json")
+	if first == nil || first != again {
+		t.Errorf("schema compiled twice or not at all: %p, %p", first, again)
+	}
+	if err := b.Warm(); err == nil {
+		t.Error("expected a compile error for the 2.3 schema")
+	}
+}
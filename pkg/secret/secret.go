@@ -0,0 +1,185 @@
+// Package secret encrypts credentials stored at rest, such as the auth
+// settings of monitored feeds.
+package secret
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/gbfs-validator-go/pkg/fetcher"
+)
+
+// prefix marks an encrypted value.
+const prefix = "enc:v1:"
+
+// Environment variables that supply the key, in order of precedence. Each
+// holds or produces a base64-encoded 32-byte key. The command form lets a
+// KMS decrypt the key at startup, e.g. "aws kms decrypt ... --query
+// Plaintext --output text".
+const (
+	KeyEnv        = "GBFS_SECRET_KEY"
+	KeyFileEnv    = "GBFS_SECRET_KEY_FILE"
+	KeyCommandEnv = "GBFS_SECRET_KEY_COMMAND"
+)
+
+// ErrNoKey is returned when encrypted values are found but no key is
+// configured.
+var ErrNoKey = errors.New("no secret key configured; set " + KeyEnv + ", " + KeyFileEnv + ", or " + KeyCommandEnv)
+
+// Box encrypts and decrypts values with AES-256-GCM.
+type Box struct {
+	aead cipher.AEAD
+}
+
+// NewBox returns a box for a 32-byte key.
+func NewBox(key []byte) (*Box, error) {
+	if len(key) != 32 {
+		return nil, fmt.Errorf("secret key must be 32 bytes, got %d", len(key))
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &Box{aead: aead}, nil
+}
+
+// ParseKey decodes a base64-encoded key.
+func ParseKey(s string) (*Box, error) {
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(s))
+	if err != nil {
+		return nil, fmt.Errorf("secret key is not base64: %w", err)
+	}
+	return NewBox(key)
+}
+
+// GenerateKey returns a new random base64-encoded key.
+func GenerateKey() (string, error) {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(key), nil
+}
+
+// FromEnv returns a box for the key configured in the environment, or nil
+// when none is.
+func FromEnv() (*Box, error) {
+	if v := os.Getenv(KeyEnv); v != "" {
+		return ParseKey(v)
+	}
+	if path := os.Getenv(KeyFileEnv); path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		return ParseKey(string(data))
+	}
+	if command := os.Getenv(KeyCommandEnv); command != "" {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		out, err := exec.CommandContext(ctx, "sh", "-c", command).Output()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", KeyCommandEnv, err)
+		}
+		return ParseKey(string(out))
+	}
+	return nil, nil
+}
+
+// IsSealed reports whether v is an encrypted value.
+func IsSealed(v string) bool {
+	return strings.HasPrefix(v, prefix)
+}
+
+// Seal encrypts a value. Empty and already sealed values are returned as
+// is.
+func (b *Box) Seal(v string) (string, error) {
+	if v == "" || IsSealed(v) {
+		return v, nil
+	}
+	nonce := make([]byte, b.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := b.aead.Seal(nonce, nonce, []byte(v), nil)
+	return prefix + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Open decrypts a sealed value. Plain values are returned as is, so
+// configuration files may mix both.
+func (b *Box) Open(v string) (string, error) {
+	if !IsSealed(v) {
+		return v, nil
+	}
+	if b == nil {
+		return "", ErrNoKey
+	}
+	data, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(v, prefix))
+	if err != nil || len(data) < b.aead.NonceSize() {
+		return "", errors.New("malformed encrypted value")
+	}
+	nonce, sealed := data[:b.aead.NonceSize()], data[b.aead.NonceSize():]
+	plain, err := b.aead.Open(nil, nonce, sealed, nil)
+	if err != nil {
+		return "", errors.New("encrypted value does not match the secret key")
+	}
+	return string(plain), nil
+}
+
+// SealAuth returns a copy of a with its secrets encrypted.
+func (b *Box) SealAuth(a *fetcher.AuthConfig) (*fetcher.AuthConfig, error) {
+	return transformAuth(a, b.Seal)
+}
+
+// OpenAuth returns a copy of a with its secrets decrypted. A nil box opens
+// configurations that hold no encrypted values.
+func (b *Box) OpenAuth(a *fetcher.AuthConfig) (*fetcher.AuthConfig, error) {
+	return transformAuth(a, b.Open)
+}
+
+// transformAuth applies fn to each secret of a copy of a.
+func transformAuth(a *fetcher.AuthConfig, fn func(string) (string, error)) (*fetcher.AuthConfig, error) {
+	if a == nil {
+		return nil, nil
+	}
+	out := *a
+	var err error
+	apply := func(v *string) {
+		if err == nil {
+			*v, err = fn(*v)
+		}
+	}
+	if a.BasicAuth != nil {
+		basic := *a.BasicAuth
+		apply(&basic.Password)
+		out.BasicAuth = &basic
+	}
+	if a.BearerToken != nil {
+		bearer := *a.BearerToken
+		apply(&bearer.Token)
+		out.BearerToken = &bearer
+	}
+	if a.OAuthClientCredentials != nil {
+		oauth := *a.OAuthClientCredentials
+		apply(&oauth.Password)
+		out.OAuthClientCredentials = &oauth
+	}
+	out.Headers = append([]fetcher.HeaderConfig(nil), a.Headers...)
+	for i := range out.Headers {
+		apply(&out.Headers[i].Value)
+	}
+	return &out, err
+}
@@ -0,0 +1,58 @@
+package secret
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/gbfs-validator-go/pkg/fetcher"
+)
+
+func TestSealAuth(t *testing.T) {
+	key, err := GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	box, err := ParseKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	auth := &fetcher.AuthConfig{
+		Type:      fetcher.AuthBasic,
+		BasicAuth: &fetcher.BasicAuthConfig{User: "operator", Password: "hunter2"},
+		Headers:   []fetcher.HeaderConfig{{Key: "X-Api-Key", Value: "k3y"}},
+	}
+	sealed, err := box.SealAuth(auth)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sealed.BasicAuth.User != "operator" || !IsSealed(sealed.BasicAuth.Password) || !IsSealed(sealed.Headers[0].Value) {
+		t.Fatalf("sealed = %+v", sealed)
+	}
+	if auth.BasicAuth.Password != "hunter2" {
+		t.Error("SealAuth modified its argument")
+	}
+
+	opened, err := box.OpenAuth(sealed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if opened.BasicAuth.Password != "hunter2" || opened.Headers[0].Value != "k3y" {
+		t.Errorf("opened = %+v", opened)
+	}
+
+	var none *Box
+	if _, err := none.OpenAuth(sealed); !errors.Is(err, ErrNoKey) {
+		t.Errorf("opening without a key: %v", err)
+	}
+	if plain, err := none.Open("plain"); err != nil || plain != "plain" {
+		t.Errorf("plain value = %q, %v", plain, err)
+	}
+
+	other, _ := GenerateKey()
+	otherBox, _ := ParseKey(other)
+	if _, err := otherBox.Open(sealed.BasicAuth.Password); err == nil || strings.Contains(err.Error(), "hunter2") {
+		t.Errorf("opening with the wrong key: %v", err)
+	}
+}
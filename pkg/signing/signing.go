@@ -0,0 +1,134 @@
+// Package signing signs serialized validation results with Ed25519, so that
+// anyone holding a validator's public key can check that a report was
+// produced by that validator and has not been altered since.
+package signing
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Environment variables that supply the signing key, in order of
+// precedence. Each holds or names a file holding a base64-encoded 32-byte
+// Ed25519 seed, as printed by GenerateKey.
+const (
+	KeyEnv     = "GBFS_SIGNING_KEY"
+	KeyFileEnv = "GBFS_SIGNING_KEY_FILE"
+)
+
+// Response headers carrying a detached signature of the response body.
+const (
+	SignatureHeader = "X-GBFS-Signature"
+	KeyIDHeader     = "X-GBFS-Signature-Key"
+)
+
+// Algorithm names the signature scheme in published key descriptions.
+const Algorithm = "Ed25519"
+
+// ErrInvalidSignature is returned when a signature does not match.
+var ErrInvalidSignature = errors.New("signature does not match the report")
+
+// Signer signs reports with an Ed25519 private key.
+type Signer struct {
+	key ed25519.PrivateKey
+}
+
+// PublicKey describes the key that verifies a Signer's signatures, as
+// served to the people who check them.
+type PublicKey struct {
+	Algorithm string `json:"algorithm"`
+	KeyID     string `json:"keyId"`
+	// Key is the base64-encoded 32-byte public key.
+	Key string `json:"publicKey"`
+}
+
+// NewSigner returns a signer for key.
+func NewSigner(key ed25519.PrivateKey) *Signer {
+	return &Signer{key: key}
+}
+
+// ParseKey decodes a base64-encoded 32-byte seed.
+func ParseKey(s string) (*Signer, error) {
+	seed, err := base64.StdEncoding.DecodeString(strings.TrimSpace(s))
+	if err != nil {
+		return nil, fmt.Errorf("signing key is not base64: %w", err)
+	}
+	if len(seed) != ed25519.SeedSize {
+		return nil, fmt.Errorf("signing key must be %d bytes, got %d", ed25519.SeedSize, len(seed))
+	}
+	return NewSigner(ed25519.NewKeyFromSeed(seed)), nil
+}
+
+// GenerateKey returns a new random base64-encoded seed.
+func GenerateKey() (string, error) {
+	seed := make([]byte, ed25519.SeedSize)
+	if _, err := rand.Read(seed); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(seed), nil
+}
+
+// FromEnv returns a signer for the key configured in the environment, or
+// nil when none is.
+func FromEnv() (*Signer, error) {
+	if v := os.Getenv(KeyEnv); v != "" {
+		return ParseKey(v)
+	}
+	if path := os.Getenv(KeyFileEnv); path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		return ParseKey(string(data))
+	}
+	return nil, nil
+}
+
+// Sign returns the base64-encoded signature of data.
+func (s *Signer) Sign(data []byte) string {
+	return base64.StdEncoding.EncodeToString(ed25519.Sign(s.key, data))
+}
+
+// Public describes the signer's public key.
+func (s *Signer) Public() PublicKey {
+	pub := s.key.Public().(ed25519.PublicKey)
+	return PublicKey{Algorithm: Algorithm, KeyID: KeyID(pub), Key: base64.StdEncoding.EncodeToString(pub)}
+}
+
+// KeyID returns a short fingerprint of pub, the first 8 bytes of its
+// SHA-256 in hex, so that reports name the key that signed them.
+func KeyID(pub ed25519.PublicKey) string {
+	sum := sha256.Sum256(pub)
+	return hex.EncodeToString(sum[:8])
+}
+
+// ParsePublicKey decodes a base64-encoded public key.
+func ParsePublicKey(s string) (ed25519.PublicKey, error) {
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(s))
+	if err != nil {
+		return nil, fmt.Errorf("public key is not base64: %w", err)
+	}
+	if len(key) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("public key must be %d bytes, got %d", ed25519.PublicKeySize, len(key))
+	}
+	return ed25519.PublicKey(key), nil
+}
+
+// Verify checks a base64-encoded signature of data against pub.
+func Verify(pub ed25519.PublicKey, data []byte, signature string) error {
+	sig, err := base64.StdEncoding.DecodeString(strings.TrimSpace(signature))
+	if err != nil {
+		return fmt.Errorf("signature is not base64: %w", err)
+	}
+	if !ed25519.Verify(pub, data, sig) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
@@ -0,0 +1,39 @@
+package signing
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestSignAndVerify(t *testing.T) {
+	seed, err := GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := ParseKey(seed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	report := []byte(`{"summary":{"hasErrors":false}}`)
+	signature := s.Sign(report)
+
+	public := s.Public()
+	pub, err := ParsePublicKey(public.Key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if public.Algorithm != Algorithm || public.KeyID != KeyID(pub) || len(public.KeyID) != 16 {
+		t.Errorf("unexpected public key %+v", public)
+	}
+	if err := Verify(pub, report, signature); err != nil {
+		t.Errorf("valid signature rejected: %v", err)
+	}
+	tampered := []byte(`{"summary":{"hasErrors":true}}`)
+	if err := Verify(pub, tampered, signature); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("tampered report: %v", err)
+	}
+
+	if _, err := ParseKey("c2hvcnQ="); err == nil {
+		t.Error("expected an error for a short key")
+	}
+}
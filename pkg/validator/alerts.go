@@ -0,0 +1,80 @@
+package validator
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/gbfs-validator-go/pkg/gbfs"
+)
+
+// checkAlerts warns about system_alerts entries riders cannot act on:
+// alerts without times, which leave open when they apply, station
+// closures that name no station or region, and summaries repeated across
+// every alert, which say nothing about any one of them.
+func (v *Validator) checkAlerts(results map[string]*FileValidationResult) {
+	result, ok := results["system_alerts"]
+	if !ok || !result.Exists || result.RawData == nil {
+		return
+	}
+	data := result.RawData
+	if result.CoercedData != nil {
+		data = result.CoercedData
+	}
+	var alerts gbfs.SystemAlerts
+	if err := json.Unmarshal(data, &alerts); err != nil {
+		return
+	}
+
+	report := func(path string, count int, message string) {
+		if count > 1 {
+			message = fmt.Sprintf("%s (%d alerts)", message, count)
+		}
+		result.Errors = append(result.Errors, ValidationError{
+			Severity:     SeverityWarning,
+			Category:     CategorySemantic,
+			InstancePath: path,
+			Message:      message,
+			Keyword:      "alertQuality",
+		})
+		result.ErrorsCount = len(result.Errors)
+	}
+
+	type group struct{ first, count int }
+	untimed, unplaced := group{first: -1}, group{first: -1}
+	add := func(g *group, i int) {
+		if g.first < 0 {
+			g.first = i
+		}
+		g.count++
+	}
+	list := alerts.Data.Alerts
+	for i, alert := range list {
+		if len(alert.Times) == 0 {
+			add(&untimed, i)
+		}
+		if strings.EqualFold(alert.Type, "station_closure") && len(alert.StationIDs) == 0 && len(alert.RegionIDs) == 0 {
+			add(&unplaced, i)
+		}
+	}
+
+	if untimed.count > 0 {
+		report(fmt.Sprintf("/data/alerts/%d", untimed.first), untimed.count,
+			"alert has no times; publish when it starts, and ends if known, so apps can show it only while it applies")
+	}
+	if unplaced.count > 0 {
+		report(fmt.Sprintf("/data/alerts/%d/type", unplaced.first), unplaced.count,
+			"station closure lists no station_ids or region_ids; riders cannot tell which stations are closed")
+	}
+	if len(list) > 1 {
+		summary := strings.TrimSpace(list[0].Summary.Default())
+		same := summary != ""
+		for _, alert := range list[1:] {
+			same = same && strings.EqualFold(strings.TrimSpace(alert.Summary.Default()), summary)
+		}
+		if same {
+			report("/data/alerts/0/summary", 1,
+				fmt.Sprintf("all %d alerts have the summary %q; summarize what each alert is about", len(list), summary))
+		}
+	}
+}
@@ -0,0 +1,75 @@
+//go:build !(js && wasm)
+
+package validator
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/gbfs-validator-go/pkg/fetcher"
+	"github.com/gbfs-validator-go/pkg/gbfs"
+)
+
+// checkAlertURLs probes the url of every alert when
+// Options.CheckAlertURLs is set and warns about pages that are
+// unreachable, such as a 404 for an alert whose page was removed. An
+// offline validator skips the check.
+func (v *Validator) checkAlertURLs(ctx context.Context, results map[string]*FileValidationResult) {
+	if !v.options.CheckAlertURLs || v.fetcher == nil || ctx.Err() != nil {
+		return
+	}
+	result, ok := results["system_alerts"]
+	if !ok || !result.Exists || result.RawData == nil {
+		return
+	}
+	var alerts gbfs.SystemAlerts
+	if err := json.Unmarshal(result.RawData, &alerts); err != nil {
+		return
+	}
+
+	type ref struct{ path, url string }
+	var refs []ref
+	for i, alert := range alerts.Data.Alerts {
+		for j, text := range alert.URL {
+			if text.Text == "" {
+				continue
+			}
+			path := fmt.Sprintf("/data/alerts/%d/url", i)
+			if !alert.URL.IsPlain() {
+				path = fmt.Sprintf("%s/%d/text", path, j)
+			}
+			refs = append(refs, ref{path: path, url: text.Text})
+		}
+	}
+
+	probes := make([]*fetcher.ProbeResult, len(refs))
+	sem := make(chan struct{}, imageProbeParallelism)
+	var wg sync.WaitGroup
+	for i, r := range refs {
+		wg.Add(1)
+		go func(i int, url string) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			probes[i] = v.fetcher.Probe(ctx, url)
+		}(i, r.url)
+	}
+	wg.Wait()
+
+	for i, r := range refs {
+		probe := probes[i]
+		if probe.Error == nil || ctx.Err() != nil {
+			continue
+		}
+		result.Errors = append(result.Errors, ValidationError{
+			Severity:     SeverityWarning,
+			Category:     CategorySemantic,
+			InstancePath: r.path,
+			Message:      fmt.Sprintf("alert url %s is unreachable: %v", probe.URL, probe.Error),
+			Keyword:      "alertUrl",
+		})
+		result.ErrorsCount = len(result.Errors)
+	}
+}
@@ -0,0 +1,295 @@
+//go:build !(js && wasm)
+
+package validator
+
+import (
+	"fmt"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/gbfs-validator-go/pkg/fetcher"
+	"github.com/gbfs-validator-go/pkg/gbfs"
+	"github.com/gbfs-validator-go/pkg/version"
+)
+
+// discoveryFeed is one feed listed in gbfs.json and where it is listed.
+type discoveryFeed struct {
+	path string
+	gbfs.FeedInfo
+}
+
+// discoveryFeeds lists the feeds of every language block of gbfs.json, or
+// of the v3 feed list.
+func discoveryFeeds(feed *gbfs.GBFSFeed) []discoveryFeed {
+	var out []discoveryFeed
+	if feed.Data.Format == gbfs.FormatLanguageMap {
+		for _, lang := range feed.Data.LanguageCodes() {
+			for i, f := range feed.Data.Languages[lang].Feeds {
+				out = append(out, discoveryFeed{path: fmt.Sprintf("/data/%s/feeds/%d", lang, i), FeedInfo: f})
+			}
+		}
+		return out
+	}
+	for i, f := range feed.Data.Feeds {
+		out = append(out, discoveryFeed{path: fmt.Sprintf("/data/feeds/%d", i), FeedInfo: f})
+	}
+	return out
+}
+
+// checkAutodiscovery checks the feeds gbfs.json advertises. It adds
+// findings to result, the result of gbfs.json as fetched from gbfsURL.
+func (v *Validator) checkAutodiscovery(result *FileValidationResult, feed *gbfs.GBFSFeed, gbfsURL, ver string) {
+	add := func(e ValidationError) {
+		result.Errors = append(result.Errors, e)
+		result.ErrorsCount = len(result.Errors)
+		if e.Severity == SeverityError {
+			result.HasErrors = true
+		}
+	}
+	for _, e := range unknownFeedNames(result, feed, ver) {
+		add(e)
+	}
+	for _, e := range feedURLFindings(feed, gbfsURL, ver, v.options.SameOrigin) {
+		add(e)
+	}
+	if version.IsV3OrLater(ver) {
+		if e := selfReference(result, feed); e != nil {
+			add(*e)
+		}
+	}
+}
+
+// selfReference checks that a v3 gbfs.json lists itself under the name
+// gbfs with the URL it was fetched from, after redirects.
+func selfReference(result *FileValidationResult, feed *gbfs.GBFSFeed) *ValidationError {
+	fetched := result.URL
+	if result.RedirectedURL != "" {
+		fetched = result.RedirectedURL
+	}
+	for _, f := range discoveryFeeds(feed) {
+		if f.Name != "gbfs" {
+			continue
+		}
+		self := fetcher.RedactURL(f.URL)
+		switch {
+		case sameURL(self, fetched):
+			return nil
+		case sameURL(self, result.URL):
+			return &ValidationError{
+				Severity:     SeverityInfo,
+				Category:     CategorySchema,
+				InstancePath: f.path + "/url",
+				Message:      fmt.Sprintf("gbfs.json lists itself as %s, which redirects to %s; list the final URL", self, fetched),
+				Keyword:      "selfReference",
+			}
+		default:
+			return &ValidationError{
+				Severity:     SeverityWarning,
+				Category:     CategorySchema,
+				InstancePath: f.path + "/url",
+				Message:      fmt.Sprintf("gbfs.json lists itself as %s but was fetched from %s", self, fetched),
+				Keyword:      "selfReference",
+			}
+		}
+	}
+	return &ValidationError{
+		Severity:     SeverityWarning,
+		Category:     CategorySchema,
+		InstancePath: "/data/feeds",
+		Message:      "gbfs.json should list itself in feeds with the name gbfs",
+		Keyword:      "selfReference",
+	}
+}
+
+// sameURL compares URLs ignoring the case of the scheme and host.
+func sameURL(a, b string) bool {
+	ua, errA := url.Parse(a)
+	ub, errB := url.Parse(b)
+	if errA != nil || errB != nil {
+		return a == b
+	}
+	return strings.EqualFold(ua.Scheme, ub.Scheme) && strings.EqualFold(ua.Host, ub.Host) &&
+		ua.Path == ub.Path && ua.RawQuery == ub.RawQuery
+}
+
+// feedURLFindings checks that feed URLs are absolute http(s) URLs naming
+// their file, listed once per language, and, when sameOrigin is set, on
+// the origin of gbfs.json.
+func feedURLFindings(feed *gbfs.GBFSFeed, gbfsURL, ver string, sameOrigin bool) []ValidationError {
+	base, _ := url.Parse(gbfsURL)
+	known := version.FeedNames(ver)
+	var findings []ValidationError
+	finding := func(severity ValidationSeverity, instance, keyword, message string) {
+		findings = append(findings, ValidationError{
+			Severity:     severity,
+			Category:     CategorySchema,
+			InstancePath: instance,
+			Message:      message,
+			Keyword:      keyword,
+		})
+	}
+
+	seen := make(map[string]string)
+	for _, f := range discoveryFeeds(feed) {
+		if f.URL == "" {
+			continue
+		}
+		instance := f.path + "/url"
+		u, err := url.Parse(f.URL)
+		if err != nil || !u.IsAbs() || u.Host == "" {
+			finding(SeverityError, instance, "format", fmt.Sprintf("URL %q of %s must be absolute", f.URL, f.Name))
+			continue
+		}
+		switch u.Scheme {
+		case "https":
+		case "http":
+			finding(SeverityWarning, instance, "insecureUrl", fmt.Sprintf("URL of %s uses http; serve feeds over https", f.Name))
+		default:
+			finding(SeverityError, instance, "format", fmt.Sprintf("URL of %s uses the %s scheme; use https", f.Name, u.Scheme))
+			continue
+		}
+
+		file := path.Base(u.Path)
+		if containsString(known, f.Name) && file != f.Name+".json" && file != f.Name {
+			finding(SeverityWarning, instance, "feedUrl", fmt.Sprintf("URL of %s ends with %q, not %s.json", f.Name, file, f.Name))
+		}
+
+		block, _, _ := strings.Cut(strings.TrimPrefix(f.path, "/data/"), "/")
+		key := block + " " + f.URL
+		if other, ok := seen[key]; ok && other != f.Name {
+			finding(SeverityWarning, instance, "uniqueItems", fmt.Sprintf("%s and %s share the URL %s", other, f.Name, fetcher.RedactURL(f.URL)))
+		} else if !ok {
+			seen[key] = f.Name
+		}
+
+		if sameOrigin && base != nil && base.Host != "" && (u.Scheme != base.Scheme || u.Host != base.Host) {
+			finding(SeverityWarning, instance, "crossOrigin", fmt.Sprintf("URL of %s is on %s://%s, not on the origin of gbfs.json (%s://%s)", f.Name, u.Scheme, u.Host, base.Scheme, base.Host))
+		}
+	}
+	return findings
+}
+
+// unknownFeedNames warns about feed names the version does not define,
+// such as typos or names from another version. When the schema already
+// rejected a name, the hint is added to that error instead.
+func unknownFeedNames(result *FileValidationResult, feed *gbfs.GBFSFeed, ver string) []ValidationError {
+	valid := version.FeedNames(ver)
+	known := make(map[string]bool, len(valid))
+	for _, name := range valid {
+		known[name] = true
+	}
+
+	var findings []ValidationError
+	for _, f := range discoveryFeeds(feed) {
+		if f.Name == "" || known[f.Name] {
+			continue
+		}
+		instance := f.path + "/name"
+		message := fmt.Sprintf("feed name %q is not defined in GBFS %s", f.Name, ver)
+		if hint := feedNameHint(f.Name, valid); hint != "" {
+			message += " (" + hint + ")"
+		}
+		message += "; valid names are " + strings.Join(valid, ", ")
+
+		amended := false
+		for i := range result.Errors {
+			if result.Errors[i].InstancePath == instance {
+				result.Errors[i].Message += " (" + message + ")"
+				amended = true
+			}
+		}
+		if !amended {
+			findings = append(findings, ValidationError{
+				Severity:     SeverityWarning,
+				Category:     CategorySchema,
+				InstancePath: instance,
+				Message:      message,
+				Keyword:      "unknownFeed",
+			})
+		}
+	}
+	return findings
+}
+
+// CheckFeedURLOverride reports whether Options.FeedURLOverrides may point
+// the feed name, with or without .json, at rawURL: the name must be a file
+// of some supported version and the URL absolute http or https.
+func CheckFeedURLOverride(name, rawURL string) error {
+	name = strings.TrimSuffix(name, ".json")
+	var valid []string
+	for _, ver := range version.SupportedVersions() {
+		for _, n := range version.FeedNames(ver) {
+			if n != "gbfs" && !containsString(valid, n) {
+				valid = append(valid, n)
+			}
+		}
+	}
+	if !containsString(valid, name) {
+		message := fmt.Sprintf("%s is not a GBFS feed", name)
+		if hint := feedNameHint(name, valid); hint != "" {
+			message += " (" + hint + ")"
+		}
+		return fmt.Errorf("%s", message)
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("%s: %q is not an http or https URL", name, rawURL)
+	}
+	return nil
+}
+
+// feedNameHint suggests what an unknown feed name was meant to be: a name
+// from another version, or a close spelling of a valid name.
+func feedNameHint(name string, valid []string) string {
+	switch {
+	case name == "free_bike_status" && containsString(valid, "vehicle_status"):
+		return "free_bike_status was renamed vehicle_status in 3.0"
+	case name == "vehicle_status" && containsString(valid, "free_bike_status"):
+		return "vehicle_status is the 3.0 name of free_bike_status"
+	}
+	var defined []string
+	for _, ver := range version.SupportedVersions() {
+		if containsString(version.FeedNames(ver), name) {
+			defined = append(defined, ver)
+		}
+	}
+	switch len(defined) {
+	case 0:
+	case 1:
+		return fmt.Sprintf("%s is defined in GBFS %s", name, defined[0])
+	default:
+		return fmt.Sprintf("%s is defined in GBFS %s to %s", name, defined[0], defined[len(defined)-1])
+	}
+	best, bestDistance := "", 3
+	for _, candidate := range valid {
+		if d := editDistance(name, candidate); d < bestDistance {
+			best, bestDistance = candidate, d
+		}
+	}
+	if best != "" {
+		return fmt.Sprintf("did you mean %q?", best)
+	}
+	return ""
+}
+
+// editDistance returns the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur := make([]int, len(b)+1)
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+	return prev[len(b)]
+}
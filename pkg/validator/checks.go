@@ -0,0 +1,416 @@
+package validator
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/gbfs-validator-go/pkg/version"
+)
+
+// Check describes a built-in check of the validator. Its findings carry
+// one of Keywords.
+type Check struct {
+	ID          string        `json:"id"`
+	Description string        `json:"description"`
+	Category    ErrorCategory `json:"category"`
+	// Severity is the most severe finding the check reports.
+	Severity ValidationSeverity `json:"severity"`
+	Keywords []string           `json:"keywords"`
+	// Files lists the files the check reads; empty means every file.
+	Files []string `json:"files,omitempty"`
+	// MinVersion and BeforeVersion bound the GBFS versions checked.
+	MinVersion    string `json:"minVersion,omitempty"`
+	BeforeVersion string `json:"beforeVersion,omitempty"`
+	// Option names the option that enables or tunes the check.
+	Option string `json:"option,omitempty"`
+
+	enabled  func(Options) bool
+	severity func(Options) ValidationSeverity
+}
+
+// AppliesTo reports whether the check runs for version ver.
+func (c Check) AppliesTo(ver string) bool {
+	if c.MinVersion != "" && !version.AtLeast(ver, c.MinVersion) {
+		return false
+	}
+	return c.BeforeVersion == "" || !version.AtLeast(ver, c.BeforeVersion)
+}
+
+// vehicleStatusFiles names the vehicle status file of every version.
+var vehicleStatusFiles = []string{"free_bike_status", "vehicle_status"}
+
+// checks is the registry of built-in checks, in the order they run.
+var checks = []Check{
+	{
+		ID: "availability", Category: CategoryAvailability, Severity: SeverityError,
+		Description: "Required files are listed in gbfs.json and can be fetched",
+		Keywords:    []string{"required", "fetch"},
+	},
+	{
+		ID: "missingRecommended", Category: CategoryAvailability, Severity: SeverityWarning,
+		Description: "Recommended files are listed in gbfs.json",
+		Keywords:    []string{"recommended"},
+		Option:      "profile",
+		severity: func(opts Options) ValidationSeverity {
+			if opts.MissingRecommendedSeverity != "" {
+				return opts.MissingRecommendedSeverity
+			}
+			profile, _ := GetProfile(opts.Profile)
+			return profile.MissingRecommendedSeverity
+		},
+	},
+	{
+		ID: "schema", Category: CategorySchema, Severity: SeverityError,
+		Description: "Files parse as JSON and match the JSON Schema of the version, or its basic structure when no schema is bundled",
+		Keywords:    []string{"parse", "schema", "type", "required", "enum", "format", "minimum", "maximum", "minItems", "additionalProperties", "dependentRequired"},
+	},
+	{
+		ID: "extensions", Category: CategoryExtension, Severity: SeverityError,
+		Description: "Declared extension fields match their schemas",
+		Keywords:    []string{"extension"},
+		Option:      "extensions",
+		enabled:     func(opts Options) bool { return len(opts.Extensions) > 0 },
+	},
+	{
+		ID: "ttlRange", Category: CategoryFreshness, Severity: SeverityWarning,
+		Description: "ttl is in the range the profile recommends: short for realtime files, not 0 for files that change rarely",
+		Keywords:    []string{"ttl"},
+		Option:      "profile",
+	},
+	{
+		ID: "timestampEncoding", Category: CategorySchema, Severity: SeverityWarning,
+		Description: "last_updated uses the encoding the version requires, not milliseconds or a string",
+		Keywords:    []string{"format"},
+	},
+	{
+		ID: "sizeBudget", Category: CategoryAvailability, Severity: SeverityWarning,
+		Description: "Files are no larger than their size budget, by default 100 KiB for gbfs.json and 5 MiB for realtime files",
+		Keywords:    []string{"size"},
+		Option:      "sizeBudgets",
+	},
+	{
+		ID: "encoding", Category: CategorySchema, Severity: SeverityError,
+		Description: "Strings are valid UTF-8 without unpaired surrogates, control characters or mojibake",
+		Keywords:    []string{"encoding"},
+	},
+	{
+		ID: "languageSelection", Category: CategoryAvailability, Severity: SeverityError,
+		Description:   "Requested language blocks exist in gbfs.json",
+		Keywords:      []string{"required"},
+		Files:         []string{"gbfs"},
+		BeforeVersion: "3.0",
+		Option:        "languages",
+		enabled:       func(opts Options) bool { return len(opts.Languages) > 0 },
+	},
+	{
+		ID: "feedUrls", Category: CategorySchema, Severity: SeverityError,
+		Description: "Feed URLs in gbfs.json are absolute https URLs naming their file, listed once per language",
+		Keywords:    []string{"format", "insecureUrl", "feedUrl", "uniqueItems"},
+		Files:       []string{"gbfs"},
+	},
+	{
+		ID: "sameOrigin", Category: CategorySchema, Severity: SeverityWarning,
+		Description: "Feed URLs are on the origin of gbfs.json",
+		Keywords:    []string{"crossOrigin"},
+		Files:       []string{"gbfs"},
+		Option:      "sameOrigin",
+		enabled:     func(opts Options) bool { return opts.SameOrigin },
+	},
+	{
+		ID: "selfReference", Category: CategorySchema, Severity: SeverityWarning,
+		Description: "gbfs.json lists itself with the URL it was fetched from",
+		Keywords:    []string{"selfReference"},
+		Files:       []string{"gbfs"},
+		MinVersion:  "3.0",
+	},
+	{
+		ID: "unknownFeeds", Category: CategorySchema, Severity: SeverityWarning,
+		Description: "gbfs.json lists only feed names the version defines",
+		Keywords:    []string{"unknownFeed"},
+		Files:       []string{"gbfs"},
+	},
+	{
+		ID: "vehicleTypeReferences", Category: CategoryCrossReference, Severity: SeverityError,
+		Description: "Vehicles reference vehicle types that exist, and motorized vehicles publish their range",
+		Keywords:    []string{"reference", "recommended"},
+		Files:       append([]string{"vehicle_types"}, vehicleStatusFiles...),
+	},
+	{
+		ID: "pricingPlanReferences", Category: CategoryCrossReference, Severity: SeverityError,
+		Description: "Vehicle types reference pricing plans that exist",
+		Keywords:    []string{"reference"},
+		Files:       []string{"vehicle_types", "system_pricing_plans"},
+	},
+	{
+		ID: "stationReferences", Category: CategoryCrossReference, Severity: SeverityError,
+		Description: "station_status references stations that exist in station_information",
+		Keywords:    []string{"reference"},
+		Files:       []string{"station_information", "station_status"},
+	},
+	{
+		ID: "conditionalVehicleTypes", Category: CategoryCrossReference, Severity: SeverityError,
+		Description: "vehicle_types is published when vehicles set vehicle_type_id",
+		Keywords:    []string{"dependentRequired"},
+		Files:       append([]string{"vehicle_types"}, vehicleStatusFiles...),
+		Option:      "referencedFileSeverity",
+		severity:    func(opts Options) ValidationSeverity { return referencedFileSeverity(opts, "conditionalVehicleTypes") },
+	},
+	{
+		ID: "conditionalPricingPlans", Category: CategoryCrossReference, Severity: SeverityError,
+		Description: "system_pricing_plans is published when vehicles set pricing_plan_id",
+		Keywords:    []string{"dependentRequired"},
+		Files:       append([]string{"system_pricing_plans"}, vehicleStatusFiles...),
+		Option:      "referencedFileSeverity",
+		severity:    func(opts Options) ValidationSeverity { return referencedFileSeverity(opts, "conditionalPricingPlans") },
+	},
+	{
+		ID: "conditionalRegions", Category: CategoryCrossReference, Severity: SeverityWarning,
+		Description: "system_regions is published when stations set region_id",
+		Keywords:    []string{"dependentRequired"},
+		Files:       []string{"system_regions", "station_information"},
+		Option:      "referencedFileSeverity",
+		severity:    func(opts Options) ValidationSeverity { return referencedFileSeverity(opts, "conditionalRegions") },
+	},
+	{
+		ID: "geofencingConflicts", Category: CategorySemantic, Severity: SeverityWarning,
+		Description: "Overlapping geofencing zones do not contradict each other for a vehicle type",
+		Keywords:    []string{"geofencingConflict"},
+		Files:       []string{"geofencing_zones"},
+		MinVersion:  "2.1",
+	},
+	{
+		ID: "depotClusters", Category: CategorySemantic, Severity: SeverityWarning,
+		Description: "Free-floating vehicles do not pile up at one exact position, as in a depot",
+		Keywords:    []string{"depotCluster"},
+		Files:       vehicleStatusFiles,
+		Option:      "depotThreshold",
+		enabled:     func(opts Options) bool { return opts.DepotThreshold >= 0 },
+	},
+	{
+		ID: "languageCoverage", Category: CategorySemantic, Severity: SeverityWarning,
+		Description: "Localized fields have text in every language of system_information",
+		Keywords:    []string{"languageCoverage"},
+		MinVersion:  "3.0",
+	},
+	{
+		ID: "pricing", Category: CategorySemantic, Severity: SeverityError,
+		Description: "Pricing plans share a currency, fit its minor unit, and have no negative prices",
+		Keywords:    []string{"currency", "currencyPrecision", "minimum"},
+		Files:       []string{"system_pricing_plans"},
+		Option:      "currencies",
+	},
+	{
+		ID: "rentalUris", Category: CategorySemantic, Severity: SeverityError,
+		Description: "Rental deep links are absolute, use App Links or Universal Links, and cover both platforms",
+		Keywords:    []string{"rentalUri", "rentalUriPlatform", "format"},
+		Files:       append([]string{"system_information", "station_information"}, vehicleStatusFiles...),
+	},
+	{
+		ID: "stationStructure", Category: CategorySemantic, Severity: SeverityWarning,
+		Description: "Virtual and physical stations publish the fields that describe them",
+		Keywords:    []string{"stationStructure"},
+		Files:       []string{"station_information"},
+	},
+	{
+		ID: "stationQuality", Category: CategorySemantic, Severity: SeverityWarning,
+		Description: "Station names are not empty, shared by many stations, or internal codes, and post codes come with an address",
+		Keywords:    []string{"stationQuality"},
+		Files:       []string{"station_information"},
+		Option:      "dataQuality",
+		enabled:     func(opts Options) bool { return opts.DataQuality },
+	},
+	{
+		ID: "alertQuality", Category: CategorySemantic, Severity: SeverityWarning,
+		Description: "Alerts have times, station closures name their stations or regions, and summaries differ between alerts",
+		Keywords:    []string{"alertQuality"},
+		Files:       []string{"system_alerts"},
+	},
+	{
+		ID: "systemIdFormat", Category: CategorySemantic, Severity: SeverityWarning,
+		Description: "system_id uses letters, digits, '.', '-' and '_' only",
+		Keywords:    []string{"systemId"},
+		Files:       []string{"system_information"},
+	},
+	{
+		ID: "systemIdChanged", Category: CategorySemantic, Severity: SeverityError,
+		Description: "system_id has not changed since the previous run",
+		Keywords:    []string{"systemId"},
+		Files:       []string{"system_information"},
+		Option:      "previous",
+		enabled:     func(opts Options) bool { return opts.Previous != nil },
+	},
+	{
+		ID: "systemIdCatalog", Category: CategoryCrossReference, Severity: SeverityWarning,
+		Description: "system_id matches the one the feed catalog registers for the feed URL",
+		Keywords:    []string{"systemId"},
+		Files:       []string{"system_information"},
+		Option:      "catalog",
+		enabled:     func(opts Options) bool { return opts.Catalog != nil },
+	},
+	{
+		ID: "coordinatePrecision", Category: CategorySemantic, Severity: SeverityWarning,
+		Description: "lat and lon have neither too few decimal places to locate a vehicle nor more than any GPS measures",
+		Keywords:    []string{"precision"},
+		Option:      "minCoordinateDecimals",
+		enabled: func(opts Options) bool {
+			fewest, most := coordinateDecimals(opts)
+			return fewest > 0 || most > 0
+		},
+	},
+	{
+		ID: "returnConstraints", Category: CategorySemantic, Severity: SeverityError,
+		Description: "return_constraint is valid for the version and return_type is not used",
+		Keywords:    []string{"deprecated", "version", "enum"},
+		Files:       []string{"vehicle_types"},
+	},
+	{
+		ID: "languageConsistency", Category: CategoryCrossReference, Severity: SeverityError,
+		Description:   "Every language block publishes the same stations and vehicles",
+		Keywords:      []string{"languageConsistency"},
+		Files:         append([]string{"station_information", "station_status"}, vehicleStatusFiles...),
+		BeforeVersion: "3.0",
+		Option:        "languages",
+		enabled: func(opts Options) bool {
+			return len(opts.Languages) > 1 || (len(opts.Languages) == 1 && opts.Languages[0] == "all")
+		},
+	},
+	{
+		ID: "images", Category: CategorySemantic, Severity: SeverityWarning,
+		Description: "Image URLs respond with an image no larger than the size limit",
+		Keywords:    []string{"imageUrl"},
+		Option:      "checkImages",
+		enabled:     func(opts Options) bool { return opts.CheckImages },
+	},
+	{
+		ID: "versionConsistency", Category: CategoryCrossReference, Severity: SeverityWarning,
+		Description: "Versions listed in gbfs_versions.json publish the same system_id, station IDs, and fleet size",
+		Keywords:    []string{"versionConsistency"},
+		Files:       []string{"gbfs_versions"},
+		MinVersion:  "1.1",
+		Option:      "compareVersions",
+		enabled:     func(opts Options) bool { return opts.CompareVersions },
+	},
+	{
+		ID: "alertUrls", Category: CategorySemantic, Severity: SeverityWarning,
+		Description: "Alert URLs are reachable",
+		Keywords:    []string{"alertUrl"},
+		Files:       []string{"system_alerts"},
+		Option:      "checkAlertUrls",
+		enabled:     func(opts Options) bool { return opts.CheckAlertURLs },
+	},
+}
+
+// Checks lists the built-in checks, sorted by ID.
+func Checks() []Check {
+	list := append([]Check(nil), checks...)
+	sort.Slice(list, func(i, j int) bool { return list[i].ID < list[j].ID })
+	return list
+}
+
+// ParseSeverity returns the severity named s: error, warning, or info.
+func ParseSeverity(s string) (ValidationSeverity, error) {
+	switch severity := ValidationSeverity(s); severity {
+	case SeverityError, SeverityWarning, SeverityInfo:
+		return severity, nil
+	}
+	return "", fmt.Errorf("unknown severity %q; want error, warning, or info", s)
+}
+
+// CheckReferencedFileSeverity reports whether Options.ReferencedFileSeverity
+// may set the severity of check to severity.
+func CheckReferencedFileSeverity(check, severity string) error {
+	if _, err := ParseSeverity(severity); err != nil {
+		return fmt.Errorf("%s: %w", check, err)
+	}
+	for _, c := range checks {
+		if c.ID == check && c.Option == "referencedFileSeverity" {
+			return nil
+		}
+	}
+	return fmt.Errorf("%s is not a referenced-file check", check)
+}
+
+// planDefaultVersion is the version a plan assumes when none is forced.
+const planDefaultVersion = "3.0"
+
+// ValidationPlan describes what a validation run would check, without
+// fetching anything.
+type ValidationPlan struct {
+	URL     string `json:"url,omitempty"`
+	Version string `json:"version"`
+	// VersionAssumed is set when Options.Version is empty. The run would
+	// validate the version gbfs.json declares; the plan assumes the latest.
+	VersionAssumed        bool   `json:"versionAssumed,omitempty"`
+	Profile               string `json:"profile"`
+	LenientMode           bool   `json:"lenientMode,omitempty"`
+	TreatWarningsAsErrors bool   `json:"treatWarningsAsErrors,omitempty"`
+	// SampleSize is Options.SampleSize: how many entries of each large
+	// array would be checked.
+	SampleSize   int           `json:"sampleSize,omitempty"`
+	SampleRandom bool          `json:"sampleRandom,omitempty"`
+	Files        []PlannedFile `json:"files"`
+	// Checks lists every built-in check in the order they run, with
+	// Skipped explaining those that would not.
+	Checks    []PlannedCheck `json:"checks"`
+	RulePacks []RulePack     `json:"rulePacks,omitempty"`
+}
+
+// PlannedFile is a file a run would fetch.
+type PlannedFile struct {
+	File        string `json:"file"`
+	Required    bool   `json:"required"`
+	Recommended bool   `json:"recommended,omitempty"`
+}
+
+// PlannedCheck is a check with the severity it would report at.
+type PlannedCheck struct {
+	Check
+	Skipped string `json:"skipped,omitempty"`
+}
+
+// Plan lists the files and checks a validation of gbfsURL with opts would
+// involve. Nothing is fetched.
+func Plan(gbfsURL string, opts Options) ValidationPlan {
+	ver := opts.Version
+	profile, _ := GetProfile(opts.Profile)
+	plan := ValidationPlan{URL: gbfsURL, Version: ver, Profile: profile.Name,
+		LenientMode: opts.LenientMode, TreatWarningsAsErrors: opts.TreatWarningsAsErrors, RulePacks: opts.RulePacks,
+		SampleSize: opts.SampleSize, SampleRandom: opts.SampleRandom}
+	if ver == "" {
+		ver = planDefaultVersion
+		plan.Version, plan.VersionAssumed = ver, true
+	}
+
+	if len(opts.FeedURLs) == 0 {
+		plan.Files = append(plan.Files, PlannedFile{File: "gbfs", Required: version.IsGBFSRequired(ver)})
+	}
+	for _, req := range version.GetFileRequirements(ver, version.Options{Docked: opts.Docked, Freefloating: opts.Freefloating}) {
+		plan.Files = append(plan.Files, PlannedFile{File: req.File, Required: req.Required, Recommended: req.Recommended})
+	}
+
+	known := version.FeedNames(ver)
+	for _, c := range checks {
+		pc := PlannedCheck{Check: c}
+		if c.severity != nil {
+			pc.Severity = c.severity(opts)
+		}
+		if c.Files != nil {
+			pc.Files = nil
+			for _, f := range c.Files {
+				if containsString(known, f) {
+					pc.Files = append(pc.Files, f)
+				}
+			}
+		}
+		switch {
+		case !c.AppliesTo(ver):
+			pc.Skipped = fmt.Sprintf("does not apply to version %s", ver)
+		case c.enabled != nil && !c.enabled(opts):
+			pc.Skipped = fmt.Sprintf("not enabled by option %s", c.Option)
+		case len(opts.FeedURLs) > 0 && len(pc.Files) == 1 && pc.Files[0] == "gbfs":
+			pc.Skipped = "feed URLs are validated without gbfs.json"
+		}
+		plan.Checks = append(plan.Checks, pc)
+	}
+	return plan
+}
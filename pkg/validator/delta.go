@@ -0,0 +1,56 @@
+package validator
+
+// contentFindings are the findings of a file's own validation, before
+// freshness and cross-file checks, kept so that unchanged content can skip
+// schema validation on the next run.
+type contentFindings struct {
+	version string
+	errors  []ValidationError
+
+	sampleSize   int
+	sampleRandom bool
+}
+
+// previousFiles indexes the files of a previous result that can be reused
+// by URL and file name. Results validated in another mode are not reused,
+// since coercion changes the findings.
+func previousFiles(prev *ValidationResult, lenient bool) map[string]*FileValidationResult {
+	if prev == nil || prev.Summary.LenientMode != lenient {
+		return nil
+	}
+	files := make(map[string]*FileValidationResult, len(prev.Files))
+	for i := range prev.Files {
+		f := &prev.Files[i]
+		if f.Exists && f.Hash != "" && f.content != nil {
+			files[f.URL+" "+f.File] = f
+		}
+	}
+	return files
+}
+
+// reuseFile copies the content findings of the previous run into result
+// when its content and version are unchanged. It returns the data later
+// checks should read.
+func (v *Validator) reuseFile(result *FileValidationResult, ver string) ([]byte, bool) {
+	prev, ok := v.previous[result.URL+" "+result.File]
+	if !ok || prev.Hash != result.Hash || prev.content.version != ver ||
+		prev.content.sampleSize != v.options.SampleSize || prev.content.sampleRandom != v.options.SampleRandom {
+		return nil, false
+	}
+	result.CoercedData = prev.CoercedData
+	result.CoercionCount = prev.CoercionCount
+	result.Coercions = prev.Coercions
+	result.Extensions = prev.Extensions
+	result.Sampled = prev.Sampled
+	result.content = prev.content
+	if errs := prev.content.errors; len(errs) > 0 {
+		result.Errors = append([]ValidationError(nil), errs...)
+		result.ErrorsCount = len(errs)
+		result.HasErrors = true
+	}
+	result.Reused = true
+	if result.CoercedData != nil {
+		return result.CoercedData, true
+	}
+	return result.RawData, true
+}
@@ -0,0 +1,206 @@
+package validator
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// mojibake matches text encoded as UTF-8 and decoded again as Latin-1 or
+// Windows-1252, such as "Ã©" for "é" or "â€™" for "’": a lead byte of a
+// two or three byte sequence followed by a continuation byte.
+var mojibake = regexp.MustCompile(`[ÂÃÄÅâ][\x{0080}-\x{00BF}€‚ƒ„…†‡ˆ‰Š‹ŒŽ‘’“”•–—˜™š›œžŸ]`)
+
+// pointerEscaper escapes an object key for use in a JSON pointer.
+var pointerEscaper = strings.NewReplacer("~", "~0", "/", "~1")
+
+// encodingIssue is a kind of encoding problem found in string values.
+type encodingIssue struct {
+	severity ValidationSeverity
+	message  string
+	first    string
+	count    int
+}
+
+// checkEncoding reports string values of a file that downstream consumers
+// cannot read reliably: bytes that are not UTF-8, unpaired UTF-16
+// surrogate escapes, control characters, and mojibake. It reads the body
+// as fetched, since decoding replaces invalid bytes and surrogates with
+// U+FFFD. Each kind of problem is reported once, at the first string that
+// has it.
+func checkEncoding(body []byte) []ValidationError {
+	issues := []*encodingIssue{
+		{severity: SeverityError, message: "string contains bytes that are not valid UTF-8"},
+		{severity: SeverityError, message: "string contains an unpaired UTF-16 surrogate escape"},
+		{severity: SeverityWarning, message: "string contains control characters"},
+		{severity: SeverityWarning, message: "string looks like UTF-8 text decoded as Latin-1 or Windows-1252 (mojibake)"},
+	}
+	invalid, surrogate, control, garbled := issues[0], issues[1], issues[2], issues[3]
+	add := func(issue *encodingIssue, path string) {
+		if issue.count == 0 {
+			issue.first = path
+		}
+		issue.count++
+	}
+
+	walkValues(body, func(path string, raw []byte, tok json.Token) {
+		s, ok := tok.(string)
+		if !ok || len(raw) < 2 {
+			return
+		}
+		raw = raw[1 : len(raw)-1]
+		switch {
+		case !utf8.Valid(raw):
+			add(invalid, path)
+		case hasUnpairedSurrogate(raw):
+			add(surrogate, path)
+		}
+		if hasControl(s) {
+			add(control, path)
+		}
+		if mojibake.MatchString(s) {
+			add(garbled, path)
+		}
+	})
+
+	var errs []ValidationError
+	for _, issue := range issues {
+		if issue.count == 0 {
+			continue
+		}
+		message := issue.message
+		if issue.count > 1 {
+			message = fmt.Sprintf("%s (%d strings)", message, issue.count)
+		}
+		errs = append(errs, ValidationError{
+			Severity:     issue.severity,
+			Category:     CategorySchema,
+			InstancePath: issue.first,
+			Message:      message,
+			Keyword:      "encoding",
+		})
+	}
+	return errs
+}
+
+// walkValues calls fn with the JSON pointer, the literal text, and the
+// decoded token of every scalar value in body, in document order. It stops
+// quietly where body is not valid JSON; the schema check reports that.
+func walkValues(body []byte, fn func(path string, raw []byte, tok json.Token)) {
+	type frame struct {
+		object    bool
+		expectKey bool
+		key       string
+		index     int
+	}
+	var stack []*frame
+	path := func() string {
+		var b strings.Builder
+		for _, f := range stack {
+			b.WriteByte('/')
+			if f.object {
+				b.WriteString(pointerEscaper.Replace(f.key))
+			} else {
+				b.WriteString(strconv.Itoa(f.index))
+			}
+		}
+		return b.String()
+	}
+	// next moves the innermost container past a complete value.
+	next := func() {
+		if len(stack) == 0 {
+			return
+		}
+		if top := stack[len(stack)-1]; top.object {
+			top.expectKey = true
+		} else {
+			top.index++
+		}
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	for {
+		start := dec.InputOffset()
+		tok, err := dec.Token()
+		if err != nil {
+			return
+		}
+		if delim, ok := tok.(json.Delim); ok {
+			switch delim {
+			case '{':
+				stack = append(stack, &frame{object: true, expectKey: true})
+			case '[':
+				stack = append(stack, &frame{})
+			default:
+				stack = stack[:len(stack)-1]
+				next()
+			}
+			continue
+		}
+		if len(stack) > 0 {
+			if top := stack[len(stack)-1]; top.object && top.expectKey {
+				top.key, _ = tok.(string)
+				top.expectKey = false
+				continue
+			}
+		}
+		// The text before the token holds only whitespace and the
+		// separator, if any.
+		raw := bytes.TrimLeft(body[start:dec.InputOffset()], " \t\r\n,:")
+		fn(path(), raw, tok)
+		next()
+	}
+}
+
+// hasUnpairedSurrogate reports whether the raw text of a JSON string has a
+// \u escape of a UTF-16 surrogate that is not part of a pair.
+func hasUnpairedSurrogate(raw []byte) bool {
+	escape := func(i int) (rune, bool) {
+		if i+6 > len(raw) || raw[i] != '\\' || raw[i+1] != 'u' {
+			return 0, false
+		}
+		n, err := strconv.ParseUint(string(raw[i+2:i+6]), 16, 16)
+		return rune(n), err == nil
+	}
+	for i := 0; i < len(raw); i++ {
+		if raw[i] != '\\' {
+			continue
+		}
+		r, ok := escape(i)
+		if !ok {
+			i++ // skip the escaped character, which may be a backslash
+			continue
+		}
+		switch {
+		case r >= 0xD800 && r <= 0xDBFF:
+			low, ok := escape(i + 6)
+			if !ok || low < 0xDC00 || low > 0xDFFF {
+				return true
+			}
+			i += 11
+		case r >= 0xDC00 && r <= 0xDFFF:
+			return true
+		default:
+			i += 5
+		}
+	}
+	return false
+}
+
+// hasControl reports whether s has a C0 or C1 control character other than
+// tab, line feed and carriage return, which descriptions may use.
+func hasControl(s string) bool {
+	for _, r := range s {
+		switch {
+		case r == '\t' || r == '\n' || r == '\r':
+		case r < 0x20, r == 0x7F, r >= 0x80 && r <= 0x9F:
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,30 @@
+package validator
+
+import (
+	"encoding/csv"
+	"io"
+)
+
+// CSVHeader lists the columns written by WriteCSV.
+var CSVHeader = []string{"file", "severity", "code", "instancePath", "message"}
+
+// WriteCSV writes one row per validation issue.
+func (r *ValidationResult) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+
+	if err := cw.Write(CSVHeader); err != nil {
+		return err
+	}
+
+	for _, file := range r.Files {
+		for _, e := range file.Errors {
+			row := []string{file.File, string(e.Severity), e.Keyword, e.InstancePath, e.Message}
+			if err := cw.Write(row); err != nil {
+				return err
+			}
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,156 @@
+package validator
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/gbfs-validator-go/pkg/schema"
+)
+
+// Extension declares a namespace of proprietary fields. Fields whose names
+// start with Prefix are removed before schema validation, so they are not
+// flagged as unknown, and are listed in FileValidationResult.Extensions.
+type Extension struct {
+	// Prefix marks the namespace, e.g. "x_" or "acme_".
+	Prefix string `json:"prefix"`
+	// Schemas optionally validates field values, keyed by full field name.
+	Schemas map[string]json.RawMessage `json:"schemas,omitempty"`
+}
+
+// ExtensionField is an extension field found in a file.
+type ExtensionField struct {
+	Namespace    string `json:"namespace"`
+	Field        string `json:"field"`
+	InstancePath string `json:"instancePath"`
+}
+
+// extension is a declared namespace with its schemas compiled.
+type extension struct {
+	prefix  string
+	schemas map[string]*schema.Schema
+	invalid map[string]error
+}
+
+// compileExtensions compiles extension schemas, keeping compile errors to
+// report on the fields they were meant to validate.
+func compileExtensions(decls []Extension) []extension {
+	exts := make([]extension, 0, len(decls))
+	for _, d := range decls {
+		if d.Prefix == "" {
+			continue
+		}
+		ext := extension{
+			prefix:  d.Prefix,
+			schemas: make(map[string]*schema.Schema, len(d.Schemas)),
+			invalid: make(map[string]error),
+		}
+		for field, raw := range d.Schemas {
+			s, err := schema.Compile(raw)
+			if err != nil {
+				ext.invalid[field] = err
+				continue
+			}
+			ext.schemas[field] = s
+		}
+		exts = append(exts, ext)
+	}
+	return exts
+}
+
+// matchExtension returns the extension whose prefix the field name carries,
+// preferring the longest prefix.
+func (v *Validator) matchExtension(name string) *extension {
+	var best *extension
+	for i := range v.extensions {
+		ext := &v.extensions[i]
+		if strings.HasPrefix(name, ext.prefix) && (best == nil || len(ext.prefix) > len(best.prefix)) {
+			best = ext
+		}
+	}
+	return best
+}
+
+// extractExtensions removes extension fields from data, returning the
+// remaining document, the fields found, and issues from extension schemas.
+// data is returned unchanged when no extension fields are present.
+func (v *Validator) extractExtensions(data []byte) ([]byte, []ExtensionField, []ValidationError) {
+	if len(v.extensions) == 0 {
+		return data, nil, nil
+	}
+
+	var doc interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return data, nil, nil
+	}
+
+	var fields []ExtensionField
+	var issues []ValidationError
+	var walk func(node interface{}, path string)
+	walk = func(node interface{}, path string) {
+		switch n := node.(type) {
+		case map[string]interface{}:
+			keys := make([]string, 0, len(n))
+			for k := range n {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			for _, k := range keys {
+				childPath := path + "/" + k
+				ext := v.matchExtension(k)
+				if ext == nil {
+					walk(n[k], childPath)
+					continue
+				}
+				fields = append(fields, ExtensionField{Namespace: ext.prefix, Field: k, InstancePath: childPath})
+				issues = append(issues, ext.check(k, n[k], childPath)...)
+				delete(n, k)
+			}
+		case []interface{}:
+			for i, item := range n {
+				walk(item, fmt.Sprintf("%s/%d", path, i))
+			}
+		}
+	}
+	walk(doc, "")
+
+	if len(fields) == 0 {
+		return data, nil, nil
+	}
+	stripped, err := json.Marshal(doc)
+	if err != nil {
+		return data, fields, issues
+	}
+	return stripped, fields, issues
+}
+
+// check validates an extension field value against its schema, if any.
+func (ext *extension) check(field string, value interface{}, path string) []ValidationError {
+	if err, ok := ext.invalid[field]; ok {
+		return []ValidationError{{
+			Severity:     SeverityError,
+			Category:     CategoryExtension,
+			Message:      fmt.Sprintf("extension schema for %s is invalid: %v", field, err),
+			InstancePath: path,
+			Keyword:      "extension",
+		}}
+	}
+
+	s, ok := ext.schemas[field]
+	if !ok {
+		return nil
+	}
+	var issues []ValidationError
+	for _, e := range s.Validate(value) {
+		issues = append(issues, ValidationError{
+			Severity:     SeverityError,
+			Category:     CategoryExtension,
+			Message:      e.Message,
+			InstancePath: path + e.InstancePath,
+			SchemaPath:   e.SchemaPath,
+			Keyword:      e.Keyword,
+		})
+	}
+	return issues
+}
@@ -0,0 +1,125 @@
+//go:build !(js && wasm)
+
+package validator
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gbfs-validator-go/pkg/scaffold"
+	"github.com/gbfs-validator-go/pkg/version"
+)
+
+// validateFeedSet validates Options.FeedURLs without fetching gbfs.json and
+// reports the autodiscovery file the set implies.
+func (v *Validator) validateFeedSet(ctx context.Context) (*ValidationResult, error) {
+	result := &ValidationResult{
+		ResultSchemaVersion: ResultSchemaVersion,
+		ValidatedAt:         validationTime(),
+		Summary: ValidationSummary{
+			ValidatorVersion: "1.0.0",
+			LenientMode:      v.options.LenientMode,
+		},
+		Files: []FileValidationResult{},
+	}
+
+	feedURLs := make(map[string]string, len(v.options.FeedURLs))
+	for name, url := range v.options.FeedURLs {
+		feedURLs[strings.TrimSuffix(name, ".json")] = url
+	}
+	for name, url := range v.overrides {
+		feedURLs[name] = url
+	}
+
+	detectedVersion := v.detectFeedSetVersion(ctx, feedURLs)
+	validatedVersion := v.options.Version
+	if validatedVersion == "" {
+		validatedVersion = detectedVersion
+	}
+
+	result.Summary.Version = VersionInfo{
+		Detected:  detectedVersion,
+		Validated: validatedVersion,
+	}
+
+	requirements := version.GetFileRequirements(validatedVersion, version.Options{
+		Docked:       v.options.Docked,
+		Freefloating: v.options.Freefloating,
+	})
+
+	fileResults := v.validateFiles(ctx, feedURLs, requirements, validatedVersion)
+	v.crossValidate(fileResults, validatedVersion)
+	v.checkImages(ctx, fileResults)
+	v.checkAlertURLs(ctx, fileResults)
+	v.addFileResults(result, []string{""}, map[string]map[string]*FileValidationResult{"": fileResults})
+	result.RulePacks = v.evaluateRulePacks(fileResults, validatedVersion)
+
+	suggestFixes(result)
+	linkSpec(result)
+	summarize(result)
+	result.SuggestedGBFS = suggestGBFS(feedURLs, fileResults, validatedVersion)
+
+	return result, nil
+}
+
+// detectFeedSetVersion reads the version field from the first file in the
+// set that declares one, preferring system_information.
+func (v *Validator) detectFeedSetVersion(ctx context.Context, feedURLs map[string]string) string {
+	names := make([]string, 0, len(feedURLs))
+	for name := range feedURLs {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if (names[i] == "system_information") != (names[j] == "system_information") {
+			return names[i] == "system_information"
+		}
+		return names[i] < names[j]
+	})
+
+	for _, name := range names {
+		var header struct {
+			Version string `json:"version"`
+		}
+		fetchResult := v.fetcher.FetchJSON(ctx, feedURLs[name], &header)
+		if fetchResult.Error == nil && header.Version != "" {
+			return header.Version
+		}
+	}
+	return "1.0"
+}
+
+// suggestGBFS builds the gbfs.json that lists every reachable file in the set,
+// in the layout the version expects.
+func suggestGBFS(feedURLs map[string]string, results map[string]*FileValidationResult, ver string) json.RawMessage {
+	feeds := make(map[string]string, len(feedURLs))
+	for name, url := range feedURLs {
+		if r, ok := results[name]; ok && !r.Exists {
+			continue
+		}
+		feeds[name] = url
+	}
+
+	data, err := scaffold.GBFS(feeds, ver, feedSetLanguage(results), "", time.Now())
+	if err != nil {
+		return nil
+	}
+	return data
+}
+
+// feedSetLanguage returns the system_information language, defaulting to "en".
+func feedSetLanguage(results map[string]*FileValidationResult) string {
+	if r, ok := results["system_information"]; ok && r.Exists {
+		var si struct {
+			Data struct {
+				Language string `json:"language"`
+			} `json:"data"`
+		}
+		if json.Unmarshal(r.RawData, &si) == nil && si.Data.Language != "" {
+			return si.Data.Language
+		}
+	}
+	return "en"
+}
@@ -0,0 +1,290 @@
+//go:build !(js && wasm)
+
+package validator
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gbfs-validator-go/pkg/fetcher"
+	"github.com/gbfs-validator-go/pkg/gbfs"
+	"github.com/gbfs-validator-go/pkg/version"
+)
+
+// FailureKind classifies why a file could not be fetched.
+type FailureKind = fetcher.FailureKind
+
+// network is the part of a Validator that reaches feeds over HTTP.
+type network struct {
+	fetcher *fetcher.Fetcher
+}
+
+// New constructs a Validator that fetches feeds with f.
+func New(f *fetcher.Fetcher, opts Options) *Validator {
+	v := newValidator(opts)
+	v.fetcher = f
+	return v
+}
+
+// Validate performs a full feed validation. When Options.FeedURLs is set,
+// gbfsURL is ignored and the explicit feed set is validated instead.
+func (v *Validator) Validate(ctx context.Context, gbfsURL string) (*ValidationResult, error) {
+	if v.fetcher == nil {
+		return nil, errors.New("validator: an offline validator cannot fetch feeds")
+	}
+	if len(v.options.FeedURLs) > 0 {
+		return v.validateFeedSet(ctx)
+	}
+
+	result := &ValidationResult{
+		ResultSchemaVersion: ResultSchemaVersion,
+		FeedURL:             fetcher.RedactURL(gbfsURL),
+		ValidatedAt:         validationTime(),
+		Summary: ValidationSummary{
+			ValidatorVersion: "1.0.0",
+			LenientMode:      v.options.LenientMode,
+		},
+		Files: []FileValidationResult{},
+	}
+
+	gbfsResult, gbfsFeed, err := v.validateGBFS(ctx, gbfsURL)
+	if err != nil || gbfsFeed == nil {
+		if gbfsResult != nil {
+			v.finishFile(gbfsResult)
+			result.Files = append(result.Files, *gbfsResult)
+		}
+		summarize(result)
+		result.Summary.VersionUnimplemented = true
+		return result, nil
+	}
+
+	v.finishFile(gbfsResult)
+	result.Files = append(result.Files, *gbfsResult)
+
+	detectedVersion := gbfsFeed.Version
+	if detectedVersion == "" {
+		detectedVersion = "1.0"
+	}
+	validatedVersion := v.options.Version
+	if validatedVersion == "" {
+		validatedVersion = detectedVersion
+	}
+
+	result.Summary.Version = VersionInfo{
+		Detected:  detectedVersion,
+		Validated: validatedVersion,
+	}
+
+	requirements := version.GetFileRequirements(validatedVersion, version.Options{
+		Docked:       v.options.Docked,
+		Freefloating: v.options.Freefloating,
+	})
+
+	languages, langErrors := v.selectLanguages(gbfsFeed)
+	if len(langErrors) > 0 {
+		gbfsResult.Errors = append(gbfsResult.Errors, langErrors...)
+		gbfsResult.ErrorsCount = len(gbfsResult.Errors)
+		gbfsResult.HasErrors = true
+		v.finishFile(gbfsResult)
+		result.Files[0] = *gbfsResult
+	}
+	v.checkAutodiscovery(gbfsResult, gbfsFeed, gbfsURL, validatedVersion)
+	v.finishFile(gbfsResult)
+	result.Files[0] = *gbfsResult
+
+	byLanguage := make(map[string]map[string]*FileValidationResult, len(languages))
+	for _, lang := range languages {
+		feedURLs := v.buildFeedURLMap(gbfsFeed, lang)
+
+		fileResults := v.validateFiles(ctx, feedURLs, requirements, validatedVersion)
+		for _, fr := range fileResults {
+			fr.Language = lang
+		}
+
+		v.crossValidate(fileResults, validatedVersion)
+		v.checkCatalog(fileResults, gbfsURL)
+		byLanguage[lang] = fileResults
+	}
+
+	if len(languages) > 1 {
+		v.compareLanguages(languages, byLanguage, validatedVersion)
+	}
+	v.checkImages(ctx, byLanguage[languages[0]])
+	v.checkAlertURLs(ctx, byLanguage[languages[0]])
+	v.checkVersionConsistency(ctx, byLanguage[languages[0]], validatedVersion, languages[0], gbfsURL)
+
+	v.addFileResults(result, languages, byLanguage)
+	result.RulePacks = v.evaluateRulePacks(byLanguage[languages[0]], validatedVersion)
+
+	suggestFixes(result)
+	linkSpec(result)
+	summarize(result)
+
+	return result, nil
+}
+
+// validateGBFS fetches and validates gbfs.json.
+func (v *Validator) validateGBFS(ctx context.Context, gbfsURL string) (*FileValidationResult, *gbfs.GBFSFeed, error) {
+	result := &FileValidationResult{
+		File:        "gbfs.json",
+		URL:         fetcher.RedactURL(gbfsURL),
+		Required:    true,
+		Recommended: true,
+	}
+
+	fetchStart := time.Now()
+	fetchResult := v.fetcher.Fetch(ctx, gbfsURL)
+	if fetchResult.Error != nil {
+		if !strings.HasSuffix(gbfsURL, "gbfs.json") {
+			altURL := fetcher.BuildFeedURL(gbfsURL, "gbfs")
+			fetchResult = v.fetcher.Fetch(ctx, altURL)
+			if fetchResult.Error == nil {
+				result.URL = fetcher.RedactURL(altURL)
+			}
+		}
+	}
+	result.RequestHeaders = fetchResult.Headers
+	if fetchResult.FinalURL != "" && fetchResult.FinalURL != result.URL {
+		result.RedirectedURL = fetchResult.FinalURL
+	}
+
+	if fetchResult.Error != nil || !fetchResult.Exists {
+		result.Exists = false
+		result.FailureKind = fetchResult.FailureKind
+		if ctx.Err() != nil {
+			notChecked(result)
+		} else if version.IsGBFSRequired(v.options.Version) {
+			result.HasErrors = true
+			result.ErrorsCount = 1
+			result.Errors = []ValidationError{{
+				Severity: SeverityError,
+				Category: CategoryAvailability,
+				Message:  "gbfs.json is required but not found",
+				Keyword:  "required",
+			}}
+		}
+		return result, nil, fmt.Errorf("gbfs.json not found")
+	}
+
+	result.Exists = true
+	result.RawData = fetchResult.Body
+	result.Hash = contentHash(fetchResult.Body)
+	result.Timing = &FileTiming{FetchMs: time.Since(fetchStart).Milliseconds()}
+	validateStart := time.Now()
+	defer func() { result.Timing.ValidateMs = time.Since(validateStart).Milliseconds() }()
+
+	var feed gbfs.GBFSFeed
+	if err := json.Unmarshal(fetchResult.Body, &feed); err != nil {
+		result.HasErrors = true
+		result.ErrorsCount = 1
+		result.Errors = []ValidationError{{
+			Severity: SeverityError,
+			Category: CategorySchema,
+			Message:  fmt.Sprintf("Failed to parse gbfs.json: %v", err),
+			Keyword:  "parse",
+		}}
+		return result, nil, err
+	}
+
+	ver := v.options.Version
+	if ver == "" {
+		ver = feed.Version
+	}
+	schemaErrors, ok := v.validateSchema(fetchResult.Body, "gbfs", ver)
+	if !ok {
+		schemaErrors = withCategory(v.validateGBFSStructure(&feed), CategorySchema)
+	}
+	if len(schemaErrors) > 0 {
+		result.HasErrors = true
+		result.Errors = schemaErrors
+		result.ErrorsCount = len(schemaErrors)
+	}
+	v.checkSize(result)
+
+	return result, &feed, nil
+}
+
+// validateFiles fetches and validates required files.
+func (v *Validator) validateFiles(ctx context.Context, feedURLs map[string]string, requirements []version.FileRequirement, ver string) map[string]*FileValidationResult {
+	results := make(map[string]*FileValidationResult)
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+
+	store := func(name string, result *FileValidationResult) {
+		mu.Lock()
+		defer mu.Unlock()
+		results[name] = result
+		if v.options.OnProgress != nil {
+			v.options.OnProgress(ProgressEvent{
+				File:  result.File,
+				Done:  len(results),
+				Total: len(requirements),
+			})
+		}
+	}
+
+	for _, req := range requirements {
+		req := req
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			result := &FileValidationResult{
+				File:        req.File + ".json",
+				Required:    req.Required,
+				Recommended: req.Recommended,
+			}
+
+			url, exists := feedURLs[req.File]
+			if !exists {
+				v.reportMissing(result, req, "not found in autodiscovery")
+				store(req.File, result)
+				return
+			}
+
+			result.URL = fetcher.RedactURL(url)
+			_, result.Overridden = v.overrides[req.File]
+
+			fetchStart := time.Now()
+			fetchResult := v.fetcher.Fetch(ctx, url)
+			result.Timing = &FileTiming{FetchMs: time.Since(fetchStart).Milliseconds()}
+			result.RequestHeaders = fetchResult.Headers
+			if fetchResult.Error != nil || !fetchResult.Exists {
+				result.Exists = false
+				result.FailureKind = fetchResult.FailureKind
+				if ctx.Err() != nil {
+					notChecked(result)
+				} else if req.Required {
+					result.HasErrors = true
+					result.ErrorsCount = 1
+					result.Errors = []ValidationError{{
+						Severity: SeverityError,
+						Category: CategoryAvailability,
+						Message:  fmt.Sprintf("Required file %s.json could not be fetched: %v", req.File, fetchResult.Error),
+						Keyword:  "fetch",
+					}}
+				}
+				store(req.File, result)
+				return
+			}
+
+			v.checkBody(result, fetchResult.Body, req.File, ver)
+			store(req.File, result)
+		}()
+	}
+
+	wg.Wait()
+	return results
+}
+
+// notChecked marks a file whose fetch the run's deadline cut short. It is
+// neither reported missing nor validated.
+func notChecked(result *FileValidationResult) {
+	result.FailureKind = fetcher.FailureTimeout
+	result.Status = FileStatusNotChecked
+}
@@ -0,0 +1,85 @@
+package validator
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gbfs-validator-go/pkg/version"
+)
+
+// ValidateFile validates one document of a feed, such as a station_status
+// body produced by a build step, without fetching anything. file names the
+// feed file, with or without ".json"; ver is the GBFS version, or empty to
+// use the version the document declares. The schema, timestamp, and ttl
+// checks run, as do semantic checks that read only this file;
+// references to other files are not checked.
+func (v *Validator) ValidateFile(file, ver string, body []byte) (*ValidationResult, error) {
+	name := strings.TrimSuffix(file, ".json")
+	var header struct {
+		Version string `json:"version"`
+	}
+	// Malformed documents are reported by the schema check.
+	json.Unmarshal(body, &header)
+	if ver == "" {
+		ver = header.Version
+	}
+	if ver == "" {
+		return nil, fmt.Errorf("the document declares no version; pass one")
+	}
+	if _, ok := version.GetConfig(ver); !ok {
+		return nil, fmt.Errorf("unsupported GBFS version %s", ver)
+	}
+	if !containsString(version.FeedNames(ver), name) {
+		return nil, fmt.Errorf("%s is not a file of GBFS %s", name, ver)
+	}
+
+	result := &ValidationResult{
+		ResultSchemaVersion: ResultSchemaVersion,
+		ValidatedAt:         validationTime(),
+		Summary: ValidationSummary{
+			ValidatorVersion: "1.0.0",
+			Version:          VersionInfo{Detected: header.Version, Validated: ver},
+			LenientMode:      v.options.LenientMode,
+		},
+	}
+	fr := &FileValidationResult{
+		File:    name + ".json",
+		Exists:  true,
+		RawData: body,
+		Hash:    contentHash(body),
+	}
+
+	validateStart := time.Now()
+	data := v.validateContent(fr, body, name, ver)
+	fr.Errors = append(fr.Errors, checkEncoding(body)...)
+	v.checkSize(fr)
+	if malformed := checkTimestampEncoding(data); malformed != nil {
+		fr.Errors = append(fr.Errors, *malformed)
+	}
+	if ttl := v.checkTTL(data, name); ttl != nil {
+		fr.Errors = append(fr.Errors, *ttl)
+	}
+
+	results := map[string]*FileValidationResult{name: fr}
+	v.checkGeofencingConflicts(results)
+	v.checkDepotClusters(results, ver)
+	v.checkLanguageCoverage(results, ver)
+	v.checkPricing(results)
+	v.checkRentalURIs(results)
+	v.checkStationStructure(results)
+	v.checkStationQuality(results)
+	v.checkCoordinatePrecision(results)
+	v.checkAlerts(results)
+	v.checkSystemID(results)
+	v.checkReturnConstraints(results, ver)
+	fr.Timing = &FileTiming{ValidateMs: time.Since(validateStart).Milliseconds()}
+
+	v.finishFile(fr)
+	result.Files = []FileValidationResult{*fr}
+	suggestFixes(result)
+	linkSpec(result)
+	summarize(result)
+	return result, nil
+}
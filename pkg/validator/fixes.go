@@ -0,0 +1,207 @@
+package validator
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gbfs-validator-go/pkg/version"
+)
+
+// SuggestedFix is a machine-readable correction for a finding: one JSON
+// Patch (RFC 6902) operation on the file that would resolve it.
+type SuggestedFix struct {
+	// Op is "replace" for a wrong value and "add" for a missing one.
+	Op    string      `json:"op"`
+	Path  string      `json:"path"`
+	Value interface{} `json:"value"`
+	// Description explains the fix for people.
+	Description string `json:"description"`
+}
+
+// timestampFields are fields holding a POSIX time before v3.0 and an
+// RFC3339 time from v3.0 on.
+var timestampFields = map[string]bool{
+	"last_updated":  true,
+	"last_reported": true,
+}
+
+// requiredLocalizedFields are localized fields that v3 files must have.
+var requiredLocalizedFields = map[string]bool{
+	"name":        true,
+	"description": true,
+	"summary":     true,
+}
+
+// requiredProperty extracts the name from a "required" finding.
+var requiredProperty = regexp.MustCompile(`required property '([^']+)'`)
+
+// millisecondEpoch is the smallest epoch in milliseconds: anything larger
+// is too far in the future to be in seconds.
+const millisecondEpoch = 1e11
+
+// suggestFixes attaches a fix to findings that match a common failure
+// pattern: booleans and numbers sent as strings, timestamps in the wrong
+// encoding, and plain or missing localized strings in v3 files.
+func suggestFixes(result *ValidationResult) {
+	ver := result.Summary.Version.Validated
+	language := systemLanguage(result.Files)
+	for i := range result.Files {
+		f := &result.Files[i]
+		if len(f.RawData) == 0 || len(f.Errors) == 0 {
+			continue
+		}
+		var doc interface{}
+		if json.Unmarshal(f.RawData, &doc) != nil {
+			continue
+		}
+		for j := range f.Errors {
+			if f.Errors[j].SuggestedFix == nil {
+				f.Errors[j].SuggestedFix = suggestFix(doc, f.Errors[j], ver, language)
+			}
+		}
+	}
+}
+
+// systemLanguage returns the first language of system_information, or ""
+// when it is unknown.
+func systemLanguage(files []FileValidationResult) string {
+	for _, f := range files {
+		if f.File != "system_information.json" || len(f.RawData) == 0 {
+			continue
+		}
+		var system struct {
+			Data struct {
+				Language  string   `json:"language"`
+				Languages []string `json:"languages"`
+			} `json:"data"`
+		}
+		if json.Unmarshal(f.RawData, &system) != nil {
+			continue
+		}
+		if len(system.Data.Languages) > 0 {
+			return system.Data.Languages[0]
+		}
+		return system.Data.Language
+	}
+	return ""
+}
+
+// suggestFix returns the fix for one finding in doc, or nil.
+func suggestFix(doc interface{}, e ValidationError, ver, language string) *SuggestedFix {
+	field := path.Base(e.InstancePath)
+	value := lookupPointer(doc, e.InstancePath)
+	switch e.Keyword {
+	case "type":
+		want := strings.TrimPrefix(e.Message, "must be ")
+		if timestampFields[field] {
+			return timestampFix(e.InstancePath, value, ver)
+		}
+		switch {
+		case strings.Contains(want, "boolean"):
+			if b, ok := booleanValue(value); ok {
+				return &SuggestedFix{Op: "replace", Path: e.InstancePath, Value: b,
+					Description: fmt.Sprintf("use the JSON boolean %v instead of %s", b, jsonText(value))}
+			}
+		case strings.Contains(want, "integer"), strings.Contains(want, "number"):
+			if s, ok := value.(string); ok {
+				if n, err := strconv.ParseFloat(strings.TrimSpace(s), 64); err == nil {
+					return &SuggestedFix{Op: "replace", Path: e.InstancePath, Value: n,
+						Description: fmt.Sprintf("use the JSON number %v instead of the string %q", n, s)}
+				}
+			}
+		case strings.Contains(want, "array"):
+			if s, ok := value.(string); ok && version.IsV3OrLater(ver) && language != "" {
+				return &SuggestedFix{Op: "replace", Path: e.InstancePath, Value: localizedValue(s, language),
+					Description: fmt.Sprintf("%s is localized since v3.0: wrap the text in an array of {text, language}", field)}
+			}
+		}
+	case "format":
+		if timestampFields[field] {
+			return timestampFix(e.InstancePath, value, ver)
+		}
+	case "required":
+		m := requiredProperty.FindStringSubmatch(e.Message)
+		if m == nil || !requiredLocalizedFields[m[1]] || !version.IsV3OrLater(ver) || language == "" {
+			return nil
+		}
+		return &SuggestedFix{Op: "add", Path: strings.TrimSuffix(e.InstancePath, "/") + "/" + m[1], Value: localizedValue("", language),
+			Description: fmt.Sprintf("add %s as an array of {text, language} and fill in the text", m[1])}
+	}
+	return nil
+}
+
+// booleanValue reads the booleans feeds commonly send as strings or
+// numbers.
+func booleanValue(value interface{}) (bool, bool) {
+	switch v := value.(type) {
+	case string:
+		switch strings.ToLower(strings.TrimSpace(v)) {
+		case "1", "true":
+			return true, true
+		case "0", "false":
+			return false, true
+		}
+	case float64:
+		switch v {
+		case 1:
+			return true, true
+		case 0:
+			return false, true
+		}
+	}
+	return false, false
+}
+
+// timestampFix converts a POSIX time in seconds or milliseconds, as a
+// number or a string, to the encoding of version ver.
+func timestampFix(pointer string, value interface{}, ver string) *SuggestedFix {
+	var n float64
+	switch v := value.(type) {
+	case float64:
+		n = v
+	case string:
+		parsed, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		if err != nil {
+			return nil
+		}
+		n = parsed
+	default:
+		return nil
+	}
+	unit := "seconds"
+	if math.Abs(n) >= millisecondEpoch {
+		n /= 1000
+		unit = "milliseconds"
+	}
+	seconds := int64(n)
+	if version.IsV3OrLater(ver) {
+		stamp := time.Unix(seconds, 0).UTC().Format(time.RFC3339)
+		return &SuggestedFix{Op: "replace", Path: pointer, Value: stamp,
+			Description: fmt.Sprintf("encode the POSIX time in %s as an RFC3339 timestamp", unit)}
+	}
+	if v, ok := value.(float64); ok && v == float64(seconds) {
+		return nil
+	}
+	return &SuggestedFix{Op: "replace", Path: pointer, Value: seconds,
+		Description: fmt.Sprintf("encode the POSIX time in %s as whole seconds", unit)}
+}
+
+// localizedValue is a v3 localized string with one translation.
+func localizedValue(text, language string) []map[string]string {
+	return []map[string]string{{"text": text, "language": language}}
+}
+
+// jsonText formats a decoded value as JSON for messages.
+func jsonText(value interface{}) string {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(data)
+}
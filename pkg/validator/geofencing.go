@@ -0,0 +1,205 @@
+package validator
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gbfs-validator-go/pkg/gbfs"
+)
+
+// anyVehicleType stands for vehicle types no rule names explicitly.
+const anyVehicleType = "*"
+
+// zoneFeature is a geofencing zone as needed for conflict detection. Start
+// and end are kept raw since they are POSIX times before v3.0 and RFC 3339
+// strings after.
+type zoneFeature struct {
+	Geometry   gbfs.GeoJSON `json:"geometry"`
+	Properties struct {
+		Start json.RawMessage       `json:"start"`
+		End   json.RawMessage       `json:"end"`
+		Rules []gbfs.GeofencingRule `json:"rules"`
+	} `json:"properties"`
+}
+
+// zone is a parsed geofencing zone.
+type zone struct {
+	index      int
+	shape      gbfs.MultiPolygon
+	start, end time.Time
+	rules      []gbfs.GeofencingRule
+}
+
+// zoneTime parses a zone start or end time; the zero time means unbounded.
+func zoneTime(raw json.RawMessage) time.Time {
+	var s string
+	if json.Unmarshal(raw, &s) == nil {
+		if t, err := time.Parse(time.RFC3339, s); err == nil {
+			return t
+		}
+		if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+			return time.Unix(n, 0)
+		}
+		return time.Time{}
+	}
+	var n int64
+	if json.Unmarshal(raw, &n) == nil {
+		return time.Unix(n, 0)
+	}
+	return time.Time{}
+}
+
+// activeTogether reports whether two zones' time windows intersect.
+func activeTogether(a, b zone) bool {
+	if !a.end.IsZero() && !b.start.IsZero() && !a.end.After(b.start) {
+		return false
+	}
+	if !b.end.IsZero() && !a.start.IsZero() && !b.end.After(a.start) {
+		return false
+	}
+	return true
+}
+
+// ruleFor returns the zone's first rule that applies to a vehicle type.
+func (z zone) ruleFor(vehicleType string) (gbfs.GeofencingRule, bool) {
+	for _, r := range z.rules {
+		if len(r.VehicleTypeIDs) == 0 {
+			return r, true
+		}
+		for _, id := range r.VehicleTypeIDs {
+			if id == vehicleType {
+				return r, true
+			}
+		}
+	}
+	return gbfs.GeofencingRule{}, false
+}
+
+// ruleDifferences describes how two rules contradict each other.
+func ruleDifferences(a, b gbfs.GeofencingRule, ai, bi int) []string {
+	var diffs []string
+	compare := func(field string, x, y bool) {
+		if x != y {
+			diffs = append(diffs, fmt.Sprintf("%s is %t in zone %d and %t in zone %d", field, x, ai, y, bi))
+		}
+	}
+	compare("ride_start_allowed", a.RideStartAllowed, b.RideStartAllowed)
+	compare("ride_end_allowed", a.RideEndAllowed, b.RideEndAllowed)
+	compare("ride_through_allowed", a.RideThroughAllowed, b.RideThroughAllowed)
+	if a.StationParking != nil && b.StationParking != nil {
+		compare("station_parking", *a.StationParking, *b.StationParking)
+	}
+	if a.MaximumSpeedKph != nil && b.MaximumSpeedKph != nil && *a.MaximumSpeedKph != *b.MaximumSpeedKph {
+		diffs = append(diffs, fmt.Sprintf("maximum_speed_kph is %d in zone %d and %d in zone %d", *a.MaximumSpeedKph, ai, *b.MaximumSpeedKph, bi))
+	}
+	return diffs
+}
+
+// zoneVehicleTypes lists the vehicle types named by either zone's rules,
+// followed by anyVehicleType.
+func zoneVehicleTypes(a, b zone) []string {
+	seen := make(map[string]bool)
+	var types []string
+	for _, z := range []zone{a, b} {
+		for _, r := range z.rules {
+			for _, id := range r.VehicleTypeIDs {
+				if !seen[id] {
+					seen[id] = true
+					types = append(types, id)
+				}
+			}
+		}
+	}
+	sort.Strings(types)
+	return append(types, anyVehicleType)
+}
+
+// parseZones reads the zones of geofencing_zones.json, skipping features
+// without a polygon geometry.
+func parseZones(data []byte) []zone {
+	var doc struct {
+		Data struct {
+			GeofencingZones struct {
+				Features []zoneFeature `json:"features"`
+			} `json:"geofencing_zones"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil
+	}
+	var zones []zone
+	for i, f := range doc.Data.GeofencingZones.Features {
+		shape, err := f.Geometry.MultiPolygon()
+		if err != nil || shape.Validate() != nil {
+			continue
+		}
+		zones = append(zones, zone{
+			index: i,
+			shape: shape,
+			start: zoneTime(f.Properties.Start),
+			end:   zoneTime(f.Properties.End),
+			rules: f.Properties.Rules,
+		})
+	}
+	return zones
+}
+
+// checkGeofencingConflicts reports overlapping zones whose rules contradict
+// each other for the same vehicle type. The spec gives the earlier zone
+// precedence, so these are warnings that the published rules are
+// ambiguous to riders and regulators rather than errors.
+func (v *Validator) checkGeofencingConflicts(results map[string]*FileValidationResult) {
+	result, ok := results["geofencing_zones"]
+	if !ok || !result.Exists || result.RawData == nil {
+		return
+	}
+	data := result.RawData
+	if result.CoercedData != nil {
+		data = result.CoercedData
+	}
+
+	zones := parseZones(data)
+	for i := range zones {
+		for j := i + 1; j < len(zones); j++ {
+			a, b := zones[i], zones[j]
+			if len(a.rules) == 0 || len(b.rules) == 0 || !activeTogether(a, b) || !a.shape.Overlaps(b.shape) {
+				continue
+			}
+
+			var conflicts []string
+			for _, vt := range zoneVehicleTypes(a, b) {
+				ra, okA := a.ruleFor(vt)
+				rb, okB := b.ruleFor(vt)
+				if !okA || !okB {
+					continue
+				}
+				diffs := ruleDifferences(ra, rb, a.index, b.index)
+				if len(diffs) == 0 {
+					continue
+				}
+				label := "vehicle type " + vt
+				if vt == anyVehicleType {
+					label = "all vehicle types"
+				}
+				conflicts = append(conflicts, label+": "+strings.Join(diffs, ", "))
+			}
+			if len(conflicts) == 0 {
+				continue
+			}
+
+			result.Errors = append(result.Errors, ValidationError{
+				Severity:     SeverityWarning,
+				Category:     CategorySemantic,
+				InstancePath: fmt.Sprintf("/data/geofencing_zones/features/%d", b.index),
+				Message: fmt.Sprintf("geofencing zones %d and %d overlap with contradictory rules (%s); zone %d takes precedence",
+					a.index, b.index, strings.Join(conflicts, "; "), a.index),
+				Keyword: "geofencingConflict",
+			})
+			result.ErrorsCount = len(result.Errors)
+		}
+	}
+}
@@ -0,0 +1,12 @@
+package validator
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// contentHash returns the hex SHA-256 of a fetched body.
+func contentHash(body []byte) string {
+	sum := sha256.Sum256(body)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,87 @@
+package validator
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/gbfs-validator-go/pkg/gbfs"
+	"github.com/gbfs-validator-go/pkg/version"
+)
+
+// defaultDepotThreshold is the share of vehicles at one exact position that
+// is reported when Options.DepotThreshold is unset.
+const defaultDepotThreshold = 0.2
+
+// minDepotVehicles is the smallest cluster reported, so that a few
+// vehicles parked together in a small fleet are not flagged.
+const minDepotVehicles = 5
+
+// checkDepotClusters warns when many free-floating vehicles report exactly
+// the same coordinates. That usually means vehicles sitting in a depot or
+// warehouse are published as rentable, which misleads trip planners.
+func (v *Validator) checkDepotClusters(results map[string]*FileValidationResult, ver string) {
+	threshold := v.options.DepotThreshold
+	if threshold < 0 {
+		return
+	}
+	if threshold == 0 {
+		threshold = defaultDepotThreshold
+	}
+
+	result, ok := results[version.GetVehicleStatusFileName(ver)]
+	if !ok || !result.Exists || result.RawData == nil {
+		return
+	}
+	data := result.RawData
+	if result.CoercedData != nil {
+		data = result.CoercedData
+	}
+	var status gbfs.VehicleStatus
+	if err := json.Unmarshal(data, &status); err != nil {
+		return
+	}
+	array := "vehicles"
+	if len(status.Data.Vehicles) == 0 {
+		array = "bikes"
+	}
+
+	type cluster struct {
+		lat, lon float64
+		first    int
+		count    int
+	}
+	clusters := make(map[[2]float64]*cluster)
+	located := 0
+	for i, vehicle := range status.Data.GetVehicles() {
+		if vehicle.StationID != "" || (vehicle.Lat == 0 && vehicle.Lon == 0) {
+			continue
+		}
+		located++
+		key := [2]float64{vehicle.Lat, vehicle.Lon}
+		if c, ok := clusters[key]; ok {
+			c.count++
+			continue
+		}
+		clusters[key] = &cluster{lat: vehicle.Lat, lon: vehicle.Lon, first: i, count: 1}
+	}
+
+	var flagged []*cluster
+	for _, c := range clusters {
+		if c.count >= minDepotVehicles && float64(c.count) >= threshold*float64(located) {
+			flagged = append(flagged, c)
+		}
+	}
+	sort.Slice(flagged, func(i, j int) bool { return flagged[i].first < flagged[j].first })
+	for _, c := range flagged {
+		result.Errors = append(result.Errors, ValidationError{
+			Severity:     SeverityWarning,
+			Category:     CategorySemantic,
+			InstancePath: fmt.Sprintf("/data/%s/%d", array, c.first),
+			Message: fmt.Sprintf("%d of %d vehicles (%.0f%%) report the same position %g,%g; vehicles parked at a depot should not be published",
+				c.count, located, 100*float64(c.count)/float64(located), c.lat, c.lon),
+			Keyword: "depotCluster",
+		})
+		result.ErrorsCount = len(result.Errors)
+	}
+}
@@ -0,0 +1,151 @@
+//go:build !(js && wasm)
+
+package validator
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gbfs-validator-go/pkg/fetcher"
+)
+
+// defaultImageMaxBytes is the image size limit used when
+// Options.ImageMaxBytes is unset.
+const defaultImageMaxBytes = 2 << 20
+
+// imageProbeParallelism bounds concurrent image requests per run.
+const imageProbeParallelism = 4
+
+// imageFields are the fields that hold image URLs shown to riders.
+var imageFields = map[string]bool{
+	"brand_image_url":      true,
+	"brand_image_url_dark": true,
+	"vehicle_image":        true,
+	"icon_url":             true,
+	"icon_url_dark":        true,
+}
+
+// imageRef is an image URL and the first place it appears.
+type imageRef struct {
+	file string
+	path string
+	url  string
+	uses int
+}
+
+// checkImages probes image URLs when Options.CheckImages is set and warns
+// about images that are unreachable, not served as images, or larger than
+// Options.ImageMaxBytes. Each URL is requested once per run; an offline
+// validator skips the check.
+func (v *Validator) checkImages(ctx context.Context, results map[string]*FileValidationResult) {
+	if !v.options.CheckImages || v.fetcher == nil || ctx.Err() != nil {
+		return
+	}
+	maxBytes := v.options.ImageMaxBytes
+	if maxBytes <= 0 {
+		maxBytes = defaultImageMaxBytes
+	}
+
+	names := make([]string, 0, len(results))
+	for name := range results {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	refs := make(map[string]*imageRef)
+	var order []*imageRef
+	for _, name := range names {
+		result := results[name]
+		if !result.Exists || result.RawData == nil {
+			continue
+		}
+		var doc struct {
+			Data interface{} `json:"data"`
+		}
+		if err := json.Unmarshal(result.RawData, &doc); err != nil {
+			continue
+		}
+		walkImageURLs(doc.Data, "/data", func(path, url string) {
+			if ref, ok := refs[url]; ok {
+				ref.uses++
+				return
+			}
+			ref := &imageRef{file: name, path: path, url: url, uses: 1}
+			refs[url] = ref
+			order = append(order, ref)
+		})
+	}
+
+	probes := make([]*fetcher.ProbeResult, len(order))
+	sem := make(chan struct{}, imageProbeParallelism)
+	var wg sync.WaitGroup
+	for i, ref := range order {
+		wg.Add(1)
+		go func(i int, url string) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			probes[i] = v.fetcher.Probe(ctx, url)
+		}(i, ref.url)
+	}
+	wg.Wait()
+
+	for i, ref := range order {
+		probe := probes[i]
+		var problem string
+		switch {
+		case probe.Error != nil && ctx.Err() != nil:
+			continue
+		case probe.Error != nil:
+			problem = fmt.Sprintf("image %s is unreachable: %v", probe.URL, probe.Error)
+		case !strings.HasPrefix(strings.ToLower(probe.ContentType), "image/"):
+			problem = fmt.Sprintf("image %s is served as %q, not image/*", probe.URL, probe.ContentType)
+		case probe.ContentLength > maxBytes:
+			problem = fmt.Sprintf("image %s is %d bytes, over the %d byte limit", probe.URL, probe.ContentLength, maxBytes)
+		default:
+			continue
+		}
+		if ref.uses > 1 {
+			problem = fmt.Sprintf("%s (used %d times)", problem, ref.uses)
+		}
+		result := results[ref.file]
+		result.Errors = append(result.Errors, ValidationError{
+			Severity:     SeverityWarning,
+			Category:     CategorySemantic,
+			InstancePath: ref.path,
+			Message:      problem,
+			Keyword:      "imageUrl",
+		})
+		result.ErrorsCount = len(result.Errors)
+	}
+}
+
+// walkImageURLs calls fn with the path and value of every image URL field
+// under node.
+func walkImageURLs(node interface{}, path string, fn func(path, url string)) {
+	switch n := node.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(n))
+		for k := range n {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			if s, ok := n[k].(string); ok && imageFields[k] {
+				if s != "" {
+					fn(path+"/"+k, s)
+				}
+				continue
+			}
+			walkImageURLs(n[k], path+"/"+k, fn)
+		}
+	case []interface{}:
+		for i, item := range n {
+			walkImageURLs(item, path+"/"+strconv.Itoa(i), fn)
+		}
+	}
+}
@@ -0,0 +1,164 @@
+package validator
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/gbfs-validator-go/pkg/gbfs"
+	"github.com/gbfs-validator-go/pkg/version"
+)
+
+// selectLanguages resolves Options.Languages against the feed's language
+// blocks. v3 feeds have a single unnamed block, returned as "".
+func (v *Validator) selectLanguages(feed *gbfs.GBFSFeed) ([]string, []ValidationError) {
+	available := feed.Data.LanguageCodes()
+	if len(available) == 0 {
+		return []string{""}, nil
+	}
+
+	requested := v.options.Languages
+	if len(requested) == 0 {
+		return available[:1], nil
+	}
+	if len(requested) == 1 && requested[0] == "all" {
+		return available, nil
+	}
+
+	var selected []string
+	var errors []ValidationError
+	for _, lang := range requested {
+		if _, ok := feed.Data.Languages[lang]; !ok {
+			errors = append(errors, ValidationError{
+				Severity:     SeverityError,
+				Category:     CategoryAvailability,
+				Message:      fmt.Sprintf("language '%s' not found in gbfs.json (available: %v)", lang, available),
+				InstancePath: "/data/" + lang,
+				Keyword:      "required",
+			})
+			continue
+		}
+		selected = append(selected, lang)
+	}
+
+	if len(selected) == 0 {
+		selected = available[:1]
+	}
+	return selected, errors
+}
+
+// languageComparison names the ID field compared across language blocks.
+type languageComparison struct {
+	file    string
+	arrays  []string
+	idField []string
+}
+
+// languageComparisons lists files whose entities must match across languages.
+func languageComparisons(ver string) []languageComparison {
+	return []languageComparison{
+		{file: "station_information", arrays: []string{"stations"}, idField: []string{"station_id"}},
+		{file: "station_status", arrays: []string{"stations"}, idField: []string{"station_id"}},
+		{file: version.GetVehicleStatusFileName(ver), arrays: []string{"vehicles", "bikes"}, idField: []string{"vehicle_id", "bike_id"}},
+	}
+}
+
+// compareLanguages checks that every language block publishes the same
+// stations and vehicles as the first block.
+func (v *Validator) compareLanguages(languages []string, byLanguage map[string]map[string]*FileValidationResult, ver string) {
+	primary := languages[0]
+
+	for _, cmp := range languageComparisons(ver) {
+		base, ok := byLanguage[primary][cmp.file]
+		if !ok || !base.Exists {
+			continue
+		}
+		baseIDs := entityIDs(base.RawData, cmp.arrays, cmp.idField)
+
+		for _, lang := range languages[1:] {
+			other, ok := byLanguage[lang][cmp.file]
+			if !ok || !other.Exists {
+				continue
+			}
+			otherIDs := entityIDs(other.RawData, cmp.arrays, cmp.idField)
+
+			missing := difference(baseIDs, otherIDs)
+			extra := difference(otherIDs, baseIDs)
+			if len(baseIDs) == len(otherIDs) && len(missing) == 0 && len(extra) == 0 {
+				continue
+			}
+
+			other.Errors = append(other.Errors, ValidationError{
+				Severity: SeverityError,
+				Category: CategoryCrossReference,
+				Message: fmt.Sprintf("%s.json in language '%s' has %d entries vs %d in '%s' (%d missing, %d extra)%s",
+					cmp.file, lang, len(otherIDs), len(baseIDs), primary, len(missing), len(extra), sampleIDs(missing, extra)),
+				Keyword: "languageConsistency",
+			})
+			other.ErrorsCount = len(other.Errors)
+			other.HasErrors = true
+		}
+	}
+}
+
+// entityIDs reads IDs from the first present array in data.
+func entityIDs(raw []byte, arrays, idFields []string) []string {
+	var doc struct {
+		Data map[string]json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		return nil
+	}
+
+	var ids []string
+	for _, name := range arrays {
+		rawEntries, ok := doc.Data[name]
+		if !ok {
+			continue
+		}
+		var entries []map[string]interface{}
+		if err := json.Unmarshal(rawEntries, &entries); err != nil {
+			return nil
+		}
+		for _, e := range entries {
+			for _, field := range idFields {
+				if id, ok := e[field].(string); ok {
+					ids = append(ids, id)
+					break
+				}
+			}
+		}
+		break
+	}
+	return ids
+}
+
+// difference returns IDs in a that are absent from b, sorted.
+func difference(a, b []string) []string {
+	inB := make(map[string]bool, len(b))
+	for _, id := range b {
+		inB[id] = true
+	}
+
+	var out []string
+	for _, id := range a {
+		if !inB[id] {
+			out = append(out, id)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+// sampleIDs formats a few example IDs for a consistency message.
+func sampleIDs(missing, extra []string) string {
+	const limit = 3
+	out := ""
+	if len(missing) > 0 {
+		out += fmt.Sprintf("; missing e.g. %v", missing[:min(limit, len(missing))])
+	}
+	if len(extra) > 0 {
+		out += fmt.Sprintf("; extra e.g. %v", extra[:min(limit, len(extra))])
+	}
+	return out
+}
@@ -0,0 +1,157 @@
+package validator
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gbfs-validator-go/pkg/version"
+)
+
+// arrayIndex matches the array indexes of an instance path.
+var arrayIndex = regexp.MustCompile(`/\d+(/|$)`)
+
+// localizedGap collects the entries of one localized field that lack some
+// of the system's languages.
+type localizedGap struct {
+	first   string
+	total   int
+	missing map[string]int
+}
+
+// checkLanguageCoverage warns when a v3 localized field has no text in a
+// language listed in system_information.languages. Gaps are reported once
+// per file and field, with counts, so large files stay readable.
+func (v *Validator) checkLanguageCoverage(results map[string]*FileValidationResult, ver string) {
+	if !version.IsV3OrLater(ver) {
+		return
+	}
+	info, ok := results["system_information"]
+	if !ok || !info.Exists || info.RawData == nil {
+		return
+	}
+	var system struct {
+		Data struct {
+			Languages []string `json:"languages"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(info.RawData, &system); err != nil || len(system.Data.Languages) == 0 {
+		return
+	}
+
+	names := make([]string, 0, len(results))
+	for name := range results {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		result := results[name]
+		if !result.Exists || result.RawData == nil {
+			continue
+		}
+		var doc interface{}
+		if err := json.Unmarshal(result.RawData, &doc); err != nil {
+			continue
+		}
+		obj, ok := doc.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		data, ok := obj["data"]
+		if !ok {
+			continue
+		}
+
+		gaps := make(map[string]*localizedGap)
+		var order []string
+		walkLocalized(data, "/data", func(path string, languages map[string]bool) {
+			field := arrayIndex.ReplaceAllString(path, "/*$1")
+			gap, ok := gaps[field]
+			if !ok {
+				gap = &localizedGap{missing: make(map[string]int)}
+				gaps[field] = gap
+				order = append(order, field)
+			}
+			gap.total++
+			for _, lang := range system.Data.Languages {
+				if !languages[strings.ToLower(lang)] {
+					if len(gap.missing) == 0 {
+						gap.first = path
+					}
+					gap.missing[lang]++
+				}
+			}
+		})
+
+		for _, field := range order {
+			gap := gaps[field]
+			if len(gap.missing) == 0 {
+				continue
+			}
+			var parts []string
+			for _, lang := range system.Data.Languages {
+				if n := gap.missing[lang]; n > 0 {
+					parts = append(parts, fmt.Sprintf("%s in %d of %d", lang, n, gap.total))
+				}
+			}
+			result.Errors = append(result.Errors, ValidationError{
+				Severity:     SeverityWarning,
+				Category:     CategorySemantic,
+				InstancePath: gap.first,
+				Message:      fmt.Sprintf("%s lacks translations listed in system_information.languages: %s", field, strings.Join(parts, ", ")),
+				Keyword:      "languageCoverage",
+			})
+			result.ErrorsCount = len(result.Errors)
+		}
+	}
+}
+
+// walkLocalized calls fn with the path and lower-cased languages of every
+// localized string array under node. A localized string array holds
+// objects with text and language fields.
+func walkLocalized(node interface{}, path string, fn func(path string, languages map[string]bool)) {
+	switch n := node.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(n))
+		for k := range n {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			walkLocalized(n[k], path+"/"+k, fn)
+		}
+	case []interface{}:
+		if languages, ok := localizedLanguages(n); ok {
+			fn(path, languages)
+			return
+		}
+		for i, item := range n {
+			walkLocalized(item, path+"/"+strconv.Itoa(i), fn)
+		}
+	}
+}
+
+// localizedLanguages returns the languages of a localized string array, or
+// false if the array is not one.
+func localizedLanguages(items []interface{}) (map[string]bool, bool) {
+	if len(items) == 0 {
+		return nil, false
+	}
+	languages := make(map[string]bool, len(items))
+	for _, item := range items {
+		obj, ok := item.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		_, hasText := obj["text"].(string)
+		lang, hasLanguage := obj["language"].(string)
+		if !hasText || !hasLanguage {
+			return nil, false
+		}
+		languages[strings.ToLower(lang)] = true
+	}
+	return languages, true
+}
@@ -0,0 +1,22 @@
+//go:build js && wasm
+
+package validator
+
+import "context"
+
+// FailureKind classifies why a file could not be fetched. Builds without
+// net/http never fetch, so it stays empty.
+type FailureKind string
+
+// network is empty: this build validates only the documents it is given.
+type network struct{}
+
+// checkImages does nothing, as image URLs cannot be probed.
+func (v *Validator) checkImages(ctx context.Context, results map[string]*FileValidationResult) {}
+
+// checkAlertURLs does nothing, as alert URLs cannot be probed.
+func (v *Validator) checkAlertURLs(ctx context.Context, results map[string]*FileValidationResult) {}
+
+// checkVersionConsistency does nothing, as other versions cannot be fetched.
+func (v *Validator) checkVersionConsistency(ctx context.Context, results map[string]*FileValidationResult, ver, lang, gbfsURL string) {
+}
@@ -0,0 +1,46 @@
+package validator
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/gbfs-validator-go/pkg/version"
+)
+
+// orderedFiles returns file results in the order the spec lists the files
+// for ver, followed by any others by name, so that results are stable
+// between runs.
+func orderedFiles(results map[string]*FileValidationResult, ver string) []*FileValidationResult {
+	rank := make(map[string]int)
+	for i, name := range version.FeedNames(ver) {
+		rank[name] = i
+	}
+	names := make([]string, 0, len(results))
+	for name := range results {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		a, aKnown := rank[strings.TrimSuffix(names[i], ".json")]
+		b, bKnown := rank[strings.TrimSuffix(names[j], ".json")]
+		switch {
+		case aKnown && bKnown:
+			return a < b
+		case aKnown != bKnown:
+			return aKnown
+		}
+		return names[i] < names[j]
+	})
+	out := make([]*FileValidationResult, len(names))
+	for i, name := range names {
+		out[i] = results[name]
+	}
+	return out
+}
+
+// sortFindings orders findings by instance path. Findings on the same path
+// keep the order in which they were reported.
+func sortFindings(errs []ValidationError) {
+	sort.SliceStable(errs, func(i, j int) bool {
+		return errs[i].InstancePath < errs[j].InstancePath
+	})
+}
@@ -0,0 +1,142 @@
+package validator
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Default bounds on the decimal places of a coordinate. Four places locate
+// a point to about 11 meters, enough to find a vehicle; eight reach about a
+// millimeter, beyond what any GPS measures.
+const (
+	defaultMinCoordinateDecimals = 4
+	defaultMaxCoordinateDecimals = 8
+)
+
+// coordinateDecimals returns the decimal-place bounds of Options, where 0
+// selects the default and a negative value disables the bound.
+func coordinateDecimals(opts Options) (fewest, most int) {
+	fewest, most = opts.MinCoordinateDecimals, opts.MaxCoordinateDecimals
+	if fewest == 0 {
+		fewest = defaultMinCoordinateDecimals
+	}
+	if most == 0 {
+		most = defaultMaxCoordinateDecimals
+	}
+	return fewest, most
+}
+
+// checkCoordinatePrecision warns about lat and lon fields published with
+// fewer decimal places than Options.MinCoordinateDecimals, too coarse to
+// find a vehicle or station, or more than Options.MaxCoordinateDecimals,
+// which is false precision that enlarges the payload. Decimals are counted
+// in the published text. Serializers drop trailing zeros, so a point is
+// only too coarse when both its lat and lon are; zero, a common
+// placeholder, is ignored. Each bound is reported once per file, at the
+// first coordinate that breaks it.
+func (v *Validator) checkCoordinatePrecision(results map[string]*FileValidationResult) {
+	fewest, most := coordinateDecimals(v.options)
+	names := make([]string, 0, len(results))
+	for name := range results {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		result := results[name]
+		if !result.Exists || result.RawData == nil {
+			continue
+		}
+		type group struct {
+			first string
+			count int
+		}
+		var coarse, fine group
+		add := func(g *group, path string) {
+			if g.count == 0 {
+				g.first = path
+			}
+			g.count++
+		}
+		// A point is the object holding a lat and lon; its coarse axes are
+		// reported once every axis is known to be coarse.
+		var point string
+		var coarseAxes []string
+		precise := false
+		flush := func() {
+			if !precise {
+				for _, path := range coarseAxes {
+					add(&coarse, path)
+				}
+			}
+			coarseAxes, precise = nil, false
+		}
+		walkValues(result.RawData, func(path string, raw []byte, tok json.Token) {
+			slash := strings.LastIndexByte(path, '/')
+			if axis := path[slash+1:]; axis != "lat" && axis != "lon" {
+				return
+			}
+			if parent := path[:slash]; parent != point {
+				flush()
+				point = parent
+			}
+			decimals, ok := coordinateDecimalPlaces(tok)
+			if !ok {
+				return
+			}
+			if fewest > 0 && decimals < fewest {
+				coarseAxes = append(coarseAxes, path)
+			} else {
+				precise = true
+			}
+			if most > 0 && decimals > most {
+				add(&fine, path)
+			}
+		})
+		flush()
+
+		report := func(g group, message string) {
+			if g.count == 0 {
+				return
+			}
+			if g.count > 1 {
+				message = fmt.Sprintf("%s (%d coordinates)", message, g.count)
+			}
+			result.Errors = append(result.Errors, ValidationError{
+				Severity:     SeverityWarning,
+				Category:     CategorySemantic,
+				InstancePath: g.first,
+				Message:      message,
+				Keyword:      "precision",
+			})
+			result.ErrorsCount = len(result.Errors)
+		}
+		report(coarse, fmt.Sprintf("coordinate has fewer than %d decimal places, too coarse to find a vehicle or station", fewest))
+		report(fine, fmt.Sprintf("coordinate has more than %d decimal places; the extra digits are false precision that enlarges the payload", most))
+	}
+}
+
+// coordinateDecimalPlaces returns the decimal places of a coordinate as
+// published, a number or a numeric string. It reports false for zero and
+// for values in exponent notation.
+func coordinateDecimalPlaces(tok json.Token) (int, bool) {
+	var text string
+	switch tok := tok.(type) {
+	case json.Number:
+		text = tok.String()
+	case string:
+		text = strings.TrimSpace(tok)
+	default:
+		return 0, false
+	}
+	if f, err := strconv.ParseFloat(text, 64); err != nil || f == 0 || strings.ContainsAny(text, "eE") {
+		return 0, false
+	}
+	if i := strings.IndexByte(text, '.'); i >= 0 {
+		return len(text) - i - 1, true
+	}
+	return 0, true
+}
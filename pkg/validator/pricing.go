@@ -0,0 +1,145 @@
+package validator
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// currencyExponents lists ISO 4217 currencies whose minor unit is not
+// hundredths. Other currencies are assumed to have two decimals.
+var currencyExponents = map[string]int{
+	"BIF": 0, "CLP": 0, "DJF": 0, "GNF": 0, "ISK": 0, "JPY": 0, "KMF": 0, "KRW": 0,
+	"PYG": 0, "RWF": 0, "UGX": 0, "UYI": 0, "VND": 0, "VUV": 0, "XAF": 0, "XOF": 0, "XPF": 0,
+	"BHD": 3, "IQD": 3, "JOD": 3, "KWD": 3, "LYD": 3, "OMR": 3, "TND": 3,
+	"CLF": 4, "UYW": 4,
+}
+
+// currencyExponent returns the number of decimals of a currency.
+func currencyExponent(currency string) int {
+	if e, ok := currencyExponents[currency]; ok {
+		return e
+	}
+	return 2
+}
+
+// hasSubunitFraction reports whether amount has more decimals than the
+// currency's minor unit.
+func hasSubunitFraction(amount float64, currency string) bool {
+	scaled := amount * math.Pow10(currencyExponent(currency))
+	return math.Abs(scaled-math.Round(scaled)) > 1e-6
+}
+
+// pricingNumber reads a price that is a number or, before v2.0, a string.
+func pricingNumber(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case string:
+		f, err := strconv.ParseFloat(n, 64)
+		return f, err == nil
+	}
+	return 0, false
+}
+
+// checkPricing checks that pricing plans agree on a currency, that amounts
+// are expressible in the currency's minor unit, and that reservation prices
+// and rates are not negative. Options.Currencies lists currencies an
+// operator documents as used together.
+func (v *Validator) checkPricing(results map[string]*FileValidationResult) {
+	result, ok := results["system_pricing_plans"]
+	if !ok || !result.Exists || result.RawData == nil {
+		return
+	}
+	data := result.RawData
+	if result.CoercedData != nil {
+		data = result.CoercedData
+	}
+	var doc struct {
+		Data struct {
+			Plans []map[string]interface{} `json:"plans"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return
+	}
+
+	add := func(severity ValidationSeverity, path, keyword, message string) {
+		result.Errors = append(result.Errors, ValidationError{
+			Severity:     severity,
+			Category:     CategorySemantic,
+			InstancePath: path,
+			Message:      message,
+			Keyword:      keyword,
+		})
+		result.ErrorsCount = len(result.Errors)
+		if severity == SeverityError {
+			result.HasErrors = true
+		}
+	}
+
+	allowed := make(map[string]bool)
+	for _, c := range v.options.Currencies {
+		allowed[strings.ToUpper(c)] = true
+	}
+	used := make(map[string]bool)
+	for i, plan := range doc.Data.Plans {
+		base := fmt.Sprintf("/data/plans/%d", i)
+		currency, _ := plan["currency"].(string)
+		if currency == "" {
+			continue
+		}
+		used[currency] = true
+		if len(allowed) > 0 && !allowed[strings.ToUpper(currency)] {
+			add(SeverityWarning, base+"/currency", "currency",
+				fmt.Sprintf("currency %s is not among the documented currencies %s", currency, strings.Join(v.options.Currencies, ", ")))
+		}
+
+		amounts := []string{"price", "reservation_price_flat_rate", "reservation_price_per_min"}
+		for _, field := range amounts {
+			amount, ok := pricingNumber(plan[field])
+			if !ok {
+				continue
+			}
+			if amount < 0 && field != "price" {
+				add(SeverityError, base+"/"+field, "minimum", fmt.Sprintf("%s must not be negative", field))
+			}
+			if hasSubunitFraction(amount, currency) {
+				add(SeverityWarning, base+"/"+field, "currencyPrecision",
+					fmt.Sprintf("%s %v has more decimals than %s allows (%d)", field, amount, currency, currencyExponent(currency)))
+			}
+		}
+		for _, field := range []string{"per_min_pricing", "per_km_pricing"} {
+			segments, _ := plan[field].([]interface{})
+			for j, s := range segments {
+				segment, _ := s.(map[string]interface{})
+				rate, ok := pricingNumber(segment["rate"])
+				if !ok {
+					continue
+				}
+				path := fmt.Sprintf("%s/%s/%d/rate", base, field, j)
+				if rate < 0 {
+					add(SeverityWarning, path, "minimum",
+						fmt.Sprintf("%s rate %v is negative, which riders see as a discount; check that this is intended", field, rate))
+				}
+				if hasSubunitFraction(rate, currency) {
+					add(SeverityWarning, path, "currencyPrecision",
+						fmt.Sprintf("%s rate %v has more decimals than %s allows (%d)", field, rate, currency, currencyExponent(currency)))
+				}
+			}
+		}
+	}
+
+	if len(used) > 1 && len(allowed) == 0 {
+		currencies := make([]string, 0, len(used))
+		for c := range used {
+			currencies = append(currencies, c)
+		}
+		sort.Strings(currencies)
+		add(SeverityWarning, "/data/plans", "currency",
+			fmt.Sprintf("pricing plans use several currencies (%s); document them if this is intended", strings.Join(currencies, ", ")))
+	}
+}
@@ -0,0 +1,91 @@
+package validator
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Profile groups severity settings applied during a validation run.
+type Profile struct {
+	Name                       string             `json:"name"`
+	MissingRecommendedSeverity ValidationSeverity `json:"missingRecommendedSeverity"`
+	// TTLRanges maps file names, without .json, to the ttl recommended for
+	// them. Files without an entry are not checked.
+	TTLRanges map[string]TTLRange `json:"ttlRanges,omitempty"`
+}
+
+// TTLRange bounds a recommended ttl, in seconds. A zero Max is unbounded.
+type TTLRange struct {
+	Min int `json:"min"`
+	Max int `json:"max,omitempty"`
+}
+
+// realtimeFiles change as vehicles move; clients poll them every ttl.
+var realtimeFiles = []string{"station_status", "free_bike_status", "vehicle_status", "system_alerts"}
+
+// staticFiles change rarely; a short ttl only makes clients refetch them.
+var staticFiles = []string{"system_information", "station_information", "vehicle_types", "system_regions",
+	"system_pricing_plans", "system_hours", "system_calendar", "geofencing_zones"}
+
+// ttlRanges assigns one range to the realtime files and another to the
+// static files.
+func ttlRanges(realtime, static TTLRange) map[string]TTLRange {
+	ranges := make(map[string]TTLRange, len(realtimeFiles)+len(staticFiles))
+	for _, file := range realtimeFiles {
+		ranges[file] = realtime
+	}
+	for _, file := range staticFiles {
+		ranges[file] = static
+	}
+	return ranges
+}
+
+// Profiles lists the built-in validation profiles.
+var Profiles = map[string]Profile{
+	"default": {
+		Name:                       "default",
+		MissingRecommendedSeverity: SeverityWarning,
+		TTLRanges:                  ttlRanges(TTLRange{Max: 300}, TTLRange{Min: 60, Max: 86400}),
+	},
+	"strict": {
+		Name:                       "strict",
+		MissingRecommendedSeverity: SeverityError,
+		TTLRanges:                  ttlRanges(TTLRange{Max: 60}, TTLRange{Min: 300, Max: 86400}),
+	},
+	"relaxed": {
+		Name:                       "relaxed",
+		MissingRecommendedSeverity: SeverityInfo,
+		TTLRanges:                  ttlRanges(TTLRange{Max: 3600}, TTLRange{Max: 7 * 86400}),
+	},
+}
+
+// GetProfile returns a profile by name; an empty name selects "default".
+// For an unknown name it returns the default profile and false.
+func GetProfile(name string) (Profile, bool) {
+	if name == "" {
+		name = "default"
+	}
+	if p, ok := Profiles[name]; ok {
+		return p, true
+	}
+	return Profiles["default"], false
+}
+
+// CheckProfile reports an unknown profile name.
+func CheckProfile(name string) error {
+	if _, ok := GetProfile(name); !ok {
+		return fmt.Errorf("unknown profile %q; want one of %s", name, strings.Join(ProfileNames(), ", "))
+	}
+	return nil
+}
+
+// ProfileNames lists built-in profile names, sorted.
+func ProfileNames() []string {
+	names := make([]string, 0, len(Profiles))
+	for name := range Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,112 @@
+package validator
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/gbfs-validator-go/pkg/gbfs"
+)
+
+// minDuplicateStations is how many stations must share a name before it is
+// reported; two stations at either end of a street often share one.
+const minDuplicateStations = 3
+
+// maxDuplicateNames caps the duplicated names reported per file.
+const maxDuplicateNames = 10
+
+// internalCode matches names that are only digits, such as "0412".
+var internalCode = regexp.MustCompile(`^[0-9]+$`)
+
+// checkStationQuality warns, when Options.DataQuality is set, about station
+// names and addresses that are valid but hard to use: names that are empty,
+// shared by many stations, or internal codes, and post codes without an
+// address. Trip planners show these fields to riders as they are.
+func (v *Validator) checkStationQuality(results map[string]*FileValidationResult) {
+	if !v.options.DataQuality {
+		return
+	}
+	result, ok := results["station_information"]
+	if !ok || !result.Exists || result.RawData == nil {
+		return
+	}
+	data := result.RawData
+	if result.CoercedData != nil {
+		data = result.CoercedData
+	}
+	var info gbfs.StationInformation
+	if err := json.Unmarshal(data, &info); err != nil {
+		return
+	}
+
+	report := func(first, count int, field, message string) {
+		if count > 1 {
+			message = fmt.Sprintf("%s (%d stations)", message, count)
+		}
+		result.Errors = append(result.Errors, ValidationError{
+			Severity:     SeverityWarning,
+			Category:     CategorySemantic,
+			InstancePath: fmt.Sprintf("/data/stations/%d/%s", first, field),
+			Message:      message,
+			Keyword:      "stationQuality",
+		})
+		result.ErrorsCount = len(result.Errors)
+	}
+
+	type group struct{ first, count int }
+	empty, codes, addresses := group{first: -1}, group{first: -1}, group{first: -1}
+	add := func(g *group, i int) {
+		if g.first < 0 {
+			g.first = i
+		}
+		g.count++
+	}
+	names := make(map[string]*group)
+	var order []string
+	for i, station := range info.Data.Stations {
+		name := strings.TrimSpace(station.Name.Default())
+		switch {
+		case name == "":
+			add(&empty, i)
+		case internalCode.MatchString(name):
+			add(&codes, i)
+		}
+		if name != "" {
+			key := strings.ToLower(name)
+			if names[key] == nil {
+				names[key] = &group{first: -1}
+				order = append(order, key)
+			}
+			add(names[key], i)
+		}
+		if station.PostCode != "" && strings.TrimSpace(station.Address) == "" {
+			add(&addresses, i)
+		}
+	}
+
+	if empty.count > 0 {
+		report(empty.first, empty.count, "name", "station name is empty; riders cannot tell the station apart")
+	}
+	if codes.count > 0 {
+		report(codes.first, codes.count, "name", "station name is only digits and looks like an internal code; use the name riders see on site")
+	}
+	sort.SliceStable(order, func(i, j int) bool { return names[order[i]].first < names[order[j]].first })
+	reported := 0
+	for _, key := range order {
+		g := names[key]
+		if g.count < minDuplicateStations || internalCode.MatchString(key) {
+			continue
+		}
+		if reported == maxDuplicateNames {
+			break
+		}
+		reported++
+		report(g.first, 1, "name", fmt.Sprintf("%d stations are named %q; add a distinguishing detail such as the street", g.count,
+			info.Data.Stations[g.first].Name.Default()))
+	}
+	if addresses.count > 0 {
+		report(addresses.first, addresses.count, "address", "post_code is set but address is missing; publish the street address riders need to find the station")
+	}
+}
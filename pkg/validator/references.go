@@ -0,0 +1,126 @@
+package validator
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/gbfs-validator-go/pkg/gbfs"
+	"github.com/gbfs-validator-go/pkg/version"
+)
+
+// referencedFile is an optional file that a feed must publish once another
+// file references it by ID.
+type referencedFile struct {
+	// check is the ID of the check, and the key of
+	// Options.ReferencedFileSeverity.
+	check    string
+	file     string
+	field    string
+	severity ValidationSeverity
+	// referrer returns the file that sets field, or "" when none does.
+	referrer func(results map[string]*FileValidationResult, ver string) string
+}
+
+// referencedFiles are the optional files data can reference. The spec makes
+// vehicle_types and system_pricing_plans conditionally required; regions
+// are only recommended.
+var referencedFiles = []referencedFile{
+	{check: "conditionalVehicleTypes", file: "vehicle_types", field: "vehicle_type_id", severity: SeverityError,
+		referrer: vehiclesSet(func(v gbfs.Vehicle) bool { return v.VehicleTypeID != "" })},
+	{check: "conditionalPricingPlans", file: "system_pricing_plans", field: "pricing_plan_id", severity: SeverityError,
+		referrer: vehiclesSet(func(v gbfs.Vehicle) bool { return v.PricingPlanID != "" })},
+	{check: "conditionalRegions", file: "system_regions", field: "region_id", severity: SeverityWarning,
+		referrer: stationsSetRegion},
+}
+
+// referencedFileSeverity returns the severity of a referenced-file check,
+// as overridden by Options.ReferencedFileSeverity.
+func referencedFileSeverity(opts Options, check string) ValidationSeverity {
+	if s, ok := opts.ReferencedFileSeverity[check]; ok && s != "" {
+		return s
+	}
+	for _, rf := range referencedFiles {
+		if rf.check == check {
+			return rf.severity
+		}
+	}
+	return SeverityError
+}
+
+// checkReferencedFiles reports optional files that are referenced by ID but
+// absent. A file missing at error severity becomes required.
+func (v *Validator) checkReferencedFiles(results map[string]*FileValidationResult, ver string) {
+	for _, rf := range referencedFiles {
+		referrer := rf.referrer(results, ver)
+		if referrer == "" {
+			continue
+		}
+		target, ok := results[rf.file]
+		if ok && target.Exists {
+			continue
+		}
+		if target == nil {
+			target = &FileValidationResult{File: rf.file + ".json"}
+			results[rf.file] = target
+		}
+
+		severity := referencedFileSeverity(v.options, rf.check)
+		if severity == SeverityError {
+			target.Required = true
+		}
+		target.Errors = append(target.Errors, ValidationError{
+			Severity: severity,
+			Category: CategoryCrossReference,
+			Message:  fmt.Sprintf("%s.json is required when %s is used in %s.json", rf.file, rf.field, referrer),
+			Keyword:  "dependentRequired",
+		})
+		v.finishFile(target)
+	}
+}
+
+// vehiclesSet returns a referrer reporting the vehicle status file when a
+// vehicle matches set.
+func vehiclesSet(set func(gbfs.Vehicle) bool) func(map[string]*FileValidationResult, string) string {
+	return func(results map[string]*FileValidationResult, ver string) string {
+		fileName := version.GetVehicleStatusFileName(ver)
+		vsResult, ok := results[fileName]
+		if !ok || !vsResult.Exists || vsResult.RawData == nil {
+			return ""
+		}
+		var vs gbfs.VehicleStatus
+		if err := json.Unmarshal(vsResult.RawData, &vs); err != nil {
+			return ""
+		}
+		for _, vehicle := range vs.Data.GetVehicles() {
+			if set(vehicle) {
+				return fileName
+			}
+		}
+		return ""
+	}
+}
+
+// stationsSetRegion reports station_information when a station sets
+// region_id.
+func stationsSetRegion(results map[string]*FileValidationResult, ver string) string {
+	siResult, ok := results["station_information"]
+	if !ok || !siResult.Exists || siResult.RawData == nil {
+		return ""
+	}
+	var si struct {
+		Data struct {
+			Stations []struct {
+				RegionID string `json:"region_id"`
+			} `json:"stations"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(siResult.RawData, &si); err != nil {
+		return ""
+	}
+	for _, station := range si.Data.Stations {
+		if station.RegionID != "" {
+			return "station_information"
+		}
+	}
+	return ""
+}
@@ -0,0 +1,179 @@
+package validator
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// uriFinding collects one kind of rental URI problem across the entries of
+// a file, so a problem shared by every vehicle is reported once.
+type uriFinding struct {
+	severity ValidationSeverity
+	keyword  string
+	message  string
+	first    string
+	count    int
+}
+
+// checkRentalURIs checks the deep links riders follow to rent: rental_uris
+// of stations and vehicles and rental_apps of system_information. Android
+// links should be App Links (https) or intent:// URIs naming a package, iOS
+// links should be Universal Links, and both platforms should be listed.
+func (v *Validator) checkRentalURIs(results map[string]*FileValidationResult) {
+	names := make([]string, 0, len(results))
+	for name := range results {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		result := results[name]
+		if !result.Exists || result.RawData == nil {
+			continue
+		}
+		var doc struct {
+			Data interface{} `json:"data"`
+		}
+		if err := json.Unmarshal(result.RawData, &doc); err != nil || doc.Data == nil {
+			continue
+		}
+
+		findings := make(map[string]*uriFinding)
+		var order []string
+		report := func(path string, severity ValidationSeverity, keyword, message string) {
+			key := arrayIndex.ReplaceAllString(path, "/*$1") + "\x00" + message
+			f, ok := findings[key]
+			if !ok {
+				f = &uriFinding{severity: severity, keyword: keyword, message: message, first: path}
+				findings[key] = f
+				order = append(order, key)
+			}
+			f.count++
+		}
+		walkRentalURIs(doc.Data, "/data", report)
+
+		for _, key := range order {
+			f := findings[key]
+			message := f.message
+			if f.count > 1 {
+				message = fmt.Sprintf("%s (%d entries)", message, f.count)
+			}
+			result.Errors = append(result.Errors, ValidationError{
+				Severity:     f.severity,
+				Category:     CategorySemantic,
+				InstancePath: f.first,
+				Message:      message,
+				Keyword:      f.keyword,
+			})
+			result.ErrorsCount = len(result.Errors)
+			if f.severity == SeverityError {
+				result.HasErrors = true
+			}
+		}
+	}
+}
+
+// walkRentalURIs calls report for every problem in the rental_uris and
+// rental_apps objects under node.
+func walkRentalURIs(node interface{}, path string, report func(path string, severity ValidationSeverity, keyword, message string)) {
+	switch n := node.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(n))
+		for k := range n {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			child, ok := n[k].(map[string]interface{})
+			switch {
+			case k == "rental_uris" && ok:
+				checkRentalURIObject(child, path+"/"+k, report)
+			case k == "rental_apps" && ok:
+				checkRentalApps(child, path+"/"+k, report)
+			default:
+				walkRentalURIs(n[k], path+"/"+k, report)
+			}
+		}
+	case []interface{}:
+		for i, item := range n {
+			walkRentalURIs(item, path+"/"+strconv.Itoa(i), report)
+		}
+	}
+}
+
+// checkRentalURIObject checks one rental_uris object.
+func checkRentalURIObject(uris map[string]interface{}, path string, report func(string, ValidationSeverity, string, string)) {
+	android, hasAndroid := uris["android"].(string)
+	ios, hasIOS := uris["ios"].(string)
+
+	if hasAndroid {
+		if u, ok := parseRentalURI(android, path+"/android", report); ok {
+			switch {
+			case u.Scheme == "intent":
+				if !strings.Contains(u.Fragment, "package=") {
+					report(path+"/android", SeverityWarning, "rentalUri",
+						"android intent:// URI names no package; add ;package=<app id>; to the #Intent fragment")
+				}
+			case u.Scheme != "https":
+				report(path+"/android", SeverityWarning, "rentalUri",
+					fmt.Sprintf("android URI uses the %s scheme; use an https App Link or an intent:// URI so riders without the app are not stranded", u.Scheme))
+			}
+		}
+	}
+	if hasIOS {
+		if u, ok := parseRentalURI(ios, path+"/ios", report); ok && u.Scheme != "https" {
+			report(path+"/ios", SeverityWarning, "rentalUri",
+				fmt.Sprintf("ios URI uses the %s scheme; use an https Universal Link so riders without the app are not stranded", u.Scheme))
+		}
+	}
+	if hasAndroid != hasIOS {
+		missing := "ios"
+		if hasIOS {
+			missing = "android"
+		}
+		report(path, SeverityWarning, "rentalUriPlatform",
+			fmt.Sprintf("rental_uris has no %s link; riders on that platform cannot deep link to a rental", missing))
+	}
+}
+
+// checkRentalApps checks the store and discovery URIs of rental_apps.
+func checkRentalApps(apps map[string]interface{}, path string, report func(string, ValidationSeverity, string, string)) {
+	for _, platform := range []string{"android", "ios"} {
+		app, ok := apps[platform].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		for _, field := range []string{"store_uri", "discovery_uri"} {
+			if s, ok := app[field].(string); ok {
+				parseRentalURI(s, path+"/"+platform+"/"+field, report)
+			}
+		}
+	}
+	_, hasAndroid := apps["android"].(map[string]interface{})
+	_, hasIOS := apps["ios"].(map[string]interface{})
+	if hasAndroid != hasIOS {
+		missing := "ios"
+		if hasIOS {
+			missing = "android"
+		}
+		report(path, SeverityWarning, "rentalUriPlatform",
+			fmt.Sprintf("rental_apps has no %s app", missing))
+	}
+}
+
+// parseRentalURI parses an absolute URI, reporting it when it does not.
+func parseRentalURI(s, path string, report func(string, ValidationSeverity, string, string)) (*url.URL, bool) {
+	u, err := url.Parse(s)
+	if err != nil || u.Scheme == "" {
+		report(path, SeverityError, "format", fmt.Sprintf("%q is not an absolute URI", s))
+		return nil, false
+	}
+	if (u.Scheme == "https" || u.Scheme == "http") && u.Host == "" {
+		report(path, SeverityError, "format", fmt.Sprintf("%q has no host", s))
+		return nil, false
+	}
+	return u, true
+}
@@ -0,0 +1,48 @@
+package validator
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// ResultSchemaVersion is the version of the ValidationResult JSON format.
+//
+// Adding fields, file statuses, or keywords keeps the version, so
+// consumers should ignore what they do not know. Removing or renaming a
+// field, or changing what one means, bumps it, and UpgradeResult learns to
+// read the previous version.
+//
+// Versions:
+//
+//	1: unversioned results. errorsCount counted findings of every
+//	   severity, and the summary left out gbfs.json findings.
+//	2: errorsCount, warningsCount, and infosCount count findings by
+//	   severity in every file and the summary; hasErrors follows them.
+const ResultSchemaVersion = 2
+
+// UpgradeResult decodes a stored ValidationResult of any earlier format
+// version and converts it to the current one.
+func UpgradeResult(data []byte) (*ValidationResult, error) {
+	var result ValidationResult
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, err
+	}
+	switch {
+	case result.ResultSchemaVersion > ResultSchemaVersion:
+		return nil, fmt.Errorf("result schema version %d is newer than the supported %d", result.ResultSchemaVersion, ResultSchemaVersion)
+	case result.ResultSchemaVersion <= 1:
+		upgradeV1(&result)
+	}
+	result.ResultSchemaVersion = ResultSchemaVersion
+	return &result, nil
+}
+
+// upgradeV1 recounts the findings of a version 1 result by severity.
+// Version 1 had no strict mode, so only errors fail a file.
+func upgradeV1(result *ValidationResult) {
+	v := &Validator{}
+	for i := range result.Files {
+		v.finishFile(&result.Files[i])
+	}
+	summarize(result)
+}
@@ -0,0 +1,278 @@
+package validator
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/gbfs-validator-go/pkg/version"
+)
+
+// RuleKind selects what a rule checks.
+type RuleKind string
+
+const (
+	// RuleFile requires File to be published.
+	RuleFile RuleKind = "file"
+	// RuleField requires every item of File to set Field.
+	RuleField RuleKind = "field"
+	// RuleValues requires Field, where set, to be one of Values.
+	RuleValues RuleKind = "values"
+	// RuleMaxTTL requires File's ttl to be at most Max seconds.
+	RuleMaxTTL RuleKind = "max_ttl"
+)
+
+// maxRuleExamples is how many failing locations a rule result lists.
+const maxRuleExamples = 5
+
+// RulePack is a set of requirements a city or regulator places on
+// operators beyond the GBFS specification. Packs are evaluated after
+// validation and reported separately from specification issues.
+type RulePack struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Rules       []Rule `json:"rules"`
+}
+
+// Rule is one requirement of a rule pack.
+type Rule struct {
+	ID          string   `json:"id"`
+	Description string   `json:"description,omitempty"`
+	Kind        RuleKind `json:"kind"`
+	// File names the feed file, e.g. "vehicle_types". "vehicle_status" and
+	// "free_bike_status" both match the file of the validated version.
+	File string `json:"file"`
+	// Items is a JSON pointer to the array of items to check, e.g.
+	// "/data/vehicle_types". It defaults to the file's main list.
+	Items string `json:"items,omitempty"`
+	// Field is a dot-separated path within each item, e.g.
+	// "rental_uris.web".
+	Field  string   `json:"field,omitempty"`
+	Values []string `json:"values,omitempty"`
+	Max    int      `json:"max,omitempty"`
+	// Severity is reported for a failing rule; error by default.
+	Severity ValidationSeverity `json:"severity,omitempty"`
+}
+
+// RulePackResult reports how a feed fared against a rule pack.
+type RulePackResult struct {
+	Name   string       `json:"name"`
+	Passed bool         `json:"passed"`
+	Rules  []RuleResult `json:"rules"`
+}
+
+// RuleResult reports one rule. Examples lists up to five failing
+// locations.
+type RuleResult struct {
+	ID          string             `json:"id"`
+	Description string             `json:"description,omitempty"`
+	Severity    ValidationSeverity `json:"severity"`
+	Passed      bool               `json:"passed"`
+	Message     string             `json:"message,omitempty"`
+	Failures    int                `json:"failures,omitempty"`
+	Examples    []string           `json:"examples,omitempty"`
+}
+
+// defaultItems is the main list of each file.
+var defaultItems = map[string]string{
+	"system_information":   "/data",
+	"station_information":  "/data/stations",
+	"station_status":       "/data/stations",
+	"vehicle_status":       "/data/vehicles",
+	"free_bike_status":     "/data/bikes",
+	"vehicle_types":        "/data/vehicle_types",
+	"system_pricing_plans": "/data/plans",
+	"system_regions":       "/data/regions",
+	"system_alerts":        "/data/alerts",
+	"geofencing_zones":     "/data/geofencing_zones/features",
+}
+
+// LoadRulePack reads a rule pack from a JSON file and checks its rules.
+func LoadRulePack(path string) (RulePack, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return RulePack{}, err
+	}
+	var pack RulePack
+	if err := json.Unmarshal(data, &pack); err != nil {
+		return RulePack{}, fmt.Errorf("%s: %w", path, err)
+	}
+	if err := pack.Validate(); err != nil {
+		return RulePack{}, fmt.Errorf("%s: %w", path, err)
+	}
+	return pack, nil
+}
+
+// Validate checks every rule names a file and has the settings its kind
+// needs.
+func (p RulePack) Validate() error {
+	if p.Name == "" {
+		return fmt.Errorf("rule pack has no name")
+	}
+	for i, r := range p.Rules {
+		if r.ID == "" {
+			return fmt.Errorf("rule %d has no id", i)
+		}
+		if r.File == "" {
+			return fmt.Errorf("rule %s has no file", r.ID)
+		}
+		switch r.Kind {
+		case RuleFile, RuleMaxTTL:
+		case RuleField:
+			if r.Field == "" {
+				return fmt.Errorf("rule %s needs a field", r.ID)
+			}
+		case RuleValues:
+			if r.Field == "" || len(r.Values) == 0 {
+				return fmt.Errorf("rule %s needs a field and values", r.ID)
+			}
+		default:
+			return fmt.Errorf("rule %s has unknown kind %q", r.ID, r.Kind)
+		}
+	}
+	return nil
+}
+
+// evaluateRulePacks checks the validated files against each rule pack.
+func (v *Validator) evaluateRulePacks(results map[string]*FileValidationResult, ver string) []RulePackResult {
+	var out []RulePackResult
+	for _, pack := range v.options.RulePacks {
+		pr := RulePackResult{Name: pack.Name, Passed: true}
+		for _, rule := range pack.Rules {
+			rr := evaluateRule(rule, results, ver)
+			if !rr.Passed && rr.Severity == SeverityError {
+				pr.Passed = false
+			}
+			pr.Rules = append(pr.Rules, rr)
+		}
+		out = append(out, pr)
+	}
+	return out
+}
+
+// evaluateRule checks one rule.
+func evaluateRule(rule Rule, results map[string]*FileValidationResult, ver string) RuleResult {
+	rr := RuleResult{ID: rule.ID, Description: rule.Description, Severity: rule.Severity, Passed: true}
+	if rr.Severity == "" {
+		rr.Severity = SeverityError
+	}
+
+	file := rule.File
+	if file == "vehicle_status" || file == "free_bike_status" {
+		file = version.GetVehicleStatusFileName(ver)
+	}
+	result, ok := results[file]
+	if !ok || !result.Exists || result.RawData == nil {
+		rr.Passed = false
+		rr.Message = file + ".json is not published"
+		return rr
+	}
+	if rule.Kind == RuleFile {
+		return rr
+	}
+
+	var doc interface{}
+	if err := json.Unmarshal(result.RawData, &doc); err != nil {
+		rr.Passed = false
+		rr.Message = file + ".json is not valid JSON"
+		return rr
+	}
+
+	if rule.Kind == RuleMaxTTL {
+		ttl, _ := lookupPointer(doc, "/ttl").(float64)
+		if int(ttl) > rule.Max {
+			rr.Passed = false
+			rr.Message = fmt.Sprintf("ttl is %ds, more than %ds", int(ttl), rule.Max)
+		}
+		return rr
+	}
+
+	pointer := rule.Items
+	if pointer == "" {
+		pointer = defaultItems[file]
+	}
+	// A single object, such as system_information's data, is checked as a
+	// list of one.
+	var items []interface{}
+	single := false
+	switch list := lookupPointer(doc, pointer).(type) {
+	case []interface{}:
+		items = list
+	case map[string]interface{}:
+		items = []interface{}{list}
+		single = true
+	default:
+		rr.Passed = false
+		rr.Message = fmt.Sprintf("%s.json has no items at %s", file, pointer)
+		return rr
+	}
+
+	fieldPath := "/" + strings.ReplaceAll(rule.Field, ".", "/")
+	for i, item := range items {
+		location := pointer + "/" + strconv.Itoa(i) + fieldPath
+		if single {
+			location = pointer + fieldPath
+		}
+		value := lookupPointer(item, fieldPath)
+		var failed bool
+		switch rule.Kind {
+		case RuleField:
+			failed = value == nil || value == ""
+		case RuleValues:
+			failed = value != nil && !containsString(rule.Values, fmt.Sprint(value))
+		}
+		if !failed {
+			continue
+		}
+		rr.Failures++
+		if len(rr.Examples) < maxRuleExamples {
+			rr.Examples = append(rr.Examples, location)
+		}
+	}
+	if rr.Failures > 0 {
+		rr.Passed = false
+		switch rule.Kind {
+		case RuleField:
+			rr.Message = fmt.Sprintf("%d of %d items in %s.json lack %s", rr.Failures, len(items), file, rule.Field)
+		case RuleValues:
+			rr.Message = fmt.Sprintf("%d of %d items in %s.json have %s outside %s", rr.Failures, len(items), file, rule.Field, strings.Join(rule.Values, ", "))
+		}
+	}
+	return rr
+}
+
+// lookupPointer resolves a JSON pointer in a decoded document, returning
+// nil when it does not exist.
+func lookupPointer(doc interface{}, pointer string) interface{} {
+	for _, token := range strings.Split(strings.Trim(pointer, "/"), "/") {
+		if token == "" {
+			continue
+		}
+		token = strings.NewReplacer("~1", "/", "~0", "~").Replace(token)
+		switch node := doc.(type) {
+		case map[string]interface{}:
+			doc = node[token]
+		case []interface{}:
+			i, err := strconv.Atoi(token)
+			if err != nil || i < 0 || i >= len(node) {
+				return nil
+			}
+			doc = node[i]
+		default:
+			return nil
+		}
+	}
+	return doc
+}
+
+// containsString reports whether s is in list.
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,126 @@
+package validator
+
+import (
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"math/rand/v2"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// SampledArray records an array of a file of which only some entries were
+// checked.
+type SampledArray struct {
+	// Path is the JSON pointer of the array, e.g. "/data/stations".
+	Path    string `json:"path"`
+	Total   int    `json:"total"`
+	Checked int    `json:"checked"`
+
+	// indices maps positions in the sample to positions in the file.
+	indices []int
+}
+
+// sample cuts the arrays under data, and the features of geofencing_zones,
+// to Options.SampleSize entries: the first ones, or a random choice when
+// Options.SampleRandom is set. The choice depends on the content only, so
+// reruns of an unchanged file check the same entries. It returns body
+// unchanged when nothing is cut.
+func (v *Validator) sample(result *FileValidationResult, body []byte) []byte {
+	size := v.options.SampleSize
+	if size <= 0 {
+		return body
+	}
+	var doc map[string]json.RawMessage
+	var data map[string]json.RawMessage
+	if json.Unmarshal(body, &doc) != nil || json.Unmarshal(doc["data"], &data) != nil {
+		return body
+	}
+
+	h := fnv.New64a()
+	h.Write(body)
+	rng := rand.New(rand.NewPCG(h.Sum64(), 0))
+	cut := func(path string, raw json.RawMessage) json.RawMessage {
+		var entries []json.RawMessage
+		if json.Unmarshal(raw, &entries) != nil || len(entries) <= size {
+			return raw
+		}
+		indices := make([]int, size)
+		for i := range indices {
+			indices[i] = i
+		}
+		if v.options.SampleRandom {
+			indices = rng.Perm(len(entries))[:size]
+			sort.Ints(indices)
+		}
+		kept := make([]json.RawMessage, size)
+		for i, index := range indices {
+			kept[i] = entries[index]
+		}
+		out, err := json.Marshal(kept)
+		if err != nil {
+			return raw
+		}
+		result.Sampled = append(result.Sampled, SampledArray{Path: path, Total: len(entries), Checked: size, indices: indices})
+		return out
+	}
+
+	names := make([]string, 0, len(data))
+	for name := range data {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if name == "geofencing_zones" {
+			var zones map[string]json.RawMessage
+			if json.Unmarshal(data[name], &zones) == nil && zones["features"] != nil {
+				zones["features"] = cut("/data/geofencing_zones/features", zones["features"])
+				if out, err := json.Marshal(zones); err == nil {
+					data[name] = out
+				}
+			}
+			continue
+		}
+		data[name] = cut("/data/"+name, data[name])
+	}
+	if len(result.Sampled) == 0 {
+		return body
+	}
+
+	out, err := json.Marshal(data)
+	if err != nil {
+		result.Sampled = nil
+		return body
+	}
+	doc["data"] = out
+	sampled, err := json.Marshal(doc)
+	if err != nil {
+		result.Sampled = nil
+		return body
+	}
+	return sampled
+}
+
+// unsample rewrites the instance paths of findings in a sampled array to
+// the positions of the entries in the file.
+func unsample(errs []ValidationError, sampled []SampledArray) {
+	for i := range errs {
+		for _, s := range sampled {
+			rest, ok := strings.CutPrefix(errs[i].InstancePath, s.Path+"/")
+			if !ok {
+				continue
+			}
+			index, tail, _ := strings.Cut(rest, "/")
+			n, err := strconv.Atoi(index)
+			if err != nil || n < 0 || n >= len(s.indices) {
+				continue
+			}
+			errs[i].InstancePath = fmt.Sprintf("%s/%d", s.Path, s.indices[n])
+			if tail != "" {
+				errs[i].InstancePath += "/" + tail
+			}
+			break
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package validator
+
+import (
+	"embed"
+	"io/fs"
+	"sync"
+
+	"github.com/gbfs-validator-go/pkg/schema"
+)
+
+//go:embed schemas
+var embeddedSchemas embed.FS
+
+var (
+	defaultBundleOnce sync.Once
+	defaultBundle     *schema.Bundle
+)
+
+// DefaultSchemas returns the schema bundle embedded in the binary.
+func DefaultSchemas() *schema.Bundle {
+	defaultBundleOnce.Do(func() {
+		sub, err := fs.Sub(embeddedSchemas, "schemas")
+		if err != nil {
+			panic(err)
+		}
+		b, err := schema.LoadFS(sub, "embedded")
+		if err != nil {
+			panic(err)
+		}
+		defaultBundle = b
+	})
+	return defaultBundle
+}
+
+// validateSchema checks data against the bundled schema for ver and file.
+// It reports false when the bundle has no schema for that combination.
+func (v *Validator) validateSchema(data []byte, file, ver string) ([]ValidationError, bool) {
+	s, err := v.schemas.Schema(ver, file)
+	if err != nil {
+		return []ValidationError{{
+			Severity: SeverityError,
+			Category: CategorySchema,
+			Message:  err.Error(),
+			Keyword:  "schema",
+		}}, true
+	}
+	if s == nil {
+		return nil, false
+	}
+
+	schemaErrs, err := s.ValidateJSON(data)
+	if err != nil {
+		return []ValidationError{{
+			Severity: SeverityError,
+			Category: CategorySchema,
+			Message:  "Invalid JSON: " + err.Error(),
+			Keyword:  "parse",
+		}}, true
+	}
+
+	errors := make([]ValidationError, 0, len(schemaErrs))
+	for _, e := range schemaErrs {
+		errors = append(errors, ValidationError{
+			Severity:     SeverityError,
+			Category:     CategorySchema,
+			Message:      e.Message,
+			InstancePath: e.InstancePath,
+			SchemaPath:   e.SchemaPath,
+			Keyword:      e.Keyword,
+		})
+	}
+	return errors, true
+}
@@ -0,0 +1,66 @@
+package validator
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"strings"
+)
+
+// DefaultSizeBudgets are the size budgets used for files
+// Options.SizeBudgets does not set. Clients fetch gbfs.json first and the
+// realtime files every ttl, often over mobile networks.
+var DefaultSizeBudgets = map[string]int64{
+	"gbfs":             100 << 10,
+	"station_status":   5 << 20,
+	"free_bike_status": 5 << 20,
+	"vehicle_status":   5 << 20,
+}
+
+// sizeBudget returns the budget of a file, named without .json, or 0 when
+// it has none.
+func sizeBudget(opts Options, file string) int64 {
+	for _, budgets := range []map[string]int64{opts.SizeBudgets, DefaultSizeBudgets} {
+		if budget, ok := budgets[file]; ok {
+			return max(budget, 0)
+		}
+	}
+	return max(opts.SizeBudgets["*"], 0)
+}
+
+// checkSize warns when a file is larger than its size budget, giving the
+// size it would have gzipped so operators can tell whether compression
+// alone brings it back under.
+func (v *Validator) checkSize(result *FileValidationResult) {
+	budget := sizeBudget(v.options, strings.TrimSuffix(result.File, ".json"))
+	size := int64(len(result.RawData))
+	if budget == 0 || size <= budget {
+		return
+	}
+
+	message := fmt.Sprintf("%s is %s, over its %s budget", result.File, formatBytes(size), formatBytes(budget))
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(result.RawData); err == nil && zw.Close() == nil {
+		message += fmt.Sprintf("; gzipped it is %s (%.0f%% of the original)",
+			formatBytes(int64(buf.Len())), 100*float64(buf.Len())/float64(size))
+	}
+	result.Errors = append(result.Errors, ValidationError{
+		Severity: SeverityWarning,
+		Category: CategoryAvailability,
+		Message:  message,
+		Keyword:  "size",
+	})
+	result.ErrorsCount = len(result.Errors)
+}
+
+// formatBytes formats a size in bytes, KiB, or MiB.
+func formatBytes(n int64) string {
+	switch {
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1f MiB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.1f KiB", float64(n)/(1<<10))
+	}
+	return fmt.Sprintf("%d bytes", n)
+}
@@ -0,0 +1,66 @@
+package validator
+
+import (
+	"fmt"
+	"strings"
+)
+
+// specDocURL is the GBFS specification document at a version tag.
+const specDocURL = "https://github.com/MobilityData/gbfs/blob/v%s/gbfs.md"
+
+// SpecReference points to the section of the GBFS specification behind a
+// finding.
+type SpecReference struct {
+	// URL links to the section in the spec of the validated version.
+	URL string `json:"url"`
+	// Section is the anchor of the section within the spec document.
+	Section string `json:"section"`
+}
+
+// outputFormatFields are top-level fields defined under the spec's Output
+// Format section rather than under each file.
+var outputFormatFields = map[string]bool{
+	"/last_updated": true,
+	"/ttl":          true,
+	"/version":      true,
+}
+
+// specAnchor returns the spec section anchor for a finding in file.
+func specAnchor(file string, e ValidationError) string {
+	switch {
+	case outputFormatFields[e.InstancePath]:
+		return "output-format"
+	case e.Keyword == "parse":
+		return "output-format"
+	case file == "":
+		return "files"
+	}
+	// GitHub anchors drop the dot of headings such as "station_status.json".
+	name := strings.TrimSuffix(file, ".json")
+	return name + "json"
+}
+
+// specReference links a finding in file to the spec of version ver.
+func specReference(ver, file string, e ValidationError) *SpecReference {
+	if ver == "" {
+		return nil
+	}
+	section := specAnchor(file, e)
+	return &SpecReference{
+		URL:     fmt.Sprintf(specDocURL, ver) + "#" + section,
+		Section: section,
+	}
+}
+
+// linkSpec attaches a spec reference to every finding without one.
+func linkSpec(result *ValidationResult) {
+	ver := result.Summary.Version.Validated
+	for i := range result.Files {
+		f := &result.Files[i]
+		for j := range f.Errors {
+			if f.Errors[j].Spec == nil {
+				f.Errors[j].Spec = specReference(ver, f.File, f.Errors[j])
+			}
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package validator
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/gbfs-validator-go/pkg/gbfs"
+)
+
+// stationRule is a consistency rule between station_information fields.
+type stationRule struct {
+	severity ValidationSeverity
+	field    string
+	message  string
+	applies  func(s gbfs.Station, virtual bool) bool
+}
+
+// stationRules relate is_virtual_station to the fields that describe a
+// station's infrastructure. Virtual stations are areas without physical
+// docks, so they are described by station_area and vehicle_types_capacity.
+var stationRules = []stationRule{
+	{
+		severity: SeverityWarning,
+		field:    "is_virtual_station",
+		message:  "virtual stations should publish station_area, capacity, or vehicle_types_capacity so riders know where and how many vehicles may be parked",
+		applies: func(s gbfs.Station, virtual bool) bool {
+			return virtual && s.StationArea == nil && s.Capacity == nil && len(s.VehicleTypesCapacity) == 0
+		},
+	},
+	{
+		severity: SeverityWarning,
+		field:    "station_area",
+		message:  "station_area describes virtual stations; set is_virtual_station to true or remove station_area",
+		applies: func(s gbfs.Station, virtual bool) bool {
+			return !virtual && s.StationArea != nil
+		},
+	},
+	{
+		severity: SeverityWarning,
+		field:    "vehicle_docks_capacity",
+		message:  "virtual stations have no docks; use vehicle_types_capacity instead of vehicle_docks_capacity",
+		applies: func(s gbfs.Station, virtual bool) bool {
+			return virtual && len(s.VehicleDocksCapacity) > 0
+		},
+	},
+	{
+		severity: SeverityWarning,
+		field:    "parking_hoop",
+		message:  "parking hoops are physical infrastructure, but is_virtual_station is true",
+		applies: func(s gbfs.Station, virtual bool) bool {
+			return virtual && s.ParkingHoop != nil && *s.ParkingHoop
+		},
+	},
+	{
+		severity: SeverityInfo,
+		field:    "parking_hoop",
+		message:  "parking_hoop is set without parking_type; add parking_type so the kind of parking is known",
+		applies: func(s gbfs.Station, virtual bool) bool {
+			return s.ParkingHoop != nil && s.ParkingType == ""
+		},
+	},
+}
+
+// checkStationStructure applies stationRules to station_information. Each
+// rule is reported once with the number of stations it affects.
+func (v *Validator) checkStationStructure(results map[string]*FileValidationResult) {
+	result, ok := results["station_information"]
+	if !ok || !result.Exists || result.RawData == nil {
+		return
+	}
+	data := result.RawData
+	if result.CoercedData != nil {
+		data = result.CoercedData
+	}
+	var info gbfs.StationInformation
+	if err := json.Unmarshal(data, &info); err != nil {
+		return
+	}
+
+	for _, rule := range stationRules {
+		first, count := -1, 0
+		for i, station := range info.Data.Stations {
+			virtual := station.IsVirtualStation != nil && *station.IsVirtualStation
+			if rule.applies(station, virtual) {
+				if first < 0 {
+					first = i
+				}
+				count++
+			}
+		}
+		if count == 0 {
+			continue
+		}
+		message := rule.message
+		if count > 1 {
+			message = fmt.Sprintf("%s (%d stations)", message, count)
+		}
+		result.Errors = append(result.Errors, ValidationError{
+			Severity:     rule.severity,
+			Category:     CategorySemantic,
+			InstancePath: fmt.Sprintf("/data/stations/%d/%s", first, rule.field),
+			Message:      message,
+			Keyword:      "stationStructure",
+		})
+		result.ErrorsCount = len(result.Errors)
+	}
+}
@@ -0,0 +1,82 @@
+package validator
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+
+	"github.com/gbfs-validator-go/pkg/gbfs"
+)
+
+// Catalog looks up the system_id a feed catalog registers for an
+// auto-discovery URL; catalog.Catalog reads the Mobility Database one.
+type Catalog interface {
+	SystemID(gbfsURL string) (string, bool)
+}
+
+// systemIDChars matches a system_id of the characters the spec recommends
+// for IDs.
+var systemIDChars = regexp.MustCompile(`^[A-Za-z0-9._-]+$`)
+
+// systemID returns the system_id system_information declares, or "".
+func systemID(result *FileValidationResult) string {
+	if result == nil || !result.Exists || result.RawData == nil {
+		return ""
+	}
+	var info gbfs.SystemInformation
+	if err := json.Unmarshal(result.RawData, &info); err != nil {
+		return ""
+	}
+	return info.Data.SystemID
+}
+
+// checkSystemID reports a system_id with spaces or other characters
+// consumers cannot use in URLs and file names, and, when Options.Previous
+// is set, a system_id that changed since that run: consumers key a system
+// by it for its whole life.
+func (v *Validator) checkSystemID(results map[string]*FileValidationResult) {
+	result := results["system_information"]
+	id := systemID(result)
+	if id == "" {
+		return
+	}
+	report := func(severity ValidationSeverity, message string) {
+		result.Errors = append(result.Errors, ValidationError{
+			Severity:     severity,
+			Category:     CategorySemantic,
+			InstancePath: "/data/system_id",
+			Message:      message,
+			Keyword:      "systemId",
+		})
+		result.ErrorsCount = len(result.Errors)
+	}
+
+	if !systemIDChars.MatchString(id) {
+		report(SeverityWarning, fmt.Sprintf("system_id %q has characters other than letters, digits, '.', '-' and '_'; consumers use it in URLs and file names", id))
+	}
+	if prev := v.options.Previous; prev != nil && prev.Summary.SystemID != "" && prev.Summary.SystemID != id {
+		report(SeverityError, fmt.Sprintf("system_id changed from %q to %q since the previous run; it must stay the same for the life of the system", prev.Summary.SystemID, id))
+	}
+}
+
+// checkCatalog warns, when Options.Catalog is set, about a system_id that
+// differs from the one the catalog registers for gbfsURL.
+func (v *Validator) checkCatalog(results map[string]*FileValidationResult, gbfsURL string) {
+	if v.options.Catalog == nil {
+		return
+	}
+	result := results["system_information"]
+	id := systemID(result)
+	registered, ok := v.options.Catalog.SystemID(gbfsURL)
+	if id == "" || !ok || registered == id {
+		return
+	}
+	result.Errors = append(result.Errors, ValidationError{
+		Severity:     SeverityWarning,
+		Category:     CategoryCrossReference,
+		InstancePath: "/data/system_id",
+		Message:      fmt.Sprintf("system_id is %q but the feed catalog registers this feed as %q", id, registered),
+		Keyword:      "systemId",
+	})
+	result.ErrorsCount = len(result.Errors)
+}
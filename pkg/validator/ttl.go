@@ -0,0 +1,44 @@
+package validator
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// checkTTL reports a ttl outside the range the profile recommends for the
+// file, named without .json: a short ttl on a file that changes rarely
+// makes clients refetch it needlessly, and a long ttl on a realtime file
+// lets them show outdated availability.
+func (v *Validator) checkTTL(data []byte, file string) *ValidationError {
+	bounds, ok := v.profile.TTLRanges[file]
+	if !ok {
+		return nil
+	}
+	var header struct {
+		TTL *int `json:"ttl"`
+	}
+	// A missing ttl is reported by the schema check.
+	if err := json.Unmarshal(data, &header); err != nil || header.TTL == nil || *header.TTL < 0 {
+		return nil
+	}
+
+	ttl := *header.TTL
+	var message string
+	switch {
+	case ttl < bounds.Min && ttl == 0:
+		message = fmt.Sprintf("ttl is 0 but %s.json changes rarely; set at least %ds so clients can cache it", file, bounds.Min)
+	case ttl < bounds.Min:
+		message = fmt.Sprintf("ttl is %ds, shorter than the %ds recommended for %s.json, which changes rarely", ttl, bounds.Min, file)
+	case bounds.Max > 0 && ttl > bounds.Max:
+		message = fmt.Sprintf("ttl is %ds, longer than the %ds recommended for %s.json; clients may show outdated data", ttl, bounds.Max, file)
+	default:
+		return nil
+	}
+	return &ValidationError{
+		Severity:     SeverityWarning,
+		Category:     CategoryFreshness,
+		Message:      message,
+		InstancePath: "/ttl",
+		Keyword:      "ttl",
+	}
+}
@@ -0,0 +1,129 @@
+package validator
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/gbfs-validator-go/pkg/version"
+)
+
+// ValidateDocuments validates a set of feed files held in memory, such as
+// files uploaded from a browser, without fetching them. docs maps file
+// names, with or without ".json", to their content. The files are checked
+// as a feed set: missing required files are errors and cross-file checks
+// run. An uploaded gbfs.json is schema-checked, but the URLs it lists are
+// not followed.
+func (v *Validator) ValidateDocuments(ctx context.Context, docs map[string][]byte) (*ValidationResult, error) {
+	if len(docs) == 0 {
+		return nil, fmt.Errorf("no files to validate")
+	}
+	bodies := make(map[string][]byte, len(docs))
+	for name, body := range docs {
+		bodies[strings.TrimSuffix(name, ".json")] = body
+	}
+
+	detectedVersion := documentsVersion(bodies)
+	validatedVersion := v.options.Version
+	if validatedVersion == "" {
+		validatedVersion = detectedVersion
+	}
+	if _, ok := version.GetConfig(validatedVersion); !ok {
+		return nil, fmt.Errorf("unsupported GBFS version %s", validatedVersion)
+	}
+	known := version.FeedNames(validatedVersion)
+	var unknown []string
+	for name := range bodies {
+		if !containsString(known, name) {
+			unknown = append(unknown, name+".json")
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return nil, fmt.Errorf("not files of GBFS %s: %s", validatedVersion, strings.Join(unknown, ", "))
+	}
+
+	result := &ValidationResult{
+		ResultSchemaVersion: ResultSchemaVersion,
+		ValidatedAt:         validationTime(),
+		Summary: ValidationSummary{
+			ValidatorVersion: "1.0.0",
+			Version:          VersionInfo{Detected: detectedVersion, Validated: validatedVersion},
+			LenientMode:      v.options.LenientMode,
+		},
+		Files: []FileValidationResult{},
+	}
+
+	if body, ok := bodies["gbfs"]; ok {
+		gbfsResult := &FileValidationResult{File: "gbfs.json", Required: version.IsGBFSRequired(validatedVersion)}
+		v.checkBody(gbfsResult, body, "gbfs", validatedVersion)
+		v.finishFile(gbfsResult)
+		result.Files = append(result.Files, *gbfsResult)
+	}
+
+	requirements := version.GetFileRequirements(validatedVersion, version.Options{
+		Docked:       v.options.Docked,
+		Freefloating: v.options.Freefloating,
+	})
+	fileResults := make(map[string]*FileValidationResult, len(requirements))
+	for _, req := range requirements {
+		fr := &FileValidationResult{
+			File:        req.File + ".json",
+			Required:    req.Required,
+			Recommended: req.Recommended,
+		}
+		if body, ok := bodies[req.File]; ok {
+			v.checkBody(fr, body, req.File, validatedVersion)
+		} else {
+			v.reportMissing(fr, req, "was not uploaded")
+		}
+		fileResults[req.File] = fr
+	}
+
+	v.crossValidate(fileResults, validatedVersion)
+	v.checkImages(ctx, fileResults)
+	v.checkAlertURLs(ctx, fileResults)
+	v.addFileResults(result, []string{""}, map[string]map[string]*FileValidationResult{"": fileResults})
+	result.RulePacks = v.evaluateRulePacks(fileResults, validatedVersion)
+
+	suggestFixes(result)
+	linkSpec(result)
+	summarize(result)
+	return result, nil
+}
+
+// documentsVersion reads the version field of the documents, preferring
+// gbfs.json and then system_information, like a feed set.
+func documentsVersion(bodies map[string][]byte) string {
+	names := make([]string, 0, len(bodies))
+	for name := range bodies {
+		names = append(names, name)
+	}
+	rank := map[string]int{"gbfs": 0, "system_information": 1}
+	sort.Slice(names, func(i, j int) bool {
+		ri, iok := rank[names[i]]
+		rj, jok := rank[names[j]]
+		if !iok {
+			ri = len(rank)
+		}
+		if !jok {
+			rj = len(rank)
+		}
+		if ri != rj {
+			return ri < rj
+		}
+		return names[i] < names[j]
+	})
+
+	for _, name := range names {
+		var header struct {
+			Version string `json:"version"`
+		}
+		if json.Unmarshal(bodies[name], &header) == nil && header.Version != "" {
+			return header.Version
+		}
+	}
+	return "1.0"
+}
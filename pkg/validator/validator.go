@@ -2,15 +2,14 @@
 package validator
 
 import (
-	"context"
 	"encoding/json"
 	"fmt"
 	"strings"
-	"sync"
+	"time"
 
 	"github.com/gbfs-validator-go/pkg/coerce"
-	"github.com/gbfs-validator-go/pkg/fetcher"
 	"github.com/gbfs-validator-go/pkg/gbfs"
+	"github.com/gbfs-validator-go/pkg/schema"
 	"github.com/gbfs-validator-go/pkg/version"
 )
 
@@ -23,28 +22,111 @@ const (
 	SeverityInfo    ValidationSeverity = "info"
 )
 
+// ErrorCategory groups validation issues by their origin.
+type ErrorCategory string
+
+const (
+	CategoryAvailability   ErrorCategory = "availability"
+	CategorySchema         ErrorCategory = "schema"
+	CategoryCrossReference ErrorCategory = "cross_reference"
+	CategoryFreshness      ErrorCategory = "freshness"
+	CategorySemantic       ErrorCategory = "semantic"
+	CategoryExtension      ErrorCategory = "extension"
+)
+
+// ErrorCategories lists categories in reporting order.
+func ErrorCategories() []ErrorCategory {
+	return []ErrorCategory{
+		CategoryAvailability,
+		CategorySchema,
+		CategoryCrossReference,
+		CategoryFreshness,
+		CategorySemantic,
+		CategoryExtension,
+	}
+}
+
 // ValidationError represents a single validation issue.
 type ValidationError struct {
 	Severity     ValidationSeverity `json:"severity"`
+	Category     ErrorCategory      `json:"category,omitempty"`
 	Message      string             `json:"message"`
 	InstancePath string             `json:"instancePath,omitempty"`
 	SchemaPath   string             `json:"schemaPath,omitempty"`
 	Keyword      string             `json:"keyword,omitempty"`
+	// Spec links to the spec section the finding relates to.
+	Spec         *SpecReference     `json:"spec,omitempty"`
+	// SuggestedFix is set when the finding matches a known failure pattern.
+	SuggestedFix *SuggestedFix      `json:"suggestedFix,omitempty"`
 }
 
+// FileStatus classifies the outcome for a single file.
+type FileStatus string
+
+const (
+	FileStatusValid              FileStatus = "valid"
+	FileStatusInvalid            FileStatus = "invalid"
+	FileStatusMissing            FileStatus = "missing"
+	FileStatusRecommendedMissing FileStatus = "recommended_missing"
+	FileStatusAbsent             FileStatus = "absent"
+	// FileStatusNotChecked marks a file the run's deadline passed before.
+	FileStatusNotChecked FileStatus = "not_checked"
+)
+
 // FileValidationResult holds validation results for a file.
 type FileValidationResult struct {
 	File           string            `json:"file"`
 	URL            string            `json:"url,omitempty"`
+	// RedirectedURL is the URL that answered when URL redirected.
+	RedirectedURL  string            `json:"redirectedUrl,omitempty"`
+	// RequestHeaders are the headers sent when fetching, secrets redacted.
+	RequestHeaders map[string]string `json:"requestHeaders,omitempty"`
 	Required       bool              `json:"required"`
 	Recommended    bool              `json:"recommended,omitempty"`
 	Exists         bool              `json:"exists"`
+	// FailureKind classifies why the file could not be fetched, so network
+	// problems can be told apart from feed bugs.
+	FailureKind    FailureKind       `json:"failureKind,omitempty"`
+	Language       string            `json:"language,omitempty"`
+	Overridden     bool              `json:"overridden,omitempty"`
+	Status         FileStatus        `json:"status"`
+	// HasErrors is set when the file has error findings, or warnings with
+	// Options.TreatWarningsAsErrors.
 	HasErrors      bool              `json:"hasErrors"`
+	// ErrorsCount, WarningsCount, and InfosCount count Errors by severity.
 	ErrorsCount    int               `json:"errorsCount"`
+	WarningsCount  int               `json:"warningsCount,omitempty"`
+	InfosCount     int               `json:"infosCount,omitempty"`
 	Errors         []ValidationError `json:"errors,omitempty"`
+	// Hash is the SHA-256 of the fetched body, to detect changed content.
+	Hash           string            `json:"hash,omitempty"`
 	RawData        json.RawMessage   `json:"-"`
 	CoercedData    json.RawMessage   `json:"-"`
 	CoercionCount  int               `json:"coercionCount,omitempty"`
+	Coercions      []coerce.Coercion `json:"-"`
+	Timing         *FileTiming       `json:"timing,omitempty"`
+	Extensions     []ExtensionField  `json:"extensions,omitempty"`
+	// Reused is set when the file was unchanged since Options.Previous and
+	// its schema findings were carried over instead of validated again.
+	Reused         bool              `json:"reused,omitempty"`
+	// Sampled lists the arrays Options.SampleSize cut; findings cover only
+	// the checked entries.
+	Sampled        []SampledArray    `json:"sampled,omitempty"`
+
+	content *contentFindings
+}
+
+// FileTiming records how long a file took to fetch and validate.
+type FileTiming struct {
+	FetchMs    int64 `json:"fetchMs"`
+	ValidateMs int64 `json:"validateMs"`
+}
+
+// ProgressEvent reports that a file finished fetching and validating.
+type ProgressEvent struct {
+	File  string
+	Done  int
+	Total int
 }
 
 // ValidationSummary summarizes a validation run.
@@ -52,10 +134,30 @@ type ValidationSummary struct {
 	ValidatorVersion     string           `json:"validatorVersion"`
 	Version              VersionInfo      `json:"version"`
 	HasErrors            bool             `json:"hasErrors"`
+	// ErrorsCount, WarningsCount, and InfosCount count findings by
+	// severity across all files.
 	ErrorsCount          int              `json:"errorsCount"`
+	WarningsCount        int              `json:"warningsCount"`
+	InfosCount           int              `json:"infosCount"`
 	VersionUnimplemented bool             `json:"versionUnimplemented,omitempty"`
 	LenientMode          bool             `json:"lenientMode,omitempty"`
+	// Incomplete is set when the deadline passed before every file was
+	// checked; those files have the not_checked status.
+	Incomplete           bool             `json:"incomplete,omitempty"`
+	// SystemID is the system_id of system_information, so later runs can
+	// tell when it changes.
+	SystemID             string           `json:"systemId,omitempty"`
+	// Sampled is set when Options.SampleSize cut an array of some file.
+	Sampled              bool             `json:"sampled,omitempty"`
 	CoercionSummary      *CoercionSummary `json:"coercionSummary,omitempty"`
+	Categories           map[ErrorCategory]*CategoryCount `json:"categories,omitempty"`
+}
+
+// CategoryCount tallies issues in one category by severity.
+type CategoryCount struct {
+	Errors   int `json:"errors"`
+	Warnings int `json:"warnings"`
+	Infos    int `json:"infos"`
 }
 
 // CoercionSummary summarizes applied coercions.
@@ -72,8 +174,28 @@ type VersionInfo struct {
 
 // ValidationResult is the full validation output.
 type ValidationResult struct {
+	// ResultSchemaVersion is the format version of this JSON document; see
+	// the ResultSchemaVersion constant.
+	ResultSchemaVersion int `json:"resultSchemaVersion"`
+
+	// FeedURL is the gbfs.json URL that was validated, with credentials
+	// redacted. It is empty for feed sets, uploads, and single files.
+	FeedURL string `json:"feedUrl,omitempty"`
+
+	// ValidatedAt is when validation started. Signed results carry it so a
+	// verifier can tell a fresh report from a replayed one.
+	ValidatedAt *time.Time `json:"validatedAt,omitempty"`
+
 	Summary ValidationSummary      `json:"summary"`
 	Files   []FileValidationResult `json:"files"`
+
+	// SuggestedGBFS is the gbfs.json that would describe an explicitly
+	// assembled feed set. It is only set when Options.FeedURLs is used.
+	SuggestedGBFS json.RawMessage `json:"suggestedGbfs,omitempty"`
+
+	// RulePacks reports each requested rule pack. Failing rules do not
+	// affect Summary.HasErrors.
+	RulePacks []RulePackResult `json:"rulePacks,omitempty"`
 }
 
 // Options configures validator behavior.
@@ -85,6 +207,105 @@ type Options struct {
 	LenientMode bool `json:"lenientMode"`
 	
 	CoerceOptions *CoerceOptions `json:"coerceOptions,omitempty"`
+
+	// Profile names a built-in severity profile; empty selects "default".
+	Profile string `json:"profile,omitempty"`
+
+	// MissingRecommendedSeverity overrides the profile severity for
+	// recommended files absent from autodiscovery.
+	MissingRecommendedSeverity ValidationSeverity `json:"missingRecommendedSeverity,omitempty"`
+
+	// ReferencedFileSeverity overrides, by check ID, the severity of an
+	// optional file referenced by ID but absent, e.g. system_regions when
+	// stations set region_id ("conditionalRegions").
+	ReferencedFileSeverity map[string]ValidationSeverity `json:"referencedFileSeverity,omitempty"`
+
+	// Languages selects v1/v2 language blocks to validate. Empty validates
+	// the first language only; "all" validates every block and compares them.
+	Languages []string `json:"languages,omitempty"`
+
+	// FeedURLOverrides replaces or adds feed URLs by feed name, e.g. to point
+	// station_status at a staging endpoint.
+	FeedURLOverrides map[string]string `json:"feedUrlOverrides,omitempty"`
+
+	// FeedURLs validates an explicit feed-name to URL set instead of reading
+	// gbfs.json, for feeds that do not publish autodiscovery yet.
+	FeedURLs map[string]string `json:"feedUrls,omitempty"`
+
+	// Extensions declares proprietary field namespaces to collect and
+	// optionally validate instead of reporting them as unknown fields.
+	Extensions []Extension `json:"extensions,omitempty"`
+
+	// DepotThreshold is the share of free-floating vehicles at one exact
+	// position that raises a depot warning. Zero selects 0.2; a negative
+	// value disables the check.
+	DepotThreshold float64 `json:"depotThreshold,omitempty"`
+
+	// Currencies documents the currencies a system's pricing plans may mix.
+	// When empty, plans are expected to share one currency.
+	Currencies []string `json:"currencies,omitempty"`
+
+	// CheckImages requests every image URL and warns about images that are
+	// unreachable, not images, or larger than ImageMaxBytes (default 2 MiB).
+	CheckImages   bool  `json:"checkImages,omitempty"`
+	ImageMaxBytes int64 `json:"imageMaxBytes,omitempty"`
+
+	// MinCoordinateDecimals and MaxCoordinateDecimals bound the decimal
+	// places of lat and lon. Zero selects 4 and 8; a negative value
+	// disables the bound.
+	MinCoordinateDecimals int `json:"minCoordinateDecimals,omitempty"`
+	MaxCoordinateDecimals int `json:"maxCoordinateDecimals,omitempty"`
+
+	// SizeBudgets maps file names, without .json, to the size in bytes
+	// above which a file raises a warning; "*" applies to files without an
+	// entry. Files it does not set use DefaultSizeBudgets, and a negative
+	// budget disables the check for a file.
+	SizeBudgets map[string]int64 `json:"sizeBudgets,omitempty"`
+
+	// CheckAlertURLs requests the url of every alert and warns about pages
+	// that are unreachable.
+	CheckAlertURLs bool `json:"checkAlertUrls,omitempty"`
+
+	// Catalog, when set, is checked for the system_id registered for the
+	// feed URL.
+	Catalog Catalog `json:"-"`
+
+	// CompareVersions fetches the other versions gbfs_versions.json lists
+	// and warns when their system_id, stations, or fleet size diverge.
+	CompareVersions bool `json:"compareVersions,omitempty"`
+
+	// SameOrigin warns when gbfs.json lists feeds on another origin.
+	SameOrigin bool `json:"sameOrigin,omitempty"`
+
+	// DataQuality adds warnings about data that is valid but hard to use,
+	// such as station names that are empty, shared, or internal codes.
+	DataQuality bool `json:"dataQuality,omitempty"`
+
+	// RulePacks adds regulatory requirements reported in their own section.
+	RulePacks []RulePack `json:"rulePacks,omitempty"`
+
+	// SampleSize, when positive, checks only that many entries of each
+	// large array of a file, for quick smoke checks of huge feeds: the first
+	// ones, or a random choice when SampleRandom is set. Cross-file and
+	// semantic checks still read every entry. Files cut this way list the arrays in Sampled.
+	SampleSize   int  `json:"sampleSize,omitempty"`
+	SampleRandom bool `json:"sampleRandom,omitempty"`
+
+	// Schemas overrides the embedded schema bundle, e.g. with a pinned
+	// local copy for offline use.
+	Schemas *schema.Bundle `json:"-"`
+
+	// TreatWarningsAsErrors makes warnings fail a file and the feed, as
+	// errors do.
+	TreatWarningsAsErrors bool `json:"treatWarningsAsErrors,omitempty"`
+
+	// Previous is an earlier result of the same feed. Files whose content
+	// hash has not changed since reuse its schema findings; freshness and
+	// cross-file checks still run on every file.
+	Previous *ValidationResult `json:"-"`
+
+	// OnProgress is called as each feed file completes. Calls are serialized.
+	OnProgress func(ProgressEvent) `json:"-"`
 }
 
 // CoerceOptions selects coercions for lenient mode.
@@ -113,16 +334,51 @@ func DefaultCoerceOptions() *CoerceOptions {
 
 // Validator validates GBFS feeds.
 type Validator struct {
-	fetcher *fetcher.Fetcher
-	options Options
-	coercer *coerce.Coercer
+	// network fetches feeds; it is empty in builds without net/http.
+	network
+
+	options   Options
+	profile   Profile
+	schemas   *schema.Bundle
+	overrides map[string]string
+	coercer   *coerce.Coercer
+
+	extensions []extension
+	previous   map[string]*FileValidationResult
 }
 
-// New constructs a Validator.
-func New(f *fetcher.Fetcher, opts Options) *Validator {
+// NewOffline constructs a Validator that never reaches the network. It
+// validates the documents it is given with ValidateFile and
+// ValidateDocuments; Validate returns an error.
+func NewOffline(opts Options) *Validator {
+	return newValidator(opts)
+}
+
+// newValidator constructs a Validator without a fetcher.
+func newValidator(opts Options) *Validator {
+	profile, _ := GetProfile(opts.Profile)
 	v := &Validator{
-		fetcher: f,
 		options: opts,
+		profile: profile,
+		schemas: opts.Schemas,
+
+		extensions: compileExtensions(opts.Extensions),
+		previous:   previousFiles(opts.Previous, opts.LenientMode),
+	}
+
+	if v.schemas == nil {
+		v.schemas = DefaultSchemas()
+	}
+
+	if len(opts.FeedURLOverrides) > 0 {
+		v.overrides = make(map[string]string, len(opts.FeedURLOverrides))
+		for name, url := range opts.FeedURLOverrides {
+			v.overrides[strings.TrimSuffix(name, ".json")] = url
+		}
+	}
+
+	if opts.MissingRecommendedSeverity != "" {
+		v.profile.MissingRecommendedSeverity = opts.MissingRecommendedSeverity
 	}
 	
 	if opts.LenientMode {
@@ -142,69 +398,24 @@ func New(f *fetcher.Fetcher, opts Options) *Validator {
 	return v
 }
 
-// Validate performs a full feed validation.
-func (v *Validator) Validate(ctx context.Context, gbfsURL string) (*ValidationResult, error) {
-	result := &ValidationResult{
-		Summary: ValidationSummary{
-			ValidatorVersion: "1.0.0",
-			LenientMode:      v.options.LenientMode,
-		},
-		Files: []FileValidationResult{},
-	}
-
-	gbfsResult, gbfsFeed, err := v.validateGBFS(ctx, gbfsURL)
-	if err != nil || gbfsFeed == nil {
-		if gbfsResult != nil {
-			result.Files = append(result.Files, *gbfsResult)
-		}
-		result.Summary.VersionUnimplemented = true
-		return result, nil
-	}
-
-	result.Files = append(result.Files, *gbfsResult)
-
-	detectedVersion := gbfsFeed.Version
-	if detectedVersion == "" {
-		detectedVersion = "1.0"
-	}
-	validatedVersion := v.options.Version
-	if validatedVersion == "" {
-		validatedVersion = detectedVersion
-	}
-
-	result.Summary.Version = VersionInfo{
-		Detected:  detectedVersion,
-		Validated: validatedVersion,
-	}
-
-	feedURLs := v.buildFeedURLMap(gbfsFeed, gbfsURL)
-
-	requirements := version.GetFileRequirements(validatedVersion, version.Options{
-		Docked:       v.options.Docked,
-		Freefloating: v.options.Freefloating,
-	})
-
-	fileResults := v.validateFiles(ctx, feedURLs, requirements, validatedVersion)
-
-	v.crossValidate(fileResults, validatedVersion)
-
+// addFileResults appends per-language file results in spec order and
+// tallies their coercions into the summary.
+func (v *Validator) addFileResults(result *ValidationResult, languages []string, byLanguage map[string]map[string]*FileValidationResult) {
 	totalCoercions := 0
 	coercionsByField := make(map[string]int)
-	
-	for _, fr := range fileResults {
-		result.Files = append(result.Files, *fr)
-		if fr.HasErrors {
-			result.Summary.HasErrors = true
-		}
-		result.Summary.ErrorsCount += fr.ErrorsCount
-		
-		if fr.CoercionCount > 0 {
-			totalCoercions += fr.CoercionCount
-		}
-	}
 
-	if gbfsResult.HasErrors {
-		result.Summary.HasErrors = true
+	for _, lang := range languages {
+		for _, fr := range orderedFiles(byLanguage[lang], result.Summary.Version.Validated) {
+			v.finishFile(fr)
+			result.Files = append(result.Files, *fr)
+
+			if fr.CoercionCount > 0 {
+				totalCoercions += fr.CoercionCount
+				for _, c := range fr.Coercions {
+					coercionsByField[c.Field]++
+				}
+			}
+		}
 	}
 
 	if v.options.LenientMode && totalCoercions > 0 {
@@ -213,65 +424,6 @@ func (v *Validator) Validate(ctx context.Context, gbfsURL string) (*ValidationRe
 			ByField:        coercionsByField,
 		}
 	}
-
-	return result, nil
-}
-
-// validateGBFS fetches and validates gbfs.json.
-func (v *Validator) validateGBFS(ctx context.Context, gbfsURL string) (*FileValidationResult, *gbfs.GBFSFeed, error) {
-	result := &FileValidationResult{
-		File:        "gbfs.json",
-		URL:         gbfsURL,
-		Required:    true,
-		Recommended: true,
-	}
-
-	fetchResult := v.fetcher.Fetch(ctx, gbfsURL)
-	if fetchResult.Error != nil {
-		if !strings.HasSuffix(gbfsURL, "gbfs.json") {
-			altURL := fetcher.BuildFeedURL(gbfsURL, "gbfs")
-			fetchResult = v.fetcher.Fetch(ctx, altURL)
-			if fetchResult.Error == nil {
-				result.URL = altURL
-			}
-		}
-	}
-
-	if fetchResult.Error != nil || !fetchResult.Exists {
-		result.Exists = false
-		if version.IsGBFSRequired(v.options.Version) {
-			result.HasErrors = true
-			result.ErrorsCount = 1
-			result.Errors = []ValidationError{{
-				Severity: SeverityError,
-				Message:  "gbfs.json is required but not found",
-			}}
-		}
-		return result, nil, fmt.Errorf("gbfs.json not found")
-	}
-
-	result.Exists = true
-	result.RawData = fetchResult.Body
-
-	var feed gbfs.GBFSFeed
-	if err := json.Unmarshal(fetchResult.Body, &feed); err != nil {
-		result.HasErrors = true
-		result.ErrorsCount = 1
-		result.Errors = []ValidationError{{
-			Severity: SeverityError,
-			Message:  fmt.Sprintf("Failed to parse gbfs.json: %v", err),
-		}}
-		return result, nil, err
-	}
-
-	schemaErrors := v.validateGBFSStructure(&feed)
-	if len(schemaErrors) > 0 {
-		result.HasErrors = true
-		result.Errors = schemaErrors
-		result.ErrorsCount = len(schemaErrors)
-	}
-
-	return result, &feed, nil
 }
 
 // validateGBFSStructure checks gbfs.json structure.
@@ -282,6 +434,7 @@ func (v *Validator) validateGBFSStructure(feed *gbfs.GBFSFeed) []ValidationError
 		errors = append(errors, ValidationError{
 			Severity:     SeverityError,
 			Message:      "ttl must be non-negative",
+			Keyword:      "minimum",
 			InstancePath: "/ttl",
 		})
 	}
@@ -290,6 +443,7 @@ func (v *Validator) validateGBFSStructure(feed *gbfs.GBFSFeed) []ValidationError
 		errors = append(errors, ValidationError{
 			Severity:     SeverityError,
 			Message:      "data.feeds array is required and must not be empty",
+			Keyword:      "minItems",
 			InstancePath: "/data/feeds",
 		})
 	}
@@ -299,6 +453,7 @@ func (v *Validator) validateGBFSStructure(feed *gbfs.GBFSFeed) []ValidationError
 			errors = append(errors, ValidationError{
 				Severity:     SeverityError,
 				Message:      "feed name is required",
+				Keyword:      "required",
 				InstancePath: fmt.Sprintf("/data/feeds/%d/name", i),
 			})
 		}
@@ -306,6 +461,7 @@ func (v *Validator) validateGBFSStructure(feed *gbfs.GBFSFeed) []ValidationError
 			errors = append(errors, ValidationError{
 				Severity:     SeverityError,
 				Message:      "feed url is required",
+				Keyword:      "required",
 				InstancePath: fmt.Sprintf("/data/feeds/%d/url", i),
 			})
 		}
@@ -314,98 +470,244 @@ func (v *Validator) validateGBFSStructure(feed *gbfs.GBFSFeed) []ValidationError
 	return errors
 }
 
-// buildFeedURLMap maps feed names to URLs.
-func (v *Validator) buildFeedURLMap(feed *gbfs.GBFSFeed, baseURL string) map[string]string {
+// buildFeedURLMap maps feed names to URLs for a language block.
+func (v *Validator) buildFeedURLMap(feed *gbfs.GBFSFeed, lang string) map[string]string {
 	urls := make(map[string]string)
 
-	for _, f := range feed.Data.Feeds {
+	for _, f := range feed.Data.FeedsFor(lang) {
 		urls[f.Name] = f.URL
 	}
 
+	for name, url := range v.overrides {
+		urls[name] = url
+	}
+
 	return urls
 }
 
-// validateFiles fetches and validates required files.
-func (v *Validator) validateFiles(ctx context.Context, feedURLs map[string]string, requirements []version.FileRequirement, ver string) map[string]*FileValidationResult {
-	results := make(map[string]*FileValidationResult)
-	var mu sync.Mutex
-	var wg sync.WaitGroup
-
-	for _, req := range requirements {
-		req := req
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-			
-			result := &FileValidationResult{
-				File:     req.File + ".json",
-				Required: req.Required,
-			}
+// reportMissing records that a file of the feed is absent, which is an
+// error for a required file and a finding of the profile for a
+// recommended one.
+func (v *Validator) reportMissing(result *FileValidationResult, req version.FileRequirement, reason string) {
+	result.Exists = false
+	if req.Required {
+		result.HasErrors = true
+		result.ErrorsCount = 1
+		result.Errors = []ValidationError{{
+			Severity: SeverityError,
+			Category: CategoryAvailability,
+			Message:  fmt.Sprintf("Required file %s.json %s", req.File, reason),
+			Keyword:  "required",
+		}}
+	} else if req.Recommended {
+		v.reportMissingRecommended(result)
+	}
+}
 
-			url, exists := feedURLs[req.File]
-			if !exists {
-				result.Exists = false
-				if req.Required {
-					result.HasErrors = true
-					result.ErrorsCount = 1
-					result.Errors = []ValidationError{{
-						Severity: SeverityError,
-						Message:  fmt.Sprintf("Required file %s.json not found in autodiscovery", req.File),
-					}}
-				}
-				mu.Lock()
-				results[req.File] = result
-				mu.Unlock()
-				return
-			}
+// checkBody validates the body of a file that exists, reusing the previous
+// result when the content is unchanged.
+func (v *Validator) checkBody(result *FileValidationResult, body []byte, file, ver string) {
+	result.Exists = true
+	result.RawData = body
+	result.Hash = contentHash(body)
+	if result.Timing == nil {
+		result.Timing = &FileTiming{}
+	}
 
-			result.URL = url
-
-			fetchResult := v.fetcher.Fetch(ctx, url)
-			if fetchResult.Error != nil || !fetchResult.Exists {
-				result.Exists = false
-				if req.Required {
-					result.HasErrors = true
-					result.ErrorsCount = 1
-					result.Errors = []ValidationError{{
-						Severity: SeverityError,
-						Message:  fmt.Sprintf("Required file %s.json could not be fetched: %v", req.File, fetchResult.Error),
-					}}
-				}
-				mu.Lock()
-				results[req.File] = result
-				mu.Unlock()
-				return
-			}
+	validateStart := time.Now()
+	dataToValidate, reused := v.reuseFile(result, ver)
+	if !reused {
+		dataToValidate = v.validateContent(result, body, file, ver)
+	}
+	if encoding := checkEncoding(body); len(encoding) > 0 {
+		result.Errors = append(result.Errors, encoding...)
+		result.ErrorsCount = len(result.Errors)
+	}
+	v.checkSize(result)
+	if malformed := checkTimestampEncoding(dataToValidate); malformed != nil {
+		result.Errors = append(result.Errors, *malformed)
+		result.ErrorsCount = len(result.Errors)
+	}
+	if ttl := v.checkTTL(dataToValidate, file); ttl != nil {
+		result.Errors = append(result.Errors, *ttl)
+		result.ErrorsCount = len(result.Errors)
+	}
+	result.Timing.ValidateMs = time.Since(validateStart).Milliseconds()
+}
 
-			result.Exists = true
-			result.RawData = fetchResult.Body
+// validateContent coerces, strips extensions from, and schema-validates a
+// fetched file. It returns the data later checks should read.
+func (v *Validator) validateContent(result *FileValidationResult, body []byte, file, ver string) []byte {
+	data := body
+	if v.coercer != nil {
+		coerceResult, err := v.coercer.Coerce(body, file)
+		if err == nil {
+			data = coerceResult.Data
+			result.CoercedData = coerceResult.Data
+			result.CoercionCount = len(coerceResult.Log.Coercions)
+			result.Coercions = coerceResult.Log.Coercions
+		}
+	}
 
-			dataToValidate := fetchResult.Body
-			if v.coercer != nil {
-				coerceResult, err := v.coercer.Coerce(fetchResult.Body, req.File)
-				if err == nil {
-					dataToValidate = coerceResult.Data
-					result.CoercedData = coerceResult.Data
-					result.CoercionCount = len(coerceResult.Log.Coercions)
-				}
-			}
+	data = v.sample(result, data)
+	data, extensions, extensionErrors := v.extractExtensions(data)
+	result.Extensions = extensions
 
-			schemaErrors := v.validateFileStructure(dataToValidate, req.File, ver)
-			if len(schemaErrors) > 0 {
-				result.HasErrors = true
-				result.Errors = schemaErrors
-				result.ErrorsCount = len(schemaErrors)
+	schemaErrors, ok := v.validateSchema(data, file, ver)
+	if !ok {
+		schemaErrors = withCategory(v.validateFileStructure(data, file, ver), CategorySchema)
+	}
+	schemaErrors = append(schemaErrors, extensionErrors...)
+	unsample(schemaErrors, result.Sampled)
+	if len(schemaErrors) > 0 {
+		result.HasErrors = true
+		result.Errors = schemaErrors
+		result.ErrorsCount = len(schemaErrors)
+	}
+	result.content = &contentFindings{version: ver, errors: append([]ValidationError(nil), schemaErrors...),
+		sampleSize: v.options.SampleSize, sampleRandom: v.options.SampleRandom}
+	return data
+}
+
+// checkTimestampEncoding reports a last_updated that was only readable by
+// tolerating a non-conformant encoding, such as a millisecond epoch.
+func checkTimestampEncoding(data []byte) *ValidationError {
+	var header gbfs.CommonHeader
+	if err := json.Unmarshal(data, &header); err != nil || header.LastUpdated.Conformant() {
+		return nil
+	}
+
+	return &ValidationError{
+		Severity:     SeverityWarning,
+		Category:     CategorySchema,
+		Message:      fmt.Sprintf("last_updated is a %s", header.LastUpdated.Issue),
+		InstancePath: "/last_updated",
+		Keyword:      "format",
+	}
+}
+
+// reportMissingRecommended records a recommended file absent from autodiscovery.
+func (v *Validator) reportMissingRecommended(result *FileValidationResult) {
+	severity := v.profile.MissingRecommendedSeverity
+	if severity == "" {
+		return
+	}
+
+	result.Errors = append(result.Errors, ValidationError{
+		Severity: severity,
+		Category: CategoryAvailability,
+		Message:  fmt.Sprintf("Recommended file %s not found in autodiscovery", result.File),
+		Keyword:  "recommended",
+	})
+	result.ErrorsCount = len(result.Errors)
+	if severity == SeverityError {
+		result.HasErrors = true
+	}
+}
+
+// withCategory fills in a category for issues that lack one.
+func withCategory(errs []ValidationError, category ErrorCategory) []ValidationError {
+	for i := range errs {
+		if errs[i].Category == "" {
+			errs[i].Category = category
+		}
+	}
+	return errs
+}
+
+// categorize tallies issues across files by category and severity.
+func categorize(files []FileValidationResult) map[ErrorCategory]*CategoryCount {
+	counts := make(map[ErrorCategory]*CategoryCount)
+	for _, file := range files {
+		for _, e := range file.Errors {
+			category := e.Category
+			if category == "" {
+				category = CategorySemantic
+			}
+			c, ok := counts[category]
+			if !ok {
+				c = &CategoryCount{}
+				counts[category] = c
+			}
+			switch e.Severity {
+			case SeverityError:
+				c.Errors++
+			case SeverityWarning:
+				c.Warnings++
+			default:
+				c.Infos++
 			}
+		}
+	}
+	if len(counts) == 0 {
+		return nil
+	}
+	return counts
+}
 
-			mu.Lock()
-			results[req.File] = result
-			mu.Unlock()
-		}()
+// finishFile sorts a file's findings, counts them by severity, and derives
+// HasErrors and Status from the counts. With TreatWarningsAsErrors,
+// warnings fail the file as well.
+func (v *Validator) finishFile(result *FileValidationResult) {
+	sortFindings(result.Errors)
+	result.ErrorsCount, result.WarningsCount, result.InfosCount = 0, 0, 0
+	for _, e := range result.Errors {
+		switch e.Severity {
+		case SeverityError:
+			result.ErrorsCount++
+		case SeverityWarning:
+			result.WarningsCount++
+		default:
+			result.InfosCount++
+		}
 	}
+	result.HasErrors = result.ErrorsCount > 0 || (v.options.TreatWarningsAsErrors && result.WarningsCount > 0)
+	result.Status = fileStatus(result)
+}
 
-	wg.Wait()
-	return results
+// validationTime stamps a new result, to the second in UTC.
+func validationTime() *time.Time {
+	now := time.Now().UTC().Truncate(time.Second)
+	return &now
+}
+
+// summarize totals the finished files into the summary.
+func summarize(result *ValidationResult) {
+	s := &result.Summary
+	s.HasErrors, s.ErrorsCount, s.WarningsCount, s.InfosCount = false, 0, 0, 0
+	for _, f := range result.Files {
+		s.ErrorsCount += f.ErrorsCount
+		s.WarningsCount += f.WarningsCount
+		s.InfosCount += f.InfosCount
+		s.HasErrors = s.HasErrors || f.HasErrors
+		s.Incomplete = s.Incomplete || f.Status == FileStatusNotChecked
+		s.Sampled = s.Sampled || len(f.Sampled) > 0
+	}
+	s.Categories = categorize(result.Files)
+	s.SystemID = ""
+	for i := range result.Files {
+		if result.Files[i].File == "system_information.json" && s.SystemID == "" {
+			s.SystemID = systemID(&result.Files[i])
+		}
+	}
+}
+
+// fileStatus classifies a file result after all checks have run.
+func fileStatus(result *FileValidationResult) FileStatus {
+	switch {
+	case result.Status == FileStatusNotChecked:
+		return FileStatusNotChecked
+	case !result.Exists && result.Required:
+		return FileStatusMissing
+	case !result.Exists && result.Recommended:
+		return FileStatusRecommendedMissing
+	case !result.Exists:
+		return FileStatusAbsent
+	case result.HasErrors:
+		return FileStatusInvalid
+	default:
+		return FileStatusValid
+	}
 }
 
 // validateFileStructure checks a feed file's basic structure.
@@ -417,6 +719,7 @@ func (v *Validator) validateFileStructure(data []byte, feedType, ver string) []V
 		errors = append(errors, ValidationError{
 			Severity: SeverityError,
 			Message:  fmt.Sprintf("Invalid JSON: %v", err),
+			Keyword:  "parse",
 		})
 		return errors
 	}
@@ -425,6 +728,7 @@ func (v *Validator) validateFileStructure(data []byte, feedType, ver string) []V
 		errors = append(errors, ValidationError{
 			Severity:     SeverityError,
 			Message:      "last_updated is required",
+			Keyword:      "required",
 			InstancePath: "/last_updated",
 		})
 	}
@@ -433,6 +737,7 @@ func (v *Validator) validateFileStructure(data []byte, feedType, ver string) []V
 		errors = append(errors, ValidationError{
 			Severity:     SeverityWarning,
 			Message:      "ttl is recommended",
+			Keyword:      "recommended",
 			InstancePath: "/ttl",
 		})
 	}
@@ -441,6 +746,7 @@ func (v *Validator) validateFileStructure(data []byte, feedType, ver string) []V
 		errors = append(errors, ValidationError{
 			Severity:     SeverityError,
 			Message:      "data object is required",
+			Keyword:      "required",
 			InstancePath: "/data",
 		})
 	}
@@ -474,6 +780,7 @@ func (v *Validator) validateSystemInformation(data map[string]interface{}, ver s
 		errors = append(errors, ValidationError{
 			Severity:     SeverityError,
 			Message:      "system_id is required",
+			Keyword:      "required",
 			InstancePath: "/data/system_id",
 		})
 	}
@@ -482,6 +789,7 @@ func (v *Validator) validateSystemInformation(data map[string]interface{}, ver s
 		errors = append(errors, ValidationError{
 			Severity:     SeverityError,
 			Message:      "timezone is required",
+			Keyword:      "required",
 			InstancePath: "/data/timezone",
 		})
 	}
@@ -490,6 +798,7 @@ func (v *Validator) validateSystemInformation(data map[string]interface{}, ver s
 		errors = append(errors, ValidationError{
 			Severity:     SeverityError,
 			Message:      "name is required",
+			Keyword:      "required",
 			InstancePath: "/data/name",
 		})
 	}
@@ -511,6 +820,7 @@ func (v *Validator) validateStationInformation(data map[string]interface{}, ver
 		errors = append(errors, ValidationError{
 			Severity:     SeverityError,
 			Message:      "stations array is required",
+			Keyword:      "required",
 			InstancePath: "/data/stations",
 		})
 		return errors
@@ -526,6 +836,7 @@ func (v *Validator) validateStationInformation(data map[string]interface{}, ver
 			errors = append(errors, ValidationError{
 				Severity:     SeverityError,
 				Message:      "station_id is required",
+				Keyword:      "required",
 				InstancePath: fmt.Sprintf("/data/stations/%d/station_id", i),
 			})
 		}
@@ -534,6 +845,7 @@ func (v *Validator) validateStationInformation(data map[string]interface{}, ver
 			errors = append(errors, ValidationError{
 				Severity:     SeverityError,
 				Message:      "lat is required",
+				Keyword:      "required",
 				InstancePath: fmt.Sprintf("/data/stations/%d/lat", i),
 			})
 		}
@@ -542,6 +854,7 @@ func (v *Validator) validateStationInformation(data map[string]interface{}, ver
 			errors = append(errors, ValidationError{
 				Severity:     SeverityError,
 				Message:      "lon is required",
+				Keyword:      "required",
 				InstancePath: fmt.Sprintf("/data/stations/%d/lon", i),
 			})
 		}
@@ -564,6 +877,7 @@ func (v *Validator) validateStationStatus(data map[string]interface{}, ver strin
 		errors = append(errors, ValidationError{
 			Severity:     SeverityError,
 			Message:      "stations array is required",
+			Keyword:      "required",
 			InstancePath: "/data/stations",
 		})
 		return errors
@@ -579,6 +893,7 @@ func (v *Validator) validateStationStatus(data map[string]interface{}, ver strin
 			errors = append(errors, ValidationError{
 				Severity:     SeverityError,
 				Message:      "station_id is required",
+				Keyword:      "required",
 				InstancePath: fmt.Sprintf("/data/stations/%d/station_id", i),
 			})
 		}
@@ -589,6 +904,7 @@ func (v *Validator) validateStationStatus(data map[string]interface{}, ver strin
 					errors = append(errors, ValidationError{
 						Severity:     SeverityError,
 						Message:      fmt.Sprintf("%s must be a boolean", field),
+						Keyword:      "type",
 						InstancePath: fmt.Sprintf("/data/stations/%d/%s", i, field),
 					})
 				}
@@ -617,6 +933,7 @@ func (v *Validator) validateVehicleStatus(data map[string]interface{}, ver strin
 		errors = append(errors, ValidationError{
 			Severity:     SeverityError,
 			Message:      "vehicles or bikes array is required",
+			Keyword:      "required",
 			InstancePath: "/data/vehicles",
 		})
 		return errors
@@ -639,6 +956,7 @@ func (v *Validator) validateVehicleStatus(data map[string]interface{}, ver strin
 			errors = append(errors, ValidationError{
 				Severity:     SeverityError,
 				Message:      "vehicle_id or bike_id is required",
+				Keyword:      "required",
 				InstancePath: fmt.Sprintf("/data/vehicles/%d", i),
 			})
 		}
@@ -661,6 +979,7 @@ func (v *Validator) validateVehicleTypes(data map[string]interface{}, ver string
 		errors = append(errors, ValidationError{
 			Severity:     SeverityError,
 			Message:      "vehicle_types array is required",
+			Keyword:      "required",
 			InstancePath: "/data/vehicle_types",
 		})
 		return errors
@@ -676,6 +995,7 @@ func (v *Validator) validateVehicleTypes(data map[string]interface{}, ver string
 			errors = append(errors, ValidationError{
 				Severity:     SeverityError,
 				Message:      "vehicle_type_id is required",
+				Keyword:      "required",
 				InstancePath: fmt.Sprintf("/data/vehicle_types/%d/vehicle_type_id", i),
 			})
 		}
@@ -684,6 +1004,7 @@ func (v *Validator) validateVehicleTypes(data map[string]interface{}, ver string
 			errors = append(errors, ValidationError{
 				Severity:     SeverityError,
 				Message:      "form_factor is required",
+				Keyword:      "required",
 				InstancePath: fmt.Sprintf("/data/vehicle_types/%d/form_factor", i),
 			})
 		}
@@ -692,6 +1013,7 @@ func (v *Validator) validateVehicleTypes(data map[string]interface{}, ver string
 			errors = append(errors, ValidationError{
 				Severity:     SeverityError,
 				Message:      "propulsion_type is required",
+				Keyword:      "required",
 				InstancePath: fmt.Sprintf("/data/vehicle_types/%d/propulsion_type", i),
 			})
 		}
@@ -702,6 +1024,7 @@ func (v *Validator) validateVehicleTypes(data map[string]interface{}, ver string
 					errors = append(errors, ValidationError{
 						Severity:     SeverityWarning,
 						Message:      "max_range_meters is required for motorized vehicles",
+						Keyword:      "dependentRequired",
 						InstancePath: fmt.Sprintf("/data/vehicle_types/%d/max_range_meters", i),
 					})
 				}
@@ -724,9 +1047,26 @@ func (v *Validator) crossValidate(results map[string]*FileValidationResult, ver
 
 	v.validateStationIDReferences(results, stationIDs, ver)
 
-	v.checkConditionalVehicleTypes(results, ver)
+	v.checkReferencedFiles(results, ver)
+
+	v.checkGeofencingConflicts(results)
+
+	v.checkDepotClusters(results, ver)
 
-	v.checkConditionalPricingPlans(results, ver)
+	v.checkLanguageCoverage(results, ver)
+
+	v.checkPricing(results)
+
+	v.checkRentalURIs(results)
+
+	v.checkStationStructure(results)
+
+	v.checkStationQuality(results)
+	v.checkCoordinatePrecision(results)
+	v.checkAlerts(results)
+	v.checkSystemID(results)
+
+	v.checkReturnConstraints(results, ver)
 }
 
 // extractVehicleTypes reads vehicle types from vehicle_types.json.
@@ -815,19 +1155,23 @@ func (v *Validator) validateVehicleTypeReferences(results map[string]*FileValida
 			if _, exists := vehicleTypes[vehicle.VehicleTypeID]; !exists {
 				result.Errors = append(result.Errors, ValidationError{
 					Severity:     SeverityError,
+					Category:     CategoryCrossReference,
 					InstancePath: fmt.Sprintf("/data/vehicles/%d/vehicle_type_id", i),
 					Message:      fmt.Sprintf("vehicle_type_id '%s' not found in vehicle_types.json", vehicle.VehicleTypeID),
+					Keyword:      "reference",
 				})
 				result.HasErrors = true
 				result.ErrorsCount++
 			}
 
 			vt := vehicleTypes[vehicle.VehicleTypeID]
-			if isMotorized(vt.PropulsionType) && vehicle.CurrentRangeMeters == 0 {
+			if isMotorized(vt.PropulsionType) && vehicle.CurrentRangeMeters == nil {
 				result.Errors = append(result.Errors, ValidationError{
 					Severity:     SeverityWarning,
+					Category:     CategorySemantic,
 					InstancePath: fmt.Sprintf("/data/vehicles/%d", i),
 					Message:      "current_range_meters is recommended for motorized vehicles",
+					Keyword:      "recommended",
 				})
 			}
 		}
@@ -849,8 +1193,10 @@ func (v *Validator) validatePricingPlanReferences(results map[string]*FileValida
 					if _, exists := pricingPlans[t.DefaultPricingPlanID]; !exists {
 						vtResult.Errors = append(vtResult.Errors, ValidationError{
 							Severity:     SeverityError,
+							Category:     CategoryCrossReference,
 							InstancePath: fmt.Sprintf("/data/vehicle_types/%d/default_pricing_plan_id", i),
 							Message:      fmt.Sprintf("default_pricing_plan_id '%s' not found in system_pricing_plans.json", t.DefaultPricingPlanID),
+							Keyword:      "reference",
 						})
 						vtResult.HasErrors = true
 						vtResult.ErrorsCount++
@@ -875,8 +1221,10 @@ func (v *Validator) validateStationIDReferences(results map[string]*FileValidati
 				if !stationIDs[s.StationID] {
 					ssResult.Errors = append(ssResult.Errors, ValidationError{
 						Severity:     SeverityError,
+						Category:     CategoryCrossReference,
 						InstancePath: fmt.Sprintf("/data/stations/%d/station_id", i),
 						Message:      fmt.Sprintf("station_id '%s' not found in station_information.json", s.StationID),
+						Keyword:      "reference",
 					})
 					ssResult.HasErrors = true
 					ssResult.ErrorsCount++
@@ -886,92 +1234,6 @@ func (v *Validator) validateStationIDReferences(results map[string]*FileValidati
 	}
 }
 
-// checkConditionalVehicleTypes enforces vehicle_types.json requirement.
-func (v *Validator) checkConditionalVehicleTypes(results map[string]*FileValidationResult, ver string) {
-	fileName := version.GetVehicleStatusFileName(ver)
-	vsResult, ok := results[fileName]
-	if !ok || !vsResult.Exists || vsResult.RawData == nil {
-		return
-	}
-
-	var vs gbfs.VehicleStatus
-	if err := json.Unmarshal(vsResult.RawData, &vs); err != nil {
-		return
-	}
-
-	hasVehicleTypeID := false
-	for _, vehicle := range vs.Data.GetVehicles() {
-		if vehicle.VehicleTypeID != "" {
-			hasVehicleTypeID = true
-			break
-		}
-	}
-
-	if hasVehicleTypeID {
-		vtResult, ok := results["vehicle_types"]
-		if !ok || !vtResult.Exists {
-			if vtResult == nil {
-				results["vehicle_types"] = &FileValidationResult{
-					File:     "vehicle_types.json",
-					Required: true,
-					Exists:   false,
-				}
-				vtResult = results["vehicle_types"]
-			}
-			vtResult.Required = true
-			vtResult.HasErrors = true
-			vtResult.ErrorsCount++
-			vtResult.Errors = append(vtResult.Errors, ValidationError{
-				Severity: SeverityError,
-				Message:  "vehicle_types.json is required when vehicle_type_id is used in " + fileName + ".json",
-			})
-		}
-	}
-}
-
-// checkConditionalPricingPlans enforces system_pricing_plans.json requirement.
-func (v *Validator) checkConditionalPricingPlans(results map[string]*FileValidationResult, ver string) {
-	fileName := version.GetVehicleStatusFileName(ver)
-	vsResult, ok := results[fileName]
-	if !ok || !vsResult.Exists || vsResult.RawData == nil {
-		return
-	}
-
-	var vs gbfs.VehicleStatus
-	if err := json.Unmarshal(vsResult.RawData, &vs); err != nil {
-		return
-	}
-
-	hasPricingPlanID := false
-	for _, vehicle := range vs.Data.GetVehicles() {
-		if vehicle.PricingPlanID != "" {
-			hasPricingPlanID = true
-			break
-		}
-	}
-
-	if hasPricingPlanID {
-		ppResult, ok := results["system_pricing_plans"]
-		if !ok || !ppResult.Exists {
-			if ppResult == nil {
-				results["system_pricing_plans"] = &FileValidationResult{
-					File:     "system_pricing_plans.json",
-					Required: true,
-					Exists:   false,
-				}
-				ppResult = results["system_pricing_plans"]
-			}
-			ppResult.Required = true
-			ppResult.HasErrors = true
-			ppResult.ErrorsCount++
-			ppResult.Errors = append(ppResult.Errors, ValidationError{
-				Severity: SeverityError,
-				Message:  "system_pricing_plans.json is required when pricing_plan_id is used in " + fileName + ".json",
-			})
-		}
-	}
-}
-
 // isMotorized reports whether a propulsion type is motorized.
 func isMotorized(propulsionType string) bool {
 	switch propulsionType {
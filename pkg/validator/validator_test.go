@@ -3,13 +3,18 @@ package validator
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/gbfs-validator-go/pkg/fetcher"
+	"github.com/gbfs-validator-go/pkg/gbfs"
+	"github.com/gbfs-validator-go/pkg/scaffold"
+	"github.com/gbfs-validator-go/pkg/version"
 )
 
 // mockGBFSServer returns a test server serving a valid feed.
@@ -176,11 +181,19 @@ func TestValidateValidFeed(t *testing.T) {
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
-	result, err := v.Validate(ctx, server.URL+"/gbfs.json")
+	start := time.Now().Truncate(time.Second)
+	result, err := v.Validate(ctx, server.URL+"/gbfs.json?token=secret")
 	if err != nil {
 		t.Fatalf("Validation failed: %v", err)
 	}
 
+	if want := server.URL + "/gbfs.json?token=REDACTED"; result.FeedURL != want {
+		t.Errorf("Expected feed URL %s, got %s", want, result.FeedURL)
+	}
+	if result.ValidatedAt == nil || result.ValidatedAt.Before(start) || result.ValidatedAt.After(time.Now()) {
+		t.Errorf("Expected the validation time, got %v", result.ValidatedAt)
+	}
+
 	if result.Summary.Version.Detected != "3.0" {
 		t.Errorf("Expected version 3.0, got %s", result.Summary.Version.Detected)
 	}
@@ -206,6 +219,9 @@ func TestValidateValidFeed(t *testing.T) {
 				if !file.Exists {
 					t.Errorf("Expected file %s to exist", expected)
 				}
+				if file.Hash != contentHash(file.RawData) || len(file.Hash) != 64 {
+					t.Errorf("Expected %s to carry the SHA-256 of its body, got %q", expected, file.Hash)
+				}
 				break
 			}
 		}
@@ -374,4 +390,1582 @@ func TestCrossFileValidation(t *testing.T) {
 	if !foundError {
 		t.Error("Expected error about invalid vehicle_type_id reference")
 	}
+
+	if c := result.Summary.Categories[CategoryCrossReference]; c == nil || c.Errors == 0 {
+		t.Error("Expected cross_reference category to count the reference error")
+	}
+}
+
+// TestGetProfile checks profile lookup and the rejection of unknown names.
+func TestGetProfile(t *testing.T) {
+	if p, ok := GetProfile(""); !ok || p.Name != "default" {
+		t.Errorf("GetProfile(\"\") = %s, %v", p.Name, ok)
+	}
+	if p, ok := GetProfile("Strict"); ok || p.Name != "default" {
+		t.Errorf("GetProfile(Strict) = %s, %v", p.Name, ok)
+	}
+	if err := CheckProfile("stirct"); err == nil {
+		t.Error("expected an error for an unknown profile")
+	}
+	if names := ProfileNames(); len(names) != len(Profiles) || names[0] != "default" {
+		t.Errorf("ProfileNames = %v", names)
+	}
+}
+
+// TestMissingRecommendedFile checks recommended files are reported per profile.
+func TestMissingRecommendedFile(t *testing.T) {
+	server := mockGBFSServer()
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	for _, tc := range []struct {
+		profile   string
+		severity  ValidationSeverity
+		hasErrors bool
+	}{
+		{profile: "default", severity: SeverityWarning, hasErrors: false},
+		{profile: "strict", severity: SeverityError, hasErrors: true},
+	} {
+		v := New(fetcher.New(), Options{Profile: tc.profile})
+		result, err := v.Validate(ctx, server.URL+"/gbfs.json")
+		if err != nil {
+			t.Fatalf("Validation failed: %v", err)
+		}
+
+		if result.Summary.HasErrors != tc.hasErrors {
+			t.Errorf("profile %s: expected hasErrors=%v", tc.profile, tc.hasErrors)
+		}
+
+		found := false
+		for _, file := range result.Files {
+			if file.File != "system_pricing_plans.json" {
+				continue
+			}
+			found = true
+			if file.Status != FileStatusRecommendedMissing {
+				t.Errorf("profile %s: expected status %s, got %s", tc.profile, FileStatusRecommendedMissing, file.Status)
+			}
+			if len(file.Errors) != 1 || file.Errors[0].Severity != tc.severity {
+				t.Errorf("profile %s: expected one %s issue, got %+v", tc.profile, tc.severity, file.Errors)
+			}
+		}
+		if !found {
+			t.Errorf("profile %s: system_pricing_plans.json not in results", tc.profile)
+		}
+	}
+}
+
+// TestLanguageComparison checks v2 language blocks are compared for consistency.
+func TestLanguageComparison(t *testing.T) {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/gbfs.json", func(w http.ResponseWriter, r *http.Request) {
+		baseURL := "http://" + r.Host
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"last_updated": time.Now().Unix(),
+			"ttl":          0,
+			"version":      "2.2",
+			"data": map[string]interface{}{
+				"en": map[string]interface{}{
+					"feeds": []map[string]string{
+						{"name": "station_information", "url": baseURL + "/en/station_information.json"},
+					},
+				},
+				"fr": map[string]interface{}{
+					"feeds": []map[string]string{
+						{"name": "station_information", "url": baseURL + "/fr/station_information.json"},
+					},
+				},
+			},
+		})
+	})
+
+	stations := func(ids ...string) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			var list []map[string]interface{}
+			for _, id := range ids {
+				list = append(list, map[string]interface{}{"station_id": id, "name": id, "lat": 40.0, "lon": -74.0})
+			}
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"last_updated": time.Now().Unix(),
+				"ttl":          0,
+				"version":      "2.2",
+				"data":         map[string]interface{}{"stations": list},
+			})
+		}
+	}
+	mux.HandleFunc("/en/station_information.json", stations("a", "b"))
+	mux.HandleFunc("/fr/station_information.json", stations("a"))
+
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	v := New(fetcher.New(), Options{Languages: []string{"all"}})
+	result, err := v.Validate(ctx, server.URL+"/gbfs.json")
+	if err != nil {
+		t.Fatalf("Validation failed: %v", err)
+	}
+
+	found := false
+	for _, file := range result.Files {
+		if file.File != "station_information.json" || file.Language != "fr" {
+			continue
+		}
+		for _, e := range file.Errors {
+			if e.Keyword == "languageConsistency" {
+				found = true
+			}
+		}
+	}
+	if !found {
+		t.Error("Expected languageConsistency error on fr station_information.json")
+	}
+}
+
+// TestValidateFeedSet checks explicit feed URLs are validated without gbfs.json.
+func TestValidateFeedSet(t *testing.T) {
+	server := mockGBFSServer()
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	feeds := map[string]string{}
+	for _, name := range []string{"system_information", "station_information", "station_status", "vehicle_types", "vehicle_status"} {
+		feeds[name] = server.URL + "/" + name + ".json"
+	}
+
+	v := New(fetcher.New(), Options{FeedURLs: feeds})
+	result, err := v.Validate(ctx, "")
+	if err != nil {
+		t.Fatalf("Validation failed: %v", err)
+	}
+
+	if result.Summary.Version.Detected != "3.0" {
+		t.Errorf("expected detected version 3.0, got %s", result.Summary.Version.Detected)
+	}
+	if result.Summary.HasErrors {
+		t.Errorf("expected no errors, got %+v", result.Files)
+	}
+	for _, file := range result.Files {
+		if file.File == "gbfs.json" {
+			t.Error("gbfs.json should not be validated for an explicit feed set")
+		}
+	}
+
+	var suggested struct {
+		Version string `json:"version"`
+		Data    struct {
+			Feeds []struct {
+				Name string `json:"name"`
+			} `json:"feeds"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(result.SuggestedGBFS, &suggested); err != nil {
+		t.Fatalf("invalid suggested gbfs.json: %v", err)
+	}
+	if suggested.Version != "3.0" || len(suggested.Data.Feeds) != len(feeds) {
+		t.Errorf("unexpected suggested gbfs.json: %s", result.SuggestedGBFS)
+	}
+}
+
+// TestCheckTimestampEncoding checks tolerated last_updated encodings are
+// reported and conformant ones are not.
+func TestCheckTimestampEncoding(t *testing.T) {
+	if issue := checkTimestampEncoding([]byte(`{"last_updated": 1700000000, "ttl": 0}`)); issue != nil {
+		t.Errorf("unexpected issue for POSIX seconds: %+v", issue)
+	}
+	issue := checkTimestampEncoding([]byte(`{"last_updated": 1700000000000, "ttl": 0}`))
+	if issue == nil || issue.Keyword != "format" || !strings.Contains(issue.Message, "millisecond") {
+		t.Errorf("expected a millisecond format issue, got %+v", issue)
+	}
+}
+
+// TestExtensions checks declared extension fields are collected, stripped,
+// and validated against their schemas.
+func TestExtensions(t *testing.T) {
+	v := New(fetcher.New(), Options{Extensions: []Extension{
+		{Prefix: "x_"},
+		{Prefix: "x_acme_", Schemas: map[string]json.RawMessage{
+			"x_acme_color": json.RawMessage(`{"type": "string", "pattern": "^#[0-9A-F]{6}$"}`),
+		}},
+	}})
+
+	data := []byte(`{"data": {"stations": [
+		{"station_id": "s1", "x_note": "hi", "x_acme_color": "#FF0000"},
+		{"station_id": "s2", "x_acme_color": 5}
+	]}, "x_generator": "tool"}`)
+
+	stripped, fields, issues := v.extractExtensions(data)
+	if strings.Contains(string(stripped), "x_") {
+		t.Errorf("extension fields were not stripped: %s", stripped)
+	}
+	if len(fields) != 4 {
+		t.Fatalf("expected 4 extension fields, got %+v", fields)
+	}
+	for _, f := range fields {
+		if f.Field == "x_acme_color" && f.Namespace != "x_acme_" {
+			t.Errorf("expected the longest prefix to win, got %+v", f)
+		}
+	}
+	if len(issues) != 1 || issues[0].InstancePath != "/data/stations/1/x_acme_color" || issues[0].Category != CategoryExtension {
+		t.Errorf("expected one extension issue on s2, got %+v", issues)
+	}
+
+	plain := New(fetcher.New(), Options{})
+	if out, fields, _ := plain.extractExtensions(data); string(out) != string(data) || fields != nil {
+		t.Error("expected data to pass through without declared extensions")
+	}
+}
+
+// TestGeofencingConflicts checks overlapping zones with contradictory rules
+// are reported, while adjacent zones and disjoint time windows are not.
+func TestGeofencingConflicts(t *testing.T) {
+	square := func(x0, y0, x1, y1 float64) map[string]interface{} {
+		return map[string]interface{}{
+			"type":        "Polygon",
+			"coordinates": [][][]float64{{{x0, y0}, {x1, y0}, {x1, y1}, {x0, y1}, {x0, y0}}},
+		}
+	}
+	rule := func(through bool, types ...string) map[string]interface{} {
+		r := map[string]interface{}{"ride_start_allowed": true, "ride_end_allowed": true, "ride_through_allowed": through}
+		if len(types) > 0 {
+			r["vehicle_type_ids"] = types
+		}
+		return r
+	}
+	feature := func(geometry map[string]interface{}, props map[string]interface{}) map[string]interface{} {
+		return map[string]interface{}{"type": "Feature", "geometry": geometry, "properties": props}
+	}
+	data, _ := json.Marshal(map[string]interface{}{
+		"last_updated": time.Now().Format(time.RFC3339),
+		"ttl":          0,
+		"version":      "3.0",
+		"data": map[string]interface{}{
+			"geofencing_zones": map[string]interface{}{
+				"type": "FeatureCollection",
+				"features": []interface{}{
+					feature(square(0, 0, 2, 2), map[string]interface{}{"rules": []interface{}{rule(true, "scooter")}}),
+					feature(square(1, 1, 3, 3), map[string]interface{}{"rules": []interface{}{rule(false)}}),
+					feature(square(2, 0, 4, 1), map[string]interface{}{"rules": []interface{}{rule(false)}}),
+					feature(square(0, 0, 2, 2), map[string]interface{}{"rules": []interface{}{rule(false)}, "end": "2020-01-01T00:00:00Z"}),
+					feature(square(0, 0, 2, 2), map[string]interface{}{"rules": []interface{}{rule(true)}, "start": "2021-01-01T00:00:00Z"}),
+				},
+			},
+		},
+	})
+	results := map[string]*FileValidationResult{
+		"geofencing_zones": {File: "geofencing_zones.json", Exists: true, RawData: data},
+	}
+	New(fetcher.New(), Options{}).checkGeofencingConflicts(results)
+
+	var conflicts []string
+	for _, e := range results["geofencing_zones"].Errors {
+		if e.Keyword == "geofencingConflict" {
+			conflicts = append(conflicts, e.Message)
+		}
+	}
+	// Zone 2 only shares an edge with zone 1, and zones 3 and 4 are never
+	// active at the same time.
+	want := []string{"zones 0 and 1", "zones 0 and 3", "zones 1 and 4"}
+	if len(conflicts) != len(want) {
+		t.Fatalf("expected %d conflicts, got %d: %v", len(want), len(conflicts), conflicts)
+	}
+	for i, pair := range want {
+		if !strings.Contains(conflicts[i], pair) {
+			t.Errorf("conflict %d = %s, want %s", i, conflicts[i], pair)
+		}
+	}
+	if !strings.Contains(conflicts[0], "vehicle type scooter: ride_through_allowed is true in zone 0 and false in zone 1") {
+		t.Errorf("unexpected conflict: %s", conflicts[0])
+	}
+}
+
+func TestRulePacks(t *testing.T) {
+	server := mockGBFSServer()
+	defer server.Close()
+
+	pack := RulePack{Name: "city", Rules: []Rule{
+		{ID: "geofencing", Kind: RuleFile, File: "geofencing_zones"},
+		{ID: "propulsion", Kind: RuleValues, File: "vehicle_types", Field: "propulsion_type", Values: []string{"electric_assist"}},
+		{ID: "contact", Kind: RuleField, File: "system_information", Field: "feed_contact_email"},
+		{ID: "fresh", Kind: RuleMaxTTL, File: "vehicle_status", Max: 60, Severity: SeverityWarning},
+	}}
+	if err := pack.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	v := New(fetcher.New(), Options{RulePacks: []RulePack{pack}})
+	result, err := v.Validate(context.Background(), server.URL+"/gbfs.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.RulePacks) != 1 || result.RulePacks[0].Passed {
+		t.Fatalf("expected one failing rule pack, got %+v", result.RulePacks)
+	}
+
+	passed := make(map[string]RuleResult)
+	for _, r := range result.RulePacks[0].Rules {
+		passed[r.ID] = r
+	}
+	if passed["geofencing"].Passed || !strings.Contains(passed["geofencing"].Message, "not published") {
+		t.Errorf("geofencing: %+v", passed["geofencing"])
+	}
+	if r := passed["propulsion"]; r.Passed || r.Failures != 1 || r.Examples[0] != "/data/vehicle_types/0/propulsion_type" {
+		t.Errorf("propulsion: %+v", r)
+	}
+	if !passed["contact"].Passed || !passed["fresh"].Passed {
+		t.Errorf("expected contact and fresh to pass: %+v", result.RulePacks[0].Rules)
+	}
+
+	if err := (RulePack{Name: "bad", Rules: []Rule{{ID: "x", Kind: RuleField, File: "vehicle_types"}}}).Validate(); err == nil {
+		t.Error("expected a field rule without a field to be rejected")
+	}
+}
+
+func TestDepotClusters(t *testing.T) {
+	var vehicles []map[string]interface{}
+	for i := 0; i < 20; i++ {
+		lat, lon := 45.5+float64(i)*0.001, -73.6
+		if i < 6 {
+			lat, lon = 45.4, -73.7
+		}
+		vehicles = append(vehicles, map[string]interface{}{"vehicle_id": fmt.Sprint(i), "lat": lat, "lon": lon})
+	}
+	data, _ := json.Marshal(map[string]interface{}{"data": map[string]interface{}{"vehicles": vehicles}})
+
+	for _, tc := range []struct {
+		threshold float64
+		want      int
+	}{{0, 1}, {0.5, 0}, {-1, 0}} {
+		results := map[string]*FileValidationResult{
+			"vehicle_status": {File: "vehicle_status.json", Exists: true, RawData: data},
+		}
+		New(fetcher.New(), Options{DepotThreshold: tc.threshold}).checkDepotClusters(results, "3.0")
+		errs := results["vehicle_status"].Errors
+		if len(errs) != tc.want {
+			t.Errorf("threshold %v: expected %d warnings, got %v", tc.threshold, tc.want, errs)
+			continue
+		}
+		if tc.want > 0 && (errs[0].Keyword != "depotCluster" || !strings.HasPrefix(errs[0].Message, "6 of 20 vehicles (30%)")) {
+			t.Errorf("unexpected warning: %+v", errs[0])
+		}
+	}
+}
+
+func TestLanguageCoverage(t *testing.T) {
+	results := map[string]*FileValidationResult{
+		"system_information": {File: "system_information.json", Exists: true, RawData: []byte(`{"data":{
+			"languages":["en","fr"],
+			"name":[{"text":"Bikes","language":"en"},{"text":"Vélos","language":"fr"}]}}`)},
+		"station_information": {File: "station_information.json", Exists: true, RawData: []byte(`{"data":{"stations":[
+			{"station_id":"1","name":[{"text":"Main","language":"en"},{"text":"Principale","language":"FR"}]},
+			{"station_id":"2","name":[{"text":"Park","language":"en"}]},
+			{"station_id":"3","name":[{"text":"Lake","language":"en"}]}]}}`)},
+	}
+	v := New(fetcher.New(), Options{})
+	v.checkLanguageCoverage(results, "2.3")
+	if len(results["station_information"].Errors) != 0 {
+		t.Fatal("expected no language coverage check before v3")
+	}
+
+	v.checkLanguageCoverage(results, "3.0")
+	if errs := results["system_information"].Errors; len(errs) != 0 {
+		t.Errorf("unexpected warnings for a complete file: %v", errs)
+	}
+	errs := results["station_information"].Errors
+	if len(errs) != 1 {
+		t.Fatalf("expected one warning, got %v", errs)
+	}
+	if errs[0].InstancePath != "/data/stations/1/name" || !strings.Contains(errs[0].Message, "/data/stations/*/name lacks translations listed in system_information.languages: fr in 2 of 3") {
+		t.Errorf("unexpected warning: %+v", errs[0])
+	}
+}
+
+func TestLanguageCoverageNonObject(t *testing.T) {
+	results := map[string]*FileValidationResult{
+		"system_information":  {File: "system_information.json", Exists: true, RawData: []byte(`{"data":{"languages":["en","fr"]}}`)},
+		"station_information": {File: "station_information.json", Exists: true, RawData: []byte(`null`)},
+		"vehicle_status":      {File: "vehicle_status.json", Exists: true, RawData: []byte(`[{"data":{}}]`)},
+		"system_alerts":       {File: "system_alerts.json", Exists: true, RawData: []byte(`"data"`)},
+	}
+	New(fetcher.New(), Options{}).checkLanguageCoverage(results, "3.0")
+	for name, result := range results {
+		if len(result.Errors) != 0 {
+			t.Errorf("%s: unexpected warnings %v", name, result.Errors)
+		}
+	}
+}
+
+func TestPricing(t *testing.T) {
+	plans := func() map[string]*FileValidationResult {
+		return map[string]*FileValidationResult{
+			"system_pricing_plans": {File: "system_pricing_plans.json", Exists: true, RawData: []byte(`{"data":{"plans":[
+				{"plan_id":"a","currency":"EUR","price":1.5,"per_min_pricing":[{"start":0,"rate":-0.1,"interval":1}]},
+				{"plan_id":"b","currency":"JPY","price":100.5,"reservation_price_flat_rate":-1},
+				{"plan_id":"c","currency":"CHF","price":"2.00"}]}}`)},
+		}
+	}
+
+	results := plans()
+	New(fetcher.New(), Options{}).checkPricing(results)
+	result := results["system_pricing_plans"]
+	keywords := make(map[string]string)
+	for _, e := range result.Errors {
+		keywords[e.InstancePath] = e.Keyword
+	}
+	want := map[string]string{
+		"/data/plans/0/per_min_pricing/0/rate":      "minimum",
+		"/data/plans/1/price":                       "currencyPrecision",
+		"/data/plans/1/reservation_price_flat_rate": "minimum",
+		"/data/plans":                               "currency",
+	}
+	if len(result.Errors) != len(want) {
+		t.Fatalf("expected %d findings, got %+v", len(want), result.Errors)
+	}
+	for path, keyword := range want {
+		if keywords[path] != keyword {
+			t.Errorf("expected %s at %s, got %+v", keyword, path, result.Errors)
+		}
+	}
+	if !result.HasErrors {
+		t.Error("expected a negative reservation price to be an error")
+	}
+
+	results = plans()
+	New(fetcher.New(), Options{Currencies: []string{"EUR", "JPY"}}).checkPricing(results)
+	var currency []ValidationError
+	for _, e := range results["system_pricing_plans"].Errors {
+		if e.Keyword == "currency" {
+			currency = append(currency, e)
+		}
+	}
+	if len(currency) != 1 || currency[0].InstancePath != "/data/plans/2/currency" {
+		t.Errorf("expected only the undocumented CHF plan to be flagged, got %+v", currency)
+	}
+}
+
+func TestRentalURIs(t *testing.T) {
+	results := map[string]*FileValidationResult{
+		"system_information": {File: "system_information.json", Exists: true, RawData: []byte(`{"data":{"rental_apps":{
+			"android":{"store_uri":"https://play.google.com/store/apps/details?id=com.example","discovery_uri":"com.example://"},
+			"ios":{"store_uri":"not a uri","discovery_uri":"example://"}}}}`)},
+		"vehicle_status": {File: "vehicle_status.json", Exists: true, RawData: []byte(`{"data":{"vehicles":[
+			{"vehicle_id":"1","rental_uris":{"android":"intent://rent/1#Intent;scheme=example;package=com.example;end","ios":"https://example.com/rent/1"}},
+			{"vehicle_id":"2","rental_uris":{"android":"example://rent/2"}},
+			{"vehicle_id":"3","rental_uris":{"android":"example://rent/3"}},
+			{"vehicle_id":"4","rental_uris":{"android":"intent://rent/4#Intent;end","ios":"example://rent/4"}}]}}`)},
+	}
+	New(fetcher.New(), Options{}).checkRentalURIs(results)
+
+	info := results["system_information"]
+	if len(info.Errors) != 1 || info.Errors[0].InstancePath != "/data/rental_apps/ios/store_uri" || !info.HasErrors {
+		t.Errorf("expected the iOS store URI to be rejected, got %+v", info.Errors)
+	}
+
+	var got []string
+	for _, e := range results["vehicle_status"].Errors {
+		got = append(got, e.InstancePath+" "+e.Keyword)
+	}
+	want := []string{
+		"/data/vehicles/1/rental_uris/android rentalUri",
+		"/data/vehicles/1/rental_uris rentalUriPlatform",
+		"/data/vehicles/3/rental_uris/android rentalUri",
+		"/data/vehicles/3/rental_uris/ios rentalUri",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("unexpected findings:\n%s", strings.Join(got, "\n"))
+	}
+	if errs := results["vehicle_status"].Errors; !strings.Contains(errs[0].Message, "(2 entries)") {
+		t.Errorf("expected shared problems to be counted, got %q", errs[0].Message)
+	}
+}
+
+func TestCheckImages(t *testing.T) {
+	var heads int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/icon.svg":
+			heads++
+			w.Header().Set("Content-Type", "image/svg+xml")
+		case "/page":
+			w.Header().Set("Content-Type", "text/html")
+		case "/huge.png":
+			if r.Method == http.MethodHead {
+				w.WriteHeader(http.StatusMethodNotAllowed)
+				return
+			}
+			w.Header().Set("Content-Type", "image/png")
+			w.Header().Set("Content-Length", "5000")
+			w.Write(make([]byte, 5000))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	raw := fmt.Sprintf(`{"data":{"vehicle_types":[
+		{"vehicle_type_id":"a","vehicle_image":"%[1]s/icon.svg","vehicle_assets":{"icon_url":"%[1]s/icon.svg"}},
+		{"vehicle_type_id":"b","vehicle_image":"%[1]s/page"},
+		{"vehicle_type_id":"c","vehicle_image":"%[1]s/huge.png"},
+		{"vehicle_type_id":"d","vehicle_image":"%[1]s/missing.png"}]}}`, server.URL)
+	newResults := func() map[string]*FileValidationResult {
+		return map[string]*FileValidationResult{
+			"vehicle_types": {File: "vehicle_types.json", Exists: true, RawData: []byte(raw)},
+		}
+	}
+
+	results := newResults()
+	New(fetcher.New(), Options{}).checkImages(context.Background(), results)
+	if len(results["vehicle_types"].Errors) != 0 || heads != 0 {
+		t.Fatal("expected no image requests unless enabled")
+	}
+
+	New(fetcher.New(), Options{CheckImages: true, ImageMaxBytes: 1000}).checkImages(context.Background(), results)
+	var paths []string
+	for _, e := range results["vehicle_types"].Errors {
+		paths = append(paths, e.InstancePath)
+	}
+	want := "/data/vehicle_types/1/vehicle_image /data/vehicle_types/2/vehicle_image /data/vehicle_types/3/vehicle_image"
+	if strings.Join(paths, " ") != want {
+		t.Errorf("unexpected image warnings: %+v", results["vehicle_types"].Errors)
+	}
+	if heads != 1 {
+		t.Errorf("expected the shared icon to be requested once, got %d", heads)
+	}
+}
+
+func TestStationStructure(t *testing.T) {
+	results := map[string]*FileValidationResult{
+		"station_information": {File: "station_information.json", Exists: true, RawData: []byte(`{"data":{"stations":[
+			{"station_id":"1","is_virtual_station":true,"station_area":{"type":"MultiPolygon","coordinates":[]}},
+			{"station_id":"2","is_virtual_station":true},
+			{"station_id":"3","is_virtual_station":true,"capacity":4,"vehicle_docks_capacity":[{"vehicle_type_ids":["a"],"count":4}],"parking_hoop":true,"parking_type":"street_parking"},
+			{"station_id":"4","station_area":{"type":"MultiPolygon","coordinates":[]},"parking_hoop":false},
+			{"station_id":"5","capacity":10,"parking_type":"parking_lot","parking_hoop":true}]}}`)},
+	}
+	New(fetcher.New(), Options{}).checkStationStructure(results)
+
+	var got []string
+	for _, e := range results["station_information"].Errors {
+		got = append(got, fmt.Sprintf("%s %s", e.Severity, e.InstancePath))
+	}
+	want := []string{
+		"warning /data/stations/1/is_virtual_station",
+		"warning /data/stations/3/station_area",
+		"warning /data/stations/2/vehicle_docks_capacity",
+		"warning /data/stations/2/parking_hoop",
+		"info /data/stations/3/parking_hoop",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("unexpected findings:\n%s", strings.Join(got, "\n"))
+	}
+}
+
+func TestReturnConstraints(t *testing.T) {
+	raw := []byte(`{"data":{"vehicle_types":[
+		{"vehicle_type_id":"a","return_constraint":"free_floating"},
+		{"vehicle_type_id":"b","return_constraint":"roundtrip"},
+		{"vehicle_type_id":"c","return_type":["free_floating"]}]}}`)
+	for _, tc := range []struct {
+		ver  string
+		want []string
+	}{
+		{"2.2", []string{
+			"info /data/vehicle_types/0/return_constraint",
+			"info /data/vehicle_types/1/return_constraint",
+			"warning /data/vehicle_types/2/return_type",
+		}},
+		{"3.0", []string{
+			"error /data/vehicle_types/1/return_constraint",
+			"warning /data/vehicle_types/2/return_type",
+		}},
+	} {
+		results := map[string]*FileValidationResult{
+			"vehicle_types": {File: "vehicle_types.json", Exists: true, RawData: raw},
+		}
+		New(fetcher.New(), Options{}).checkReturnConstraints(results, tc.ver)
+		var got []string
+		for _, e := range results["vehicle_types"].Errors {
+			got = append(got, fmt.Sprintf("%s %s", e.Severity, e.InstancePath))
+		}
+		if strings.Join(got, "\n") != strings.Join(tc.want, "\n") {
+			t.Errorf("%s: unexpected findings:\n%s", tc.ver, strings.Join(got, "\n"))
+		}
+	}
+}
+
+func TestUnknownFeedNames(t *testing.T) {
+	var feed gbfs.GBFSFeed
+	if err := json.Unmarshal([]byte(`{"version":"2.3","data":{"en":{"feeds":[
+		{"name":"system_information","url":"https://example.com/system_information.json"},
+		{"name":"station_informations","url":"https://example.com/station_information.json"},
+		{"name":"vehicle_status","url":"https://example.com/vehicle_status.json"},
+		{"name":"system_hours","url":"https://example.com/system_hours.json"}]}}}`), &feed); err != nil {
+		t.Fatal(err)
+	}
+	result := &FileValidationResult{File: "gbfs.json", Exists: true, Errors: []ValidationError{{
+		Severity: SeverityError, InstancePath: "/data/en/feeds/2/name", Message: "value must be one of the enum", Keyword: "enum",
+	}}}
+	New(fetcher.New(), Options{}).checkAutodiscovery(result, &feed, "https://example.com/gbfs.json", "2.3")
+
+	if len(result.Errors) != 2 {
+		t.Fatalf("expected the schema error and one warning, got %+v", result.Errors)
+	}
+	if !strings.Contains(result.Errors[0].Message, "vehicle_status is the 3.0 name of free_bike_status") {
+		t.Errorf("expected the schema error to gain a hint, got %q", result.Errors[0].Message)
+	}
+	warning := result.Errors[1]
+	if warning.InstancePath != "/data/en/feeds/1/name" || !strings.Contains(warning.Message, `(did you mean "station_information"?)`) ||
+		!strings.Contains(warning.Message, "valid names are gbfs, gbfs_versions, system_information") {
+		t.Errorf("unexpected warning: %+v", warning)
+	}
+}
+
+func TestFeedURLs(t *testing.T) {
+	var feed gbfs.GBFSFeed
+	if err := json.Unmarshal([]byte(`{"version":"3.0","data":{"feeds":[
+		{"name":"gbfs","url":"https://example.com/gbfs.json"},
+		{"name":"system_information","url":"https://example.com/system_information.json"},
+		{"name":"station_information","url":"/station_information.json"},
+		{"name":"station_status","url":"http://example.com/station_status"},
+		{"name":"vehicle_types","url":"https://cdn.example.net/types.json"},
+		{"name":"system_alerts","url":"https://example.com/system_information.json"}]}}`), &feed); err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		sameOrigin bool
+		want       []string
+	}{
+		{false, []string{
+			"/data/feeds/2/url format",
+			"/data/feeds/3/url insecureUrl",
+			"/data/feeds/4/url feedUrl",
+			"/data/feeds/5/url feedUrl",
+			"/data/feeds/5/url uniqueItems",
+		}},
+		{true, []string{
+			"/data/feeds/2/url format",
+			"/data/feeds/3/url insecureUrl",
+			"/data/feeds/3/url crossOrigin",
+			"/data/feeds/4/url feedUrl",
+			"/data/feeds/4/url crossOrigin",
+			"/data/feeds/5/url feedUrl",
+			"/data/feeds/5/url uniqueItems",
+		}},
+	} {
+		var got []string
+		for _, e := range feedURLFindings(&feed, "https://example.com/gbfs.json", "3.0", tc.sameOrigin) {
+			got = append(got, e.InstancePath+" "+e.Keyword)
+		}
+		if strings.Join(got, "\n") != strings.Join(tc.want, "\n") {
+			t.Errorf("sameOrigin=%v: unexpected findings:\n%s", tc.sameOrigin, strings.Join(got, "\n"))
+		}
+	}
+}
+
+func TestFeedURLOverrides(t *testing.T) {
+	server := mockGBFSServer()
+	defer server.Close()
+	var hits atomic.Int32
+	staging := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		w.Write([]byte(`{"last_updated":"2024-01-01T00:00:00Z","ttl":0,"version":"3.0","data":{}}`))
+	}))
+	defer staging.Close()
+
+	v := New(fetcher.New(), Options{FeedURLOverrides: map[string]string{"station_status.json": staging.URL + "/status"}})
+	result, err := v.Validate(context.Background(), server.URL+"/gbfs.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := hits.Load(); n != 1 {
+		t.Errorf("override fetched %d times, want 1", n)
+	}
+	for _, file := range result.Files {
+		switch file.File {
+		case "station_status.json":
+			if !file.Overridden || file.URL != staging.URL+"/status" || !file.HasErrors {
+				t.Errorf("station_status = overridden %v, url %s, errors %v; want the override's invalid body", file.Overridden, file.URL, file.HasErrors)
+			}
+		case "station_information.json":
+			if file.Overridden || file.URL != server.URL+"/station_information.json" {
+				t.Errorf("station_information = overridden %v, url %s", file.Overridden, file.URL)
+			}
+		}
+	}
+
+	for _, tc := range []struct {
+		name, url, want string
+	}{
+		{"station_status", "https://staging.example.com/status.json", ""},
+		{"free_bike_status.json", "https://staging.example.com/bikes.json", ""},
+		{"station_stauts", "https://staging.example.com/status.json", `did you mean "station_status"?`},
+		{"gbfs", "https://staging.example.com/gbfs.json", "is not a GBFS feed"},
+		{"station_status", "/status.json", "not an http or https URL"},
+	} {
+		err := CheckFeedURLOverride(tc.name, tc.url)
+		if tc.want == "" && err != nil || tc.want != "" && (err == nil || !strings.Contains(err.Error(), tc.want)) {
+			t.Errorf("CheckFeedURLOverride(%q, %q) = %v, want %q", tc.name, tc.url, err, tc.want)
+		}
+	}
+}
+
+func TestSelfReference(t *testing.T) {
+	feed := func(self string) *gbfs.GBFSFeed {
+		f := &gbfs.GBFSFeed{}
+		f.Data.Feeds = []gbfs.FeedInfo{{Name: "system_information", URL: "https://example.com/system_information.json"}}
+		if self != "" {
+			f.Data.Feeds = append(f.Data.Feeds, gbfs.FeedInfo{Name: "gbfs", URL: self})
+		}
+		return f
+	}
+	result := &FileValidationResult{URL: "https://example.com/gbfs.json", RedirectedURL: "https://EXAMPLE.com/v3/gbfs.json"}
+	for _, tc := range []struct {
+		self string
+		want ValidationSeverity
+	}{
+		{"https://example.com/v3/gbfs.json", ""},
+		{"https://example.com/gbfs.json", SeverityInfo},
+		{"https://other.example.com/gbfs.json", SeverityWarning},
+		{"", SeverityWarning},
+	} {
+		e := selfReference(result, feed(tc.self))
+		switch {
+		case tc.want == "" && e != nil:
+			t.Errorf("%q: unexpected finding %+v", tc.self, e)
+		case tc.want != "" && (e == nil || e.Severity != tc.want):
+			t.Errorf("%q: expected a %s, got %+v", tc.self, tc.want, e)
+		}
+	}
+}
+
+// TestDeltaValidation checks that unchanged files reuse the schema findings
+// of the previous run.
+func TestDeltaValidation(t *testing.T) {
+	updated := time.Now().Format(time.RFC3339)
+	systemInformation := `{"last_updated":"` + updated + `","ttl":0,"version":"3.0","data":{"system_id":"test","languages":["en"]}}`
+	mux := http.NewServeMux()
+	mux.HandleFunc("/gbfs.json", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"last_updated":%q,"ttl":0,"version":"3.0","data":{"feeds":[
+			{"name":"system_information","url":"http://%s/system_information.json"}]}}`, updated, r.Host)
+	})
+	mux.HandleFunc("/system_information.json", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(systemInformation))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	run := func(prev *ValidationResult) FileValidationResult {
+		result, err := New(fetcher.New(), Options{Previous: prev}).Validate(context.Background(), server.URL+"/gbfs.json")
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, f := range result.Files {
+			if f.File == "system_information.json" {
+				f.content = nil
+				return f
+			}
+		}
+		t.Fatal("system_information.json not validated")
+		return FileValidationResult{}
+	}
+	first, err := New(fetcher.New(), Options{}).Validate(context.Background(), server.URL+"/gbfs.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fresh := run(nil)
+	if fresh.Reused || !fresh.HasErrors {
+		t.Fatalf("expected a fresh validation with schema errors, got %+v", fresh)
+	}
+
+	reused := run(first)
+	if !reused.Reused {
+		t.Fatal("unchanged file was validated again")
+	}
+	if reused.ErrorsCount != fresh.ErrorsCount || !reused.HasErrors {
+		t.Errorf("reused %d findings, fresh run has %d", reused.ErrorsCount, fresh.ErrorsCount)
+	}
+
+	systemInformation = strings.Replace(systemInformation, `"test"`, `"renamed"`, 1)
+	if changed := run(first); changed.Reused {
+		t.Error("changed file reused the previous findings")
+	}
+}
+
+// TestPartialResultOnTimeout checks that files the deadline cut short are
+// reported as not checked rather than missing.
+func TestPartialResultOnTimeout(t *testing.T) {
+	unblock := make(chan struct{})
+	defer close(unblock)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/gbfs.json", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"last_updated":0,"ttl":0,"version":"3.0","data":{"feeds":[
+			{"name":"system_information","url":"http://%[1]s/system_information.json"},
+			{"name":"station_information","url":"http://%[1]s/station_information.json"},
+			{"name":"station_status","url":"http://%[1]s/station_status.json"}]}}`, r.Host)
+	})
+	mux.HandleFunc("/system_information.json", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"last_updated":0,"ttl":0,"version":"3.0","data":{}}`))
+	})
+	slow := func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-unblock:
+		case <-r.Context().Done():
+		}
+	}
+	mux.HandleFunc("/station_information.json", slow)
+	mux.HandleFunc("/station_status.json", slow)
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+	result, err := New(fetcher.New(), Options{Docked: true}).Validate(ctx, server.URL+"/gbfs.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Summary.Incomplete {
+		t.Error("expected an incomplete result")
+	}
+	for _, f := range result.Files {
+		switch f.File {
+		case "station_information.json", "station_status.json":
+			if f.Status != FileStatusNotChecked || f.HasErrors || f.FailureKind != fetcher.FailureTimeout {
+				t.Errorf("%s: status %s, hasErrors %v, failure %q", f.File, f.Status, f.HasErrors, f.FailureKind)
+			}
+		case "system_information.json":
+			if !f.Exists || f.Status == FileStatusNotChecked {
+				t.Errorf("system_information.json was not checked: %s", f.Status)
+			}
+		}
+	}
+}
+
+// TestSeverityCounts checks that HasErrors and the counts follow the
+// severities of the findings, however they were added.
+func TestSeverityCounts(t *testing.T) {
+	finding := func(severity ValidationSeverity) ValidationError {
+		return ValidationError{Severity: severity, Message: string(severity)}
+	}
+	tests := []struct {
+		name     string
+		file     FileValidationResult
+		strict   bool
+		errors   int
+		warnings int
+		infos    int
+		failed   bool
+	}{
+		{"error", FileValidationResult{Exists: true, Errors: []ValidationError{finding(SeverityError), finding(SeverityWarning)}}, false, 1, 1, 0, true},
+		// Cross-file checks append warnings without touching the counts.
+		{"appended warning", FileValidationResult{Exists: true, ErrorsCount: 0, Errors: []ValidationError{finding(SeverityWarning), finding(SeverityInfo)}}, false, 0, 1, 1, false},
+		// Fallback structure checks set HasErrors for warnings too.
+		{"stale HasErrors", FileValidationResult{Exists: true, HasErrors: true, ErrorsCount: 1, Errors: []ValidationError{finding(SeverityWarning)}}, false, 0, 1, 0, false},
+		{"strict warning", FileValidationResult{Exists: true, Errors: []ValidationError{finding(SeverityWarning)}}, true, 0, 1, 0, true},
+		{"strict info", FileValidationResult{Exists: true, Errors: []ValidationError{finding(SeverityInfo)}}, true, 0, 0, 1, false},
+	}
+	for _, tt := range tests {
+		v := New(fetcher.New(), Options{TreatWarningsAsErrors: tt.strict})
+		f := tt.file
+		v.finishFile(&f)
+		if f.ErrorsCount != tt.errors || f.WarningsCount != tt.warnings || f.InfosCount != tt.infos || f.HasErrors != tt.failed {
+			t.Errorf("%s: got %d/%d/%d hasErrors %v", tt.name, f.ErrorsCount, f.WarningsCount, f.InfosCount, f.HasErrors)
+		}
+		if want := map[bool]FileStatus{true: FileStatusInvalid, false: FileStatusValid}[tt.failed]; f.Status != want {
+			t.Errorf("%s: status %s, want %s", tt.name, f.Status, want)
+		}
+	}
+
+	// gbfs.json findings count towards the summary like any other file.
+	result := &ValidationResult{Files: []FileValidationResult{
+		{File: "gbfs.json", Exists: true, Errors: []ValidationError{finding(SeverityError)}},
+		{File: "system_information.json", Exists: true, Errors: []ValidationError{finding(SeverityWarning)}},
+	}}
+	v := New(fetcher.New(), Options{})
+	for i := range result.Files {
+		v.finishFile(&result.Files[i])
+	}
+	summarize(result)
+	if s := result.Summary; !s.HasErrors || s.ErrorsCount != 1 || s.WarningsCount != 1 {
+		t.Errorf("summary = %+v", s)
+	}
+
+	server := mockGBFSServer()
+	defer server.Close()
+	for _, strict := range []bool{false, true} {
+		result, err := New(fetcher.New(), Options{TreatWarningsAsErrors: strict}).Validate(context.Background(), server.URL+"/gbfs.json")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if s := result.Summary; s.ErrorsCount != 0 || s.WarningsCount == 0 || s.HasErrors != strict {
+			t.Errorf("strict=%v: summary = %+v", strict, s)
+		}
+	}
+}
+
+// TestDeterministicOrder checks that files follow spec order and that two
+// runs over the same feed encode identically.
+func TestWriteCSV(t *testing.T) {
+	result := &ValidationResult{Files: []FileValidationResult{
+		{File: "gbfs.json"},
+		{File: "station_information.json", Errors: []ValidationError{
+			{Severity: SeverityError, Keyword: "required", InstancePath: "/data/stations/0", Message: "missing property 'name'"},
+			{Severity: SeverityWarning, Keyword: "dataQuality", InstancePath: "/data/stations/1/name", Message: "name \"Main St, North\"\nrepeats station 0"},
+		}},
+	}}
+	var buf strings.Builder
+	if err := result.WriteCSV(&buf); err != nil {
+		t.Fatal(err)
+	}
+	want := "file,severity,code,instancePath,message\n" +
+		"station_information.json,error,required,/data/stations/0,missing property 'name'\n" +
+		"station_information.json,warning,dataQuality,/data/stations/1/name,\"name \"\"Main St, North\"\"\nrepeats station 0\"\n"
+	if buf.String() != want {
+		t.Errorf("CSV =\n%s\nwant\n%s", buf.String(), want)
+	}
+}
+
+func TestDeterministicOrder(t *testing.T) {
+	server := mockGBFSServer()
+	defer server.Close()
+
+	encode := func() (string, []string) {
+		result, err := New(fetcher.New(), Options{Docked: true, Freefloating: true}).Validate(context.Background(), server.URL+"/gbfs.json")
+		if err != nil {
+			t.Fatal(err)
+		}
+		var files []string
+		// The mock feed stamps the current second into its bodies.
+		for i := range result.Files {
+			result.Files[i].Timing, result.Files[i].Hash = nil, ""
+			files = append(files, result.Files[i].File)
+		}
+		data, err := json.Marshal(result)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(data), files
+	}
+
+	first, files := encode()
+	want := []string{"gbfs.json", "manifest.json", "gbfs_versions.json", "system_information.json", "vehicle_types.json",
+		"station_information.json", "station_status.json", "vehicle_status.json", "system_regions.json",
+		"system_pricing_plans.json", "system_alerts.json", "geofencing_zones.json"}
+	if strings.Join(files, " ") != strings.Join(want, " ") {
+		t.Errorf("files in order %v, want %v", files, want)
+	}
+	for i := 0; i < 5; i++ {
+		if again, _ := encode(); again != first {
+			t.Fatalf("run %d encoded differently:\n%s\n%s", i+2, first, again)
+		}
+	}
+}
+
+// TestUpgradeResult checks that unversioned results are recounted by
+// severity and that newer formats are refused.
+func TestUpgradeResult(t *testing.T) {
+	v1 := `{"summary":{"hasErrors":true,"errorsCount":2},"files":[
+		{"file":"gbfs.json","exists":true,"status":"invalid","hasErrors":true,"errorsCount":1,
+		 "errors":[{"severity":"error","message":"bad"}]},
+		{"file":"station_status.json","exists":true,"status":"invalid","hasErrors":true,"errorsCount":2,
+		 "errors":[{"severity":"warning","message":"ttl"},{"severity":"info","message":"note"}]}]}`
+	result, err := UpgradeResult([]byte(v1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.ResultSchemaVersion != ResultSchemaVersion {
+		t.Errorf("version = %d", result.ResultSchemaVersion)
+	}
+	if s := result.Summary; !s.HasErrors || s.ErrorsCount != 1 || s.WarningsCount != 1 || s.InfosCount != 1 {
+		t.Errorf("summary = %+v", s)
+	}
+	if f := result.Files[1]; f.HasErrors || f.Status != FileStatusValid || f.ErrorsCount != 0 {
+		t.Errorf("station_status.json = %+v", f)
+	}
+
+	current, err := json.Marshal(&ValidationResult{ResultSchemaVersion: ResultSchemaVersion, Summary: ValidationSummary{WarningsCount: 3}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if same, err := UpgradeResult(current); err != nil || same.Summary.WarningsCount != 3 {
+		t.Errorf("current result changed: %+v, %v", same, err)
+	}
+	if _, err := UpgradeResult([]byte(`{"resultSchemaVersion":99}`)); err == nil {
+		t.Error("expected an error for a newer format")
+	}
+}
+
+func TestLinkSpec(t *testing.T) {
+	result := &ValidationResult{
+		Summary: ValidationSummary{Version: VersionInfo{Validated: "2.3"}},
+		Files: []FileValidationResult{{File: "station_status.json", Errors: []ValidationError{
+			{Severity: SeverityError, InstancePath: "/data/stations/0/num_bikes_available", Keyword: "type"},
+			{Severity: SeverityWarning, InstancePath: "/last_updated"},
+			{Severity: SeverityInfo, Spec: &SpecReference{URL: "https://example.com", Section: "custom"}},
+		}}},
+	}
+	linkSpec(result)
+
+	errs := result.Files[0].Errors
+	want := []string{
+		"https://github.com/MobilityData/gbfs/blob/v2.3/gbfs.md#station_statusjson",
+		"https://github.com/MobilityData/gbfs/blob/v2.3/gbfs.md#output-format",
+		"https://example.com",
+	}
+	for i, url := range want {
+		if errs[i].Spec == nil || errs[i].Spec.URL != url {
+			t.Errorf("finding %d: spec %+v, want %s", i, errs[i].Spec, url)
+		}
+	}
+}
+
+func TestSuggestFixes(t *testing.T) {
+	result := &ValidationResult{
+		Summary: ValidationSummary{Version: VersionInfo{Validated: "3.0"}},
+		Files: []FileValidationResult{
+			{File: "system_information.json", RawData: []byte(`{"data":{"system_id":"s","languages":["fr"],"name":"Vélo"}}`),
+				Errors: []ValidationError{{Keyword: "type", Message: "must be array", InstancePath: "/data/name"}}},
+			{File: "station_status.json", RawData: []byte(`{"last_updated":1700000000000,"data":{"stations":[{"is_renting":"1","num_vehicles_available":"4"}]}}`),
+				Errors: []ValidationError{
+					{Keyword: "type", Message: "must be string", InstancePath: "/last_updated"},
+					{Keyword: "type", Message: "must be boolean", InstancePath: "/data/stations/0/is_renting"},
+					{Keyword: "type", Message: "must be integer", InstancePath: "/data/stations/0/num_vehicles_available"},
+					{Keyword: "required", Message: "must have required property 'station_id'", InstancePath: "/data/stations/0"},
+				}},
+			{File: "station_information.json", RawData: []byte(`{"data":{"stations":[{"station_id":"a"}]}}`),
+				Errors: []ValidationError{{Keyword: "required", Message: "must have required property 'name'", InstancePath: "/data/stations/0"}}},
+		},
+	}
+	suggestFixes(result)
+
+	fix := func(file, i int) string {
+		f := result.Files[file].Errors[i].SuggestedFix
+		if f == nil {
+			return "<nil>"
+		}
+		value, _ := json.Marshal(f.Value)
+		return f.Op + " " + f.Path + " " + string(value)
+	}
+	tests := []struct {
+		file, finding int
+		want          string
+	}{
+		{0, 0, `replace /data/name [{"language":"fr","text":"Vélo"}]`},
+		{1, 0, `replace /last_updated "2023-11-14T22:13:20Z"`},
+		{1, 1, `replace /data/stations/0/is_renting true`},
+		{1, 2, `replace /data/stations/0/num_vehicles_available 4`},
+		{1, 3, `<nil>`},
+		{2, 0, `add /data/stations/0/name [{"language":"fr","text":""}]`},
+	}
+	for _, tt := range tests {
+		if got := fix(tt.file, tt.finding); got != tt.want {
+			t.Errorf("file %d finding %d: got %s, want %s", tt.file, tt.finding, got, tt.want)
+		}
+	}
+
+	// Before v3.0, millisecond timestamps become whole seconds.
+	v2 := suggestFix(map[string]interface{}{"last_updated": 1700000000000.0},
+		ValidationError{Keyword: "format", InstancePath: "/last_updated"}, "2.3", "")
+	if v2 == nil || v2.Value != int64(1700000000) {
+		t.Errorf("v2 timestamp fix = %+v", v2)
+	}
+}
+
+func TestPlan(t *testing.T) {
+	plan := Plan("https://example.com/gbfs.json", Options{Freefloating: true, Profile: "strict", CheckImages: true})
+	if plan.Version != "3.0" || !plan.VersionAssumed {
+		t.Errorf("version %s, assumed %v", plan.Version, plan.VersionAssumed)
+	}
+	if len(plan.Files) == 0 || plan.Files[0].File != "gbfs" || !plan.Files[0].Required {
+		t.Errorf("files = %+v", plan.Files)
+	}
+
+	byID := make(map[string]PlannedCheck)
+	for _, c := range plan.Checks {
+		byID[c.ID] = c
+	}
+	if c := byID["missingRecommended"]; c.Severity != SeverityError || c.Skipped != "" {
+		t.Errorf("strict profile: missingRecommended = %+v", c)
+	}
+	if c := byID["images"]; c.Skipped != "" {
+		t.Errorf("images skipped: %s", c.Skipped)
+	}
+	if c := byID["languageConsistency"]; c.Skipped == "" {
+		t.Error("languageConsistency should not apply to v3.0")
+	}
+	if c := byID["systemIdFormat"]; c.Severity != SeverityWarning || c.Skipped != "" {
+		t.Errorf("systemIdFormat = %+v", c)
+	}
+	if c := byID["systemIdChanged"]; c.Severity != SeverityError || c.Skipped == "" {
+		t.Errorf("systemIdChanged should need a previous result: %+v", c)
+	}
+	if c := byID["depotClusters"]; len(c.Files) != 1 || c.Files[0] != "vehicle_status" {
+		t.Errorf("depotClusters files = %v", c.Files)
+	}
+
+	// Explicit feed URLs skip gbfs.json and its checks.
+	plan = Plan("", Options{Version: "2.3", FeedURLs: map[string]string{"system_information": "https://example.com/si.json"}})
+	for _, f := range plan.Files {
+		if f.File == "gbfs" {
+			t.Error("feed URL plan includes gbfs.json")
+		}
+	}
+	for _, c := range plan.Checks {
+		if c.ID == "feedUrls" && c.Skipped == "" {
+			t.Error("feedUrls should be skipped without gbfs.json")
+		}
+	}
+}
+
+func TestValidateFile(t *testing.T) {
+	v := New(fetcher.New(), Options{})
+	body := []byte(`{"last_updated":1700000000,"ttl":0,"version":"2.3","data":{"stations":[
+		{"station_id":"a","num_bikes_available":1,"num_docks_available":2,"is_installed":true,"is_renting":"1","is_returning":true,"last_reported":1700000000}]}}`)
+	result, err := v.ValidateFile("station_status.json", "", body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Summary.Version.Validated != "2.3" || len(result.Files) != 1 {
+		t.Fatalf("summary %+v, %d files", result.Summary, len(result.Files))
+	}
+	var fix *SuggestedFix
+	for _, e := range result.Files[0].Errors {
+		if e.InstancePath == "/data/stations/0/is_renting" {
+			fix = e.SuggestedFix
+		}
+	}
+	if !result.Summary.HasErrors || fix == nil || fix.Value != true {
+		t.Errorf("is_renting: hasErrors %v, fix %+v", result.Summary.HasErrors, fix)
+	}
+
+	if _, err := v.ValidateFile("vehicle_status", "2.3", body); err == nil {
+		t.Error("vehicle_status is not a 2.3 file")
+	}
+	if _, err := v.ValidateFile("station_status", "", []byte(`{}`)); err == nil {
+		t.Error("expected an error without a version")
+	}
+}
+
+func TestValidateDocuments(t *testing.T) {
+	docs, err := scaffold.Skeleton("2.3", "https://example.com", version.Options{Docked: true}, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	v := New(fetcher.New(), Options{Docked: true})
+	result, err := v.ValidateDocuments(context.Background(), docs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Summary.Version.Validated != "2.3" || result.Summary.HasErrors {
+		t.Fatalf("summary %+v", result.Summary)
+	}
+	if result.Files[0].File != "gbfs.json" || !result.Files[0].Exists {
+		t.Errorf("first file %+v", result.Files[0])
+	}
+
+	delete(docs, "station_status.json")
+	docs["station_information.json"] = []byte(strings.Replace(string(docs["station_information.json"]), `"lat"`, `"latitude"`, 1))
+	result, err = v.ValidateDocuments(context.Background(), docs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	failed := map[string]bool{}
+	for _, f := range result.Files {
+		if f.HasErrors {
+			failed[f.File] = true
+		}
+	}
+	if !failed["station_status.json"] || !failed["station_information.json"] {
+		t.Errorf("failed files = %v", failed)
+	}
+
+	if _, err := v.ValidateDocuments(context.Background(), map[string][]byte{"stations.json": docs["gbfs.json"]}); err == nil {
+		t.Error("expected an error for an unknown file")
+	}
+}
+
+func TestNewOffline(t *testing.T) {
+	docs, err := scaffold.Skeleton("3.0", "https://example.com", version.Options{Freefloating: true}, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	v := NewOffline(Options{Freefloating: true, CheckImages: true})
+	result, err := v.ValidateDocuments(context.Background(), docs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Summary.Version.Validated != "3.0" || result.Summary.HasErrors {
+		t.Errorf("summary %+v", result.Summary)
+	}
+	if _, err := v.Validate(context.Background(), "https://example.com/gbfs.json"); err == nil {
+		t.Error("an offline validator fetched a feed")
+	}
+}
+
+func TestSampleSize(t *testing.T) {
+	var stations []string
+	for i := 0; i < 10; i++ {
+		stations = append(stations, fmt.Sprintf(`{"station_id":"s%d","name":"S%d","lon":2.35,"capacity":5}`, i, i))
+	}
+	body := []byte(`{"last_updated":1700000000,"ttl":0,"version":"2.3","data":{"stations":[` + strings.Join(stations, ",") + `]}}`)
+
+	paths := func(opts Options) []string {
+		result, err := NewOffline(opts).ValidateFile("station_information", "", body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if opts.SampleSize > 0 {
+			f := result.Files[0]
+			if !result.Summary.Sampled || len(f.Sampled) != 1 || f.Sampled[0].Path != "/data/stations" || f.Sampled[0].Total != 10 || f.Sampled[0].Checked != 4 {
+				t.Errorf("sampled = %+v", f.Sampled)
+			}
+		}
+		var out []string
+		for _, e := range result.Files[0].Errors {
+			if e.Keyword == "required" {
+				out = append(out, e.InstancePath)
+			}
+		}
+		return out
+	}
+
+	if got := paths(Options{}); len(got) != 10 {
+		t.Fatalf("full run reported %d stations", len(got))
+	}
+	if got := strings.Join(paths(Options{SampleSize: 4}), ","); got != "/data/stations/0,/data/stations/1,/data/stations/2,/data/stations/3" {
+		t.Errorf("first entries: %s", got)
+	}
+	random := paths(Options{SampleSize: 4, SampleRandom: true})
+	if len(random) != 4 || strings.Join(random, ",") != strings.Join(paths(Options{SampleSize: 4, SampleRandom: true}), ",") {
+		t.Errorf("random entries: %v", random)
+	}
+}
+
+func TestReferencedFileSeverity(t *testing.T) {
+	docs, err := scaffold.Skeleton("2.3", "https://example.com", version.Options{Docked: true}, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	docs["station_information.json"] = []byte(strings.Replace(string(docs["station_information.json"]), `"station_id"`, `"region_id":"north","station_id"`, 1))
+
+	regions := func(opts Options) (*FileValidationResult, bool) {
+		opts.Docked = true
+		result, err := NewOffline(opts).ValidateDocuments(context.Background(), docs)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := range result.Files {
+			if result.Files[i].File == "system_regions.json" {
+				return &result.Files[i], result.Summary.HasErrors
+			}
+		}
+		t.Fatal("no system_regions.json result")
+		return nil, false
+	}
+
+	f, hasErrors := regions(Options{})
+	if hasErrors || f.WarningsCount != 1 || f.Errors[0].Keyword != "dependentRequired" || f.Status == FileStatusMissing {
+		t.Errorf("default: hasErrors %v, %+v", hasErrors, f)
+	}
+	f, hasErrors = regions(Options{ReferencedFileSeverity: map[string]ValidationSeverity{"conditionalRegions": SeverityError}})
+	if !hasErrors || f.ErrorsCount != 1 || f.Status != FileStatusMissing {
+		t.Errorf("error: hasErrors %v, %+v", hasErrors, f)
+	}
+	f, _ = regions(Options{ReferencedFileSeverity: map[string]ValidationSeverity{"conditionalRegions": SeverityInfo}})
+	if f.InfosCount != 1 || f.WarningsCount != 0 {
+		t.Errorf("info: %+v", f)
+	}
+}
+
+func TestStationQuality(t *testing.T) {
+	results := map[string]*FileValidationResult{
+		"station_information": {File: "station_information.json", Exists: true, RawData: []byte(`{"data":{"stations":[
+			{"station_id":"1","name":"Main St"},
+			{"station_id":"2","name":""},
+			{"station_id":"3","name":"0412","post_code":"75001"},
+			{"station_id":"4","name":"main st","address":"1 Main St","post_code":"75001"},
+			{"station_id":"5","name":"Main St "},
+			{"station_id":"6","name":"0413","post_code":"75002","address":" "}]}}`)},
+	}
+	NewOffline(Options{}).checkStationQuality(results)
+	if n := len(results["station_information"].Errors); n != 0 {
+		t.Fatalf("reported %d findings without DataQuality", n)
+	}
+	NewOffline(Options{DataQuality: true}).checkStationQuality(results)
+
+	var got []string
+	for _, e := range results["station_information"].Errors {
+		got = append(got, fmt.Sprintf("%s %s %s", e.Severity, e.InstancePath, e.Message))
+	}
+	want := []string{
+		"warning /data/stations/1/name station name is empty; riders cannot tell the station apart",
+		"warning /data/stations/2/name station name is only digits and looks like an internal code; use the name riders see on site (2 stations)",
+		`warning /data/stations/0/name 3 stations are named "Main St"; add a distinguishing detail such as the street`,
+		"warning /data/stations/2/address post_code is set but address is missing; publish the street address riders need to find the station (2 stations)",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("unexpected findings:\n%s", strings.Join(got, "\n"))
+	}
+}
+
+func TestCheckEncoding(t *testing.T) {
+	body := []byte("{\"data\":{\"stations\":[" +
+		"{\"station_id\":\"1\",\"name\":\"Gare \xe9st\"}," +
+		"{\"station_id\":\"2\",\"name\":\"Caf\\ud83d\",\"address\":\"ok \\ud83d\\ude00 \\\\ud800\"}," +
+		"{\"station_id\":\"3\",\"name\":\"Line\\u0007feed\",\"rental_uris\":{\"a/b\":\"Ã©cole\"}}," +
+		"{\"station_id\":\"4\",\"name\":\"CafÃ©\\n\",\"address\":\"Château, São Paulo\"}]}}")
+
+	var got []string
+	for _, e := range checkEncoding(body) {
+		got = append(got, fmt.Sprintf("%s %s %s", e.Severity, e.InstancePath, e.Message))
+	}
+	want := []string{
+		"error /data/stations/0/name string contains bytes that are not valid UTF-8",
+		"error /data/stations/1/name string contains an unpaired UTF-16 surrogate escape",
+		"warning /data/stations/2/name string contains control characters",
+		"warning /data/stations/2/rental_uris/a~1b string looks like UTF-8 text decoded as Latin-1 or Windows-1252 (mojibake) (2 strings)",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("unexpected findings:\n%s", strings.Join(got, "\n"))
+	}
+	if errs := checkEncoding([]byte(`{"name":"Zürich Hauptbahnhof – Süd"}`)); len(errs) != 0 {
+		t.Errorf("reported clean text: %v", errs)
+	}
+}
+
+func TestCoordinatePrecision(t *testing.T) {
+	results := map[string]*FileValidationResult{
+		"station_information": {File: "station_information.json", Exists: true, RawData: []byte(`{"data":{"stations":[
+			{"station_id":"1","lat":48.85,"lon":2.3522},
+			{"station_id":"2","lat":48.85,"lon":2.35},
+			{"station_id":"3","lat":"48.8","lon":"2.3"},
+			{"station_id":"4","lat":0,"lon":0},
+			{"station_id":"5","lat":48.856613451,"lon":2.352222}]}}`)},
+	}
+	NewOffline(Options{}).checkCoordinatePrecision(results)
+
+	var got []string
+	for _, e := range results["station_information"].Errors {
+		got = append(got, fmt.Sprintf("%s %s %s", e.Severity, e.InstancePath, e.Message))
+	}
+	want := []string{
+		"warning /data/stations/1/lat coordinate has fewer than 4 decimal places, too coarse to find a vehicle or station (4 coordinates)",
+		"warning /data/stations/4/lat coordinate has more than 8 decimal places; the extra digits are false precision that enlarges the payload",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("unexpected findings:\n%s", strings.Join(got, "\n"))
+	}
+
+	results["station_information"].Errors = nil
+	NewOffline(Options{MinCoordinateDecimals: -1, MaxCoordinateDecimals: 9}).checkCoordinatePrecision(results)
+	if n := len(results["station_information"].Errors); n != 0 {
+		t.Errorf("reported %d findings with relaxed bounds", n)
+	}
+}
+
+func TestSizeBudget(t *testing.T) {
+	body := []byte(`{"data":{"stations":[` + strings.Repeat(`{"station_id":"1","num_bikes_available":3},`, 40) + `{}]}}`)
+	check := func(opts Options, file string) []ValidationError {
+		fr := &FileValidationResult{File: file + ".json", RawData: body}
+		NewOffline(opts).checkSize(fr)
+		return fr.Errors
+	}
+
+	if errs := check(Options{}, "station_status"); len(errs) != 0 {
+		t.Errorf("default budget: got %v", errs)
+	}
+	errs := check(Options{SizeBudgets: map[string]int64{"station_status": 1 << 10}}, "station_status")
+	if len(errs) != 1 || errs[0].Keyword != "size" ||
+		!strings.HasPrefix(errs[0].Message, "station_status.json is 1.7 KiB, over its 1.0 KiB budget; gzipped it is ") {
+		t.Fatalf("unexpected findings: %+v", errs)
+	}
+	if errs := check(Options{SizeBudgets: map[string]int64{"*": 1 << 10}}, "station_information"); len(errs) != 1 {
+		t.Errorf("wildcard budget: got %d findings", len(errs))
+	}
+	if errs := check(Options{SizeBudgets: map[string]int64{"*": 1 << 10, "gbfs": -1}}, "gbfs"); len(errs) != 0 {
+		t.Errorf("disabled budget: got %v", errs)
+	}
+}
+
+func TestTTLRange(t *testing.T) {
+	tests := []struct {
+		profile, file, body string
+		want                string
+	}{
+		{"", "station_status", `{"ttl":0}`, ""},
+		{"", "station_status", `{"ttl":3600}`, "ttl is 3600s, longer than the 300s recommended for station_status.json; clients may show outdated data"},
+		{"relaxed", "station_status", `{"ttl":3600}`, ""},
+		{"", "system_information", `{"ttl":0}`, "ttl is 0 but system_information.json changes rarely; set at least 60s so clients can cache it"},
+		{"strict", "station_information", `{"ttl":120}`, "ttl is 120s, shorter than the 300s recommended for station_information.json, which changes rarely"},
+		{"", "system_information", `{}`, ""},
+		{"", "gbfs", `{"ttl":0}`, ""},
+	}
+	for _, tt := range tests {
+		got := ""
+		if e := NewOffline(Options{Profile: tt.profile}).checkTTL([]byte(tt.body), tt.file); e != nil {
+			got = e.Message
+		}
+		if got != tt.want {
+			t.Errorf("%s %s %s: got %q, want %q", tt.profile, tt.file, tt.body, got, tt.want)
+		}
+	}
+}
+
+func TestCheckAlerts(t *testing.T) {
+	results := map[string]*FileValidationResult{
+		"system_alerts": {File: "system_alerts.json", Exists: true, RawData: []byte(`{"data":{"alerts":[
+			{"alert_id":"1","type":"STATION_CLOSURE","summary":"Service alert","times":[{"start":1700000000}]},
+			{"alert_id":"2","type":"station_closure","summary":"service alert ","station_ids":["s1"]},
+			{"alert_id":"3","type":"OTHER","summary":"Service alert"}]}}`)},
+	}
+	NewOffline(Options{}).checkAlerts(results)
+
+	var got []string
+	for _, e := range results["system_alerts"].Errors {
+		got = append(got, fmt.Sprintf("%s %s", e.InstancePath, e.Message))
+	}
+	want := []string{
+		"/data/alerts/1 alert has no times; publish when it starts, and ends if known, so apps can show it only while it applies (2 alerts)",
+		"/data/alerts/0/type station closure lists no station_ids or region_ids; riders cannot tell which stations are closed",
+		`/data/alerts/0/summary all 3 alerts have the summary "Service alert"; summarize what each alert is about`,
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("unexpected findings:\n%s", strings.Join(got, "\n"))
+	}
+}
+
+func TestCheckAlertURLs(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/works" {
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	raw := fmt.Sprintf(`{"data":{"alerts":[
+		{"alert_id":"1","url":"%[1]s/works"},
+		{"alert_id":"2","url":[{"text":"%[1]s/works","language":"en"},{"text":"%[1]s/gone","language":"fr"}]}]}}`, server.URL)
+	results := map[string]*FileValidationResult{
+		"system_alerts": {File: "system_alerts.json", Exists: true, RawData: []byte(raw)},
+	}
+	New(fetcher.New(), Options{}).checkAlertURLs(context.Background(), results)
+	if n := len(results["system_alerts"].Errors); n != 0 {
+		t.Fatalf("reported %d findings without CheckAlertURLs", n)
+	}
+
+	New(fetcher.New(), Options{CheckAlertURLs: true}).checkAlertURLs(context.Background(), results)
+	errs := results["system_alerts"].Errors
+	if len(errs) != 1 || errs[0].InstancePath != "/data/alerts/1/url/1/text" || errs[0].Keyword != "alertUrl" ||
+		!strings.Contains(errs[0].Message, "404") {
+		t.Errorf("unexpected findings: %+v", errs)
+	}
+}
+
+func TestVersionConsistency(t *testing.T) {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/2.3/gbfs.json":
+			fmt.Fprintf(w, `{"data":{"en":{"feeds":[
+				{"name":"system_information","url":"%[1]s/2.3/system_information.json"},
+				{"name":"station_information","url":"%[1]s/2.3/station_information.json"},
+				{"name":"free_bike_status","url":"%[1]s/2.3/free_bike_status.json"}]}}}`, server.URL)
+		case "/2.3/system_information.json":
+			fmt.Fprint(w, `{"data":{"system_id":"legacy"}}`)
+		case "/2.3/station_information.json":
+			fmt.Fprint(w, `{"data":{"stations":[{"station_id":"s1"},{"station_id":"s3"}]}}`)
+		case "/2.3/free_bike_status.json":
+			fmt.Fprint(w, `{"data":{"bikes":[{"bike_id":"b1"},{"bike_id":"b2"},{"bike_id":"b3"}]}}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	results := map[string]*FileValidationResult{
+		"gbfs_versions": {File: "gbfs_versions.json", Exists: true, RawData: []byte(fmt.Sprintf(`{"data":{"versions":[
+			{"version":"2.3","url":"%[1]s/2.3/gbfs.json"},
+			{"version":"3.0","url":"%[1]s/3.0/gbfs.json"},
+			{"version":"3.1-RC","url":"%[1]s/missing/gbfs.json"}]}}`, server.URL))},
+		"system_information":  {Exists: true, RawData: []byte(`{"data":{"system_id":"system"}}`)},
+		"station_information": {Exists: true, RawData: []byte(`{"data":{"stations":[{"station_id":"s1"},{"station_id":"s2"}]}}`)},
+		"vehicle_status":      {Exists: true, RawData: []byte(`{"data":{"vehicles":[{"vehicle_id":"v1"},{"vehicle_id":"v2","station_id":"s1"}]}}`)},
+	}
+	New(fetcher.New(), Options{}).checkVersionConsistency(context.Background(), results, "3.0", "", server.URL+"/3.0/gbfs.json")
+	if n := len(results["gbfs_versions"].Errors); n != 0 {
+		t.Fatalf("reported %d findings without CompareVersions", n)
+	}
+	New(fetcher.New(), Options{CompareVersions: true}).checkVersionConsistency(context.Background(), results, "3.0", "", server.URL+"/3.0/gbfs.json")
+
+	var got []string
+	for _, e := range results["gbfs_versions"].Errors {
+		got = append(got, fmt.Sprintf("%s %s", e.InstancePath, e.Message))
+	}
+	want := []string{
+		`/data/versions/0 system_id is "system" in version 3.0 but "legacy" in version 2.3`,
+		`/data/versions/0 version 3.0 lists 2 stations and version 2.3 lists 2; 2 station_ids appear in only one, e.g. "s2"`,
+		"/data/versions/0 version 3.0 reports a fleet of 1 vehicles but version 2.3 reports 3",
+		fmt.Sprintf("/data/versions/2 could not compare with version 3.1-RC: %s/missing/gbfs.json was not found", server.URL),
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("unexpected findings:\n%s", strings.Join(got, "\n"))
+	}
+}
+
+// catalogFunc adapts a function to Catalog.
+type catalogFunc func(string) (string, bool)
+
+func (f catalogFunc) SystemID(url string) (string, bool) { return f(url) }
+
+func TestSystemID(t *testing.T) {
+	newResults := func(id string) map[string]*FileValidationResult {
+		return map[string]*FileValidationResult{
+			"system_information": {File: "system_information.json", Exists: true,
+				RawData: []byte(fmt.Sprintf(`{"data":{"system_id":%q}}`, id))},
+		}
+	}
+	messages := func(results map[string]*FileValidationResult) []string {
+		var got []string
+		for _, e := range results["system_information"].Errors {
+			got = append(got, fmt.Sprintf("%s %s", e.Severity, e.Message))
+		}
+		return got
+	}
+
+	results := newResults("bikes_pdx")
+	NewOffline(Options{}).checkSystemID(results)
+	if got := messages(results); len(got) != 0 {
+		t.Errorf("reported a valid system_id: %v", got)
+	}
+
+	previous := &ValidationResult{Summary: ValidationSummary{SystemID: "bikes_pdx"}}
+	results = newResults("Bikes PDX")
+	NewOffline(Options{Previous: previous}).checkSystemID(results)
+	want := []string{
+		`warning system_id "Bikes PDX" has characters other than letters, digits, '.', '-' and '_'; consumers use it in URLs and file names`,
+		`error system_id changed from "bikes_pdx" to "Bikes PDX" since the previous run; it must stay the same for the life of the system`,
+	}
+	if got := messages(results); strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("unexpected findings:\n%s", strings.Join(got, "\n"))
+	}
+
+	catalog := catalogFunc(func(url string) (string, bool) { return "bikes_pdx", url == "https://pdx.example/gbfs.json" })
+	for url, n := range map[string]int{"https://pdx.example/gbfs.json": 1, "https://other.example/gbfs.json": 0} {
+		results = newResults("pdx")
+		NewOffline(Options{Catalog: catalog}).checkCatalog(results, url)
+		if got := messages(results); len(got) != n {
+			t.Errorf("%s: got %v", url, got)
+		}
+	}
+
+	result := &ValidationResult{Files: []FileValidationResult{*newResults("bikes_pdx")["system_information"]}}
+	summarize(result)
+	if result.Summary.SystemID != "bikes_pdx" {
+		t.Errorf("Summary.SystemID = %q", result.Summary.SystemID)
+	}
 }
@@ -0,0 +1,78 @@
+package validator
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/gbfs-validator-go/pkg/gbfs"
+	"github.com/gbfs-validator-go/pkg/version"
+)
+
+// checkReturnConstraints checks return_constraint against the validated
+// version. return_constraint was added in v2.3; return_type, a draft name
+// some producers still publish, is not part of any release.
+func (v *Validator) checkReturnConstraints(results map[string]*FileValidationResult, ver string) {
+	result, ok := results["vehicle_types"]
+	if !ok || !result.Exists || result.RawData == nil {
+		return
+	}
+	data := result.RawData
+	if result.CoercedData != nil {
+		data = result.CoercedData
+	}
+	var doc struct {
+		Data struct {
+			VehicleTypes []map[string]interface{} `json:"vehicle_types"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return
+	}
+
+	reported := make(map[string]bool, len(result.Errors))
+	for _, e := range result.Errors {
+		reported[e.InstancePath] = true
+	}
+	supported := version.AtLeast(ver, "2.3")
+	add := func(severity ValidationSeverity, path, keyword, message string) {
+		result.Errors = append(result.Errors, ValidationError{
+			Severity:     severity,
+			Category:     CategorySemantic,
+			InstancePath: path,
+			Message:      message,
+			Keyword:      keyword,
+		})
+		result.ErrorsCount = len(result.Errors)
+		if severity == SeverityError {
+			result.HasErrors = true
+		}
+	}
+
+	for i, vt := range doc.Data.VehicleTypes {
+		base := fmt.Sprintf("/data/vehicle_types/%d", i)
+		if _, ok := vt["return_type"]; ok {
+			message := "return_type is not a GBFS field; use return_constraint"
+			if !supported {
+				message += fmt.Sprintf(", which requires v2.3 (feed is %s)", ver)
+			}
+			add(SeverityWarning, base+"/return_type", "deprecated", message)
+		}
+
+		value, ok := vt["return_constraint"]
+		if !ok {
+			continue
+		}
+		path := base + "/return_constraint"
+		if !supported {
+			add(SeverityInfo, path, "version",
+				fmt.Sprintf("return_constraint was added in v2.3; %s consumers ignore it", ver))
+			continue
+		}
+		s, _ := value.(string)
+		if !gbfs.IsReturnConstraint(s) && !reported[path] {
+			add(SeverityError, path, "enum",
+				fmt.Sprintf("return_constraint %q must be one of %s", s, strings.Join(gbfs.ReturnConstraints, ", ")))
+		}
+	}
+}
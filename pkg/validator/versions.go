@@ -0,0 +1,194 @@
+//go:build !(js && wasm)
+
+package validator
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/gbfs-validator-go/pkg/fetcher"
+	"github.com/gbfs-validator-go/pkg/gbfs"
+	"github.com/gbfs-validator-go/pkg/version"
+)
+
+// fleetTolerance is how far, as a share of the larger fleet, the fleets of
+// two versions may differ: the files of each version are fetched moments
+// apart, and vehicles are rented in between.
+const fleetTolerance = 0.05
+
+// versionSnapshot is what is compared between the versions of a feed.
+type versionSnapshot struct {
+	systemID string
+	// stations holds the station IDs; nil when station_information is
+	// not published.
+	stations map[string]bool
+	// fleet counts vehicles at stations and vehicles away from a station,
+	// or -1 when neither status file is published.
+	fleet int
+}
+
+// snapshot measures a feed from its files, read by name without .json.
+func snapshot(ver string, file func(name string) []byte) versionSnapshot {
+	s := versionSnapshot{fleet: -1}
+	var info gbfs.SystemInformation
+	if json.Unmarshal(file("system_information"), &info) == nil {
+		s.systemID = info.Data.SystemID
+	}
+	var stations gbfs.StationInformation
+	if json.Unmarshal(file("station_information"), &stations) == nil {
+		s.stations = make(map[string]bool, len(stations.Data.Stations))
+		for _, station := range stations.Data.Stations {
+			s.stations[station.StationID] = true
+		}
+	}
+	var status gbfs.StationStatus
+	if json.Unmarshal(file("station_status"), &status) == nil {
+		s.fleet = 0
+		for _, station := range status.Data.Stations {
+			s.fleet += station.Available()
+		}
+	}
+	var vehicles gbfs.VehicleStatus
+	if json.Unmarshal(file(version.GetVehicleStatusFileName(ver)), &vehicles) == nil {
+		s.fleet = max(s.fleet, 0)
+		for _, vehicle := range vehicles.Data.GetVehicles() {
+			if vehicle.StationID == "" {
+				s.fleet++
+			}
+		}
+	}
+	return s
+}
+
+// checkVersionConsistency compares, when Options.CompareVersions is set, the
+// feed with every other version gbfs_versions.json lists: feeds migrating
+// between versions should publish one system, so system_id, the station
+// IDs, and the fleet size must agree. Divergences are reported on
+// gbfs_versions.json.
+func (v *Validator) checkVersionConsistency(ctx context.Context, results map[string]*FileValidationResult, ver, lang, gbfsURL string) {
+	if !v.options.CompareVersions || v.fetcher == nil || ctx.Err() != nil {
+		return
+	}
+	result, ok := results["gbfs_versions"]
+	if !ok || !result.Exists || result.RawData == nil {
+		return
+	}
+	var versions gbfs.GBFSVersions
+	if err := json.Unmarshal(result.RawData, &versions); err != nil {
+		return
+	}
+
+	report := func(i int, message string) {
+		result.Errors = append(result.Errors, ValidationError{
+			Severity:     SeverityWarning,
+			Category:     CategoryCrossReference,
+			InstancePath: fmt.Sprintf("/data/versions/%d", i),
+			Message:      message,
+			Keyword:      "versionConsistency",
+		})
+		result.ErrorsCount = len(result.Errors)
+	}
+
+	current := snapshot(ver, func(name string) []byte {
+		if r, ok := results[name]; ok && r.Exists {
+			return r.RawData
+		}
+		return nil
+	})
+	for i, other := range versions.Data.Versions {
+		if other.Version == ver || other.URL == "" || other.URL == gbfsURL {
+			continue
+		}
+		files, err := v.fetchVersion(ctx, other, lang)
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			report(i, fmt.Sprintf("could not compare with version %s: %v", other.Version, err))
+			continue
+		}
+		theirs := snapshot(other.Version, func(name string) []byte { return files[name] })
+
+		if current.systemID != "" && theirs.systemID != "" && current.systemID != theirs.systemID {
+			report(i, fmt.Sprintf("system_id is %q in version %s but %q in version %s", current.systemID, ver, theirs.systemID, other.Version))
+		}
+		if current.stations != nil && theirs.stations != nil {
+			if only := stationsInOne(current.stations, theirs.stations); len(only) > 0 {
+				report(i, fmt.Sprintf("version %s lists %d stations and version %s lists %d; %d station_ids appear in only one, e.g. %q",
+					ver, len(current.stations), other.Version, len(theirs.stations), len(only), only[0]))
+			}
+		}
+		if current.fleet >= 0 && theirs.fleet >= 0 {
+			diff := current.fleet - theirs.fleet
+			if float64(max(diff, -diff)) > fleetTolerance*float64(max(current.fleet, theirs.fleet)) {
+				report(i, fmt.Sprintf("version %s reports a fleet of %d vehicles but version %s reports %d", ver, current.fleet, other.Version, theirs.fleet))
+			}
+		}
+	}
+}
+
+// fetchVersion fetches the files compared by checkVersionConsistency from
+// another version of the feed, in language lang when it has one.
+func (v *Validator) fetchVersion(ctx context.Context, other gbfs.VersionInfo, lang string) (map[string][]byte, error) {
+	fetched, err := v.fetchBody(ctx, other.URL)
+	if err != nil {
+		return nil, err
+	}
+	var feed gbfs.GBFSFeed
+	if err := json.Unmarshal(fetched, &feed); err != nil {
+		return nil, fmt.Errorf("%s: %w", fetcher.RedactURL(other.URL), err)
+	}
+	if codes := feed.Data.LanguageCodes(); len(codes) > 0 {
+		if _, ok := feed.Data.Languages[lang]; !ok {
+			lang = codes[0]
+		}
+	} else {
+		lang = ""
+	}
+
+	wanted := map[string]bool{"system_information": true, "station_information": true, "station_status": true,
+		version.GetVehicleStatusFileName(other.Version): true}
+	files := make(map[string][]byte)
+	for _, f := range feed.Data.FeedsFor(lang) {
+		if !wanted[f.Name] {
+			continue
+		}
+		body, err := v.fetchBody(ctx, f.URL)
+		if err != nil {
+			return nil, err
+		}
+		files[f.Name] = body
+	}
+	return files, nil
+}
+
+// fetchBody fetches url, treating a missing file as an error.
+func (v *Validator) fetchBody(ctx context.Context, url string) ([]byte, error) {
+	fetched := v.fetcher.Fetch(ctx, url)
+	switch {
+	case fetched.Error != nil:
+		return nil, fmt.Errorf("%s: %w", fetcher.RedactURL(url), fetched.Error)
+	case !fetched.Exists:
+		return nil, fmt.Errorf("%s was not found", fetcher.RedactURL(url))
+	}
+	return fetched.Body, nil
+}
+
+// stationsInOne returns the station IDs in only one of a and b, sorted.
+func stationsInOne(a, b map[string]bool) []string {
+	var only []string
+	for id := range a {
+		if !b[id] {
+			only = append(only, id)
+		}
+	}
+	for id := range b {
+		if !a[id] {
+			only = append(only, id)
+		}
+	}
+	sort.Strings(only)
+	return only
+}
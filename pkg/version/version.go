@@ -1,10 +1,16 @@
 // Package version defines GBFS version-specific requirements.
 package version
 
+import (
+	"strconv"
+	"strings"
+)
+
 // FileRequirement declares a feed file and its requirement status.
 type FileRequirement struct {
-	File     string
-	Required bool
+	File        string
+	Required    bool
+	Recommended bool // Reported when absent even though not required
 }
 
 // Options selects docked/free-floating requirements.
@@ -82,7 +88,7 @@ var Configs = map[string]Config{
 			return []FileRequirement{
 				{File: "gbfs_versions", Required: false},
 				{File: "system_information", Required: true},
-				{File: "vehicle_types", Required: false}, // Conditionally required
+				{File: "vehicle_types", Required: false, Recommended: true}, // Conditionally required
 				{File: "station_information", Required: opts.Docked},
 				{File: "station_status", Required: opts.Docked},
 				{File: "free_bike_status", Required: opts.Freefloating},
@@ -102,7 +108,7 @@ var Configs = map[string]Config{
 			return []FileRequirement{
 				{File: "gbfs_versions", Required: false},
 				{File: "system_information", Required: true},
-				{File: "vehicle_types", Required: false}, // Conditionally required
+				{File: "vehicle_types", Required: false, Recommended: true}, // Conditionally required
 				{File: "station_information", Required: opts.Docked},
 				{File: "station_status", Required: opts.Docked},
 				{File: "free_bike_status", Required: opts.Freefloating},
@@ -122,7 +128,7 @@ var Configs = map[string]Config{
 			return []FileRequirement{
 				{File: "gbfs_versions", Required: false},
 				{File: "system_information", Required: true},
-				{File: "vehicle_types", Required: false}, // Conditionally required
+				{File: "vehicle_types", Required: false, Recommended: true}, // Conditionally required
 				{File: "station_information", Required: opts.Docked},
 				{File: "station_status", Required: opts.Docked},
 				{File: "free_bike_status", Required: opts.Freefloating},
@@ -143,12 +149,12 @@ var Configs = map[string]Config{
 				{File: "manifest", Required: false},
 				{File: "gbfs_versions", Required: false},
 				{File: "system_information", Required: true},
-				{File: "vehicle_types", Required: false},
+				{File: "vehicle_types", Required: false, Recommended: true},
 				{File: "station_information", Required: opts.Docked},
 				{File: "station_status", Required: opts.Docked},
 				{File: "vehicle_status", Required: opts.Freefloating}, // Renamed from free_bike_status
 				{File: "system_regions", Required: false},
-				{File: "system_pricing_plans", Required: false},
+				{File: "system_pricing_plans", Required: false, Recommended: true},
 				{File: "system_alerts", Required: false},
 				{File: "geofencing_zones", Required: false},
 			}
@@ -162,13 +168,13 @@ var Configs = map[string]Config{
 				{File: "manifest", Required: false},
 				{File: "gbfs_versions", Required: false},
 				{File: "system_information", Required: true},
-				{File: "vehicle_types", Required: false},
+				{File: "vehicle_types", Required: false, Recommended: true},
 				{File: "station_information", Required: opts.Docked},
 				{File: "station_status", Required: opts.Docked},
 				{File: "vehicle_status", Required: opts.Freefloating},
 				{File: "vehicle_availability", Required: false},
 				{File: "system_regions", Required: false},
-				{File: "system_pricing_plans", Required: false},
+				{File: "system_pricing_plans", Required: false, Recommended: true},
 				{File: "system_alerts", Required: false},
 				{File: "geofencing_zones", Required: false},
 			}
@@ -191,6 +197,16 @@ func GetFileRequirements(version string, opts Options) []FileRequirement {
 	return cfg.Files(opts)
 }
 
+// FeedNames lists the feed names gbfs.json may advertise in a version,
+// including gbfs itself.
+func FeedNames(version string) []string {
+	names := []string{"gbfs"}
+	for _, req := range GetFileRequirements(version, Options{Docked: true, Freefloating: true}) {
+		names = append(names, req.File)
+	}
+	return names
+}
+
 // IsGBFSRequired reports whether gbfs.json is required.
 func IsGBFSRequired(version string) bool {
 	cfg, ok := Configs[version]
@@ -207,12 +223,24 @@ func SupportedVersions() []string {
 
 // IsV3OrLater reports whether a version uses v3+ file layouts.
 func IsV3OrLater(version string) bool {
-	switch version {
-	case "3.0", "3.1-RC2", "3.1":
-		return true
-	default:
-		return false
+	major, _, _ := strings.Cut(version, ".")
+	n, err := strconv.Atoi(major)
+	return err == nil && n >= 3
+}
+
+// AtLeast reports whether version is min or later. Pre-release suffixes
+// such as "-RC2" are ignored.
+func AtLeast(version, min string) bool {
+	parse := func(s string) (int, int) {
+		s, _, _ = strings.Cut(s, "-")
+		major, minor, _ := strings.Cut(s, ".")
+		a, _ := strconv.Atoi(major)
+		b, _ := strconv.Atoi(minor)
+		return a, b
 	}
+	major, minor := parse(version)
+	minMajor, minMinor := parse(min)
+	return major > minMajor || (major == minMajor && minor >= minMinor)
 }
 
 // GetVehicleStatusFileName returns the vehicle status filename for a version.
@@ -16,6 +16,17 @@ export async function fetchValidatorSummary(url) {
   });
 }
 
+// validateUpload validates dropped feed files, or a zip of them, without
+// the feed being hosted.
+export async function validateUpload(files, options = {}) {
+  const body = new FormData();
+  for (const file of files) {
+    body.append('files', file, file.name);
+  }
+  body.append('options', JSON.stringify(options));
+  return fetchJSON('/api/validate-upload', { method: 'POST', body });
+}
+
 // fetchFeedProxy loads raw feed data via the proxy endpoint.
 export async function fetchFeedProxy(url) {
   return fetchJSON(`/api/proxy?url=${encodeURIComponent(url)}`);
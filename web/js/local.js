@@ -0,0 +1,37 @@
+// Client-side validation with the WebAssembly build of the validator
+// (cmd/wasm). Feed files are validated in the browser and never uploaded.
+
+let ready = null;
+
+// loadValidator loads wasm_exec.js and validator.wasm once.
+function loadValidator() {
+  if (!ready) {
+    ready = new Promise((resolve, reject) => {
+      const script = document.createElement('script');
+      script.src = 'js/wasm_exec.js';
+      script.onload = resolve;
+      script.onerror = () => reject(new Error('wasm_exec.js is not available'));
+      document.head.appendChild(script);
+    }).then(async () => {
+      const go = new Go();
+      const { instance } = await WebAssembly.instantiateStreaming(fetch('validator.wasm'), go.importObject);
+      go.run(instance);
+    });
+  }
+  return ready;
+}
+
+// validateLocally validates dropped feed files in the browser. Zip files are
+// not supported here; use validateUpload from api.js for those.
+export async function validateLocally(files, options = {}) {
+  await loadValidator();
+  const docs = {};
+  for (const file of files) {
+    docs[file.name] = await file.text();
+  }
+  const result = JSON.parse(globalThis.gbfsValidate(docs, JSON.stringify(options)));
+  if (result && result.error) {
+    throw new Error(result.error);
+  }
+  return result;
+}